@@ -1,9 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +19,28 @@ var behaviorRegexCache = struct {
 	cache: make(map[string]*regexp.Regexp),
 }
 
+// compileBehaviorPatterns compiles every regex behavior into the cache, so
+// prompts are matched without compiling at read time. Returns an error
+// naming the first pattern that does not compile.
+func compileBehaviorPatterns(behaviors []Behavior) error {
+	behaviorRegexCache.Lock()
+	defer behaviorRegexCache.Unlock()
+	for _, b := range behaviors {
+		if b.Type != "regex" {
+			continue
+		}
+		if _, ok := behaviorRegexCache.cache[b.Match]; ok {
+			continue
+		}
+		re, err := regexp.Compile(b.Match)
+		if err != nil {
+			return fmt.Errorf("behavior pattern %q: %w", b.Match, err)
+		}
+		behaviorRegexCache.cache[b.Match] = re
+	}
+	return nil
+}
+
 // matchBehavior finds the first behavior that matches the prompt.
 // Returns the matching behavior or the default behavior if no match.
 func (s *Simulator) matchBehavior(prompt string) *Behavior {
@@ -94,6 +118,13 @@ func (s *Simulator) executeBehavior(b *Behavior, prompt string) error {
 		time.Sleep(delay)
 	}
 
+	// Tool events come before the final action; delay_then_crash emits its
+	// own, timed against the crash
+	if action.Type != ActionDelayCrash {
+		s.emitToolEvents(action.Events)
+	}
+	s.logWorkFields(action.Log)
+
 	switch action.Type {
 	case ActionComplete:
 		return s.actionComplete(action)
@@ -107,6 +138,12 @@ func (s *Simulator) executeBehavior(b *Behavior, prompt string) error {
 		return s.actionHang()
 	case ActionCrash:
 		return s.actionCrash(action)
+	case ActionExit:
+		return s.actionExit(action)
+	case ActionDelayCrash:
+		return s.actionDelayCrash(action)
+	case ActionError:
+		return s.actionError(action)
 	default:
 		// Unknown action type, default to complete
 		s.logger.Warn("unknown action type, defaulting to complete", "type", action.Type)
@@ -114,11 +151,26 @@ func (s *Simulator) executeBehavior(b *Behavior, prompt string) error {
 	}
 }
 
+// logWorkFields writes the action's log fields as one structured log line,
+// as agents that report their work in JSON logs do.
+func (s *Simulator) logWorkFields(fields map[string]any) {
+	if len(fields) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]any, 0, 2*len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, k, fields[k])
+	}
+	s.logger.Info("work summary", attrs...)
+}
+
 // actionComplete signals successful completion via IPC.
 func (s *Simulator) actionComplete(action Action) error {
-	// Emit tool events if configured
-	s.emitToolEvents(action.Events)
-
 	// Print work output (simulating Claude's output)
 	fmt.Println("Task completed successfully.")
 
@@ -129,7 +181,10 @@ func (s *Simulator) actionComplete(action Action) error {
 	}
 
 	if err := s.ipc.StepDone(outputs); err != nil {
+		// Like Claude ending its turn after a failed command: back at the
+		// prompt, where the orchestrator can re-prompt with the errors
 		s.logger.Error("meow done failed", "error", err)
+		s.transitionTo(StateIdle)
 		return err
 	}
 
@@ -193,6 +248,25 @@ func (s *Simulator) actionFail(action Action) error {
 	return nil
 }
 
+// actionError reports the step as failed via IPC, like an agent calling
+// meow done --error.
+func (s *Simulator) actionError(action Action) error {
+	message := action.FailMessage
+	if message == "" {
+		message = "An error occurred"
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+	if err := s.ipc.StepFailed(message); err != nil {
+		s.logger.Error("meow done --error failed", "error", err)
+		s.transitionTo(StateIdle)
+		return err
+	}
+
+	s.transitionTo(StateIdle)
+	return nil
+}
+
 // actionFailThenSucceed fails N times, then succeeds.
 func (s *Simulator) actionFailThenSucceed(b *Behavior, action Action) error {
 	// Track attempts per pattern
@@ -258,6 +332,40 @@ func (s *Simulator) actionCrash(action Action) error {
 	return nil // Unreachable
 }
 
+// actionDelayCrash starts working on the prompt, emitting the action's tool
+// events, then crashes once CrashAfter has elapsed without signaling
+// completion, like an agent dying partway through a task.
+func (s *Simulator) actionDelayCrash(action Action) error {
+	start := time.Now()
+	fmt.Println("Working on it...")
+	s.emitToolEvents(action.Events)
+
+	if remaining := time.Until(start.Add(action.CrashAfter)); remaining > 0 {
+		time.Sleep(remaining)
+	}
+	return s.actionCrash(action)
+}
+
+// actionExit exits the process with the action's exit code, including 0,
+// without signaling completion (for agents that report through their exit
+// status). Outputs are written to the result file first, if one is set.
+func (s *Simulator) actionExit(action Action) error {
+	if action.ResultFile != "" {
+		data, err := json.Marshal(action.Outputs)
+		if err != nil {
+			return fmt.Errorf("encoding result: %w", err)
+		}
+		if err := os.WriteFile(action.ResultFile, data, 0644); err != nil {
+			return fmt.Errorf("writing result file: %w", err)
+		}
+	}
+
+	s.logger.Info("exiting", "exit_code", action.ExitCode)
+	os.Exit(action.ExitCode)
+
+	return nil // Unreachable
+}
+
 // emitToolEvents emits tool events according to their timing.
 // NOTE: Events should be listed in chronological order by "when" field.
 // Events are emitted sequentially without sorting.
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -17,6 +18,9 @@ func LoadConfig(path string) (SimConfig, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return SimConfig{}, err
 	}
+	if err := compileBehaviorPatterns(config.Behaviors); err != nil {
+		return SimConfig{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
 
 	return config, nil
 }
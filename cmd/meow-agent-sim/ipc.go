@@ -114,7 +114,24 @@ func (c *IPCClient) StepDone(outputs map[string]any) error {
 		"step":     c.stepID,
 		"outputs":  outputs,
 	}
+	return c.sendStepDone(msg)
+}
+
+// StepFailed reports to the orchestrator that the step failed, as
+// `meow done --error` does.
+func (c *IPCClient) StepFailed(message string) error {
+	msg := map[string]any{
+		"type":     "step_done",
+		"workflow": c.workflowID,
+		"agent":    c.agentID,
+		"step":     c.stepID,
+		"error":    message,
+	}
+	return c.sendStepDone(msg)
+}
 
+// sendStepDone sends a step_done message and checks the response.
+func (c *IPCClient) sendStepDone(msg map[string]any) error {
 	resp, err := c.sendAndReceive(msg)
 	if err != nil {
 		return err
@@ -1,18 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
 // mockIPCClient implements IPCClientInterface for testing.
 type mockIPCClient struct {
-	stepDoneCalls []map[string]any
-	eventCalls    []mockEvent
-	stepDoneError error
+	stepDoneCalls   []map[string]any
+	stepFailedCalls []string
+	eventCalls      []mockEvent
+	stepDoneError   error
 }
 
 type mockEvent struct {
@@ -32,6 +37,11 @@ func (m *mockIPCClient) StepDone(outputs map[string]any) error {
 	return m.stepDoneError
 }
 
+func (m *mockIPCClient) StepFailed(message string) error {
+	m.stepFailedCalls = append(m.stepFailedCalls, message)
+	return m.stepDoneError
+}
+
 func (m *mockIPCClient) Event(eventType string, data map[string]any) error {
 	m.eventCalls = append(m.eventCalls, mockEvent{eventType: eventType, data: data})
 	return nil
@@ -52,6 +62,7 @@ func newTestSimulator(config SimConfig) (*Simulator, *mockIPCClient) {
 		ipc:            mock,
 		attemptCounts:  make(map[string]int),
 		sequenceCounts: make(map[string]int),
+		swallowCounts:  make(map[string]int),
 	}
 	return sim, mock
 }
@@ -147,6 +158,30 @@ timing:
 	}
 }
 
+func TestLoadConfig_InvalidRegex(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `
+behaviors:
+  - match: "implement .* feature"
+    type: regex
+    action:
+      type: complete
+  - match: "[invalid(regex"
+    type: regex
+    action:
+      type: complete
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	_, err := LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "[invalid(regex") {
+		t.Fatalf("LoadConfig error = %v, want the invalid pattern named", err)
+	}
+}
+
 func TestLoadConfig_DefaultValues(t *testing.T) {
 	// Minimal config - should use defaults
 	content := `
@@ -585,6 +620,36 @@ func TestActionComplete(t *testing.T) {
 	}
 }
 
+func TestActionComplete_RejectedReturnsToIdle(t *testing.T) {
+	config := SimConfig{
+		Default: DefaultConfig{
+			Behavior: Behavior{
+				Action: Action{Type: ActionComplete},
+			},
+		},
+	}
+
+	sim, mock := newTestSimulator(config)
+	sim.state = StateIdle
+	mock.stepDoneError = errors.New("output validation failed")
+
+	if err := sim.handleInput("do the task"); err == nil {
+		t.Fatal("handleInput succeeded, want the meow done error")
+	}
+
+	// Back at the prompt, a corrected retry can be handled
+	if sim.state != StateIdle {
+		t.Errorf("state after rejected meow done = %v, want %v", sim.state, StateIdle)
+	}
+	mock.stepDoneError = nil
+	if err := sim.handleInput("do the task again"); err != nil {
+		t.Fatalf("retry handleInput failed: %v", err)
+	}
+	if len(mock.stepDoneCalls) != 2 {
+		t.Errorf("StepDone called %d times, want 2", len(mock.stepDoneCalls))
+	}
+}
+
 func TestActionAsk(t *testing.T) {
 	config := SimConfig{
 		Timing: TimingConfig{
@@ -667,6 +732,43 @@ func TestActionFail(t *testing.T) {
 	}
 }
 
+func TestActionError(t *testing.T) {
+	config := SimConfig{
+		Behaviors: []Behavior{
+			{
+				Match: "broken task",
+				Type:  "contains",
+				Action: Action{
+					Type:        ActionError,
+					FailMessage: "tests do not compile",
+				},
+			},
+		},
+		Default: DefaultConfig{
+			Behavior: Behavior{
+				Action: Action{Type: ActionComplete},
+			},
+		},
+	}
+
+	sim, mock := newTestSimulator(config)
+	sim.state = StateIdle
+
+	if err := sim.handleInput("do the broken task"); err != nil {
+		t.Fatalf("handleInput failed: %v", err)
+	}
+
+	if sim.state != StateIdle {
+		t.Errorf("State = %v, want %v", sim.state, StateIdle)
+	}
+	if len(mock.stepDoneCalls) != 0 {
+		t.Errorf("StepDone called %d times, want 0", len(mock.stepDoneCalls))
+	}
+	if len(mock.stepFailedCalls) != 1 || mock.stepFailedCalls[0] != "tests do not compile" {
+		t.Errorf("StepFailed calls = %q, want one with the fail message", mock.stepFailedCalls)
+	}
+}
+
 func TestActionFailThenSucceed(t *testing.T) {
 	config := SimConfig{
 		Timing: TimingConfig{
@@ -937,6 +1039,152 @@ func TestToolEvents_NotEmittedWhenDisabled(t *testing.T) {
 	}
 }
 
+func TestToolEvents_EmittedBeforeFailure(t *testing.T) {
+	config := SimConfig{
+		Hooks: HooksConfig{
+			FireToolEvents: true,
+		},
+		Behaviors: []Behavior{
+			{
+				Match: "flaky",
+				Type:  "contains",
+				Action: Action{
+					Type: ActionFail,
+					Events: []EventDef{
+						{Type: "edit file", When: 10 * time.Millisecond},
+						{Type: "run tests", When: 20 * time.Millisecond},
+					},
+				},
+			},
+		},
+	}
+
+	sim, mock := newTestSimulator(config)
+	sim.state = StateIdle
+
+	start := time.Now()
+	if err := sim.handleInput("flaky task"); err != nil {
+		t.Fatalf("handleInput failed: %v", err)
+	}
+
+	if len(mock.eventCalls) != 2 {
+		t.Fatalf("Event called %d times, want 2", len(mock.eventCalls))
+	}
+	if mock.eventCalls[0].eventType != "edit file" || mock.eventCalls[1].eventType != "run tests" {
+		t.Errorf("events = %+v, want edit file then run tests", mock.eventCalls)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("events emitted after %v, want their 20ms spacing honored", elapsed)
+	}
+	if len(mock.stepDoneCalls) != 0 {
+		t.Errorf("StepDone called %d times, want 0 for a failing action", len(mock.stepDoneCalls))
+	}
+}
+
+func TestLogFields_LoggedAsJSONLine(t *testing.T) {
+	config := SimConfig{
+		Behaviors: []Behavior{
+			{
+				Match: "implement",
+				Type:  "contains",
+				Action: Action{
+					Type: ActionComplete,
+					Log:  map[string]any{"files_changed": 3, "summary": "added parser"},
+				},
+			},
+		},
+	}
+
+	sim, mock := newTestSimulator(config)
+	var buf bytes.Buffer
+	sim.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	sim.state = StateIdle
+
+	if err := sim.handleInput("implement the parser"); err != nil {
+		t.Fatalf("handleInput failed: %v", err)
+	}
+
+	var entry map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err == nil && e["msg"] == "work summary" {
+			entry = e
+		}
+	}
+	if entry == nil {
+		t.Fatalf("no work summary log line in:\n%s", buf.String())
+	}
+	if entry["files_changed"] != float64(3) || entry["summary"] != "added parser" {
+		t.Errorf("work summary = %v, want the action's log fields", entry)
+	}
+	if len(mock.stepDoneCalls) != 1 {
+		t.Errorf("StepDone called %d times, want 1", len(mock.stepDoneCalls))
+	}
+}
+
+func TestPromptReceived_SwallowThenAck(t *testing.T) {
+	config := SimConfig{
+		Hooks: HooksConfig{
+			FirePromptReceived: true,
+		},
+		Behaviors: []Behavior{
+			{
+				Match:          "review",
+				Type:           "contains",
+				Action:         Action{Type: ActionComplete},
+				AckDelay:       20 * time.Millisecond,
+				SwallowPrompts: 2,
+			},
+		},
+		Default: DefaultConfig{
+			Behavior: Behavior{
+				Action: Action{Type: ActionComplete},
+			},
+		},
+	}
+
+	sim, mock := newTestSimulator(config)
+	sim.state = StateIdle
+
+	// The first two matching prompts vanish, with the lines that follow
+	// them: no ack, no work
+	for i := 0; i < 2; i++ {
+		for _, line := range []string{"please review", "When complete, run: `meow done`"} {
+			if err := sim.handleInput(line); err != nil {
+				t.Fatalf("handleInput failed: %v", err)
+			}
+		}
+		if sim.state != StateIdle || len(mock.eventCalls) != 0 || len(mock.stepDoneCalls) != 0 {
+			t.Fatalf("prompt %d: state = %v, events = %d, step done = %d; want the prompt swallowed",
+				i+1, sim.state, len(mock.eventCalls), len(mock.stepDoneCalls))
+		}
+		sim.swallowUntil = time.Time{} // The next prompt arrives later
+	}
+
+	// The third is acknowledged after the delay, then handled
+	start := time.Now()
+	if err := sim.handleInput("please review"); err != nil {
+		t.Fatalf("handleInput failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("handled after %v, want the ack delayed by 20ms", elapsed)
+	}
+	if len(mock.eventCalls) != 1 || mock.eventCalls[0].eventType != "prompt-received" {
+		t.Fatalf("events = %+v, want one prompt-received", mock.eventCalls)
+	}
+	if len(mock.stepDoneCalls) != 1 {
+		t.Errorf("StepDone called %d times, want 1", len(mock.stepDoneCalls))
+	}
+
+	// Other prompts are acknowledged immediately
+	if err := sim.handleInput("something else"); err != nil {
+		t.Fatalf("handleInput failed: %v", err)
+	}
+	if len(mock.eventCalls) != 2 {
+		t.Errorf("Event called %d times, want 2", len(mock.eventCalls))
+	}
+}
+
 // =============================================================================
 // TestNewDefaultSimConfig - Test default configuration
 // =============================================================================
@@ -27,10 +27,52 @@ type Simulator struct {
 
 	// State tracking for output sequences
 	sequenceCounts map[string]int
+
+	// State tracking for swallow_prompts
+	swallowCounts map[string]int
+	swallowUntil  time.Time // Lines before this belong to a swallowed prompt
+}
+
+// swallowWindow is how long after a swallowed line further lines are taken
+// to be the rest of the same (multi-line) prompt and swallowed with it.
+const swallowWindow = 500 * time.Millisecond
+
+// promptLineGap separates prompts on stdin: a line arriving within this long
+// of the previous one continues the same multi-line prompt.
+const promptLineGap = 30 * time.Millisecond
+
+// inputLine is a line read from stdin with its arrival time.
+type inputLine struct {
+	text string
+	at   time.Time
+}
+
+// readLines sends each line of r to lines as it arrives. It returns nil at
+// EOF or the read error.
+func readLines(r io.Reader, lines chan<- inputLine) error {
+	reader := bufio.NewReader(r)
+	for {
+		text, err := reader.ReadString('\n')
+		if text != "" {
+			lines <- inputLine{text: text, at: time.Now()}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
 }
 
 // NewSimulator creates a new simulator instance.
 func NewSimulator(config SimConfig, logger *slog.Logger) *Simulator {
+	// LoadConfig rejects invalid patterns; a config built in code may still
+	// have one, which then never matches
+	if err := compileBehaviorPatterns(config.Behaviors); err != nil {
+		logger.Warn("invalid behavior pattern", "error", err)
+	}
+
 	return &Simulator{
 		config:         config,
 		logger:         logger,
@@ -41,6 +83,7 @@ func NewSimulator(config SimConfig, logger *slog.Logger) *Simulator {
 		stepID:         os.Getenv("MEOW_STEP"),
 		attemptCounts:  make(map[string]int),
 		sequenceCounts: make(map[string]int),
+		swallowCounts:  make(map[string]int),
 	}
 }
 
@@ -61,7 +104,13 @@ func (s *Simulator) Run() error {
 	s.transitionTo(StateIdle)
 
 	// Main loop
-	reader := bufio.NewReader(os.Stdin)
+	lines := make(chan inputLine, 64)
+	var readErr error
+	go func() {
+		defer close(lines)
+		readErr = readLines(os.Stdin, lines)
+	}()
+	var lastLine time.Time
 	for {
 		if s.state == StateIdle || s.state == StateAsking {
 			// Small delay before showing prompt
@@ -74,19 +123,29 @@ func (s *Simulator) Run() error {
 			}
 		}
 
-		// Read input
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
+		// Read input: the first line of the next prompt. The rest of a
+		// multi-line prompt arrives in the same burst and is dropped, as
+		// Claude takes a pasted prompt as one message. Injection presses
+		// Escape before each prompt, so a line starting with it is always a
+		// new prompt, even when typed ahead before the simulator started.
+		var prompt string
+		for prompt == "" {
+			line, ok := <-lines
+			if !ok {
+				if readErr != nil {
+					return fmt.Errorf("reading input: %w", readErr)
+				}
 				s.logger.Info("stdin closed, exiting")
 				return nil
 			}
-			return fmt.Errorf("reading input: %w", err)
-		}
-
-		prompt := strings.TrimSpace(line)
-		if prompt == "" {
-			continue
+			continuation := !lastLine.IsZero() && line.at.Sub(lastLine) < promptLineGap &&
+				!strings.HasPrefix(line.text, "\x1b")
+			lastLine = line.at
+			if continuation {
+				s.logger.Debug("ignoring prompt continuation line", "line", truncate(line.text, 50))
+				continue
+			}
+			prompt = strings.TrimSpace(line.text)
 		}
 
 		s.logger.Debug("received input",
@@ -121,8 +180,22 @@ func (s *Simulator) handleInput(prompt string) error {
 	switch s.state {
 	case StateIdle:
 		// Normal prompt from orchestrator
-		s.transitionTo(StateWorking)
+		if time.Now().Before(s.swallowUntil) {
+			s.swallowUntil = time.Now().Add(swallowWindow)
+			return nil
+		}
 		behavior := s.matchBehavior(prompt)
+		if s.swallowCounts[behavior.Match] < behavior.SwallowPrompts {
+			s.swallowCounts[behavior.Match]++
+			s.swallowUntil = time.Now().Add(swallowWindow)
+			s.logger.Info("swallowing prompt",
+				"pattern", behavior.Match,
+				"swallowed", s.swallowCounts[behavior.Match],
+			)
+			return nil
+		}
+		s.transitionTo(StateWorking)
+		s.firePromptReceived(behavior)
 		return s.executeBehavior(behavior, prompt)
 
 	case StateAsking:
@@ -167,6 +240,23 @@ func (s *Simulator) fireStopHook() {
 	}
 }
 
+// firePromptReceived emulates the Claude Code prompt-submit hook, which
+// acknowledges the prompt with a prompt-received event, after the behavior's
+// ack delay.
+func (s *Simulator) firePromptReceived(b *Behavior) {
+	if !s.config.Hooks.FirePromptReceived {
+		return
+	}
+	if b.AckDelay > 0 {
+		time.Sleep(b.AckDelay)
+	}
+
+	s.logger.Debug("firing prompt-received hook")
+	if err := s.ipc.Event("prompt-received", nil); err != nil {
+		s.logger.Debug("prompt-received event failed", "error", err)
+	}
+}
+
 // truncate shortens a string to max length, adding "..." if truncated.
 func truncate(s string, maxLen int) string {
 	if maxLen < 4 {
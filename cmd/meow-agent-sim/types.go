@@ -28,8 +28,9 @@ type TimingConfig struct {
 }
 
 type HooksConfig struct {
-    FireStopHook   bool `yaml:"fire_stop_hook"`
-    FireToolEvents bool `yaml:"fire_tool_events"`
+    FireStopHook       bool `yaml:"fire_stop_hook"`
+    FireToolEvents     bool `yaml:"fire_tool_events"`
+    FirePromptReceived bool `yaml:"fire_prompt_received"`
 }
 
 type DefaultConfig struct {
@@ -51,13 +52,18 @@ const (
     ActionFailThenSucceed ActionType = "fail_then_succeed"
     ActionHang            ActionType = "hang"
     ActionCrash           ActionType = "crash"
+    ActionExit            ActionType = "exit"
+    ActionDelayCrash      ActionType = "delay_then_crash"
+    ActionError           ActionType = "error"
 )
 
 // Behavior defines how the simulator responds to a prompt pattern
 type Behavior struct {
-    Match  string `yaml:"match"`
-    Type   string `yaml:"type"` // "contains" or "regex"
-    Action Action `yaml:"action"`
+    Match          string        `yaml:"match"`
+    Type           string        `yaml:"type"` // "contains" or "regex"
+    Action         Action        `yaml:"action"`
+    AckDelay       time.Duration `yaml:"ack_delay"`       // Wait before firing prompt-received
+    SwallowPrompts int           `yaml:"swallow_prompts"` // Ignore the first N matching prompts entirely
 }
 
 // Action defines the simulator's response action
@@ -69,8 +75,11 @@ type Action struct {
     Events          []EventDef       `yaml:"events"`
     Question        string           `yaml:"question"`
     FailCount       int              `yaml:"fail_count"`
-    FailMessage     string           `yaml:"fail_message"`
+    FailMessage     string           `yaml:"fail_message"` // For fail and error: the error reported
     ExitCode        int              `yaml:"exit_code"`
+    ResultFile      string           `yaml:"result_file"` // For exit: write outputs here as JSON before exiting
+    CrashAfter      time.Duration    `yaml:"crash_after"` // For delay_then_crash: how long to work before crashing
+    Log             map[string]any   `yaml:"log"`         // Fields logged as one structured (JSON) log line before the final action
 }
 
 // EventDef defines a tool event to emit
@@ -83,6 +92,7 @@ type EventDef struct {
 // IPCClientInterface defines the interface for orchestrator communication
 type IPCClientInterface interface {
     StepDone(outputs map[string]any) error
+    StepFailed(message string) error
     Event(eventType string, data map[string]any) error
     Close() error
 }
@@ -2,11 +2,10 @@ package cmd
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -14,9 +13,10 @@ import (
 
 	"github.com/akatz-ai/meow/internal/agent"
 	"github.com/akatz-ai/meow/internal/cli"
+	"github.com/akatz-ai/meow/internal/config"
+	"github.com/akatz-ai/meow/internal/logging"
 	"github.com/akatz-ai/meow/internal/orchestrator"
 	"github.com/akatz-ai/meow/internal/types"
-	"github.com/akatz-ai/meow/internal/workflow"
 	"github.com/spf13/cobra"
 )
 
@@ -28,7 +28,7 @@ var (
 )
 
 // CleanupTimeout is the maximum time allowed for cleanup script execution.
-const CleanupTimeout = 60 * time.Second
+const CleanupTimeout = orchestrator.CleanupTimeout
 
 // Maximum number of workflows to show in interactive selection
 const maxCleanupOptions = 15
@@ -75,6 +75,10 @@ type cleanupResources struct {
 	CleanupScript string
 	// Which trigger the script is from
 	CleanupTrigger string
+	// cleanup_when scripts, run if their conditions hold
+	ConditionalCleanups int
+	// Whether the after_all_agents hook has yet to run
+	AfterAllAgents bool
 }
 
 func runCleanup(cmd *cobra.Command, args []string) error {
@@ -131,7 +135,8 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	printCleanupPreview(wf, resources)
 
 	// Check if there's anything to do
-	if len(resources.Sessions) == 0 && resources.CleanupScript == "" {
+	if len(resources.Sessions) == 0 && resources.CleanupScript == "" &&
+		resources.ConditionalCleanups == 0 && !resources.AfterAllAgents {
 		fmt.Println("\nNothing to clean up.")
 		return nil
 	}
@@ -159,7 +164,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 
 	// Execute cleanup
 	fmt.Println()
-	if err := executeCleanup(ctx, wf, resources, dir); err != nil {
+	if err := executeCleanup(ctx, store, wf, resources, dir); err != nil {
 		return fmt.Errorf("cleanup failed: %w", err)
 	}
 
@@ -345,6 +350,9 @@ func discoverResources(ctx context.Context, wf *types.Run, workdir string) (*cle
 		}
 	}
 
+	resources.ConditionalCleanups = len(wf.CleanupWhen)
+	resources.AfterAllAgents = wf.AfterAllAgents != "" && wf.BeforeAllAgentsRan && !wf.AfterAllAgentsRan
+
 	return resources, nil
 }
 
@@ -385,19 +393,42 @@ func printCleanupPreview(wf *types.Run, resources *cleanupResources) {
 		fmt.Printf("  cleanup script: %s (will run)\n", resources.CleanupTrigger)
 	} else {
 		fmt.Printf("  cleanup script: not defined for '%s' status\n", wf.Status)
-		if wf.Status == types.RunStatusFailed || wf.Status == types.RunStatusStopped {
-			fmt.Println("\n  ⚠️  No cleanup script for this status. Only tmux sessions will be killed.")
-			fmt.Println("     You may need to manually clean up worktrees in .meow/worktrees/")
-		}
+	}
+	if resources.ConditionalCleanups > 0 {
+		fmt.Printf("  cleanup_when scripts: %d (run if their conditions hold)\n", resources.ConditionalCleanups)
+	}
+	if resources.AfterAllAgents {
+		fmt.Println("  after_all_agents hook: will run after sessions are killed")
+	}
+	if wf.CleanupOrder == types.CleanupScriptFirst && (resources.CleanupScript != "" || resources.ConditionalCleanups > 0) {
+		fmt.Println("  cleanup_order: scripts run before sessions are killed")
+	}
+	if resources.CleanupScript == "" && resources.ConditionalCleanups == 0 &&
+		(wf.Status == types.RunStatusFailed || wf.Status == types.RunStatusStopped) {
+		fmt.Println("\n  ⚠️  No cleanup script for this status. Only tmux sessions will be killed.")
+		fmt.Println("     You may need to manually clean up worktrees in .meow/worktrees/")
 	}
 }
 
-// executeCleanup performs the actual cleanup.
-func executeCleanup(ctx context.Context, wf *types.Run, resources *cleanupResources, workdir string) error {
-	tmux := agent.NewTmuxWrapper()
+// executeCleanup performs the actual cleanup, in the same order and with the
+// same hooks as the orchestrator's own cleanup.
+func executeCleanup(ctx context.Context, store *orchestrator.YAMLRunStore, wf *types.Run, resources *cleanupResources, workdir string) error {
+	logger := logging.New(config.LoggingConfig{Format: config.LogFormatText}, os.Stderr, slog.LevelWarn)
+	return orchestrator.CleanupManually(ctx, store, wf, logger, orchestrator.ManualCleanupOptions{
+		Workdir: workdir,
+		Output:  os.Stdout,
+		KillAgents: func() error {
+			killSessions(ctx, resources.Sessions)
+			return nil
+		},
+	})
+}
 
-	// 1. Kill tmux sessions
-	for _, session := range resources.Sessions {
+// killSessions kills tmux sessions, sending C-c first for a graceful
+// shutdown. Failures are reported as warnings.
+func killSessions(ctx context.Context, sessions []string) {
+	tmux := agent.NewTmuxWrapper()
+	for _, session := range sessions {
 		fmt.Printf("Killing tmux session: %s... ", session)
 
 		// Send C-c first for graceful shutdown
@@ -411,60 +442,6 @@ func executeCleanup(ctx context.Context, wf *types.Run, resources *cleanupResour
 			fmt.Println("done")
 		}
 	}
-
-	// 2. Run cleanup script if defined
-	if resources.CleanupScript != "" {
-		fmt.Printf("\nRunning %s...\n", resources.CleanupTrigger)
-		if err := runCleanupScript(ctx, wf, resources.CleanupScript, workdir); err != nil {
-			return fmt.Errorf("cleanup script failed: %w", err)
-		}
-	}
-
-	return nil
-}
-
-// runCleanupScript executes a cleanup script with timeout.
-func runCleanupScript(ctx context.Context, wf *types.Run, script string, workdir string) error {
-	if script == "" {
-		return nil
-	}
-
-	// Create a timeout context for cleanup
-	cleanupCtx, cancel := context.WithTimeout(ctx, CleanupTimeout)
-	defer cancel()
-
-	// Execute the cleanup script via bash
-	cmd := exec.CommandContext(cleanupCtx, "bash", "-c", script)
-	cmd.Dir = workdir
-
-	// Set environment variables from workflow
-	cmd.Env = os.Environ()
-	for k, v := range wf.Variables {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, workflow.StringifyValue(v)))
-	}
-	// Add workflow ID as an environment variable
-	cmd.Env = append(cmd.Env, fmt.Sprintf("MEOW_WORKFLOW=%s", wf.ID))
-	// Mark this as a manual cleanup run
-	cmd.Env = append(cmd.Env, "MEOW_CLEANUP=manual")
-
-	// Capture output for display
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		if cleanupCtx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("cleanup script timed out after %s", CleanupTimeout)
-		}
-		return fmt.Errorf("%w\nstderr: %s", err, stderr.String())
-	}
-
-	// Print script output
-	if stdout.Len() > 0 {
-		fmt.Print(stdout.String())
-	}
-
-	return nil
 }
 
 // contains checks if a string slice contains a value.
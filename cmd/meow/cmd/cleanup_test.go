@@ -247,3 +247,60 @@ func TestCleanupTimeout(t *testing.T) {
 		t.Errorf("CleanupTimeout too long: %v", CleanupTimeout)
 	}
 }
+
+func TestExecuteCleanup(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	store, err := orchestrator.NewYAMLRunStore(filepath.Join(tmpDir, "runs"))
+	if err != nil {
+		t.Fatalf("NewYAMLRunStore failed: %v", err)
+	}
+	defer store.Close()
+
+	trace := filepath.Join(tmpDir, "trace")
+	wf := &types.Run{
+		ID:                 "run-test",
+		Status:             types.RunStatusFailed,
+		CleanupOnFailure:   "echo script >> " + trace,
+		CleanupOrder:       types.CleanupScriptFirst,
+		AfterAllAgents:     "echo after_all >> " + trace,
+		BeforeAllAgentsRan: true,
+		CleanupWhen: []types.ConditionalCleanup{
+			{Condition: "true", Script: "echo when_$MEOW_CLEANUP >> " + trace},
+			{Condition: "false", Script: "echo skipped >> " + trace},
+		},
+		Steps:  make(map[string]*types.Step),
+		Agents: make(map[string]*types.AgentInfo),
+	}
+	if err := store.Save(ctx, wf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	resources, err := discoverResources(ctx, wf, tmpDir)
+	if err != nil {
+		t.Fatalf("discoverResources failed: %v", err)
+	}
+	if resources.ConditionalCleanups != 2 || !resources.AfterAllAgents {
+		t.Errorf("resources = %+v, want 2 conditional cleanups and the after_all_agents hook", resources)
+	}
+
+	if err := executeCleanup(ctx, store, wf, resources, tmpDir); err != nil {
+		t.Fatalf("executeCleanup failed: %v", err)
+	}
+
+	data, err := os.ReadFile(trace)
+	if err != nil {
+		t.Fatalf("reading trace: %v", err)
+	}
+	if got, want := string(data), "script\nwhen_manual\nafter_all\n"; got != want {
+		t.Errorf("cleanup ran %q, want %q", got, want)
+	}
+
+	saved, err := store.Get(ctx, wf.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !saved.AfterAllAgentsRan {
+		t.Error("after_all_agents hook not recorded as run")
+	}
+}
@@ -33,7 +33,10 @@ Examples:
   meow done --output-json '{"key": "value"}'
 
   # With notes
-  meow done --notes "Completed successfully"`,
+  meow done --notes "Completed successfully"
+
+  # Report that the step could not be done (the step fails)
+  meow done --error "tests do not compile"`,
 	RunE: runDone,
 }
 
@@ -41,12 +44,14 @@ var (
 	doneNotes      string
 	doneOutputs    []string
 	doneOutputJSON string
+	doneError      string
 )
 
 func init() {
 	doneCmd.Flags().StringVar(&doneNotes, "notes", "", "completion notes")
 	doneCmd.Flags().StringArrayVar(&doneOutputs, "output", nil, "output values (format: name=value)")
 	doneCmd.Flags().StringVar(&doneOutputJSON, "output-json", "", "outputs as JSON object")
+	doneCmd.Flags().StringVar(&doneError, "error", "", "fail the step with this message instead of completing it")
 	rootCmd.AddCommand(doneCmd)
 }
 
@@ -82,6 +87,21 @@ func runDone(cmd *cobra.Command, args []string) error {
 	}
 	// If still empty, the IPC handler will find the running step for this agent
 
+	// Create IPC client using the socket path from environment
+	client := ipc.NewClient(sockPath)
+
+	// A failed step reports no outputs
+	if doneError != "" {
+		if len(doneOutputs) > 0 || doneOutputJSON != "" {
+			return fmt.Errorf("--error cannot be combined with --output or --output-json")
+		}
+		response, err := client.SendStepFailed(workflowID, agentID, stepID, doneError, doneNotes)
+		if err != nil {
+			return fmt.Errorf("sending done message: %w", err)
+		}
+		return checkDoneResponse(response, stepID, "failed")
+	}
+
 	// Parse outputs from flags
 	outputs := make(map[string]any)
 
@@ -105,23 +125,24 @@ func runDone(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Create IPC client using the socket path from environment
-	client := ipc.NewClient(sockPath)
-
 	// Send step done message
 	response, err := client.SendStepDone(workflowID, agentID, stepID, outputs, doneNotes)
 	if err != nil {
 		return fmt.Errorf("sending done message: %w", err)
 	}
+	return checkDoneResponse(response, stepID, "completed")
+}
 
-	// Check response
+// checkDoneResponse checks the orchestrator's response to meow done. outcome
+// describes the step in verbose output ("completed" or "failed").
+func checkDoneResponse(response any, stepID, outcome string) error {
 	switch r := response.(type) {
 	case *ipc.AckMessage:
 		if !r.Success {
 			return fmt.Errorf("orchestrator rejected completion")
 		}
 		if verbose {
-			fmt.Printf("Step %s completed\n", stepID)
+			fmt.Printf("Step %s %s\n", stepID, outcome)
 		}
 	case *ipc.ErrorMessage:
 		return fmt.Errorf("orchestrator error: %s", r.Message)
@@ -18,13 +18,19 @@ var eventCmd = &cobra.Command{
 Events are fire-and-forget notifications. The orchestrator will route the event
 to any matching waiters, or simply log it if there are no waiters.
 
-Environment variables required:
-  MEOW_ORCH_SOCK - Path to orchestrator socket (set by orchestrator)
+Events also complete gate steps waiting on them (wait_for_event).
+
+Environment variables:
+  MEOW_ORCH_SOCK - Path to orchestrator socket (set by orchestrator;
+                   derived from --workflow when not set)
 
 Examples:
   # Simple event
   meow event agent-stopped
 
+  # Outside workflow (manual use): open a gate
+  meow event --workflow wf-abc123 approve-deploy --data approver=alice
+
   # Event with data
   meow event tool-completed --data tool=Bash --data exit_code=0
 
@@ -35,23 +41,28 @@ Examples:
 }
 
 var (
-	eventData []string
+	eventData     []string
+	eventWorkflow string
 )
 
 func init() {
 	eventCmd.Flags().StringArrayVar(&eventData, "data", nil, "event data (format: key=value)")
+	eventCmd.Flags().StringVar(&eventWorkflow, "workflow", "", "workflow ID to send to when MEOW_ORCH_SOCK is not set")
 	rootCmd.AddCommand(eventCmd)
 }
 
 func runEvent(cmd *cobra.Command, args []string) error {
 	eventType := args[0]
 
-	// Get orchestrator socket from environment
-	// If not set, exit silently (no-op) - allows agents to call meow event
-	// without being in a MEOW workflow
+	// Get orchestrator socket from environment, or derive it from --workflow.
+	// If neither is set, exit silently (no-op) - allows agents to call meow
+	// event without being in a MEOW workflow
 	sockPath := os.Getenv("MEOW_ORCH_SOCK")
 	if sockPath == "" {
-		return nil // Silent no-op
+		if eventWorkflow == "" {
+			return nil // Silent no-op
+		}
+		sockPath = ipc.SocketPath(eventWorkflow)
 	}
 
 	// Parse --data flags into map
@@ -74,6 +85,9 @@ func runEvent(cmd *cobra.Command, args []string) error {
 	// Get agent and workflow from environment for context
 	agent := os.Getenv("MEOW_AGENT")
 	workflow := os.Getenv("MEOW_WORKFLOW")
+	if eventWorkflow != "" {
+		workflow = eventWorkflow
+	}
 
 	// Create IPC client
 	client := ipc.NewClient(sockPath)
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/akatz-ai/meow/internal/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+var exportOutput string
+
+var exportCmd = &cobra.Command{
+	Use:   "export <workflow-id>",
+	Short: "Export a run as a self-contained bundle",
+	Long: `Export a run's state together with the template it was created from.
+
+The bundle contains the full run state (steps, outputs, agents) and the
+content of every template module the run references, so it can be loaded
+into another project with 'meow import' for inspection or resumption.
+
+Templates referenced from outside those modules (other files, collections)
+are not bundled and must be available where the bundle is imported.
+
+Examples:
+  meow export run-abc123 > run-abc123.meow-bundle.yaml
+  meow export run-abc123 -o bundle.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "write the bundle to a file instead of stdout")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	workflowID := args[0]
+
+	dir, err := getWorkDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := orchestrator.NewYAMLRunStore(bundleRunsDir(dir))
+	if err != nil {
+		return fmt.Errorf("opening workflow store: %w", err)
+	}
+
+	bundle, err := orchestrator.ExportRun(context.Background(), store, workflowID)
+	if err != nil {
+		return fmt.Errorf("exporting workflow: %w", err)
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if exportOutput != "" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			return fmt.Errorf("creating bundle file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := orchestrator.WriteBundle(out, bundle); err != nil {
+		return fmt.Errorf("writing bundle: %w", err)
+	}
+	if exportOutput != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Exported %s (%d steps, %d templates) to %s\n",
+			workflowID, len(bundle.Run.Steps), len(bundle.Templates), exportOutput)
+	}
+	return nil
+}
+
+// bundleRunsDir returns the runs directory used by export and import,
+// honoring MEOW_RUNS_DIR like meow resume.
+func bundleRunsDir(dir string) string {
+	if runsDir := os.Getenv("MEOW_RUNS_DIR"); runsDir != "" {
+		return runsDir
+	}
+	return filepath.Join(dir, ".meow", "runs")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/akatz-ai/meow/internal/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Import a run exported with meow export",
+	Long: `Load a run bundle created by 'meow export' into this project's run store.
+
+The run keeps its ID and state. Bundled templates are written to
+.meow/imports/<workflow-id>/ and the run is pointed at them, so pending
+expansions resolve without the original files. Use 'meow resume <id>' to
+continue an imported run that had not finished.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	dir, err := getWorkDir()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("opening bundle: %w", err)
+	}
+	defer f.Close()
+
+	bundle, err := orchestrator.ReadBundle(f)
+	if err != nil {
+		return err
+	}
+
+	store, err := orchestrator.NewYAMLRunStore(bundleRunsDir(dir))
+	if err != nil {
+		return fmt.Errorf("opening workflow store: %w", err)
+	}
+
+	var templatesDir string
+	if bundle.Run != nil {
+		templatesDir = filepath.Join(dir, ".meow", "imports", bundle.Run.ID)
+	}
+	wf, err := orchestrator.ImportRun(context.Background(), store, bundle, templatesDir)
+	if err != nil {
+		return fmt.Errorf("importing workflow: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Imported workflow %s (status: %s, %d steps)\n", wf.ID, wf.Status, len(wf.Steps))
+	if !wf.Status.IsTerminal() {
+		fmt.Fprintf(cmd.OutOrStdout(), "Resume with: meow resume %s\n", wf.ID)
+	}
+	return nil
+}
@@ -114,10 +114,26 @@ logs_dir = ".meow/logs"
 
 [orchestrator]
 poll_interval = "100ms"
+# While idle the poll interval backs off up to this (0 = always poll_interval)
+# max_poll_interval = "1s"
+# Cap on agent steps running at once across workflows (0 = unlimited)
+# max_concurrent_agents = 4
+# How long a timed-out agent step has to stop after C-c before it fails
+# timeout_grace_period = "10s"
 
 [logging]
 level = "info"
 
+# Per-executor log levels, e.g. quiet shell steps and verbose agent steps:
+# [logging.executors]
+# shell = "warn"
+# agent = "debug"
+
+# Send step counters and durations to statsd over UDP:
+# [metrics]
+# statsd_addr = "127.0.0.1:8125"
+# prefix = "meow"
+
 [agent]
 # default_adapter controls which adapter spawn steps use when none is specified.
 default_adapter = "claude"
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/akatz-ai/meow/internal/config"
+	"github.com/akatz-ai/meow/internal/orchestrator"
+)
+
+// newMetricsSink creates the statsd metrics sink for a run when [metrics]
+// names a statsd address. It returns a nil sink (metrics disabled) and a
+// no-op close when none is configured.
+func newMetricsSink(cfg *config.Config) (*orchestrator.StatsdMetricsSink, func(), error) {
+	if cfg.Metrics.StatsdAddr == "" {
+		return nil, func() {}, nil
+	}
+	sink, err := orchestrator.DialStatsd(cfg.Metrics.StatsdAddr, cfg.Metrics.Prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sink, func() { sink.Close() }, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/orchestrator"
+	"github.com/akatz-ai/meow/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause <workflow-id>",
+	Short: "Pause a running workflow",
+	Long: `Pause a running workflow without stopping it.
+
+While the workflow is paused no new steps are dispatched. Steps that are
+already running carry on: agents stay alive and can still finish their
+steps with meow done, so they can be inspected in the meantime.
+
+Continue with 'meow resume <workflow-id>'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPause,
+}
+
+func init() {
+	rootCmd.AddCommand(pauseCmd)
+}
+
+func runPause(cmd *cobra.Command, args []string) error {
+	workflowID := args[0]
+	ctx := context.Background()
+
+	dir, err := getWorkDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := orchestrator.NewYAMLRunStore(bundleRunsDir(dir))
+	if err != nil {
+		return fmt.Errorf("opening workflow store: %w", err)
+	}
+
+	wf, err := store.Get(ctx, workflowID)
+	if err != nil {
+		return fmt.Errorf("workflow not found: %s", workflowID)
+	}
+
+	switch wf.Status {
+	case types.RunStatusRunning:
+	case types.RunStatusPaused:
+		return fmt.Errorf("workflow %s is already paused", workflowID)
+	default:
+		return fmt.Errorf("workflow %s is %s, only running workflows can be paused", workflowID, wf.Status)
+	}
+	if !orchestratorRunning(wf) {
+		return fmt.Errorf("workflow %s has no running orchestrator (run 'meow resume %s' to recover it)", workflowID, workflowID)
+	}
+
+	if err := ipc.NewClientForWorkflow(workflowID).Pause(workflowID); err != nil {
+		return fmt.Errorf("pausing workflow: %w", err)
+	}
+
+	fmt.Printf("Workflow %s paused\n", workflowID)
+	fmt.Printf("Running steps carry on; run 'meow resume %s' to continue.\n", workflowID)
+	return nil
+}
+
+// orchestratorRunning reports whether the run's orchestrator process is alive.
+func orchestratorRunning(wf *types.Run) bool {
+	return wf.OrchestratorPID != 0 && validateMeowProcess(wf.OrchestratorPID) == nil
+}
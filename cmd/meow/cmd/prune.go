@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/orchestrator"
+	"github.com/akatz-ai/meow/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// Prune command flags
+var (
+	pruneOlderThan time.Duration
+	pruneStatus    []string
+	pruneDryRun    bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune --older-than <duration>",
+	Short: "Delete old finished workflows from the run store",
+	Long: `Delete the state of workflows that finished longer ago than --older-than.
+
+Only terminal workflows (done, failed, stopped) are deleted; pending, running
+and cleaning-up workflows are always kept. Use --status to restrict which
+terminal statuses are pruned, and --dry-run to list what would be deleted.
+
+Examples:
+  meow prune --older-than 168h                       # Finished more than a week ago
+  meow prune --older-than 720h --status done,failed  # Keep stopped runs
+  meow prune --older-than 24h --dry-run              # Preview only`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 0, "Delete workflows that finished longer ago than this (e.g. 168h)")
+	pruneCmd.Flags().StringSliceVar(&pruneStatus, "status", nil, "Statuses to prune: done, failed, stopped (default all three)")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "List workflows that would be deleted without deleting them")
+	pruneCmd.MarkFlagRequired("older-than")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	if pruneOlderThan <= 0 {
+		return fmt.Errorf("--older-than must be positive")
+	}
+	var statuses []types.RunStatus
+	for _, s := range pruneStatus {
+		status := types.RunStatus(s)
+		if !status.Valid() {
+			return fmt.Errorf("invalid status %q: must be done, failed or stopped", s)
+		}
+		statuses = append(statuses, status)
+	}
+
+	dir, err := getWorkDir()
+	if err != nil {
+		return err
+	}
+
+	runsDir := filepath.Join(dir, ".meow", "runs")
+	store, err := orchestrator.NewYAMLRunStore(runsDir)
+	if err != nil {
+		return fmt.Errorf("opening workflow store: %w", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	pruned, err := orchestrator.PruneRuns(context.Background(), store, orchestrator.PruneOptions{
+		Before:   now.Add(-pruneOlderThan),
+		Statuses: statuses,
+		DryRun:   pruneDryRun,
+	})
+	if len(pruned) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tFINISHED\tTEMPLATE")
+		for _, wf := range pruned {
+			finished := wf.StartedAt
+			if wf.DoneAt != nil {
+				finished = *wf.DoneAt
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", wf.ID, wf.Status, finished.Format("Jan 02 15:04"), wf.Template)
+		}
+		w.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(pruned) == 0:
+		fmt.Println("No workflows to prune.")
+	case pruneDryRun:
+		fmt.Printf("\n--dry-run specified, %d workflow(s) would be deleted.\n", len(pruned))
+	default:
+		fmt.Printf("\nDeleted %d workflow(s).\n", len(pruned))
+	}
+	return nil
+}
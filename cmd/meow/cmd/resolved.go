@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akatz-ai/meow/internal/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+var resolvedCmd = &cobra.Command{
+	Use:   "resolved <workflow-id>",
+	Short: "Show a run's fully resolved steps",
+	Long: `Print the run's step graph as it exists after expansion, as YAML.
+
+Template variables are substituted when the run is created, and expand,
+foreach, and branch steps add their children as the run progresses. This
+command also substitutes every {{step.outputs.field}} reference whose
+output exists, so commands and prompts read as they actually ran. References
+to outputs not produced yet are left as written.
+
+Examples:
+  meow resolved run-abc123
+  meow resolved run-abc123 | yq '.steps[] | select(.id == "build")'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runResolved,
+}
+
+func init() {
+	rootCmd.AddCommand(resolvedCmd)
+}
+
+func runResolved(cmd *cobra.Command, args []string) error {
+	workflowID := args[0]
+
+	dir, err := getWorkDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := orchestrator.NewYAMLRunStore(bundleRunsDir(dir))
+	if err != nil {
+		return fmt.Errorf("opening workflow store: %w", err)
+	}
+
+	resolved, err := orchestrator.ResolveRun(context.Background(), store, workflowID)
+	if err != nil {
+		return fmt.Errorf("resolving workflow: %w", err)
+	}
+
+	if err := orchestrator.WriteResolved(cmd.OutOrStdout(), resolved); err != nil {
+		return fmt.Errorf("writing resolved workflow: %w", err)
+	}
+	return nil
+}
@@ -7,10 +7,12 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/akatz-ai/meow/internal/config"
 	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/logging"
 	"github.com/akatz-ai/meow/internal/orchestrator"
 	"github.com/akatz-ai/meow/internal/types"
 	"github.com/spf13/cobra"
@@ -18,21 +20,32 @@ import (
 
 var resumeCmd = &cobra.Command{
 	Use:   "resume <workflow-id>",
-	Short: "Resume a crashed or interrupted workflow",
-	Long: `Resume execution of a workflow that was interrupted.
+	Short: "Resume a paused, crashed, or interrupted workflow",
+	Long: `Resume execution of a workflow that was paused or interrupted.
 
-This command handles crash recovery by:
+A workflow paused with meow pause whose orchestrator is still running is
+simply resumed: its ready steps are dispatched again.
+
+Otherwise this command handles crash recovery by:
 1. Loading the workflow state from disk
 2. Recovering interrupted steps (resetting orchestrator steps to pending,
    checking if agents are still alive, etc.)
 3. Continuing execution from where it left off
 
-Use this after the orchestrator crashed while running a workflow.`,
+Use this after the orchestrator crashed while running a workflow. A paused
+workflow recovered this way is resumed as well.
+
+A workflow run with --pause-on-failure that paused on a failed step retries
+that step when resumed. Use --abort to let the failure stand instead: the
+workflow then fails as usual, running cleanup_on_failure.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runResume,
 }
 
+var resumeAbort bool
+
 func init() {
+	resumeCmd.Flags().BoolVar(&resumeAbort, "abort", false, "let the step failures that paused the workflow stand instead of retrying them")
 	rootCmd.AddCommand(resumeCmd)
 }
 
@@ -51,6 +64,12 @@ func runResume(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	if err := cfg.Logging.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.Shell.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 
 	// Determine runs directory - check MEOW_RUNS_DIR env var first (used by E2E tests),
 	// then fall back to default .meow/runs
@@ -69,13 +88,6 @@ func runResume(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("opening workflow store: %w", err)
 	}
 
-	// Acquire per-workflow lock before resuming
-	lock, err := store.AcquireWorkflowLock(workflowID)
-	if err != nil {
-		return fmt.Errorf("acquiring workflow lock: %w", err)
-	}
-	defer lock.Release()
-
 	// Load the workflow
 	wf, err := store.Get(ctx, workflowID)
 	if err != nil {
@@ -87,11 +99,29 @@ func runResume(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("workflow %s is already %s, cannot resume", workflowID, wf.Status)
 	}
 
-	if wf.DefaultAdapter == "" && cfg.Agent.DefaultAdapter != "" {
-		wf.DefaultAdapter = cfg.Agent.DefaultAdapter
-		if err := store.Save(ctx, wf); err != nil {
-			return fmt.Errorf("saving workflow defaults: %w", err)
+	if resumeAbort && (wf.Status != types.RunStatusPaused || len(wf.PausedOnFailure) == 0) {
+		return fmt.Errorf("workflow %s is not paused on a failure, nothing to abort", workflowID)
+	}
+
+	// A paused workflow with a live orchestrator only needs to be told
+	if wf.Status == types.RunStatusPaused && orchestratorRunning(wf) {
+		client := ipc.NewClientForWorkflow(workflowID)
+		if resumeAbort {
+			if err := client.Abort(workflowID); err != nil {
+				return fmt.Errorf("aborting workflow: %w", err)
+			}
+			fmt.Printf("Workflow %s resumed; the failures of %s stand\n", workflowID, strings.Join(wf.PausedOnFailure, ", "))
+			return nil
 		}
+		if err := client.Resume(workflowID); err != nil {
+			return fmt.Errorf("resuming workflow: %w", err)
+		}
+		if len(wf.PausedOnFailure) > 0 {
+			fmt.Printf("Workflow %s resumed, retrying %s\n", workflowID, strings.Join(wf.PausedOnFailure, ", "))
+		} else {
+			fmt.Printf("Workflow %s resumed\n", workflowID)
+		}
+		return nil
 	}
 
 	fmt.Printf("Resuming workflow %s (status: %s)\n", workflowID, wf.Status)
@@ -101,16 +131,22 @@ func runResume(cmd *cobra.Command, args []string) error {
 	if verbose {
 		logLevel = slog.LevelDebug
 	}
-	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
+	// Per-executor overrides in [logging.executors] can raise or lower this
+	logger := logging.New(config.LoggingConfig{
+		Format:    config.LogFormatText,
+		Executors: cfg.Logging.Executors,
+	}, os.Stderr, logLevel)
 
 	// Create shell runner
 	shellRunner := orchestrator.NewDefaultShellRunner()
 
-	// Create agent manager for tmux sessions
+	// Create agent manager for tmux sessions, recording agent exit codes
+	// alongside the run state as meow run does
 	// Passing nil registry uses the default (project + global adapters)
-	agentManager := orchestrator.NewTmuxAgentManager(dir, nil, logger)
+	agentManager := orchestrator.NewTmuxAgentManagerWithOptions(dir, nil, logger, orchestrator.AgentManagerOptions{
+		LoggingEnabled: true,
+		StateDir:       runsDir,
+	})
 
 	// Create template expander
 	expander := orchestrator.NewTemplateExpanderAdapter(dir)
@@ -118,8 +154,31 @@ func runResume(cmd *cobra.Command, args []string) error {
 	// Create orchestrator with agent support
 	orch := orchestrator.New(cfg, store, agentManager, shellRunner, expander, logger)
 	orch.SetWorkflowID(workflowID)
+	orch.SetArtifactsDir(filepath.Join(cfg.ArtifactsDir(dir), workflowID))
+	orch.SetLocksDir(cfg.LocksDir(dir))
+	orch.SetInputsDir(dir)
 
-	// Perform crash recovery
+	// Emit OpenTelemetry spans when [tracing] is enabled
+	tp, shutdownTracing, err := newTracerProvider(cfg, dir, workflowID)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	if tp != nil {
+		orch.SetTracerProvider(tp)
+	}
+
+	// Send step metrics to statsd when [metrics] names an address
+	sink, closeMetrics, err := newMetricsSink(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeMetrics()
+	if sink != nil {
+		orch.SetMetricsSink(sink)
+	}
+
+	// Perform crash recovery (acquires the workflow lock, held until Run returns)
 	fmt.Println("Performing crash recovery...")
 	if err := orch.Recover(ctx); err != nil {
 		return fmt.Errorf("crash recovery failed: %w", err)
@@ -137,6 +196,21 @@ func runResume(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if wf.DefaultAdapter == "" && cfg.Agent.DefaultAdapter != "" {
+		wf.DefaultAdapter = cfg.Agent.DefaultAdapter
+	}
+
+	// Recovery keeps a paused workflow paused; resuming it is what was asked
+	if wf.Status == types.RunStatusPaused {
+		resume := wf.Resume
+		if resumeAbort {
+			resume = wf.Abort
+		}
+		if err := resume(); err != nil {
+			return err
+		}
+	}
+
 	// Store orchestrator PID for meow stop
 	wf.OrchestratorPID = os.Getpid()
 	if err := store.Save(ctx, wf); err != nil {
@@ -196,11 +270,14 @@ func runResume(cmd *cobra.Command, args []string) error {
 
 	// Print final status
 	fmt.Printf("\nWorkflow %s: %s\n", workflowID, wf.Status)
+	printRunResults(wf)
 	if verbose || wf.Status == types.RunStatusFailed {
 		fmt.Println("\nStep results:")
 		for _, step := range wf.Steps {
 			status := string(step.Status)
-			if step.Error != nil {
+			if step.SkipReason != nil {
+				status = fmt.Sprintf("%s (%s)", status, step.SkipReason.Message)
+			} else if step.Error != nil {
 				status = fmt.Sprintf("%s (error: %s)", status, step.Error.Message)
 			}
 			fmt.Printf("  %s: %s\n", step.ID, status)
@@ -29,7 +29,7 @@ var rootCmd = &cobra.Command{
 The Makefile of agent orchestration. No Python. No cloud. No magic.
 Just tmux, TOML, and a binary.
 
-Built on 7 primitive executors (shell, spawn, kill, expand, branch, foreach, agent),
+Built on 8 primitive executors (shell, spawn, kill, expand, branch, foreach, gate, agent),
 MEOW enables complex multi-agent workflows with crash recovery and context management.
 
 For more information, see: https://github.com/akatz-ai/meow`,
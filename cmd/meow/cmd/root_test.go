@@ -26,14 +26,14 @@ func TestRootCmdFlags(t *testing.T) {
 }
 
 // TestRootCmdHelpUsesCorrectVocabulary verifies the help text uses correct terminology.
-// There are 7 executors (shell, spawn, kill, expand, branch, foreach, agent),
+// There are 8 executors (shell, spawn, kill, expand, branch, foreach, gate, agent),
 // not "6 bead types".
 func TestRootCmdHelpUsesCorrectVocabulary(t *testing.T) {
 	longHelp := rootCmd.Long
 
-	// Should mention "7" executors, not "6"
+	// Should mention "8" executors, not "6"
 	if strings.Contains(longHelp, "6 primitive") {
-		t.Error("help text should not say '6 primitive' - MEOW has 7 executors")
+		t.Error("help text should not say '6 primitive' - MEOW has 8 executors")
 	}
 
 	// Should use "executors" terminology, not "bead types"
@@ -41,13 +41,13 @@ func TestRootCmdHelpUsesCorrectVocabulary(t *testing.T) {
 		t.Error("help text should not mention 'bead types' - use 'executors' instead")
 	}
 
-	// Should mention "7 primitive executors"
-	if !strings.Contains(longHelp, "7 primitive executors") {
-		t.Error("help text should mention '7 primitive executors'")
+	// Should mention "8 primitive executors"
+	if !strings.Contains(longHelp, "8 primitive executors") {
+		t.Error("help text should mention '8 primitive executors'")
 	}
 
-	// Should list all 7 executors
-	executors := []string{"shell", "spawn", "kill", "expand", "branch", "foreach", "agent"}
+	// Should list all 8 executors
+	executors := []string{"shell", "spawn", "kill", "expand", "branch", "foreach", "gate", "agent"}
 	for _, exec := range executors {
 		if !strings.Contains(longHelp, exec) {
 			t.Errorf("help text should mention executor %q", exec)
@@ -9,13 +9,14 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
-	"time"
 
 	"github.com/akatz-ai/meow/internal/cli"
 	"github.com/akatz-ai/meow/internal/config"
 	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/logging"
 	"github.com/akatz-ai/meow/internal/orchestrator"
 	"github.com/akatz-ai/meow/internal/types"
 	"github.com/akatz-ai/meow/internal/workflow"
@@ -37,6 +38,11 @@ as a daemon and you can use 'meow status' to check progress and
 Examples:
   meow run workflow.toml              # Run in foreground
   meow run workflow.toml -d           # Run in background
+  meow run workflow.toml --watch      # Re-run steps when their inputs change
+  meow run workflow.toml --skip-to review  # Mark review's upstream steps done and start there
+  meow run workflow.toml --strict     # Exit non-zero unless the workflow succeeds (for CI)
+  meow run workflow.toml --pause-on-failure  # Pause on a step failure to inspect it
+  meow run workflow.toml --dry-run    # Print the steps in dispatch order without running them
   meow run workflow.toml --var x=y    # Pass variables`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRun,
@@ -52,10 +58,14 @@ var (
 	runVarsJSON      []string
 	runWorkflow      string
 	runYes           bool
+	runWatch         bool
+	runSkipTo        string
+	runStrict        bool
+	runPauseOnFail   bool
 )
 
 func init() {
-	runCmd.Flags().BoolVar(&runDry, "dry-run", false, "validate and show what would be created without executing")
+	runCmd.Flags().BoolVar(&runDry, "dry-run", false, "print the execution plan without creating or running the workflow")
 	runCmd.Flags().BoolVarP(&runDetach, "detach", "d", false, "run in background (detached mode)")
 	runCmd.Flags().BoolVar(&runDetachedChild, "_detached-child", false, "internal: running as detached child")
 	runCmd.Flags().StringVar(&runWorkflowID, "_workflow-id", "", "internal: workflow ID for detached child")
@@ -67,6 +77,10 @@ func init() {
 	runCmd.Flags().StringArrayVar(&runVarsJSON, "var-json", nil, "variable with JSON value (format: name={...} or name=[...])")
 	runCmd.Flags().StringVar(&runWorkflow, "workflow", "main", "workflow name to run (default: main)")
 	runCmd.Flags().BoolVarP(&runYes, "yes", "y", false, "auto-confirm prompts (create .meow/ if missing)")
+	runCmd.Flags().BoolVar(&runWatch, "watch", false, "after completion, re-run steps whose declared inputs change")
+	runCmd.Flags().BoolVar(&runStrict, "strict", false, "exit non-zero when the workflow ends failed or stopped")
+	runCmd.Flags().BoolVar(&runPauseOnFail, "pause-on-failure", false, "pause the workflow when a step fails instead of failing it, to inspect and then resume (retry) or abort")
+	runCmd.Flags().StringVar(&runSkipTo, "skip-to", "", "mark the steps this step depends on done (with empty outputs) and start from it")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -75,6 +89,16 @@ func runRun(cmd *cobra.Command, args []string) error {
 	workflowName := runWorkflow
 	ctx := context.Background()
 
+	if runWatch && runDetach {
+		return fmt.Errorf("--watch cannot be combined with --detach")
+	}
+	if runDry && (runDetach || runWatch) {
+		return fmt.Errorf("--dry-run cannot be combined with --detach or --watch")
+	}
+	if runStrict && (runDetach || runWatch) {
+		return fmt.Errorf("--strict needs the workflow to finish in the foreground; it cannot be combined with --detach or --watch")
+	}
+
 	// Get working directory
 	dir, err := getWorkDir()
 	if err != nil {
@@ -105,6 +129,12 @@ func runRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	if err := cfg.Logging.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.Shell.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 
 	// Ensure runs directory exists
 	runsDir := cfg.RunsDir(dir)
@@ -228,7 +258,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	// Generate a unique workflow ID (or use passed ID for detached child)
 	workflowID := runWorkflowID
 	if workflowID == "" {
-		workflowID = fmt.Sprintf("run-%d", time.Now().UnixNano())
+		workflowID = orchestrator.GenerateRunIDWithScheme(cfg.Orchestrator.RunID, templatePath)
 	}
 
 	// Create baker
@@ -240,19 +270,6 @@ func runRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("baking workflow: %w", err)
 	}
 
-	if runDry {
-		fmt.Printf("Would create workflow with %d steps from template: %s (workflow: %s)\n", len(result.Steps), templatePath, workflowName)
-		fmt.Printf("Workflow ID: %s\n", result.WorkflowID)
-		fmt.Println()
-		for _, step := range result.Steps {
-			fmt.Printf("  %s [%s]\n", step.ID, step.Executor)
-			if len(step.Needs) > 0 {
-				fmt.Printf("    needs: %v\n", step.Needs)
-			}
-		}
-		return nil
-	}
-
 	// Handle detached mode: spawn child process and exit
 	if runDetach && !runDetachedChild {
 		return spawnDetachedOrchestrator(cfg, dir, templatePath, workflowID, workflowName, collectionDir)
@@ -270,6 +287,25 @@ func runRun(cmd *cobra.Command, args []string) error {
 	wf.CleanupOnSuccess = templateWorkflow.CleanupOnSuccess
 	wf.CleanupOnFailure = templateWorkflow.CleanupOnFailure
 	wf.CleanupOnStop = templateWorkflow.CleanupOnStop
+	wf.CleanupOrder = templateWorkflow.CleanupOrder
+	wf.CleanupWhen = templateWorkflow.CleanupWhen
+
+	// Agent lifecycle hooks, substituted at runtime like cleanup scripts
+	wf.BeforeAllAgents = templateWorkflow.BeforeAllAgents
+	wf.AfterAllAgents = templateWorkflow.AfterAllAgents
+
+	// Agent prompt wrapping (variables were substituted during baking)
+	wf.PromptPrefix = result.PromptPrefix
+	wf.PromptSuffix = result.PromptSuffix
+	wf.OutputDefs = result.Outputs
+	if result.RetryBudget > 0 {
+		budget := result.RetryBudget
+		wf.RetryBudget = &budget
+	}
+	wf.OutputBudget = result.OutputBudget
+	wf.Timeout = result.Timeout
+	wf.Requires = templateWorkflow.Requires
+	wf.PauseOnFailure = runPauseOnFail
 
 	// Add all steps to the workflow
 	for _, step := range result.Steps {
@@ -278,20 +314,28 @@ func runRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Skip upstream steps when iterating on a later one
+	var skipped []string
+	if runSkipTo != "" {
+		skipped, err = orchestrator.SkipTo(wf, runSkipTo)
+		if err != nil {
+			return fmt.Errorf("--skip-to: %w", err)
+		}
+	}
+
+	// Print the execution plan instead of persisting and running the workflow
+	if runDry {
+		fmt.Printf("Would create workflow with %d steps from template: %s (workflow: %s)\n", len(result.Steps), templatePath, workflowName)
+		fmt.Printf("Workflow ID: %s\n\n", result.WorkflowID)
+		return orchestrator.WritePlan(os.Stdout, wf)
+	}
+
 	// Create workflow store
 	store, err := orchestrator.NewYAMLRunStore(runsDir)
 	if err != nil {
 		return fmt.Errorf("opening workflow store: %w", err)
 	}
 
-	// Acquire per-workflow lock before persisting
-	// This prevents multiple orchestrators from running the same workflow
-	lock, err := store.AcquireWorkflowLock(workflowID)
-	if err != nil {
-		return fmt.Errorf("acquiring workflow lock: %w", err)
-	}
-	defer lock.Release()
-
 	// Persist the workflow
 	if err := store.Create(ctx, wf); err != nil {
 		return fmt.Errorf("creating workflow: %w", err)
@@ -300,6 +344,9 @@ func runRun(cmd *cobra.Command, args []string) error {
 	// Output success
 	fmt.Printf("Created workflow with %d steps from template: %s\n", len(result.Steps), filepath.Base(templatePath))
 	fmt.Printf("Workflow ID: %s\n", result.WorkflowID)
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %d steps before %s: %s\n", len(skipped), runSkipTo, strings.Join(skipped, ", "))
+	}
 	if verbose {
 		fmt.Println("\nSteps created:")
 		for _, step := range result.Steps {
@@ -330,9 +377,11 @@ func runRun(cmd *cobra.Command, args []string) error {
 	if verbose {
 		logLevel = slog.LevelDebug
 	}
-	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
+	// Per-executor overrides in [logging.executors] can raise or lower this
+	logger := logging.New(config.LoggingConfig{
+		Format:    config.LogFormatText,
+		Executors: cfg.Logging.Executors,
+	}, os.Stderr, logLevel)
 
 	// Create shell runner
 	shellRunner := orchestrator.NewDefaultShellRunner()
@@ -348,6 +397,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	agentManager := orchestrator.NewTmuxAgentManagerWithOptions(dir, nil, logger, orchestrator.AgentManagerOptions{
 		LoggingEnabled: cfg.Agent.IsLoggingEnabled(),
 		LogDir:         runLogDir,
+		StateDir:       runsDir,
 	})
 
 	// Create template expander with scope awareness
@@ -356,6 +406,29 @@ func runRun(cmd *cobra.Command, args []string) error {
 	// Create orchestrator with agent support
 	orch := orchestrator.New(cfg, store, agentManager, shellRunner, expander, logger)
 	orch.SetWorkflowID(workflowID)
+	orch.SetArtifactsDir(filepath.Join(cfg.ArtifactsDir(dir), workflowID))
+	orch.SetLocksDir(cfg.LocksDir(dir))
+	orch.SetInputsDir(dir)
+
+	// Emit OpenTelemetry spans when [tracing] is enabled
+	tp, shutdownTracing, err := newTracerProvider(cfg, dir, workflowID)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	if tp != nil {
+		orch.SetTracerProvider(tp)
+	}
+
+	// Send step metrics to statsd when [metrics] names an address
+	sink, closeMetrics, err := newMetricsSink(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeMetrics()
+	if sink != nil {
+		orch.SetMetricsSink(sink)
+	}
 
 	// Set up signal handling for graceful shutdown
 	ctx, cancel := context.WithCancel(ctx)
@@ -388,9 +461,19 @@ func runRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Run the orchestrator
-	if err := orch.Run(ctx); err != nil {
+	var runErr error
+	if runWatch {
+		fmt.Println("Watching step inputs for changes (Ctrl-C to exit)...")
+		runErr = orch.Watch(ctx, dir, orchestrator.DefaultWatchInterval)
+	} else {
+		runErr = orch.Run(ctx)
+	}
+	if err := runErr; err != nil {
 		if err == context.Canceled {
 			fmt.Println("Workflow cancelled.")
+			if runStrict {
+				return fmt.Errorf("workflow %s was cancelled", workflowID)
+			}
 			return nil
 		}
 		return fmt.Errorf("running workflow: %w", err)
@@ -404,11 +487,14 @@ func runRun(cmd *cobra.Command, args []string) error {
 
 	// Print final status
 	fmt.Printf("\nWorkflow %s: %s\n", workflowID, wf.Status)
+	printRunResults(wf)
 	if verbose || wf.Status == types.RunStatusFailed {
 		fmt.Println("\nStep results:")
 		for _, step := range wf.Steps {
 			status := string(step.Status)
-			if step.Error != nil {
+			if step.SkipReason != nil {
+				status = fmt.Sprintf("%s (%s)", status, step.SkipReason.Message)
+			} else if step.Error != nil {
 				status = fmt.Sprintf("%s (error: %s)", status, step.Error.Message)
 			}
 			fmt.Printf("  %s: %s\n", step.ID, status)
@@ -420,9 +506,32 @@ func runRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if runStrict && wf.Status != types.RunStatusDone {
+		return fmt.Errorf("workflow %s %s", workflowID, wf.Status)
+	}
 	return nil
 }
 
+// printRunResults prints a finished run's workflow-level error and its
+// declared workflow outputs.
+func printRunResults(wf *types.Run) {
+	if wf.Error != "" {
+		fmt.Printf("Error: %s\n", wf.Error)
+	}
+	if len(wf.Outputs) == 0 {
+		return
+	}
+	names := make([]string, 0, len(wf.Outputs))
+	for name := range wf.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println("\nOutputs:")
+	for _, name := range names {
+		fmt.Printf("  %s: %s\n", name, workflow.StringifyValue(wf.Outputs[name]))
+	}
+}
+
 // spawnDetachedOrchestrator spawns a child process to run the workflow in background
 func spawnDetachedOrchestrator(cfg *config.Config, dir, templatePath, workflowID, workflowName, collectionDir string) error {
 	// Build command args for the child process
@@ -436,6 +545,12 @@ func spawnDetachedOrchestrator(cfg *config.Config, dir, templatePath, workflowID
 	for _, v := range runVarsJSON {
 		args = append(args, "--var-json", v)
 	}
+	if runSkipTo != "" {
+		args = append(args, "--skip-to", runSkipTo)
+	}
+	if runPauseOnFail {
+		args = append(args, "--pause-on-failure")
+	}
 	if verbose {
 		args = append(args, "--verbose")
 	}
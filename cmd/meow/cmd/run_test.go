@@ -51,6 +51,24 @@ func TestRunFlags(t *testing.T) {
 			t.Errorf("--detach default should be false, got %s", flag.DefValue)
 		}
 	})
+	t.Run("--strict flag registered", func(t *testing.T) {
+		flag := runCmd.Flags().Lookup("strict")
+		if flag == nil {
+			t.Fatal("--strict flag not found")
+		}
+		if flag.DefValue != "false" {
+			t.Errorf("--strict default should be false, got %s", flag.DefValue)
+		}
+	})
+	t.Run("--skip-to flag registered", func(t *testing.T) {
+		flag := runCmd.Flags().Lookup("skip-to")
+		if flag == nil {
+			t.Fatal("--skip-to flag not found")
+		}
+		if flag.DefValue != "" {
+			t.Errorf("--skip-to default should be empty, got %q", flag.DefValue)
+		}
+	})
 }
 
 // TestSpawnDetachedOrchestratorArgs tests that the correct arguments are built
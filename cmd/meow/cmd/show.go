@@ -131,7 +131,7 @@ func outputShowJSON(module *workflow.Module, location *workflow.WorkflowLocation
 			Name:        name,
 			Description: wf.Description,
 			Internal:    wf.Internal,
-			HasCleanup:  wf.CleanupOnSuccess != "" || wf.CleanupOnFailure != "" || wf.CleanupOnStop != "",
+			HasCleanup:  wf.CleanupOnSuccess != "" || wf.CleanupOnFailure != "" || wf.CleanupOnStop != "" || len(wf.CleanupWhen) > 0,
 		}
 
 		// Variables
@@ -214,7 +214,7 @@ func outputShowText(module *workflow.Module, location *workflow.WorkflowLocation
 	printSteps(wf.Steps)
 
 	// Cleanup info
-	hasCleanup := wf.CleanupOnSuccess != "" || wf.CleanupOnFailure != "" || wf.CleanupOnStop != ""
+	hasCleanup := wf.CleanupOnSuccess != "" || wf.CleanupOnFailure != "" || wf.CleanupOnStop != "" || len(wf.CleanupWhen) > 0
 	if hasCleanup {
 		fmt.Println()
 		fmt.Print("Cleanup: ")
@@ -228,6 +228,9 @@ func outputShowText(module *workflow.Module, location *workflow.WorkflowLocation
 		if wf.CleanupOnStop != "" {
 			cleanups = append(cleanups, "on_stop")
 		}
+		if len(wf.CleanupWhen) > 0 {
+			cleanups = append(cleanups, fmt.Sprintf("%d conditional", len(wf.CleanupWhen)))
+		}
 		fmt.Println(strings.Join(cleanups, ", "))
 	}
 
@@ -361,6 +364,13 @@ func getStepDetail(step *workflow.Step) string {
 		if step.Agent != "" {
 			return fmt.Sprintf("agent=%s", step.Agent)
 		}
+		if step.RequiresCapability != "" {
+			return fmt.Sprintf("capability=%s", step.RequiresCapability)
+		}
+	case workflow.ExecutorGate:
+		if step.WaitForEvent != "" {
+			return fmt.Sprintf("event=%s", step.WaitForEvent)
+		}
 	case workflow.ExecutorBranch:
 		if step.Condition != "" {
 			cond := step.Condition
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/akatz-ai/meow/internal/orchestrator"
@@ -164,7 +165,7 @@ func displayWorkflowDetail(ctx context.Context, store *orchestrator.YAMLRunStore
 	}
 
 	// Check if this workflow is orphaned (running but no lock)
-	isOrphaned := wf.Status == types.RunStatusRunning && !store.IsLocked(wf.ID)
+	isOrphaned := hasOrchestrator(wf.Status) && !store.IsLocked(wf.ID)
 
 	summary := status.NewWorkflowSummary(wf)
 	opts := status.FormatOptions{
@@ -205,7 +206,7 @@ func displayWorkflowList(ctx context.Context, store *orchestrator.YAMLRunStore)
 	if statusFilter != "" {
 		filter.Status = types.RunStatus(statusFilter)
 		if !filter.Status.Valid() {
-			return fmt.Errorf("invalid status filter: %s (use: pending, running, done, failed, stopped)", statusFilter)
+			return fmt.Errorf("invalid status filter: %s (use: pending, running, paused, done, failed, stopped)", statusFilter)
 		}
 	}
 
@@ -216,9 +217,9 @@ func displayWorkflowList(ctx context.Context, store *orchestrator.YAMLRunStore)
 
 	// Detect orphaned runs (running but no lock) - show these regardless of filters
 	var orphaned []*types.Run
-	if statusFilter == "" || statusFilter == "running" {
+	if statusFilter == "" || statusFilter == "running" || statusFilter == "paused" {
 		for _, wf := range workflows {
-			if wf.Status == types.RunStatusRunning && !store.IsLocked(wf.ID) {
+			if hasOrchestrator(wf.Status) && !store.IsLocked(wf.ID) {
 				orphaned = append(orphaned, wf)
 			}
 		}
@@ -231,17 +232,17 @@ func displayWorkflowList(ctx context.Context, store *orchestrator.YAMLRunStore)
 	}
 
 	// Apply active filter by default (unless --all or --filter specified)
-	// Active = status=running AND lock held
+	// Active = status=running or paused AND lock held
 	// Also exclude orphaned runs from the main list to avoid double-counting
 	if !statusAll && statusFilter == "" {
 		active := make([]*types.Run, 0, len(workflows))
 		for _, wf := range workflows {
-			if wf.Status == types.RunStatusRunning && store.IsLocked(wf.ID) {
+			if hasOrchestrator(wf.Status) && store.IsLocked(wf.ID) {
 				active = append(active, wf)
 			}
 		}
 		workflows = active
-	} else if statusFilter == "running" {
+	} else if statusFilter == "running" || statusFilter == "paused" {
 		// When filtering by running, exclude orphaned from main list
 		filtered := make([]*types.Run, 0, len(workflows))
 		for _, wf := range workflows {
@@ -257,8 +258,8 @@ func displayWorkflowList(ctx context.Context, store *orchestrator.YAMLRunStore)
 		fmt.Println("⚠ Orphaned Workflows (no orchestrator):")
 		fmt.Println()
 		for _, wf := range orphaned {
-			fmt.Printf("  %-12s %-20s RUNNING (orphaned)   Started: %s\n",
-				wf.ID, filepath.Base(wf.Template), wf.StartedAt.Format("15:04"))
+			fmt.Printf("  %-12s %-20s %-7s (orphaned)   Started: %s\n",
+				wf.ID, filepath.Base(wf.Template), strings.ToUpper(string(wf.Status)), wf.StartedAt.Format("15:04"))
 		}
 		fmt.Println()
 		if len(orphaned) == 1 {
@@ -362,3 +363,9 @@ func displayWorkflowList(ctx context.Context, store *orchestrator.YAMLRunStore)
 
 	return nil
 }
+
+// hasOrchestrator reports whether a run with this status is driven by a live
+// orchestrator, which holds the run's lock. A paused run keeps its orchestrator.
+func hasOrchestrator(status types.RunStatus) bool {
+	return status == types.RunStatusRunning || status == types.RunStatusPaused
+}
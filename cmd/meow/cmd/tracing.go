@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/akatz-ai/meow/internal/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newTracerProvider creates the tracer provider for a run when [tracing] is
+// enabled, exporting spans as JSON to <traces_dir>/<run_id>.json. Spans from a
+// resumed run are appended to the same file. It returns a nil provider (tracing
+// disabled) and a no-op shutdown when tracing is off.
+func newTracerProvider(cfg *config.Config, dir, workflowID string) (*sdktrace.TracerProvider, func(), error) {
+	if !cfg.Tracing.Enabled {
+		return nil, func() {}, nil
+	}
+
+	tracesDir := cfg.TracesDir(dir)
+	if err := os.MkdirAll(tracesDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("creating traces dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(tracesDir, workflowID+".json"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening trace file: %w", err)
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("creating trace exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "meow"))),
+	)
+	shutdown := func() {
+		tp.Shutdown(context.Background())
+		f.Close()
+	}
+	return tp, shutdown, nil
+}
@@ -16,10 +16,14 @@ var validateCmd = &cobra.Command{
 
 Checks:
 - TOML syntax
-- Required fields
+- Known executors and their required fields
+- Dependencies (unknown steps, cycles)
+- Local template references (.workflow, .workflow.step)
 - Variable references
-- Dependency cycles
-- Output references`,
+- Output references ({{step.outputs.key}}) to unknown steps or undeclared keys
+
+All problems are reported, not just the first. External template references
+(file#workflow, collections) are resolved when the workflow runs.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runValidate,
 }
@@ -49,8 +53,8 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Validating template: %s\n", templatePath)
 
-	// Parse the module file
-	module, err := workflow.ParseModuleFile(templatePath)
+	// Parse without fail-fast validation so every problem is reported below
+	module, err := workflow.ParseModuleFileUnvalidated(templatePath)
 	if err != nil {
 		fmt.Printf("\n%s Parsing failed:\n", errorMark())
 		fmt.Printf("  %v\n", err)
@@ -429,3 +429,31 @@ func TestRegistryValidate_MissingCollectionManifest(t *testing.T) {
 		t.Errorf("Expected error about missing manifest, got: %s", errMsg)
 	}
 }
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.meow.toml")
+	content := `
+[main]
+name = "main"
+
+[[main.steps]]
+id = "build"
+executor = "shell"
+command = "make"
+needs = ["setup"]
+
+[[main.steps]]
+id = "build"
+executor = "shel"
+command = "make {{target}}"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dangling needs and the duplicate id used to stop parsing at the first error
+	err := runValidate(validateCmd, []string{path})
+	if err == nil || !strings.Contains(err.Error(), "validation failed with 4 error(s)") {
+		t.Errorf("runValidate() error = %v, want 4 errors reported", err)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -48,6 +51,20 @@ func NewTmuxWrapper(opts ...TmuxOption) *TmuxWrapper {
 	return w
 }
 
+// Available reports whether tmux can be used: the binary must be on PATH and,
+// when a custom socket is configured, the socket's directory must exist.
+func (w *TmuxWrapper) Available() error {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux not found on PATH")
+	}
+	if w.socketPath != "" {
+		if _, err := os.Stat(filepath.Dir(w.socketPath)); err != nil {
+			return fmt.Errorf("tmux socket directory for %s is not accessible: %w", w.socketPath, err)
+		}
+	}
+	return nil
+}
+
 // SessionOptions configures session creation.
 type SessionOptions struct {
 	Name    string            // Session name (required)
@@ -234,6 +251,8 @@ type CapturePaneOptions struct {
 	Start int  // Start line (negative = from bottom, 0 = visible start). Default: visible area.
 	End   int  // End line (negative = from bottom, -1 = last line). Default: visible area.
 	Escape bool // Include escape sequences (colors, etc.)
+	// History captures the whole scrollback plus the visible area, ignoring Start/End.
+	History bool
 }
 
 // CapturePaneWithOptions captures pane content with options.
@@ -245,7 +264,9 @@ func (w *TmuxWrapper) CapturePaneWithOptions(ctx context.Context, session string
 	args := []string{"capture-pane", "-t", session, "-p"} // -p prints to stdout
 
 	// Add start/end if specified
-	if opts.Start != 0 || opts.End != 0 {
+	if opts.History {
+		args = append(args, "-S", "-", "-E", "-")
+	} else if opts.Start != 0 || opts.End != 0 {
 		args = append(args, "-S", fmt.Sprintf("%d", opts.Start))
 		args = append(args, "-E", fmt.Sprintf("%d", opts.End))
 	}
@@ -263,6 +284,24 @@ func (w *TmuxWrapper) CapturePaneWithOptions(ctx context.Context, session string
 	return string(output), nil
 }
 
+// PanePID returns the process ID of the command running in a session's
+// pane (the process the session was started with).
+func (w *TmuxWrapper) PanePID(ctx context.Context, session string) (int, error) {
+	if session == "" {
+		return 0, fmt.Errorf("session name is required")
+	}
+
+	output, err := w.runCmd(ctx, "display-message", "-p", "-t", session, "#{pane_pid}")
+	if err != nil {
+		return 0, fmt.Errorf("display-message: %w: %s", err, output)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, fmt.Errorf("parsing pane pid %q: %w", output, err)
+	}
+	return pid, nil
+}
+
 // SetEnv sets an environment variable in a tmux session.
 // The variable will be available to new processes in the session.
 func (w *TmuxWrapper) SetEnv(ctx context.Context, session, key, value string) error {
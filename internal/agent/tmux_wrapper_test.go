@@ -114,6 +114,14 @@ func TestTmuxWrapper_CapturePane_EmptySession(t *testing.T) {
 	}
 }
 
+func TestTmuxWrapper_PanePID_EmptySession(t *testing.T) {
+	w := NewTmuxWrapper()
+	_, err := w.PanePID(context.Background(), "")
+	if err == nil {
+		t.Error("PanePID() should fail with empty session")
+	}
+}
+
 func TestTmuxWrapper_SetEnv_EmptySession(t *testing.T) {
 	w := NewTmuxWrapper()
 	err := w.SetEnv(context.Background(), "", "KEY", "VALUE")
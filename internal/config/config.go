@@ -2,8 +2,12 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -37,6 +41,26 @@ type AgentConfig struct {
 	// output is captured to a log file in .meow/logs/<run_id>/<agent_id>.log.
 	// Default: true
 	Logging *bool `toml:"logging"`
+
+	// MaxValidationRetries is how many times an agent whose `meow done`
+	// outputs fail validation is re-prompted with the errors before the step
+	// fails. 0 fails the step on the first invalid outputs.
+	// Default: 3
+	MaxValidationRetries *int `toml:"max_validation_retries"`
+
+	// PromptRecoveryRetries is how many times a prompt the agent never
+	// acknowledges (no prompt-received event) is re-injected before a
+	// prompt-swallowed event is emitted. Default: 1
+	PromptRecoveryRetries *int `toml:"prompt_recovery_retries"`
+
+	// PromptRecoveryBackoff is how long to wait for acknowledgment after the
+	// first re-injection; each later re-injection waits twice as long, up to
+	// PromptRecoveryMaxBackoff. Default: half the acknowledgment timeout
+	PromptRecoveryBackoff time.Duration `toml:"prompt_recovery_backoff"`
+
+	// PromptRecoveryMaxBackoff caps the wait after a re-injection.
+	// Default: 30s
+	PromptRecoveryMaxBackoff time.Duration `toml:"prompt_recovery_max_backoff"`
 }
 
 // IsLoggingEnabled returns whether agent logging is enabled (default: true).
@@ -47,22 +71,181 @@ func (c *AgentConfig) IsLoggingEnabled() bool {
 	return *c.Logging
 }
 
+// DefaultMaxValidationRetries is the validation retry limit when
+// max_validation_retries is not set.
+const DefaultMaxValidationRetries = 3
+
+// ValidationRetryLimit returns the max_validation_retries setting (default: 3).
+func (c *AgentConfig) ValidationRetryLimit() int {
+	if c.MaxValidationRetries == nil {
+		return DefaultMaxValidationRetries
+	}
+	return *c.MaxValidationRetries
+}
+
+// Defaults for prompt recovery when its settings are not set.
+const (
+	DefaultPromptRecoveryRetries    = 1
+	DefaultPromptRecoveryMaxBackoff = 30 * time.Second
+)
+
+// PromptRecoveryRetryLimit returns the prompt_recovery_retries setting (default: 1).
+func (c *AgentConfig) PromptRecoveryRetryLimit() int {
+	if c.PromptRecoveryRetries == nil {
+		return DefaultPromptRecoveryRetries
+	}
+	return *c.PromptRecoveryRetries
+}
+
 // PathsConfig holds path configuration.
 type PathsConfig struct {
-	WorkflowDir string `toml:"workflow_dir"`
-	RunsDir     string `toml:"runs_dir"`
-	LogsDir     string `toml:"logs_dir"`
+	WorkflowDir  string `toml:"workflow_dir"`
+	RunsDir      string `toml:"runs_dir"`
+	LogsDir      string `toml:"logs_dir"`
+	ArtifactsDir string `toml:"artifacts_dir"`
+	TracesDir    string `toml:"traces_dir"`
+	LocksDir     string `toml:"locks_dir"`
 }
 
 // OrchestratorConfig holds orchestrator settings.
 type OrchestratorConfig struct {
 	PollInterval time.Duration `toml:"poll_interval"`
+	RunID        RunIDConfig   `toml:"run_id"`
+
+	// MaxPollInterval caps the backoff of the poll interval while the
+	// orchestrator is idle: each tick that changes nothing doubles the
+	// interval up to this, and activity resets it to PollInterval. Default:
+	// 1s. 0 disables the backoff.
+	MaxPollInterval time.Duration `toml:"max_poll_interval"`
+
+	// MaxConcurrentAgents caps the agent steps running at once across all
+	// workflows this orchestrator processes. Default: 0 (unlimited).
+	MaxConcurrentAgents int `toml:"max_concurrent_agents"`
+
+	// TimeoutGracePeriod is how long a timed-out agent step has to stop after
+	// its agent is sent C-c before the step is marked failed. Default: 10s.
+	TimeoutGracePeriod time.Duration `toml:"timeout_grace_period"`
+}
+
+// DefaultMaxPollInterval is the max_poll_interval used when it is not set.
+const DefaultMaxPollInterval = time.Second
+
+// DefaultTimeoutGracePeriod is the timeout_grace_period used when it is not set.
+const DefaultTimeoutGracePeriod = 10 * time.Second
+
+// RunIDTimestamp specifies how the creation time is embedded in run IDs.
+type RunIDTimestamp string
+
+const (
+	RunIDTimestampHex      RunIDTimestamp = "hex"      // Nanoseconds in hex (default)
+	RunIDTimestampDatetime RunIDTimestamp = "datetime" // UTC, e.g. 20260102-150405 (human-readable, sortable)
+	RunIDTimestampNone     RunIDTimestamp = "none"     // No timestamp
+)
+
+// RunIDConfig controls how run IDs are generated.
+// IDs are {prefix}[-{template}][-{timestamp}]-{random}; the random suffix
+// always keeps IDs unique regardless of the other settings.
+type RunIDConfig struct {
+	// Prefix starts every run ID. Default: "run".
+	Prefix string `toml:"prefix"`
+
+	// IncludeTemplate embeds the template name after the prefix.
+	IncludeTemplate bool `toml:"include_template"`
+
+	// Timestamp selects the timestamp format: hex (default), datetime, or none.
+	Timestamp RunIDTimestamp `toml:"timestamp"`
+}
+
+// TracingConfig holds OpenTelemetry tracing settings.
+type TracingConfig struct {
+	// Enabled emits a span per run, step, and agent interaction, written as
+	// JSON to <traces_dir>/<run_id>.json. Default: false.
+	Enabled bool `toml:"enabled"`
+}
+
+// MetricsConfig holds step metrics settings.
+type MetricsConfig struct {
+	// StatsdAddr, when set, sends step counters and durations as statsd lines
+	// over UDP to this host:port. Default: empty (no metrics).
+	StatsdAddr string `toml:"statsd_addr"`
+
+	// Prefix starts every metric name. Default: "meow".
+	Prefix string `toml:"prefix"`
+}
+
+// Validate checks that the statsd address is a host:port.
+func (c *MetricsConfig) Validate() error {
+	if c.StatsdAddr == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.StatsdAddr); err != nil {
+		return fmt.Errorf("metrics.statsd_addr must be host:port: %w", err)
+	}
+	return nil
+}
+
+// ShellConfig holds settings for shell steps.
+type ShellConfig struct {
+	// ErrorPatterns classify failed shell steps by their stderr. The first
+	// pattern that matches sets the step error's type in place of
+	// command_failed, so failures can be told apart (e.g., "permission").
+	ErrorPatterns []ErrorPattern `toml:"error_patterns"`
+}
+
+// ErrorPattern maps stderr matching a regular expression to an error type.
+type ErrorPattern struct {
+	Match string `toml:"match"`
+	Type  string `toml:"type"`
+}
+
+// Validate checks that every error pattern compiles and names a type.
+func (c *ShellConfig) Validate() error {
+	for i, p := range c.ErrorPatterns {
+		if p.Type == "" {
+			return fmt.Errorf("shell.error_patterns[%d]: type is required", i)
+		}
+		if _, err := regexp.Compile(p.Match); err != nil {
+			return fmt.Errorf("shell.error_patterns[%d]: invalid match: %w", i, err)
+		}
+	}
+	return nil
 }
 
 // LoggingConfig holds logging settings.
 type LoggingConfig struct {
 	Level  LogLevel  `toml:"level"`
 	Format LogFormat `toml:"format"`
+
+	// Executors overrides the level for logs about steps of a given executor
+	// (e.g., shell = "warn", agent = "debug").
+	Executors map[string]LogLevel `toml:"executors"`
+}
+
+// logExecutors are the executor names accepted in [logging.executors].
+var logExecutors = []string{"shell", "spawn", "kill", "expand", "branch", "foreach", "gate", "agent"}
+
+// Validate checks the log levels and executor names.
+func (c *LoggingConfig) Validate() error {
+	if !c.Level.valid() {
+		return fmt.Errorf("logging.level must be debug, info, warn, or error, got %q", c.Level)
+	}
+	for name, level := range c.Executors {
+		if !slices.Contains(logExecutors, name) {
+			return fmt.Errorf("logging.executors: unknown executor %q (want one of %s)", name, strings.Join(logExecutors, ", "))
+		}
+		if !level.valid() {
+			return fmt.Errorf("logging.executors.%s must be debug, info, warn, or error, got %q", name, level)
+		}
+	}
+	return nil
+}
+
+func (l LogLevel) valid() bool {
+	switch l {
+	case "", LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+		return true
+	}
+	return false
 }
 
 // Config is the main configuration struct for MEOW.
@@ -72,6 +255,9 @@ type Config struct {
 	Orchestrator OrchestratorConfig `toml:"orchestrator"`
 	Logging      LoggingConfig      `toml:"logging"`
 	Agent        AgentConfig        `toml:"agent"`
+	Tracing      TracingConfig      `toml:"tracing"`
+	Metrics      MetricsConfig      `toml:"metrics"`
+	Shell        ShellConfig        `toml:"shell"`
 }
 
 // Default returns a Config with sensible defaults.
@@ -79,12 +265,17 @@ func Default() *Config {
 	return &Config{
 		Version: "1",
 		Paths: PathsConfig{
-			WorkflowDir: ".meow/workflows",
-			RunsDir:     ".meow/runs",
-			LogsDir:     ".meow/logs",
+			WorkflowDir:  ".meow/workflows",
+			RunsDir:      ".meow/runs",
+			LogsDir:      ".meow/logs",
+			ArtifactsDir: ".meow/artifacts",
+			TracesDir:    ".meow/traces",
+			LocksDir:     ".meow/locks",
 		},
 		Orchestrator: OrchestratorConfig{
-			PollInterval: 100 * time.Millisecond,
+			PollInterval:       100 * time.Millisecond,
+			MaxPollInterval:    DefaultMaxPollInterval,
+			TimeoutGracePeriod: DefaultTimeoutGracePeriod,
 		},
 		Logging: LoggingConfig{
 			Level:  LogLevelInfo,
@@ -154,9 +345,58 @@ func (c *Config) Validate() error {
 	if c.Orchestrator.PollInterval <= 0 {
 		return fmt.Errorf("poll_interval must be positive")
 	}
+	if c.Orchestrator.MaxPollInterval != 0 && c.Orchestrator.MaxPollInterval < c.Orchestrator.PollInterval {
+		return fmt.Errorf("max_poll_interval must not be less than poll_interval (0 disables the backoff)")
+	}
+	if c.Orchestrator.MaxConcurrentAgents < 0 {
+		return fmt.Errorf("max_concurrent_agents must not be negative")
+	}
+	if c.Orchestrator.TimeoutGracePeriod < 0 {
+		return fmt.Errorf("timeout_grace_period must not be negative")
+	}
+	if c.Agent.MaxValidationRetries != nil && *c.Agent.MaxValidationRetries < 0 {
+		return fmt.Errorf("max_validation_retries must not be negative")
+	}
+	if c.Agent.PromptRecoveryRetries != nil && *c.Agent.PromptRecoveryRetries < 0 {
+		return fmt.Errorf("prompt_recovery_retries must not be negative")
+	}
+	if c.Agent.PromptRecoveryBackoff < 0 || c.Agent.PromptRecoveryMaxBackoff < 0 {
+		return fmt.Errorf("prompt_recovery_backoff and prompt_recovery_max_backoff must not be negative")
+	}
+	if err := c.Orchestrator.RunID.Validate(); err != nil {
+		return err
+	}
+	if err := c.Logging.Validate(); err != nil {
+		return err
+	}
+	if err := c.Metrics.Validate(); err != nil {
+		return err
+	}
+	if err := c.Shell.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// Validate checks that the run ID scheme produces filesystem-safe IDs.
+func (c *RunIDConfig) Validate() error {
+	for _, r := range c.Prefix {
+		if !isRunIDChar(r) {
+			return fmt.Errorf("run_id.prefix %q may only contain letters, digits, '-' and '_'", c.Prefix)
+		}
+	}
+	switch c.Timestamp {
+	case "", RunIDTimestampHex, RunIDTimestampDatetime, RunIDTimestampNone:
+	default:
+		return fmt.Errorf("run_id.timestamp must be hex, datetime, or none, got %q", c.Timestamp)
+	}
+	return nil
+}
+
+func isRunIDChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_'
+}
+
 // WorkflowDir returns the absolute workflow directory path.
 func (c *Config) WorkflowDir(baseDir string) string {
 	if filepath.IsAbs(c.Paths.WorkflowDir) {
@@ -180,3 +420,27 @@ func (c *Config) LogsDir(baseDir string) string {
 	}
 	return filepath.Join(baseDir, c.Paths.LogsDir)
 }
+
+// ArtifactsDir returns the absolute artifacts directory path.
+func (c *Config) ArtifactsDir(baseDir string) string {
+	if filepath.IsAbs(c.Paths.ArtifactsDir) {
+		return c.Paths.ArtifactsDir
+	}
+	return filepath.Join(baseDir, c.Paths.ArtifactsDir)
+}
+
+// TracesDir returns the absolute traces directory path.
+func (c *Config) TracesDir(baseDir string) string {
+	if filepath.IsAbs(c.Paths.TracesDir) {
+		return c.Paths.TracesDir
+	}
+	return filepath.Join(baseDir, c.Paths.TracesDir)
+}
+
+// LocksDir returns the absolute directory path for step lock files.
+func (c *Config) LocksDir(baseDir string) string {
+	if filepath.IsAbs(c.Paths.LocksDir) {
+		return c.Paths.LocksDir
+	}
+	return filepath.Join(baseDir, c.Paths.LocksDir)
+}
@@ -25,6 +25,9 @@ func TestDefault(t *testing.T) {
 	if cfg.Orchestrator.PollInterval != 100*time.Millisecond {
 		t.Errorf("PollInterval = %v, want 100ms", cfg.Orchestrator.PollInterval)
 	}
+	if cfg.Orchestrator.MaxPollInterval != time.Second {
+		t.Errorf("MaxPollInterval = %v, want 1s", cfg.Orchestrator.MaxPollInterval)
+	}
 	if cfg.Logging.Level != LogLevelInfo {
 		t.Errorf("Logging.Level = %s, want info", cfg.Logging.Level)
 	}
@@ -48,6 +51,7 @@ logs_dir = "custom/logs"
 
 [orchestrator]
 poll_interval = "200ms"
+max_poll_interval = "2s"
 
 [logging]
 level = "debug"
@@ -55,6 +59,10 @@ format = "text"
 
 [agent]
 default_adapter = "aider"
+
+[metrics]
+statsd_addr = "127.0.0.1:8125"
+prefix = "ci.meow"
 `
 
 	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
@@ -75,12 +83,18 @@ default_adapter = "aider"
 	if cfg.Orchestrator.PollInterval != 200*time.Millisecond {
 		t.Errorf("PollInterval = %v, want 200ms", cfg.Orchestrator.PollInterval)
 	}
+	if cfg.Orchestrator.MaxPollInterval != 2*time.Second {
+		t.Errorf("MaxPollInterval = %v, want 2s", cfg.Orchestrator.MaxPollInterval)
+	}
 	if cfg.Logging.Level != LogLevelDebug {
 		t.Errorf("Logging.Level = %s, want debug", cfg.Logging.Level)
 	}
 	if cfg.Agent.DefaultAdapter != "aider" {
 		t.Errorf("Agent.DefaultAdapter = %s, want aider", cfg.Agent.DefaultAdapter)
 	}
+	if cfg.Metrics.StatsdAddr != "127.0.0.1:8125" || cfg.Metrics.Prefix != "ci.meow" {
+		t.Errorf("Metrics = %+v, want statsd_addr 127.0.0.1:8125 and prefix ci.meow", cfg.Metrics)
+	}
 }
 
 func TestLoad_NonExistent(t *testing.T) {
@@ -254,6 +268,169 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "max_poll_interval below poll_interval",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Second, MaxPollInterval: time.Millisecond},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max_concurrent_agents",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond, MaxConcurrentAgents: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative timeout_grace_period",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond, TimeoutGracePeriod: -time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "metrics statsd_addr without port",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Metrics:      MetricsConfig{StatsdAddr: "localhost"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max_validation_retries",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Agent:        AgentConfig{MaxValidationRetries: func() *int { n := -1; return &n }()},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative prompt_recovery_retries",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Agent:        AgentConfig{PromptRecoveryRetries: func() *int { n := -1; return &n }()},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative prompt_recovery_max_backoff",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Agent:        AgentConfig{PromptRecoveryMaxBackoff: -time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid run_id scheme",
+			cfg: &Config{
+				Version: "1",
+				Paths:   PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{
+					PollInterval: time.Millisecond,
+					RunID:        RunIDConfig{Prefix: "nightly_ci", IncludeTemplate: true, Timestamp: RunIDTimestampDatetime},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "run_id prefix with path separator",
+			cfg: &Config{
+				Version: "1",
+				Paths:   PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{
+					PollInterval: time.Millisecond,
+					RunID:        RunIDConfig{Prefix: "a/b"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown run_id timestamp",
+			cfg: &Config{
+				Version: "1",
+				Paths:   PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{
+					PollInterval: time.Millisecond,
+					RunID:        RunIDConfig{Timestamp: "unix"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid executor log levels",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Logging:      LoggingConfig{Executors: map[string]LogLevel{"shell": LogLevelWarn, "agent": LogLevelDebug}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown executor in log levels",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Logging:      LoggingConfig{Executors: map[string]LogLevel{"approval": LogLevelWarn}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid executor log level",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Logging:      LoggingConfig{Executors: map[string]LogLevel{"shell": "quiet"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid shell error patterns",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Shell:        ShellConfig{ErrorPatterns: []ErrorPattern{{Match: "(?i)permission denied", Type: "permission"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "shell error pattern with invalid regex",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Shell:        ShellConfig{ErrorPatterns: []ErrorPattern{{Match: "(unclosed", Type: "permission"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "shell error pattern without type",
+			cfg: &Config{
+				Version:      "1",
+				Paths:        PathsConfig{WorkflowDir: "a"},
+				Orchestrator: OrchestratorConfig{PollInterval: time.Millisecond},
+				Shell:        ShellConfig{ErrorPatterns: []ErrorPattern{{Match: "denied"}}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
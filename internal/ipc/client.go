@@ -89,6 +89,21 @@ func (c *Client) SendStepDone(workflow, agent, step string, outputs map[string]a
 	return c.Send(msg)
 }
 
+// SendStepFailed sends a step completion message reporting that the agent
+// failed the step with the given error message.
+// Returns the parsed response (AckMessage or ErrorMessage).
+func (c *Client) SendStepFailed(workflow, agent, step, errMsg, notes string) (any, error) {
+	msg := &StepDoneMessage{
+		Type:     MsgStepDone,
+		Workflow: workflow,
+		Agent:    agent,
+		Step:     step,
+		Notes:    notes,
+		Error:    errMsg,
+	}
+	return c.Send(msg)
+}
+
 // GetSessionID requests the Claude session ID for an agent.
 func (c *Client) GetSessionID(agent string) (string, error) {
 	msg := &GetSessionIDMessage{
@@ -185,3 +200,39 @@ func (c *Client) GetStepStatus(workflow, stepID string) (string, error) {
 		return "", fmt.Errorf("unexpected response type: %T", response)
 	}
 }
+
+// Pause asks the orchestrator to pause a running workflow.
+func (c *Client) Pause(workflow string) error {
+	return c.sendAcked(&PauseMessage{Type: MsgPause, Workflow: workflow})
+}
+
+// Resume asks the orchestrator to resume a paused workflow.
+func (c *Client) Resume(workflow string) error {
+	return c.sendAcked(&ResumeMessage{Type: MsgResume, Workflow: workflow})
+}
+
+// Abort asks the orchestrator to resume a workflow paused on a failure
+// without retrying the failed steps.
+func (c *Client) Abort(workflow string) error {
+	return c.sendAcked(&ResumeMessage{Type: MsgResume, Workflow: workflow, Abort: true})
+}
+
+// sendAcked sends a request whose response is an acknowledgment.
+func (c *Client) sendAcked(msg any) error {
+	response, err := c.Send(msg)
+	if err != nil {
+		return err
+	}
+
+	switch r := response.(type) {
+	case *AckMessage:
+		if !r.Success {
+			return fmt.Errorf("request was not acknowledged")
+		}
+		return nil
+	case *ErrorMessage:
+		return fmt.Errorf("%s", r.Message)
+	default:
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+}
@@ -19,6 +19,8 @@ const (
 	MsgEvent         MessageType = "event"
 	MsgAwaitEvent    MessageType = "await_event"
 	MsgGetStepStatus MessageType = "get_step_status"
+	MsgPause         MessageType = "pause"
+	MsgResume        MessageType = "resume"
 
 	// Response types (orchestrator → agent)
 	MsgAck        MessageType = "ack"
@@ -33,6 +35,7 @@ func (t MessageType) Valid() bool {
 	switch t {
 	case MsgStepDone, MsgGetSessionID,
 		MsgEvent, MsgAwaitEvent, MsgGetStepStatus,
+		MsgPause, MsgResume,
 		MsgAck, MsgError, MsgSessionID,
 		MsgEventMatch, MsgStepStatus:
 		return true
@@ -44,7 +47,8 @@ func (t MessageType) Valid() bool {
 func (t MessageType) IsRequest() bool {
 	switch t {
 	case MsgStepDone, MsgGetSessionID,
-		MsgEvent, MsgAwaitEvent, MsgGetStepStatus:
+		MsgEvent, MsgAwaitEvent, MsgGetStepStatus,
+		MsgPause, MsgResume:
 		return true
 	}
 	return false
@@ -71,6 +75,9 @@ type StepDoneMessage struct {
 	Step     string         `json:"step"`
 	Outputs  map[string]any `json:"outputs,omitempty"`
 	Notes    string         `json:"notes,omitempty"`
+	// Error reports that the agent could not do the step (meow done
+	// --error): the step fails with this message instead of completing.
+	Error string `json:"error,omitempty"`
 }
 
 // GetSessionIDMessage requests the Claude session ID for an agent.
@@ -113,6 +120,21 @@ type GetStepStatusMessage struct {
 	StepID   string      `json:"step_id"`  // Step ID to query
 }
 
+// PauseMessage holds a running workflow: no new steps are dispatched.
+// Sent by: meow pause
+type PauseMessage struct {
+	Type     MessageType `json:"type"`     // Always "pause"
+	Workflow string      `json:"workflow"` // Workflow ID
+}
+
+// ResumeMessage returns a paused workflow to running.
+// Sent by: meow resume
+type ResumeMessage struct {
+	Type     MessageType `json:"type"`            // Always "resume"
+	Workflow string      `json:"workflow"`        // Workflow ID
+	Abort    bool        `json:"abort,omitempty"` // Let the failures that paused it stand instead of retrying them
+}
+
 // --- Response Messages (orchestrator → agent) ---
 
 // AckMessage confirms successful operation.
@@ -163,6 +185,8 @@ func (m *GetSessionIDMessage) MessageType() MessageType  { return MsgGetSessionI
 func (m *EventMessage) MessageType() MessageType         { return MsgEvent }
 func (m *AwaitEventMessage) MessageType() MessageType    { return MsgAwaitEvent }
 func (m *GetStepStatusMessage) MessageType() MessageType { return MsgGetStepStatus }
+func (m *PauseMessage) MessageType() MessageType         { return MsgPause }
+func (m *ResumeMessage) MessageType() MessageType        { return MsgResume }
 func (m *AckMessage) MessageType() MessageType           { return MsgAck }
 func (m *ErrorMessage) MessageType() MessageType         { return MsgError }
 func (m *SessionIDMessage) MessageType() MessageType     { return MsgSessionID }
@@ -196,6 +220,10 @@ func ParseMessage(data []byte) (Message, error) {
 		msg = &AwaitEventMessage{}
 	case MsgGetStepStatus:
 		msg = &GetStepStatusMessage{}
+	case MsgPause:
+		msg = &PauseMessage{}
+	case MsgResume:
+		msg = &ResumeMessage{}
 	case MsgAck:
 		msg = &AckMessage{}
 	case MsgError:
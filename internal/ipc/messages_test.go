@@ -16,6 +16,8 @@ func TestMessageType_Valid(t *testing.T) {
 		{MsgEvent, true},
 		{MsgAwaitEvent, true},
 		{MsgGetStepStatus, true},
+		{MsgPause, true},
+		{MsgResume, true},
 		{MsgAck, true},
 		{MsgError, true},
 		{MsgSessionID, true},
@@ -33,7 +35,7 @@ func TestMessageType_Valid(t *testing.T) {
 }
 
 func TestMessageType_IsRequest(t *testing.T) {
-	requests := []MessageType{MsgStepDone, MsgGetSessionID, MsgEvent, MsgAwaitEvent, MsgGetStepStatus}
+	requests := []MessageType{MsgStepDone, MsgGetSessionID, MsgEvent, MsgAwaitEvent, MsgGetStepStatus, MsgPause, MsgResume}
 	responses := []MessageType{MsgAck, MsgError, MsgSessionID, MsgEventMatch, MsgStepStatus}
 
 	for _, mt := range requests {
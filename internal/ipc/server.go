@@ -41,6 +41,14 @@ type Handler interface {
 	// HandleGetStepStatus returns the status of a step.
 	// Returns a StepStatusMessage or ErrorMessage.
 	HandleGetStepStatus(ctx context.Context, msg *GetStepStatusMessage) any
+
+	// HandlePause pauses a running workflow.
+	// Returns an AckMessage or ErrorMessage.
+	HandlePause(ctx context.Context, msg *PauseMessage) any
+
+	// HandleResume resumes a paused workflow.
+	// Returns an AckMessage or ErrorMessage.
+	HandleResume(ctx context.Context, msg *ResumeMessage) any
 }
 
 // Server listens for IPC messages on a Unix domain socket.
@@ -261,6 +269,14 @@ func (s *Server) handleMessage(ctx context.Context, data []byte) any {
 		s.logger.Debug("handling get_step_status", "workflow", m.Workflow, "step_id", m.StepID)
 		return s.handler.HandleGetStepStatus(ctx, m)
 
+	case *PauseMessage:
+		s.logger.Debug("handling pause", "workflow", m.Workflow)
+		return s.handler.HandlePause(ctx, m)
+
+	case *ResumeMessage:
+		s.logger.Debug("handling resume", "workflow", m.Workflow)
+		return s.handler.HandleResume(ctx, m)
+
 	default:
 		s.logger.Error("unexpected message type", "type", fmt.Sprintf("%T", msg))
 		return &ErrorMessage{
@@ -18,6 +18,8 @@ type mockHandler struct {
 	eventCalls         []*EventMessage
 	awaitEventCalls    []*AwaitEventMessage
 	getStepStatusCalls []*GetStepStatusMessage
+	pauseCalls         []*PauseMessage
+	resumeCalls        []*ResumeMessage
 
 	// Configurable responses
 	stepDoneResponse      any
@@ -25,6 +27,8 @@ type mockHandler struct {
 	eventResponse         any
 	awaitEventResponse    any
 	getStepStatusResponse any
+	pauseResponse         any
+	resumeResponse        any
 }
 
 func newMockHandler() *mockHandler {
@@ -34,6 +38,8 @@ func newMockHandler() *mockHandler {
 		eventResponse:         &AckMessage{Type: MsgAck, Success: true},
 		awaitEventResponse:    &EventMatchMessage{Type: MsgEventMatch, EventType: "test", Data: nil, Timestamp: 0},
 		getStepStatusResponse: &StepStatusMessage{Type: MsgStepStatus, StepID: "step-1", Status: "done"},
+		pauseResponse:         &AckMessage{Type: MsgAck, Success: true},
+		resumeResponse:        &AckMessage{Type: MsgAck, Success: true},
 	}
 }
 
@@ -72,6 +78,20 @@ func (h *mockHandler) HandleGetStepStatus(ctx context.Context, msg *GetStepStatu
 	return h.getStepStatusResponse
 }
 
+func (h *mockHandler) HandlePause(ctx context.Context, msg *PauseMessage) any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pauseCalls = append(h.pauseCalls, msg)
+	return h.pauseResponse
+}
+
+func (h *mockHandler) HandleResume(ctx context.Context, msg *ResumeMessage) any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resumeCalls = append(h.resumeCalls, msg)
+	return h.resumeResponse
+}
+
 func TestSocketPath(t *testing.T) {
 	path := SocketPath("run-abc123")
 	expected := filepath.Join(os.TempDir(), "meow-run-abc123.sock")
@@ -177,6 +197,41 @@ func TestServer_HandleStepDone(t *testing.T) {
 	}
 }
 
+func TestServer_HandleStepFailed(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	handler := newMockHandler()
+	server := NewServerWithPath(socketPath, handler, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := server.StartAsync(ctx); err != nil {
+		t.Fatalf("StartAsync() error: %v", err)
+	}
+	defer server.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	client := NewClient(socketPath)
+	client.SetTimeout(5 * time.Second)
+
+	response, err := client.SendStepFailed("run-test", "agent-1", "step-1", "tests do not compile", "")
+	if err != nil {
+		t.Fatalf("SendStepFailed() error: %v", err)
+	}
+	if _, ok := response.(*AckMessage); !ok {
+		t.Fatalf("response is %T, want *AckMessage", response)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if len(handler.stepDoneCalls) != 1 {
+		t.Fatalf("stepDoneCalls = %d, want 1", len(handler.stepDoneCalls))
+	}
+	if call := handler.stepDoneCalls[0]; call.Error != "tests do not compile" || call.Outputs != nil {
+		t.Errorf("step_done = %+v, want the error and no outputs", call)
+	}
+}
+
 func TestServer_HandleGetSessionID(t *testing.T) {
 	socketPath := filepath.Join(t.TempDir(), "test.sock")
 	handler := newMockHandler()
@@ -204,6 +259,41 @@ func TestServer_HandleGetSessionID(t *testing.T) {
 	}
 }
 
+func TestServer_HandlePauseResume(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	handler := newMockHandler()
+	handler.resumeResponse = &ErrorMessage{Type: MsgError, Message: "cannot resume run in status running"}
+	server := NewServerWithPath(socketPath, handler, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := server.StartAsync(ctx); err != nil {
+		t.Fatalf("StartAsync() error: %v", err)
+	}
+	defer server.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	client := NewClient(socketPath)
+	client.SetTimeout(5 * time.Second)
+
+	if err := client.Pause("run-test"); err != nil {
+		t.Fatalf("Pause() error: %v", err)
+	}
+	err := client.Resume("run-test")
+	if err == nil || err.Error() != "cannot resume run in status running" {
+		t.Errorf("Resume() error = %v, want the handler's error", err)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if len(handler.pauseCalls) != 1 || handler.pauseCalls[0].Workflow != "run-test" {
+		t.Errorf("pauseCalls = %+v, want one for run-test", handler.pauseCalls)
+	}
+	if len(handler.resumeCalls) != 1 || handler.resumeCalls[0].Workflow != "run-test" {
+		t.Errorf("resumeCalls = %+v, want one for run-test", handler.resumeCalls)
+	}
+}
+
 func TestServer_ErrorResponse(t *testing.T) {
 	socketPath := filepath.Join(t.TempDir(), "test.sock")
 	handler := newMockHandler()
@@ -2,6 +2,7 @@
 package logging
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"os"
@@ -13,15 +14,11 @@ import (
 // NewFromConfig creates a new slog.Logger based on configuration.
 // Per-run logs should be created separately using NewForRun.
 func NewFromConfig(cfg *config.Config, baseDir string) (*slog.Logger, io.Closer, error) {
-	level := parseLevel(cfg.Logging.Level)
-	handler := newHandler(cfg.Logging.Format, os.Stderr, level)
-	return slog.New(handler), nil, nil
+	return newConfigured(cfg.Logging, os.Stderr, parseLevel(cfg.Logging.Level)), nil, nil
 }
 
 // NewForRun creates a logger for a specific run that writes to both stderr and a run-specific log file.
 func NewForRun(cfg *config.Config, baseDir, runID string) (*slog.Logger, io.Closer, error) {
-	level := parseLevel(cfg.Logging.Level)
-
 	// Create log file path in logs directory
 	logsDir := cfg.LogsDir(baseDir)
 	logPath := filepath.Join(logsDir, runID+".log")
@@ -39,9 +36,28 @@ func NewForRun(cfg *config.Config, baseDir, runID string) (*slog.Logger, io.Clos
 
 	// Create multi-writer for both stderr and file
 	multi := io.MultiWriter(os.Stderr, file)
-	handler := newHandler(cfg.Logging.Format, multi, level)
 
-	return slog.New(handler), file, nil
+	return newConfigured(cfg.Logging, multi, parseLevel(cfg.Logging.Level)), file, nil
+}
+
+// New creates a logger writing to w at the given level, in the configured
+// format. The underlying handler also admits the levels of any per-executor
+// overrides, so loggers derived with WithLevel can be more verbose.
+func New(cfg config.LoggingConfig, w io.Writer, level slog.Level) *slog.Logger {
+	return newConfigured(cfg, w, level)
+}
+
+// newConfigured builds a handler at the lowest level any executor override
+// needs and filters the returned logger to level.
+func newConfigured(cfg config.LoggingConfig, w io.Writer, level slog.Level) *slog.Logger {
+	minLevel := level
+	for _, l := range cfg.Executors {
+		if pl := parseLevel(l); pl < minLevel {
+			minLevel = pl
+		}
+	}
+	handler := newHandler(cfg.Format, w, minLevel)
+	return slog.New(NewLevelHandler(level, handler))
 }
 
 // NewDefault creates a default logger writing to stderr.
@@ -65,6 +81,11 @@ func NewWithLevel(level slog.Level) *slog.Logger {
 	}))
 }
 
+// ParseLevel converts a config log level to slog.Level (info if unrecognized).
+func ParseLevel(level config.LogLevel) slog.Level {
+	return parseLevel(level)
+}
+
 // parseLevel converts config log level to slog.Level.
 func parseLevel(level config.LogLevel) slog.Level {
 	switch level {
@@ -97,6 +118,49 @@ func newHandler(format config.LogFormat, w io.Writer, level slog.Level) slog.Han
 	}
 }
 
+// LevelHandler drops records below its level before passing them to the
+// wrapped handler. Loggers sharing one output can then differ in verbosity,
+// as long as the wrapped handler admits the lowest level in use.
+type LevelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+// NewLevelHandler wraps handler so only records at level or above reach it.
+func NewLevelHandler(level slog.Leveler, handler slog.Handler) *LevelHandler {
+	if lh, ok := handler.(*LevelHandler); ok {
+		handler = lh.handler
+	}
+	return &LevelHandler{level: level, handler: handler}
+}
+
+// Enabled implements slog.Handler.
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *LevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LevelHandler{level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	return &LevelHandler{level: h.level, handler: h.handler.WithGroup(name)}
+}
+
+// WithLevel returns a logger sharing logger's output and attributes but
+// filtered to level. It can only be more verbose than logger if logger was
+// created by New (or another constructor that admits lower levels).
+func WithLevel(logger *slog.Logger, level slog.Level) *slog.Logger {
+	return slog.New(NewLevelHandler(level, logger.Handler()))
+}
+
 // WithFields returns a logger with the given fields added.
 func WithFields(logger *slog.Logger, fields ...any) *slog.Logger {
 	return logger.With(fields...)
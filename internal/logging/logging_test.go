@@ -182,6 +182,35 @@ func TestNewWithLevel(t *testing.T) {
 	}
 }
 
+func TestNew_ExecutorOverrides(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := config.LoggingConfig{
+		Format:    config.LogFormatJSON,
+		Executors: map[string]config.LogLevel{"agent": config.LogLevelDebug, "shell": config.LogLevelError},
+	}
+	logger := New(cfg, &buf, slog.LevelInfo).With("component", "orchestrator")
+
+	logger.Debug("base debug")
+	WithLevel(logger, slog.LevelDebug).Debug("agent debug")
+	WithLevel(logger, slog.LevelError).Warn("shell warn")
+	WithLevel(logger, slog.LevelError).Error("shell error")
+
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("JSON unmarshal failed: %v", err)
+		}
+		if record["component"] != "orchestrator" {
+			t.Errorf("record %v lost the logger's attributes", record)
+		}
+		msgs = append(msgs, record["msg"].(string))
+	}
+	if got := strings.Join(msgs, ","); got != "agent debug,shell error" {
+		t.Errorf("logged %q, want \"agent debug,shell error\"", got)
+	}
+}
+
 func TestWithFields(t *testing.T) {
 	var buf bytes.Buffer
 	handler := slog.NewJSONHandler(&buf, nil)
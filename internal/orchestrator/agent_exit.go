@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// checkAgentExits completes running agent steps with completion = "exit"
+// whose agent process has exited. Exit code 0 completes the step with an
+// exit_code output plus whatever the result file holds, validated like the
+// outputs of meow done; any other exit code fails it.
+// Returns true if any step state was modified (requires save).
+func (o *Orchestrator) checkAgentExits(ctx context.Context, wf *types.Run) bool {
+	watcher, ok := o.agents.(AgentExitWatcher)
+	if !ok {
+		return false
+	}
+
+	modified := false
+	for _, stepID := range sortedKeys(wf.Steps) {
+		step := wf.Steps[stepID]
+		if step.Status != types.StepStatusRunning || step.Executor != types.ExecutorAgent {
+			continue
+		}
+		if step.Agent == nil || step.Agent.Completion != types.CompletionExit {
+			continue
+		}
+
+		agentID := step.Agent.Agent
+		exited, code, err := watcher.ExitStatus(ctx, agentID)
+		if err != nil {
+			o.logger.Warn("cannot check agent exit", "step", step.ID, "agent", agentID, "error", err)
+			continue
+		}
+		if !exited {
+			continue
+		}
+		modified = true
+
+		if code != 0 {
+			o.logger.Warn("agent exited with failure", "step", step.ID, "agent", agentID, "exitCode", code)
+			o.failExitedStep(wf, step, &types.StepError{
+				Message:  fmt.Sprintf("Agent exited with code %d", code),
+				Type:     types.StepErrorCommandFailed,
+				Code:     code,
+				ExitCode: &code,
+			})
+			continue
+		}
+
+		outputs := map[string]any{"exit_code": 0}
+		if step.Agent.ResultFile != "" {
+			workdir := ""
+			if mgr, ok := o.agents.(*TmuxAgentManager); ok {
+				workdir = mgr.GetWorkdir(agentID)
+			}
+			if err := readAgentResultFile(step.Agent.ResultFile, workdir, outputs); err != nil {
+				o.failExitedStep(wf, step, &types.StepError{
+					Message: err.Error(),
+					Type:    types.StepErrorOutputCapture,
+				})
+				continue
+			}
+		}
+
+		o.logger.Info("agent exited", "step", step.ID, "agent", agentID)
+		if err := o.completeAgentStep(ctx, wf, step, agentID, outputs); err != nil {
+			// The agent is gone, so it cannot retry with better outputs
+			o.failExitedStep(wf, step, &types.StepError{
+				Message: err.Error(),
+				Type:    types.StepErrorOutputCapture,
+			})
+		}
+	}
+	return modified
+}
+
+// failExitedStep fails a running step whose agent has exited.
+func (o *Orchestrator) failExitedStep(wf *types.Run, step *types.Step, stepErr *types.StepError) {
+	if step.Status != types.StepStatusRunning {
+		return
+	}
+	if err := step.Fail(stepErr); err != nil {
+		o.logger.Error("failed to mark exited step as failed", "step", step.ID, "error", err)
+		return
+	}
+	o.recordStepFinished(wf.ID, step)
+}
+
+// readAgentResultFile adds the contents of an exited agent's result file to
+// outputs: the fields of a JSON object, or otherwise the trimmed text as
+// "result". A relative path is resolved against the agent's workdir.
+func readAgentResultFile(path, workdir string, outputs map[string]any) error {
+	if !filepath.IsAbs(path) && workdir != "" {
+		path = filepath.Join(workdir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading result file: %w", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err == nil && fields != nil {
+		for k, v := range fields {
+			outputs[k] = v
+		}
+		return nil
+	}
+	outputs["result"] = strings.TrimSpace(string(data))
+	return nil
+}
@@ -0,0 +1,119 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestOrchestrator_AgentExitCompletion(t *testing.T) {
+	newRun := func(agentCfg *types.AgentConfig) (*mockRunStore, *types.Run) {
+		store := newMockRunStore()
+		wf := types.NewRun("test-wf", "test-template", nil)
+		wf.Status = types.RunStatusRunning
+		wf.Steps["work"] = &types.Step{
+			ID:       "work",
+			Executor: types.ExecutorAgent,
+			Status:   types.StepStatusPending,
+			Agent:    agentCfg,
+		}
+		store.workflows[wf.ID] = wf
+		return store, wf
+	}
+	ctx := context.Background()
+
+	// dispatchThenExit dispatches the step, then has the agent exit with code
+	// and runs another processing pass
+	dispatchThenExit := func(t *testing.T, store *mockRunStore, wf *types.Run, code int) *types.Step {
+		t.Helper()
+		agents := newMockAgentManager()
+		agents.running["worker"] = true
+		orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+		if got := wf.Steps["work"].Status; got != types.StepStatusRunning {
+			t.Fatalf("work status = %v, want running while the agent is alive", got)
+		}
+
+		agents.mu.Lock()
+		agents.exits = map[string]int{"worker": code}
+		agents.mu.Unlock()
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		return wf.Steps["work"]
+	}
+
+	t.Run("exit 0 completes the step", func(t *testing.T) {
+		store, wf := newRun(&types.AgentConfig{Agent: "worker", Prompt: "Work", Completion: types.CompletionExit})
+		step := dispatchThenExit(t, store, wf, 0)
+		if step.Status != types.StepStatusDone {
+			t.Fatalf("work status = %v, want done (error %+v)", step.Status, step.Error)
+		}
+		if step.Outputs["exit_code"] != 0 {
+			t.Errorf("outputs = %v, want exit_code 0", step.Outputs)
+		}
+	})
+
+	t.Run("nonzero exit fails the step", func(t *testing.T) {
+		store, wf := newRun(&types.AgentConfig{Agent: "worker", Prompt: "Work", Completion: types.CompletionExit})
+		step := dispatchThenExit(t, store, wf, 3)
+		if step.Status != types.StepStatusFailed {
+			t.Fatalf("work status = %v, want failed", step.Status)
+		}
+		if step.Error == nil || step.Error.ExitCode == nil || *step.Error.ExitCode != 3 {
+			t.Errorf("work error = %+v, want exit code 3", step.Error)
+		}
+	})
+
+	t.Run("result file becomes outputs", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "result.json")
+		if err := os.WriteFile(path, []byte(`{"summary": "all green", "count": 2}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		store, wf := newRun(&types.AgentConfig{
+			Agent:      "worker",
+			Prompt:     "Work",
+			Completion: types.CompletionExit,
+			ResultFile: path,
+			Outputs:    map[string]types.AgentOutputDef{"summary": {Required: true, Type: "string"}},
+		})
+		step := dispatchThenExit(t, store, wf, 0)
+		if step.Status != types.StepStatusDone {
+			t.Fatalf("work status = %v, want done (error %+v)", step.Status, step.Error)
+		}
+		if step.Outputs["summary"] != "all green" || step.Outputs["count"] != float64(2) {
+			t.Errorf("outputs = %v, want the result file's fields", step.Outputs)
+		}
+	})
+
+	t.Run("missing result file fails the step", func(t *testing.T) {
+		store, wf := newRun(&types.AgentConfig{
+			Agent:      "worker",
+			Prompt:     "Work",
+			Completion: types.CompletionExit,
+			ResultFile: filepath.Join(t.TempDir(), "missing.json"),
+		})
+		step := dispatchThenExit(t, store, wf, 0)
+		if step.Status != types.StepStatusFailed {
+			t.Fatalf("work status = %v, want failed", step.Status)
+		}
+		if step.Error == nil || step.Error.Type != types.StepErrorOutputCapture {
+			t.Errorf("work error = %+v, want output_capture", step.Error)
+		}
+	})
+
+	t.Run("exit is ignored for done completion", func(t *testing.T) {
+		store, wf := newRun(&types.AgentConfig{Agent: "worker", Prompt: "Work"})
+		step := dispatchThenExit(t, store, wf, 0)
+		if step.Status != types.StepStatusRunning {
+			t.Errorf("work status = %v, want running until meow done", step.Status)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// Agent lifecycle hooks set up and tear down infrastructure shared by all of
+// a run's agents (credentials, MCP servers). They track the number of live
+// agents: before_all_agents runs on the transition from none to the first,
+// after_all_agents on the transition back to none once no spawn remains.
+// Each runs at most once per run.
+
+// runBeforeAllAgents runs the before_all_agents hook if no agent has been
+// spawned yet. Called before each spawn; an error fails the spawn step.
+func (o *Orchestrator) runBeforeAllAgents(ctx context.Context, wf *types.Run) error {
+	if wf.BeforeAllAgents == "" || wf.BeforeAllAgentsRan {
+		return nil
+	}
+	hook, err := o.prepareAgentHook(wf, "before_all_agents", wf.BeforeAllAgents)
+	if err != nil {
+		return err
+	}
+	if err := o.runAgentHook(ctx, hook); err != nil {
+		return err
+	}
+	wf.BeforeAllAgentsRan = true
+	return nil
+}
+
+// claimAfterAllAgents returns the after_all_agents hook if it should run now
+// and marks it as run, or nil if it should not. Unless force is set, the hook
+// waits until the run has no live agents and no spawn left to dispatch. The
+// caller must hold wfMu and save wf.
+func (o *Orchestrator) claimAfterAllAgents(wf *types.Run, force bool) *agentHook {
+	if wf.AfterAllAgents == "" || wf.AfterAllAgentsRan || !wf.BeforeAllAgentsRan {
+		return nil
+	}
+	if !force && agentsOutstanding(wf) {
+		return nil
+	}
+	wf.AfterAllAgentsRan = true
+	hook, err := o.prepareAgentHook(wf, "after_all_agents", wf.AfterAllAgents)
+	if err != nil {
+		o.logger.Error("agent lifecycle hook failed", "error", err)
+		return nil
+	}
+	return hook
+}
+
+// agentsOutstanding reports whether any agent the run spawned is still
+// alive, or a spawn step has yet to finish.
+func agentsOutstanding(wf *types.Run) bool {
+	live := make(map[string]int)
+	for _, step := range wf.Steps {
+		switch step.Executor {
+		case types.ExecutorSpawn:
+			if !step.Status.IsTerminal() {
+				return true
+			}
+			if step.Status == types.StepStatusDone && step.Spawn != nil {
+				live[step.Spawn.Agent]++
+			}
+		case types.ExecutorKill:
+			if step.Status == types.StepStatusDone && step.Kill != nil {
+				live[step.Kill.Agent]--
+			}
+		}
+	}
+	for _, n := range live {
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// agentHook is a lifecycle hook script with the run context substituted,
+// ready to execute without access to the run.
+type agentHook struct {
+	workflowID string
+	name       string
+	script     string
+	env        []string
+}
+
+// prepareAgentHook substitutes the run context into a hook script the same
+// way as for cleanup scripts. The caller must hold wfMu if wf is shared.
+func (o *Orchestrator) prepareAgentHook(wf *types.Run, name, script string) (*agentHook, error) {
+	runCtx := NewCleanupContext(wf, wf.Status, o.now())
+	script, err := runCtx.Substitute(script)
+	if err != nil {
+		return nil, fmt.Errorf("substituting %s hook: %w", name, err)
+	}
+	return &agentHook{workflowID: wf.ID, name: name, script: script, env: runCtx.Env()}, nil
+}
+
+// runAgentHook executes a prepared hook via bash, bounded by CleanupTimeout.
+func (o *Orchestrator) runAgentHook(ctx context.Context, hook *agentHook) error {
+	hookCtx, cancel := context.WithTimeout(ctx, CleanupTimeout)
+	defer cancel()
+
+	o.logger.Info("running agent lifecycle hook", "workflow", hook.workflowID, "hook", hook.name)
+
+	cmd := exec.CommandContext(hookCtx, "bash", "-c", hook.script)
+	killProcessGroupOnCancel(cmd)
+	cmd.Env = append(os.Environ(), hook.env...)
+	o.prepareCleanupCmd(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if hookCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s hook timed out after %s", hook.name, CleanupTimeout)
+		}
+		return fmt.Errorf("%s hook failed: %w (stderr: %s)", hook.name, err, stderr.String())
+	}
+	return nil
+}
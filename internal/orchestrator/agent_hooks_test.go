@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// loggingAgentManager records spawns and kills in the same event log the
+// test's hook scripts append to, so their relative order can be checked.
+type loggingAgentManager struct {
+	*mockAgentManager
+	mu  sync.Mutex
+	log string
+}
+
+func (m *loggingAgentManager) record(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.log, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	f.WriteString(event + "\n")
+}
+
+func (m *loggingAgentManager) Start(ctx context.Context, wf *types.Run, step *types.Step) error {
+	m.record("spawn " + step.Spawn.Agent)
+	return m.mockAgentManager.Start(ctx, wf, step)
+}
+
+func (m *loggingAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.Step) (StopOutcome, error) {
+	outcome, err := m.mockAgentManager.Stop(ctx, wf, step)
+	m.record("kill " + step.Kill.Agent)
+	return outcome, err
+}
+
+func TestAgentLifecycleHooks(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "events.log")
+	agents := &loggingAgentManager{mockAgentManager: newMockAgentManager(), log: log}
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.BeforeAllAgents = "echo before-all >> " + log
+	wf.AfterAllAgents = "echo after-all >> " + log
+	// Cleanup must not run the after-all hook a second time
+	wf.CleanupOnSuccess = "true"
+	add := func(step *types.Step) {
+		step.Status = types.StepStatusPending
+		wf.Steps[step.ID] = step
+	}
+	add(&types.Step{ID: "spawn-a", Executor: types.ExecutorSpawn, Spawn: &types.SpawnConfig{Agent: "a"}})
+	add(&types.Step{ID: "spawn-b", Executor: types.ExecutorSpawn, Needs: []string{"spawn-a"}, Spawn: &types.SpawnConfig{Agent: "b"}})
+	add(&types.Step{ID: "kill-a", Executor: types.ExecutorKill, Needs: []string{"spawn-b"}, Kill: &types.KillConfig{Agent: "a"}})
+	add(&types.Step{ID: "kill-b", Executor: types.ExecutorKill, Needs: []string{"kill-a"}, Kill: &types.KillConfig{Agent: "b"}})
+
+	store := newMockRunStore()
+	store.workflows[wf.ID] = wf
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("reading event log: %v", err)
+	}
+	want := "before-all\nspawn a\nspawn b\nkill a\nkill b\nafter-all\n"
+	if string(data) != want {
+		t.Errorf("events =\n%s\nwant\n%s", data, want)
+	}
+	if !wf.BeforeAllAgentsRan || !wf.AfterAllAgentsRan {
+		t.Errorf("ran flags = %v, %v, want both set", wf.BeforeAllAgentsRan, wf.AfterAllAgentsRan)
+	}
+}
+
+func TestAgentLifecycleHooks_AfterAllOnCleanup(t *testing.T) {
+	// Agents left running at the end are killed by cleanup, which then runs
+	// the after-all hook in place of a final kill step
+	log := filepath.Join(t.TempDir(), "events.log")
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.AfterAllAgents = "echo after-all >> " + log
+	wf.BeforeAllAgentsRan = true
+	wf.Steps["spawn"] = &types.Step{ID: "spawn", Executor: types.ExecutorSpawn, Status: types.StepStatusDone, Spawn: &types.SpawnConfig{Agent: "a"}}
+
+	store := newMockRunStore()
+	store.workflows[wf.ID] = wf
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	if err := orch.RunCleanup(context.Background(), wf, types.RunStatusFailed); err != nil {
+		t.Fatalf("RunCleanup() error = %v", err)
+	}
+	data, _ := os.ReadFile(log)
+	if string(data) != "after-all\n" {
+		t.Errorf("events = %q, want after-all once", data)
+	}
+}
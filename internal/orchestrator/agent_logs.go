@@ -0,0 +1,167 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// Agents that write structured JSON log lines can report outputs through
+// them: an agent output declared with from_log = "files_changed" takes the
+// value at that JSON path from the last line the agent logged during the
+// step. The lines are read from the agent's output log (the raw pane output,
+// stderr included), starting where the log ended when the step's prompt was
+// injected. Outputs passed to meow done take precedence.
+
+// agentLogSettle bounds how long meow done waits for from_log fields that
+// are not in the log yet: the log trails the pane slightly, so a line written
+// just before meow done may still be on its way.
+const agentLogSettle = 250 * time.Millisecond
+
+// markAgentLogOffset records where the step's part of its agent's log begins,
+// for steps with from_log outputs.
+func (o *Orchestrator) markAgentLogOffset(step *types.Step) {
+	if !hasLogOutputs(step.Agent) {
+		return
+	}
+	reader, ok := o.agents.(AgentLogReader)
+	if !ok {
+		return
+	}
+	size, err := reader.AgentLogSize(step.Agent.Agent)
+	if err != nil {
+		o.logger.Warn("cannot read agent log size", "step", step.ID, "agent", step.Agent.Agent, "error", err)
+		return
+	}
+	step.LogOffset = size
+}
+
+// awaitLogOutputs gives the step's agent log up to agentLogSettle to catch
+// up with the from_log fields meow done did not report, so the step's
+// completion finds them. It waits without holding wfMu.
+func (o *Orchestrator) awaitLogOutputs(ctx context.Context, msg *ipc.StepDoneMessage) {
+	reader, ok := o.agents.(AgentLogReader)
+	if !ok || msg.Error != "" {
+		return
+	}
+
+	o.wfMu.Lock()
+	var step *types.Step
+	if wf, err := o.store.Get(ctx, msg.Workflow); err == nil {
+		if msg.Step != "" {
+			step = wf.Steps[msg.Step]
+		} else {
+			step = wf.GetRunningStepForAgent(msg.Agent)
+		}
+	}
+	var agentID string
+	var offset int64
+	var pending map[string]types.AgentOutputDef
+	if step != nil && step.Status == types.StepStatusRunning && step.Agent != nil && step.Agent.Agent == msg.Agent {
+		agentID, offset = step.Agent.Agent, step.LogOffset
+		pending = pendingLogOutputs(step.Agent, ExtractAgentOutputPaths(msg.Outputs, step.Agent.Outputs))
+	}
+	o.wfMu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	deadline := time.Now().Add(agentLogSettle)
+	for {
+		log, err := reader.ReadAgentLog(agentID, offset)
+		if err != nil || len(extractLogFields(log, pending)) == len(pending) || time.Now().After(deadline) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(25 * time.Millisecond):
+		}
+	}
+}
+
+// extractLogOutputs adds the step's from_log outputs, read from its agent's
+// log, to outputs. Outputs already reported are kept. Fields that cannot be
+// found are left unset so required-output validation reports them as missing.
+func (o *Orchestrator) extractLogOutputs(step *types.Step, outputs map[string]any) map[string]any {
+	pending := pendingLogOutputs(step.Agent, outputs)
+	if len(pending) == 0 {
+		return outputs
+	}
+	reader, ok := o.agents.(AgentLogReader)
+	if !ok {
+		o.logger.Warn("agent manager cannot read agent logs", "step", step.ID)
+		return outputs
+	}
+
+	log, err := reader.ReadAgentLog(step.Agent.Agent, step.LogOffset)
+	if err != nil {
+		o.logger.Warn("failed to read agent log", "step", step.ID, "agent", step.Agent.Agent, "error", err)
+		return outputs
+	}
+	found := extractLogFields(log, pending)
+	if len(found) == 0 {
+		return outputs
+	}
+
+	result := make(map[string]any, len(outputs)+len(found))
+	for k, v := range outputs {
+		result[k] = v
+	}
+	for k, v := range found {
+		result[k] = v
+	}
+	return result
+}
+
+// pendingLogOutputs returns the from_log outputs of cfg not in outputs.
+func pendingLogOutputs(cfg *types.AgentConfig, outputs map[string]any) map[string]types.AgentOutputDef {
+	pending := make(map[string]types.AgentOutputDef)
+	for name, def := range cfg.Outputs {
+		if _, reported := outputs[name]; def.FromLog != "" && !reported {
+			pending[name] = def
+		}
+	}
+	return pending
+}
+
+// extractLogFields returns the value of each output's from_log path in the
+// last JSON log line that has it. Lines that are not JSON objects, such as
+// the agent's other terminal output, are ignored.
+func extractLogFields(log []byte, defs map[string]types.AgentOutputDef) map[string]any {
+	found := make(map[string]any)
+	for _, line := range strings.Split(string(log), "\n") {
+		start := strings.IndexByte(line, '{')
+		end := strings.LastIndexByte(line, '}')
+		if start < 0 || end < start {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line[start:end+1]), &entry); err != nil {
+			continue
+		}
+		for name, def := range defs {
+			if val, ok := getNestedOutputValue(entry, def.FromLog); ok {
+				found[name] = val
+			}
+		}
+	}
+	return found
+}
+
+// hasLogOutputs reports whether an agent step declares from_log outputs.
+func hasLogOutputs(cfg *types.AgentConfig) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, def := range cfg.Outputs {
+		if def.FromLog != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package orchestrator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestOrchestrator_HandleStepDone_LogOutputs(t *testing.T) {
+	// Logged by an earlier step on the same agent, before the offset
+	earlier := `{"level":"INFO","msg":"work summary","files_changed":9}` + "\n"
+
+	tests := []struct {
+		name       string
+		log        string
+		outputs    map[string]types.AgentOutputDef
+		reported   map[string]any
+		wantStatus types.StepStatus
+		want       map[string]any
+	}{
+		{
+			name: "last logged value",
+			log: "Working on it...\r\n" +
+				`{"time":"2026-01-01T00:00:00Z","level":"INFO","msg":"edit","files_changed":2}` + "\r\n" +
+				`{"time":"2026-01-01T00:00:01Z","level":"INFO","msg":"work summary","files_changed":3}` + "\r\n" +
+				"Task completed successfully.\r\n",
+			outputs: map[string]types.AgentOutputDef{
+				"files_changed": {Required: true, Type: "number", FromLog: "files_changed"},
+			},
+			wantStatus: types.StepStatusDone,
+			want:       map[string]any{"files_changed": float64(3)},
+		},
+		{
+			name: "nested path",
+			log:  `{"msg":"work summary","stats":{"files":["a.go","b.go"]}}` + "\n",
+			outputs: map[string]types.AgentOutputDef{
+				"first_file": {Required: true, Type: "string", FromLog: "stats.files[0]"},
+			},
+			wantStatus: types.StepStatusDone,
+			want:       map[string]any{"first_file": "a.go"},
+		},
+		{
+			name: "reported output wins",
+			log:  `{"msg":"work summary","files_changed":3}` + "\n",
+			outputs: map[string]types.AgentOutputDef{
+				"files_changed": {Required: true, Type: "number", FromLog: "files_changed"},
+			},
+			reported:   map[string]any{"files_changed": 5},
+			wantStatus: types.StepStatusDone,
+			want:       map[string]any{"files_changed": 5},
+		},
+		{
+			name: "missing required field",
+			log:  "no structured logs here\n",
+			outputs: map[string]types.AgentOutputDef{
+				"files_changed": {Required: true, Type: "number", FromLog: "files_changed"},
+			},
+			wantStatus: types.StepStatusRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			now := time.Now()
+			wf.Steps["implement"] = &types.Step{
+				ID:        "implement",
+				Executor:  types.ExecutorAgent,
+				Status:    types.StepStatusRunning,
+				StartedAt: &now,
+				LogOffset: int64(len(earlier)),
+				Agent:     &types.AgentConfig{Agent: "worker", Prompt: "Implement", Outputs: tt.outputs},
+			}
+			store.workflows[wf.ID] = wf
+
+			agents := newMockAgentManager()
+			agents.logs = map[string]string{"worker": earlier + tt.log}
+			orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+			reported := tt.reported
+			if reported == nil {
+				reported = map[string]any{}
+			}
+			err := orch.HandleStepDone(context.Background(), &ipc.StepDoneMessage{
+				Workflow: wf.ID,
+				Agent:    "worker",
+				Step:     "implement",
+				Outputs:  reported,
+			})
+			if tt.wantStatus == types.StepStatusDone && err != nil {
+				t.Fatalf("HandleStepDone error = %v", err)
+			}
+
+			step := wf.Steps["implement"]
+			if step.Status != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", step.Status, tt.wantStatus)
+			}
+			if tt.want != nil && !reflect.DeepEqual(step.Outputs, tt.want) {
+				t.Errorf("outputs = %v, want %v", step.Outputs, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_HandleStepDone_LateLogOutput(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	wf.Steps["implement"] = &types.Step{
+		ID:        "implement",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent: &types.AgentConfig{Agent: "worker", Prompt: "Implement", Outputs: map[string]types.AgentOutputDef{
+			"files_changed": {Required: true, Type: "number", FromLog: "files_changed"},
+		}},
+	}
+	store.workflows[wf.ID] = wf
+
+	agents := newMockAgentManager()
+	agents.logs = map[string]string{"worker": ""}
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	// The line trails meow done, and the wait for it leaves the workflow
+	// unlocked: one logged after taking wfMu still counts
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		orch.wfMu.Lock()
+		orch.wfMu.Unlock()
+		agents.mu.Lock()
+		agents.logs["worker"] = `{"msg":"work summary","files_changed":4}` + "\n"
+		agents.mu.Unlock()
+	}()
+
+	err := orch.HandleStepDone(context.Background(), &ipc.StepDoneMessage{
+		Workflow: wf.ID,
+		Agent:    "worker",
+		Step:     "implement",
+		Outputs:  map[string]any{},
+	})
+	if err != nil {
+		t.Fatalf("HandleStepDone error = %v", err)
+	}
+	step := wf.Steps["implement"]
+	if step.Status != types.StepStatusDone {
+		t.Fatalf("status = %v, want done", step.Status)
+	}
+	if got := step.Outputs["files_changed"]; got != float64(4) {
+		t.Errorf("files_changed = %#v, want 4", got)
+	}
+}
+
+func TestHandleAgent_MarksLogOffset(t *testing.T) {
+	agents := newMockAgentManager()
+	agents.logs = map[string]string{"worker": "output of earlier steps\n"}
+	orch := New(testConfig(), newMockRunStore(), agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	withLog := &types.Step{
+		ID:       "with-log",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Agent: &types.AgentConfig{Agent: "worker", Prompt: "Implement", Outputs: map[string]types.AgentOutputDef{
+			"files_changed": {Type: "number", FromLog: "files_changed"},
+		}},
+	}
+	without := &types.Step{
+		ID:       "without",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Agent:    &types.AgentConfig{Agent: "worker", Prompt: "Review"},
+	}
+	wf.Steps[withLog.ID] = withLog
+	wf.Steps[without.ID] = without
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, step := range []*types.Step{withLog, without} {
+		if err := orch.handleAgent(ctx, wf, step); err != nil {
+			t.Fatalf("handleAgent(%s) error = %v", step.ID, err)
+		}
+	}
+	if want := int64(len(agents.logs["worker"])); withLog.LogOffset != want {
+		t.Errorf("with-log offset = %d, want %d", withLog.LogOffset, want)
+	}
+	if without.LogOffset != 0 {
+		t.Errorf("without offset = %d, want 0 (no from_log outputs)", without.LogOffset)
+	}
+}
@@ -2,12 +2,18 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/akatz-ai/meow/internal/adapter"
@@ -33,6 +39,8 @@ type TmuxAgentManager struct {
 	// Logging configuration (abstracted from backend details)
 	loggingEnabled bool   // Whether to capture agent output to log files
 	logDir         string // Directory for agent log files (e.g., .meow/logs/<run_id>)
+
+	stateDir string // Directory for agent exit code files (e.g., .meow/runs)
 }
 
 type agentState struct {
@@ -40,7 +48,10 @@ type agentState struct {
 	workflowID    string
 	currentStepID string
 	workdir       string
-	adapterName   string // Which adapter this agent uses (for stop/inject)
+	adapterName   string      // Which adapter this agent uses (for stop/inject)
+	exitPath      string      // File the agent's command records its exit code in (empty if not recorded)
+	seenRunning   atomic.Bool // Agent's command was seen in its shell's foreground (when exitPath is empty)
+	startedAt     time.Time   // When the agent was spawned or reattached
 }
 
 // AgentManagerOptions configures the TmuxAgentManager.
@@ -50,6 +61,10 @@ type AgentManagerOptions struct {
 	// LogDir is the directory for agent log files (e.g., .meow/logs/<run_id>).
 	// Required if LoggingEnabled is true.
 	LogDir string
+	// StateDir is the directory for files recording agent exit codes, for
+	// agent steps with completion = "exit" (e.g., .meow/runs). Defaults to
+	// .meow/runs under the working directory.
+	StateDir string
 }
 
 // NewTmuxAgentManager creates a new TmuxAgentManager.
@@ -98,6 +113,11 @@ func NewTmuxAgentManagerWithOptions(workdir string, registry *adapter.Registry,
 	tmuxOpts = append(tmuxOpts, agent.WithTimeout(sendKeysTimeout))
 	tmuxWrapper := agent.NewTmuxWrapper(tmuxOpts...)
 
+	stateDir := opts.StateDir
+	if stateDir == "" {
+		stateDir = filepath.Join(workdir, ".meow", "runs")
+	}
+
 	return &TmuxAgentManager{
 		logger:          logger.With("component", "agent-manager"),
 		agents:          make(map[string]*agentState),
@@ -108,6 +128,7 @@ func NewTmuxAgentManagerWithOptions(workdir string, registry *adapter.Registry,
 		registry:        registry,
 		loggingEnabled:  opts.LoggingEnabled,
 		logDir:          opts.LogDir,
+		stateDir:        stateDir,
 	}
 }
 
@@ -133,6 +154,10 @@ func (m *TmuxAgentManager) Start(ctx context.Context, wf *types.Run, step *types
 		return fmt.Errorf("spawn step missing config")
 	}
 
+	if err := m.requireTmux(); err != nil {
+		return err
+	}
+
 	cfg := step.Spawn
 	agentID := cfg.Agent
 
@@ -162,10 +187,14 @@ func (m *TmuxAgentManager) Start(ctx context.Context, wf *types.Run, step *types
 
 	m.logger.Info("spawning agent", "agent", agentID, "adapter", adapterName, "session", sessionName, "workdir", workdir)
 
+	exitPath := m.recordedExitPath(wf, agentID, sessionName)
+	seenRunning := false
+
 	// Check if session already exists
 	if m.tmux.SessionExists(ctx, sessionName) {
 		m.logger.Warn("tmux session already exists", "session", sessionName)
 		// Attach to existing session instead of creating new
+		seenRunning = exitPath == "" && m.agentProcessRunning(ctx, sessionName)
 	} else {
 		// Build environment variables from multiple sources
 		// Priority: orchestrator-injected > step config > adapter defaults
@@ -224,6 +253,20 @@ func (m *TmuxAgentManager) Start(ctx context.Context, wf *types.Run, step *types
 			agentCmd = agentCmd + " " + cfg.SpawnArgs
 		}
 
+		// Record the agent's exit code when its process ends, if an agent
+		// step waits for it (completion = "exit"). The shell outlives the
+		// agent, so the session alone cannot tell a finished agent from a
+		// working one. The INT trap keeps the shell recording the exit of an
+		// agent stopped with C-c; the agent itself still gets the default
+		// SIGINT handling.
+		if exitPath != "" {
+			if err := os.MkdirAll(m.stateDir, 0755); err != nil {
+				return fmt.Errorf("creating agent state dir: %w", err)
+			}
+			os.Remove(exitPath)
+			agentCmd = fmt.Sprintf("(trap : INT; %s; echo $? > %s)", agentCmd, shellQuote(exitPath))
+		}
+
 		// Give the session a moment to initialize
 		time.Sleep(100 * time.Millisecond)
 
@@ -236,8 +279,15 @@ func (m *TmuxAgentManager) Start(ctx context.Context, wf *types.Run, step *types
 		}
 
 		// Wait for agent to fully start up before returning
-		// Uses the adapter's configured startup delay
+		// Uses the step's startup_delay if set, else the adapter's
 		startupDelay := adapterCfg.GetStartupDelay()
+		if cfg.StartupDelay != "" {
+			d, err := time.ParseDuration(cfg.StartupDelay)
+			if err != nil {
+				return fmt.Errorf("invalid startup_delay %q: %w", cfg.StartupDelay, err)
+			}
+			startupDelay = d
+		}
 		m.logger.Info("waiting for agent to start", "agent", agentID, "delay", startupDelay)
 		time.Sleep(startupDelay)
 	}
@@ -249,7 +299,10 @@ func (m *TmuxAgentManager) Start(ctx context.Context, wf *types.Run, step *types
 		workflowID:  wf.ID,
 		workdir:     workdir,
 		adapterName: adapterName,
+		exitPath:    exitPath,
+		startedAt:   time.Now(),
 	}
+	m.agents[agentID].seenRunning.Store(seenRunning)
 	m.mu.Unlock()
 
 	// Register agent in workflow for file_path validation
@@ -262,11 +315,212 @@ func (m *TmuxAgentManager) Start(ctx context.Context, wf *types.Run, step *types
 	return nil
 }
 
+// Reattach registers an agent whose tmux session survived an orchestrator
+// restart, so IsRunning, InjectPrompt, and Interrupt can reach it again. The
+// adapter is resolved from the agent's spawn step the same way Start does. It
+// is a no-op if the agent is already registered or its session is gone.
+func (m *TmuxAgentManager) Reattach(ctx context.Context, wf *types.Run, agentID string) error {
+	m.mu.RLock()
+	_, known := m.agents[agentID]
+	m.mu.RUnlock()
+	if known {
+		return nil
+	}
+
+	sessionName := BuildTmuxSessionName(wf.ID, agentID)
+	workdir := m.workdir
+	if info, ok := wf.Agents[agentID]; ok {
+		if info.TmuxSession != "" {
+			sessionName = info.TmuxSession
+		}
+		if info.Workdir != "" {
+			workdir = info.Workdir
+		}
+	}
+	if !m.tmux.SessionExists(ctx, sessionName) {
+		return nil
+	}
+
+	adapterName, err := m.spawnAdapter(wf, agentID)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("reattaching agent", "agent", agentID, "adapter", adapterName, "session", sessionName)
+	state := &agentState{
+		tmuxSession: sessionName,
+		workflowID:  wf.ID,
+		workdir:     workdir,
+		adapterName: adapterName,
+		exitPath:    m.recordedExitPath(wf, agentID, sessionName),
+		startedAt:   time.Now(),
+	}
+	state.seenRunning.Store(state.exitPath == "" && m.agentProcessRunning(ctx, sessionName))
+
+	m.mu.Lock()
+	m.agents[agentID] = state
+	m.mu.Unlock()
+	return nil
+}
+
+// Adopt takes over an agent registered by an earlier run, if its tmux session
+// is still alive, and reports whether it did. The session's MEOW_WORKFLOW and
+// MEOW_ORCH_SOCK are pointed at wf so processes started in it from now on
+// report here; the agent keeps the adapter of wf's spawn step.
+func (m *TmuxAgentManager) Adopt(ctx context.Context, wf *types.Run, agentID string, prior *types.AgentInfo) (bool, error) {
+	if err := m.requireTmux(); err != nil {
+		return false, err
+	}
+	if prior.TmuxSession == "" || !m.tmux.SessionExists(ctx, prior.TmuxSession) {
+		return false, nil
+	}
+
+	adapterName, err := m.spawnAdapter(wf, agentID)
+	if err != nil {
+		return false, err
+	}
+	workdir := prior.Workdir
+	if workdir == "" {
+		workdir = m.workdir
+	}
+
+	m.logger.Info("adopting agent", "agent", agentID, "adapter", adapterName, "session", prior.TmuxSession)
+
+	for key, value := range map[string]string{
+		"MEOW_WORKFLOW":  wf.ID,
+		"MEOW_ORCH_SOCK": ipc.SocketPath(wf.ID),
+	} {
+		if err := m.tmux.SetEnv(ctx, prior.TmuxSession, key, value); err != nil {
+			m.logger.Warn("failed to update adopted session environment", "agent", agentID, "key", key, "error", err)
+		}
+	}
+
+	state := &agentState{
+		tmuxSession: prior.TmuxSession,
+		workflowID:  wf.ID,
+		workdir:     workdir,
+		adapterName: adapterName,
+		exitPath:    m.recordedExitPath(wf, agentID, prior.TmuxSession),
+		startedAt:   time.Now(),
+	}
+	state.seenRunning.Store(state.exitPath == "" && m.agentProcessRunning(ctx, prior.TmuxSession))
+
+	m.mu.Lock()
+	m.agents[agentID] = state
+	m.mu.Unlock()
+
+	wf.RegisterAgent(agentID, &types.AgentInfo{
+		TmuxSession:   prior.TmuxSession,
+		Status:        "active",
+		Workdir:       workdir,
+		ClaudeSession: prior.ClaudeSession,
+	})
+	return true, nil
+}
+
+// spawnAdapter resolves the adapter for an agent from its spawn step the same
+// way Start does.
+func (m *TmuxAgentManager) spawnAdapter(wf *types.Run, agentID string) (string, error) {
+	var stepAdapter string
+	for _, step := range wf.Steps {
+		if step.Executor == types.ExecutorSpawn && step.Spawn != nil && step.Spawn.Agent == agentID && step.Spawn.Adapter != "" {
+			stepAdapter = step.Spawn.Adapter
+			break
+		}
+	}
+	adapterName := m.registry.Resolve(stepAdapter, wf.DefaultAdapter)
+	if adapterName == "" {
+		return "", fmt.Errorf("no adapter specified for agent %q", agentID)
+	}
+	return adapterName, nil
+}
+
+// ValidateAdapters checks every adapter referenced by a pending spawn step:
+// tmux must be available, the adapter must load, and its spawn command must
+// resolve to an executable. Workflows without spawn steps never touch tmux.
+func (m *TmuxAgentManager) ValidateAdapters(ctx context.Context, wf *types.Run) error {
+	stepIDs := make([]string, 0, len(wf.Steps))
+	for id := range wf.Steps {
+		stepIDs = append(stepIDs, id)
+	}
+	sort.Strings(stepIDs)
+
+	tmuxChecked := false
+	for _, id := range stepIDs {
+		step := wf.Steps[id]
+		if step.Spawn == nil || step.Status != types.StepStatusPending {
+			continue
+		}
+
+		// Only workflows that spawn agents need tmux
+		if !tmuxChecked {
+			if err := m.requireTmux(); err != nil {
+				return err
+			}
+			tmuxChecked = true
+		}
+
+		adapterName := m.registry.Resolve(step.Spawn.Adapter, wf.DefaultAdapter)
+		if adapterName == "" {
+			return fmt.Errorf("step %q: no adapter specified for agent %q", id, step.Spawn.Agent)
+		}
+		if strings.Contains(adapterName, "{{") {
+			continue // Resolved at spawn time
+		}
+		adapterCfg, err := m.registry.Load(adapterName)
+		if err != nil {
+			return fmt.Errorf("step %q: loading adapter %q: %w", id, adapterName, err)
+		}
+
+		commands := []string{adapterCfg.Spawn.Command}
+		if step.Spawn.ResumeSession != "" && adapterCfg.Spawn.ResumeCommand != "" {
+			commands = append(commands, adapterCfg.Spawn.ResumeCommand)
+		}
+		for _, command := range commands {
+			bin := commandBinary(command)
+			if bin == "" {
+				continue
+			}
+			if _, err := exec.LookPath(bin); err != nil {
+				return fmt.Errorf("step %q: adapter %q spawn command %q: %w", id, adapterName, command, err)
+			}
+		}
+	}
+	return nil
+}
+
+// requireTmux returns ErrTmuxRequired with the reason when tmux cannot be used.
+func (m *TmuxAgentManager) requireTmux() error {
+	if err := m.tmux.Available(); err != nil {
+		return fmt.Errorf("%w: %v", ErrTmuxRequired, err)
+	}
+	return nil
+}
+
+// commandBinary returns the program a shell command line would run, skipping
+// leading VAR=value assignments. Returns "" when the program is only known
+// after expansion (shell variables or template placeholders).
+func commandBinary(command string) string {
+	for _, field := range strings.Fields(command) {
+		if name, _, ok := strings.Cut(field, "="); ok && name != "" && !strings.ContainsAny(name, `/"'$`) {
+			continue
+		}
+		field = strings.Trim(field, `"'`)
+		if strings.Contains(field, "$") || strings.Contains(field, "{{") {
+			return ""
+		}
+		return field
+	}
+	return ""
+}
+
 // Stop kills an agent's tmux session using the configured adapter.
-// The adapter determines the graceful stop keys and wait duration.
-func (m *TmuxAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.Step) error {
+// The adapter determines the graceful stop keys, and how long to wait for the
+// agent to exit unless the kill step sets a grace period. The session is
+// killed once the agent exits or the wait runs out.
+func (m *TmuxAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.Step) (StopOutcome, error) {
 	if step.Kill == nil {
-		return fmt.Errorf("kill step missing config")
+		return "", fmt.Errorf("kill step missing config")
 	}
 
 	agentID := step.Kill.Agent
@@ -278,13 +532,17 @@ func (m *TmuxAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.
 
 	if !ok {
 		m.logger.Warn("agent not found, assuming already stopped", "agent", agentID)
-		return nil
+		return StopNotRunning, nil
 	}
 
 	sessionName := state.tmuxSession
 	m.logger.Info("stopping agent", "agent", agentID, "session", sessionName, "graceful", graceful)
 
-	if graceful {
+	outcome := StopForced
+	if running, _ := m.IsRunning(ctx, agentID); !running {
+		outcome = StopNotRunning
+	} else if graceful {
+		wait := step.Kill.GracePeriod
 		// Load adapter config for graceful stop settings
 		adapterCfg, err := m.registry.Load(state.adapterName)
 		if err != nil {
@@ -293,7 +551,9 @@ func (m *TmuxAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.
 			if err := m.tmux.SendKeysSpecial(ctx, sessionName, "C-c"); err != nil {
 				m.logger.Warn("failed to send C-c", "error", err)
 			}
-			time.Sleep(2 * time.Second)
+			if wait == 0 {
+				wait = 2 * time.Second
+			}
 		} else {
 			// Send graceful stop keys from adapter config
 			for _, key := range adapterCfg.GracefulStop.Keys {
@@ -301,8 +561,14 @@ func (m *TmuxAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.
 					m.logger.Warn("failed to send graceful stop key", "key", key, "error", err)
 				}
 			}
-			// Wait for graceful shutdown using adapter's configured duration
-			time.Sleep(adapterCfg.GetGracefulStopWait())
+			if wait == 0 {
+				wait = adapterCfg.GetGracefulStopWait()
+			}
+		}
+		if m.waitForExit(ctx, agentID, wait) {
+			outcome = StopGraceful
+		} else {
+			m.logger.Info("agent did not exit within grace period, forcing", "agent", agentID, "grace_period", wait)
 		}
 	}
 
@@ -311,16 +577,42 @@ func (m *TmuxAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.
 		m.logger.Warn("failed to kill session", "error", err)
 		// Not fatal - session might already be gone
 	}
+	if state.exitPath != "" {
+		os.Remove(state.exitPath)
+	}
 
 	// Clean up state
 	m.mu.Lock()
 	delete(m.agents, agentID)
 	m.mu.Unlock()
 
-	return nil
+	return outcome, nil
+}
+
+// waitForExit polls until the agent stops running or wait elapses, reporting
+// whether it exited.
+func (m *TmuxAgentManager) waitForExit(ctx context.Context, agentID string, wait time.Duration) bool {
+	deadline := time.Now().Add(wait)
+	for {
+		if running, _ := m.IsRunning(ctx, agentID); !running {
+			return true
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(min(remaining, 100*time.Millisecond)):
+		}
+	}
 }
 
-// IsRunning checks if an agent is currently running.
+// IsRunning checks if an agent is currently running. The session's shell
+// outlives the agent command, so an agent whose command has recorded its exit
+// code, or that was seen running and has since left its shell in the
+// foreground again, is not running even though its session still exists.
 func (m *TmuxAgentManager) IsRunning(ctx context.Context, agentID string) (bool, error) {
 	m.mu.RLock()
 	state, ok := m.agents[agentID]
@@ -330,7 +622,131 @@ func (m *TmuxAgentManager) IsRunning(ctx context.Context, agentID string) (bool,
 		return false, nil
 	}
 
-	return m.tmux.SessionExists(ctx, state.tmuxSession), nil
+	if !m.tmux.SessionExists(ctx, state.tmuxSession) {
+		return false, nil
+	}
+	if state.exitPath == "" {
+		if m.agentProcessRunning(ctx, state.tmuxSession) {
+			state.seenRunning.Store(true)
+			return true, nil
+		}
+		// An agent never seen running may still be waiting on its shell
+		starting := time.Since(state.startedAt) < agentCommandStartTimeout
+		return !state.seenRunning.Load() && starting, nil
+	}
+	if _, err := os.Stat(state.exitPath); err == nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// agentProcessRunning reports whether the agent command typed into a
+// session's shell is running: the pane's terminal has a foreground process
+// group other than the shell's. When that cannot be determined, the agent is
+// assumed to be running.
+func (m *TmuxAgentManager) agentProcessRunning(ctx context.Context, sessionName string) bool {
+	pid, err := m.tmux.PanePID(ctx, sessionName)
+	if err != nil {
+		return true
+	}
+	out, err := exec.CommandContext(ctx, "ps", "-o", "tpgid=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return true
+	}
+	foreground, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return true
+	}
+	return foreground != pid
+}
+
+// agentCommandStartTimeout bounds how long a session's shell may take to run
+// the agent command it was sent; an agent not seen running by then is gone.
+const agentCommandStartTimeout = 10 * time.Second
+
+// waitForAgentCommand polls until the agent command sent to a session's
+// shell is running, or timeout elapses, reporting whether it was seen.
+func (m *TmuxAgentManager) waitForAgentCommand(ctx context.Context, sessionName string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if m.agentProcessRunning(ctx, sessionName) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// ExitStatus reports whether the agent's process has exited and, if so, its
+// exit code. An agent whose exit code is not recorded, or whose whole session
+// is gone, reports exit code -1.
+func (m *TmuxAgentManager) ExitStatus(ctx context.Context, agentID string) (bool, int, error) {
+	m.mu.RLock()
+	state, ok := m.agents[agentID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return false, 0, fmt.Errorf("agent %s not found", agentID)
+	}
+
+	if state.exitPath == "" {
+		if running, err := m.IsRunning(ctx, agentID); err != nil || running {
+			return false, 0, err
+		}
+		return true, -1, nil
+	}
+	data, err := os.ReadFile(state.exitPath)
+	if err == nil {
+		code, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			// Still being written
+			return false, 0, nil
+		}
+		return true, code, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return false, 0, err
+	}
+	if !m.tmux.SessionExists(ctx, state.tmuxSession) {
+		return true, -1, nil
+	}
+	return false, 0, nil
+}
+
+// exitPath is where an agent's session records its exit code.
+func (m *TmuxAgentManager) exitPath(sessionName string) string {
+	return filepath.Join(m.stateDir, sessionName+".exit")
+}
+
+// recordedExitPath returns the file an agent's session records its exit
+// code in, or "" if no agent step in wf waits for the agent's exit.
+func (m *TmuxAgentManager) recordedExitPath(wf *types.Run, agentID, sessionName string) string {
+	if !waitsForAgentExit(wf, agentID) {
+		return ""
+	}
+	return m.exitPath(sessionName)
+}
+
+// waitsForAgentExit reports whether any agent step in wf for agentID
+// completes when the agent exits (completion = "exit").
+func waitsForAgentExit(wf *types.Run, agentID string) bool {
+	for _, step := range wf.Steps {
+		if step.Agent != nil && step.Agent.Agent == agentID && step.Agent.Completion == types.CompletionExit {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote quotes s as a single bash word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // InjectPromptOpts controls prompt injection behavior.
@@ -339,6 +755,11 @@ type InjectPromptOpts struct {
 	// Set to true for subsequent prompts (after the agent has completed at least one step).
 	// Set to false for the first prompt after spawn and for fire_forget mode.
 	Stabilize bool
+
+	// PreDelay and PostDelay override the adapter's pre_delay and post_delay
+	// when non-zero (from the agent step's pre_delay/post_delay).
+	PreDelay  time.Duration
+	PostDelay time.Duration
 }
 
 // InjectPrompt sends a prompt to an agent's tmux session using the configured adapter.
@@ -356,6 +777,17 @@ func (m *TmuxAgentManager) InjectPrompt(ctx context.Context, agentID string, pro
 	sessionName := state.tmuxSession
 	m.logger.Info("injecting prompt", "agent", agentID, "session", sessionName, "promptLen", len(prompt), "stabilize", opts.Stabilize)
 
+	// An agent that exits before it was seen running looks like one still
+	// starting, so make sure it is running before it can act on a prompt
+	if state.exitPath == "" && !state.seenRunning.Load() {
+		wait := time.Until(state.startedAt.Add(agentCommandStartTimeout))
+		if m.waitForAgentCommand(ctx, sessionName, wait) {
+			state.seenRunning.Store(true)
+		} else {
+			m.logger.Warn("agent command not seen running", "agent", agentID, "session", sessionName)
+		}
+	}
+
 	// Load adapter config for prompt injection settings
 	adapterCfg, err := m.registry.Load(state.adapterName)
 	if err != nil {
@@ -397,8 +829,12 @@ func (m *TmuxAgentManager) InjectPrompt(ctx context.Context, agentID string, pro
 	}
 
 	// Wait pre-delay
-	if injection.PreDelay > 0 {
-		time.Sleep(injection.PreDelay.Duration())
+	preDelay := injection.PreDelay.Duration()
+	if opts.PreDelay > 0 {
+		preDelay = opts.PreDelay
+	}
+	if preDelay > 0 {
+		time.Sleep(preDelay)
 	}
 
 	// Send prompt using the configured method
@@ -417,8 +853,12 @@ func (m *TmuxAgentManager) InjectPrompt(ctx context.Context, agentID string, pro
 	}
 
 	// Wait post-delay before sending post-keys
-	if injection.PostDelay > 0 {
-		time.Sleep(injection.PostDelay.Duration())
+	postDelay := injection.PostDelay.Duration()
+	if opts.PostDelay > 0 {
+		postDelay = opts.PostDelay
+	}
+	if postDelay > 0 {
+		time.Sleep(postDelay)
 	}
 
 	// Send post-keys (e.g., Enter to submit) with retry
@@ -474,7 +914,8 @@ func (m *TmuxAgentManager) GetWorkdir(agentID string) string {
 	return ""
 }
 
-// Interrupt sends C-c to an agent's tmux session for graceful cancellation.
+// Interrupt sends the adapter's interrupt keys (C-c by default) to an agent's
+// tmux session for graceful cancellation.
 // This is used by the timeout enforcement to interrupt running agents.
 func (m *TmuxAgentManager) Interrupt(ctx context.Context, agentID string) error {
 	m.mu.RLock()
@@ -485,10 +926,92 @@ func (m *TmuxAgentManager) Interrupt(ctx context.Context, agentID string) error
 		return fmt.Errorf("agent %s not found", agentID)
 	}
 
+	keys := []string{"C-c"}
+	adapterCfg, err := m.registry.Load(state.adapterName)
+	if err != nil {
+		m.logger.Warn("failed to load adapter for interrupt, sending C-c", "adapter", state.adapterName, "error", err)
+	} else {
+		keys = adapterCfg.GetInterruptKeys()
+	}
+
 	sessionName := state.tmuxSession
-	m.logger.Info("sending interrupt to agent", "agent", agentID, "session", sessionName)
+	m.logger.Info("sending interrupt to agent", "agent", agentID, "session", sessionName, "keys", keys)
 
-	return m.tmux.SendKeysSpecial(ctx, sessionName, "C-c")
+	for _, key := range keys {
+		if err := m.tmux.SendKeysSpecial(ctx, sessionName, key); err != nil {
+			return fmt.Errorf("sending interrupt key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// CaptureTranscript returns the agent's pane content, including scrollback,
+// with the trailing blank lines of the visible area trimmed.
+func (m *TmuxAgentManager) CaptureTranscript(ctx context.Context, agentID string) (string, error) {
+	m.mu.RLock()
+	state, ok := m.agents[agentID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("agent %s not found", agentID)
+	}
+
+	content, err := m.tmux.CapturePaneWithOptions(ctx, state.tmuxSession, agent.CapturePaneOptions{History: true})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(content, "\n") + "\n", nil
+}
+
+// AgentLogSize returns the current size of the agent's output log, or 0 if
+// nothing has been logged yet.
+func (m *TmuxAgentManager) AgentLogSize(agentID string) (int64, error) {
+	path, err := m.agentLogPath(agentID)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// ReadAgentLog returns the agent's output log from offset on. The log is the
+// raw pane output, so the agent's stderr appears in it as written.
+func (m *TmuxAgentManager) ReadAgentLog(agentID string, offset int64) ([]byte, error) {
+	path, err := m.agentLogPath(agentID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if offset > info.Size() {
+		offset = 0 // Log was recreated (e.g., agent respawned)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+// agentLogPath returns the path of the agent's output log (see Start).
+func (m *TmuxAgentManager) agentLogPath(agentID string) (string, error) {
+	if !m.loggingEnabled || m.logDir == "" {
+		return "", fmt.Errorf("agent logging is disabled")
+	}
+	return filepath.Join(m.logDir, agentID+".log"), nil
 }
 
 // KillAll kills all agent sessions for a workflow.
@@ -518,6 +1041,9 @@ func (m *TmuxAgentManager) KillAll(ctx context.Context, wf *types.Run) error {
 			m.logger.Warn("failed to kill session", "agent", agentID, "error", err)
 			lastErr = err
 		}
+		if state.exitPath != "" {
+			os.Remove(state.exitPath)
+		}
 
 		delete(m.agents, agentID)
 	}
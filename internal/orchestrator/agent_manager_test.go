@@ -0,0 +1,563 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/adapter"
+	"github.com/akatz-ai/meow/internal/agent"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// writeTestAdapter creates <dir>/<name>/adapter.toml with the given spawn commands.
+func writeTestAdapter(t *testing.T, dir, name, command, resumeCommand string) {
+	t.Helper()
+	adapterDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(adapterDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[adapter]\nname = \"" + name + "\"\n\n[spawn]\ncommand = \"" + command + "\"\n"
+	if resumeCommand != "" {
+		content += "resume_command = \"" + resumeCommand + "\"\n"
+	}
+	if err := os.WriteFile(filepath.Join(adapterDir, "adapter.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newSpawnRun(adapterName, resumeSession string) *types.Run {
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["spawn"] = &types.Step{
+		ID:       "spawn",
+		Executor: types.ExecutorSpawn,
+		Status:   types.StepStatusPending,
+		Spawn:    &types.SpawnConfig{Agent: "worker", Adapter: adapterName, ResumeSession: resumeSession},
+	}
+	return wf
+}
+
+func TestTmuxAgentManager_ValidateAdapters(t *testing.T) {
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "good", "sh -c true", "")
+	writeTestAdapter(t, adaptersDir, "env-prefixed", "FOO=/usr/bin sh", "")
+	writeTestAdapter(t, adaptersDir, "missing", "meow-nonexistent-agent --flag", "")
+	writeTestAdapter(t, adaptersDir, "bad-resume", "sh", "meow-nonexistent-agent --resume {{session_id}}")
+
+	tests := []struct {
+		name          string
+		adapter       string
+		resumeSession string
+		wantErr       string
+	}{
+		{name: "binary on PATH", adapter: "good"},
+		{name: "env assignments skipped", adapter: "env-prefixed"},
+		{name: "missing binary", adapter: "missing", wantErr: `"meow-nonexistent-agent": executable file not found`},
+		{name: "resume command unused", adapter: "bad-resume"},
+		{name: "resume command missing", adapter: "bad-resume", resumeSession: "abc", wantErr: `"meow-nonexistent-agent": executable file not found`},
+		{name: "unknown adapter", adapter: "nope", wantErr: `loading adapter "nope"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger())
+			err := m.ValidateAdapters(context.Background(), newSpawnRun(tt.adapter, tt.resumeSession))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateAdapters() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateAdapters() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RunFailsOnMissingAdapterBinary(t *testing.T) {
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "broken", "/nonexistent/bin/agent", "")
+
+	store := newMockRunStore()
+	wf := newSpawnRun("", "")
+	wf.DefaultAdapter = "broken"
+	store.workflows[wf.ID] = wf
+
+	agents := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger())
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := orch.Run(ctx)
+	if err == nil {
+		t.Fatal("Run() error = nil, want adapter validation error")
+	}
+	if !strings.Contains(err.Error(), `adapter "broken"`) || !strings.Contains(err.Error(), "/nonexistent/bin/agent") {
+		t.Errorf("Run() error = %v, want it to name the adapter and binary", err)
+	}
+
+	if wf.Status != types.RunStatusFailed {
+		t.Errorf("workflow status = %s, want failed", wf.Status)
+	}
+	if wf.Steps["spawn"].Status != types.StepStatusPending {
+		t.Errorf("spawn step status = %s, want pending (never dispatched)", wf.Steps["spawn"].Status)
+	}
+}
+
+func TestOrchestrator_ShellOnlyWorkflowWithoutTmux(t *testing.T) {
+	t.Setenv("MEOW_TMUX_SOCKET", filepath.Join(t.TempDir(), "missing", "tmux.sock"))
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "true"},
+	}
+	store.workflows[wf.ID] = wf
+
+	agents := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", ""), testLogger())
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if wf.Status != types.RunStatusDone {
+		t.Errorf("workflow status = %s, want done", wf.Status)
+	}
+}
+
+func TestOrchestrator_AgentWorkflowWithoutTmux(t *testing.T) {
+	t.Setenv("MEOW_TMUX_SOCKET", filepath.Join(t.TempDir(), "missing", "tmux.sock"))
+
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "good", "sh", "")
+
+	store := newMockRunStore()
+	wf := newSpawnRun("good", "")
+	store.workflows[wf.ID] = wf
+
+	agents := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger())
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := orch.Run(ctx)
+	if !errors.Is(err, ErrTmuxRequired) {
+		t.Fatalf("Run() error = %v, want ErrTmuxRequired", err)
+	}
+	if !strings.Contains(err.Error(), "tmux required") {
+		t.Errorf("Run() error = %q, want a \"tmux required\" message", err)
+	}
+
+	// Spawns created after startup (e.g., by expansion) fail the same way
+	if err := agents.Start(ctx, wf, wf.Steps["spawn"]); !errors.Is(err, ErrTmuxRequired) {
+		t.Errorf("Start() error = %v, want ErrTmuxRequired", err)
+	}
+}
+
+func TestTmuxAgentManager_InjectPrompt_StepDelayOverridesAdapter(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "paced", "sh", "")
+	injection := "\n[prompt_injection]\nmethod = \"literal\"\npost_keys = [\"Enter\"]\npost_delay = \"10ms\"\n"
+	f, err := os.OpenFile(filepath.Join(adaptersDir, "paced", "adapter.toml"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(injection); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	socket := filepath.Join(t.TempDir(), "tmux.sock")
+	m := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger())
+	m.SetTmuxSocket(socket)
+	t.Cleanup(func() { exec.Command("tmux", "-S", socket, "kill-server").Run() })
+
+	ctx := context.Background()
+	if err := m.tmux.NewSession(ctx, agent.SessionOptions{Name: "meow-test-paced", Command: "cat"}); err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	m.agents["worker"] = &agentState{tmuxSession: "meow-test-paced", adapterName: "paced"}
+
+	const override = 400 * time.Millisecond
+
+	start := time.Now()
+	if err := m.InjectPrompt(ctx, "worker", "fast", InjectPromptOpts{}); err != nil {
+		t.Fatalf("InjectPrompt() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= override {
+		t.Fatalf("adapter-default injection took %v, expected well under %v", elapsed, override)
+	}
+
+	start = time.Now()
+	if err := m.InjectPrompt(ctx, "worker", "slow", InjectPromptOpts{PostDelay: override}); err != nil {
+		t.Fatalf("InjectPrompt() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < override {
+		t.Errorf("injection with step post_delay took %v, want at least %v", elapsed, override)
+	}
+}
+
+func TestAgentInjectOpts(t *testing.T) {
+	opts, err := agentInjectOpts(&types.AgentConfig{PreDelay: "250ms", PostDelay: "2s"})
+	if err != nil {
+		t.Fatalf("agentInjectOpts() error = %v", err)
+	}
+	if opts.PreDelay != 250*time.Millisecond || opts.PostDelay != 2*time.Second {
+		t.Errorf("agentInjectOpts() = %+v, want pre 250ms, post 2s", opts)
+	}
+
+	if opts, err := agentInjectOpts(&types.AgentConfig{}); err != nil || opts.PreDelay != 0 || opts.PostDelay != 0 {
+		t.Errorf("agentInjectOpts() without overrides = %+v, %v; want zero delays (adapter defaults)", opts, err)
+	}
+
+	if _, err := agentInjectOpts(&types.AgentConfig{PostDelay: "soon"}); err == nil || !strings.Contains(err.Error(), "post_delay") {
+		t.Errorf("agentInjectOpts() error = %v, want invalid post_delay", err)
+	}
+}
+
+func TestTmuxAgentManager_Reattach(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "sim", "sh", "")
+
+	socket := filepath.Join(t.TempDir(), "tmux.sock")
+	newManager := func() *TmuxAgentManager {
+		m := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger())
+		m.SetTmuxSocket(socket)
+		return m
+	}
+	t.Cleanup(func() { exec.Command("tmux", "-S", socket, "kill-server").Run() })
+
+	wf := newSpawnRun("sim", "")
+	wf.Steps["spawn"].Status = types.StepStatusDone
+	workdir := t.TempDir()
+	wf.RegisterAgent("worker", &types.AgentInfo{
+		TmuxSession: BuildTmuxSessionName(wf.ID, "worker"),
+		Status:      "active",
+		Workdir:     workdir,
+	})
+
+	// A fresh manager (as after an orchestrator restart) does not know the agent
+	ctx := context.Background()
+	m := newManager()
+	if err := m.Reattach(ctx, wf, "worker"); err != nil {
+		t.Fatalf("Reattach() with no session error = %v", err)
+	}
+	if alive, _ := m.IsRunning(ctx, "worker"); alive {
+		t.Fatal("IsRunning() = true before the session exists")
+	}
+
+	// A shell running the agent in the foreground, as Start leaves a session
+	if err := m.tmux.NewSession(ctx, agent.SessionOptions{Name: BuildTmuxSessionName(wf.ID, "worker"), Command: "sh -ic 'cat; :'"}); err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	m = newManager()
+	if err := m.Reattach(ctx, wf, "worker"); err != nil {
+		t.Fatalf("Reattach() error = %v", err)
+	}
+	if alive, _ := m.IsRunning(ctx, "worker"); !alive {
+		t.Fatal("IsRunning() = false after reattaching a live session")
+	}
+	if got := m.GetWorkdir("worker"); got != workdir {
+		t.Errorf("GetWorkdir() = %q, want %q", got, workdir)
+	}
+	if state := m.agents["worker"]; state.adapterName != "sim" {
+		t.Errorf("adapter = %q, want sim", state.adapterName)
+	}
+}
+
+func TestTmuxAgentManager_Adopt(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "sim", "sh", "")
+
+	socket := filepath.Join(t.TempDir(), "tmux.sock")
+	m := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger())
+	m.SetTmuxSocket(socket)
+	t.Cleanup(func() { exec.Command("tmux", "-S", socket, "kill-server").Run() })
+
+	ctx := context.Background()
+	priorSession := BuildTmuxSessionName("run-a", "worker")
+	prior := &types.AgentInfo{TmuxSession: priorSession, Status: "active", Workdir: t.TempDir()}
+
+	// Run A's agent is gone: nothing to adopt
+	wf := newSpawnRun("sim", "")
+	if adopted, err := m.Adopt(ctx, wf, "worker", prior); err != nil || adopted {
+		t.Fatalf("Adopt() with no session = %v, %v; want false, nil", adopted, err)
+	}
+
+	// Run A's agent is still alive: run B takes over its session
+	if err := m.tmux.NewSession(ctx, agent.SessionOptions{Name: priorSession, Command: "sh -ic 'cat; :'"}); err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	adopted, err := m.Adopt(ctx, wf, "worker", prior)
+	if err != nil || !adopted {
+		t.Fatalf("Adopt() = %v, %v; want true, nil", adopted, err)
+	}
+	if alive, _ := m.IsRunning(ctx, "worker"); !alive {
+		t.Error("IsRunning() = false after adopting a live session")
+	}
+	if got := wf.Agents["worker"]; got == nil || got.TmuxSession != priorSession || got.Workdir != prior.Workdir {
+		t.Errorf("registered agent = %+v, want run-a's session and workdir", got)
+	}
+	if m.tmux.SessionExists(ctx, BuildTmuxSessionName(wf.ID, "worker")) {
+		t.Error("a new session was spawned for the adopted agent")
+	}
+	out, err := exec.Command("tmux", "-S", socket, "show-environment", "-t", priorSession, "MEOW_WORKFLOW").Output()
+	if err != nil {
+		t.Fatalf("show-environment: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "MEOW_WORKFLOW="+wf.ID {
+		t.Errorf("session environment = %q, want MEOW_WORKFLOW=%s", got, wf.ID)
+	}
+}
+
+func TestTmuxAgentManager_Interrupt_AdapterKeys(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "custom", "sh", "")
+	f, err := os.OpenFile(filepath.Join(adaptersDir, "custom", "adapter.toml"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("\n[graceful_stop]\ninterrupt_keys = [\"Escape\", \"stop-now\"]\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	socket := filepath.Join(t.TempDir(), "tmux.sock")
+	m := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger())
+	m.SetTmuxSocket(socket)
+	t.Cleanup(func() { exec.Command("tmux", "-S", socket, "kill-server").Run() })
+
+	// cat dies on C-c, taking the session with it; other keys are echoed
+	ctx := context.Background()
+	if err := m.tmux.NewSession(ctx, agent.SessionOptions{Name: "meow-test-custom", Command: "cat"}); err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	m.agents["worker"] = &agentState{tmuxSession: "meow-test-custom", adapterName: "custom"}
+
+	if err := m.Interrupt(ctx, "worker"); err != nil {
+		t.Fatalf("Interrupt() error = %v", err)
+	}
+
+	var pane string
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if !m.tmux.SessionExists(ctx, "meow-test-custom") {
+			t.Fatal("session exited: interrupt sent C-c instead of the adapter's interrupt_keys")
+		}
+		pane, _ = m.tmux.CapturePane(ctx, "meow-test-custom")
+		if strings.Contains(pane, "^[stop-now") {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("pane = %q, want the Escape + stop-now interrupt sequence", pane)
+}
+
+func TestTmuxAgentManager_CaptureTranscript(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	socket := filepath.Join(t.TempDir(), "tmux.sock")
+	m := NewTmuxAgentManager(t.TempDir(), adapter.NewRegistry("", t.TempDir()), testLogger())
+	m.SetTmuxSocket(socket)
+	t.Cleanup(func() { exec.Command("tmux", "-S", socket, "kill-server").Run() })
+
+	// More lines than the pane shows, so the start is only in the scrollback
+	ctx := context.Background()
+	if err := m.tmux.NewSession(ctx, agent.SessionOptions{Name: "meow-test-transcript", Command: "seq 1 200; sleep 30"}); err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	m.agents["worker"] = &agentState{tmuxSession: "meow-test-transcript"}
+
+	var transcript string
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var err error
+		transcript, err = m.CaptureTranscript(ctx, "worker")
+		if err != nil {
+			t.Fatalf("CaptureTranscript() error = %v", err)
+		}
+		if strings.HasSuffix(transcript, "\n200\n") {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !strings.HasPrefix(transcript, "1\n2\n") || !strings.HasSuffix(transcript, "\n200\n") {
+		t.Errorf("transcript = %q, want lines 1 through 200", transcript)
+	}
+
+	if _, err := m.CaptureTranscript(ctx, "unknown"); err == nil {
+		t.Error("CaptureTranscript() for an unknown agent should fail")
+	}
+}
+
+func TestTmuxAgentManager_ReadAgentLog(t *testing.T) {
+	logDir := t.TempDir()
+	m := NewTmuxAgentManagerWithOptions(t.TempDir(), adapter.NewRegistry("", t.TempDir()), testLogger(), AgentManagerOptions{
+		LoggingEnabled: true,
+		LogDir:         logDir,
+	})
+
+	if size, err := m.AgentLogSize("worker"); err != nil || size != 0 {
+		t.Fatalf("AgentLogSize() before any output = %d, %v; want 0, nil", size, err)
+	}
+	if err := os.WriteFile(filepath.Join(logDir, "worker.log"), []byte("first step\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	offset, err := m.AgentLogSize("worker")
+	if err != nil {
+		t.Fatalf("AgentLogSize() error = %v", err)
+	}
+	f, err := os.OpenFile(filepath.Join(logDir, "worker.log"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("second step\n")
+	f.Close()
+
+	got, err := m.ReadAgentLog("worker", offset)
+	if err != nil {
+		t.Fatalf("ReadAgentLog() error = %v", err)
+	}
+	if string(got) != "second step\n" {
+		t.Errorf("ReadAgentLog() = %q, want the output after the offset", got)
+	}
+
+	disabled := NewTmuxAgentManagerWithOptions(t.TempDir(), adapter.NewRegistry("", t.TempDir()), testLogger(), AgentManagerOptions{})
+	if _, err := disabled.ReadAgentLog("worker", 0); err == nil {
+		t.Error("ReadAgentLog() with logging disabled should fail")
+	}
+}
+
+func TestTmuxAgentManager_ExitStatus(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	adaptersDir := t.TempDir()
+	writeTestAdapter(t, adaptersDir, "failing", "sh -c 'exit 3'", "")
+	writeTestAdapter(t, adaptersDir, "brief", "sleep 1", "")
+	socket := filepath.Join(t.TempDir(), "tmux.sock")
+	t.Cleanup(func() { exec.Command("tmux", "-S", socket, "kill-server").Run() })
+
+	tests := []struct {
+		name       string
+		adapter    string
+		completion string
+		wantExit   bool
+	}{
+		{name: "recorded for completion exit", adapter: "failing", completion: types.CompletionExit, wantExit: true},
+		{name: "not recorded otherwise", adapter: "brief", completion: "", wantExit: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateDir := filepath.Join(t.TempDir(), "runs")
+			m := NewTmuxAgentManagerWithOptions(t.TempDir(), adapter.NewRegistry("", adaptersDir), testLogger(), AgentManagerOptions{
+				StateDir: stateDir,
+			})
+			m.SetTmuxSocket(socket)
+
+			wf := newSpawnRun(tt.adapter, "")
+			wf.Steps["spawn"].Spawn.StartupDelay = "10ms"
+			wf.Steps["work"] = &types.Step{
+				ID:       "work",
+				Executor: types.ExecutorAgent,
+				Status:   types.StepStatusPending,
+				Needs:    []string{"spawn"},
+				Agent:    &types.AgentConfig{Agent: "worker", Prompt: "Work", Completion: tt.completion},
+			}
+			ctx := context.Background()
+			if err := m.Start(ctx, wf, wf.Steps["spawn"]); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			t.Cleanup(func() { m.KillAll(ctx, wf) })
+
+			exitPath := filepath.Join(stateDir, BuildTmuxSessionName(wf.ID, "worker")+".exit")
+			if !tt.wantExit {
+				// Liveness comes from the shell's foreground process instead
+				waitForRunning := func(want bool) {
+					t.Helper()
+					deadline := time.Now().Add(5 * time.Second)
+					for {
+						if alive, _ := m.IsRunning(ctx, "worker"); alive == want {
+							return
+						}
+						if time.Now().After(deadline) {
+							t.Fatalf("IsRunning() never became %v", want)
+						}
+						time.Sleep(20 * time.Millisecond)
+					}
+				}
+				waitForRunning(true)
+				waitForRunning(false)
+				if _, err := os.Stat(exitPath); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("exit file stat error = %v, want no file written", err)
+				}
+				if exited, code, err := m.ExitStatus(ctx, "worker"); err != nil || !exited || code != -1 {
+					t.Errorf("ExitStatus() = %v, %d, %v; want exited with the code not recorded (-1)", exited, code, err)
+				}
+				return
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				exited, code, err := m.ExitStatus(ctx, "worker")
+				if err != nil {
+					t.Fatalf("ExitStatus() error = %v", err)
+				}
+				if exited {
+					if code != 3 {
+						t.Errorf("exit code = %d, want 3", code)
+					}
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("agent exit was never recorded")
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+			if _, err := os.Stat(exitPath); err != nil {
+				t.Errorf("exit file not under the state dir: %v", err)
+			}
+			if alive, _ := m.IsRunning(ctx, "worker"); alive {
+				t.Error("IsRunning() = true after the agent exited")
+			}
+		})
+	}
+}
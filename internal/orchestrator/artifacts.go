@@ -0,0 +1,259 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// SetArtifactsDir sets the directory that receives outputs marked
+// artifact = true, laid out as <dir>/<step>/<output> with a manifest.json at
+// the root. An empty dir disables artifact persistence.
+func (o *Orchestrator) SetArtifactsDir(dir string) {
+	o.artifactsDir = dir
+}
+
+// persistShellArtifacts saves the artifact-marked outputs of a completed shell
+// step. Outputs captured from a file are copied; others are written from the
+// captured value. Failures are logged and never fail the step.
+func (o *Orchestrator) persistShellArtifacts(wf *types.Run, step *types.Step, sources map[string]types.OutputSource, outputs map[string]any, substituteSource SourceSubstituteFunc) {
+	if o.artifactsDir == "" {
+		return
+	}
+
+	var entries []types.ArtifactEntry
+	for _, name := range sortedKeys(sources) {
+		source := sources[name]
+		if !source.Artifact {
+			continue
+		}
+		srcPath, fromFile := source.FilePath()
+		if fromFile {
+			if substituteSource != nil && strings.Contains(srcPath, "{{") {
+				substituted, err := substituteSource(srcPath)
+				if err != nil {
+					o.logger.Warn("artifact path substitution failed", "step", step.ID, "output", name, "error", err)
+					continue
+				}
+				srcPath = substituted
+			}
+		}
+		entry, err := o.saveArtifact(step.ID, name, srcPath, outputs[name])
+		if err != nil {
+			o.logger.Warn("failed to persist artifact", "step", step.ID, "output", name, "error", err)
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	o.recordArtifacts(wf.ID, entries)
+}
+
+// persistAgentArtifacts saves the artifact-marked outputs of a completed agent
+// step. file_path outputs are copied from the agent's workdir.
+func (o *Orchestrator) persistAgentArtifacts(wf *types.Run, step *types.Step, defs map[string]types.AgentOutputDef, outputs map[string]any, agentWorkdir string) {
+	if o.artifactsDir == "" {
+		return
+	}
+
+	var entries []types.ArtifactEntry
+	for _, name := range sortedKeys(defs) {
+		def := defs[name]
+		if !def.Artifact {
+			continue
+		}
+		value, ok := outputs[name]
+		if !ok {
+			continue
+		}
+		srcPath := ""
+		if path, isString := value.(string); isString && def.Type == "file_path" {
+			srcPath = path
+			if !filepath.IsAbs(srcPath) {
+				srcPath = filepath.Join(agentWorkdir, srcPath)
+			}
+		}
+		entry, err := o.saveArtifact(step.ID, name, srcPath, value)
+		if err != nil {
+			o.logger.Warn("failed to persist artifact", "step", step.ID, "output", name, "error", err)
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	o.recordArtifacts(wf.ID, entries)
+}
+
+// TranscriptOutput is the output under which a capture_transcript step
+// exposes the path of its saved pane transcript.
+const TranscriptOutput = "transcript"
+
+// captureTranscript saves the agent's pane transcript as the step's
+// transcript.txt artifact and returns outputs with TranscriptOutput set to its
+// absolute path. Failures are logged and never fail the step.
+func (o *Orchestrator) captureTranscript(ctx context.Context, wf *types.Run, step *types.Step, outputs map[string]any) map[string]any {
+	capturer, ok := o.agents.(TranscriptCapturer)
+	if !ok {
+		o.logger.Warn("agent manager cannot capture transcripts", "step", step.ID)
+		return outputs
+	}
+	if o.artifactsDir == "" {
+		o.logger.Warn("transcript capture needs an artifacts directory", "step", step.ID)
+		return outputs
+	}
+
+	transcript, err := capturer.CaptureTranscript(ctx, step.Agent.Agent)
+	if err != nil {
+		o.logger.Warn("failed to capture transcript", "step", step.ID, "agent", step.Agent.Agent, "error", err)
+		return outputs
+	}
+	entry, err := o.saveArtifact(step.ID, TranscriptOutput, "", transcript)
+	if err != nil {
+		o.logger.Warn("failed to persist transcript", "step", step.ID, "error", err)
+		return outputs
+	}
+	o.recordArtifacts(wf.ID, []types.ArtifactEntry{*entry})
+
+	withTranscript := make(map[string]any, len(outputs)+1)
+	for k, v := range outputs {
+		withTranscript[k] = v
+	}
+	withTranscript[TranscriptOutput] = o.artifactPath(entry)
+	return withTranscript
+}
+
+// artifactPath returns the absolute path of a saved artifact.
+func (o *Orchestrator) artifactPath(entry *types.ArtifactEntry) string {
+	path := filepath.Join(o.artifactsDir, filepath.FromSlash(entry.Path))
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// saveArtifact writes one output under <artifactsDir>/<step>/. When srcPath is
+// set the file is copied as <output> plus the source's extension; otherwise the
+// value is written as <output>.txt (strings) or <output>.json (anything else).
+func (o *Orchestrator) saveArtifact(stepID, name, srcPath string, value any) (*types.ArtifactEntry, error) {
+	stepDir := filepath.Join(o.artifactsDir, stepID)
+	if err := os.MkdirAll(stepDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating artifact directory: %w", err)
+	}
+
+	var dest string
+	var size int64
+	if srcPath != "" {
+		dest = filepath.Join(stepDir, name+filepath.Ext(srcPath))
+		n, err := copyFile(srcPath, dest)
+		if err != nil {
+			return nil, err
+		}
+		size = n
+	} else {
+		var data []byte
+		if s, ok := value.(string); ok {
+			dest = filepath.Join(stepDir, name+".txt")
+			data = []byte(s)
+		} else {
+			dest = filepath.Join(stepDir, name+".json")
+			encoded, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("encoding output: %w", err)
+			}
+			data = encoded
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return nil, fmt.Errorf("writing artifact: %w", err)
+		}
+		size = int64(len(data))
+	}
+
+	rel, err := filepath.Rel(o.artifactsDir, dest)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ArtifactEntry{
+		Step:    stepID,
+		Output:  name,
+		Path:    filepath.ToSlash(rel),
+		Source:  srcPath,
+		Size:    size,
+		SavedAt: time.Now(),
+	}, nil
+}
+
+// recordArtifacts merges entries into the manifest, replacing earlier entries
+// for the same step output (e.g., when a step is re-run).
+func (o *Orchestrator) recordArtifacts(runID string, entries []types.ArtifactEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	path := filepath.Join(o.artifactsDir, types.ArtifactManifestFile)
+	manifest := &types.ArtifactManifest{RunID: runID}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, manifest); err != nil {
+			o.logger.Warn("artifact manifest is corrupt, rewriting", "path", path, "error", err)
+			manifest = &types.ArtifactManifest{RunID: runID}
+		}
+	}
+
+	for _, entry := range entries {
+		if existing := manifest.Find(entry.Step, entry.Output); existing != nil {
+			*existing = entry
+		} else {
+			manifest.Artifacts = append(manifest.Artifacts, entry)
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		o.logger.Warn("failed to encode artifact manifest", "error", err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		o.logger.Warn("failed to write artifact manifest", "path", path, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		o.logger.Warn("failed to write artifact manifest", "path", path, "error", err)
+	}
+}
+
+// copyFile copies src to dst and returns the number of bytes written.
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("opening artifact source: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, fmt.Errorf("creating artifact: %w", err)
+	}
+	n, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("copying artifact: %w", err)
+	}
+	return n, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func readArtifactManifest(t *testing.T, dir string) *types.ArtifactManifest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, types.ArtifactManifestFile))
+	if err != nil {
+		t.Fatalf("reading manifest: %v", err)
+	}
+	var manifest types.ArtifactManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("parsing manifest: %v", err)
+	}
+	return &manifest
+}
+
+func TestOrchestrator_PersistsArtifactOutputs(t *testing.T) {
+	workDir := t.TempDir()
+	artifactsDir := filepath.Join(t.TempDir(), "test-wf")
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["report"] = &types.Step{
+		ID:       "report",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell: &types.ShellConfig{
+			Command: "cd " + workDir + " && printf '# Report\\nall good\\n' > report.md && echo summary-line",
+			Outputs: map[string]types.OutputSource{
+				"report":  {Source: "file:" + filepath.Join(workDir, "report.md"), Artifact: true},
+				"summary": {Source: "stdout", Artifact: true},
+				"scratch": {Source: "stderr"},
+			},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetArtifactsDir(artifactsDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	copied, err := os.ReadFile(filepath.Join(artifactsDir, "report", "report.md"))
+	if err != nil {
+		t.Fatalf("reading copied artifact: %v", err)
+	}
+	if string(copied) != "# Report\nall good\n" {
+		t.Errorf("copied artifact = %q, want original file contents", copied)
+	}
+
+	manifest := readArtifactManifest(t, artifactsDir)
+	if manifest.RunID != wf.ID {
+		t.Errorf("manifest run_id = %q, want %q", manifest.RunID, wf.ID)
+	}
+	if len(manifest.Artifacts) != 2 {
+		t.Fatalf("manifest has %d entries, want 2: %+v", len(manifest.Artifacts), manifest.Artifacts)
+	}
+
+	report := manifest.Find("report", "report")
+	if report == nil {
+		t.Fatal("manifest missing report.report entry")
+	}
+	if report.Path != "report/report.md" || report.Size != int64(len(copied)) {
+		t.Errorf("report entry = %+v, want path report/report.md and size %d", report, len(copied))
+	}
+	if report.Source != filepath.Join(workDir, "report.md") {
+		t.Errorf("report entry source = %q, want original path", report.Source)
+	}
+
+	summary := manifest.Find("report", "summary")
+	if summary == nil || summary.Path != "report/summary.txt" {
+		t.Fatalf("summary entry = %+v, want path report/summary.txt", summary)
+	}
+	if data, _ := os.ReadFile(filepath.Join(artifactsDir, "report", "summary.txt")); string(data) != "summary-line" {
+		t.Errorf("summary artifact = %q, want summary-line", data)
+	}
+
+	if manifest.Find("report", "scratch") != nil {
+		t.Error("unmarked output should not be persisted")
+	}
+}
@@ -0,0 +1,58 @@
+package orchestrator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/akatz-ai/meow/internal/types"
+	"github.com/akatz-ai/meow/internal/workflow"
+)
+
+// checkOutputAssertions checks captured outputs against a step's assert table.
+// It returns nil when every assertion holds. Otherwise it returns an
+// assertion_failed error naming each mismatch, with a want/got diff of all of
+// them in Output.
+func checkOutputAssertions(assertions map[string]types.OutputAssertion, outputs map[string]any) *types.StepError {
+	var summaries []string
+	var diff strings.Builder
+	for _, name := range sortedKeys(assertions) {
+		a := assertions[name]
+
+		value, present := outputs[name]
+		got := "(missing)"
+		if present && value != nil {
+			got = fmt.Sprintf("%q", workflow.StringifyValue(value))
+		}
+
+		var want string
+		var ok bool
+		if a.Matches != "" {
+			want = fmt.Sprintf("match /%s/", a.Matches)
+			re, err := regexp.Compile(a.Matches)
+			if err != nil {
+				want = fmt.Sprintf("match /%s/ (invalid regex: %v)", a.Matches, err)
+			} else {
+				ok = present && value != nil && re.MatchString(workflow.StringifyValue(value))
+			}
+		} else {
+			want = fmt.Sprintf("%q", a.Equals)
+			ok = present && value != nil && workflow.StringifyValue(value) == a.Equals
+		}
+		if ok {
+			continue
+		}
+
+		summaries = append(summaries, fmt.Sprintf("%s = %s, want %s", name, got, want))
+		fmt.Fprintf(&diff, "%s:\n  - want %s\n  + got  %s\n", name, want, got)
+	}
+
+	if len(summaries) == 0 {
+		return nil
+	}
+	return &types.StepError{
+		Message: "output assertion failed: " + strings.Join(summaries, "; "),
+		Output:  diff.String(),
+		Type:    types.StepErrorAssertionFailed,
+	}
+}
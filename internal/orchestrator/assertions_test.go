@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestCheckOutputAssertions(t *testing.T) {
+	assertions := map[string]types.OutputAssertion{
+		"status":  {Equals: "ok"},
+		"version": {Matches: `^v\d+\.\d+`},
+		"count":   {Equals: "3"},
+	}
+
+	if err := checkOutputAssertions(assertions, map[string]any{
+		"status": "ok", "version": "v1.4.0", "count": 3,
+	}); err != nil {
+		t.Fatalf("expected assertions to hold, got %+v", err)
+	}
+
+	err := checkOutputAssertions(assertions, map[string]any{"status": "degraded", "count": 3})
+	if err == nil {
+		t.Fatal("expected assertion failure")
+	}
+	if err.Type != types.StepErrorAssertionFailed {
+		t.Errorf("Type = %q, want assertion_failed", err.Type)
+	}
+	wantMessage := `output assertion failed: status = "degraded", want "ok"; version = (missing), want match /^v\d+\.\d+/`
+	if err.Message != wantMessage {
+		t.Errorf("Message = %q, want %q", err.Message, wantMessage)
+	}
+	wantDiff := "status:\n  - want \"ok\"\n  + got  \"degraded\"\n" +
+		"version:\n  - want match /^v\\d+\\.\\d+/\n  + got  (missing)\n"
+	if err.Output != wantDiff {
+		t.Errorf("Output = %q, want %q", err.Output, wantDiff)
+	}
+
+	if err := checkOutputAssertions(nil, map[string]any{"status": "anything"}); err != nil {
+		t.Errorf("no assertions should pass, got %+v", err)
+	}
+}
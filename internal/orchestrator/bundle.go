@@ -0,0 +1,153 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// BundleVersion is the run bundle format written by ExportRun.
+const BundleVersion = 1
+
+// RunBundle is a self-contained export of a run: its persisted state (steps,
+// outputs, agents) plus the content of the template modules it was baked and
+// expands from, so it can be inspected or resumed on another machine.
+type RunBundle struct {
+	Version    int               `yaml:"version"`
+	ExportedAt time.Time         `yaml:"exported_at"`
+	Templates  []BundledTemplate `yaml:"templates,omitempty"`
+	Run        *types.Run        `yaml:"run"`
+}
+
+// BundledTemplate is the content of a template module referenced by the run.
+type BundledTemplate struct {
+	Path    string `yaml:"path"` // Path as recorded in the run (Template or a step's SourceModule)
+	Content string `yaml:"content"`
+}
+
+// ExportRun builds a bundle for the run with the given ID. Referenced template
+// modules that no longer exist on disk (or live in the embedded FS) are left
+// out; their references are kept as-is.
+func ExportRun(ctx context.Context, store RunStore, id string) (*RunBundle, error) {
+	wf, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &RunBundle{
+		Version:    BundleVersion,
+		ExportedAt: time.Now(),
+		Run:        wf,
+	}
+	for _, path := range referencedModules(wf) {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading template %s: %w", path, err)
+		}
+		bundle.Templates = append(bundle.Templates, BundledTemplate{Path: path, Content: string(content)})
+	}
+	return bundle, nil
+}
+
+// ImportRun writes the bundled templates under templatesDir, points the run's
+// template references at those copies, and creates the run in store. The run
+// keeps its ID and state; the orchestrator PID from the exporting machine is
+// cleared so the run can be resumed with meow resume.
+func ImportRun(ctx context.Context, store RunStore, bundle *RunBundle, templatesDir string) (*types.Run, error) {
+	if bundle.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (want %d)", bundle.Version, BundleVersion)
+	}
+	wf := bundle.Run
+	if wf == nil || wf.ID == "" {
+		return nil, fmt.Errorf("bundle has no run")
+	}
+	// The ID names the run's state file and templates directory
+	if strings.ContainsAny(wf.ID, `/\`) || strings.Contains(wf.ID, "..") {
+		return nil, fmt.Errorf("invalid run id %q in bundle", wf.ID)
+	}
+
+	relocated := make(map[string]string, len(bundle.Templates))
+	if len(bundle.Templates) > 0 {
+		if err := os.MkdirAll(templatesDir, 0755); err != nil {
+			return nil, fmt.Errorf("creating templates directory: %w", err)
+		}
+	}
+	used := make(map[string]bool)
+	for i, tmpl := range bundle.Templates {
+		name := filepath.Base(tmpl.Path)
+		if used[name] {
+			name = fmt.Sprintf("%d-%s", i, name)
+		}
+		used[name] = true
+
+		dest := filepath.Join(templatesDir, name)
+		if err := os.WriteFile(dest, []byte(tmpl.Content), 0644); err != nil {
+			return nil, fmt.Errorf("writing template %s: %w", name, err)
+		}
+		relocated[tmpl.Path] = dest
+	}
+
+	if dest, ok := relocated[wf.Template]; ok {
+		wf.Template = dest
+	}
+	for _, step := range wf.Steps {
+		if dest, ok := relocated[step.SourceModule]; ok {
+			step.SourceModule = dest
+		}
+	}
+	wf.OrchestratorPID = 0
+
+	if err := store.Create(ctx, wf); err != nil {
+		return nil, err
+	}
+	return wf, nil
+}
+
+// WriteBundle encodes a bundle as YAML.
+func WriteBundle(w io.Writer, bundle *RunBundle) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(bundle)
+}
+
+// ReadBundle decodes a bundle written by WriteBundle.
+func ReadBundle(r io.Reader) (*RunBundle, error) {
+	var bundle RunBundle
+	if err := yaml.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("decoding bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// referencedModules returns the template module paths a run resolves local
+// references against, in a stable order.
+func referencedModules(wf *types.Run) []string {
+	seen := make(map[string]bool)
+	if wf.Template != "" {
+		seen[wf.Template] = true
+	}
+	for _, step := range wf.Steps {
+		if step.SourceModule != "" {
+			seen[step.SourceModule] = true
+		}
+	}
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,177 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+const bundleTestTemplate = `
+[main]
+name = "main"
+
+[[main.steps]]
+id = "prepare"
+executor = "shell"
+command = "true"
+
+[[main.steps]]
+id = "work"
+executor = "expand"
+template = ".child"
+needs = ["prepare"]
+
+[child]
+name = "child"
+internal = true
+
+[child.variables]
+out = { required = true }
+
+[[child.steps]]
+id = "write"
+executor = "shell"
+command = "echo child > {{out}}"
+`
+
+func TestExportImportRun_ResumesOnFreshStore(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	templatePath := filepath.Join(srcDir, "flow.meow.toml")
+	if err := os.WriteFile(templatePath, []byte(bundleTestTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srcStore, err := NewYAMLRunStore(filepath.Join(srcDir, "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mid-run: prepare is done and captured an output, the expand step was in
+	// flight when the orchestrator went away
+	outFile := filepath.Join(t.TempDir(), "child.txt")
+	startedAt := time.Now().Add(-time.Minute)
+	wf := types.NewRun("run-export", templatePath, map[string]any{"out": outFile})
+	wf.Status = types.RunStatusRunning
+	wf.OrchestratorPID = 999999
+	wf.Steps["prepare"] = &types.Step{
+		ID:       "prepare",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusDone,
+		Outputs:  map[string]any{"exit_code": 0},
+		Shell:    &types.ShellConfig{Command: "true"},
+	}
+	wf.Steps["work"] = &types.Step{
+		ID:        "work",
+		Executor:  types.ExecutorExpand,
+		Status:    types.StepStatusRunning,
+		StartedAt: &startedAt,
+		Needs:     []string{"prepare"},
+		Expand: &types.ExpandConfig{
+			Template:  ".child",
+			Variables: map[string]any{"out": outFile},
+		},
+	}
+	if err := srcStore.Create(ctx, wf); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := ExportRun(ctx, srcStore, wf.ID)
+	if err != nil {
+		t.Fatalf("ExportRun() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, bundle); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+
+	// The original machine (and its template) are gone
+	if err := os.RemoveAll(srcDir); err != nil {
+		t.Fatal(err)
+	}
+
+	dstDir := t.TempDir()
+	dstStore, err := NewYAMLRunStore(filepath.Join(dstDir, "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadBundle(&buf)
+	if err != nil {
+		t.Fatalf("ReadBundle() error = %v", err)
+	}
+	imported, err := ImportRun(ctx, dstStore, read, filepath.Join(dstDir, "imports", wf.ID))
+	if err != nil {
+		t.Fatalf("ImportRun() error = %v", err)
+	}
+	if want := filepath.Join(dstDir, "imports", wf.ID, "flow.meow.toml"); imported.Template != want {
+		t.Errorf("imported template = %q, want %q", imported.Template, want)
+	}
+	if imported.OrchestratorPID != 0 {
+		t.Errorf("imported OrchestratorPID = %d, want cleared", imported.OrchestratorPID)
+	}
+	if _, err := ImportRun(ctx, dstStore, read, filepath.Join(dstDir, "imports", wf.ID)); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second ImportRun() error = %v, want already exists", err)
+	}
+
+	// Recovery on the fresh store resumes the run from the bundled template
+	orch := New(testConfig(), dstStore, newMockAgentManager(), newMockShellRunner(), NewTemplateExpanderAdapter(dstDir), testLogger())
+	orch.SetWorkflowID(wf.ID)
+	if err := orch.Recover(ctx); err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	runCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := orch.Run(runCtx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	final, err := dstStore.Get(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Status != types.RunStatusDone {
+		t.Errorf("resumed run status = %s, want done", final.Status)
+	}
+	if final.Steps["prepare"].Outputs["exit_code"] != 0 {
+		t.Errorf("prepare outputs = %v, want preserved from export", final.Steps["prepare"].Outputs)
+	}
+	if data, err := os.ReadFile(outFile); err != nil || strings.TrimSpace(string(data)) != "child" {
+		t.Errorf("expanded child step output = %q, %v; want it to have run", data, err)
+	}
+}
+
+func TestImportRun_RejectsUnknownVersion(t *testing.T) {
+	store := newMockRunStore()
+	bundle := &RunBundle{Version: BundleVersion + 1, Run: types.NewRun("run-x", "t", nil)}
+	if _, err := ImportRun(context.Background(), store, bundle, t.TempDir()); err == nil || !strings.Contains(err.Error(), "unsupported bundle version") {
+		t.Errorf("ImportRun() error = %v, want unsupported version", err)
+	}
+}
+
+func TestImportRun_RejectsUnsafeRunID(t *testing.T) {
+	for _, id := range []string{"../../x", "runs/x", `..\x`, ".."} {
+		t.Run(id, func(t *testing.T) {
+			store := newMockRunStore()
+			templatesDir := filepath.Join(t.TempDir(), "imports")
+			bundle := &RunBundle{
+				Version:   BundleVersion,
+				Run:       types.NewRun(id, "t", nil),
+				Templates: []BundledTemplate{{Path: "/src/t.meow.toml", Content: "[main]\n"}},
+			}
+			if _, err := ImportRun(context.Background(), store, bundle, templatesDir); err == nil || !strings.Contains(err.Error(), "invalid run id") {
+				t.Errorf("ImportRun() error = %v, want invalid run id", err)
+			}
+			if _, err := os.Stat(templatesDir); !os.IsNotExist(err) {
+				t.Errorf("templates directory created for rejected bundle (stat error = %v)", err)
+			}
+			if len(store.workflows) != 0 {
+				t.Errorf("store has %d runs, want none", len(store.workflows))
+			}
+		})
+	}
+}
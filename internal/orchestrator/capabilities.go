@@ -0,0 +1,32 @@
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// assignCapableAgent points an agent step with requires_capability at the
+// first idle, live agent spawned with that capability, in agent ID order. It
+// returns false if there is none; the step then stays pending. The agent is
+// chosen afresh on every dispatch, so a retried step may land elsewhere.
+func (o *Orchestrator) assignCapableAgent(ctx context.Context, wf *types.Run, step *types.Step) bool {
+	capability := step.Agent.RequiresCapability
+	for _, agentID := range wf.AgentsWithCapability(capability) {
+		if !wf.AgentIsIdle(agentID) {
+			continue
+		}
+		if o.agents != nil {
+			if alive, err := o.agents.IsRunning(ctx, agentID); err != nil || !alive {
+				continue
+			}
+		}
+		if step.Agent.Agent != agentID {
+			o.logger.Info("routing step by capability", "step", step.ID, "capability", capability, "agent", agentID)
+		}
+		step.Agent.Agent = agentID
+		return true
+	}
+	o.logger.Debug("agent step waiting for a capable agent", "step", step.ID, "capability", capability)
+	return false
+}
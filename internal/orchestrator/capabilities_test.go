@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestCapabilities_RoutesStepToCapableAgent(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["spawn-cpu"] = &types.Step{
+		ID:       "spawn-cpu",
+		Executor: types.ExecutorSpawn,
+		Status:   types.StepStatusPending,
+		Spawn:    &types.SpawnConfig{Agent: "cpu-worker"},
+	}
+	wf.Steps["spawn-gpu"] = &types.Step{
+		ID:       "spawn-gpu",
+		Executor: types.ExecutorSpawn,
+		Status:   types.StepStatusPending,
+		Spawn:    &types.SpawnConfig{Agent: "gpu-worker", Capabilities: []string{"gpu"}},
+	}
+	wf.Steps["lint"] = &types.Step{
+		ID:       "lint",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"spawn-cpu", "spawn-gpu"},
+		Agent:    &types.AgentConfig{Agent: "cpu-worker", Prompt: "lint the code"},
+	}
+	wf.Steps["train"] = &types.Step{
+		ID:       "train",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"spawn-cpu", "spawn-gpu"},
+		Agent:    &types.AgentConfig{RequiresCapability: "gpu", Prompt: "train the model"},
+	}
+	store.workflows[wf.ID] = wf
+
+	ctx := context.Background()
+	for range 2 {
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow() error = %v", err)
+		}
+	}
+
+	if got := wf.AgentsWithCapability("gpu"); len(got) != 1 || got[0] != "gpu-worker" {
+		t.Errorf("AgentsWithCapability(gpu) = %v, want [gpu-worker]", got)
+	}
+	train := wf.Steps["train"]
+	if train.Status != types.StepStatusRunning {
+		t.Fatalf("train status = %s, want running", train.Status)
+	}
+	if train.Agent.Agent != "gpu-worker" {
+		t.Errorf("train agent = %q, want gpu-worker", train.Agent.Agent)
+	}
+
+	got := make(map[string]string)
+	for _, inj := range agents.GetInjections() {
+		got[inj.AgentID] = inj.Prompt
+	}
+	if !strings.Contains(got["gpu-worker"], "train the model") {
+		t.Errorf("gpu-worker prompt = %q, want the train step", got["gpu-worker"])
+	}
+	if !strings.Contains(got["cpu-worker"], "lint the code") {
+		t.Errorf("cpu-worker prompt = %q, want the lint step", got["cpu-worker"])
+	}
+}
+
+func TestCapabilities_WaitsForCapableAgent(t *testing.T) {
+	agents := newMockAgentManager()
+	orch := New(testConfig(), newMockRunStore(), agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.SetAgentCapabilities("gpu-worker", []string{"gpu"})
+	wf.Steps["busy"] = &types.Step{
+		ID:       "busy",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusRunning,
+		Agent:    &types.AgentConfig{Agent: "gpu-worker", Prompt: "first"},
+	}
+	train := &types.Step{
+		ID:       "train",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Agent:    &types.AgentConfig{RequiresCapability: "gpu", Prompt: "second"},
+	}
+	wf.Steps[train.ID] = train
+	ctx := context.Background()
+
+	// Registered but not running: not a candidate
+	if orch.assignCapableAgent(ctx, wf, train) {
+		t.Fatal("step assigned to an agent that is not running")
+	}
+	agents.running["gpu-worker"] = true
+
+	// Running but busy with another step
+	if orch.assignCapableAgent(ctx, wf, train) {
+		t.Fatal("step assigned to a busy agent")
+	}
+
+	wf.Steps["busy"].Status = types.StepStatusDone
+	if !orch.assignCapableAgent(ctx, wf, train) || train.Agent.Agent != "gpu-worker" {
+		t.Errorf("agent = %q, want gpu-worker once idle", train.Agent.Agent)
+	}
+}
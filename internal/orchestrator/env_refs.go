@@ -0,0 +1,38 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Commands can read the orchestrator's environment through {{env.NAME}}
+// references, e.g. command = "deploy --token {{env.DEPLOY_TOKEN}}". Baking
+// leaves them in place and they are substituted only into the command being
+// run, so values such as tokens are never saved with the run. A reference to
+// an unset variable fails the step rather than running with an empty value;
+// listing the variable in the workflow's required_env catches it at startup.
+
+// envRefPattern matches {{env.NAME}} references.
+var envRefPattern = regexp.MustCompile(`\{\{\s*env\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// resolveEnvRefs substitutes {{env.NAME}} references in s from the process
+// environment. It fails on the first variable that is not set.
+func resolveEnvRefs(s string) (string, error) {
+	var missing string
+	result := envRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := envRefPattern.FindStringSubmatch(match)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if missing == "" {
+				missing = name
+			}
+			return match
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set (referenced as {{env.%s}})", missing, missing)
+	}
+	return result, nil
+}
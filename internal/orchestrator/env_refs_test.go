@@ -0,0 +1,73 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestResolveEnvRefs(t *testing.T) {
+	t.Setenv("MEOW_TEST_TOKEN", "s3cret")
+	t.Setenv("MEOW_TEST_EMPTY", "")
+
+	got, err := resolveEnvRefs("deploy --token {{env.MEOW_TEST_TOKEN}} --note '{{ env.MEOW_TEST_EMPTY }}' {{step.outputs.x}}")
+	if err != nil {
+		t.Fatalf("resolveEnvRefs() error = %v", err)
+	}
+	if want := "deploy --token s3cret --note '' {{step.outputs.x}}"; got != want {
+		t.Errorf("resolveEnvRefs() = %q, want %q", got, want)
+	}
+
+	_, err = resolveEnvRefs("deploy --token {{env.MEOW_TEST_UNSET}}")
+	if err == nil || !strings.Contains(err.Error(), "environment variable MEOW_TEST_UNSET is not set") {
+		t.Errorf("resolveEnvRefs() error = %v, want not set", err)
+	}
+}
+
+func TestOrchestrator_ShellEnvRefs(t *testing.T) {
+	t.Setenv("MEOW_TEST_TOKEN", "s3cret")
+	out := filepath.Join(t.TempDir(), "token")
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["deploy"] = &types.Step{
+		ID:       "deploy",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "printf %s {{env.MEOW_TEST_TOKEN}} > " + out},
+	}
+	wf.Steps["missing"] = &types.Step{
+		ID:       "missing",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "echo {{env.MEOW_TEST_UNSET}}"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if err := orch.processWorkflow(context.Background(), wf); err != nil {
+		t.Fatalf("processWorkflow() error = %v", err)
+	}
+	orch.wg.Wait()
+
+	if data, err := os.ReadFile(out); err != nil || string(data) != "s3cret" {
+		t.Errorf("command wrote %q (err %v), want s3cret", data, err)
+	}
+	// The value is substituted only into what runs, not saved with the step
+	if cmd := wf.Steps["deploy"].Branch.Condition; strings.Contains(cmd, "s3cret") {
+		t.Errorf("saved condition %q contains the environment value", cmd)
+	}
+
+	step := wf.Steps["missing"]
+	if step.Status != types.StepStatusFailed {
+		t.Fatalf("missing status = %s, want failed", step.Status)
+	}
+	if step.Error == nil || !strings.Contains(step.Error.Message, "MEOW_TEST_UNSET is not set") {
+		t.Errorf("missing error = %+v, want not set", step.Error)
+	}
+}
@@ -26,6 +26,12 @@ type eventWaiter struct {
 	deadline  time.Time
 }
 
+// expired reports whether the waiter's deadline has passed at now.
+// Waiters without a deadline never expire.
+func (w *eventWaiter) expired(now time.Time) bool {
+	return !w.deadline.IsZero() && now.After(w.deadline)
+}
+
 // NewEventRouter creates a new event router.
 func NewEventRouter(logger *slog.Logger) *EventRouter {
 	if logger == nil {
@@ -39,6 +45,7 @@ func NewEventRouter(logger *slog.Logger) *EventRouter {
 
 // RegisterWaiter registers a waiter for events of the given type.
 // Returns a channel that will receive the matching event or be closed on timeout/cancellation.
+// A timeout of zero or less never expires; remove such waiters with RemoveWaiter.
 func (r *EventRouter) RegisterWaiter(eventType string, filter map[string]string, timeout time.Duration) <-chan *ipc.EventMessage {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -48,7 +55,9 @@ func (r *EventRouter) RegisterWaiter(eventType string, filter map[string]string,
 		eventType: eventType,
 		filter:    filter,
 		response:  ch,
-		deadline:  time.Now().Add(timeout),
+	}
+	if timeout > 0 {
+		waiter.deadline = time.Now().Add(timeout)
 	}
 
 	r.waiters[eventType] = append(r.waiters[eventType], waiter)
@@ -79,7 +88,7 @@ func (r *EventRouter) Route(event *ipc.EventMessage) bool {
 	for i, waiter := range waiters {
 		if r.matchesFilter(event, waiter.filter) {
 			// Check if waiter hasn't expired
-			if time.Now().After(waiter.deadline) {
+			if waiter.expired(time.Now()) {
 				r.logger.Debug("waiter expired", "event_type", event.EventType)
 				continue
 			}
@@ -111,6 +120,28 @@ func (r *EventRouter) Route(event *ipc.EventMessage) bool {
 	return false
 }
 
+// RemoveWaiter unregisters the waiter that returned ch, if it is still
+// waiting, without closing the channel. Returns true if a waiter was removed.
+func (r *EventRouter) RemoveWaiter(ch <-chan *ipc.EventMessage) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for eventType, waiters := range r.waiters {
+		for i, waiter := range waiters {
+			if (<-chan *ipc.EventMessage)(waiter.response) != ch {
+				continue
+			}
+			r.waiters[eventType] = append(waiters[:i], waiters[i+1:]...)
+			if len(r.waiters[eventType]) == 0 {
+				delete(r.waiters, eventType)
+			}
+			r.logger.Debug("waiter removed", "event_type", eventType, "filter", waiter.filter)
+			return true
+		}
+	}
+	return false
+}
+
 // matchesFilter checks if an event matches all the filter criteria.
 // All filter key-value pairs must match the event data exactly.
 // Special keys "agent" and "workflow" are matched against the event's
@@ -158,7 +189,7 @@ func (r *EventRouter) Cleanup() int {
 	for eventType, waiters := range r.waiters {
 		var active []*eventWaiter
 		for _, waiter := range waiters {
-			if now.Before(waiter.deadline) {
+			if !waiter.expired(now) {
 				active = append(active, waiter)
 			} else {
 				// Close the channel to signal timeout
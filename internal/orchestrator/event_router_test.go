@@ -260,3 +260,35 @@ func TestEventRouter_NoFilter(t *testing.T) {
 		t.Error("timeout waiting for event")
 	}
 }
+
+func TestEventRouter_NoTimeoutAndRemove(t *testing.T) {
+	router := NewEventRouter(nil)
+
+	// A waiter without a timeout survives cleanup
+	ch := router.RegisterWaiter("approve", nil, 0)
+	if removed := router.Cleanup(); removed != 0 {
+		t.Errorf("expected no waiters removed, got %d", removed)
+	}
+
+	if !router.RemoveWaiter(ch) {
+		t.Error("RemoveWaiter should remove the registered waiter")
+	}
+	if router.RemoveWaiter(ch) {
+		t.Error("RemoveWaiter should report an already removed waiter")
+	}
+	if router.Route(&ipc.EventMessage{Type: ipc.MsgEvent, EventType: "approve"}) {
+		t.Error("should not route to a removed waiter")
+	}
+
+	// A live waiter without a timeout still receives events
+	ch = router.RegisterWaiter("approve", nil, 0)
+	if !router.Route(&ipc.EventMessage{Type: ipc.MsgEvent, EventType: "approve"}) {
+		t.Fatal("should route to a waiter without a timeout")
+	}
+	select {
+	case <-ch:
+		// Expected
+	case <-time.After(50 * time.Millisecond):
+		t.Error("timeout waiting for event")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -94,6 +96,24 @@ func StartAgentStep(step *types.Step) (*AgentStartResult, *types.StepError) {
 	}, nil
 }
 
+// wrapAgentPrompt surrounds an agent prompt with the run's prompt_prefix and
+// prompt_suffix, separated by blank lines. Steps with skip_prompt_wrap are
+// returned unchanged.
+func wrapAgentPrompt(prompt string, wf *types.Run, cfg *types.AgentConfig) string {
+	if cfg.SkipPromptWrap {
+		return prompt
+	}
+	var parts []string
+	if wf.PromptPrefix != "" {
+		parts = append(parts, strings.TrimRight(wf.PromptPrefix, "\n"))
+	}
+	parts = append(parts, prompt)
+	if wf.PromptSuffix != "" {
+		parts = append(parts, strings.TrimRight(wf.PromptSuffix, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 // buildAgentPrompt constructs the full prompt including output expectations.
 func buildAgentPrompt(cfg *types.AgentConfig) string {
 	var sb strings.Builder
@@ -116,7 +136,11 @@ func buildAgentPrompt(cfg *types.AgentConfig) string {
 			if def.Required {
 				required = " **(required)**"
 			}
-			sb.WriteString(fmt.Sprintf("- `%s` (%s)%s", name, def.Type, required))
+			kind := def.Type
+			if c := describeOutputConstraints(def); c != "" {
+				kind += ", " + c
+			}
+			sb.WriteString(fmt.Sprintf("- `%s` (%s)%s", name, kind, required))
 			if def.Description != "" {
 				sb.WriteString(": " + def.Description)
 			}
@@ -129,6 +153,41 @@ func buildAgentPrompt(cfg *types.AgentConfig) string {
 	return sb.String()
 }
 
+// describeOutputConstraints summarizes an output's min, max, enum and
+// pattern for the prompt, e.g. "0 to 100" or "one of: low, high".
+func describeOutputConstraints(def types.AgentOutputDef) string {
+	var parts []string
+	switch {
+	case def.Min != nil && def.Max != nil:
+		parts = append(parts, fmt.Sprintf("%g to %g", *def.Min, *def.Max))
+	case def.Min != nil:
+		parts = append(parts, fmt.Sprintf(">= %g", *def.Min))
+	case def.Max != nil:
+		parts = append(parts, fmt.Sprintf("<= %g", *def.Max))
+	}
+	if len(def.Enum) > 0 {
+		parts = append(parts, "one of: "+strings.Join(def.Enum, ", "))
+	}
+	if def.Pattern != "" {
+		parts = append(parts, "matching `"+def.Pattern+"`")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validationRetryPrompt re-prompts an agent whose `meow done` outputs failed
+// validation: the step's prompt, followed by the errors to fix.
+func validationRetryPrompt(prompt string, errs []string, retry, limit int) string {
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString(fmt.Sprintf("\n\n## Outputs Rejected (retry %d of %d)\n\n", retry, limit))
+	sb.WriteString("Your last `meow done` outputs failed validation:\n\n")
+	for _, e := range errs {
+		sb.WriteString("- " + e + "\n")
+	}
+	sb.WriteString("\nFix these outputs and run `meow done` again.")
+	return sb.String()
+}
+
 // CompleteAgentStep validates outputs and completes the step.
 // This is called when an agent runs `meow done`.
 //
@@ -189,12 +248,44 @@ func ValidateAgentOutputs(outputs map[string]any, defs map[string]types.AgentOut
 		typeErr := validateOutputType(name, val, def.Type, agentWorkdir)
 		if typeErr != "" {
 			errs = append(errs, typeErr)
+			continue
+		}
+
+		// Validate bounds and allowed values
+		if constraintErr := validateOutputConstraints(name, val, def); constraintErr != "" {
+			errs = append(errs, constraintErr)
 		}
 	}
 
 	return errs
 }
 
+// ExtractAgentOutputPaths resolves outputs declared with a `from` JSON path
+// against the raw done payload. The payload's own keys are kept; extracted
+// values are added under the output name. Unresolvable paths leave the output
+// unset so required-output validation reports it as missing.
+func ExtractAgentOutputPaths(payload map[string]any, defs map[string]types.AgentOutputDef) map[string]any {
+	var result map[string]any
+	for name, def := range defs {
+		if def.From == "" {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]any, len(payload)+1)
+			for k, v := range payload {
+				result[k] = v
+			}
+		}
+		if val, ok := getNestedOutputValue(payload, def.From); ok {
+			result[name] = val
+		}
+	}
+	if result == nil {
+		return payload
+	}
+	return result
+}
+
 // validateOutputType checks that a value matches its declared type.
 // If the value is a string, it attempts to coerce it to the expected type.
 func validateOutputType(name string, val any, declaredType, agentWorkdir string) string {
@@ -247,6 +338,56 @@ func validateOutputType(name string, val any, declaredType, agentWorkdir string)
 	return ""
 }
 
+// validateOutputConstraints checks a value against its declared min, max,
+// enum and pattern. Called after the type check, so a number may still be a
+// string.
+func validateOutputConstraints(name string, val any, def types.AgentOutputDef) string {
+	if def.Min != nil || def.Max != nil {
+		n, ok := outputNumber(val)
+		if !ok {
+			return fmt.Sprintf("output %s: min and max require a number, got %T", name, val)
+		}
+		if def.Min != nil && n < *def.Min {
+			return fmt.Sprintf("output %s: %g is below the minimum of %g", name, n, *def.Min)
+		}
+		if def.Max != nil && n > *def.Max {
+			return fmt.Sprintf("output %s: %g is above the maximum of %g", name, n, *def.Max)
+		}
+	}
+	if len(def.Enum) > 0 {
+		s := fmt.Sprint(val)
+		if !slices.Contains(def.Enum, s) {
+			return fmt.Sprintf("output %s: %q is not one of: %s", name, s, strings.Join(def.Enum, ", "))
+		}
+	}
+	if def.Pattern != "" {
+		re, err := regexp.Compile(def.Pattern)
+		if err != nil {
+			return fmt.Sprintf("output %s: invalid pattern %s: %v", name, def.Pattern, err)
+		}
+		if s := fmt.Sprint(val); !re.MatchString(s) {
+			return fmt.Sprintf("output %s: %q does not match pattern %s", name, s, def.Pattern)
+		}
+	}
+	return ""
+}
+
+// outputNumber converts a number output, or a string holding one, to float64.
+func outputNumber(val any) (float64, bool) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
 // coerceStringToType attempts to convert a string value to the expected type.
 // Returns the coerced value and an error message (empty string if successful).
 func coerceStringToType(s string, targetType string) (any, string) {
@@ -434,6 +434,43 @@ func TestValidateAgentOutputs_JsonArray(t *testing.T) {
 	}
 }
 
+func TestValidateAgentOutputs_Constraints(t *testing.T) {
+	lo, hi := 0.0, 100.0
+	defs := map[string]types.AgentOutputDef{
+		"count":    {Type: "number", Min: &lo, Max: &hi},
+		"severity": {Type: "string", Enum: []string{"low", "high"}},
+		"task_id":  {Type: "string", Pattern: `^PROJ-\d+$`},
+	}
+
+	tests := []struct {
+		name    string
+		outputs map[string]any
+		wantErr string
+	}{
+		{"within bounds", map[string]any{"count": int64(100), "severity": "low"}, ""},
+		{"number string within bounds", map[string]any{"count": "0"}, ""},
+		{"above max", map[string]any{"count": 200}, "output count: 200 is above the maximum of 100"},
+		{"below min", map[string]any{"count": -1.5}, "output count: -1.5 is below the minimum of 0"},
+		{"not in enum", map[string]any{"severity": "medium"}, `output severity: "medium" is not one of: low, high`},
+		{"matches pattern", map[string]any{"task_id": "PROJ-12"}, ""},
+		{"does not match pattern", map[string]any{"task_id": "proj-12"}, `output task_id: "proj-12" does not match pattern ^PROJ-\d+$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateAgentOutputs(tt.outputs, defs, "")
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0] != tt.wantErr {
+				t.Errorf("errors = %v, want [%s]", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestParseAgentMode(t *testing.T) {
 	tests := []struct {
 		input    string
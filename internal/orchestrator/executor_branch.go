@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"maps"
 	"time"
 
 	"github.com/akatz-ai/meow/internal/types"
@@ -231,12 +232,26 @@ type SimpleConditionExecutor struct {
 	WorkflowID string
 	// StepID is the step ID for MEOW_STEP environment variable.
 	StepID string
+	// RunAs is the OS user to run commands as, if not the orchestrator's.
+	RunAs string
+	// Workdir and Env, if set, are the directory and extra environment
+	// variables commands run with.
+	Workdir string
+	Env     map[string]string
+	// CancelSignal, if set, is sent to a cancelled command, which is killed
+	// only after CancelGrace (soft cancel).
+	CancelSignal string
+	CancelGrace  string
+	// Usage is the resource usage of the last command Execute ran, or nil
+	// if unavailable.
+	Usage *ResourceUsage
 }
 
 // Execute runs a command using the shell executor.
 func (e *SimpleConditionExecutor) Execute(ctx context.Context, command string) (int, string, string, error) {
 	// Build environment - inject MEOW_* variables
-	env := make(map[string]string)
+	env := make(map[string]string, len(e.Env)+3)
+	maps.Copy(env, e.Env)
 
 	// Inject MEOW_WORKFLOW if we have a workflow ID
 	if e.WorkflowID != "" {
@@ -259,7 +274,12 @@ func (e *SimpleConditionExecutor) Execute(ctx context.Context, command string) (
 		Shell: &types.ShellConfig{
 			Command: command,
 			OnError: "continue", // Don't fail on non-zero exit
+			Workdir: e.Workdir,
 			Env:     env,
+			RunAs:   e.RunAs,
+
+			CancelSignal: e.CancelSignal,
+			CancelGrace:  e.CancelGrace,
 		},
 	}
 
@@ -267,6 +287,7 @@ func (e *SimpleConditionExecutor) Execute(ctx context.Context, command string) (
 	if result == nil {
 		return 1, "", "", fmt.Errorf("shell execution returned nil result")
 	}
+	e.Usage = result.Usage
 
 	// Check for execution error (not just non-zero exit)
 	if ctx.Err() != nil {
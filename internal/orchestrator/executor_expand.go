@@ -115,10 +115,14 @@ func ExecuteExpand(
 		mergedVars[k] = v
 	}
 
-	// Build set of template step IDs for dependency resolution
-	templateStepIDs := make(map[string]bool)
-	for _, ts := range templateSteps {
-		templateStepIDs[ts.ID] = true
+	// Resolve templated step IDs and build the set for dependency resolution
+	ids, err := resolveTemplateStepIDs(templateSteps, buildVarContext(mergedVars))
+	if err != nil {
+		return nil, &types.StepError{Message: err.Error()}
+	}
+	templateStepIDs := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		templateStepIDs[id] = true
 	}
 
 	// Expand each template step
@@ -129,7 +133,7 @@ func ExecuteExpand(
 
 	for _, tmplStep := range templateSteps {
 		// Create new step with prefixed ID
-		newID := step.ID + "." + tmplStep.ID
+		newID := step.ID + "." + ids[tmplStep.ID]
 		newStep := cloneStep(tmplStep)
 		newStep.ID = newID
 		newStep.Status = types.StepStatusPending
@@ -144,7 +148,7 @@ func ExecuteExpand(
 		}
 
 		// Update dependencies to use prefixed IDs
-		newStep.Needs = prefixNeeds(tmplStep.Needs, step.ID, templateStepIDs)
+		newStep.Needs = prefixNeeds(workflow.RenameNeeds(tmplStep.Needs, ids), step.ID, templateStepIDs)
 
 		result.ExpandedSteps = append(result.ExpandedSteps, newStep)
 		result.StepIDs = append(result.StepIDs, newID)
@@ -157,14 +161,36 @@ func ExecuteExpand(
 // The caller should update ID, Status, Needs, and ExpandedFrom.
 func cloneStep(src *types.Step) *types.Step {
 	dst := &types.Step{
-		ID:           src.ID,
-		Executor:     src.Executor,
-		Status:       src.Status,
-		Needs:        append([]string(nil), src.Needs...),
-		ExpandedFrom: src.ExpandedFrom,
-		ExpandedInto: append([]string(nil), src.ExpandedInto...),
-		SourceModule: src.SourceModule,
+		ID:                src.ID,
+		Executor:          src.Executor,
+		Status:            src.Status,
+		Needs:             append([]string(nil), src.Needs...),
+		Inputs:            append([]string(nil), src.Inputs...),
+		OnlyBetween:       src.OnlyBetween,
+		OutsideWindow:     src.OutsideWindow,
+		Retries:           src.Retries,
+		Backoff:           src.Backoff,
+		BackoffMultiplier: src.BackoffMultiplier,
+		ExpandedFrom:      src.ExpandedFrom,
+		OnErrorTemplate:   src.OnErrorTemplate,
+		ExpandedInto:      append([]string(nil), src.ExpandedInto...),
+		SourceModule:      src.SourceModule,
+	}
+
+	if src.Assert != nil {
+		dst.Assert = make(map[string]types.OutputAssertion, len(src.Assert))
+		for k, v := range src.Assert {
+			dst.Assert[k] = v
+		}
+	}
+	if src.Requires != nil {
+		dst.Requires = make(map[string]string, len(src.Requires))
+		for k, v := range src.Requires {
+			dst.Requires[k] = v
+		}
 	}
+	dst.If = src.If
+	dst.Lock = src.Lock
 
 	// Clone executor-specific configs
 	if src.Shell != nil {
@@ -185,6 +211,10 @@ func cloneStep(src *types.Step) *types.Step {
 	if src.Foreach != nil {
 		dst.Foreach = cloneForeachConfig(src.Foreach)
 	}
+	if src.Gate != nil {
+		gate := *src.Gate
+		dst.Gate = &gate
+	}
 	if src.Agent != nil {
 		dst.Agent = cloneAgentConfig(src.Agent)
 	}
@@ -197,6 +227,10 @@ func cloneShellConfig(src *types.ShellConfig) *types.ShellConfig {
 		Command: src.Command,
 		Workdir: src.Workdir,
 		OnError: src.OnError,
+		RunAs:   src.RunAs,
+
+		CancelSignal: src.CancelSignal,
+		CancelGrace:  src.CancelGrace,
 	}
 	if src.Env != nil {
 		dst.Env = make(map[string]string)
@@ -220,6 +254,9 @@ func cloneSpawnConfig(src *types.SpawnConfig) *types.SpawnConfig {
 		Workdir:       src.Workdir,
 		ResumeSession: src.ResumeSession,
 		SpawnArgs:     src.SpawnArgs,
+		StartupDelay:  src.StartupDelay,
+		ReuseFrom:     src.ReuseFrom,
+		Capabilities:  append([]string(nil), src.Capabilities...),
 	}
 	if src.Env != nil {
 		dst.Env = make(map[string]string)
@@ -232,9 +269,10 @@ func cloneSpawnConfig(src *types.SpawnConfig) *types.SpawnConfig {
 
 func cloneKillConfig(src *types.KillConfig) *types.KillConfig {
 	return &types.KillConfig{
-		Agent:    src.Agent,
-		Graceful: src.Graceful,
-		Timeout:  src.Timeout,
+		Agent:       src.Agent,
+		Graceful:    src.Graceful,
+		Timeout:     src.Timeout,
+		GracePeriod: src.GracePeriod,
 	}
 }
 
@@ -255,6 +293,24 @@ func cloneBranchConfig(src *types.BranchConfig) *types.BranchConfig {
 	dst := &types.BranchConfig{
 		Condition: src.Condition,
 		Timeout:   src.Timeout,
+		Workdir:   src.Workdir,
+		OnError:   src.OnError,
+		RunAs:     src.RunAs,
+
+		CancelSignal: src.CancelSignal,
+		CancelGrace:  src.CancelGrace,
+	}
+	if src.Env != nil {
+		dst.Env = make(map[string]string)
+		for k, v := range src.Env {
+			dst.Env[k] = v
+		}
+	}
+	if src.Outputs != nil {
+		dst.Outputs = make(map[string]types.OutputSource)
+		for k, v := range src.Outputs {
+			dst.Outputs[k] = v
+		}
 	}
 	if src.OnTrue != nil {
 		dst.OnTrue = cloneBranchTarget(src.OnTrue)
@@ -287,10 +343,26 @@ func cloneBranchTarget(src *types.BranchTarget) *types.BranchTarget {
 
 func cloneAgentConfig(src *types.AgentConfig) *types.AgentConfig {
 	dst := &types.AgentConfig{
-		Agent:   src.Agent,
-		Prompt:  src.Prompt,
-		Mode:    src.Mode,
-		Timeout: src.Timeout,
+		Agent:             src.Agent,
+		Prompt:            src.Prompt,
+		Mode:              src.Mode,
+		Timeout:           src.Timeout,
+		AckTimeout:        src.AckTimeout,
+		PreDelay:          src.PreDelay,
+		PostDelay:         src.PostDelay,
+		SkipPromptWrap:    src.SkipPromptWrap,
+		StallTimeout:      src.StallTimeout,
+		OnStall:           src.OnStall,
+		CaptureTranscript: src.CaptureTranscript,
+		Completion:        src.Completion,
+		ResultFile:        src.ResultFile,
+		CancelPrompt:      src.CancelPrompt,
+		CancelGrace:       src.CancelGrace,
+	}
+	dst.RequiresCapability = src.RequiresCapability
+	if src.Nudge != nil {
+		nudge := *src.Nudge
+		dst.Nudge = &nudge
 	}
 	if src.Outputs != nil {
 		dst.Outputs = make(map[string]types.AgentOutputDef)
@@ -342,6 +414,10 @@ func prefixNeeds(needs []string, parentID string, templateStepIDs map[string]boo
 func substituteStepVariablesTyped(step *types.Step, ctx *workflow.VarContext) error {
 	var err error
 
+	if step.If, err = ctx.Render(step.If); err != nil {
+		return fmt.Errorf("if: %w", err)
+	}
+
 	switch step.Executor {
 	case types.ExecutorShell:
 		if step.Shell != nil {
@@ -351,6 +427,9 @@ func substituteStepVariablesTyped(step *types.Step, ctx *workflow.VarContext) er
 			if step.Shell.Workdir, err = ctx.Render(step.Shell.Workdir); err != nil {
 				return fmt.Errorf("shell.workdir: %w", err)
 			}
+			if step.Shell.RunAs, err = ctx.Render(step.Shell.RunAs); err != nil {
+				return fmt.Errorf("shell.run_as: %w", err)
+			}
 			for k, v := range step.Shell.Env {
 				if step.Shell.Env[k], err = ctx.Render(v); err != nil {
 					return fmt.Errorf("shell.env.%s: %w", k, err)
@@ -374,6 +453,14 @@ func substituteStepVariablesTyped(step *types.Step, ctx *workflow.VarContext) er
 			if step.Spawn.SpawnArgs, err = ctx.Render(step.Spawn.SpawnArgs); err != nil {
 				return fmt.Errorf("spawn.spawn_args: %w", err)
 			}
+			if step.Spawn.ReuseFrom, err = ctx.Render(step.Spawn.ReuseFrom); err != nil {
+				return fmt.Errorf("spawn.reuse_from: %w", err)
+			}
+			for i, c := range step.Spawn.Capabilities {
+				if step.Spawn.Capabilities[i], err = ctx.Render(c); err != nil {
+					return fmt.Errorf("spawn.capabilities: %w", err)
+				}
+			}
 			for k, v := range step.Spawn.Env {
 				if step.Spawn.Env[k], err = ctx.Render(v); err != nil {
 					return fmt.Errorf("spawn.env.%s: %w", k, err)
@@ -437,11 +524,26 @@ func substituteStepVariablesTyped(step *types.Step, ctx *workflow.VarContext) er
 			if step.Foreach.MaxConcurrent, err = ctx.Render(step.Foreach.MaxConcurrent); err != nil {
 				return fmt.Errorf("foreach.max_concurrent: %w", err)
 			}
+			if step.Foreach.Window, err = ctx.Render(step.Foreach.Window); err != nil {
+				return fmt.Errorf("foreach.window: %w", err)
+			}
+			if step.Foreach.FailureThreshold, err = ctx.Render(step.Foreach.FailureThreshold); err != nil {
+				return fmt.Errorf("foreach.failure_threshold: %w", err)
+			}
 			// Use EvalMap for Variables to preserve types
 			if step.Foreach.Variables, err = ctx.EvalMap(step.Foreach.Variables); err != nil {
 				return fmt.Errorf("foreach.variables: %w", err)
 			}
 		}
+	case types.ExecutorGate:
+		if step.Gate != nil {
+			if step.Gate.WaitForEvent, err = ctx.Render(step.Gate.WaitForEvent); err != nil {
+				return fmt.Errorf("gate.wait_for_event: %w", err)
+			}
+			if step.Gate.Timeout, err = ctx.Render(step.Gate.Timeout); err != nil {
+				return fmt.Errorf("gate.timeout: %w", err)
+			}
+		}
 	case types.ExecutorAgent:
 		if step.Agent != nil {
 			if step.Agent.Agent, err = ctx.Render(step.Agent.Agent); err != nil {
@@ -450,6 +552,20 @@ func substituteStepVariablesTyped(step *types.Step, ctx *workflow.VarContext) er
 			if step.Agent.Prompt, err = ctx.Render(step.Agent.Prompt); err != nil {
 				return fmt.Errorf("agent.prompt: %w", err)
 			}
+			if step.Agent.RequiresCapability, err = ctx.Render(step.Agent.RequiresCapability); err != nil {
+				return fmt.Errorf("agent.requires_capability: %w", err)
+			}
+			if step.Agent.Nudge != nil {
+				if step.Agent.Nudge.Prompt, err = ctx.Render(step.Agent.Nudge.Prompt); err != nil {
+					return fmt.Errorf("agent.nudge.prompt: %w", err)
+				}
+			}
+			if step.Agent.ResultFile, err = ctx.Render(step.Agent.ResultFile); err != nil {
+				return fmt.Errorf("agent.result_file: %w", err)
+			}
+			if step.Agent.CancelPrompt, err = ctx.Render(step.Agent.CancelPrompt); err != nil {
+				return fmt.Errorf("agent.cancel_prompt: %w", err)
+			}
 		}
 	}
 	return nil
@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/akatz-ai/meow/internal/types"
@@ -518,6 +519,127 @@ func TestExecuteExpand_SourceModulePropagation(t *testing.T) {
 	}
 }
 
+func TestExecuteExpand_CopiesStepFields(t *testing.T) {
+	tmpl := &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Inputs:   []string{"src/*.go"},
+		Shell:    &types.ShellConfig{Command: "go build"},
+	}
+	spawn := &types.Step{
+		ID:       "spawn",
+		Executor: types.ExecutorSpawn,
+		Spawn:    &types.SpawnConfig{Agent: "worker", StartupDelay: "3s"},
+	}
+	work := &types.Step{
+		ID:       "work",
+		Executor: types.ExecutorAgent,
+		Agent:    &types.AgentConfig{Agent: "worker", Prompt: "Work", PreDelay: "1s", PostDelay: "2s"},
+	}
+	loader := &mockTemplateLoader{steps: []*types.Step{tmpl, spawn, work}}
+	step := &types.Step{
+		ID:       "expand-step",
+		Executor: types.ExecutorExpand,
+		Expand:   &types.ExpandConfig{Template: ".build"},
+	}
+
+	result, stepErr := ExecuteExpand(context.Background(), step, loader, nil, 0, nil)
+	if stepErr != nil {
+		t.Fatalf("unexpected error: %v", stepErr)
+	}
+	got := result.ExpandedSteps[0]
+
+	if !reflect.DeepEqual(got.Inputs, tmpl.Inputs) {
+		t.Errorf("Inputs = %v, want %v", got.Inputs, tmpl.Inputs)
+	}
+	got.Inputs[0] = "changed"
+	if tmpl.Inputs[0] != "src/*.go" {
+		t.Error("expanded step shares the template's Inputs")
+	}
+
+	if got := result.ExpandedSteps[1].Spawn.StartupDelay; got != "3s" {
+		t.Errorf("Spawn.StartupDelay = %q, want 3s", got)
+	}
+	if got := result.ExpandedSteps[2].Agent; got.PreDelay != "1s" || got.PostDelay != "2s" {
+		t.Errorf("Agent delays = %q/%q, want 1s/2s", got.PreDelay, got.PostDelay)
+	}
+}
+
+func TestCloneAgentConfig(t *testing.T) {
+	src := &types.AgentConfig{
+		Agent:      "worker",
+		Prompt:     "Work",
+		Timeout:    "10m",
+		AckTimeout: "30s",
+	}
+	dst := cloneAgentConfig(src)
+	if dst.Timeout != "10m" || dst.AckTimeout != "30s" {
+		t.Errorf("clone timeouts = %q/%q, want 10m/30s", dst.Timeout, dst.AckTimeout)
+	}
+}
+
+func TestCloneStep_CopiesConfigs(t *testing.T) {
+	steps := []*types.Step{
+		{
+			ID:              "deploy",
+			Executor:        types.ExecutorShell,
+			Lock:            "prod",
+			OnErrorTemplate: ".rollback",
+			Shell:           &types.ShellConfig{Command: "make deploy"},
+		},
+		{
+			ID:       "approve",
+			Executor: types.ExecutorGate,
+			Gate:     &types.GateConfig{WaitForEvent: "approve-deploy", Timeout: "1h"},
+		},
+		{
+			ID:       "spawn",
+			Executor: types.ExecutorSpawn,
+			Spawn:    &types.SpawnConfig{Agent: "reviewer", Capabilities: []string{"review"}},
+		},
+		{
+			ID:       "review",
+			Executor: types.ExecutorAgent,
+			Agent:    &types.AgentConfig{Prompt: "Review", RequiresCapability: "review"},
+		},
+		{
+			ID:       "check",
+			Executor: types.ExecutorBranch,
+			Branch: &types.BranchConfig{
+				Condition: "test -f ready",
+				Workdir:   "/srv",
+				Env:       map[string]string{"STAGE": "prod"},
+				Outputs:   map[string]types.OutputSource{"state": {Source: "stdout"}},
+				OnError:   "continue",
+				RunAs:     "deploy",
+				OnTrue:    &types.BranchTarget{Template: ".ship"},
+			},
+		},
+	}
+
+	for _, src := range steps {
+		t.Run(src.ID, func(t *testing.T) {
+			dst := cloneStep(src)
+			if !reflect.DeepEqual(dst, src) {
+				t.Fatalf("cloneStep() = %+v, want %+v", dst, src)
+			}
+			if dst.Gate != nil {
+				dst.Gate.WaitForEvent = "changed"
+			}
+			if dst.Spawn != nil {
+				dst.Spawn.Capabilities[0] = "changed"
+			}
+			if dst.Branch != nil {
+				dst.Branch.Env["STAGE"] = "changed"
+				dst.Branch.Outputs["state"] = types.OutputSource{Source: "stderr"}
+			}
+			if reflect.DeepEqual(dst, src) && (src.Gate != nil || src.Spawn != nil || src.Branch != nil) {
+				t.Error("clone shares configs with the template step")
+			}
+		})
+	}
+}
+
 func TestBuildVarContextRender(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/akatz-ai/meow/internal/types"
+	"github.com/akatz-ai/meow/internal/workflow"
 )
 
 // fileTemplateLoader implements TemplateLoader using FileTemplateExpander.
@@ -57,6 +60,7 @@ type ExecuteForeachResult struct {
 	ExpandedSteps []*types.Step // All newly created steps
 	StepIDs       []string      // IDs of the expanded steps (top-level per iteration)
 	IterationIDs  []string      // IDs of each iteration prefix (e.g., "foreach.0", "foreach.1")
+	Remaining     int           // Iterations left to expand (windowed expansion)
 }
 
 // ExecuteForeach expands a template for each item in a list.
@@ -76,6 +80,22 @@ func ExecuteForeach(
 	variables map[string]any,
 	depth int,
 	limits *ExpansionLimits,
+) (*ExecuteForeachResult, *types.StepError) {
+	return expandForeachIterations(ctx, step, loader, variables, depth, limits, 0, 0)
+}
+
+// expandForeachIterations expands the iterations of a foreach step from index
+// start on, at most count of them (0 expands the rest). The items are
+// evaluated again on every call, so a windowed foreach reads items_file once
+// per batch.
+func expandForeachIterations(
+	ctx context.Context,
+	step *types.Step,
+	loader TemplateLoader,
+	variables map[string]any,
+	depth int,
+	limits *ExpansionLimits,
+	start, count int,
 ) (*ExecuteForeachResult, *types.StepError) {
 	if step.Foreach == nil {
 		return nil, &types.StepError{Message: "foreach step missing config"}
@@ -130,8 +150,13 @@ func ExecuteForeach(
 		}
 	}
 
+	end := len(items)
+	if count > 0 {
+		end = min(start+count, end)
+	}
+
 	// Empty array - nothing to expand
-	if len(items) == 0 {
+	if start >= end {
 		return &ExecuteForeachResult{
 			ExpandedSteps: nil,
 			StepIDs:       nil,
@@ -161,54 +186,47 @@ func ExecuteForeach(
 		}, nil
 	}
 
-	// Build set of template step IDs for dependency resolution
-	templateStepIDs := make(map[string]bool)
-	for _, ts := range templateSteps {
-		templateStepIDs[ts.ID] = true
-	}
-
 	result := &ExecuteForeachResult{
-		ExpandedSteps: make([]*types.Step, 0, len(items)*len(templateSteps)),
-		StepIDs:       make([]string, 0, len(items)*len(templateSteps)),
-		IterationIDs:  make([]string, 0, len(items)),
+		ExpandedSteps: make([]*types.Step, 0, (end-start)*len(templateSteps)),
+		StepIDs:       make([]string, 0, (end-start)*len(templateSteps)),
+		IterationIDs:  make([]string, 0, end-start),
+		Remaining:     len(items) - end,
 	}
 
-	// Track the last step of the previous iteration (for sequential mode)
+	// Track the last step of the previous iteration (for sequential mode),
+	// which an earlier batch expanded when resuming a window
 	var prevIterationLastStepID string
+	if start > 0 {
+		prevPrefix := fmt.Sprintf("%s.%d", step.ID, start-1)
+		ids, err := resolveTemplateStepIDs(templateSteps, buildVarContext(foreachIterationVars(cfg, variables, items, start-1, prevPrefix)))
+		if err != nil {
+			return nil, &types.StepError{Message: fmt.Sprintf("foreach iteration %s: %v", prevPrefix, err)}
+		}
+		prevIterationLastStepID = prevPrefix + "." + ids[templateSteps[len(templateSteps)-1].ID]
+	}
 
 	// Expand for each item
-	for i, item := range items {
+	for i := start; i < end; i++ {
 		iterationPrefix := fmt.Sprintf("%s.%d", step.ID, i)
 		result.IterationIDs = append(result.IterationIDs, iterationPrefix)
+		iterVars := foreachIterationVars(cfg, variables, items, i, iterationPrefix)
 
-		// Build iteration-specific variables
-		iterVars := make(map[string]any)
-		// Copy workflow variables
-		for k, v := range variables {
-			iterVars[k] = v
-		}
-		// Copy foreach step variables
-		for k, v := range cfg.Variables {
-			iterVars[k] = v
+		// Resolve templated step IDs (e.g., "deploy-{{item.region}}") for this item
+		ids, err := resolveTemplateStepIDs(templateSteps, buildVarContext(iterVars))
+		if err != nil {
+			return nil, &types.StepError{Message: fmt.Sprintf("foreach iteration %s: %v", iterationPrefix, err)}
 		}
-		// Set item_var as typed value (map, array, string, etc.)
-		// VarContext.resolve handles nested field access like {{task.name}}
-		iterVars[cfg.ItemVar] = item
-		// Set index_var as int (not string) if specified
-		if cfg.IndexVar != "" {
-			iterVars[cfg.IndexVar] = i
+		templateStepIDs := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			templateStepIDs[id] = true
 		}
-		// Inject __step_prefix__ built-in variable for this iteration.
-		// This allows templates to reference sibling steps by their full prefixed ID.
-		// For example, if foreach step is "agents" and index is 0, the prefix is "agents.0."
-		iterVars["__step_prefix__"] = iterationPrefix + "."
 
 		var iterFirstStepID string
 
 		// Expand each template step for this iteration
 		for j, tmplStep := range templateSteps {
 			// Create new step with prefixed ID: {foreach_id}.{index}.{step_id}
-			newID := fmt.Sprintf("%s.%s", iterationPrefix, tmplStep.ID)
+			newID := fmt.Sprintf("%s.%s", iterationPrefix, ids[tmplStep.ID])
 			newStep := cloneStep(tmplStep)
 			newStep.ID = newID
 			newStep.Status = types.StepStatusPending
@@ -224,7 +242,7 @@ func ExecuteForeach(
 
 			// Update dependencies to use prefixed IDs
 			newStep.Needs = prefixForeachNeeds(
-				tmplStep.Needs,
+				workflow.RenameNeeds(tmplStep.Needs, ids),
 				iterationPrefix,
 				step.ID,
 				templateStepIDs,
@@ -243,7 +261,7 @@ func ExecuteForeach(
 
 		// Track last step of this iteration for sequential mode
 		if len(templateSteps) > 0 {
-			lastStepID := fmt.Sprintf("%s.%s", iterationPrefix, templateSteps[len(templateSteps)-1].ID)
+			lastStepID := fmt.Sprintf("%s.%s", iterationPrefix, ids[templateSteps[len(templateSteps)-1].ID])
 			prevIterationLastStepID = lastStepID
 		}
 
@@ -254,6 +272,65 @@ func ExecuteForeach(
 	return result, nil
 }
 
+// foreachIterationVars returns the variables of iteration i of a foreach:
+// the workflow's and the foreach step's variables, plus the item, its index,
+// and the iteration's __step_prefix__.
+func foreachIterationVars(cfg *types.ForeachConfig, variables map[string]any, items []any, i int, iterationPrefix string) map[string]any {
+	item := items[i]
+	// Build iteration-specific variables
+	iterVars := make(map[string]any)
+	// Copy workflow variables
+	for k, v := range variables {
+		iterVars[k] = v
+	}
+	// Copy foreach step variables
+	for k, v := range cfg.Variables {
+		iterVars[k] = v
+	}
+	// Set item_var as typed value (map, array, string, etc.)
+	// VarContext.resolve handles nested field access like {{task.name}}
+	iterVars[cfg.ItemVar] = item
+	// Set index_var as int (not string) if specified
+	if cfg.IndexVar != "" {
+		iterVars[cfg.IndexVar] = i
+	}
+	// Inject __step_prefix__ built-in variable for this iteration.
+	// This allows templates to reference sibling steps by their full prefixed ID.
+	// For example, if foreach step is "agents" and index is 0, the prefix is "agents.0."
+	iterVars["__step_prefix__"] = iterationPrefix + "."
+	return iterVars
+}
+
+// resolveTemplateStepIDs resolves the template step IDs left templated until
+// expansion, such as "deploy-{{item.region}}", returning each step's resolved
+// ID keyed by its template ID. Resolved IDs must be unique.
+func resolveTemplateStepIDs(templateSteps []*types.Step, ctx *workflow.VarContext) (map[string]string, error) {
+	ids := make(map[string]string, len(templateSteps))
+	seen := make(map[string]bool, len(templateSteps))
+	for _, ts := range templateSteps {
+		id := ts.ID
+		if strings.Contains(id, "{{") {
+			resolved, err := ctx.Substitute(id)
+			if err != nil {
+				return nil, fmt.Errorf("step id %q: %w", id, err)
+			}
+			if strings.Contains(resolved, "{{") {
+				return nil, fmt.Errorf("step id %q has unresolved variables", id)
+			}
+			if err := workflow.ValidateResolvedStepID(id, resolved); err != nil {
+				return nil, err
+			}
+			id = resolved
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate step id %q after resolving templated step ids", id)
+		}
+		seen[id] = true
+		ids[ts.ID] = id
+	}
+	return ids, nil
+}
+
 // readItemsFromFile reads a JSON array from a file.
 // This bypasses variable substitution entirely, avoiding escaping issues
 // when JSON contains embedded newlines or special characters.
@@ -362,12 +439,15 @@ func cloneForeachConfig(src *types.ForeachConfig) *types.ForeachConfig {
 	}
 
 	dst := &types.ForeachConfig{
-		Items:         src.Items,
-		ItemsFile:     src.ItemsFile,
-		ItemVar:       src.ItemVar,
-		IndexVar:      src.IndexVar,
-		Template:      src.Template,
-		MaxConcurrent: src.MaxConcurrent,
+		Items:            src.Items,
+		ItemsFile:        src.ItemsFile,
+		ItemVar:          src.ItemVar,
+		IndexVar:         src.IndexVar,
+		Template:         src.Template,
+		MaxConcurrent:    src.MaxConcurrent,
+		Window:           src.Window,
+		FailureThreshold: src.FailureThreshold,
+		Collect:          src.Collect,
 	}
 
 	if src.Parallel != nil {
@@ -409,6 +489,106 @@ func IsForeachComplete(foreachStep *types.Step, allSteps map[string]*types.Step)
 	return true
 }
 
+// CountExpandedIterations counts the iterations of a foreach step that have
+// been expanded. Used by windowed expansion to find the next item.
+func CountExpandedIterations(foreachStep *types.Step) int {
+	prefix := foreachStep.ID + "."
+	expanded := make(map[string]bool)
+	for _, childID := range foreachStep.ExpandedInto {
+		if index, _, ok := strings.Cut(strings.TrimPrefix(childID, prefix), "."); ok {
+			expanded[index] = true
+		}
+	}
+	return len(expanded)
+}
+
+// CountUnfinishedIterations counts the expanded iterations of a foreach step
+// with a step that is not yet terminal. Used by windowed expansion to bound
+// the iterations in flight.
+func CountUnfinishedIterations(foreachStep *types.Step, allSteps map[string]*types.Step) int {
+	prefix := foreachStep.ID + "."
+	unfinished := make(map[string]bool)
+	for _, childID := range foreachStep.ExpandedInto {
+		index, _, ok := strings.Cut(strings.TrimPrefix(childID, prefix), ".")
+		if !ok {
+			continue
+		}
+		if child, exists := allSteps[childID]; exists && !child.Status.IsTerminal() {
+			unfinished[index] = true
+		}
+	}
+	return len(unfinished)
+}
+
+// IsForeachFullyExpanded checks if every child recorded in a foreach step's
+// ExpandedInto exists. Used by crash recovery: a fully expanded foreach was
+// interrupted while its iterations ran and can resume them in place, while one
+// with no or missing children was interrupted mid-expansion and must re-expand.
+func IsForeachFullyExpanded(foreachStep *types.Step, allSteps map[string]*types.Step) bool {
+	if len(foreachStep.ExpandedInto) == 0 {
+		return false
+	}
+	for _, childID := range foreachStep.ExpandedInto {
+		if _, ok := allSteps[childID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// CountCompletedIterations counts the iterations whose steps are all done.
+func CountCompletedIterations(foreachStep *types.Step, allSteps map[string]*types.Step) int {
+	prefix := foreachStep.ID + "."
+	done := make(map[string]bool)
+	for _, childID := range foreachStep.ExpandedInto {
+		index, _, ok := strings.Cut(strings.TrimPrefix(childID, prefix), ".")
+		if !ok {
+			continue
+		}
+		child, exists := allSteps[childID]
+		iterationDone := exists && child.Satisfied()
+		if prev, seen := done[index]; seen {
+			iterationDone = iterationDone && prev
+		}
+		done[index] = iterationDone
+	}
+
+	count := 0
+	for _, d := range done {
+		if d {
+			count++
+		}
+	}
+	return count
+}
+
+// FailedIterations returns the indexes of a foreach step's iterations with a
+// failed step, in order, and the total number of iterations.
+func FailedIterations(foreachStep *types.Step, allSteps map[string]*types.Step) (failed []int, total int) {
+	prefix := foreachStep.ID + "."
+	iterations := make(map[int]bool) // index -> failed
+	for _, childID := range foreachStep.ExpandedInto {
+		indexStr, _, ok := strings.Cut(strings.TrimPrefix(childID, prefix), ".")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(indexStr)
+		if err != nil {
+			continue
+		}
+		child, exists := allSteps[childID]
+		iterations[index] = iterations[index] || (exists && child.Status == types.StepStatusFailed)
+	}
+
+	for index, f := range iterations {
+		if f {
+			failed = append(failed, index)
+		}
+	}
+	sort.Ints(failed)
+	return failed, len(iterations)
+}
+
 // IsForeachFailed checks if any child of a foreach step has failed.
 func IsForeachFailed(foreachStep *types.Step, allSteps map[string]*types.Step) bool {
 	if foreachStep.ExpandedInto == nil {
@@ -474,6 +654,75 @@ func GetIterationStepIDs(foreachStep *types.Step, iterationIndex int) []string {
 	return stepIDs
 }
 
+// AggregateForeachResults collects the outputs of a foreach step's iterations.
+// results is ordered by iteration index, regardless of the order in which the
+// iterations finished; each entry maps an iteration's step IDs (relative to the
+// iteration, e.g. "work" for "foreach.2.work") to that step's outputs.
+// resultsByIndex holds the same entries keyed by the index as a string.
+func AggregateForeachResults(foreachStep *types.Step, allSteps map[string]*types.Step) (results []any, resultsByIndex map[string]any) {
+	prefix := foreachStep.ID + "."
+	iterations := make(map[int]map[string]any)
+	maxIndex := -1
+
+	for _, childID := range foreachStep.ExpandedInto {
+		rest, ok := strings.CutPrefix(childID, prefix)
+		if !ok {
+			continue
+		}
+		indexStr, localID, ok := strings.Cut(rest, ".")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(indexStr)
+		if err != nil || index < 0 {
+			continue
+		}
+
+		iteration := iterations[index]
+		if iteration == nil {
+			iteration = make(map[string]any)
+			iterations[index] = iteration
+		}
+		if index > maxIndex {
+			maxIndex = index
+		}
+		if child, ok := allSteps[childID]; ok && child.Outputs != nil {
+			iteration[localID] = child.Outputs
+		}
+	}
+
+	results = make([]any, 0, maxIndex+1)
+	resultsByIndex = make(map[string]any, maxIndex+1)
+	for i := 0; i <= maxIndex; i++ {
+		iteration := iterations[i]
+		if iteration == nil {
+			iteration = map[string]any{}
+		}
+		results = append(results, iteration)
+		resultsByIndex[strconv.Itoa(i)] = iteration
+	}
+	return results, resultsByIndex
+}
+
+// CollectForeachOutput picks the foreach's collect output ("step.field") out
+// of each iteration's aggregated results. An iteration without the output,
+// such as a failed one, contributes nil, so values stay aligned with
+// iteration indexes.
+func CollectForeachOutput(collect string, results []any) []any {
+	stepID, field, _ := strings.Cut(collect, ".")
+	collected := make([]any, 0, len(results))
+	for _, result := range results {
+		var value any
+		if iteration, ok := result.(map[string]any); ok {
+			if outputs, ok := iteration[stepID].(map[string]any); ok {
+				value, _ = getNestedOutputValue(outputs, field)
+			}
+		}
+		collected = append(collected, value)
+	}
+	return collected
+}
+
 // resolveForeachVariables evaluates foreach step variables against workflow variables.
 // This handles cases like protocol = "{{protocol}}" where the foreach passes through
 // a workflow-level variable to the expanded template.
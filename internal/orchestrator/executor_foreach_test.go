@@ -2,8 +2,16 @@ package orchestrator
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/akatz-ai/meow/internal/ipc"
 	"github.com/akatz-ai/meow/internal/types"
 )
 
@@ -294,6 +302,338 @@ func TestExecuteForeach_SequentialDependencies(t *testing.T) {
 	}
 }
 
+func TestExpandForeachIterations_Batch(t *testing.T) {
+	loader := &foreachMockLoader{
+		steps: []*types.Step{
+			{ID: "work", Executor: types.ExecutorShell, Shell: &types.ShellConfig{Command: "echo {{item}}"}},
+		},
+	}
+	parallel := false
+	step := &types.Step{
+		ID:       "fan",
+		Executor: types.ExecutorForeach,
+		Foreach: &types.ForeachConfig{
+			Items:    `["a", "b", "c", "d", "e"]`,
+			ItemVar:  "item",
+			Template: ".work",
+			Parallel: &parallel,
+		},
+	}
+
+	result, err := expandForeachIterations(context.Background(), step, loader, nil, 0, nil, 2, 2)
+	if err != nil {
+		t.Fatalf("expandForeachIterations failed: %v", err)
+	}
+	if got := strings.Join(result.StepIDs, ","); got != "fan.2.work,fan.3.work" {
+		t.Errorf("step IDs = %s, want fan.2.work,fan.3.work", got)
+	}
+	if result.Remaining != 1 {
+		t.Errorf("remaining = %d, want 1", result.Remaining)
+	}
+	if cmd := result.ExpandedSteps[0].Shell.Command; cmd != "echo c" {
+		t.Errorf("first command = %q, want item c", cmd)
+	}
+	// The batch chains onto the previous batch's last iteration
+	if needs := result.ExpandedSteps[0].Needs; len(needs) != 1 || needs[0] != "fan.1.work" {
+		t.Errorf("fan.2.work needs = %v, want [fan.1.work]", needs)
+	}
+
+	// Past the end there is nothing left
+	result, err = expandForeachIterations(context.Background(), step, loader, nil, 0, nil, 5, 2)
+	if err != nil {
+		t.Fatalf("expandForeachIterations failed: %v", err)
+	}
+	if len(result.ExpandedSteps) != 0 || result.Remaining != 0 {
+		t.Errorf("expanded %d steps with %d remaining, want none", len(result.ExpandedSteps), result.Remaining)
+	}
+}
+
+// windowCheckStore records the most iterations of the "fan" foreach that
+// were unfinished in any saved state.
+type windowCheckStore struct {
+	*mockRunStore
+	maxUnfinished int
+}
+
+func (s *windowCheckStore) Save(ctx context.Context, wf *types.Run) error {
+	if fan, ok := wf.Steps["fan"]; ok {
+		s.maxUnfinished = max(s.maxUnfinished, CountUnfinishedIterations(fan, wf.Steps))
+	}
+	return s.mockRunStore.Save(ctx, wf)
+}
+
+func TestHandleForeach_Window(t *testing.T) {
+	const items, window = 200, 5
+
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "fan.meow.toml")
+	template := `
+[worker]
+name = "worker"
+
+[[worker.steps]]
+id = "work"
+executor = "shell"
+command = "echo {{item}}"
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list := make([]string, items)
+	for i := range list {
+		list[i] = strconv.Itoa(i)
+	}
+	wf := types.NewRun("test-wf", templatePath, nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["fan"] = &types.Step{
+		ID:       "fan",
+		Executor: types.ExecutorForeach,
+		Status:   types.StepStatusPending,
+		Foreach: &types.ForeachConfig{
+			Items:    "[" + strings.Join(list, ",") + "]",
+			ItemVar:  "item",
+			Template: ".worker",
+			Window:   strconv.Itoa(window),
+		},
+	}
+	store := &windowCheckStore{mockRunStore: newMockRunStore()}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), NewTemplateExpanderAdapter(dir), testLogger())
+	orch.SetWorkflowID(wf.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	fan := wf.Steps["fan"]
+	if fan.Status != types.StepStatusDone {
+		t.Fatalf("foreach status = %s, want done (error %+v)", fan.Status, fan.Error)
+	}
+	if got := CountExpandedIterations(fan); got != items {
+		t.Errorf("expanded iterations = %d, want %d", got, items)
+	}
+	if results, _ := fan.Outputs["results"].([]any); len(results) != items {
+		t.Errorf("results = %d entries, want %d", len(results), items)
+	}
+	if store.maxUnfinished == 0 || store.maxUnfinished > window {
+		t.Errorf("most unfinished iterations = %d, want 1 to %d", store.maxUnfinished, window)
+	}
+}
+
+func TestExecuteForeach_TemplatedStepIDs(t *testing.T) {
+	loader := &foreachMockLoader{
+		steps: []*types.Step{
+			{ID: "deploy-{{item.region}}", Executor: types.ExecutorShell, Shell: &types.ShellConfig{Command: "deploy {{item.region}}"}},
+			{ID: "verify", Executor: types.ExecutorShell, Shell: &types.ShellConfig{Command: "verify"}, Needs: []string{"deploy-{{item.region}}"}},
+		},
+	}
+	step := &types.Step{
+		ID:       "fanout",
+		Executor: types.ExecutorForeach,
+		Foreach: &types.ForeachConfig{
+			Items:    `[{"region": "us-east"}, {"region": "eu-west"}]`,
+			ItemVar:  "item",
+			Template: ".deploy",
+		},
+	}
+
+	result, stepErr := ExecuteForeach(context.Background(), step, loader, nil, 0, nil)
+	if stepErr != nil {
+		t.Fatalf("ExecuteForeach failed: %v", stepErr)
+	}
+	want := "fanout.0.deploy-us-east,fanout.0.verify,fanout.1.deploy-eu-west,fanout.1.verify"
+	if got := strings.Join(result.StepIDs, ","); got != want {
+		t.Errorf("step IDs = %s, want %s", got, want)
+	}
+	if needs := result.ExpandedSteps[3].Needs; len(needs) != 1 || needs[0] != "fanout.1.deploy-eu-west" {
+		t.Errorf("fanout.1.verify needs = %v, want [fanout.1.deploy-eu-west]", needs)
+	}
+
+	// IDs must stay unique within an iteration and free of dots
+	tests := []struct {
+		name  string
+		items string
+		steps []*types.Step
+		want  string
+	}{
+		{
+			name:  "duplicate",
+			items: `[{"region": "us-east", "zone": "us-east"}]`,
+			steps: []*types.Step{
+				{ID: "deploy-{{item.region}}", Executor: types.ExecutorShell, Shell: &types.ShellConfig{Command: "true"}},
+				{ID: "deploy-{{item.zone}}", Executor: types.ExecutorShell, Shell: &types.ShellConfig{Command: "true"}},
+			},
+			want: `duplicate step id "deploy-us-east"`,
+		},
+		{
+			name:  "dot",
+			items: `[{"region": "us.east"}]`,
+			steps: []*types.Step{
+				{ID: "deploy-{{item.region}}", Executor: types.ExecutorShell, Shell: &types.ShellConfig{Command: "true"}},
+			},
+			want: `resolves to "deploy-us.east"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step.Foreach.Items = tt.items
+			_, stepErr := ExecuteForeach(context.Background(), step, &foreachMockLoader{steps: tt.steps}, nil, 0, nil)
+			if stepErr == nil || !strings.Contains(stepErr.Message, tt.want) {
+				t.Errorf("ExecuteForeach() error = %v, want containing %q", stepErr, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleForeach_TemplatedStepIDs(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "deploy.meow.toml")
+	template := `
+[region]
+name = "region"
+
+[[region.steps]]
+id = "deploy-{{item.region}}"
+executor = "shell"
+command = "echo {{item.region}}"
+
+[region.steps.outputs]
+region = { source = "stdout" }
+
+[[region.steps]]
+id = "verify"
+executor = "shell"
+command = "echo verified {{deploy-{{item.region}}.outputs.region}}"
+needs = ["deploy-{{item.region}}"]
+
+[region.steps.outputs]
+result = { source = "stdout" }
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wf := types.NewRun("test-wf", templatePath, nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["fanout"] = &types.Step{
+		ID:       "fanout",
+		Executor: types.ExecutorForeach,
+		Status:   types.StepStatusPending,
+		Foreach: &types.ForeachConfig{
+			Items:    `[{"region": "us-east"}, {"region": "eu-west"}]`,
+			ItemVar:  "item",
+			Template: ".region",
+		},
+	}
+	wf.Steps["report"] = &types.Step{
+		ID:       "report",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"fanout"},
+		Shell: &types.ShellConfig{
+			Command: "echo {{fanout.1.deploy-eu-west.outputs.region}}",
+			Outputs: map[string]types.OutputSource{"region": {Source: "stdout"}},
+		},
+	}
+	store := newMockRunStore()
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), NewTemplateExpanderAdapter(dir), testLogger())
+	orch.SetWorkflowID(wf.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for _, id := range []string{"fanout.0.deploy-us-east", "fanout.1.deploy-eu-west", "fanout.0.verify", "fanout.1.verify"} {
+		if step, ok := wf.Steps[id]; !ok || step.Status != types.StepStatusDone {
+			t.Fatalf("step %s missing or not done: %+v", id, step)
+		}
+	}
+	if got := wf.Steps["fanout.0.verify"].Outputs["result"]; got != "verified us-east" {
+		t.Errorf("fanout.0.verify result = %q, want %q", got, "verified us-east")
+	}
+	if got := wf.Steps["report"].Outputs["region"]; got != "eu-west" {
+		t.Errorf("report region = %q, want eu-west", got)
+	}
+}
+
+func TestHandleForeach_Gate(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "approve.meow.toml")
+	template := `
+[approve]
+name = "approve"
+
+[[approve.steps]]
+id = "wait"
+executor = "gate"
+wait_for_event = "approve-{{item}}"
+
+[[approve.steps]]
+id = "ship"
+executor = "shell"
+command = "echo shipped {{item}}"
+needs = ["wait"]
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wf := types.NewRun("test-wf", templatePath, nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["fanout"] = &types.Step{
+		ID:       "fanout",
+		Executor: types.ExecutorForeach,
+		Status:   types.StepStatusPending,
+		Foreach: &types.ForeachConfig{
+			Items:    `["a", "b"]`,
+			ItemVar:  "item",
+			Template: ".approve",
+		},
+	}
+	store := newMockRunStore()
+	store.workflows[wf.ID] = wf
+
+	router := NewEventRouter(testLogger())
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), NewTemplateExpanderAdapter(dir), testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetEventRouter(router)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- orch.Run(ctx) }()
+
+	for _, event := range []string{"approve-a", "approve-b"} {
+		for router.WaiterCount(event) == 0 {
+			select {
+			case err := <-done:
+				t.Fatalf("Run() returned before the %s gate waited: %v", event, err)
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		if !router.Route(&ipc.EventMessage{Type: ipc.MsgEvent, EventType: event}) {
+			t.Fatalf("%s event was not delivered to the gate", event)
+		}
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, id := range []string{"fanout.0.wait", "fanout.1.wait", "fanout.0.ship", "fanout.1.ship"} {
+		if step, ok := wf.Steps[id]; !ok || step.Status != types.StepStatusDone {
+			t.Fatalf("step %s missing or not done: %+v", id, step)
+		}
+	}
+	if got := wf.Steps["fanout.1.wait"].Gate.WaitForEvent; got != "approve-b" {
+		t.Errorf("fanout.1.wait event = %q, want approve-b", got)
+	}
+}
+
 func TestExecuteForeach_MissingConfig(t *testing.T) {
 	step := &types.Step{
 		ID:       "foreach-no-config",
@@ -549,6 +889,37 @@ func TestIsForeachFailed(t *testing.T) {
 	}
 }
 
+func TestIsForeachFullyExpanded(t *testing.T) {
+	foreachStep := &types.Step{
+		ID:           "foreach",
+		Executor:     types.ExecutorForeach,
+		Status:       types.StepStatusRunning,
+		ExpandedInto: []string{"foreach.0.build", "foreach.0.test", "foreach.1.build", "foreach.1.test"},
+	}
+	steps := map[string]*types.Step{
+		"foreach":         foreachStep,
+		"foreach.0.build": {ID: "foreach.0.build", Status: types.StepStatusDone},
+		"foreach.0.test":  {ID: "foreach.0.test", Status: types.StepStatusDone},
+		"foreach.1.build": {ID: "foreach.1.build", Status: types.StepStatusDone},
+		"foreach.1.test":  {ID: "foreach.1.test", Status: types.StepStatusRunning},
+	}
+
+	if !IsForeachFullyExpanded(foreachStep, steps) {
+		t.Error("IsForeachFullyExpanded() = false with every child present")
+	}
+	if got := CountCompletedIterations(foreachStep, steps); got != 1 {
+		t.Errorf("CountCompletedIterations() = %d, want 1", got)
+	}
+
+	delete(steps, "foreach.1.test")
+	if IsForeachFullyExpanded(foreachStep, steps) {
+		t.Error("IsForeachFullyExpanded() = true with a child missing")
+	}
+	if IsForeachFullyExpanded(&types.Step{ID: "foreach", Executor: types.ExecutorForeach}, steps) {
+		t.Error("IsForeachFullyExpanded() = true before expansion")
+	}
+}
+
 func TestCountRunningIterations(t *testing.T) {
 	foreachStep := &types.Step{
 		ID:           "foreach",
@@ -602,6 +973,173 @@ func TestCountRunningIterations(t *testing.T) {
 	}
 }
 
+func TestCheckForeachCompletion_ResultsIndexOrdered(t *testing.T) {
+	const n = 12 // More than 10 so lexical ordering ("10" < "2") would be caught
+	wf := types.NewRun("test-wf", "test-template", nil)
+	foreachStep := &types.Step{
+		ID:       "fan",
+		Executor: types.ExecutorForeach,
+		Status:   types.StepStatusRunning,
+		Foreach:  &types.ForeachConfig{ItemVar: "item", Template: ".worker"},
+	}
+	wf.Steps[foreachStep.ID] = foreachStep
+
+	// Iterations finish in reverse order; ExpandedInto lists them as they finished
+	for i := n - 1; i >= 0; i-- {
+		id := fmt.Sprintf("fan.%d.work", i)
+		child := &types.Step{ID: id, Executor: types.ExecutorShell, Status: types.StepStatusRunning, ExpandedFrom: "fan"}
+		if err := child.Complete(map[string]any{"value": fmt.Sprintf("item-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+		wf.Steps[id] = child
+		foreachStep.ExpandedInto = append(foreachStep.ExpandedInto, id)
+	}
+
+	orch := New(testConfig(), newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if !orch.checkForeachCompletion(wf) {
+		t.Fatal("checkForeachCompletion() = false, want foreach completed")
+	}
+	if foreachStep.Status != types.StepStatusDone {
+		t.Fatalf("foreach status = %s, want done", foreachStep.Status)
+	}
+
+	results, ok := foreachStep.Outputs["results"].([]any)
+	if !ok || len(results) != n {
+		t.Fatalf("results = %#v, want %d entries", foreachStep.Outputs["results"], n)
+	}
+	byIndex, ok := foreachStep.Outputs["results_by_index"].(map[string]any)
+	if !ok || len(byIndex) != n {
+		t.Fatalf("results_by_index = %#v, want %d entries", foreachStep.Outputs["results_by_index"], n)
+	}
+	for i, r := range results {
+		want := fmt.Sprintf("item-%d", i)
+		work := r.(map[string]any)["work"].(map[string]any)
+		if work["value"] != want {
+			t.Errorf("results[%d].work.value = %v, want %s", i, work["value"], want)
+		}
+		indexed := byIndex[strconv.Itoa(i)].(map[string]any)["work"].(map[string]any)
+		if indexed["value"] != want {
+			t.Errorf("results_by_index[%d].work.value = %v, want %s", i, indexed["value"], want)
+		}
+	}
+}
+
+func TestCheckForeachCompletion_Collect(t *testing.T) {
+	wf := types.NewRun("test-wf", "test-template", nil)
+	foreachStep := &types.Step{
+		ID:       "fan",
+		Executor: types.ExecutorForeach,
+		Status:   types.StepStatusRunning,
+		Foreach:  &types.ForeachConfig{ItemVar: "item", Template: ".worker", Collect: "work.summary"},
+	}
+	wf.Steps[foreachStep.ID] = foreachStep
+
+	// Three iterations of two steps, finishing out of order
+	for _, i := range []int{2, 0, 1} {
+		work := &types.Step{ID: fmt.Sprintf("fan.%d.work", i), Status: types.StepStatusRunning, ExpandedFrom: "fan"}
+		if err := work.Complete(map[string]any{"summary": fmt.Sprintf("summary-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+		report := &types.Step{ID: fmt.Sprintf("fan.%d.report", i), Status: types.StepStatusDone, ExpandedFrom: "fan"}
+		for _, child := range []*types.Step{work, report} {
+			wf.Steps[child.ID] = child
+			foreachStep.ExpandedInto = append(foreachStep.ExpandedInto, child.ID)
+		}
+	}
+
+	orch := New(testConfig(), newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if !orch.checkForeachCompletion(wf) {
+		t.Fatal("checkForeachCompletion() = false, want foreach completed")
+	}
+	if foreachStep.Status != types.StepStatusDone {
+		t.Fatalf("foreach status = %s, want done", foreachStep.Status)
+	}
+
+	want := []any{"summary-0", "summary-1", "summary-2"}
+	if got := foreachStep.Outputs["collected"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("collected = %#v, want %#v", got, want)
+	}
+	// Downstream steps reference the values like any other output
+	if got := orch.resolveOutputRefs(wf, "{{fan.outputs.collected.1}}", "merge"); got != "summary-1" {
+		t.Errorf("{{fan.outputs.collected.1}} = %q, want summary-1", got)
+	}
+}
+
+func TestCollectForeachOutput_MissingValues(t *testing.T) {
+	results := []any{
+		map[string]any{"work": map[string]any{"summary": "ok", "stats": map[string]any{"lines": 3}}},
+		map[string]any{}, // Failed iteration: no outputs
+		map[string]any{"work": map[string]any{"other": "x"}},
+	}
+	if got, want := CollectForeachOutput("work.summary", results), []any{"ok", nil, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectForeachOutput(work.summary) = %#v, want %#v", got, want)
+	}
+	if got, want := CollectForeachOutput("work.stats.lines", results), []any{3, nil, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectForeachOutput(work.stats.lines) = %#v, want %#v", got, want)
+	}
+}
+
+func TestCheckForeachCompletion_FailureThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  string
+		failures   int
+		wantStatus types.StepStatus
+	}{
+		{name: "2 of 10 within 30%", threshold: "30%", failures: 2, wantStatus: types.StepStatusDone},
+		{name: "3 of 10 at 30%", threshold: "30%", failures: 3, wantStatus: types.StepStatusDone},
+		{name: "4 of 10 over 30%", threshold: "30%", failures: 4, wantStatus: types.StepStatusFailed},
+		{name: "2 within a count of 2", threshold: "2", failures: 2, wantStatus: types.StepStatusDone},
+		{name: "1 with no threshold", threshold: "", failures: 1, wantStatus: types.StepStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := types.NewRun("test-wf", "test-template", nil)
+			foreachStep := &types.Step{
+				ID:       "fan",
+				Executor: types.ExecutorForeach,
+				Status:   types.StepStatusRunning,
+				Foreach:  &types.ForeachConfig{ItemVar: "item", Template: ".worker", FailureThreshold: tt.threshold},
+			}
+			wf.Steps[foreachStep.ID] = foreachStep
+
+			// 10 iterations of two steps; the first step of the last
+			// iterations fails and its dependent is skipped
+			for i := 0; i < 10; i++ {
+				work := &types.Step{ID: fmt.Sprintf("fan.%d.work", i), Status: types.StepStatusDone, ExpandedFrom: "fan"}
+				report := &types.Step{ID: fmt.Sprintf("fan.%d.report", i), Status: types.StepStatusDone, ExpandedFrom: "fan"}
+				if i >= 10-tt.failures {
+					work.Status = types.StepStatusFailed
+					report.Status = types.StepStatusSkipped
+				}
+				for _, child := range []*types.Step{work, report} {
+					wf.Steps[child.ID] = child
+					foreachStep.ExpandedInto = append(foreachStep.ExpandedInto, child.ID)
+				}
+			}
+
+			orch := New(testConfig(), newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			if !orch.checkForeachCompletion(wf) {
+				t.Fatal("checkForeachCompletion() = false, want foreach finished")
+			}
+			if foreachStep.Status != tt.wantStatus {
+				t.Fatalf("foreach status = %s, want %s (error %+v)", foreachStep.Status, tt.wantStatus, foreachStep.Error)
+			}
+			if got := wf.HasFailed(); got != (tt.wantStatus == types.StepStatusFailed) {
+				t.Errorf("HasFailed() = %v, want failed iterations to fail the run only over the threshold", got)
+			}
+
+			if tt.wantStatus == types.StepStatusDone {
+				failed, _ := foreachStep.Outputs["failed_iterations"].([]int)
+				if len(failed) != tt.failures || failed[0] != 10-tt.failures {
+					t.Errorf("failed_iterations = %v, want the last %d iterations", foreachStep.Outputs["failed_iterations"], tt.failures)
+				}
+			}
+		})
+	}
+}
+
 func TestForeachConfig_IsParallel(t *testing.T) {
 	trueBool := true
 	falseBool := false
@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// handleGate starts a gate step and waits in the background for the event it
+// names. The step stays running until the event arrives through the event
+// router (meow event <name>), then completes with the event's data as its
+// outputs. No subprocess is involved, so a resumed workflow simply waits again.
+func (o *Orchestrator) handleGate(ctx context.Context, wf *types.Run, step *types.Step) error {
+	if step.Gate == nil {
+		return fmt.Errorf("gate step %s missing config", step.ID)
+	}
+	cfg := step.Gate
+
+	var timeout time.Duration
+	if cfg.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %v", cfg.Timeout, err)
+		}
+	}
+
+	if err := step.Start(); err != nil {
+		return fmt.Errorf("starting step: %w", err)
+	}
+
+	if o.eventRouter == nil {
+		return step.Fail(&types.StepError{
+			Message: fmt.Sprintf("cannot wait for event %q: no event router", cfg.WaitForEvent),
+		})
+	}
+
+	// Register before returning so an event sent right after dispatch is not missed
+	ch := o.eventRouter.RegisterWaiter(cfg.WaitForEvent, nil, timeout)
+	o.stepLogger(ctx).Info("gate waiting for event", "step", step.ID, "event", cfg.WaitForEvent, "timeout", cfg.Timeout)
+
+	// Capture IDs by value for goroutine (NOT pointers!)
+	workflowID := wf.ID
+	stepID := step.ID
+
+	gateCtx, cancel := context.WithCancel(ctx)
+	o.pendingCommands.Store(workflowID+":"+stepID, cancel)
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer o.pendingCommands.Delete(workflowID + ":" + stepID)
+		defer cancel()
+		o.awaitGateEvent(gateCtx, workflowID, stepID, cfg, ch, timeout)
+	}()
+	return nil
+}
+
+// awaitGateEvent blocks until the gate's event arrives, its timeout passes, or
+// ctx is cancelled, then completes or fails the step. Called in a goroutine.
+func (o *Orchestrator) awaitGateEvent(ctx context.Context, workflowID, stepID string, cfg *types.GateConfig, ch <-chan *ipc.EventMessage, timeout time.Duration) {
+	logger := o.stepLogger(ctx)
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var event *ipc.EventMessage
+	select {
+	case event = <-ch:
+	case <-deadline:
+	case <-ctx.Done():
+		// Workflow stopping: leave the step running (recovery resets it)
+		o.eventRouter.RemoveWaiter(ch)
+		logger.Info("gate wait cancelled", "step", stepID, "event", cfg.WaitForEvent)
+		return
+	}
+	if event == nil {
+		o.eventRouter.RemoveWaiter(ch)
+	}
+
+	o.wfMu.Lock()
+	defer o.wfMu.Unlock()
+
+	wf, err := o.store.Get(ctx, workflowID)
+	if err != nil {
+		logger.Error("re-fetching workflow after gate event", "error", err)
+		return
+	}
+	if wf == nil || wf.Status.IsTerminal() {
+		return
+	}
+	step, ok := wf.GetStep(stepID)
+	if !ok || step.Status != types.StepStatusRunning {
+		return
+	}
+
+	if event == nil {
+		logger.Warn("gate timed out", "step", stepID, "event", cfg.WaitForEvent, "timeout", cfg.Timeout)
+		if err := step.Fail(&types.StepError{
+			Message: fmt.Sprintf("event %q not received within %s", cfg.WaitForEvent, cfg.Timeout),
+			Type:    types.StepErrorTimeout,
+		}); err != nil {
+			logger.Error("failed to mark step as failed", "step", stepID, "error", err)
+			return
+		}
+	} else {
+		logger.Info("gate event received", "step", stepID, "event", cfg.WaitForEvent)
+		outputs := make(map[string]any, len(event.Data))
+		maps.Copy(outputs, event.Data)
+		if err := step.Complete(o.applyOutputBudget(wf, step, outputs)); err != nil {
+			logger.Error("failed to complete step", "step", stepID, "error", err)
+			return
+		}
+	}
+	o.recordStepFinished(wf.ID, step)
+	if err := o.store.Save(ctx, wf); err != nil {
+		logger.Error("failed to save workflow after gate", "step", stepID, "error", err)
+	}
+}
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// newGateWorkflow returns a running workflow whose deploy step waits behind
+// an approve gate.
+func newGateWorkflow(timeout string) *types.Run {
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["approve"] = &types.Step{
+		ID:       "approve",
+		Executor: types.ExecutorGate,
+		Status:   types.StepStatusPending,
+		Gate:     &types.GateConfig{WaitForEvent: "approve-deploy", Timeout: timeout},
+	}
+	wf.Steps["deploy"] = &types.Step{
+		ID:       "deploy",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"approve"},
+		Shell:    &types.ShellConfig{Command: "echo deploying"},
+	}
+	return wf
+}
+
+func TestHandleGate_CompletesOnEvent(t *testing.T) {
+	store := newMockRunStore()
+	wf := newGateWorkflow("")
+	store.workflows[wf.ID] = wf
+
+	router := NewEventRouter(testLogger())
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetEventRouter(router)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- orch.Run(ctx) }()
+
+	// Events are not queued: wait for the gate to be listening
+	for router.WaiterCount("approve-deploy") == 0 {
+		select {
+		case err := <-done:
+			t.Fatalf("Run() returned before the gate waited: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// Unrelated events leave the gate closed
+	router.Route(&ipc.EventMessage{Type: ipc.MsgEvent, EventType: "approve-rollback"})
+	if !router.Route(&ipc.EventMessage{
+		Type:      ipc.MsgEvent,
+		EventType: "approve-deploy",
+		Data:      map[string]any{"approver": "alice"},
+	}) {
+		t.Fatal("approve-deploy event was not delivered to the gate")
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	gate := wf.Steps["approve"]
+	if gate.Status != types.StepStatusDone {
+		t.Fatalf("gate status = %s, want done", gate.Status)
+	}
+	if gate.Outputs["approver"] != "alice" {
+		t.Errorf("gate outputs = %v, want approver=alice", gate.Outputs)
+	}
+	if status := wf.Steps["deploy"].Status; status != types.StepStatusDone {
+		t.Errorf("deploy status = %s, want done", status)
+	}
+}
+
+func TestHandleGate_Timeout(t *testing.T) {
+	store := newMockRunStore()
+	wf := newGateWorkflow("50ms")
+	store.workflows[wf.ID] = wf
+
+	router := NewEventRouter(testLogger())
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetEventRouter(router)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	gate := wf.Steps["approve"]
+	if gate.Status != types.StepStatusFailed {
+		t.Fatalf("gate status = %s, want failed", gate.Status)
+	}
+	if gate.Error == nil || gate.Error.Type != types.StepErrorTimeout {
+		t.Errorf("gate error = %+v, want timeout", gate.Error)
+	}
+	if status := wf.Steps["deploy"].Status; status == types.StepStatusDone {
+		t.Error("deploy ran although the gate timed out")
+	}
+	if n := router.WaiterCount(""); n != 0 {
+		t.Errorf("waiters left after timeout = %d, want 0", n)
+	}
+}
+
+func TestHandleGate_NoEventRouter(t *testing.T) {
+	store := newMockRunStore()
+	wf := newGateWorkflow("")
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if err := orch.processWorkflow(context.Background(), wf); err != nil {
+		t.Fatalf("processWorkflow() error = %v", err)
+	}
+	if status := wf.Steps["approve"].Status; status != types.StepStatusFailed {
+		t.Errorf("gate status = %s, want failed", status)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/akatz-ai/meow/internal/types"
 )
@@ -24,8 +25,24 @@ type AgentStopConfig struct {
 	WorkflowID string // Workflow instance ID (to construct tmux session name)
 	Graceful   bool   // Whether to attempt graceful shutdown
 	Timeout    int    // Seconds to wait for graceful shutdown
+	// GracePeriod, when set, overrides Timeout
+	GracePeriod time.Duration
 }
 
+// StopOutcome records how a kill step stopped its agent. It is the kill
+// step's "stopped" output, so templates can branch on it.
+type StopOutcome string
+
+const (
+	// StopNotRunning means the agent had already exited.
+	StopNotRunning StopOutcome = "not_running"
+	// StopGraceful means the agent exited within its grace period.
+	StopGraceful StopOutcome = "graceful"
+	// StopForced means the agent's session was killed, either because the
+	// kill was not graceful or because the grace period ran out.
+	StopForced StopOutcome = "forced"
+)
+
 // KillResult contains the results of killing an agent.
 type KillResult struct {
 	WasRunning bool // Whether the agent was running before kill
@@ -68,10 +85,11 @@ func ExecuteKill(ctx context.Context, step *types.Step, workflowID string, stopp
 	}
 
 	stopCfg := &AgentStopConfig{
-		AgentID:    cfg.Agent,
-		WorkflowID: workflowID,
-		Graceful:   cfg.Graceful,
-		Timeout:    timeout,
+		AgentID:     cfg.Agent,
+		WorkflowID:  workflowID,
+		Graceful:    cfg.Graceful,
+		Timeout:     timeout,
+		GracePeriod: cfg.GracePeriod,
 	}
 
 	// Stop the agent
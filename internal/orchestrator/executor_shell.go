@@ -5,10 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/akatz-ai/meow/internal/config"
 	"github.com/akatz-ai/meow/internal/types"
 )
 
@@ -18,6 +22,8 @@ type ShellResult struct {
 	ExitCode int
 	Stdout   string
 	Stderr   string
+	Duration time.Duration  // Wall-clock run time of the command
+	Usage    *ResourceUsage // CPU and memory used (nil if unavailable)
 }
 
 // ExecuteShell runs a shell command and captures outputs.
@@ -37,16 +43,45 @@ func ExecuteShell(ctx context.Context, step *types.Step) (*ShellResult, *types.S
 			result.Outputs["error"] = err.Error()
 			return result, nil
 		}
-		return result, &types.StepError{
-			Message: err.Error(),
-			Code:    result.ExitCode,
-			Output:  result.Stderr,
-		}
+		return result, types.NewCommandError(err.Error(), cfg.Command, result.ExitCode, result.Stdout, result.Stderr)
 	}
 
 	return result, nil
 }
 
+// shellErrorPattern is a compiled [shell.error_patterns] entry.
+type shellErrorPattern struct {
+	re      *regexp.Regexp
+	errType types.StepErrorType
+}
+
+// compileShellErrorPatterns compiles the configured error patterns in order.
+// Patterns are validated when config is loaded; any that still fail to
+// compile are logged and ignored.
+func compileShellErrorPatterns(patterns []config.ErrorPattern, logger *slog.Logger) []shellErrorPattern {
+	var compiled []shellErrorPattern
+	for _, p := range patterns {
+		re, err := regexp.Compile(p.Match)
+		if err != nil {
+			logger.Warn("ignoring invalid shell error pattern", "match", p.Match, "error", err)
+			continue
+		}
+		compiled = append(compiled, shellErrorPattern{re: re, errType: types.StepErrorType(p.Type)})
+	}
+	return compiled
+}
+
+// shellErrorType classifies a failed shell command by its stderr: the type of
+// the first matching error pattern, or command_failed if none match.
+func (o *Orchestrator) shellErrorType(stderr string) types.StepErrorType {
+	for _, p := range o.shellErrorPatterns {
+		if p.re.MatchString(stderr) {
+			return p.errType
+		}
+	}
+	return types.StepErrorCommandFailed
+}
+
 // runShellCommand executes the shell command and captures output.
 func runShellCommand(ctx context.Context, cfg *types.ShellConfig) (*ShellResult, error) {
 	result := &ShellResult{
@@ -55,15 +90,33 @@ func runShellCommand(ctx context.Context, cfg *types.ShellConfig) (*ShellResult,
 
 	// Create command
 	cmd := exec.CommandContext(ctx, "sh", "-c", cfg.Command)
+	killProcessGroupOnCancel(cmd)
+	stopCancel, err := applyCancelSignal(cmd, cfg.CancelSignal, cfg.CancelGrace)
+	if err != nil {
+		result.ExitCode = -1
+		result.Stderr = err.Error()
+		return result, err
+	}
 
 	// Set working directory
 	if cfg.Workdir != "" {
 		cmd.Dir = cfg.Workdir
 	}
 
+	if cfg.RunAs != "" {
+		if err := runAsUser(cmd, cfg.RunAs); err != nil {
+			// Never ran: report it as a failed command rather than a clean exit
+			result.ExitCode = -1
+			result.Stderr = err.Error()
+			return result, err
+		}
+	}
+
 	// Set environment variables
 	if len(cfg.Env) > 0 {
-		cmd.Env = os.Environ()
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
 		for k, v := range cfg.Env {
 			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 		}
@@ -75,7 +128,10 @@ func runShellCommand(ctx context.Context, cfg *types.ShellConfig) (*ShellResult,
 	cmd.Stderr = &stderr
 
 	// Run the command
-	err := cmd.Run()
+	started := time.Now()
+	err = cmd.Run()
+	stopCancel()
+	result.Duration = time.Since(started)
 
 	// Capture raw output
 	result.Stdout = strings.TrimSpace(stdout.String())
@@ -85,6 +141,7 @@ func runShellCommand(ctx context.Context, cfg *types.ShellConfig) (*ShellResult,
 	if cmd.ProcessState != nil {
 		result.ExitCode = cmd.ProcessState.ExitCode()
 	}
+	result.Usage = resourceUsage(cmd.ProcessState)
 
 	// Capture outputs based on config
 	// Note: This standalone executor doesn't have workflow context for step output substitution
@@ -100,12 +157,46 @@ func runShellCommand(ctx context.Context, cfg *types.ShellConfig) (*ShellResult,
 		}
 	}
 
-	// Always include exit_code in outputs for convenience
+	// Always include exit_code and duration_ms in outputs for convenience
 	result.Outputs["exit_code"] = result.ExitCode
+	result.Outputs["duration_ms"] = result.Duration.Milliseconds()
+	addUsageOutputs(result.Outputs, result.Usage)
 
 	return result, err
 }
 
+// matchOutputPattern applies an output's regex to the captured text. The first
+// capture group is returned, or the whole match if the pattern has no groups.
+func matchOutputPattern(pattern, value string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	match := re.FindStringSubmatch(value)
+	if match == nil {
+		return "", fmt.Errorf("pattern %q did not match", pattern)
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
+
+// selectOutputLine returns the nth line of the captured text, counting from 1,
+// or from the end if n is negative (-1 is the last line). A trailing newline
+// does not start an empty last line.
+func selectOutputLine(n int, value string) (string, error) {
+	lines := strings.Split(strings.TrimSuffix(value, "\n"), "\n")
+	i := n - 1
+	if n < 0 {
+		i = len(lines) + n
+	}
+	if i < 0 || i >= len(lines) {
+		return "", fmt.Errorf("line %d out of range (%d lines)", n, len(lines))
+	}
+	return strings.TrimRight(lines[i], "\r"), nil
+}
+
 // SourceSubstituteFunc substitutes variables in a source path at runtime.
 // Used to resolve step output references like {{step.outputs.field}} in output paths.
 type SourceSubstituteFunc func(source string) (string, error)
@@ -125,34 +216,60 @@ func captureOutput(outputSource types.OutputSource, result *ShellResult, substit
 	case "exit_code":
 		return result.ExitCode, nil // exit_code is always int, ignore type
 	default:
-		// Check for file: prefix
-		if strings.HasPrefix(source, "file:") {
-			filePath := strings.TrimPrefix(source, "file:")
-			// Substitute any remaining variable references (e.g., step outputs)
-			if substituteSource != nil && strings.Contains(filePath, "{{") {
-				substituted, err := substituteSource(filePath)
-				if err != nil {
-					return nil, fmt.Errorf("substituting output path: %w", err)
-				}
-				filePath = substituted
-			}
-			content, err := os.ReadFile(filePath)
+		filePath, ok := outputSource.FilePath()
+		if !ok {
+			return nil, fmt.Errorf("unknown output source: %s", source)
+		}
+		// Substitute any remaining variable references (e.g., step outputs)
+		if substituteSource != nil && strings.Contains(filePath, "{{") {
+			substituted, err := substituteSource(filePath)
 			if err != nil {
-				return nil, fmt.Errorf("reading output file %s: %w", filePath, err)
+				return nil, fmt.Errorf("substituting output path: %w", err)
 			}
-			value = strings.TrimSpace(string(content))
-		} else {
-			return nil, fmt.Errorf("unknown output source: %s", source)
+			filePath = substituted
 		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading output file %s: %w", filePath, err)
+		}
+		value = strings.TrimSpace(string(content))
+		source = "file:" + filePath
+	}
+
+	if outputSource.Line != 0 {
+		selected, err := selectOutputLine(outputSource.Line, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w in %s", err, source)
+		}
+		value = selected
+	}
+
+	if outputSource.Pattern != "" {
+		matched, err := matchOutputPattern(outputSource.Pattern, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w in %s", err, source)
+		}
+		value = matched
 	}
 
 	// Handle type conversion
-	if outputSource.Type == "json" {
+	if outputSource.IsJSON() {
 		var parsed any
 		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
 			return nil, fmt.Errorf("parsing JSON from %s: %w", source, err)
 		}
-		return parsed, nil
+		if outputSource.Field == "" {
+			return parsed, nil
+		}
+		obj, ok := parsed.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("selecting field %s: JSON from %s is not an object", outputSource.Field, source)
+		}
+		selected, ok := getNestedOutputValue(obj, outputSource.Field)
+		if !ok {
+			return nil, fmt.Errorf("field %s not found in JSON from %s", outputSource.Field, source)
+		}
+		return selected, nil
 	}
 
 	return value, nil
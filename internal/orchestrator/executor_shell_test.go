@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -510,3 +511,138 @@ func TestExecuteShell_JSONOutputParsing(t *testing.T) {
 		t.Errorf("expected nested='value', got %v", dataMap["nested"])
 	}
 }
+
+func TestExecuteShell_JSONFileOutput(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	step := &types.Step{
+		ID:       "test-json-file",
+		Executor: types.ExecutorShell,
+		Shell: &types.ShellConfig{
+			Command: `echo '{"summary":{"passed":12,"failed":1},"failures":[{"name":"TestFoo"}]}' > ` + outputFile,
+			Outputs: map[string]types.OutputSource{
+				"report":  {Source: "file", Path: outputFile},
+				"first":   {Source: "file", Path: outputFile, Field: "failures[0].name"},
+				"summary": {Source: "file", Path: outputFile, Field: "summary"},
+			},
+		},
+	}
+
+	result, stepErr := ExecuteShell(context.Background(), step)
+	if stepErr != nil {
+		t.Fatalf("unexpected error: %v", stepErr)
+	}
+
+	// The whole document is stored as a map, so nested refs resolve
+	report, ok := result.Outputs["report"].(map[string]any)
+	if !ok {
+		t.Fatalf("report is not a map, got %T", result.Outputs["report"])
+	}
+	if got, _ := getNestedOutputValue(report, "summary.failed"); got != float64(1) {
+		t.Errorf("report.summary.failed = %v, want 1", got)
+	}
+	if result.Outputs["first"] != "TestFoo" {
+		t.Errorf("first = %v, want TestFoo", result.Outputs["first"])
+	}
+	summary, ok := result.Outputs["summary"].(map[string]any)
+	if !ok || summary["passed"] != float64(12) {
+		t.Errorf("summary = %v, want passed=12", result.Outputs["summary"])
+	}
+}
+
+func TestExecuteShell_JSONFileOutput_Errors(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	tests := []struct {
+		name    string
+		content string
+		field   string
+		wantErr string
+	}{
+		{"invalid json", "not json", "", "parsing JSON"},
+		{"missing field", `{"a":1}`, "b.c", "field b.c not found"},
+		{"not an object", `[1,2]`, "a", "is not an object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(outputFile, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			source := types.OutputSource{Source: "file", Path: outputFile, Field: tt.field}
+			_, err := captureOutput(source, &ShellResult{}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("captureOutput() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCaptureOutput_Line(t *testing.T) {
+	result := &ShellResult{Stdout: "first\nsecond\nthird\n"}
+	tests := []struct {
+		name    string
+		source  types.OutputSource
+		want    any
+		wantErr string
+	}{
+		{"first line", types.OutputSource{Source: "stdout", Line: 1}, "first", ""},
+		{"last line", types.OutputSource{Source: "stdout", Line: -1}, "third", ""},
+		{"line then pattern", types.OutputSource{Source: "stdout", Line: 2, Pattern: `s(\w+)d`}, "econ", ""},
+		{"out of range", types.OutputSource{Source: "stdout", Line: 4}, nil, "line 4 out of range (3 lines)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := captureOutput(tt.source, result, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("captureOutput() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("captureOutput() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("captureOutput() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteShell_ResourceUsage(t *testing.T) {
+	// The shell itself holds a 32MB string, so its peak RSS must exceed that
+	step := &types.Step{
+		ID:       "test-rusage",
+		Executor: types.ExecutorShell,
+		Shell: &types.ShellConfig{
+			Command: `x=$(head -c 33554432 /dev/zero | tr '\0' a); echo ${#x}`,
+		},
+	}
+
+	result, stepErr := ExecuteShell(context.Background(), step)
+	if stepErr != nil {
+		t.Fatalf("unexpected error: %v", stepErr)
+	}
+	if result.Usage == nil {
+		t.Fatal("expected resource usage on Linux/macOS, got nil")
+	}
+	if result.Usage.MaxRSSBytes < 32<<20 {
+		t.Errorf("MaxRSSBytes = %d, want at least 32MB", result.Usage.MaxRSSBytes)
+	}
+	if result.Outputs["max_rss_bytes"] != result.Usage.MaxRSSBytes {
+		t.Errorf("max_rss_bytes output = %v, want %d", result.Outputs["max_rss_bytes"], result.Usage.MaxRSSBytes)
+	}
+	if _, ok := result.Outputs["cpu_ms"].(int64); !ok {
+		t.Errorf("cpu_ms output = %#v, want int64", result.Outputs["cpu_ms"])
+	}
+}
+
+func TestResourceUsage_Unavailable(t *testing.T) {
+	// A command that never ran has no process state; outputs are left alone
+	if usage := resourceUsage(nil); usage != nil {
+		t.Errorf("resourceUsage(nil) = %+v, want nil", usage)
+	}
+	outputs := map[string]any{"exit_code": 0}
+	addUsageOutputs(outputs, nil)
+	if len(outputs) != 1 {
+		t.Errorf("outputs = %v, want only exit_code", outputs)
+	}
+}
@@ -313,3 +313,85 @@ func TestFileTemplateExpander_CollectionErrorMessage(t *testing.T) {
 		t.Errorf("Error should indicate collection search path: %s", errStr)
 	}
 }
+
+func TestFileTemplateExpander_WhenVarOmitsOptionalStep(t *testing.T) {
+	baseDir := t.TempDir()
+	modulePath := filepath.Join(baseDir, "optional.meow.toml")
+	content := `
+[main]
+name = "optional"
+
+[main.variables.reviewer]
+description = "Agent to review the build (optional)"
+
+[[main.steps]]
+id = "build"
+executor = "shell"
+command = "make"
+
+[[main.steps]]
+id = "review"
+executor = "shell"
+command = "echo review by {{reviewer}}"
+when_var = "reviewer"
+needs = ["build"]
+
+[[main.steps]]
+id = "publish"
+executor = "shell"
+command = "echo publish"
+needs = ["review"]
+`
+	if err := os.WriteFile(modulePath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+
+	expander := NewFileTemplateExpander(baseDir)
+
+	t.Run("unset variable omits step", func(t *testing.T) {
+		config := &types.ExpandConfig{Template: modulePath}
+		result, err := expander.Expand(context.Background(), config, "expand", "", "")
+		if err != nil {
+			t.Fatalf("Expand failed: %v", err)
+		}
+		ids := getStepIDs(result.Steps)
+		if len(ids) != 2 || ids[0] != "expand.build" || ids[1] != "expand.publish" {
+			t.Fatalf("steps = %v, want [expand.build expand.publish]", ids)
+		}
+		// Dependents of the omitted step inherit its dependencies
+		publish := result.Steps[1]
+		if len(publish.Needs) != 1 || publish.Needs[0] != "expand.build" {
+			t.Errorf("publish needs = %v, want [expand.build]", publish.Needs)
+		}
+	})
+
+	t.Run("empty variable omits step", func(t *testing.T) {
+		config := &types.ExpandConfig{Template: modulePath, Variables: map[string]any{"reviewer": ""}}
+		result, err := expander.Expand(context.Background(), config, "expand", "", "")
+		if err != nil {
+			t.Fatalf("Expand failed: %v", err)
+		}
+		if len(result.Steps) != 2 {
+			t.Fatalf("steps = %v, want 2 steps", getStepIDs(result.Steps))
+		}
+	})
+
+	t.Run("set variable keeps step", func(t *testing.T) {
+		config := &types.ExpandConfig{Template: modulePath, Variables: map[string]any{"reviewer": "alice"}}
+		result, err := expander.Expand(context.Background(), config, "expand", "", "")
+		if err != nil {
+			t.Fatalf("Expand failed: %v", err)
+		}
+		ids := getStepIDs(result.Steps)
+		if len(ids) != 3 || ids[1] != "expand.review" {
+			t.Fatalf("steps = %v, want build, review, publish", ids)
+		}
+		if got := result.Steps[1].Shell.Command; got != "echo review by alice" {
+			t.Errorf("review command = %q", got)
+		}
+		publish := result.Steps[2]
+		if len(publish.Needs) != 1 || publish.Needs[0] != "expand.review" {
+			t.Errorf("publish needs = %v, want [expand.review]", publish.Needs)
+		}
+	})
+}
@@ -4,17 +4,72 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/akatz-ai/meow/internal/config"
 )
 
 // GenerateRunID creates a unique run identifier.
 // Format: run-{timestamp_hex}-{random_hex}
 // Example: run-1a2b3c4d-e5f6a7b8
 func GenerateRunID() string {
-	ts := time.Now().UnixNano()
+	return GenerateRunIDWithScheme(config.RunIDConfig{}, "")
+}
+
+// GenerateRunIDWithScheme creates a unique run identifier using a configured scheme.
+// Format: {prefix}[-{template}][-{timestamp}]-{random_hex}
+// Example: nightly-sprint-20260102-150405-e5f6a7b8
+// templateRef may be a name or path; it is reduced to a filesystem-safe name.
+func GenerateRunIDWithScheme(scheme config.RunIDConfig, templateRef string) string {
+	prefix := scheme.Prefix
+	if prefix == "" {
+		prefix = "run"
+	}
+	parts := []string{prefix}
+
+	if scheme.IncludeTemplate {
+		if name := runIDTemplateName(templateRef); name != "" {
+			parts = append(parts, name)
+		}
+	}
+
+	now := time.Now()
+	switch scheme.Timestamp {
+	case config.RunIDTimestampNone:
+	case config.RunIDTimestampDatetime:
+		parts = append(parts, now.UTC().Format("20060102-150405"))
+	default:
+		parts = append(parts, fmt.Sprintf("%x", now.UnixNano()))
+	}
+
+	// Random suffix guarantees uniqueness even when timestamps collide
 	randBytes := make([]byte, 4)
 	rand.Read(randBytes)
-	return fmt.Sprintf("run-%x-%s", ts, hex.EncodeToString(randBytes))
+	parts = append(parts, hex.EncodeToString(randBytes))
+
+	return strings.Join(parts, "-")
+}
+
+// runIDTemplateName reduces a template reference ("lib/sprint.meow.toml#main")
+// to a filesystem-safe name ("sprint").
+func runIDTemplateName(templateRef string) string {
+	name, _, _ := strings.Cut(templateRef, "#")
+	name = filepath.Base(name)
+	name = strings.TrimSuffix(name, ".toml")
+	name = strings.TrimSuffix(name, ".meow")
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return strings.Trim(b.String(), "_-")
 }
 
 // GenerateExpandedStepID creates a unique step identifier within a run.
@@ -4,6 +4,8 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/akatz-ai/meow/internal/config"
 )
 
 func TestGenerateRunID(t *testing.T) {
@@ -46,6 +48,63 @@ func TestGenerateRunID(t *testing.T) {
 	})
 }
 
+func TestGenerateRunIDWithScheme(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheme      config.RunIDConfig
+		templateRef string
+		pattern     string
+	}{
+		{
+			name:    "defaults match GenerateRunID",
+			pattern: `^run-[0-9a-f]+-[0-9a-f]{8}$`,
+		},
+		{
+			name:        "prefix and template",
+			scheme:      config.RunIDConfig{Prefix: "ci", IncludeTemplate: true},
+			templateRef: "/work/.meow/workflows/sprint.meow.toml",
+			pattern:     `^ci-sprint-[0-9a-f]+-[0-9a-f]{8}$`,
+		},
+		{
+			name:        "template name is sanitized",
+			scheme:      config.RunIDConfig{IncludeTemplate: true, Timestamp: config.RunIDTimestampNone},
+			templateRef: "lib/my flow.meow.toml#main",
+			pattern:     `^run-my_flow-[0-9a-f]{8}$`,
+		},
+		{
+			name:    "datetime timestamp",
+			scheme:  config.RunIDConfig{Timestamp: config.RunIDTimestampDatetime},
+			pattern: `^run-\d{8}-\d{6}-[0-9a-f]{8}$`,
+		},
+		{
+			name:    "no timestamp",
+			scheme:  config.RunIDConfig{Prefix: "job", Timestamp: config.RunIDTimestampNone},
+			pattern: `^job-[0-9a-f]{8}$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := GenerateRunIDWithScheme(tt.scheme, tt.templateRef)
+			if !regexp.MustCompile(tt.pattern).MatchString(id) {
+				t.Errorf("GenerateRunIDWithScheme() = %q, want match for %s", id, tt.pattern)
+			}
+		})
+	}
+
+	t.Run("unique without timestamp", func(t *testing.T) {
+		scheme := config.RunIDConfig{Timestamp: config.RunIDTimestampNone}
+		seen := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			id := GenerateRunIDWithScheme(scheme, "")
+			if seen[id] {
+				t.Errorf("duplicate run ID generated: %s", id)
+			}
+			seen[id] = true
+		}
+	})
+}
+
 func TestGenerateExpandedStepID(t *testing.T) {
 	tests := []struct {
 		name     string
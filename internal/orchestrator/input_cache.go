@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"sort"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// A step's inputs (inputs = [...]) key its cached result: when a step
+// completes, each input glob's files are fingerprinted onto the step. A
+// resumed run keeps completed steps whose inputs still match (cache hit) and
+// re-runs the others, along with everything downstream of them (cache miss).
+
+// SetInputsDir sets the directory relative input globs are resolved against.
+func (o *Orchestrator) SetInputsDir(dir string) {
+	o.inputsDir = dir
+}
+
+// recordInputChecksums fingerprints a completed step's input globs.
+func (o *Orchestrator) recordInputChecksums(step *types.Step) {
+	if len(step.Inputs) == 0 {
+		return
+	}
+	step.InputChecksums = make(map[string]string, len(step.Inputs))
+	for _, input := range step.Inputs {
+		step.InputChecksums[input] = fingerprintGlob(o.inputsDir, input)
+	}
+}
+
+// inputsChanged reports whether any of a completed step's input globs no
+// longer match the checksums recorded when it completed. Inputs without a
+// recorded checksum count as unchanged.
+func (o *Orchestrator) inputsChanged(step *types.Step) bool {
+	for _, input := range step.Inputs {
+		recorded, ok := step.InputChecksums[input]
+		if ok && recorded != fingerprintGlob(o.inputsDir, input) {
+			return true
+		}
+	}
+	return false
+}
+
+// rerunStaleSteps resets completed steps whose inputs changed, and the
+// finished steps downstream of them, so they run again. Steps still pending
+// or in flight are left alone. Reports whether the run was modified.
+func (o *Orchestrator) rerunStaleSteps(wf *types.Run) bool {
+	stale := make(map[string]bool)
+	for id, step := range wf.Steps {
+		if step.Status == types.StepStatusDone && o.inputsChanged(step) {
+			stale[id] = true
+		}
+	}
+	if len(stale) == 0 {
+		return false
+	}
+	addDependents(wf, stale)
+
+	// Only finished steps are reset; pending and in-flight ones run anyway
+	var ids []string
+	for id := range stale {
+		if wf.Steps[id].Status.IsTerminal() {
+			ids = append(ids, id)
+		} else {
+			delete(stale, id)
+		}
+	}
+	if len(ids) == 0 {
+		return false
+	}
+	sort.Strings(ids)
+
+	var rerun []string
+	for _, id := range ids {
+		step := wf.Steps[id]
+		// Children of a rerun expansion are recreated when the parent runs again
+		if stale[step.ExpandedFrom] {
+			delete(wf.Steps, id)
+			continue
+		}
+		if err := step.Rerun(); err != nil {
+			o.logger.Error("failed to reset stale step", "step", id, "error", err)
+			continue
+		}
+		rerun = append(rerun, id)
+	}
+	o.logger.Info("inputs changed, re-running steps", "workflow", wf.ID, "steps", rerun)
+	return true
+}
@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestOrchestrator_Recover_RerunsStepsWithChangedInputs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	// A live agent keeps the run open while the shell steps finish
+	wf.Steps["review"] = &types.Step{
+		ID:        "review",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent:     &types.AgentConfig{Agent: "worker", Prompt: "Review"},
+	}
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Inputs:   []string{"src.txt"},
+		Shell:    &types.ShellConfig{Command: "cd " + dir + " && cat src.txt >> build.log"},
+	}
+	wf.Steps["report"] = &types.Step{
+		ID:       "report",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"build"},
+		Shell:    &types.ShellConfig{Command: "cd " + dir + " && echo run >> report.log"},
+	}
+	store.workflows[wf.ID] = wf
+
+	agents := newMockAgentManager()
+	agents.running["worker"] = true
+	newOrch := func() *Orchestrator {
+		orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		orch.SetInputsDir(dir)
+		return orch
+	}
+	runShellSteps := func(orch *Orchestrator) {
+		t.Helper()
+		for range 20 {
+			if err := orch.processWorkflow(context.Background(), wf); err != nil {
+				t.Fatalf("processWorkflow() error = %v", err)
+			}
+			orch.wg.Wait()
+			if wf.Steps["report"].Status == types.StepStatusDone {
+				return
+			}
+		}
+		t.Fatalf("report status = %s, want done", wf.Steps["report"].Status)
+	}
+
+	runShellSteps(newOrch())
+	if wf.Steps["build"].InputChecksums["src.txt"] == "" {
+		t.Fatalf("build input checksums = %v, want src.txt recorded", wf.Steps["build"].InputChecksums)
+	}
+
+	// Unchanged inputs: the completed steps are kept
+	if err := newOrch().Recover(context.Background()); err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	for _, id := range []string{"build", "report"} {
+		if status := wf.Steps[id].Status; status != types.StepStatusDone {
+			t.Errorf("%s status with unchanged inputs = %s, want done", id, status)
+		}
+	}
+
+	// Edited input: build and its dependent run again
+	if err := os.WriteFile(src, []byte("v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	orch := newOrch()
+	if err := orch.Recover(context.Background()); err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	for _, id := range []string{"build", "report"} {
+		if status := wf.Steps[id].Status; status != types.StepStatusPending {
+			t.Errorf("%s status with changed inputs = %s, want pending", id, status)
+		}
+	}
+	if status := wf.Steps["review"].Status; status != types.StepStatusRunning {
+		t.Errorf("review status = %s, want running", status)
+	}
+	runShellSteps(orch)
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "build.log")); string(data) != "v1\nv2\n" {
+		t.Errorf("build.log = %q, want both input versions", data)
+	}
+	if got := countLines(t, filepath.Join(dir, "report.log")); got != 2 {
+		t.Errorf("report ran %d times, want 2", got)
+	}
+}
@@ -58,6 +58,7 @@ func (h *IPCHandler) HandleStepDone(ctx context.Context, msg *ipc.StepDoneMessag
 
 	// Delegate to orchestrator (which has the mutex)
 	err := h.orch.HandleStepDone(ctx, msg)
+	h.orch.Wake()
 	if err != nil {
 		h.logger.Error("step_done failed", "error", err)
 		return &ipc.ErrorMessage{
@@ -116,7 +117,16 @@ func (h *IPCHandler) HandleGetSessionID(ctx context.Context, msg *ipc.GetSession
 
 // HandleEvent processes an event emitted by an agent.
 func (h *IPCHandler) HandleEvent(ctx context.Context, msg *ipc.EventMessage) any {
-	h.logger.Info("handling event", "event_type", msg.EventType, "agent", msg.Agent)
+	h.logger.Info("handling event", "event_type", msg.EventType, "agent", msg.Agent, "workflow", msg.Workflow)
+
+	// Any event from an agent counts as activity for stall detection, and
+	// may change what the orchestrator can do next
+	if h.orch != nil {
+		if msg.Agent != "" {
+			h.orch.RecordAgentActivity(msg.Workflow, msg.Agent)
+		}
+		defer h.orch.Wake()
+	}
 
 	// Filter expected agent-stopped events that occur right after step completion.
 	// This prevents false nudges when the Claude Code Stop hook fires after meow done.
@@ -133,6 +143,14 @@ func (h *IPCHandler) HandleEvent(ctx context.Context, msg *ipc.EventMessage) any
 		}
 	}
 
+	// Record prompt acknowledgment on the agent's running step (starts the
+	// work budget for steps with ack_timeout)
+	if msg.EventType == "prompt-received" && msg.Agent != "" {
+		if err := h.orch.HandlePromptReceived(ctx, msg.Workflow, msg.Agent); err != nil {
+			h.logger.Warn("failed to record prompt acknowledgment", "agent", msg.Agent, "error", err)
+		}
+	}
+
 	// Add metadata
 	msg.Timestamp = time.Now().Unix()
 
@@ -227,3 +245,38 @@ func (h *IPCHandler) HandleGetStepStatus(ctx context.Context, msg *ipc.GetStepSt
 		Status: string(step.Status),
 	}
 }
+
+// HandlePause pauses a running workflow.
+// Delegates to Orchestrator.Pause for thread-safe state mutation.
+func (h *IPCHandler) HandlePause(ctx context.Context, msg *ipc.PauseMessage) any {
+	h.logger.Info("handling pause", "workflow", msg.Workflow)
+	defer h.orch.Wake()
+	return h.ack(h.orch.Pause(ctx, msg.Workflow))
+}
+
+// HandleResume resumes a paused workflow, or aborts one paused on a failure.
+// Delegates to Orchestrator.Resume and Orchestrator.Abort for thread-safe
+// state mutation.
+func (h *IPCHandler) HandleResume(ctx context.Context, msg *ipc.ResumeMessage) any {
+	h.logger.Info("handling resume", "workflow", msg.Workflow, "abort", msg.Abort)
+	defer h.orch.Wake()
+	if msg.Abort {
+		return h.ack(h.orch.Abort(ctx, msg.Workflow))
+	}
+	return h.ack(h.orch.Resume(ctx, msg.Workflow))
+}
+
+// ack returns an AckMessage, or an ErrorMessage for a non-nil err.
+func (h *IPCHandler) ack(err error) any {
+	if err != nil {
+		h.logger.Error("request failed", "error", err)
+		return &ipc.ErrorMessage{
+			Type:    ipc.MsgError,
+			Message: err.Error(),
+		}
+	}
+	return &ipc.AckMessage{
+		Type:    ipc.MsgAck,
+		Success: true,
+	}
+}
@@ -0,0 +1,71 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// The orchestrator routes an event through the event router at each major
+// transition of a run, so observers can follow it without polling the run
+// file: meow await-event step-done --filter step=build blocks until the build
+// step finishes. Every event carries the workflow ID; step events carry the
+// step ID in their data under "step". Like all events they are not queued, so
+// only waiters already registered receive them.
+const (
+	EventWorkflowStarted   = "workflow-started"
+	EventStepDispatched    = "step-dispatched"
+	EventStepDone          = "step-done"
+	EventStepFailed        = "step-failed"
+	EventWorkflowCompleted = "workflow-completed"
+	EventWorkflowFailed    = "workflow-failed"
+)
+
+// routeLifecycleEvent routes a lifecycle event for workflow wfID, if an event
+// router is set.
+func (o *Orchestrator) routeLifecycleEvent(eventType, wfID string, data map[string]any) {
+	if o.eventRouter == nil {
+		return
+	}
+	if data == nil {
+		data = map[string]any{}
+	}
+	o.eventRouter.Route(&ipc.EventMessage{
+		Type:      ipc.MsgEvent,
+		EventType: eventType,
+		Workflow:  wfID,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+}
+
+// routeStepFinished routes step-done or step-failed for a terminal step.
+func (o *Orchestrator) routeStepFinished(wfID string, step *types.Step) {
+	switch step.Status {
+	case types.StepStatusDone:
+		o.routeLifecycleEvent(EventStepDone, wfID, map[string]any{"step": step.ID})
+	case types.StepStatusFailed:
+		data := map[string]any{"step": step.ID}
+		if step.Error != nil {
+			data["error"] = step.Error.Message
+		}
+		o.routeLifecycleEvent(EventStepFailed, wfID, data)
+	}
+}
+
+// recordRunFinished ends the run's trace and routes workflow-completed or
+// workflow-failed. Stopped runs route nothing.
+func (o *Orchestrator) recordRunFinished(wf *types.Run) {
+	o.tracing.runFinished(wf)
+	switch wf.Status {
+	case types.RunStatusDone:
+		o.routeLifecycleEvent(EventWorkflowCompleted, wf.ID, nil)
+	case types.RunStatusFailed:
+		data := map[string]any{}
+		if wf.Error != "" {
+			data["error"] = wf.Error
+		}
+		o.routeLifecycleEvent(EventWorkflowFailed, wf.ID, data)
+	}
+}
@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// runWithLifecycleWaiters runs a one-step workflow whose step runs command,
+// with a waiter registered for each of the given events.
+func runWithLifecycleWaiters(t *testing.T, command string, waiters map[string]map[string]string) map[string]<-chan *ipc.EventMessage {
+	t.Helper()
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: command},
+	}
+	store.workflows[wf.ID] = wf
+
+	router := NewEventRouter(testLogger())
+	channels := make(map[string]<-chan *ipc.EventMessage, len(waiters))
+	for eventType, filter := range waiters {
+		channels[eventType] = router.RegisterWaiter(eventType, filter, 0)
+	}
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetEventRouter(router)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return channels
+}
+
+// receivedEvent returns the event delivered on ch, failing if there is none.
+func receivedEvent(t *testing.T, eventType string, ch <-chan *ipc.EventMessage) *ipc.EventMessage {
+	t.Helper()
+	select {
+	case event := <-ch:
+		if event.Workflow != "test-wf" {
+			t.Errorf("%s workflow = %q, want test-wf", eventType, event.Workflow)
+		}
+		return event
+	default:
+		t.Fatalf("%s event not routed", eventType)
+		return nil
+	}
+}
+
+func TestLifecycleEvents_Success(t *testing.T) {
+	step := map[string]string{"step": "build"}
+	channels := runWithLifecycleWaiters(t, "echo building", map[string]map[string]string{
+		EventWorkflowStarted:   {"workflow": "test-wf"},
+		EventStepDispatched:    step,
+		EventStepDone:          step,
+		EventWorkflowCompleted: {"workflow": "test-wf"},
+		EventStepFailed:        nil,
+		EventWorkflowFailed:    nil,
+	})
+
+	receivedEvent(t, EventWorkflowStarted, channels[EventWorkflowStarted])
+	for _, eventType := range []string{EventStepDispatched, EventStepDone} {
+		if event := receivedEvent(t, eventType, channels[eventType]); event.Data["step"] != "build" {
+			t.Errorf("%s step = %v, want build", eventType, event.Data["step"])
+		}
+	}
+	receivedEvent(t, EventWorkflowCompleted, channels[EventWorkflowCompleted])
+	for _, eventType := range []string{EventStepFailed, EventWorkflowFailed} {
+		select {
+		case event := <-channels[eventType]:
+			t.Errorf("unexpected %s event: %+v", eventType, event)
+		default:
+		}
+	}
+}
+
+func TestLifecycleEvents_Failure(t *testing.T) {
+	channels := runWithLifecycleWaiters(t, "exit 3", map[string]map[string]string{
+		EventStepFailed:     {"step": "build"},
+		EventWorkflowFailed: {"workflow": "test-wf"},
+		EventStepDone:       nil,
+	})
+
+	event := receivedEvent(t, EventStepFailed, channels[EventStepFailed])
+	if msg, _ := event.Data["error"].(string); msg == "" {
+		t.Errorf("step-failed data = %v, want the step error", event.Data)
+	}
+	receivedEvent(t, EventWorkflowFailed, channels[EventWorkflowFailed])
+	select {
+	case event := <-channels[EventStepDone]:
+		t.Errorf("unexpected step-done event: %+v", event)
+	default:
+	}
+}
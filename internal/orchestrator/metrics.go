@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// MetricsSink receives step lifecycle transitions for external observability.
+// Implementations must be safe for concurrent use: async handlers (branch
+// conditions, kills, meow done) report completions from their own goroutines.
+type MetricsSink interface {
+	// StepStarted is called when a dispatched step leaves pending.
+	StepStarted(workflowID string, step *types.Step)
+
+	// StepFinished is called when a step reaches done or failed.
+	// duration is the time between StartedAt and DoneAt (zero if unknown).
+	StepFinished(workflowID string, step *types.Step, duration time.Duration)
+}
+
+// Ensure implementations satisfy MetricsSink.
+var (
+	_ MetricsSink = (*NullMetricsSink)(nil)
+	_ MetricsSink = (*StatsdMetricsSink)(nil)
+)
+
+// NullMetricsSink is a metrics sink that discards all measurements.
+type NullMetricsSink struct{}
+
+func (n *NullMetricsSink) StepStarted(_ string, _ *types.Step)                   {}
+func (n *NullMetricsSink) StepFinished(_ string, _ *types.Step, _ time.Duration) {}
+
+// StatsdMetricsSink emits statsd-format lines (with DogStatsD-style tags):
+//
+//	<prefix>.step.started:1|c|#executor:shell
+//	<prefix>.step.completed:1|c|#executor:shell
+//	<prefix>.step.failed:1|c|#executor:agent
+//	<prefix>.step.duration_ms:1523|h|#executor:shell,status:done
+//
+// Write errors are ignored; metrics must never affect workflow execution.
+type StatsdMetricsSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+}
+
+// NewStatsdMetricsSink creates a sink that writes statsd lines to w.
+// An empty prefix defaults to "meow".
+func NewStatsdMetricsSink(w io.Writer, prefix string) *StatsdMetricsSink {
+	if prefix == "" {
+		prefix = "meow"
+	}
+	return &StatsdMetricsSink{w: w, prefix: prefix}
+}
+
+// DialStatsd creates a sink that sends statsd lines over UDP to addr (host:port).
+func DialStatsd(addr, prefix string) (*StatsdMetricsSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing statsd %s: %w", addr, err)
+	}
+	return NewStatsdMetricsSink(conn, prefix), nil
+}
+
+// Close closes the sink's writer if it is closable, such as the connection
+// DialStatsd opens.
+func (s *StatsdMetricsSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// StepStarted increments the started counter.
+func (s *StatsdMetricsSink) StepStarted(_ string, step *types.Step) {
+	s.emit(fmt.Sprintf("%s.step.started:1|c|#executor:%s\n", s.prefix, step.Executor))
+}
+
+// StepFinished increments the completed or failed counter and records the duration.
+func (s *StatsdMetricsSink) StepFinished(_ string, step *types.Step, duration time.Duration) {
+	counter := "completed"
+	if step.Status == types.StepStatusFailed {
+		counter = "failed"
+	}
+	s.emit(fmt.Sprintf("%s.step.%s:1|c|#executor:%s\n", s.prefix, counter, step.Executor) +
+		fmt.Sprintf("%s.step.duration_ms:%d|h|#executor:%s,status:%s\n", s.prefix, duration.Milliseconds(), step.Executor, step.Status))
+}
+
+func (s *StatsdMetricsSink) emit(lines string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(s.w, lines)
+}
+
+// recordStepStarted reports a dispatched step to the metrics sink and tracer,
+// and routes step-dispatched.
+func (o *Orchestrator) recordStepStarted(wf *types.Run, step *types.Step) {
+	o.metrics.StepStarted(wf.ID, step)
+	o.tracing.stepStarted(wf, step)
+	o.routeLifecycleEvent(EventStepDispatched, wf.ID, map[string]any{"step": step.ID})
+}
+
+// recordStepFinished reports a terminal step to the metrics sink and tracer,
+// and routes step-done or step-failed. Only done and failed steps are
+// reported; skipped steps never started.
+func (o *Orchestrator) recordStepFinished(wfID string, step *types.Step) {
+	if step.Status != types.StepStatusDone && step.Status != types.StepStatusFailed {
+		return
+	}
+	if step.Status == types.StepStatusDone {
+		o.recordInputChecksums(step)
+	}
+	var duration time.Duration
+	if step.StartedAt != nil && step.DoneAt != nil {
+		duration = step.DoneAt.Sub(*step.StartedAt)
+	}
+	o.metrics.StepFinished(wfID, step, duration)
+	o.tracing.stepFinished(wfID, step)
+	o.routeStepFinished(wfID, step)
+	// A finished step may make others ready
+	o.Wake()
+}
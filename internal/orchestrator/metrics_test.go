@@ -0,0 +1,214 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// fakeMetricsSink records every call for assertions.
+type fakeMetricsSink struct {
+	mu        sync.Mutex
+	started   []string
+	finished  map[string]types.StepStatus
+	durations map[string]time.Duration
+}
+
+func newFakeMetricsSink() *fakeMetricsSink {
+	return &fakeMetricsSink{
+		finished:  make(map[string]types.StepStatus),
+		durations: make(map[string]time.Duration),
+	}
+}
+
+func (f *fakeMetricsSink) StepStarted(_ string, step *types.Step) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = append(f.started, step.ID)
+}
+
+func (f *fakeMetricsSink) StepFinished(_ string, step *types.Step, duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finished[step.ID] = step.Status
+	f.durations[step.ID] = duration
+}
+
+func (f *fakeMetricsSink) count(status types.StepStatus) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, s := range f.finished {
+		if s == status {
+			n++
+		}
+	}
+	return n
+}
+
+func TestOrchestrator_MetricsSink_RecordsTransitions(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["spawn"] = &types.Step{
+		ID:       "spawn",
+		Executor: types.ExecutorSpawn,
+		Status:   types.StepStatusPending,
+		Spawn:    &types.SpawnConfig{Agent: "worker"},
+	}
+	wf.Steps["sleep"] = &types.Step{
+		ID:       "sleep",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"spawn"},
+		Shell:    &types.ShellConfig{Command: "sleep 0.05"},
+	}
+	wf.Steps["broken"] = &types.Step{
+		ID:       "broken",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "exit 3"},
+	}
+	store.workflows[wf.ID] = wf
+
+	sink := newFakeMetricsSink()
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetMetricsSink(sink)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	sink.mu.Lock()
+	started := len(sink.started)
+	sink.mu.Unlock()
+	if started != 3 {
+		t.Errorf("started = %d, want 3 (%v)", started, sink.started)
+	}
+	if got := sink.count(types.StepStatusDone); got != 2 {
+		t.Errorf("completed = %d, want 2", got)
+	}
+	if got := sink.count(types.StepStatusFailed); got != 1 {
+		t.Errorf("failed = %d, want 1", got)
+	}
+	if sink.finished["broken"] != types.StepStatusFailed {
+		t.Errorf("broken step status = %q, want failed", sink.finished["broken"])
+	}
+	if d := sink.durations["sleep"]; d < 50*time.Millisecond {
+		t.Errorf("sleep duration = %v, want >= 50ms", d)
+	}
+}
+
+func TestOrchestrator_MetricsSink_HandleStepDone(t *testing.T) {
+	store := newMockRunStore()
+
+	started := time.Now().Add(-2 * time.Second)
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["work"] = &types.Step{
+		ID:        "work",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &started,
+		Agent:     &types.AgentConfig{Agent: "worker", Prompt: "do it"},
+	}
+	store.workflows[wf.ID] = wf
+
+	sink := newFakeMetricsSink()
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetMetricsSink(sink)
+
+	msg := &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "worker",
+		Step:     "work",
+	}
+	if err := orch.HandleStepDone(context.Background(), msg); err != nil {
+		t.Fatalf("HandleStepDone() error = %v", err)
+	}
+
+	if sink.finished["work"] != types.StepStatusDone {
+		t.Errorf("work status = %q, want done", sink.finished["work"])
+	}
+	if d := sink.durations["work"]; d < 2*time.Second {
+		t.Errorf("work duration = %v, want >= 2s", d)
+	}
+}
+
+func TestOrchestrator_SetMetricsSink_NilRestoresNull(t *testing.T) {
+	orch := New(testConfig(), newMockRunStore(), nil, nil, nil, testLogger())
+	orch.SetMetricsSink(nil)
+	if _, ok := orch.metrics.(*NullMetricsSink); !ok {
+		t.Errorf("metrics = %T, want *NullMetricsSink", orch.metrics)
+	}
+}
+
+func TestStatsdMetricsSink_Format(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewStatsdMetricsSink(&buf, "")
+
+	start := time.Now()
+	done := start.Add(1500 * time.Millisecond)
+	step := &types.Step{ID: "s", Executor: types.ExecutorShell, Status: types.StepStatusRunning, StartedAt: &start}
+	sink.StepStarted("wf", step)
+
+	step.Status = types.StepStatusFailed
+	step.DoneAt = &done
+	sink.StepFinished("wf", step, done.Sub(start))
+
+	want := []string{
+		"meow.step.started:1|c|#executor:shell",
+		"meow.step.failed:1|c|#executor:shell",
+		"meow.step.duration_ms:1500|h|#executor:shell,status:failed",
+	}
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(got), len(want), buf.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDialStatsd_SendsAndCloses(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := DialStatsd(conn.LocalAddr().String(), "ci")
+	if err != nil {
+		t.Fatalf("DialStatsd() error = %v", err)
+	}
+	sink.StepStarted("wf", &types.Step{ID: "s", Executor: types.ExecutorShell})
+
+	buf := make([]byte, 512)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if got, want := strings.TrimSpace(string(buf[:n])), "ci.step.started:1|c|#executor:shell"; got != want {
+		t.Errorf("packet = %q, want %q", got, want)
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// checkAgentNudges enforces nudge policies on running agent steps. An agent
+// silent for the policy's after duration (measured from its last event, the
+// step start, or the previous nudge, whichever is latest) gets the nudge
+// prompt re-injected without stabilization, as in fire_forget mode. Once
+// max_nudges have been sent, the next silent period interrupts the agent and
+// fails the step. Any event from the agent resets the silence clock, so an
+// agent that resumes work is left alone.
+// Returns true if any step state was modified (requires save).
+func (o *Orchestrator) checkAgentNudges(ctx context.Context, wf *types.Run) bool {
+	modified := false
+	now := o.now()
+	for _, step := range wf.Steps {
+		if step.Status != types.StepStatusRunning || step.Executor != types.ExecutorAgent {
+			continue
+		}
+		if step.Agent == nil || step.Agent.Nudge == nil || step.StartedAt == nil || step.InterruptedAt != nil || step.CancelPromptedAt != nil {
+			continue
+		}
+		policy := step.Agent.Nudge
+
+		after, err := types.ParseNudgeAfter(policy.After)
+		if err != nil {
+			o.logger.Warn("invalid nudge policy", "step", step.ID, "error", err)
+			continue
+		}
+
+		agentID := step.Agent.Agent
+		lastActivity := *step.StartedAt
+		if v, ok := o.agentActivity.Load(wf.ID + ":" + agentID); ok && v.(time.Time).After(lastActivity) {
+			lastActivity = v.(time.Time)
+		}
+		if step.NudgedAt != nil && step.NudgedAt.After(lastActivity) {
+			lastActivity = *step.NudgedAt
+		}
+		silentFor := now.Sub(lastActivity)
+		if silentFor < after {
+			continue
+		}
+		modified = true
+
+		if policy.MaxNudges > 0 && step.Nudges >= policy.MaxNudges {
+			o.logger.Warn("agent still silent after nudges, failing step",
+				"step", step.ID,
+				"agent", agentID,
+				"nudges", step.Nudges,
+				"silentFor", silentFor.Round(time.Second))
+			if o.agents != nil {
+				if err := o.agents.Interrupt(ctx, agentID); err != nil {
+					o.logger.Error("failed to send interrupt to agent", "step", step.ID, "agent", agentID, "error", err)
+				}
+			}
+			if err := step.Fail(&types.StepError{
+				Message: fmt.Sprintf("Agent silent for %s after %d nudges", silentFor.Round(time.Second), step.Nudges),
+				Type:    types.StepErrorTimeout,
+			}); err != nil {
+				o.logger.Error("failed to mark silent step as failed", "step", step.ID, "error", err)
+			} else {
+				o.recordStepFinished(wf.ID, step)
+			}
+			continue
+		}
+
+		prompt := policy.Prompt
+		if prompt == "" {
+			result, stepErr := StartAgentStep(step)
+			if stepErr != nil {
+				o.logger.Warn("cannot nudge silent agent", "step", step.ID, "error", stepErr.Message)
+				continue
+			}
+			prompt = wrapAgentPrompt(result.Prompt, wf, step.Agent)
+		}
+
+		nudgedAt := now
+		step.NudgedAt = &nudgedAt
+		step.Nudges++
+		o.logger.Info("nudging silent agent",
+			"step", step.ID,
+			"agent", agentID,
+			"nudge", step.Nudges,
+			"maxNudges", policy.MaxNudges,
+			"silentFor", silentFor.Round(time.Second))
+		o.tracing.stepEvent(wf.ID, step.ID, "agent.nudged",
+			attribute.String("meow.agent.id", agentID),
+			attribute.Int("meow.agent.nudge", step.Nudges))
+
+		if o.eventRouter != nil {
+			o.eventRouter.Route(&ipc.EventMessage{
+				EventType: "agent-nudged",
+				Agent:     agentID,
+				Workflow:  wf.ID,
+				Timestamp: now.Unix(),
+				Data: map[string]any{
+					"step":       step.ID,
+					"nudge":      step.Nudges,
+					"silent_for": silentFor.Round(time.Second).String(),
+				},
+			})
+		}
+
+		if o.agents != nil {
+			injectOpts, err := agentInjectOpts(step.Agent)
+			if err != nil {
+				o.logger.Warn("cannot nudge silent agent", "step", step.ID, "error", err)
+				continue
+			}
+			o.wg.Add(1)
+			go func() {
+				defer o.wg.Done()
+				if err := o.agents.InjectPrompt(ctx, agentID, prompt, injectOpts); err != nil {
+					o.logger.Warn("failed to nudge silent agent", "agent", agentID, "error", err)
+				}
+			}()
+		}
+	}
+	return modified
+}
@@ -0,0 +1,140 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+	"github.com/akatz-ai/meow/internal/workflow"
+)
+
+// A step with on_error = ".workflow" recovers from its failure instead of
+// blocking its dependents. Once it has no retries left, the template is
+// expanded with the failure in _failed_step (id, error, error_type); the step
+// stays failed while the recovery steps run, and its dependents wait. If the
+// recovery steps all succeed the step is marked done and the workflow carries
+// on; otherwise its dependents are skipped as for any failure.
+
+// recoverFailedSteps expands the on_error template of failed steps that have
+// no retries left. Returns true if any step was modified.
+func (o *Orchestrator) recoverFailedSteps(ctx context.Context, wf *types.Run) bool {
+	modified := false
+	for _, id := range sortedKeys(wf.Steps) {
+		step := wf.Steps[id]
+		if step.Status != types.StepStatusFailed || step.OnErrorTemplate == "" || len(step.RecoveryInto) > 0 {
+			continue
+		}
+		if step.Attempts < step.Retries {
+			continue // retryFailedSteps re-runs it first
+		}
+
+		failed := map[string]string{"id": step.ID}
+		if step.Error != nil {
+			failed["error"] = step.Error.Message
+			failed["error_type"] = string(step.Error.Type)
+		}
+		// Reuse the expander through a temporary expand step, as branch targets do
+		expandStep := &types.Step{
+			ID:            step.ID,
+			Executor:      types.ExecutorExpand,
+			SourceModule:  step.SourceModule,
+			CollectionDir: step.CollectionDir,
+			Expand: &types.ExpandConfig{
+				Template:  step.OnErrorTemplate,
+				Variables: map[string]any{workflow.FailedStepVariable: failed},
+			},
+		}
+
+		var err error
+		if o.expander == nil {
+			err = fmt.Errorf("expander not configured")
+		} else {
+			err = o.expander.Expand(ctx, wf, expandStep)
+		}
+		modified = true
+		if err != nil {
+			// Give up on recovery so the failure blocks dependents as usual
+			o.logger.Error("failed to expand recovery template",
+				"step", step.ID,
+				"template", step.OnErrorTemplate,
+				"error", err)
+			if step.Error == nil {
+				step.Error = &types.StepError{}
+			}
+			step.Error.Message = fmt.Sprintf("%s (recovery template %s failed: %v)", step.Error.Message, step.OnErrorTemplate, err)
+			step.OnErrorTemplate = ""
+			continue
+		}
+
+		if len(expandStep.ExpandedInto) == 0 {
+			o.logger.Info("step recovered (recovery template has no steps)",
+				"step", step.ID,
+				"template", step.OnErrorTemplate)
+			markRecovered(step)
+			continue
+		}
+		step.RecoveryInto = expandStep.ExpandedInto
+		o.logger.Info("expanded recovery template for failed step",
+			"step", step.ID,
+			"template", step.OnErrorTemplate,
+			"error", failed["error"],
+			"childCount", len(step.RecoveryInto))
+	}
+	return modified
+}
+
+// checkRecoveryCompletion marks failed steps done once all their recovery
+// steps have succeeded. A failed recovery leaves the step failed. Returns
+// true if any step was modified.
+func (o *Orchestrator) checkRecoveryCompletion(wf *types.Run) bool {
+	modified := false
+	for _, step := range wf.Steps {
+		if step.Status != types.StepStatusFailed || len(step.RecoveryInto) == 0 || isRecovering(step, wf.Steps) {
+			continue
+		}
+		recovered := true
+		for _, childID := range step.RecoveryInto {
+			if child, ok := wf.Steps[childID]; ok && !child.Satisfied() {
+				recovered = false
+				break
+			}
+		}
+		if !recovered {
+			continue // Stays failed; checkBlockedSteps skips its dependents
+		}
+		o.logger.Info("step recovered (recovery steps done)",
+			"step", step.ID,
+			"template", step.OnErrorTemplate,
+			"childCount", len(step.RecoveryInto))
+		markRecovered(step)
+		modified = true
+	}
+	return modified
+}
+
+// isRecovering reports whether a failed step's recovery steps are still
+// running, so its dependents must wait rather than be skipped.
+func isRecovering(step *types.Step, allSteps map[string]*types.Step) bool {
+	if step.Status != types.StepStatusFailed {
+		return false
+	}
+	if step.OnErrorTemplate != "" && len(step.RecoveryInto) == 0 {
+		return step.Attempts >= step.Retries // Recovery not expanded yet
+	}
+	for _, childID := range step.RecoveryInto {
+		if child, ok := allSteps[childID]; ok && !child.Status.IsTerminal() {
+			return true
+		}
+	}
+	return false
+}
+
+// markRecovered moves a failed step whose recovery succeeded to done. Failed
+// is terminal for the normal lifecycle, so the status is set directly.
+func markRecovered(step *types.Step) {
+	now := time.Now()
+	step.Status = types.StepStatusDone
+	step.DoneAt = &now
+	step.Error = nil
+}
@@ -0,0 +1,126 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestOrchestrator_OnErrorRecoveryTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		recover     string
+		wantBuild   types.StepStatus
+		wantDeploy  types.StepStatus
+		wantRunDone bool
+	}{
+		{"recovery succeeds", "true", types.StepStatusDone, types.StepStatusDone, true},
+		{"recovery fails", "exit 1", types.StepStatusFailed, types.StepStatusSkipped, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			log := filepath.Join(dir, "recovery.log")
+			templatePath := filepath.Join(dir, "build.meow.toml")
+			template := `
+[main]
+name = "main"
+
+[[main.steps]]
+id = "noop"
+executor = "shell"
+command = "true"
+
+[recover]
+name = "recover"
+
+[[recover.steps]]
+id = "report"
+executor = "shell"
+command = "echo '{{_failed_step.id}} {{_failed_step.error_type}}: {{_failed_step.error}}' > ` + log + ` && ` + tc.recover + `"
+`
+			if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			wf := types.NewRun("test-wf", templatePath, nil)
+			wf.Status = types.RunStatusRunning
+			wf.Steps["build"] = &types.Step{
+				ID:              "build",
+				Executor:        types.ExecutorShell,
+				Status:          types.StepStatusPending,
+				OnErrorTemplate: ".recover",
+				Shell:           &types.ShellConfig{Command: "exit 3"},
+			}
+			wf.Steps["deploy"] = &types.Step{
+				ID:       "deploy",
+				Executor: types.ExecutorShell,
+				Status:   types.StepStatusPending,
+				Needs:    []string{"build"},
+				Shell:    &types.ShellConfig{Command: "true"},
+			}
+			store := newMockRunStore()
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), NewTemplateExpanderAdapter(dir), testLogger())
+			orch.SetWorkflowID(wf.ID)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := orch.Run(ctx); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			build := wf.Steps["build"]
+			if build.Status != tc.wantBuild {
+				t.Errorf("build status = %s, want %s (error %+v)", build.Status, tc.wantBuild, build.Error)
+			}
+			if len(build.RecoveryInto) != 1 || build.RecoveryInto[0] != "build.report" {
+				t.Errorf("build recovery steps = %v, want [build.report]", build.RecoveryInto)
+			}
+			if status := wf.Steps["deploy"].Status; status != tc.wantDeploy {
+				t.Errorf("deploy status = %s, want %s", status, tc.wantDeploy)
+			}
+			if done := wf.Status == types.RunStatusDone; done != tc.wantRunDone {
+				t.Errorf("run status = %s, want done = %v", wf.Status, tc.wantRunDone)
+			}
+
+			data, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatalf("recovery step did not run: %v", err)
+			}
+			if got := string(data); got != "build command_failed: command failed\n" {
+				t.Errorf("recovery saw %q, want the failed step's id and error", got)
+			}
+		})
+	}
+}
+
+func TestIsRecovering(t *testing.T) {
+	steps := map[string]*types.Step{
+		"fix.a": {ID: "fix.a", Status: types.StepStatusDone},
+		"fix.b": {ID: "fix.b", Status: types.StepStatusRunning},
+	}
+	tests := []struct {
+		name string
+		step *types.Step
+		want bool
+	}{
+		{"no recovery", &types.Step{Status: types.StepStatusFailed}, false},
+		{"not expanded yet", &types.Step{Status: types.StepStatusFailed, OnErrorTemplate: ".fix"}, true},
+		{"retries left", &types.Step{Status: types.StepStatusFailed, OnErrorTemplate: ".fix", Retries: 1}, false},
+		{"children running", &types.Step{Status: types.StepStatusFailed, OnErrorTemplate: ".fix", RecoveryInto: []string{"fix.a", "fix.b"}}, true},
+		{"children finished", &types.Step{Status: types.StepStatusFailed, OnErrorTemplate: ".fix", RecoveryInto: []string{"fix.a"}}, false},
+		{"recovered", &types.Step{Status: types.StepStatusDone, OnErrorTemplate: ".fix", RecoveryInto: []string{"fix.a"}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRecovering(tc.step, steps); got != tc.want {
+				t.Errorf("isRecovering() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
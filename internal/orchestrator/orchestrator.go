@@ -5,12 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -18,8 +20,10 @@ import (
 
 	"github.com/akatz-ai/meow/internal/config"
 	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/logging"
 	"github.com/akatz-ai/meow/internal/types"
 	"github.com/akatz-ai/meow/internal/workflow"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -28,15 +32,73 @@ var (
 
 	// ErrNotImplemented signals that an executor is not yet implemented.
 	ErrNotImplemented = errors.New("executor not implemented")
+
+	// ErrAlreadyRunning signals that another orchestrator holds the workflow lock.
+	ErrAlreadyRunning = errors.New("workflow is already running")
+
+	// ErrTmuxRequired signals that agent steps need tmux but it is unavailable.
+	ErrTmuxRequired = errors.New("tmux required for agent steps")
 )
 
+// WorkflowLocker is implemented by stores that support exclusive per-workflow locks.
+// When the store implements it, Run and Recover refuse to operate on a workflow
+// that another orchestrator is already driving.
+type WorkflowLocker interface {
+	AcquireWorkflowLock(workflowID string) (*WorkflowLock, error)
+}
+
+// AdapterValidator is implemented by agent managers that can check adapters
+// before any step runs. Run calls it at startup so a missing agent binary fails
+// the workflow immediately instead of when the first spawn step executes.
+type AdapterValidator interface {
+	ValidateAdapters(ctx context.Context, wf *types.Run) error
+}
+
+// AgentReattacher is implemented by agent managers that can adopt agents
+// spawned by an earlier orchestrator process. Recover calls it before checking
+// liveness, so an agent whose session outlived the restart is not mistaken for
+// a dead one and respawned.
+type AgentReattacher interface {
+	Reattach(ctx context.Context, wf *types.Run, agentID string) error
+}
+
+// AgentAdopter is implemented by agent managers that can take over a live
+// agent registered by another run, for spawn steps with reuse_from. Adopt
+// reports false, with no error, when the agent's session is gone.
+type AgentAdopter interface {
+	Adopt(ctx context.Context, wf *types.Run, agentID string, prior *types.AgentInfo) (bool, error)
+}
+
+// TranscriptCapturer is implemented by agent managers that can read back an
+// agent's terminal output, for agent steps with capture_transcript.
+type TranscriptCapturer interface {
+	CaptureTranscript(ctx context.Context, agentID string) (string, error)
+}
+
+// AgentExitWatcher is implemented by agent managers that can tell when an
+// agent's process has exited, for agent steps with completion = "exit".
+// ExitStatus reports exit code -1 when the agent's session is gone entirely.
+type AgentExitWatcher interface {
+	ExitStatus(ctx context.Context, agentID string) (exited bool, exitCode int, err error)
+}
+
+// AgentLogReader is implemented by agent managers that keep a log of each
+// agent's output, for agent outputs declared with from_log. AgentLogSize
+// marks where a step's part of the log begins; ReadAgentLog returns the log
+// from that offset on.
+type AgentLogReader interface {
+	AgentLogSize(agentID string) (int64, error)
+	ReadAgentLog(agentID string, offset int64) ([]byte, error)
+}
+
 // AgentManager manages agent lifecycle (tmux sessions).
 type AgentManager interface {
 	// Start spawns an agent in a tmux session.
 	Start(ctx context.Context, wf *types.Run, step *types.Step) error
 
-	// Stop kills an agent's tmux session.
-	Stop(ctx context.Context, wf *types.Run, step *types.Step) error
+	// Stop kills an agent's tmux session, reporting whether the agent exited
+	// gracefully or had to be forced.
+	Stop(ctx context.Context, wf *types.Run, step *types.Step) (StopOutcome, error)
 
 	// IsRunning checks if an agent is currently running.
 	IsRunning(ctx context.Context, agentID string) (bool, error)
@@ -45,7 +107,8 @@ type AgentManager interface {
 	// If opts.Stabilize is true, runs the stabilization sequence before injection.
 	InjectPrompt(ctx context.Context, agentID string, prompt string, opts InjectPromptOpts) error
 
-	// Interrupt sends C-c to an agent's tmux session for graceful cancellation.
+	// Interrupt sends the adapter's interrupt keys (C-c by default) to an
+	// agent's tmux session for graceful cancellation.
 	Interrupt(ctx context.Context, agentID string) error
 
 	// KillAll kills all agent sessions for a workflow.
@@ -89,24 +152,120 @@ type Orchestrator struct {
 	// Value: context.CancelFunc
 	pendingCommands sync.Map
 
+	// Track steps whose requires checks run asynchronously
+	// Key: "workflowID:stepID" (string)
+	// Value: bool, true once the checks passed
+	stepChecks sync.Map
+
 	// Shutdown coordination
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
 	// Event router for prompt acknowledgment tracking
 	eventRouter *EventRouter
+
+	// Metrics sink for step start/finish observability
+	metrics MetricsSink
+
+	// OpenTelemetry spans for runs, steps, and agent interactions
+	tracing *runTracer
+
+	// Directory for outputs marked artifact = true (empty disables persistence)
+	artifactsDir string
+
+	// Directory relative input globs are resolved against (empty: working directory)
+	inputsDir string
+
+	// Directory for lock = "name" files (empty disables step locks), and the
+	// locks held, by workflow ID then step ID
+	locksDir    string
+	stepLocksMu sync.Mutex
+	stepLocks   map[string]map[string]*os.File
+
+	// Exclusive lock on the active workflow, held from Recover/Run until Run returns
+	lock *WorkflowLock
+
+	// Set for meow cleanup, which runs a run's cleanup outside the orchestrator
+	manualCleanup *ManualCleanupOptions
+
+	// Clock for scheduling decisions (only_between windows); injectable for tests
+	now func() time.Time
+
+	// Loggers for executors with a [logging.executors] level override
+	executorLoggers map[types.ExecutorType]*slog.Logger
+
+	// Compiled [shell.error_patterns], for classifying shell failures
+	shellErrorPatterns []shellErrorPattern
+
+	// Fair scheduling across workflows: tick rotates the processing order by
+	// tickRotation and hands out agentSlots (agent steps that may still start
+	// under max_concurrent_agents; -1 when unlimited). Both are only touched
+	// by tick and processWorkflow.
+	tickRotation int
+	agentSlots   int
+
+	// Poll backoff: tickBusy records whether the current tick did anything
+	// (only touched by the run loop's tick), and wakeCh carries wake-ups
+	// that reset the backoff
+	tickBusy bool
+	wakeCh   chan struct{}
+
+	// Last event time per agent, for stall detection and nudge policies
+	// Key: "workflowID:agentID" (string)
+	// Value: time.Time
+	agentActivity sync.Map
+
+	// Last stall report per agent, re-arming stall_timeout without counting
+	// as agent activity (same key and value as agentActivity)
+	stallReported sync.Map
 }
 
+// stepLoggerKey is the context key for the executor logger dispatch selects.
+type stepLoggerKey struct{}
+
 // New creates a new Orchestrator.
 func New(cfg *config.Config, store RunStore, agents AgentManager, shell ShellRunner, expander TemplateExpander, logger *slog.Logger) *Orchestrator {
-	return &Orchestrator{
-		cfg:      cfg,
-		store:    store,
-		agents:   agents,
-		shell:    shell,
-		expander: expander,
-		logger:   logger,
+	o := &Orchestrator{
+		cfg:        cfg,
+		store:      store,
+		agents:     agents,
+		shell:      shell,
+		expander:   expander,
+		logger:     logger,
+		metrics:    &NullMetricsSink{},
+		tracing:    newRunTracer(nil),
+		now:        time.Now,
+		agentSlots: -1,
+		wakeCh:     make(chan struct{}, 1),
+	}
+	if cfg != nil && len(cfg.Logging.Executors) > 0 {
+		o.executorLoggers = make(map[types.ExecutorType]*slog.Logger, len(cfg.Logging.Executors))
+		for name, level := range cfg.Logging.Executors {
+			o.executorLoggers[types.ExecutorType(name)] = logging.WithLevel(logger, logging.ParseLevel(level))
+		}
+	}
+	if cfg != nil {
+		o.shellErrorPatterns = compileShellErrorPatterns(cfg.Shell.ErrorPatterns, logger)
+	}
+	return o
+}
+
+// executorLogger returns the logger for steps of the given executor, honoring
+// any [logging.executors] level override.
+func (o *Orchestrator) executorLogger(executor types.ExecutorType) *slog.Logger {
+	if logger, ok := o.executorLoggers[executor]; ok {
+		return logger
+	}
+	return o.logger
+}
+
+// stepLogger returns the executor logger dispatch attached to ctx, falling
+// back to the orchestrator's logger outside of step handling.
+func (o *Orchestrator) stepLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(stepLoggerKey{}).(*slog.Logger); ok {
+		return logger
 	}
+	return o.logger
 }
 
 // SetWorkflowID sets the active workflow ID for single-workflow mode.
@@ -119,10 +278,30 @@ func (o *Orchestrator) SetEventRouter(router *EventRouter) {
 	o.eventRouter = router
 }
 
+// SetMetricsSink sets the sink that receives step start/finish metrics.
+// Passing nil restores the no-op sink.
+func (o *Orchestrator) SetMetricsSink(sink MetricsSink) {
+	if sink == nil {
+		sink = &NullMetricsSink{}
+	}
+	o.metrics = sink
+}
+
+// SetClock sets the clock used for scheduling decisions such as only_between
+// windows. Passing nil restores the wall clock.
+func (o *Orchestrator) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	o.now = now
+}
+
 // waitForPromptAcknowledgment waits for a prompt-received event from the agent.
 // This is best-effort monitoring; it does not block workflow execution.
 // Logs DEBUG on success, WARN on timeout.
 func (o *Orchestrator) waitForPromptAcknowledgment(ctx context.Context, agentID, stepID string, timeout time.Duration) {
+	logger := o.stepLogger(ctx)
+
 	if o.eventRouter == nil {
 		return // No event router available
 	}
@@ -137,19 +316,19 @@ func (o *Orchestrator) waitForPromptAcknowledgment(ctx context.Context, agentID,
 	select {
 	case event, ok := <-ch:
 		if ok && event != nil {
-			o.logger.Debug("prompt acknowledged by agent",
+			logger.Debug("prompt acknowledged by agent",
 				"agent", agentID,
 				"step", stepID,
 			)
 		} else {
-			o.logger.Warn("prompt-received event not received (timeout)",
+			logger.Warn("prompt-received event not received (timeout)",
 				"agent", agentID,
 				"step", stepID,
 				"timeout", timeout,
 			)
 		}
 	case <-timer.C:
-		o.logger.Warn("prompt-received event not received (timeout)",
+		logger.Warn("prompt-received event not received (timeout)",
 			"agent", agentID,
 			"step", stepID,
 			"timeout", timeout,
@@ -161,32 +340,38 @@ func (o *Orchestrator) waitForPromptAcknowledgment(ctx context.Context, agentID,
 
 // waitForPromptAcknowledgmentWithRecovery waits for a prompt-received event and
 // attempts recovery if the prompt is not acknowledged within the timeout.
-// Recovery involves re-injecting the prompt with stabilization (Escape keys).
-// If recovery fails after 1 retry, emits a prompt-swallowed event.
+// Recovery involves re-injecting the prompt with opts plus stabilization
+// (Escape keys), backing off exponentially between attempts (see promptRecoveryWait).
+// If recovery fails after prompt_recovery_retries attempts, emits a
+// prompt-swallowed event.
 // This is best-effort monitoring; it does not block workflow execution.
-func (o *Orchestrator) waitForPromptAcknowledgmentWithRecovery(ctx context.Context, agentID, stepID, prompt string, timeout time.Duration) {
+func (o *Orchestrator) waitForPromptAcknowledgmentWithRecovery(ctx context.Context, agentID, stepID, prompt string, opts InjectPromptOpts, timeout time.Duration) {
+	logger := o.stepLogger(ctx)
+
 	if o.eventRouter == nil {
 		return // No event router available
 	}
 
-	const maxRetries = 1
-	retryTimeout := timeout / 2 // Shorter timeout for retries
-	if retryTimeout < 50*time.Millisecond {
-		retryTimeout = 50 * time.Millisecond
+	maxRetries := config.DefaultPromptRecoveryRetries
+	if o.cfg != nil {
+		maxRetries = o.cfg.Agent.PromptRecoveryRetryLimit()
 	}
 
-	// Helper to wait for acknowledgment
-	waitForAck := func(t time.Duration) bool {
+	// Helpers to wait for acknowledgment. Events are not queued, so the
+	// waiter must be registered before the prompt is re-injected: the agent
+	// may acknowledge it before InjectPrompt returns
+	registerAck := func(t time.Duration) <-chan *ipc.EventMessage {
 		filter := map[string]string{"agent": agentID}
-		ch := o.eventRouter.RegisterWaiter("prompt-received", filter, t)
-
+		return o.eventRouter.RegisterWaiter("prompt-received", filter, t)
+	}
+	waitForAck := func(ch <-chan *ipc.EventMessage, t time.Duration) bool {
 		timer := time.NewTimer(t)
 		defer timer.Stop()
 
 		select {
 		case event, ok := <-ch:
 			if ok && event != nil {
-				o.logger.Debug("prompt acknowledged by agent",
+				logger.Debug("prompt acknowledged by agent",
 					"agent", agentID,
 					"step", stepID,
 				)
@@ -201,40 +386,53 @@ func (o *Orchestrator) waitForPromptAcknowledgmentWithRecovery(ctx context.Conte
 	}
 
 	// Initial wait
-	if waitForAck(timeout) {
+	if waitForAck(registerAck(timeout), timeout) {
 		return
 	}
 
-	o.logger.Warn("prompt-received event not received, attempting recovery",
+	logger.Warn("prompt-received event not received, attempting recovery",
 		"agent", agentID,
 		"step", stepID,
 		"timeout", timeout,
 	)
 
-	// Attempt recovery: re-inject with stabilization
+	// Attempt recovery: re-inject with stabilization. Each attempt's wait is
+	// recorded for the prompt-swallowed event.
+	waits := make([]string, 0, maxRetries)
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		o.logger.Info("recovery attempt: re-injecting prompt with stabilization",
+		wait := o.promptRecoveryWait(attempt+1, timeout)
+		waits = append(waits, wait.String())
+		logger.Info("recovery attempt: re-injecting prompt with stabilization",
 			"agent", agentID,
 			"step", stepID,
 			"attempt", attempt+1,
 			"maxRetries", maxRetries,
+			"wait", wait,
 		)
 
-		// Re-inject with stabilization (this sends Escape keys first)
-		if err := o.agents.InjectPrompt(ctx, agentID, prompt, InjectPromptOpts{
-			Stabilize: true,
-		}); err != nil {
-			o.logger.Warn("recovery injection failed",
+		// Re-inject with stabilization (this sends Escape keys first),
+		// keeping the step's prompt delays
+		ch := registerAck(wait)
+		opts.Stabilize = true
+		if err := o.agents.InjectPrompt(ctx, agentID, prompt, opts); err != nil {
+			o.eventRouter.RemoveWaiter(ch)
+			logger.Warn("recovery injection failed",
 				"agent", agentID,
 				"step", stepID,
 				"error", err,
 			)
+			// Still back off before the next attempt
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
 			continue
 		}
 
-		// Wait for acknowledgment with shorter timeout
-		if waitForAck(retryTimeout) {
-			o.logger.Info("recovery successful: prompt acknowledged after re-injection",
+		// Wait for acknowledgment, backing off further on each attempt
+		if waitForAck(ch, wait) {
+			logger.Info("recovery successful: prompt acknowledged after re-injection",
 				"agent", agentID,
 				"step", stepID,
 				"attempt", attempt+1,
@@ -244,10 +442,13 @@ func (o *Orchestrator) waitForPromptAcknowledgmentWithRecovery(ctx context.Conte
 	}
 
 	// Recovery exhausted - emit prompt-swallowed event for RW monitor to handle
-	o.logger.Warn("recovery exhausted: emitting prompt-swallowed event",
+	logger.Warn("recovery exhausted: emitting prompt-swallowed event",
+		"event_type", "prompt-swallowed",
 		"agent", agentID,
+		"workflow", o.workflowID,
 		"step", stepID,
 		"retries", maxRetries,
+		"waits", waits,
 	)
 
 	// Emit prompt-swallowed event
@@ -257,19 +458,65 @@ func (o *Orchestrator) waitForPromptAcknowledgmentWithRecovery(ctx context.Conte
 		Data: map[string]any{
 			"step":    stepID,
 			"retries": maxRetries,
+			"waits":   waits,
 		},
 	}
 	o.eventRouter.Route(swallowedEvent)
 }
 
+// promptRecoveryWait returns how long recovery attempt (1 for the first)
+// waits for acknowledgment after re-injecting the prompt:
+// prompt_recovery_backoff (default half the acknowledgment timeout) doubled
+// for each earlier attempt, capped at prompt_recovery_max_backoff.
+func (o *Orchestrator) promptRecoveryWait(attempt int, ackTimeout time.Duration) time.Duration {
+	base := max(ackTimeout/2, 50*time.Millisecond)
+	limit := config.DefaultPromptRecoveryMaxBackoff
+	if o.cfg != nil {
+		if o.cfg.Agent.PromptRecoveryBackoff > 0 {
+			base = o.cfg.Agent.PromptRecoveryBackoff
+		}
+		if o.cfg.Agent.PromptRecoveryMaxBackoff > 0 {
+			limit = o.cfg.Agent.PromptRecoveryMaxBackoff
+		}
+	}
+	wait := base
+	for i := 1; i < attempt && wait < limit; i++ {
+		wait *= 2
+	}
+	return min(wait, limit)
+}
+
 // Run starts the orchestrator main loop.
 // It blocks until the context is cancelled or all work is done.
 // IPC messages are handled by IPCHandler which delegates to Orchestrator methods
 // (HandleStepDone) for thread-safe state mutations.
 // Handles SIGINT/SIGTERM for graceful shutdown with cleanup.
 func (o *Orchestrator) Run(ctx context.Context) error {
+	if err := o.acquireLock(); err != nil {
+		return err
+	}
+	defer o.releaseLock()
+
+	if err := o.validateAdapters(ctx); err != nil {
+		return err
+	}
+	if err := o.checkRequirements(ctx); err != nil {
+		return err
+	}
+	if o.workflowID != "" {
+		o.routeLifecycleEvent(EventWorkflowStarted, o.workflowID, nil)
+	}
+
+	return o.runLoop(ctx)
+}
+
+// runLoop drives the workflow until all work is done, ctx is cancelled, or a
+// signal arrives. The caller is responsible for holding the workflow lock.
+func (o *Orchestrator) runLoop(ctx context.Context) error {
 	ctx, o.cancel = context.WithCancel(ctx)
 	defer o.cancel()
+	defer o.tracing.close()
+	defer o.releaseAllStepLocks()
 
 	o.logger.Info("orchestrator starting")
 
@@ -277,8 +524,9 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 	sigChan := o.setupSignalHandler()
 	defer signal.Stop(sigChan)
 
-	ticker := time.NewTicker(o.cfg.Orchestrator.PollInterval)
-	defer ticker.Stop()
+	interval := o.cfg.Orchestrator.PollInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
 	for {
 		select {
@@ -304,7 +552,14 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 			o.wg.Wait()
 			return ctx.Err()
 
-		case <-ticker.C:
+		case <-o.wakeCh:
+			if base := o.cfg.Orchestrator.PollInterval; interval > base {
+				interval = base
+				timer.Reset(interval)
+			}
+
+		case <-timer.C:
+			o.tickBusy = false
 			if err := o.tick(ctx); err != nil {
 				if errors.Is(err, ErrAllDone) {
 					o.logger.Info("all work complete")
@@ -315,8 +570,38 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 				o.logger.Error("tick error", "error", err)
 				// Continue running on non-fatal errors
 			}
+			interval = o.nextPollInterval(interval, o.tickBusy)
+			timer.Reset(interval)
+		}
+	}
+}
+
+// validateAdapters runs pre-flight adapter checks if the agent manager supports them.
+// On failure the workflow is marked failed so it is not left pending.
+func (o *Orchestrator) validateAdapters(ctx context.Context) error {
+	validator, ok := o.agents.(AdapterValidator)
+	if !ok || o.workflowID == "" {
+		return nil
+	}
+
+	wf, err := o.store.Get(ctx, o.workflowID)
+	if err != nil {
+		return fmt.Errorf("getting workflow: %w", err)
+	}
+	if wf == nil || wf.Status.IsTerminal() {
+		return nil
+	}
+
+	if err := validator.ValidateAdapters(ctx, wf); err != nil {
+		o.logger.Error("adapter validation failed", "workflow", wf.ID, "error", err)
+		wf.Fail()
+		if saveErr := o.store.Save(ctx, wf); saveErr != nil {
+			o.logger.Error("failed to save workflow", "error", saveErr)
 		}
+		o.recordRunFinished(wf)
+		return fmt.Errorf("adapter validation failed: %w", err)
 	}
+	return nil
 }
 
 // cleanupOnSignal handles SIGINT/SIGTERM by optionally running cleanup_on_stop.
@@ -357,11 +642,16 @@ func (o *Orchestrator) Shutdown() {
 
 // tick performs one iteration of the main loop.
 func (o *Orchestrator) tick(ctx context.Context) error {
-	// Get running workflows
+	// Get running workflows, and paused ones (their running steps are still tracked)
 	workflows, err := o.store.List(ctx, RunFilter{Status: types.RunStatusRunning})
 	if err != nil {
 		return fmt.Errorf("listing workflows: %w", err)
 	}
+	paused, err := o.store.List(ctx, RunFilter{Status: types.RunStatusPaused})
+	if err != nil {
+		return fmt.Errorf("listing paused workflows: %w", err)
+	}
+	workflows = append(workflows, paused...)
 
 	// If single-workflow mode, filter to just that workflow
 	if o.workflowID != "" {
@@ -389,16 +679,21 @@ func (o *Orchestrator) tick(ctx context.Context) error {
 		}
 	}
 
+	o.releaseOtherStepLocks(workflows)
+
 	if len(workflows) == 0 {
 		return ErrAllDone
 	}
 
+	workflows = o.rotateWorkflows(workflows)
+	o.agentSlots = o.freeAgentSlots(workflows)
+
 	allComplete := true
 	for _, wf := range workflows {
 		if err := o.processWorkflow(ctx, wf); err != nil {
 			return err
 		}
-		if wf.Status == types.RunStatusRunning {
+		if wf.Status == types.RunStatusRunning || wf.Status == types.RunStatusPaused {
 			allComplete = false
 		}
 	}
@@ -409,6 +704,38 @@ func (o *Orchestrator) tick(ctx context.Context) error {
 	return nil
 }
 
+// rotateWorkflows orders workflows by ID, then rotates the start by one
+// position per tick so that no workflow always gets first pick of the agent
+// slots under max_concurrent_agents.
+func (o *Orchestrator) rotateWorkflows(workflows []*types.Run) []*types.Run {
+	if len(workflows) < 2 {
+		return workflows
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].ID < workflows[j].ID })
+	start := o.tickRotation % len(workflows)
+	o.tickRotation++
+	rotated := make([]*types.Run, 0, len(workflows))
+	rotated = append(rotated, workflows[start:]...)
+	return append(rotated, workflows[:start]...)
+}
+
+// freeAgentSlots returns how many more agent steps may start this tick under
+// max_concurrent_agents, or -1 when there is no cap.
+func (o *Orchestrator) freeAgentSlots(workflows []*types.Run) int {
+	if o.cfg == nil || o.cfg.Orchestrator.MaxConcurrentAgents <= 0 {
+		return -1
+	}
+	running := 0
+	for _, wf := range workflows {
+		for _, step := range wf.Steps {
+			if step.Executor == types.ExecutorAgent && step.Status == types.StepStatusRunning {
+				running++
+			}
+		}
+	}
+	return max(o.cfg.Orchestrator.MaxConcurrentAgents-running, 0)
+}
+
 // processWorkflow processes a single workflow, dispatching all ready steps.
 func (o *Orchestrator) processWorkflow(ctx context.Context, wf *types.Run) error {
 	// Lock to coordinate with async handlers (handleStepDone, handleKill goroutines)
@@ -422,18 +749,76 @@ func (o *Orchestrator) processWorkflow(ctx context.Context, wf *types.Run) error
 	}
 	wf = freshWf
 
+	// Fail the whole run once it outlives its global timeout
+	if o.runTimedOut(wf) {
+		return o.failTimedOutRun(ctx, wf)
+	}
+
 	// Check timeouts for running agent steps
 	timeoutModified := o.checkStepTimeouts(ctx, wf)
 
+	// Fail running agent steps whose agent has crashed
+	livenessModified := o.checkAgentLiveness(ctx, wf)
+
+	// Warn about (and optionally nudge) agents that have gone silent
+	o.checkAgentStalls(ctx, wf)
+
+	// Nudge silent agents with a nudge policy, failing those that stay silent
+	nudgeModified := o.checkAgentNudges(ctx, wf)
+
+	// Complete or fail agent steps whose agent finishes by exiting
+	exitModified := o.checkAgentExits(ctx, wf)
+
+	// Re-run failed steps that still have retries (before their dependents are skipped)
+	retryModified := o.retryFailedSteps(wf)
+
+	// Expand the on_error templates of failed steps, then finish steps whose
+	// recovery steps are done (also before their dependents are skipped)
+	recoveryModified := o.recoverFailedSteps(ctx, wf)
+	recoveredModified := o.checkRecoveryCompletion(wf)
+
+	// With pause_on_failure, a step that failed for good pauses the run, and
+	// its failure is held there (dependents are not skipped) until the
+	// operator resumes or aborts it
+	pausedModified := o.pauseOnFailures(wf)
+	if len(wf.PausedOnFailure) > 0 {
+		o.releaseStepLocks(wf)
+		if timeoutModified || livenessModified || nudgeModified || exitModified ||
+			retryModified || recoveryModified || recoveredModified || pausedModified {
+			return o.saveProgress(ctx, wf)
+		}
+		return nil
+	}
+
 	// Check for pending steps that are blocked by failed dependencies
 	blockedModified := o.checkBlockedSteps(wf)
 
-	// Check for foreach steps with implicit join that are ready to complete
+	// Expand further iterations of windowed foreach steps, then check for
+	// foreach steps with implicit join that are ready to complete
+	foreachWindowModified := o.advanceForeachWindows(ctx, wf)
 	foreachModified := o.checkForeachCompletion(wf)
 
 	// Check for branch steps waiting for their expanded children to complete
 	branchModified := o.checkBranchCompletion(wf)
 
+	// Free the locks of steps that have stopped running
+	o.releaseStepLocks(wf)
+
+	// Any change made by the checks above must be saved, even when no step is
+	// dispatched (e.g., a timed-out step's InterruptedAt, which starts its grace
+	// period)
+	checksModified := timeoutModified || livenessModified || nudgeModified || exitModified ||
+		retryModified || recoveryModified || recoveredModified || blockedModified || foreachWindowModified || foreachModified || branchModified
+
+	// A paused workflow only tracks the steps already running: nothing is
+	// dispatched, and it does not finish, until it resumes
+	if wf.Status == types.RunStatusPaused {
+		if checksModified {
+			return o.saveProgress(ctx, wf)
+		}
+		return nil
+	}
+
 	readySteps := wf.GetReadySteps()
 	if len(readySteps) == 0 {
 		if wf.AllDone() {
@@ -443,10 +828,21 @@ func (o *Orchestrator) processWorkflow(ctx context.Context, wf *types.Run) error
 				finalStatus = types.RunStatusFailed
 			}
 
+			// A run whose steps all succeeded still fails if it did not
+			// produce its declared outputs
+			if finalStatus == types.RunStatusDone {
+				if err := resolveWorkflowOutputs(wf); err != nil {
+					o.logger.Error("workflow failed", "id", wf.ID, "error", err)
+					wf.Error = err.Error()
+					finalStatus = types.RunStatusFailed
+				}
+			}
+
 			// Check if cleanup is defined for this trigger (opt-in cleanup)
 			if wf.HasCleanup(finalStatus) {
 				o.logger.Info("workflow complete, running cleanup", "id", wf.ID, "reason", finalStatus)
 				// Unlock before RunCleanup since it may do I/O
+				o.tickBusy = true
 				o.wfMu.Unlock()
 				err := o.RunCleanup(ctx, wf, finalStatus)
 				o.wfMu.Lock()
@@ -465,11 +861,12 @@ func (o *Orchestrator) processWorkflow(ctx context.Context, wf *types.Run) error
 				wf.Complete()
 				o.logger.Info("workflow completed (no cleanup defined)", "id", wf.ID)
 			}
-			return o.store.Save(ctx, wf)
+			o.recordRunFinished(wf)
+			return o.saveProgress(ctx, wf)
 		}
 		// Save if timeout handling or blocked step detection modified state
-		if timeoutModified || blockedModified || foreachModified || branchModified {
-			return o.store.Save(ctx, wf)
+		if checksModified {
+			return o.saveProgress(ctx, wf)
 		}
 		return nil // Waiting for external completion
 	}
@@ -484,20 +881,45 @@ func (o *Orchestrator) processWorkflow(ctx context.Context, wf *types.Run) error
 
 	// Track which steps we dispatched for merging later
 	dispatchedSteps := make(map[string]*types.Step)
+	windowModified := false
+	lockModified := false
 
 	// Process ALL ready steps (enables parallel agent execution)
 	for _, step := range readySteps {
+		// Hold retries until their backoff has elapsed
+		if step.RetryAt != nil && o.now().Before(*step.RetryAt) {
+			continue
+		}
+
+		// Hold (or skip) steps outside their only_between window
+		if step.OnlyBetween != "" {
+			dispatchable, modified := o.checkStepWindow(step)
+			windowModified = windowModified || modified
+			if !dispatchable {
+				continue
+			}
+		}
+
 		// For agent steps, check idleness unless it's fire_forget mode
 		if step.Executor == types.ExecutorAgent {
 			if step.Agent == nil {
 				o.logger.Error("agent step missing config", "step", step.ID)
 				continue
 			}
+			// requires_capability: pick an idle agent advertising the capability
+			if step.Agent.RequiresCapability != "" && !o.assignCapableAgent(ctx, wf, step) {
+				continue
+			}
 			// fire_forget mode injects prompts without waiting for agent to be idle
 			// This is used for nudge prompts in the Ralph Wiggum persistence pattern
 			if step.Agent.Mode != "fire_forget" && !wf.AgentIsIdle(step.Agent.Agent) {
 				continue
 			}
+			// Global cap on running agent steps (max_concurrent_agents)
+			if o.agentSlots == 0 {
+				o.logger.Debug("agent step waiting for a free slot", "step", step.ID)
+				continue
+			}
 		}
 
 		// Check if step is throttled by parent foreach's max_concurrent
@@ -505,6 +927,15 @@ func (o *Orchestrator) processWorkflow(ctx context.Context, wf *types.Run) error
 			continue
 		}
 
+		// Serialize steps sharing a named lock, across workflows
+		if step.Lock != "" {
+			acquired, modified := o.acquireStepLock(wf, step)
+			lockModified = lockModified || modified
+			if !acquired {
+				continue
+			}
+		}
+
 		if err := o.dispatch(ctx, wf, step); err != nil {
 			o.logger.Error("dispatch error", "step", step.ID, "error", err)
 			// Fail any step left in running state after a dispatch error (defense-in-depth).
@@ -513,52 +944,126 @@ func (o *Orchestrator) processWorkflow(ctx context.Context, wf *types.Run) error
 				step.Fail(&types.StepError{Message: err.Error()})
 			}
 		}
+		if step.Executor == types.ExecutorAgent && step.Status == types.StepStatusRunning && o.agentSlots > 0 {
+			o.agentSlots--
+		}
+		// Synchronous executors (spawn, expand, fire-and-forget) finish during dispatch
+		if step.Status.IsTerminal() {
+			o.recordStepFinished(wf.ID, step)
+		}
 		dispatchedSteps[step.ID] = step
 	}
 
+	// Synchronous steps are done with their locks already
+	o.releaseStepLocks(wf)
+
 	// Save if we dispatched any steps or if state was modified by checks.
 	// Note: All state mutations (including IPC handler) go through wfMu mutex,
 	// so no merge logic is needed - we hold the lock for the entire operation.
-	if len(dispatchedSteps) > 0 || timeoutModified || blockedModified || foreachModified || branchModified {
-		return o.store.Save(ctx, wf)
+	if len(dispatchedSteps) > 0 || checksModified || windowModified || lockModified {
+		return o.saveProgress(ctx, wf)
 	}
 
 	return nil
 }
 
+// checkStepWindow reports whether a ready step with only_between may be
+// dispatched now. Outside the window the step stays pending, or is skipped
+// when outside_window = "skip" (its dependents are then skipped too).
+// modified is true if the step's state changed.
+func (o *Orchestrator) checkStepWindow(step *types.Step) (dispatchable, modified bool) {
+	window, err := types.ParseTimeWindow(step.OnlyBetween)
+	if err != nil {
+		// Baked steps are validated, so this only guards hand-edited state.
+		// A pending step can't fail directly; start it first.
+		o.logger.Error("invalid step time window", "step", step.ID, "error", err)
+		if startErr := step.Start(); startErr == nil {
+			step.Fail(&types.StepError{Message: fmt.Sprintf("invalid only_between: %v", err)})
+		}
+		return false, true
+	}
+
+	now := o.now()
+	if window.Contains(now) {
+		return true, false
+	}
+
+	if step.OutsideWindow == types.OutsideWindowSkip {
+		reason := &types.SkipReason{
+			Kind:    types.SkipReasonOutsideWindow,
+			Message: fmt.Sprintf("outside time window %s", step.OnlyBetween),
+		}
+		o.logger.Info("skipping step outside its time window",
+			"step", step.ID,
+			"window", step.OnlyBetween,
+			"now", now.Format("15:04"))
+		if err := step.Skip(reason); err != nil {
+			o.logger.Error("failed to skip step", "step", step.ID, "error", err)
+		}
+		return false, true
+	}
+
+	o.logger.Debug("step waiting for time window",
+		"step", step.ID,
+		"window", step.OnlyBetween,
+		"now", now.Format("15:04"))
+	return false, false
+}
+
 // dispatch routes a step to the appropriate executor handler.
-// IMPORTANT: Exactly 6 executors. Gate is NOT an executor.
+// IMPORTANT: Exactly 8 executors.
 func (o *Orchestrator) dispatch(ctx context.Context, wf *types.Run, step *types.Step) error {
-	o.logger.Info("dispatching step", "id", step.ID, "executor", step.Executor)
+	// Handlers log through the executor's logger so [logging.executors]
+	// overrides apply to everything about this step, including async work
+	logger := o.executorLogger(step.Executor)
+	ctx = context.WithValue(ctx, stepLoggerKey{}, logger)
+	logger.Info("dispatching step", "id", step.ID, "executor", step.Executor)
 
 	// Resolve any deferred step output references before executing
 	o.resolveStepOutputRefs(wf, step)
 
+	// The if check and mandatory environment checks (requires) gate the
+	// executor entirely. They run asynchronously; the step stays pending
+	// until they pass, and a false if check skips it.
+	if (step.If != "" || len(step.Requires) > 0) && !o.preconditionsPassed(ctx, wf, step) {
+		return nil
+	}
+
+	var err error
 	switch step.Executor {
 	case types.ExecutorShell:
-		return o.handleShell(ctx, wf, step)
+		err = o.handleShell(ctx, wf, step)
 	case types.ExecutorSpawn:
-		return o.handleSpawn(ctx, wf, step)
+		err = o.handleSpawn(ctx, wf, step)
 	case types.ExecutorKill:
-		return o.handleKill(ctx, wf, step)
+		err = o.handleKill(ctx, wf, step)
 	case types.ExecutorExpand:
-		return o.handleExpand(ctx, wf, step)
+		err = o.handleExpand(ctx, wf, step)
 	case types.ExecutorBranch:
-		return o.handleBranch(ctx, wf, step)
+		err = o.handleBranch(ctx, wf, step)
 	case types.ExecutorForeach:
-		return o.handleForeach(ctx, wf, step)
+		err = o.handleForeach(ctx, wf, step)
+	case types.ExecutorGate:
+		err = o.handleGate(ctx, wf, step)
 	case types.ExecutorAgent:
-		return o.handleAgent(ctx, wf, step)
+		err = o.handleAgent(ctx, wf, step)
 	default:
 		return fmt.Errorf("unknown executor: %s", step.Executor)
 	}
+
+	// Only count the start if the handler actually started the step
+	// (agent steps reset to pending on transient injection failures).
+	if step.Status != types.StepStatusPending {
+		o.recordStepStarted(wf, step)
+	}
+	return err
 }
 
 // stepOutputRefPattern matches {{step-id.outputs.field}} references
 // Step IDs can contain dots (e.g., "parent.child" from expansion prefixes), so we match
 // everything before ".outputs." as the step ID.
-// Field names can also contain dots for nested access (e.g., "config.nested").
-var stepOutputRefPattern = regexp.MustCompile(`\{\{([a-zA-Z0-9_.-]+)\.outputs\.([a-zA-Z0-9_.]+)\}\}`)
+// Field names can also contain dots and array indexes for nested access (e.g., "config.nested", "items[0].id").
+var stepOutputRefPattern = regexp.MustCompile(`\{\{([a-zA-Z0-9_.-]+)\.outputs\.([a-zA-Z0-9_.\[\]]+)\}\}`)
 
 // findStepWithScopeWalk looks up a step by ID, using scope-walk resolution if exact match fails.
 // When templates are expanded inside foreach loops, step IDs get prefixed (e.g., "agents.0.shell-step").
@@ -595,26 +1100,35 @@ func findStepWithScopeWalk(wf *types.Run, refStepID, currentStepID string) (*typ
 }
 
 // getNestedOutputValue retrieves a potentially nested value from step outputs.
-// Field can be simple ("result") or nested ("config.database.host").
+// Field can be simple ("result"), nested ("config.database.host"), or index into
+// arrays ("result.items[0].id", equivalently "result.items.0.id").
 func getNestedOutputValue(outputs map[string]any, field string) (any, bool) {
-	// Simple case: no dots in field name
-	if !strings.Contains(field, ".") {
+	// Simple case: no path separators in field name
+	if !strings.ContainsAny(field, ".[") {
 		val, ok := outputs[field]
 		return val, ok
 	}
 
 	// Nested case: walk down the path
-	parts := strings.Split(field, ".")
+	parts, ok := splitOutputPath(field)
+	if !ok {
+		return nil, false
+	}
 	var val any = outputs
 
 	for _, part := range parts {
 		switch v := val.(type) {
 		case map[string]any:
-			var ok bool
 			val, ok = v[part]
 			if !ok {
 				return nil, false
 			}
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			val = v[idx]
 		default:
 			return nil, false
 		}
@@ -623,6 +1137,44 @@ func getNestedOutputValue(outputs map[string]any, field string) (any, bool) {
 	return val, true
 }
 
+// OutputValue returns the value at field in a step's outputs. Fields are
+// resolved as in {{step.outputs.field}} references, so they may be nested
+// ("config.database.host") or index into arrays ("items[0].id").
+func OutputValue(outputs map[string]any, field string) (any, bool) {
+	return getNestedOutputValue(outputs, field)
+}
+
+// splitOutputPath splits "result.items[0].id" into ["result", "items", "0", "id"].
+// Returns false for malformed paths (empty segments, unbalanced brackets).
+func splitOutputPath(path string) ([]string, bool) {
+	var parts []string
+	for _, dotted := range strings.Split(path, ".") {
+		name, rest, hasIndex := strings.Cut(dotted, "[")
+		if name == "" && (!hasIndex || len(parts) == 0) {
+			return nil, false
+		}
+		if name != "" {
+			parts = append(parts, name)
+		}
+		for hasIndex {
+			var idx string
+			idx, rest, hasIndex = strings.Cut(rest, "]")
+			if !hasIndex || idx == "" {
+				return nil, false
+			}
+			parts = append(parts, idx)
+			if rest == "" {
+				break
+			}
+			if rest[0] != '[' {
+				return nil, false
+			}
+			rest = rest[1:]
+		}
+	}
+	return parts, true
+}
+
 // resolveStepOutputRefs substitutes {{step.outputs.field}} references with actual values
 // from completed steps in the workflow. Uses scope-walk resolution to find steps within
 // foreach-expanded contexts.
@@ -668,6 +1220,7 @@ func (o *Orchestrator) resolveStepOutputRefs(wf *types.Run, step *types.Step) {
 		if step.Shell != nil {
 			step.Shell.Command = resolve(step.Shell.Command)
 			step.Shell.Workdir = resolve(step.Shell.Workdir)
+			step.Shell.RunAs = resolve(step.Shell.RunAs)
 			for k, v := range step.Shell.Env {
 				step.Shell.Env[k] = resolve(v)
 			}
@@ -679,6 +1232,10 @@ func (o *Orchestrator) resolveStepOutputRefs(wf *types.Run, step *types.Step) {
 			step.Spawn.Workdir = resolve(step.Spawn.Workdir)
 			step.Spawn.ResumeSession = resolve(step.Spawn.ResumeSession)
 			step.Spawn.SpawnArgs = resolve(step.Spawn.SpawnArgs)
+			step.Spawn.ReuseFrom = resolve(step.Spawn.ReuseFrom)
+			for i, c := range step.Spawn.Capabilities {
+				step.Spawn.Capabilities[i] = resolve(c)
+			}
 			for k, v := range step.Spawn.Env {
 				step.Spawn.Env[k] = resolve(v)
 			}
@@ -691,12 +1248,21 @@ func (o *Orchestrator) resolveStepOutputRefs(wf *types.Run, step *types.Step) {
 		if step.Agent != nil {
 			step.Agent.Agent = resolve(step.Agent.Agent)
 			step.Agent.Prompt = resolve(step.Agent.Prompt)
+			step.Agent.RequiresCapability = resolve(step.Agent.RequiresCapability)
+			if step.Agent.Nudge != nil {
+				step.Agent.Nudge.Prompt = resolve(step.Agent.Nudge.Prompt)
+			}
+			step.Agent.ResultFile = resolve(step.Agent.ResultFile)
 		}
 	case types.ExecutorForeach:
 		if step.Foreach != nil {
 			step.Foreach.Items = resolve(step.Foreach.Items)
 			step.Foreach.ItemsFile = resolve(step.Foreach.ItemsFile)
 		}
+	case types.ExecutorGate:
+		if step.Gate != nil {
+			step.Gate.WaitForEvent = resolve(step.Gate.WaitForEvent)
+		}
 	case types.ExecutorExpand:
 		if step.Expand != nil {
 			step.Expand.Template = resolve(step.Expand.Template)
@@ -756,6 +1322,8 @@ func (o *Orchestrator) resolveStepOutputRefs(wf *types.Run, step *types.Step) {
 // Thread-safe: acquires wfMu before any state changes.
 // Called by IPCHandler - this is the ONLY code path for step completion.
 func (o *Orchestrator) HandleStepDone(ctx context.Context, msg *ipc.StepDoneMessage) error {
+	o.awaitLogOutputs(ctx, msg)
+
 	o.wfMu.Lock()
 	defer o.wfMu.Unlock()
 
@@ -798,42 +1366,208 @@ func (o *Orchestrator) HandleStepDone(ctx context.Context, msg *ipc.StepDoneMess
 		return fmt.Errorf("step %s is not assigned to agent %s", step.ID, msg.Agent)
 	}
 
+	if msg.Error != "" {
+		return o.failAgentStep(ctx, wf, step, msg.Error)
+	}
+
+	// A prompt the agent never read can reach its session's shell after the
+	// agent exits, running the meow done it asks for; that is a crash
+	if watcher, ok := o.agents.(AgentExitWatcher); ok {
+		if exited, _, err := watcher.ExitStatus(ctx, msg.Agent); err == nil && exited {
+			return o.failCrashedStep(ctx, wf, step)
+		}
+	}
+
+	err = o.completeAgentStep(ctx, wf, step, msg.Agent, msg.Outputs)
+	var invalid *outputValidationError
+	if errors.As(err, &invalid) {
+		return o.handleInvalidOutputs(ctx, wf, step, msg.Agent, invalid)
+	}
+	return err
+}
+
+// failAgentStep fails a running agent step whose agent reported an error
+// instead of completing it, saving the workflow. Retries, on_error recovery
+// and optional apply as for any failure. The caller must hold wfMu.
+func (o *Orchestrator) failAgentStep(ctx context.Context, wf *types.Run, step *types.Step, message string) error {
+	logger := o.executorLogger(step.Executor)
+	if err := step.Fail(&types.StepError{
+		Message: message,
+		Type:    types.StepErrorAgentFailed,
+	}); err != nil {
+		return fmt.Errorf("failing step: %w", err)
+	}
+	o.recordStepFinished(wf.ID, step)
+	logger.Warn("agent reported step failed", "step", step.ID, "workflow", wf.ID, "error", message)
+	return o.store.Save(ctx, wf)
+}
+
+// failCrashedStep fails a running agent step whose agent exited before
+// completing it, saving the workflow. The caller must hold wfMu.
+func (o *Orchestrator) failCrashedStep(ctx context.Context, wf *types.Run, step *types.Step) error {
+	if err := step.Fail(&types.StepError{
+		Message: fmt.Sprintf("Agent %s exited before completing the step", step.Agent.Agent),
+		Type:    types.StepErrorAgentCrashed,
+	}); err != nil {
+		return fmt.Errorf("failing step: %w", err)
+	}
+	o.recordStepFinished(wf.ID, step)
+	o.executorLogger(step.Executor).Warn("agent crashed", "step", step.ID, "agent", step.Agent.Agent)
+	if err := o.store.Save(ctx, wf); err != nil {
+		return err
+	}
+	return fmt.Errorf("agent %s exited before completing step %s", step.Agent.Agent, step.ID)
+}
+
+// outputValidationError reports agent outputs that failed validation.
+type outputValidationError struct {
+	errs []string
+}
+
+func (e *outputValidationError) Error() string {
+	return fmt.Sprintf("output validation failed: %v", e.errs)
+}
+
+// handleInvalidOutputs responds to `meow done` outputs that failed
+// validation. While the step has validation retries left, the agent is
+// re-prompted with the errors and the step keeps running; after that the
+// step fails. Either way the errors are returned to the agent, and the
+// workflow is saved. The caller must hold wfMu.
+func (o *Orchestrator) handleInvalidOutputs(ctx context.Context, wf *types.Run, step *types.Step, agentID string, invalid *outputValidationError) error {
+	logger := o.executorLogger(step.Executor)
+	limit := config.DefaultMaxValidationRetries
+	if o.cfg != nil {
+		limit = o.cfg.Agent.ValidationRetryLimit()
+	}
+
+	if step.ValidationRetries >= limit {
+		logger.Warn("output validation failed, no retries left",
+			"step", step.ID, "errors", invalid.errs, "max_validation_retries", limit)
+		if err := step.Fail(&types.StepError{
+			Message: fmt.Sprintf("output validation failed after %d retries: %s", limit, strings.Join(invalid.errs, "; ")),
+			Type:    types.StepErrorValidationFailed,
+		}); err != nil {
+			return fmt.Errorf("failing step: %w", err)
+		}
+		o.recordStepFinished(wf.ID, step)
+		if err := o.store.Save(ctx, wf); err != nil {
+			logger.Error("failed to save workflow after validation failure", "error", err)
+		}
+		return fmt.Errorf("%w; step failed after %d retries", invalid, limit)
+	}
+
+	step.ValidationRetries++
+	logger.Warn("output validation failed, re-prompting agent",
+		"step", step.ID, "errors", invalid.errs, "retry", step.ValidationRetries, "max_validation_retries", limit)
+	if err := o.store.Save(ctx, wf); err != nil {
+		logger.Error("failed to save workflow after validation failure", "error", err)
+	}
+
+	result, stepErr := StartAgentStep(step)
+	if stepErr != nil || o.agents == nil {
+		return invalid
+	}
+	prompt := wrapAgentPrompt(validationRetryPrompt(result.Prompt, invalid.errs, step.ValidationRetries, limit), wf, step.Agent)
+	opts, err := agentInjectOpts(step.Agent)
+	if err != nil {
+		logger.Warn("cannot re-prompt agent", "step", step.ID, "error", err)
+		return invalid
+	}
+	opts.Stabilize = true
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		// A dead agent cannot retry; liveness polling fails its step
+		if alive, err := o.agents.IsRunning(ctx, agentID); err != nil || !alive {
+			return
+		}
+		if err := o.agents.InjectPrompt(ctx, agentID, prompt, opts); err != nil {
+			logger.Warn("failed to re-prompt agent after validation failure", "agent", agentID, "error", err)
+		}
+	}()
+	return invalid
+}
+
+// completeAgentStep validates the outputs an agent reported for a running
+// step and completes it, saving the workflow. Outputs that fail validation
+// leave the step running and return an *outputValidationError without
+// saving. The caller must hold wfMu.
+func (o *Orchestrator) completeAgentStep(ctx context.Context, wf *types.Run, step *types.Step, agentID string, reported map[string]any) error {
+	logger := o.executorLogger(step.Executor)
+
 	// Transition to completing to prevent race with stop hook
 	if err := step.SetCompleting(); err != nil {
 		return fmt.Errorf("setting step completing: %w", err)
 	}
 
+	// Extract outputs declared with a JSON path (from = "result.items[0].id")
+	// or read from the agent's structured log (from_log = "files_changed")
+	outputs := reported
+	if step.Agent != nil {
+		outputs = ExtractAgentOutputPaths(reported, step.Agent.Outputs)
+		outputs = o.extractLogOutputs(step, outputs)
+	}
+
 	// Validate outputs if defined
 	if step.Agent != nil && len(step.Agent.Outputs) > 0 {
 		agentWorkdir := ""
 		if o.agents != nil {
 			if mgr, ok := o.agents.(*TmuxAgentManager); ok {
-				agentWorkdir = mgr.GetWorkdir(msg.Agent)
+				agentWorkdir = mgr.GetWorkdir(agentID)
 			}
 		}
-		errs := ValidateAgentOutputs(msg.Outputs, step.Agent.Outputs, agentWorkdir)
+		errs := ValidateAgentOutputs(outputs, step.Agent.Outputs, agentWorkdir)
 		if len(errs) > 0 {
 			// Validation failed - keep step running so agent can retry
 			step.Status = types.StepStatusRunning
-			o.logger.Warn("output validation failed", "step", step.ID, "errors", errs)
-			if saveErr := o.store.Save(ctx, wf); saveErr != nil {
-				o.logger.Error("failed to save workflow after validation failure", "error", saveErr)
-			}
-			return fmt.Errorf("output validation failed: %v", errs)
+			return &outputValidationError{errs: errs}
 		}
 	}
 
-	// Mark step complete
-	if err := step.Complete(msg.Outputs); err != nil {
+	// Self-checking steps: the reported outputs must satisfy the assert
+	// table. Unlike validation errors this fails the step instead of asking
+	// the agent to retry.
+	if stepErr := checkOutputAssertions(step.Assert, outputs); stepErr != nil {
+		logger.Warn("output assertion failed", "step", step.ID, "diff", stepErr.Output)
+		step.Status = types.StepStatusRunning // completing cannot fail directly
+		step.Outputs = outputs
+		if err := step.Fail(stepErr); err != nil {
+			return fmt.Errorf("failing step: %w", err)
+		}
+		o.recordStepFinished(wf.ID, step)
+		return o.store.Save(ctx, wf)
+	}
+
+	if step.Agent != nil && step.Agent.CaptureTranscript {
+		outputs = o.captureTranscript(ctx, wf, step, outputs)
+	}
+
+	// Mark step complete, keeping the stored outputs within the run's budget
+	// (artifacts are persisted from the full outputs)
+	if err := step.Complete(o.applyOutputBudget(wf, step, outputs)); err != nil {
 		return fmt.Errorf("completing step: %w", err)
 	}
+	if step.Agent != nil {
+		agentWorkdir := ""
+		if mgr, ok := o.agents.(*TmuxAgentManager); ok {
+			agentWorkdir = mgr.GetWorkdir(agentID)
+		}
+		o.persistAgentArtifacts(wf, step, step.Agent.Outputs, outputs, agentWorkdir)
+	}
 
-	o.logger.Info("step completed", "step", step.ID, "workflow", wf.ID)
+	o.recordStepFinished(wf.ID, step)
+	logger.Info("step completed", "step", step.ID, "workflow", wf.ID)
 	return o.store.Save(ctx, wf)
 }
 
-// TimeoutGracePeriod is the duration to wait after sending C-c before marking a step as failed.
-const TimeoutGracePeriod = 10 * time.Second
+// timeoutGracePeriod returns how long to wait after sending C-c to a timed-out
+// step's agent before marking the step as failed (timeout_grace_period).
+func (o *Orchestrator) timeoutGracePeriod() time.Duration {
+	if o.cfg == nil || o.cfg.Orchestrator.TimeoutGracePeriod <= 0 {
+		return config.DefaultTimeoutGracePeriod
+	}
+	return o.cfg.Orchestrator.TimeoutGracePeriod
+}
 
 // CleanupTimeout is the maximum duration for cleanup script execution.
 const CleanupTimeout = 60 * time.Second
@@ -850,26 +1584,25 @@ func (o *Orchestrator) checkStepTimeouts(ctx context.Context, wf *types.Run) boo
 		if step.Executor != types.ExecutorAgent {
 			continue
 		}
-		if step.Agent == nil || step.Agent.Timeout == "" {
+		if step.Agent == nil || step.StartedAt == nil {
 			continue
 		}
 
-		timeout, err := time.ParseDuration(step.Agent.Timeout)
+		timeout, from, awaitingAck, err := agentStepTimeout(step)
 		if err != nil {
-			o.logger.Warn("invalid timeout duration", "step", step.ID, "timeout", step.Agent.Timeout, "error", err)
+			o.logger.Warn("invalid timeout duration", "step", step.ID, "error", err)
 			continue
 		}
-
-		if step.StartedAt == nil {
+		if timeout == 0 {
 			continue
 		}
 
-		elapsed := time.Since(*step.StartedAt)
+		elapsed := time.Since(from)
 
 		// If already interrupted, check if grace period has passed
 		if step.InterruptedAt != nil {
 			gracePeriodElapsed := time.Since(*step.InterruptedAt)
-			if gracePeriodElapsed >= TimeoutGracePeriod {
+			if gracePeriodElapsed >= o.timeoutGracePeriod() {
 				// Grace period expired - mark step as failed
 				o.logger.Warn("step timeout grace period expired",
 					"step", step.ID,
@@ -877,47 +1610,366 @@ func (o *Orchestrator) checkStepTimeouts(ctx context.Context, wf *types.Run) boo
 					"elapsed", elapsed,
 					"gracePeriod", gracePeriodElapsed)
 
+				message := fmt.Sprintf("Step timed out after %s", elapsed.Round(time.Second))
+				if awaitingAck {
+					message = fmt.Sprintf("Prompt not acknowledged within %s", timeout)
+				}
 				if err := step.Fail(&types.StepError{
-					Message: fmt.Sprintf("Step timed out after %s", elapsed.Round(time.Second)),
+					Message: message,
+					Type:    types.StepErrorTimeout,
 				}); err != nil {
 					o.logger.Error("failed to mark timed-out step as failed",
 						"step", step.ID,
 						"error", err)
+				} else {
+					o.recordStepFinished(wf.ID, step)
 				}
 				modified = true
 			}
 			continue
 		}
 
+		// Asked to wrap up (soft cancel): interrupt once its grace has passed
+		if step.CancelPromptedAt != nil {
+			if time.Since(*step.CancelPromptedAt) >= o.cancelGrace(step) {
+				o.logger.Warn("step still running after cancel prompt, sending interrupt",
+					"step", step.ID,
+					"elapsed", elapsed)
+				o.interruptTimedOutStep(ctx, step)
+				modified = true
+			}
+			continue
+		}
+
 		// Check if step has exceeded timeout
 		if elapsed > timeout {
+			// An agent that is working gets the chance to checkpoint first
+			if step.Agent.CancelPrompt != "" && !awaitingAck && o.agents != nil {
+				o.logger.Warn("step timed out, asking agent to wrap up",
+					"step", step.ID,
+					"timeout", timeout,
+					"elapsed", elapsed,
+					"grace", o.cancelGrace(step))
+				o.promptCancel(ctx, step)
+				modified = true
+				continue
+			}
+
 			o.logger.Warn("step timed out, sending interrupt",
 				"step", step.ID,
 				"timeout", timeout,
-				"elapsed", elapsed)
+				"elapsed", elapsed,
+				"awaitingAck", awaitingAck)
+			o.interruptTimedOutStep(ctx, step)
+			modified = true
+		}
+	}
+	return modified
+}
 
-			// Send C-c to agent
-			if o.agents != nil {
-				if err := o.agents.Interrupt(ctx, step.Agent.Agent); err != nil {
-					o.logger.Error("failed to send interrupt to agent",
-						"step", step.ID,
-						"agent", step.Agent.Agent,
-						"error", err)
-					// Continue anyway - mark as interrupted to start grace period
-				}
+// interruptTimedOutStep sends C-c to a timed-out step's agent and starts the
+// grace period after which the step fails.
+func (o *Orchestrator) interruptTimedOutStep(ctx context.Context, step *types.Step) {
+	if o.agents != nil {
+		if err := o.agents.Interrupt(ctx, step.Agent.Agent); err != nil {
+			o.logger.Error("failed to send interrupt to agent",
+				"step", step.ID,
+				"agent", step.Agent.Agent,
+				"error", err)
+			// Continue anyway - mark as interrupted to start grace period
+		}
+	}
+
+	// Record when we sent the interrupt
+	now := time.Now()
+	step.InterruptedAt = &now
+}
+
+// promptCancel injects a timed-out step's cancel_prompt, asking its agent to
+// checkpoint and finish with meow done, and starts the cancel grace period.
+// The prompt is injected without stabilization, as nudges are; if it cannot
+// be sent the agent is still interrupted when the grace period ends.
+func (o *Orchestrator) promptCancel(ctx context.Context, step *types.Step) {
+	now := time.Now()
+	step.CancelPromptedAt = &now
+
+	agentID, prompt := step.Agent.Agent, step.Agent.CancelPrompt
+	injectOpts, err := agentInjectOpts(step.Agent)
+	if err != nil {
+		o.logger.Warn("cannot send cancel prompt to agent", "step", step.ID, "error", err)
+		return
+	}
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if err := o.agents.InjectPrompt(ctx, agentID, prompt, injectOpts); err != nil {
+			o.logger.Error("failed to send cancel prompt to agent", "agent", agentID, "error", err)
+		}
+	}()
+}
+
+// cancelGrace returns how long a timed-out agent step has to finish after its
+// cancel_prompt before it is interrupted: its cancel_grace, or
+// timeout_grace_period.
+func (o *Orchestrator) cancelGrace(step *types.Step) time.Duration {
+	if step.Agent != nil && step.Agent.CancelGrace != "" {
+		if grace, err := time.ParseDuration(step.Agent.CancelGrace); err == nil {
+			return grace
+		}
+		o.logger.Warn("invalid cancel_grace, using timeout_grace_period", "step", step.ID, "cancel_grace", step.Agent.CancelGrace)
+	}
+	return o.timeoutGracePeriod()
+}
+
+// checkAgentLiveness fails running agent steps whose agent is no longer
+// running (its process exited or its session is gone), so a crashed agent
+// is detected within a poll interval instead of when the step times out.
+// Steps with completion = "exit" are left to checkAgentExits, which reports
+// the exit code, and steps already interrupted for a timeout are left to
+// checkStepTimeouts.
+// Returns true if any step state was modified (requires save).
+func (o *Orchestrator) checkAgentLiveness(ctx context.Context, wf *types.Run) bool {
+	if o.agents == nil {
+		return false
+	}
+
+	modified := false
+	for _, stepID := range sortedKeys(wf.Steps) {
+		step := wf.Steps[stepID]
+		if step.Status != types.StepStatusRunning || step.Executor != types.ExecutorAgent {
+			continue
+		}
+		if step.Agent == nil || step.Agent.Completion == types.CompletionExit || step.InterruptedAt != nil {
+			continue
+		}
+
+		alive, err := o.agents.IsRunning(ctx, step.Agent.Agent)
+		if err != nil {
+			o.logger.Warn("cannot check agent liveness", "step", step.ID, "agent", step.Agent.Agent, "error", err)
+			continue
+		}
+		if alive {
+			continue
+		}
+
+		o.logger.Warn("agent crashed", "step", step.ID, "agent", step.Agent.Agent)
+		if err := step.Fail(&types.StepError{
+			Message: fmt.Sprintf("Agent %s exited before completing the step", step.Agent.Agent),
+			Type:    types.StepErrorAgentCrashed,
+		}); err != nil {
+			o.logger.Error("failed to mark crashed step as failed", "step", step.ID, "error", err)
+			continue
+		}
+		o.recordStepFinished(wf.ID, step)
+		modified = true
+	}
+	return modified
+}
+
+// RecordAgentActivity notes that an agent just sent an event, resetting its
+// stall_timeout clock. Called for every event from an agent.
+func (o *Orchestrator) RecordAgentActivity(workflowID, agentID string) {
+	if workflowID == "" {
+		workflowID = o.workflowID
+	}
+	o.agentActivity.Store(workflowID+":"+agentID, o.now())
+}
+
+// checkAgentStalls reports running agent steps whose agent has sent no events
+// for the step's stall_timeout. Each report emits an agent-stalled event and,
+// with on_stall = "nudge", re-injects the step's prompt. Reporting re-arms the
+// clock, so an agent that stays silent is reported once per stall_timeout.
+// Steps already being interrupted for a timeout are left alone.
+func (o *Orchestrator) checkAgentStalls(ctx context.Context, wf *types.Run) {
+	now := o.now()
+	for _, step := range wf.Steps {
+		if step.Status != types.StepStatusRunning || step.Executor != types.ExecutorAgent {
+			continue
+		}
+		if step.Agent == nil || step.Agent.StallTimeout == "" || step.StartedAt == nil || step.InterruptedAt != nil {
+			continue
+		}
+
+		stallTimeout, err := time.ParseDuration(step.Agent.StallTimeout)
+		if err != nil {
+			o.logger.Warn("invalid stall_timeout", "step", step.ID, "error", err)
+			continue
+		}
+
+		key := wf.ID + ":" + step.Agent.Agent
+		lastActivity := *step.StartedAt
+		if v, ok := o.agentActivity.Load(key); ok && v.(time.Time).After(lastActivity) {
+			lastActivity = v.(time.Time)
+		}
+		silentFor := now.Sub(lastActivity)
+		lastReport := lastActivity
+		if v, ok := o.stallReported.Load(key); ok && v.(time.Time).After(lastReport) {
+			lastReport = v.(time.Time)
+		}
+		if now.Sub(lastReport) < stallTimeout {
+			continue
+		}
+		o.stallReported.Store(key, now)
+
+		nudge := step.Agent.OnStall == types.OnStallNudge
+		o.tracing.stepEvent(wf.ID, step.ID, "agent.stalled",
+			attribute.String("meow.agent.id", step.Agent.Agent),
+			attribute.Bool("meow.agent.nudge", nudge))
+		o.logger.Warn("agent stalled",
+			"step", step.ID,
+			"agent", step.Agent.Agent,
+			"silentFor", silentFor.Round(time.Second),
+			"stallTimeout", stallTimeout,
+			"nudge", nudge)
+
+		if o.eventRouter != nil {
+			o.eventRouter.Route(&ipc.EventMessage{
+				EventType: "agent-stalled",
+				Agent:     step.Agent.Agent,
+				Workflow:  wf.ID,
+				Timestamp: now.Unix(),
+				Data: map[string]any{
+					"step":       step.ID,
+					"silent_for": silentFor.Round(time.Second).String(),
+				},
+			})
+		}
+
+		if nudge && o.agents != nil {
+			result, stepErr := StartAgentStep(step)
+			if stepErr != nil {
+				o.logger.Warn("cannot nudge stalled agent", "step", step.ID, "error", stepErr.Message)
+				continue
 			}
+			injectOpts, err := agentInjectOpts(step.Agent)
+			if err != nil {
+				o.logger.Warn("cannot nudge stalled agent", "step", step.ID, "error", err)
+				continue
+			}
+			injectOpts.Stabilize = true
+			agentID := step.Agent.Agent
+			prompt := wrapAgentPrompt(result.Prompt, wf, step.Agent)
+			o.wg.Add(1)
+			go func() {
+				defer o.wg.Done()
+				if err := o.agents.InjectPrompt(ctx, agentID, prompt, injectOpts); err != nil {
+					o.logger.Warn("failed to nudge stalled agent", "agent", agentID, "error", err)
+				}
+			}()
+		}
+	}
+}
+
+// agentStepTimeout returns the timeout currently in force for a running agent
+// step and the time it is measured from. Normally that is the step timeout from
+// dispatch. With ack_timeout set, the step first has ack_timeout to acknowledge
+// the prompt and then the full step timeout from the acknowledgment. A zero
+// timeout means none applies.
+func agentStepTimeout(step *types.Step) (timeout time.Duration, from time.Time, awaitingAck bool, err error) {
+	cfg := step.Agent
+	if cfg.AckTimeout != "" && step.AcknowledgedAt == nil {
+		timeout, err = time.ParseDuration(cfg.AckTimeout)
+		if err != nil {
+			return 0, time.Time{}, false, fmt.Errorf("ack_timeout %q: %w", cfg.AckTimeout, err)
+		}
+		return timeout, *step.StartedAt, true, nil
+	}
+	if cfg.Timeout == "" {
+		return 0, time.Time{}, false, nil
+	}
+	timeout, err = time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return 0, time.Time{}, false, fmt.Errorf("timeout %q: %w", cfg.Timeout, err)
+	}
+	from = *step.StartedAt
+	if cfg.AckTimeout != "" {
+		from = *step.AcknowledgedAt
+	}
+	return timeout, from, false, nil
+}
+
+// HandlePromptReceived records the agent's acknowledgment of its current prompt
+// on the running step, starting the work budget for steps with ack_timeout.
+// Thread-safe: acquires wfMu before any state changes.
+func (o *Orchestrator) HandlePromptReceived(ctx context.Context, workflowID, agentID string) error {
+	if workflowID == "" {
+		workflowID = o.workflowID
+	}
+
+	o.wfMu.Lock()
+	defer o.wfMu.Unlock()
 
-			// Record when we sent the interrupt
-			now := time.Now()
-			step.InterruptedAt = &now
+	wf, err := o.store.Get(ctx, workflowID)
+	if err != nil {
+		return fmt.Errorf("getting workflow %s: %w", workflowID, err)
+	}
+
+	step := wf.GetRunningStepForAgent(agentID)
+	if step == nil || step.AcknowledgedAt != nil || step.InterruptedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	step.AcknowledgedAt = &now
+	o.logger.Debug("prompt acknowledged", "step", step.ID, "agent", agentID)
+	return o.store.Save(ctx, wf)
+}
+
+// retryFailedSteps resets failed steps that have retries left to pending so
+// they run again. Each retry also spends one from the run's retry budget, if it
+// has one; once the budget is spent, further retries are denied (the step's
+// remaining retries are forfeited) and the failure stands.
+func (o *Orchestrator) retryFailedSteps(wf *types.Run) bool {
+	modified := false
+	for _, id := range sortedKeys(wf.Steps) {
+		step := wf.Steps[id]
+		if step.Status != types.StepStatusFailed || step.Attempts >= step.Retries || len(step.ExpandedInto) > 0 {
+			continue
+		}
+
+		if wf.RetryBudget != nil && *wf.RetryBudget <= 0 {
+			o.logger.Warn("retry denied: workflow retry budget exhausted",
+				"step", step.ID,
+				"attempts", step.Attempts,
+				"retries", step.Retries)
+			step.Retries = step.Attempts
 			modified = true
+			continue
+		}
+
+		var reason string
+		if step.Error != nil {
+			reason = step.Error.Message
+		}
+		if err := step.Rerun(); err != nil {
+			o.logger.Error("failed to reset step for retry", "step", step.ID, "error", err)
+			continue
 		}
+		step.Attempts++
+		if wf.RetryBudget != nil {
+			*wf.RetryBudget--
+		}
+		// Baked steps are validated, so a bad backoff only comes from
+		// hand-edited state; retry right away rather than never
+		if delay, err := step.RetryDelay(step.Attempts); err != nil {
+			o.logger.Error("ignoring retry backoff", "step", step.ID, "error", err)
+		} else if delay > 0 {
+			retryAt := o.now().Add(delay)
+			step.RetryAt = &retryAt
+		}
+		o.logger.Info("retrying failed step",
+			"step", step.ID,
+			"attempt", step.Attempts,
+			"retries", step.Retries,
+			"error", reason)
+		modified = true
 	}
 	return modified
 }
 
 // checkBlockedSteps marks pending steps as skipped if they have failed dependencies.
 // A step is blocked if any of its dependencies has failed (and that dependency doesn't have on_error=continue).
+// A dependency whose on_error recovery steps are still running blocks nothing yet.
 // Returns true if any step was modified.
 func (o *Orchestrator) checkBlockedSteps(wf *types.Run) bool {
 	modified := false
@@ -932,9 +1984,13 @@ func (o *Orchestrator) checkBlockedSteps(wf *types.Run) bool {
 			if !ok {
 				continue
 			}
-			if dep.Status == types.StepStatusFailed {
+			if dep.Status == types.StepStatusFailed && !isRecovering(dep, wf.Steps) {
 				// Dependency failed - this step should be skipped
-				reason := fmt.Sprintf("dependency %q failed", depID)
+				reason := &types.SkipReason{
+					Kind:       types.SkipReasonDependencyFailed,
+					Dependency: depID,
+					Message:    fmt.Sprintf("dependency %q failed", depID),
+				}
 				o.logger.Info("skipping step due to failed dependency",
 					"step", step.ID,
 					"dependency", depID)
@@ -946,9 +2002,13 @@ func (o *Orchestrator) checkBlockedSteps(wf *types.Run) bool {
 				modified = true
 				break // No need to check other dependencies
 			}
-			if dep.Status == types.StepStatusSkipped {
+			if dep.Status == types.StepStatusSkipped && !dep.Satisfied() {
 				// Dependency was skipped - cascade the skip
-				reason := fmt.Sprintf("dependency %q was skipped", depID)
+				reason := &types.SkipReason{
+					Kind:       types.SkipReasonDependencySkipped,
+					Dependency: depID,
+					Message:    fmt.Sprintf("dependency %q was skipped", depID),
+				}
 				o.logger.Info("skipping step due to skipped dependency",
 					"step", step.ID,
 					"dependency", depID)
@@ -965,8 +2025,68 @@ func (o *Orchestrator) checkBlockedSteps(wf *types.Run) bool {
 	return modified
 }
 
+// advanceForeachWindows expands the next iterations of running foreach steps
+// with a window, so that up to window iterations are unfinished at a time. A
+// windowed foreach is complete once its last batch finishes. Returns true if
+// any step was modified.
+func (o *Orchestrator) advanceForeachWindows(ctx context.Context, wf *types.Run) bool {
+	modified := false
+	for _, step := range wf.Steps {
+		if step.Status != types.StepStatusRunning || step.Executor != types.ExecutorForeach {
+			continue
+		}
+		if step.Foreach == nil || !step.Foreach.IsJoin() {
+			continue
+		}
+		window := step.Foreach.GetWindow()
+		if window <= 0 {
+			continue
+		}
+		free := window - CountUnfinishedIterations(step, wf.Steps)
+		if free <= 0 {
+			continue
+		}
+
+		loader := o.foreachLoader(wf, step)
+		if loader == nil {
+			continue
+		}
+		start := CountExpandedIterations(step)
+		result, stepErr := expandForeachIterations(ctx, step, loader, wf.Variables, 0, nil, start, free)
+		if stepErr != nil {
+			o.logger.Error("foreach window expansion failed", "step", step.ID, "error", stepErr.Message)
+			stepErr.Message = "foreach expansion failed: " + stepErr.Message
+			if err := step.Fail(stepErr); err != nil {
+				o.logger.Error("failed to fail foreach step", "step", step.ID, "error", err)
+			}
+			o.recordStepFinished(wf.ID, step)
+			modified = true
+			continue
+		}
+		if len(result.ExpandedSteps) == 0 {
+			continue
+		}
+
+		for _, newStep := range result.ExpandedSteps {
+			wf.Steps[newStep.ID] = newStep
+		}
+		step.ExpandedInto = append(step.ExpandedInto, result.StepIDs...)
+		o.logger.Debug("foreach window advanced",
+			"step", step.ID,
+			"from", start,
+			"iterations", len(result.IterationIDs),
+			"remaining", result.Remaining)
+		modified = true
+	}
+	return modified
+}
+
 // checkForeachCompletion checks for foreach steps with implicit join that are ready to complete.
-// When join=true (default) and all child steps are done, the foreach step is marked done.
+// When join=true (default) and all child steps are done, the foreach step is marked done
+// with its iterations' outputs aggregated in index order (see AggregateForeachResults),
+// plus the values of its collect output, if any.
+// Failed iterations fail the foreach only beyond its failure_threshold; below it
+// the foreach completes and lists them in its failed_iterations output.
 func (o *Orchestrator) checkForeachCompletion(wf *types.Run) bool {
 	modified := false
 	for _, step := range wf.Steps {
@@ -984,26 +2104,61 @@ func (o *Orchestrator) checkForeachCompletion(wf *types.Run) bool {
 		// Check if all children are complete
 		if IsForeachComplete(step, wf.Steps) {
 			modified = true
-			// Check if any children failed
+
+			// Failed iterations within the failure_threshold are tolerated
+			var failed []int
+			tolerated := false
 			if IsForeachFailed(step, wf.Steps) {
+				var total int
+				failed, total = FailedIterations(step, wf.Steps)
+				limit, err := step.Foreach.FailureLimit(total)
+				if err != nil {
+					o.logger.Warn("invalid foreach failure threshold", "step", step.ID, "error", err)
+				}
+				tolerated = len(failed) <= limit
+			}
+
+			// Check if any children failed
+			if len(failed) > 0 && !tolerated {
 				o.logger.Info("foreach step failed (child failed)",
-					"step", step.ID)
+					"step", step.ID,
+					"failedIterations", len(failed))
+				message := "one or more iterations failed"
+				if step.Foreach.FailureThreshold != "" {
+					message = fmt.Sprintf("%d iterations failed, over the failure threshold of %s",
+						len(failed), step.Foreach.FailureThreshold)
+				}
 				if err := step.Fail(&types.StepError{
-					Message: "one or more iterations failed",
+					Message: message,
+					Type:    types.StepErrorChildFailed,
 				}); err != nil {
 					o.logger.Error("failed to fail foreach step",
 						"step", step.ID,
 						"error", err)
 				}
+				o.recordStepFinished(wf.ID, step)
 			} else {
 				o.logger.Info("foreach step complete (all children done)",
 					"step", step.ID,
-					"childCount", len(step.ExpandedInto))
-				if err := step.Complete(nil); err != nil {
+					"childCount", len(step.ExpandedInto),
+					"failedIterations", len(failed))
+				results, resultsByIndex := AggregateForeachResults(step, wf.Steps)
+				outputs := map[string]any{
+					"results":          results,
+					"results_by_index": resultsByIndex,
+				}
+				if step.Foreach.Collect != "" {
+					outputs["collected"] = CollectForeachOutput(step.Foreach.Collect, results)
+				}
+				if len(failed) > 0 {
+					outputs["failed_iterations"] = failed
+				}
+				if err := step.Complete(o.applyOutputBudget(wf, step, outputs)); err != nil {
 					o.logger.Error("failed to complete foreach step",
 						"step", step.ID,
 						"error", err)
 				}
+				o.recordStepFinished(wf.ID, step)
 			}
 		}
 	}
@@ -1036,11 +2191,13 @@ func (o *Orchestrator) checkBranchCompletion(wf *types.Run) bool {
 					"step", step.ID)
 				if err := step.Fail(&types.StepError{
 					Message: "branch child step failed",
+					Type:    types.StepErrorChildFailed,
 				}); err != nil {
 					o.logger.Error("failed to fail branch step",
 						"step", step.ID,
 						"error", err)
 				}
+				o.recordStepFinished(wf.ID, step)
 			} else {
 				o.logger.Info("branch step complete (all children done)",
 					"step", step.ID,
@@ -1051,6 +2208,7 @@ func (o *Orchestrator) checkBranchCompletion(wf *types.Run) bool {
 						"step", step.ID,
 						"error", err)
 				}
+				o.recordStepFinished(wf.ID, step)
 			}
 			modified = true
 		}
@@ -1157,6 +2315,7 @@ func (o *Orchestrator) isForeachThrottled(wf *types.Run, step *types.Step) bool
 // 2. Persist state
 // 3. Kill all agent tmux sessions
 // 4. Execute cleanup script (60 second timeout)
+// (3 and 4 swap when cleanup_order = "script_first")
 // 5. Set final status
 // 6. Persist final state
 func (o *Orchestrator) RunCleanup(ctx context.Context, wf *types.Run, reason types.RunStatus) error {
@@ -1172,27 +2331,25 @@ func (o *Orchestrator) RunCleanup(ctx context.Context, wf *types.Run, reason typ
 		o.wfMu.Unlock()
 		return fmt.Errorf("starting cleanup: %w", err)
 	}
+	// Agents still alive at the end die in KillAll below, so the after-all
+	// hook can't wait for a final kill step
+	afterAll := o.claimAfterAllAgents(wf, true)
 	if err := o.store.Save(ctx, wf); err != nil {
 		o.logger.Error("failed to save workflow cleanup state", "error", err)
 		// Continue with cleanup anyway
 	}
 	o.wfMu.Unlock()
 
-	// 3. Kill all agent tmux sessions (long I/O, runs WITHOUT lock)
-	if o.agents != nil {
-		if err := o.agents.KillAll(ctx, wf); err != nil {
-			o.logger.Error("failed to kill agents during cleanup", "error", err)
-			// Continue with cleanup anyway
-		}
-	}
-
-	// 4. Execute cleanup script (if defined for this trigger, long I/O, runs WITHOUT lock)
-	if cleanupScript != "" {
-		if err := o.runCleanupScript(ctx, wf, cleanupScript); err != nil {
-			o.logger.Error("cleanup script failed", "error", err)
-			// Cleanup script errors are logged but don't prevent workflow termination
+	// 3-4. Kill all agent tmux sessions and execute the cleanup script (if
+	// defined for this trigger), in the template's cleanup_order. Both are
+	// long I/O and run WITHOUT lock. Failures are logged but don't prevent
+	// workflow termination.
+	_ = o.runCleanupActions(ctx, wf, reason, afterAll, func() error {
+		if o.agents == nil {
+			return nil
 		}
-	}
+		return o.agents.KillAll(ctx, wf)
+	})
 
 	// 5-6. Finalize UNDER LOCK (re-read to avoid stale pointer)
 	o.wfMu.Lock()
@@ -1205,6 +2362,7 @@ func (o *Orchestrator) RunCleanup(ctx context.Context, wf *types.Run, reason typ
 	saveErr := o.store.Save(ctx, freshWf)
 	o.wfMu.Unlock()
 
+	o.recordRunFinished(freshWf)
 	if saveErr != nil {
 		return fmt.Errorf("saving final workflow state: %w", saveErr)
 	}
@@ -1216,12 +2374,178 @@ func (o *Orchestrator) RunCleanup(ctx context.Context, wf *types.Run, reason typ
 	return nil
 }
 
-// runCleanupScript executes a cleanup script with timeout.
-func (o *Orchestrator) runCleanupScript(ctx context.Context, wf *types.Run, script string) error {
+// runCleanupActions kills a run's agents with killAgents, followed by the
+// after_all_agents hook if claimed, and runs the cleanup script for reason
+// and the run's cleanup_when scripts, in the run's cleanup_order. Failures
+// are logged and returned together. Long I/O: call WITHOUT lock.
+func (o *Orchestrator) runCleanupActions(ctx context.Context, wf *types.Run, reason types.RunStatus, afterAll *agentHook, killAgents func() error) error {
+	var errs []error
+	killAll := func() {
+		if err := killAgents(); err != nil {
+			o.logger.Error("failed to kill agents during cleanup", "error", err)
+			errs = append(errs, fmt.Errorf("killing agents: %w", err))
+		}
+		if afterAll != nil {
+			if err := o.runAgentHook(ctx, afterAll); err != nil {
+				o.logger.Error("agent lifecycle hook failed", "error", err)
+				errs = append(errs, err)
+			}
+		}
+	}
+	runScripts := func() {
+		if script := wf.GetCleanupScript(reason); script != "" {
+			if err := o.runCleanupScript(ctx, wf, script, reason); err != nil {
+				o.logger.Error("cleanup script failed", "error", err)
+				errs = append(errs, err)
+			}
+		}
+		if err := o.runConditionalCleanups(ctx, wf, reason); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if wf.CleanupOrder == types.CleanupScriptFirst {
+		runScripts()
+		killAll()
+	} else {
+		killAll()
+		runScripts()
+	}
+	return errors.Join(errs...)
+}
+
+// ManualCleanupOptions configure CleanupManually.
+type ManualCleanupOptions struct {
+	Workdir    string       // Directory the cleanup scripts and hooks run in
+	Output     io.Writer    // Receives the output of cleanup scripts and hooks
+	KillAgents func() error // Ends the run's agent sessions
+}
+
+// CleanupManually cleans up a run that ended without cleaning up, for
+// meow cleanup. It follows RunCleanup: opts.KillAgents stands in for the
+// agent manager, and the after_all_agents hook (unless it already ran), the
+// cleanup script for the run's status and its cleanup_when scripts run in the
+// run's cleanup_order. Scripts see MEOW_CLEANUP=manual. The run's status is
+// left as is.
+func CleanupManually(ctx context.Context, store RunStore, wf *types.Run, logger *slog.Logger, opts ManualCleanupOptions) error {
+	o := New(nil, store, nil, nil, nil, logger)
+	o.manualCleanup = &opts
+
+	afterAll := o.claimAfterAllAgents(wf, true)
+	if afterAll != nil {
+		if err := store.Save(ctx, wf); err != nil {
+			return fmt.Errorf("saving workflow: %w", err)
+		}
+	}
+	return o.runCleanupActions(ctx, wf, wf.Status, afterAll, opts.KillAgents)
+}
+
+// prepareCleanupCmd adapts a cleanup script, condition or hook command to a
+// manual cleanup, if this is one.
+func (o *Orchestrator) prepareCleanupCmd(cmd *exec.Cmd) {
+	if o.manualCleanup == nil {
+		return
+	}
+	cmd.Dir = o.manualCleanup.Workdir
+	cmd.Env = append(cmd.Env, "MEOW_CLEANUP=manual")
+	if cmd.Stdout == nil {
+		cmd.Stdout = o.manualCleanup.Output
+	}
+}
+
+// CleanupContext is the run context handed to cleanup scripts, both as
+// environment variables and as {{...}} substitutions in the script text, so a
+// single script can tailor its behavior to how the run ended.
+type CleanupContext struct {
+	run *types.Run
+
+	WorkflowID  string
+	Status      types.RunStatus // Outcome that triggered cleanup: done, failed, or stopped
+	Duration    time.Duration   // Run time so far (or until done_at, if set)
+	StepCount   int
+	FailedSteps []string // IDs of failed steps, sorted
+}
+
+// NewCleanupContext captures the cleanup context for a run ending with status.
+func NewCleanupContext(wf *types.Run, status types.RunStatus, now time.Time) *CleanupContext {
+	end := now
+	if wf.DoneAt != nil {
+		end = *wf.DoneAt
+	}
+	var failed []string
+	for _, id := range sortedKeys(wf.Steps) {
+		if wf.Steps[id].Status == types.StepStatusFailed {
+			failed = append(failed, id)
+		}
+	}
+	return &CleanupContext{
+		run:         wf,
+		WorkflowID:  wf.ID,
+		Status:      status,
+		Duration:    end.Sub(wf.StartedAt).Round(time.Second),
+		StepCount:   len(wf.Steps),
+		FailedSteps: failed,
+	}
+}
+
+// Env returns the script environment: the run's variables plus MEOW_WORKFLOW,
+// MEOW_RUN_STATUS, MEOW_RUN_DURATION, MEOW_STEP_COUNT, and MEOW_FAILED_STEPS
+// (comma-separated).
+func (c *CleanupContext) Env() []string {
+	env := make([]string, 0, len(c.run.Variables)+5)
+	for k, v := range c.run.Variables {
+		env = append(env, fmt.Sprintf("%s=%s", k, workflow.StringifyValue(v)))
+	}
+	return append(env,
+		fmt.Sprintf("MEOW_WORKFLOW=%s", c.WorkflowID),
+		fmt.Sprintf("MEOW_RUN_STATUS=%s", c.Status),
+		fmt.Sprintf("MEOW_RUN_DURATION=%s", c.Duration),
+		fmt.Sprintf("MEOW_STEP_COUNT=%d", c.StepCount),
+		fmt.Sprintf("MEOW_FAILED_STEPS=%s", strings.Join(c.FailedSteps, ",")),
+	)
+}
+
+// Substitute resolves {{...}} references in a cleanup script: run variables,
+// step outputs, and the builtins workflow_id, run_status, run_duration,
+// step_count, and failed_steps. Other references are left as written, so
+// scripts keep literal braces such as docker's --format '{{.Names}}'.
+func (c *CleanupContext) Substitute(script string) (string, error) {
+	vc := workflow.NewVarContext()
+	vc.DeferUndefinedVariables = true
+	for k, v := range c.run.Variables {
+		vc.Set(k, v)
+	}
+	vc.SetBuiltin("workflow_id", c.WorkflowID)
+	vc.SetBuiltin("run_status", string(c.Status))
+	vc.SetBuiltin("run_duration", c.Duration.String())
+	vc.SetBuiltin("step_count", c.StepCount)
+	vc.SetBuiltin("failed_steps", strings.Join(c.FailedSteps, ","))
+	vc.SetStepLookup(func(stepID string) (*workflow.StepInfo, error) {
+		s, ok := c.run.GetStep(stepID)
+		if !ok {
+			return nil, nil // Not found
+		}
+		return &workflow.StepInfo{
+			ID:      s.ID,
+			Status:  string(s.Status),
+			Outputs: s.Outputs,
+		}, nil
+	})
+	return vc.Substitute(script)
+}
+
+// runCleanupScript executes a cleanup script with timeout, with the run
+// context for reason in its environment and substituted into the script.
+func (o *Orchestrator) runCleanupScript(ctx context.Context, wf *types.Run, script string, reason types.RunStatus) error {
 	if script == "" {
 		return nil
 	}
 
+	runCtx := NewCleanupContext(wf, reason, o.now())
+	script, err := runCtx.Substitute(script)
+	if err != nil {
+		return fmt.Errorf("substituting cleanup script: %w", err)
+	}
+
 	// Create a timeout context for cleanup
 	cleanupCtx, cancel := context.WithTimeout(ctx, CleanupTimeout)
 	defer cancel()
@@ -1230,14 +2554,9 @@ func (o *Orchestrator) runCleanupScript(ctx context.Context, wf *types.Run, scri
 
 	// Execute the cleanup script via bash
 	cmd := exec.CommandContext(cleanupCtx, "bash", "-c", script)
-
-	// Set environment variables from workflow
-	cmd.Env = os.Environ()
-	for k, v := range wf.Variables {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, workflow.StringifyValue(v)))
-	}
-	// Add workflow ID as an environment variable
-	cmd.Env = append(cmd.Env, fmt.Sprintf("MEOW_WORKFLOW=%s", wf.ID))
+	killProcessGroupOnCancel(cmd)
+	cmd.Env = append(os.Environ(), runCtx.Env()...)
+	o.prepareCleanupCmd(cmd)
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -1253,6 +2572,56 @@ func (o *Orchestrator) runCleanupScript(ctx context.Context, wf *types.Run, scri
 	return nil
 }
 
+// runConditionalCleanups runs each of the run's cleanup_when scripts whose
+// condition exits 0, after the trigger's own script. A condition that cannot
+// be substituted, such as one naming an output its step never reported, does
+// not hold. Failures are logged and returned together.
+func (o *Orchestrator) runConditionalCleanups(ctx context.Context, wf *types.Run, reason types.RunStatus) error {
+	var errs []error
+	for i, cleanup := range wf.CleanupWhen {
+		met, err := o.cleanupConditionMet(ctx, wf, cleanup.Condition, reason)
+		if err != nil {
+			o.logger.Info("cleanup condition not met", "workflow", wf.ID, "index", i, "error", err)
+			continue
+		}
+		if !met {
+			o.logger.Info("cleanup condition not met", "workflow", wf.ID, "index", i)
+			continue
+		}
+		if err := o.runCleanupScript(ctx, wf, cleanup.Script, reason); err != nil {
+			o.logger.Error("conditional cleanup script failed", "index", i, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cleanupConditionMet runs a cleanup_when condition with the cleanup script
+// context and reports whether it exited 0.
+func (o *Orchestrator) cleanupConditionMet(ctx context.Context, wf *types.Run, condition string, reason types.RunStatus) (bool, error) {
+	runCtx := NewCleanupContext(wf, reason, o.now())
+	condition, err := runCtx.Substitute(condition)
+	if err != nil {
+		return false, fmt.Errorf("substituting cleanup condition: %w", err)
+	}
+
+	condCtx, cancel := context.WithTimeout(ctx, CleanupTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(condCtx, "bash", "-c", condition)
+	killProcessGroupOnCancel(cmd)
+	cmd.Env = append(os.Environ(), runCtx.Env()...)
+	o.prepareCleanupCmd(cmd)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && condCtx.Err() == nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("running cleanup condition: %w", err)
+	}
+	return true, nil
+}
+
 // setupSignalHandler sets up SIGINT/SIGTERM handling.
 // Returns a channel that receives true when a signal is caught.
 func (o *Orchestrator) setupSignalHandler() chan os.Signal {
@@ -1261,18 +2630,55 @@ func (o *Orchestrator) setupSignalHandler() chan os.Signal {
 	return sigChan
 }
 
+// acquireLock takes the exclusive lock for the active workflow.
+// It is a no-op without an active workflow ID, when the store does not support
+// locking, or when the lock is already held (Recover followed by Run).
+func (o *Orchestrator) acquireLock() error {
+	if o.workflowID == "" || o.lock != nil {
+		return nil
+	}
+	locker, ok := o.store.(WorkflowLocker)
+	if !ok {
+		return nil
+	}
+	lock, err := locker.AcquireWorkflowLock(o.workflowID)
+	if err != nil {
+		return err
+	}
+	o.lock = lock
+	return nil
+}
+
+// releaseLock releases the workflow lock if held.
+func (o *Orchestrator) releaseLock() {
+	if o.lock == nil {
+		return
+	}
+	if err := o.lock.Release(); err != nil {
+		o.logger.Warn("releasing workflow lock", "error", err)
+	}
+	o.lock = nil
+}
+
 // --- Crash Recovery ---
 
 // Recover handles crash recovery for workflows on orchestrator startup.
 // Per MVP-SPEC-v2:
-// 1. Load all running workflows
+// 1. Load all running workflows (and paused ones, which stay paused)
 // 2. Handle workflows in cleaning_up state (resume cleanup)
 // 3. For running/completing steps:
 //   - Orchestrator executors: reset to pending
 //   - Expand steps: delete partial child steps, reset to pending
 //   - Agent steps with dead agent: reset to pending
-//   - Agent steps with live agent: keep running (wait for stop hook)
+//   - Agent steps with live agent: keep running (wait for meow done or stop hook)
+//     without re-injecting the prompt; the agent is reattached and its stall
+//     clock re-armed, since both live only in memory
 func (o *Orchestrator) Recover(ctx context.Context) error {
+	// The lock stays held so the following Run keeps ownership of the workflow
+	if err := o.acquireLock(); err != nil {
+		return err
+	}
+
 	o.logger.Info("starting crash recovery")
 
 	// Load all running and cleaning_up workflows
@@ -1281,6 +2687,14 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 		return fmt.Errorf("listing running workflows: %w", err)
 	}
 
+	// Paused workflows have their steps recovered the same way; they stay
+	// paused, so steps reset to pending wait for meow resume
+	pausedWorkflows, err := o.store.List(ctx, RunFilter{Status: types.RunStatusPaused})
+	if err != nil {
+		return fmt.Errorf("listing paused workflows: %w", err)
+	}
+	runningWorkflows = append(runningWorkflows, pausedWorkflows...)
+
 	cleaningUpWorkflows, err := o.store.List(ctx, RunFilter{Status: types.RunStatusCleaningUp})
 	if err != nil {
 		return fmt.Errorf("listing cleaning_up workflows: %w", err)
@@ -1299,13 +2713,20 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 	for _, wf := range runningWorkflows {
 		modified := false
 
-		// First pass: identify partial expansions (steps that expand children and were running)
+		// First pass: identify partial expansions (steps that expand children and were running).
+		// A foreach that finished expanding resumes its iterations in place instead:
+		// done children keep their outputs and in-flight ones are reset below.
 		partialExpands := make(map[string]bool)
+		resumableForeach := make(map[string]bool)
 		for _, step := range wf.Steps {
 			if (step.Executor == types.ExecutorExpand ||
 				step.Executor == types.ExecutorBranch ||
 				step.Executor == types.ExecutorForeach) &&
 				(step.Status == types.StepStatusRunning || step.Status == types.StepStatusCompleting) {
+				if step.Executor == types.ExecutorForeach && IsForeachFullyExpanded(step, wf.Steps) {
+					resumableForeach[step.ID] = true
+					continue
+				}
 				partialExpands[step.ID] = true
 			}
 		}
@@ -1343,6 +2764,7 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 					step.Status = types.StepStatusPending
 					step.StartedAt = nil
 					step.InterruptedAt = nil
+					step.CancelPromptedAt = nil
 					step.Outputs = nil
 					modified = true
 				} else {
@@ -1358,6 +2780,21 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 				continue // Skip the generic orchestrator reset below
 			}
 
+			// Fully expanded foreach: keep running so checkForeachCompletion
+			// joins the kept and re-run iterations
+			if resumableForeach[step.ID] {
+				o.logger.Info("keeping foreach step running (resuming iterations)",
+					"step", step.ID,
+					"childSteps", len(step.ExpandedInto),
+					"completedIterations", CountCompletedIterations(step, wf.Steps),
+					"workflow", wf.ID)
+				if step.Status == types.StepStatusCompleting {
+					step.Status = types.StepStatusRunning
+					modified = true
+				}
+				continue
+			}
+
 			if step.Executor.IsOrchestrator() {
 				// Orchestrator step was mid-execution - reset to pending
 				o.logger.Info("resetting orchestrator step",
@@ -1368,6 +2805,7 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 				step.Status = types.StepStatusPending
 				step.StartedAt = nil
 				step.InterruptedAt = nil
+				step.CancelPromptedAt = nil
 				// Clear ExpandedInto for steps that expand (expand, branch, foreach)
 				if step.Executor == types.ExecutorExpand ||
 					step.Executor == types.ExecutorBranch ||
@@ -1379,6 +2817,14 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 				// Check if agent is still alive
 				var agentAlive bool
 				if o.agents != nil && step.Agent != nil {
+					if reattacher, ok := o.agents.(AgentReattacher); ok {
+						if err := reattacher.Reattach(ctx, wf, step.Agent.Agent); err != nil {
+							o.logger.Warn("failed to reattach agent",
+								"step", step.ID,
+								"agent", step.Agent.Agent,
+								"error", err)
+						}
+					}
 					agentAlive, _ = o.agents.IsRunning(ctx, step.Agent.Agent)
 				}
 
@@ -1391,6 +2837,7 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 					step.Status = types.StepStatusPending
 					step.StartedAt = nil
 					step.InterruptedAt = nil
+					step.CancelPromptedAt = nil
 					modified = true
 				} else {
 					// Agent still alive - keep running
@@ -1405,10 +2852,18 @@ func (o *Orchestrator) Recover(ctx context.Context) error {
 						step.Status = types.StepStatusRunning
 						modified = true
 					}
+					// Silence measured from StartedAt would report a stall on
+					// the first tick; count the restart as activity instead
+					o.RecordAgentActivity(wf.ID, step.Agent.Agent)
 				}
 			}
 		}
 
+		// Completed steps whose input files changed since are stale
+		if o.rerunStaleSteps(wf) {
+			modified = true
+		}
+
 		if modified {
 			if err := o.store.Save(ctx, wf); err != nil {
 				o.logger.Error("failed to save workflow after recovery",
@@ -1439,10 +2894,11 @@ func (o *Orchestrator) resumeCleanup(ctx context.Context, wf *types.Run) error {
 
 	// Run cleanup script again (should be idempotent)
 	if cleanupScript != "" {
-		if err := o.runCleanupScript(ctx, wf, cleanupScript); err != nil {
+		if err := o.runCleanupScript(ctx, wf, cleanupScript, wf.PriorStatus); err != nil {
 			o.logger.Warn("cleanup script failed during resume", "error", err)
 		}
 	}
+	_ = o.runConditionalCleanups(ctx, wf, wf.PriorStatus)
 
 	// Set final status
 	wf.FinishCleanup()
@@ -1469,6 +2925,8 @@ func (o *Orchestrator) completeBranchCondition(
 	result *ShellResult,
 	cfg *types.BranchConfig,
 ) {
+	logger := o.stepLogger(ctx)
+
 	// Acquire mutex for state mutation
 	o.wfMu.Lock()
 	defer o.wfMu.Unlock()
@@ -1476,7 +2934,7 @@ func (o *Orchestrator) completeBranchCondition(
 	// Re-fetch workflow to get fresh state
 	wf, err := o.store.Get(ctx, workflowID)
 	if err != nil {
-		o.logger.Error("re-fetching workflow after command", "error", err)
+		logger.Error("re-fetching workflow after command", "error", err)
 		return
 	}
 	if wf == nil || wf.Status.IsTerminal() {
@@ -1488,22 +2946,13 @@ func (o *Orchestrator) completeBranchCondition(
 		return
 	}
 
-	// Handle expansion for branch with targets
-	if target != nil {
-		if err := o.expandBranchTarget(ctx, wf, step, target); err != nil {
-			if failErr := step.Fail(&types.StepError{Message: fmt.Sprintf("expansion failed: %v", err)}); failErr != nil {
-				o.logger.Error("failed to mark step as failed", "step", stepID, "error", failErr)
-			}
-			o.store.Save(ctx, wf)
-			return
-		}
-	}
-
 	// Build outputs - capture per cfg.Outputs definitions
 	outputs := map[string]any{
-		"outcome":   string(outcome),
-		"exit_code": result.ExitCode,
+		"outcome":     string(outcome),
+		"exit_code":   result.ExitCode,
+		"duration_ms": result.Duration.Milliseconds(),
 	}
+	addUsageOutputs(outputs, result.Usage)
 
 	// Capture defined outputs (stdout, stderr, file:path)
 	// Create a source substitution function that resolves step output references
@@ -1524,15 +2973,18 @@ func (o *Orchestrator) completeBranchCondition(
 		return vc.Substitute(source)
 	}
 
-	if cfg.Outputs != nil {
-		for name, source := range cfg.Outputs {
-			value, err := captureOutput(source, result, substituteSource)
-			if err != nil {
-				o.logger.Warn("output capture failed", "name", name, "error", err)
-				outputs[name] = nil
-			} else {
-				outputs[name] = value
+	var captureFailures []string
+	for _, name := range sortedKeys(cfg.Outputs) {
+		source := cfg.Outputs[name]
+		value, err := captureOutput(source, result, substituteSource)
+		if err != nil {
+			logger.Warn("output capture failed", "name", name, "error", err)
+			outputs[name] = nil
+			if source.Required {
+				captureFailures = append(captureFailures, fmt.Sprintf("%s (source %s): %v", name, source.Source, err))
 			}
+		} else {
+			outputs[name] = value
 		}
 	}
 
@@ -1541,28 +2993,94 @@ func (o *Orchestrator) completeBranchCondition(
 	if target == nil && result.ExitCode != 0 {
 		if cfg.OnError != "continue" {
 			// Default to fail
-			if failErr := step.Fail(&types.StepError{
-				Message: "command failed",
-				Code:    result.ExitCode,
-				Output:  result.Stderr,
-			}); failErr != nil {
-				o.logger.Error("failed to mark step as failed", "step", stepID, "error", failErr)
+			stepErr := types.NewCommandError("command failed", cfg.Condition, result.ExitCode, result.Stdout, result.Stderr)
+			stepErr.Type = o.shellErrorType(result.Stderr)
+			if outcome == BranchOutcomeTimeout {
+				stepErr.Message = fmt.Sprintf("command timed out after %s", cfg.Timeout)
+				stepErr.Type = types.StepErrorTimeout
 			}
+			if failErr := step.Fail(stepErr); failErr != nil {
+				logger.Error("failed to mark step as failed", "step", stepID, "error", failErr)
+			}
+			o.recordStepFinished(wf.ID, step)
 			o.store.Save(ctx, wf)
 			return
 		}
 		// on_error: continue - include error info in outputs
 		outputs["error"] = result.Stderr
+		outputs["error_type"] = string(o.shellErrorType(result.Stderr))
+	}
+
+	// A required output that could not be captured fails the step rather
+	// than completing it with an empty value
+	if len(captureFailures) > 0 {
+		stepErr := types.NewCommandError(
+			"required output capture failed: "+strings.Join(captureFailures, "; "),
+			cfg.Condition, result.ExitCode, result.Stdout, result.Stderr)
+		stepErr.Type = types.StepErrorOutputCapture
+		if failErr := step.Fail(stepErr); failErr != nil {
+			logger.Error("failed to mark step as failed", "step", stepID, "error", failErr)
+		}
+		o.recordStepFinished(wf.ID, step)
+		o.store.Save(ctx, wf)
+		return
+	}
+
+	// Self-checking steps: captured outputs must satisfy the assert table
+	if assertErr := checkOutputAssertions(step.Assert, outputs); assertErr != nil {
+		stepErr := types.NewCommandError(assertErr.Message, cfg.Condition, result.ExitCode, result.Stdout, result.Stderr)
+		stepErr.Type = assertErr.Type
+		stepErr.Output = assertErr.Output
+		logger.Warn("output assertion failed", "step", stepID, "diff", assertErr.Output)
+		if failErr := step.Fail(stepErr); failErr != nil {
+			logger.Error("failed to mark step as failed", "step", stepID, "error", failErr)
+		}
+		o.recordStepFinished(wf.ID, step)
+		o.store.Save(ctx, wf)
+		return
+	}
+
+	// Handle expansion for branch with targets. The condition's outputs are
+	// published first, so the target's variables and steps can use them.
+	if target != nil {
+		step.Outputs = outputs
+		// Resolve into a copy: the step's own config stays as written, so a
+		// retry or resume resolves against its fresh outputs
+		resolved := *target
+		resolved.Variables = make(map[string]any, len(target.Variables))
+		for k, v := range target.Variables {
+			if str, ok := v.(string); ok {
+				v = o.resolveOutputRefs(wf, str, stepID)
+			}
+			resolved.Variables[k] = v
+		}
+		if err := o.expandBranchTarget(ctx, wf, step, &resolved); err != nil {
+			if failErr := step.Fail(&types.StepError{
+				Message: fmt.Sprintf("expansion failed: %v", err),
+				Type:    types.StepErrorExpansionFailed,
+			}); failErr != nil {
+				logger.Error("failed to mark step as failed", "step", stepID, "error", failErr)
+			}
+			o.recordStepFinished(wf.ID, step)
+			o.store.Save(ctx, wf)
+			return
+		}
+	}
+
+	if result.ExitCode == 0 {
+		o.persistShellArtifacts(wf, step, cfg.Outputs, outputs, substituteSource)
 	}
 
 	// Complete or stay running based on children
+	outputs = o.applyOutputBudget(wf, step, outputs)
 	if len(step.ExpandedInto) > 0 {
 		step.Outputs = outputs
 	} else {
 		if err := step.Complete(outputs); err != nil {
-			o.logger.Error("failed to complete step", "step", stepID, "error", err)
+			logger.Error("failed to complete step", "step", stepID, "error", err)
 			return
 		}
+		o.recordStepFinished(wf.ID, step)
 	}
 
 	o.store.Save(ctx, wf)
@@ -1585,6 +3103,10 @@ func (o *Orchestrator) handleShell(ctx context.Context, wf *types.Run, step *typ
 		Env:       step.Shell.Env,
 		Outputs:   step.Shell.Outputs,
 		OnError:   step.Shell.OnError,
+		RunAs:     step.Shell.RunAs,
+
+		CancelSignal: step.Shell.CancelSignal,
+		CancelGrace:  step.Shell.CancelGrace,
 		// No on_true/on_false → just run, capture outputs, complete
 	}
 
@@ -1609,20 +3131,73 @@ func (o *Orchestrator) handleSpawn(ctx context.Context, wf *types.Run, step *typ
 		return fmt.Errorf("spawn executor not implemented: %w", ErrNotImplemented)
 	}
 
-	if err := o.agents.Start(ctx, wf, step); err != nil {
-		return fmt.Errorf("starting agent: %w", err)
+	if err := o.runBeforeAllAgents(ctx, wf); err != nil {
+		return err
+	}
+
+	// A spawn with reuse_from adopts the prior run's agent when it can and
+	// reports which happened as the "reused" output
+	var outputs map[string]any
+	reused := false
+	if step.Spawn.ReuseFrom != "" {
+		var err error
+		reused, err = o.reuseAgent(ctx, wf, step)
+		if err != nil {
+			o.logger.Warn("cannot reuse agent, spawning a new one",
+				"step", step.ID, "agent", step.Spawn.Agent, "reuse_from", step.Spawn.ReuseFrom, "error", err)
+		} else if reused {
+			o.logger.Info("reused agent", "step", step.ID, "agent", step.Spawn.Agent, "from", step.Spawn.ReuseFrom)
+		}
+		outputs = map[string]any{"reused": reused}
+	}
+
+	if !reused {
+		if err := o.agents.Start(ctx, wf, step); err != nil {
+			return fmt.Errorf("starting agent: %w", err)
+		}
+	}
+	if len(step.Spawn.Capabilities) > 0 {
+		wf.SetAgentCapabilities(step.Spawn.Agent, step.Spawn.Capabilities)
 	}
 
 	// Spawn completes when agent is running
-	if err := step.Complete(nil); err != nil {
+	if err := step.Complete(outputs); err != nil {
 		return fmt.Errorf("completing step: %w", err)
 	}
 	return nil
 }
 
-// handleKill stops an agent's tmux session.
-// Runs asynchronously to avoid blocking parallel step dispatch.
+// reuseAgent adopts the spawn step's agent from the run named by reuse_from,
+// looked up in the store. The prior run must have finished, so the agent is
+// not shared with a run still using it, and its session must still be alive.
+func (o *Orchestrator) reuseAgent(ctx context.Context, wf *types.Run, step *types.Step) (bool, error) {
+	adopter, ok := o.agents.(AgentAdopter)
+	if !ok {
+		return false, fmt.Errorf("agent manager cannot adopt agents")
+	}
+
+	prior, err := o.store.Get(ctx, step.Spawn.ReuseFrom)
+	if err != nil {
+		return false, fmt.Errorf("loading run %s: %w", step.Spawn.ReuseFrom, err)
+	}
+	if prior == nil {
+		return false, fmt.Errorf("run %s not found", step.Spawn.ReuseFrom)
+	}
+	if !prior.Status.IsTerminal() {
+		return false, fmt.Errorf("run %s is still %s", prior.ID, prior.Status)
+	}
+	info, ok := prior.Agents[step.Spawn.Agent]
+	if !ok {
+		return false, fmt.Errorf("run %s has no agent %s", prior.ID, step.Spawn.Agent)
+	}
+	return adopter.Adopt(ctx, wf, step.Spawn.Agent, info)
+}
+
+// handleKill stops an agent's tmux session and records how it stopped as the
+// step's "stopped" output. Runs asynchronously to avoid blocking parallel step dispatch.
 func (o *Orchestrator) handleKill(ctx context.Context, wf *types.Run, step *types.Step) error {
+	logger := o.stepLogger(ctx)
+
 	if step.Kill == nil {
 		return fmt.Errorf("kill step %s missing config", step.ID)
 	}
@@ -1644,8 +3219,21 @@ func (o *Orchestrator) handleKill(ctx context.Context, wf *types.Run, step *type
 	go func() {
 		defer o.wg.Done()
 
+		// Check liveness first so killing an agent that already exited
+		// (e.g., crashed) is idempotent rather than failing the workflow
+		agentID := step.Kill.Agent
+		wasRunning, runErr := o.agents.IsRunning(ctx, agentID)
+
 		// The actual stop operation doesn't need the lock
-		stopErr := o.agents.Stop(ctx, wf, step)
+		outcome, stopErr := o.agents.Stop(ctx, wf, step)
+		if stopErr != nil && runErr == nil && !wasRunning {
+			logger.Info("agent already stopped, treating kill as done",
+				"step", stepID, "agent", agentID, "error", stopErr)
+			stopErr = nil
+		}
+		if runErr == nil && !wasRunning {
+			outcome = StopNotRunning
+		}
 
 		// Lock when modifying workflow state
 		o.wfMu.Lock()
@@ -1654,33 +3242,47 @@ func (o *Orchestrator) handleKill(ctx context.Context, wf *types.Run, step *type
 		// Re-fetch workflow to get latest state (avoid overwriting other changes)
 		freshWf, err := o.store.Get(ctx, workflowID)
 		if err != nil {
-			o.logger.Error("re-fetching workflow after kill", "error", err)
+			logger.Error("re-fetching workflow after kill", "error", err)
 			return
 		}
 		if freshWf == nil {
-			o.logger.Error("workflow not found after kill", "workflow", workflowID)
+			logger.Error("workflow not found after kill", "workflow", workflowID)
 			return
 		}
 
 		// Find the step in the fresh workflow
 		freshStep, ok := freshWf.GetStep(stepID)
 		if !ok {
-			o.logger.Error("step not found after kill", "step", stepID)
+			logger.Error("step not found after kill", "step", stepID)
 			return
 		}
 
 		if stopErr != nil {
-			o.logger.Error("kill step failed", "step", stepID, "error", stopErr)
+			logger.Error("kill step failed", "step", stepID, "error", stopErr)
 			freshStep.Fail(&types.StepError{Message: stopErr.Error()})
 		} else {
-			if err := freshStep.Complete(nil); err != nil {
-				o.logger.Error("completing kill step", "step", stepID, "error", err)
+			if err := freshStep.Complete(map[string]any{"stopped": string(outcome)}); err != nil {
+				logger.Error("completing kill step", "step", stepID, "error", err)
 			}
 		}
+		o.recordStepFinished(workflowID, freshStep)
+
+		// Claim the after-all hook before saving so no other kill runs it too
+		afterAll := o.claimAfterAllAgents(freshWf, false)
 
 		// Save workflow state after step completes
 		if err := o.store.Save(ctx, freshWf); err != nil {
-			o.logger.Error("saving workflow after kill", "error", err)
+			logger.Error("saving workflow after kill", "error", err)
+		}
+
+		if afterAll != nil {
+			o.wg.Add(1)
+			go func() {
+				defer o.wg.Done()
+				if err := o.runAgentHook(ctx, afterAll); err != nil {
+					logger.Error("agent lifecycle hook failed", "error", err)
+				}
+			}()
 		}
 	}()
 
@@ -1712,8 +3314,12 @@ func (o *Orchestrator) handleExpand(ctx context.Context, wf *types.Run, step *ty
 	return nil
 }
 
-// handleForeach expands a template for each item in a list.
+// handleForeach expands a template for each item in a list. A joined foreach
+// with a window expands only that many iterations here; advanceForeachWindows
+// expands the rest as they finish.
 func (o *Orchestrator) handleForeach(ctx context.Context, wf *types.Run, step *types.Step) error {
+	logger := o.stepLogger(ctx)
+
 	if step.Foreach == nil {
 		return fmt.Errorf("foreach step %s missing config", step.ID)
 	}
@@ -1722,28 +3328,17 @@ func (o *Orchestrator) handleForeach(ctx context.Context, wf *types.Run, step *t
 		return fmt.Errorf("starting step: %w", err)
 	}
 
-	// Build template loader from the expander
-	// Use step's SourceModule if set (for nested foreach), otherwise workflow template
-	sourceModule := step.SourceModule
-	if sourceModule == "" {
-		sourceModule = wf.Template
-	}
-	var loader TemplateLoader
-	if o.expander != nil {
-		if adapter, ok := o.expander.(*TemplateExpanderAdapter); ok {
-			loader = &fileTemplateLoader{
-				expander:     adapter.Expander,
-				sourceModule: sourceModule,
-				workflowID:   wf.ID,
-			}
-		}
-	}
+	loader := o.foreachLoader(wf, step)
 	if loader == nil {
 		return fmt.Errorf("foreach executor requires template loader")
 	}
 
 	// Execute the foreach expansion
-	result, stepErr := ExecuteForeach(ctx, step, loader, wf.Variables, 0, nil)
+	window := 0
+	if step.Foreach.IsJoin() {
+		window = step.Foreach.GetWindow()
+	}
+	result, stepErr := expandForeachIterations(ctx, step, loader, wf.Variables, 0, nil, 0, window)
 	if stepErr != nil {
 		return fmt.Errorf("foreach expansion failed: %s", stepErr.Message)
 	}
@@ -1763,17 +3358,18 @@ func (o *Orchestrator) handleForeach(ctx context.Context, wf *types.Run, step *t
 	if step.Foreach.IsJoin() {
 		// Implicit join: step stays running until all children complete
 		// The orchestrator will mark it done when IsForeachComplete returns true
-		o.logger.Info("foreach expansion complete, waiting for children",
+		logger.Info("foreach expansion complete, waiting for children",
 			"step", step.ID,
 			"iterations", len(result.IterationIDs),
-			"childSteps", len(result.ExpandedSteps))
+			"childSteps", len(result.ExpandedSteps),
+			"remaining", result.Remaining)
 		// Step stays in "running" state - main loop will check for completion
 	} else {
 		// Fire-and-forget: mark done immediately after expansion
 		if err := step.Complete(nil); err != nil {
 			return fmt.Errorf("completing step: %w", err)
 		}
-		o.logger.Info("foreach expansion complete (fire-and-forget)",
+		logger.Info("foreach expansion complete (fire-and-forget)",
 			"step", step.ID,
 			"iterations", len(result.IterationIDs),
 			"childSteps", len(result.ExpandedSteps))
@@ -1782,6 +3378,25 @@ func (o *Orchestrator) handleForeach(ctx context.Context, wf *types.Run, step *t
 	return nil
 }
 
+// foreachLoader returns the loader for a foreach step's template, or nil
+// without a file template expander.
+func (o *Orchestrator) foreachLoader(wf *types.Run, step *types.Step) TemplateLoader {
+	// Use step's SourceModule if set (for nested foreach), otherwise workflow template
+	sourceModule := step.SourceModule
+	if sourceModule == "" {
+		sourceModule = wf.Template
+	}
+	adapter, ok := o.expander.(*TemplateExpanderAdapter)
+	if !ok {
+		return nil
+	}
+	return &fileTemplateLoader{
+		expander:     adapter.Expander,
+		sourceModule: sourceModule,
+		workflowID:   wf.ID,
+	}
+}
+
 // handleBranch evaluates a condition and expands the appropriate branch.
 // Launches condition execution asynchronously and returns immediately.
 func (o *Orchestrator) handleBranch(ctx context.Context, wf *types.Run, step *types.Step) error {
@@ -1795,6 +3410,27 @@ func (o *Orchestrator) handleBranch(ctx context.Context, wf *types.Run, step *ty
 
 	cfg := step.Branch
 	condition := o.resolveOutputRefs(wf, cfg.Condition, step.ID)
+	// Environment values are substituted only into what runs, never saved
+	condition, err := resolveEnvRefs(condition)
+	if err != nil {
+		return err
+	}
+
+	// Fail now, with the reason, if the command cannot run as its user
+	if cfg.RunAs != "" {
+		if _, _, err := runAsCredential(cfg.RunAs); err != nil {
+			return err
+		}
+	}
+	cancelGrace := cfg.CancelGrace
+	if cfg.CancelSignal != "" {
+		if _, err := types.ParseCancelSignal(cfg.CancelSignal); err != nil {
+			return err
+		}
+		if cancelGrace == "" {
+			cancelGrace = o.timeoutGracePeriod().String()
+		}
+	}
 
 	// Capture IDs by value for goroutine (NOT pointers!)
 	workflowID := wf.ID
@@ -1821,7 +3457,7 @@ func (o *Orchestrator) handleBranch(ctx context.Context, wf *types.Run, step *ty
 	o.wg.Add(1)
 	go func() {
 		defer o.wg.Done()
-		o.executeBranchConditionAsync(condCtx, workflowID, stepID, condition, cfg)
+		o.executeBranchConditionAsync(condCtx, workflowID, stepID, condition, cfg, cancelGrace)
 	}()
 
 	// Step is running, return immediately
@@ -1837,7 +3473,10 @@ func (o *Orchestrator) executeBranchConditionAsync(
 	stepID string,
 	condition string,
 	cfg *types.BranchConfig,
+	cancelGrace string,
 ) {
+	logger := o.stepLogger(ctx)
+
 	// Clean up tracking regardless of outcome
 	defer o.pendingCommands.Delete(workflowID + ":" + stepID)
 
@@ -1848,15 +3487,23 @@ func (o *Orchestrator) executeBranchConditionAsync(
 		SocketPath: ipc.SocketPath(workflowID),
 		WorkflowID: workflowID,
 		StepID:     stepID,
+		RunAs:      cfg.RunAs,
+
+		CancelSignal: cfg.CancelSignal,
+		CancelGrace:  cancelGrace,
 	}
+	logger.Debug("running condition", "step", stepID, "command", cfg.Condition)
+	started := time.Now()
 	exitCode, stdout, stderr, execErr := condExec.Execute(ctx, condition)
+	duration := time.Since(started)
 
 	// Check for context cancellation (workflow stopped/shutdown)
 	if ctx.Err() == context.Canceled {
-		o.logger.Info("branch condition cancelled",
+		logger.Info("branch condition cancelled",
 			"step", stepID,
 			"reason", "context cancelled")
-		// Don't complete - workflow is stopping
+		// Don't complete - workflow is stopping - but keep what the command printed
+		o.recordInterruptedCondition(workflowID, stepID, condition, stdout, stderr)
 		return
 	}
 
@@ -1872,13 +3519,13 @@ func (o *Orchestrator) executeBranchConditionAsync(
 			if target == nil {
 				target = cfg.OnFalse // Fallback per spec
 			}
-			o.logger.Info("branch condition timed out",
+			logger.Info("branch condition timed out",
 				"step", stepID)
 		} else {
 			// Execution error (command failed to run, not non-zero exit)
 			outcome = BranchOutcomeFalse
 			target = cfg.OnFalse
-			o.logger.Warn("branch condition execution error",
+			logger.Warn("branch condition execution error",
 				"step", stepID,
 				"error", execErr)
 		}
@@ -1892,10 +3539,11 @@ func (o *Orchestrator) executeBranchConditionAsync(
 		target = cfg.OnFalse
 	}
 
-	o.logger.Info("branch condition completed",
+	logger.Info("branch condition completed",
 		"step", stepID,
 		"outcome", outcome,
 		"exitCode", exitCode,
+		"duration", duration,
 		"hasTarget", target != nil)
 
 	// Complete the branch (acquires mutex, updates state, saves)
@@ -1903,10 +3551,40 @@ func (o *Orchestrator) executeBranchConditionAsync(
 		ExitCode: exitCode,
 		Stdout:   stdout,
 		Stderr:   stderr,
+		Duration: duration,
+		Usage:    condExec.Usage,
 	}
 	o.completeBranchCondition(ctx, workflowID, stepID, outcome, target, result, cfg)
 }
 
+// recordInterruptedCondition stores the partial output of a cancelled branch
+// condition on its step so diagnostics survive shutdown. The step stays running
+// (recovery resets it to pending); only InterruptedAt and Error are set.
+func (o *Orchestrator) recordInterruptedCondition(workflowID, stepID, condition, stdout, stderr string) {
+	o.wfMu.Lock()
+	defer o.wfMu.Unlock()
+
+	// The condition's context is already cancelled
+	ctx := context.Background()
+	wf, err := o.store.Get(ctx, workflowID)
+	if err != nil || wf == nil {
+		o.logger.Error("re-fetching workflow after cancelled command", "error", err)
+		return
+	}
+
+	step, ok := wf.GetStep(stepID)
+	if !ok || step.Status != types.StepStatusRunning {
+		return
+	}
+
+	now := time.Now()
+	step.InterruptedAt = &now
+	step.Error = types.NewInterruptedError(condition, stdout, stderr)
+	if err := o.store.Save(ctx, wf); err != nil {
+		o.logger.Error("failed to save interrupted step output", "step", stepID, "error", err)
+	}
+}
+
 // cancelPendingCommands cancels all in-flight async command executions.
 // Called during cleanup to ensure condition goroutines exit promptly.
 //
@@ -2053,8 +3731,30 @@ func (o *Orchestrator) resolveOutputRefs(wf *types.Run, s string, currentStepID
 	})
 }
 
+// agentInjectOpts builds injection options carrying the step's timing overrides.
+func agentInjectOpts(cfg *types.AgentConfig) (InjectPromptOpts, error) {
+	var opts InjectPromptOpts
+	if cfg.PreDelay != "" {
+		d, err := time.ParseDuration(cfg.PreDelay)
+		if err != nil {
+			return opts, fmt.Errorf("invalid pre_delay %q: %w", cfg.PreDelay, err)
+		}
+		opts.PreDelay = d
+	}
+	if cfg.PostDelay != "" {
+		d, err := time.ParseDuration(cfg.PostDelay)
+		if err != nil {
+			return opts, fmt.Errorf("invalid post_delay %q: %w", cfg.PostDelay, err)
+		}
+		opts.PostDelay = d
+	}
+	return opts, nil
+}
+
 // handleAgent injects a prompt into an agent.
 func (o *Orchestrator) handleAgent(ctx context.Context, wf *types.Run, step *types.Step) error {
+	logger := o.stepLogger(ctx)
+
 	if step.Agent == nil {
 		return fmt.Errorf("agent step %s missing config", step.ID)
 	}
@@ -2072,6 +3772,7 @@ func (o *Orchestrator) handleAgent(ctx context.Context, wf *types.Run, step *typ
 	if stepErr != nil {
 		return fmt.Errorf("building agent prompt: %s", stepErr.Message)
 	}
+	prompt := wrapAgentPrompt(result.Prompt, wf, step.Agent)
 
 	// Determine if this is a subsequent prompt (agent has completed previous steps)
 	// Subsequent prompts need stabilization to ensure the agent is idle.
@@ -2079,15 +3780,33 @@ func (o *Orchestrator) handleAgent(ctx context.Context, wf *types.Run, step *typ
 	isSubsequent := wf.AgentHasCompletedSteps(step.Agent.Agent)
 	stabilize := isSubsequent && !IsFireForget(step.Agent)
 
+	injectOpts, err := agentInjectOpts(step.Agent)
+	if err != nil {
+		return fmt.Errorf("agent step %s: %w", step.ID, err)
+	}
+	injectOpts.Stabilize = stabilize
+
+	logger.Debug("injecting prompt",
+		"step", step.ID,
+		"agent", step.Agent.Agent,
+		"stabilize", stabilize,
+		"pre_delay", injectOpts.PreDelay,
+		"post_delay", injectOpts.PostDelay,
+		"prompt_bytes", len(prompt))
+
+	// Outputs read from the agent's log only consider what it logs from here on
+	o.markAgentLogOffset(step)
+
 	// Inject prompt to agent's tmux session
-	if err := o.agents.InjectPrompt(ctx, step.Agent.Agent, result.Prompt, InjectPromptOpts{
-		Stabilize: stabilize,
-	}); err != nil {
+	span := o.tracing.startAgentSpan(wf, step, "agent.inject_prompt")
+	err = o.agents.InjectPrompt(ctx, step.Agent.Agent, prompt, injectOpts)
+	endSpan(span, err)
+	if err != nil {
 		// Check if agent session is still alive
 		alive, _ := o.agents.IsRunning(ctx, step.Agent.Agent)
 		if alive {
 			// Transient error (e.g., tmux 'not in a mode') — reset to pending for retry
-			o.logger.Warn("prompt injection failed, resetting step to pending for retry",
+			logger.Warn("prompt injection failed, resetting step to pending for retry",
 				"step", step.ID, "agent", step.Agent.Agent, "error", err)
 			if resetErr := step.ResetToPending(); resetErr != nil {
 				return fmt.Errorf("resetting step after injection failure: %w", resetErr)
@@ -2103,7 +3822,7 @@ func (o *Orchestrator) handleAgent(ctx context.Context, wf *types.Run, step *typ
 	o.wg.Add(1)
 	go func() {
 		defer o.wg.Done()
-		o.waitForPromptAcknowledgmentWithRecovery(ctx, step.Agent.Agent, step.ID, result.Prompt, 5*time.Second)
+		o.waitForPromptAcknowledgmentWithRecovery(ctx, step.Agent.Agent, step.ID, prompt, injectOpts, 5*time.Second)
 	}()
 
 	// Fire-and-forget mode: complete immediately after injection
@@ -2111,7 +3830,7 @@ func (o *Orchestrator) handleAgent(ctx context.Context, wf *types.Run, step *typ
 		if err := step.Complete(nil); err != nil {
 			return fmt.Errorf("completing fire-forget step: %w", err)
 		}
-		o.logger.Info("fire-forget step completed", "step", step.ID)
+		logger.Info("fire-forget step completed", "step", step.ID)
 		return nil
 	}
 
@@ -1,10 +1,15 @@
 package orchestrator
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -12,6 +17,7 @@ import (
 
 	"github.com/akatz-ai/meow/internal/config"
 	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/logging"
 	"github.com/akatz-ai/meow/internal/types"
 )
 
@@ -67,7 +73,7 @@ func (m *mockRunStore) List(ctx context.Context, filter RunFilter) ([]*types.Run
 	m.calls = append(m.calls, "List")
 	var result []*types.Run
 	for _, wf := range m.workflows {
-		if filter.Status != "" && wf.Status != filter.Status {
+		if !filter.Matches(wf) {
 			continue
 		}
 		result = append(result, wf)
@@ -75,6 +81,20 @@ func (m *mockRunStore) List(ctx context.Context, filter RunFilter) ([]*types.Run
 	return result, nil
 }
 
+func (m *mockRunStore) ListByTemplate(ctx context.Context, template string) ([]*types.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, "ListByTemplate:"+template)
+	filter := RunFilter{Template: template}
+	var result []*types.Run
+	for _, wf := range m.workflows {
+		if filter.Matches(wf) {
+			result = append(result, wf)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockRunStore) GetByAgent(ctx context.Context, agentID string) ([]*types.Run, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -97,6 +117,8 @@ type injectedPromptRecord struct {
 	AgentID   string
 	Prompt    string
 	Stabilize bool
+	PostDelay time.Duration
+	At        time.Time
 }
 
 type mockAgentManager struct {
@@ -110,6 +132,16 @@ type mockAgentManager struct {
 	injections []injectedPromptRecord
 	// injectErr if set, InjectPrompt returns this error
 	injectErr error
+	// stopErr if set, Stop returns this error
+	stopErr error
+	// stopOutcome is how Stop reports the agent stopped (graceful if unset)
+	stopOutcome StopOutcome
+	// transcripts holds the pane content CaptureTranscript returns per agent
+	transcripts map[string]string
+	// exits holds the exit codes of agents whose process has exited
+	exits map[string]int
+	// logs holds the output log ReadAgentLog returns per agent
+	logs map[string]string
 }
 
 func newMockAgentManager() *mockAgentManager {
@@ -127,13 +159,16 @@ func (m *mockAgentManager) Start(ctx context.Context, wf *types.Run, step *types
 	return nil
 }
 
-func (m *mockAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.Step) error {
+func (m *mockAgentManager) Stop(ctx context.Context, wf *types.Run, step *types.Step) (StopOutcome, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	agentID := step.Kill.Agent
 	m.stopped = append(m.stopped, agentID)
 	m.running[agentID] = false
-	return nil
+	if m.stopOutcome == "" {
+		return StopGraceful, m.stopErr
+	}
+	return m.stopOutcome, m.stopErr
 }
 
 func (m *mockAgentManager) IsRunning(ctx context.Context, agentID string) (bool, error) {
@@ -150,6 +185,8 @@ func (m *mockAgentManager) InjectPrompt(ctx context.Context, agentID string, pro
 		AgentID:   agentID,
 		Prompt:    prompt,
 		Stabilize: opts.Stabilize,
+		PostDelay: opts.PostDelay,
+		At:        time.Now(),
 	})
 	if m.injectErr != nil {
 		return m.injectErr
@@ -173,6 +210,50 @@ func (m *mockAgentManager) Interrupt(ctx context.Context, agentID string) error
 	return nil
 }
 
+// Adopt takes over agentID when the mock considers it running.
+func (m *mockAgentManager) Adopt(ctx context.Context, wf *types.Run, agentID string, prior *types.AgentInfo) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running[agentID] {
+		return false, nil
+	}
+	wf.RegisterAgent(agentID, &types.AgentInfo{TmuxSession: prior.TmuxSession, Status: "active", Workdir: prior.Workdir})
+	return true, nil
+}
+
+func (m *mockAgentManager) CaptureTranscript(ctx context.Context, agentID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transcript, ok := m.transcripts[agentID]
+	if !ok {
+		return "", fmt.Errorf("agent %s not found", agentID)
+	}
+	return transcript, nil
+}
+
+func (m *mockAgentManager) ExitStatus(ctx context.Context, agentID string) (bool, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code, ok := m.exits[agentID]
+	return ok, code, nil
+}
+
+func (m *mockAgentManager) AgentLogSize(agentID string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.logs[agentID])), nil
+}
+
+func (m *mockAgentManager) ReadAgentLog(agentID string, offset int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	log, ok := m.logs[agentID]
+	if !ok {
+		return nil, fmt.Errorf("agent %s has no log", agentID)
+	}
+	return []byte(log[offset:]), nil
+}
+
 func (m *mockAgentManager) KillAll(ctx context.Context, wf *types.Run) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -301,6 +382,229 @@ func TestOrchestrator_SingleWorkflow_CompletesAllSteps(t *testing.T) {
 	}
 }
 
+func TestOrchestrator_WorkflowOutputs(t *testing.T) {
+	tests := []struct {
+		name        string
+		outputDefs  map[string]string
+		wantStatus  types.RunStatus
+		wantOutputs map[string]any
+		wantErr     string
+	}{
+		{
+			name:        "mapped from step output",
+			outputDefs:  map[string]string{"version": "{{build.outputs.version}}", "tag": "v{{build.outputs.version}}"},
+			wantStatus:  types.RunStatusDone,
+			wantOutputs: map[string]any{"version": "1.2.3", "tag": "v1.2.3"},
+		},
+		{
+			name:        "missing source",
+			outputDefs:  map[string]string{"version": "{{build.outputs.version}}", "digest": "{{build.outputs.digest}}"},
+			wantStatus:  types.RunStatusFailed,
+			wantOutputs: map[string]any{"version": "1.2.3"},
+			wantErr:     "digest",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockRunStore()
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.OutputDefs = tc.outputDefs
+			wf.Steps["build"] = &types.Step{
+				ID:       "build",
+				Executor: types.ExecutorShell,
+				Status:   types.StepStatusDone,
+				Shell:    &types.ShellConfig{Command: "make"},
+				Outputs:  map[string]any{"version": "1.2.3"},
+			}
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			orch.SetWorkflowID(wf.ID)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			if err := orch.Run(ctx); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if wf.Status != tc.wantStatus {
+				t.Errorf("workflow status = %v, want %v", wf.Status, tc.wantStatus)
+			}
+			if !reflect.DeepEqual(wf.Outputs, tc.wantOutputs) {
+				t.Errorf("workflow outputs = %v, want %v", wf.Outputs, tc.wantOutputs)
+			}
+			if tc.wantErr == "" {
+				if wf.Error != "" {
+					t.Errorf("workflow error = %q, want none", wf.Error)
+				}
+			} else if !strings.Contains(wf.Error, tc.wantErr) {
+				t.Errorf("workflow error = %q, want it to name %q", wf.Error, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_StepRetries(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "flaked")
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["flaky"] = &types.Step{
+		ID:       "flaky",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Retries:  2,
+		Shell:    &types.ShellConfig{Command: fmt.Sprintf("test -f %[1]s || { touch %[1]s; exit 1; }", marker)},
+	}
+	wf.Steps["after"] = &types.Step{
+		ID:       "after",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"flaky"},
+		Shell:    &types.ShellConfig{Command: "true"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if wf.Status != types.RunStatusDone {
+		t.Errorf("workflow status = %v, want done", wf.Status)
+	}
+	flaky := wf.Steps["flaky"]
+	if flaky.Status != types.StepStatusDone || flaky.Attempts != 1 {
+		t.Errorf("flaky: status = %v, attempts = %d; want done after 1 retry", flaky.Status, flaky.Attempts)
+	}
+	if after := wf.Steps["after"]; after.Status != types.StepStatusDone {
+		t.Errorf("dependent status = %v, want done", after.Status)
+	}
+}
+
+func TestOrchestrator_RetryBudget(t *testing.T) {
+	dir := t.TempDir()
+	runLog := filepath.Join(dir, "runs.log")
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	budget := 2
+	wf.RetryBudget = &budget
+	for _, id := range []string{"a", "b", "c"} {
+		wf.Steps[id] = &types.Step{
+			ID:       id,
+			Executor: types.ExecutorShell,
+			Status:   types.StepStatusPending,
+			Retries:  3,
+			Shell:    &types.ShellConfig{Command: fmt.Sprintf("echo %s >> %s; exit 1", id, runLog)},
+		}
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if wf.Status != types.RunStatusFailed {
+		t.Errorf("workflow status = %v, want failed", wf.Status)
+	}
+	if *wf.RetryBudget != 0 {
+		t.Errorf("retry budget left = %d, want 0", *wf.RetryBudget)
+	}
+
+	// Three first runs plus the two retries the budget allows, although each
+	// step alone would have retried three times
+	data, err := os.ReadFile(runLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "\n"); runs != 5 {
+		t.Errorf("commands ran %d times, want 5:\n%s", runs, data)
+	}
+	attempts := 0
+	for _, step := range wf.Steps {
+		if step.Status != types.StepStatusFailed {
+			t.Errorf("step %s status = %v, want failed", step.ID, step.Status)
+		}
+		attempts += step.Attempts
+	}
+	if attempts != 2 {
+		t.Errorf("total retries = %d, want 2", attempts)
+	}
+}
+
+func TestOrchestrator_RetryBackoff(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["flaky"] = &types.Step{
+		ID:                "flaky",
+		Executor:          types.ExecutorShell,
+		Status:            types.StepStatusPending,
+		Retries:           2,
+		Backoff:           "10s",
+		BackoffMultiplier: 3,
+		Shell:             &types.ShellConfig{Command: "exit 1"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	clock := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	orch.SetClock(func() time.Time { return clock })
+	ctx := context.Background()
+	flaky := wf.Steps["flaky"]
+
+	// Each tick fails the attempt in flight, or holds the retry until its backoff elapses
+	tick := func(advance time.Duration) {
+		t.Helper()
+		clock = clock.Add(advance)
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+	}
+
+	tick(0)
+	if flaky.Status != types.StepStatusFailed {
+		t.Fatalf("first attempt status = %s, want failed", flaky.Status)
+	}
+
+	for i, wait := range []time.Duration{10 * time.Second, 30 * time.Second} {
+		tick(0)
+		if flaky.Status != types.StepStatusPending || flaky.RetryAt == nil || !flaky.RetryAt.Equal(clock.Add(wait)) {
+			t.Fatalf("retry %d: status = %s, retry_at = %v; want pending until %v", i+1, flaky.Status, flaky.RetryAt, clock.Add(wait))
+		}
+		tick(wait - time.Second)
+		if flaky.Status != types.StepStatusPending {
+			t.Fatalf("retry %d dispatched %v early", i+1, time.Second)
+		}
+		tick(time.Second)
+		if flaky.Status != types.StepStatusFailed {
+			t.Fatalf("retry %d status = %s, want failed after its backoff", i+1, flaky.Status)
+		}
+	}
+
+	// Attempts are exhausted: the failure stands
+	tick(time.Hour)
+	if flaky.Status != types.StepStatusFailed || flaky.Attempts != 2 {
+		t.Errorf("flaky: status = %s, attempts = %d; want failed after 2 retries", flaky.Status, flaky.Attempts)
+	}
+}
+
 func TestOrchestrator_DependencyOrdering(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
@@ -546,6 +850,198 @@ func TestOrchestrator_AgentIdleCheck(t *testing.T) {
 	}
 }
 
+func TestOrchestrator_AgentPromptWrap(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	shell := newMockShellRunner()
+	expander := &mockTemplateExpander{}
+	logger := testLogger()
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.PromptPrefix = "Follow the coding standards."
+	wf.PromptSuffix = "Reply in Markdown.\n"
+
+	wf.Steps["wrapped"] = &types.Step{
+		ID:       "wrapped",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Agent:    &types.AgentConfig{Agent: "agent-a", Prompt: "Fix the bug", Mode: "fire_forget"},
+	}
+	wf.Steps["raw"] = &types.Step{
+		ID:       "raw",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Agent:    &types.AgentConfig{Agent: "agent-b", Prompt: "Just this", Mode: "fire_forget", SkipPromptWrap: true},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow error = %v", err)
+	}
+
+	prompts := make(map[string]string)
+	for _, inj := range agents.GetInjections() {
+		prompts[inj.AgentID] = inj.Prompt
+	}
+
+	want := "Follow the coding standards.\n\nFix the bug\n\nReply in Markdown."
+	if got := prompts["agent-a"]; got != want {
+		t.Errorf("wrapped prompt = %q, want %q", got, want)
+	}
+	if got := prompts["agent-b"]; got != "Just this" {
+		t.Errorf("opted-out prompt = %q, want %q", got, "Just this")
+	}
+}
+
+func TestOrchestrator_MaxConcurrentAgents(t *testing.T) {
+	for _, tt := range []struct {
+		limit       int
+		wantRunning int
+	}{
+		{limit: 2, wantRunning: 2},
+		{limit: 0, wantRunning: 3}, // Unlimited
+	} {
+		t.Run(fmt.Sprintf("limit=%d", tt.limit), func(t *testing.T) {
+			store := newMockRunStore()
+			agents := newMockAgentManager()
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			for i := range 3 {
+				agents.running[fmt.Sprintf("agent-%d", i)] = true
+				stepID := fmt.Sprintf("task-%d", i)
+				wf.Steps[stepID] = &types.Step{
+					ID:       stepID,
+					Executor: types.ExecutorAgent,
+					Status:   types.StepStatusPending,
+					Agent:    &types.AgentConfig{Agent: fmt.Sprintf("agent-%d", i), Prompt: "Work"},
+				}
+			}
+			store.workflows[wf.ID] = wf
+
+			cfg := testConfig()
+			cfg.Orchestrator.MaxConcurrentAgents = tt.limit
+			orch := New(cfg, store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			ctx := context.Background()
+
+			var running []*types.Step
+			countRunning := func() int {
+				running = running[:0]
+				for _, step := range store.workflows[wf.ID].Steps {
+					if step.Status == types.StepStatusRunning {
+						running = append(running, step)
+					}
+				}
+				return len(running)
+			}
+
+			// Ready steps beyond the limit stay pending, tick after tick
+			for range 2 {
+				if err := orch.tick(ctx); err != nil {
+					t.Fatalf("tick error = %v", err)
+				}
+				if got := countRunning(); got != tt.wantRunning {
+					t.Fatalf("running = %d, want %d", got, tt.wantRunning)
+				}
+			}
+			if tt.limit == 0 {
+				return
+			}
+
+			// A finished step frees its slot for the remaining one
+			if err := running[0].Complete(nil); err != nil {
+				t.Fatalf("completing %s: %v", running[0].ID, err)
+			}
+			if err := orch.tick(ctx); err != nil {
+				t.Fatalf("tick error = %v", err)
+			}
+			if got := countRunning(); got != 2 {
+				t.Errorf("running after a slot freed = %d, want 2", got)
+			}
+			for _, step := range store.workflows[wf.ID].Steps {
+				if step.Status == types.StepStatusPending {
+					t.Errorf("step %s still pending after a slot freed", step.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestOrchestrator_FairSchedulingUnderAgentCap(t *testing.T) {
+	store := newMockRunStore()
+	for _, id := range []string{"wf-a", "wf-b"} {
+		wf := types.NewRun(id, "test-template", nil)
+		wf.Status = types.RunStatusRunning
+		for i := range 4 {
+			stepID := fmt.Sprintf("task-%d", i)
+			wf.Steps[stepID] = &types.Step{
+				ID:       stepID,
+				Executor: types.ExecutorAgent,
+				Status:   types.StepStatusPending,
+				Agent:    &types.AgentConfig{Agent: fmt.Sprintf("%s-agent-%d", id, i), Prompt: "Work"},
+			}
+		}
+		store.workflows[id] = wf
+	}
+
+	cfg := testConfig()
+	cfg.Orchestrator.MaxConcurrentAgents = 2
+	orch := New(cfg, store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	ctx := context.Background()
+
+	running := func(id string) int {
+		n := 0
+		for _, step := range store.workflows[id].Steps {
+			if step.Status == types.StepStatusRunning {
+				n++
+			}
+		}
+		return n
+	}
+	finishRunning := func() {
+		for _, wf := range store.workflows {
+			for _, step := range wf.Steps {
+				if step.Status == types.StepStatusRunning {
+					if err := step.Complete(nil); err != nil {
+						t.Fatalf("completing %s: %v", step.ID, err)
+					}
+				}
+			}
+		}
+	}
+
+	// First tick: the cap is filled, never exceeded
+	if err := orch.tick(ctx); err != nil {
+		t.Fatalf("tick error = %v", err)
+	}
+	first := map[string]int{"wf-a": running("wf-a"), "wf-b": running("wf-b")}
+	if first["wf-a"]+first["wf-b"] != 2 {
+		t.Fatalf("running after first tick = %v, want 2 total", first)
+	}
+
+	// Next tick: the other workflow gets first pick of the freed slots
+	finishRunning()
+	if err := orch.tick(ctx); err != nil {
+		t.Fatalf("tick error = %v", err)
+	}
+	second := map[string]int{"wf-a": running("wf-a"), "wf-b": running("wf-b")}
+	if second["wf-a"]+second["wf-b"] != 2 {
+		t.Fatalf("running after second tick = %v, want 2 total", second)
+	}
+	for _, id := range []string{"wf-a", "wf-b"} {
+		if first[id]+second[id] == 0 {
+			t.Errorf("%s made no progress over two ticks (first=%v second=%v)", id, first, second)
+		}
+	}
+	if first["wf-a"] == 2 && second["wf-a"] == 2 || first["wf-b"] == 2 && second["wf-b"] == 2 {
+		t.Errorf("one workflow monopolized the cap: first=%v second=%v", first, second)
+	}
+}
+
 func TestOrchestrator_HandleStepDone(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
@@ -594,6 +1090,44 @@ func TestOrchestrator_HandleStepDone(t *testing.T) {
 	}
 }
 
+func TestOrchestrator_HandleStepDone_AgentExited(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	agents.exits = map[string]int{"test-agent": -1}
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent:     &types.AgentConfig{Agent: "test-agent", Prompt: "Do work"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	// meow done run by the session's shell after the agent exited
+	msg := &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "test-agent",
+	}
+	if err := orch.HandleStepDone(context.Background(), msg); err == nil {
+		t.Error("HandleStepDone error = nil, want the agent reported as exited")
+	}
+
+	step := wf.Steps["agent-step"]
+	if step.Status != types.StepStatusFailed {
+		t.Fatalf("Step status = %v, want %v", step.Status, types.StepStatusFailed)
+	}
+	if step.Error == nil || step.Error.Type != types.StepErrorAgentCrashed {
+		t.Errorf("Step error = %+v, want agent_crashed", step.Error)
+	}
+}
+
 func TestOrchestrator_OutputValidation(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
@@ -671,29 +1205,151 @@ func TestOrchestrator_OutputValidation(t *testing.T) {
 	}
 }
 
-func TestOrchestrator_WorkflowCompletion(t *testing.T) {
+func TestOrchestrator_OutputValidationRetries(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
-	shell := newMockShellRunner()
-	expander := &mockTemplateExpander{}
-	logger := testLogger()
+	agents.running["test-agent"] = true
 
-	// Create workflow that will complete
 	wf := types.NewRun("test-wf", "test-template", nil)
 	wf.Status = types.RunStatusRunning
-	wf.Steps["step-1"] = &types.Step{
-		ID:       "step-1",
-		Executor: types.ExecutorShell,
-		Status:   types.StepStatusDone,
+	now := time.Now()
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent: &types.AgentConfig{
+			Agent:   "test-agent",
+			Prompt:  "Count the items",
+			Outputs: map[string]types.AgentOutputDef{"count": {Required: true, Type: "number"}},
+		},
 	}
 	store.workflows[wf.ID] = wf
 
-	orch := New(testConfig(), store, agents, shell, expander, logger)
+	cfg := testConfig()
+	limit := 2
+	cfg.Agent.MaxValidationRetries = &limit
+	orch := New(cfg, store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
 
-	// Process - should complete workflow
 	ctx := context.Background()
-	err := orch.processWorkflow(ctx, wf)
-	if err != nil {
+	msg := &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "test-agent",
+		Step:     "agent-step",
+		Outputs:  map[string]any{"count": "forty-two"},
+	}
+	step := wf.Steps["agent-step"]
+
+	// Each invalid attempt within the limit re-prompts the agent with the errors
+	for retry := 1; retry <= limit; retry++ {
+		if err := orch.HandleStepDone(ctx, msg); err == nil || !strings.Contains(err.Error(), "count") {
+			t.Fatalf("attempt %d: HandleStepDone error = %v, want validation error naming count", retry, err)
+		}
+		orch.wg.Wait()
+		if step.Status != types.StepStatusRunning || step.ValidationRetries != retry {
+			t.Fatalf("attempt %d: status = %s, validation retries = %d; want running, %d", retry, step.Status, step.ValidationRetries, retry)
+		}
+		if len(agents.injections) != retry {
+			t.Fatalf("attempt %d: %d prompts injected, want %d", retry, len(agents.injections), retry)
+		}
+		prompt := agents.injections[retry-1].Prompt
+		want := fmt.Sprintf("retry %d of %d", retry, limit)
+		if !strings.HasPrefix(prompt, "Count the items") || !strings.Contains(prompt, want) || !strings.Contains(prompt, "count") {
+			t.Errorf("attempt %d: correction prompt = %q, want the step prompt, %q and the error", retry, prompt, want)
+		}
+	}
+
+	// Past the limit, the step fails
+	if err := orch.HandleStepDone(ctx, msg); err == nil {
+		t.Fatal("HandleStepDone succeeded past the retry limit, want validation error")
+	}
+	orch.wg.Wait()
+	if step.Status != types.StepStatusFailed || step.Error == nil || step.Error.Type != types.StepErrorValidationFailed {
+		t.Errorf("status = %s, error = %+v; want failed with validation_failed", step.Status, step.Error)
+	}
+	if len(agents.injections) != limit {
+		t.Errorf("%d prompts injected, want %d", len(agents.injections), limit)
+	}
+}
+
+func TestOrchestrator_OutputValidation_OutOfRange(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	agents.running["test-agent"] = true
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	lo, hi := 0.0, 100.0
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent: &types.AgentConfig{
+			Agent:   "test-agent",
+			Prompt:  "Score the change",
+			Outputs: map[string]types.AgentOutputDef{"count": {Required: true, Type: "number", Min: &lo, Max: &hi}},
+		},
+	}
+	store.workflows[wf.ID] = wf
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	ctx := context.Background()
+	msg := &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "test-agent",
+		Step:     "agent-step",
+		Outputs:  map[string]any{"count": 200},
+	}
+
+	// An out-of-range value takes the validation retry path
+	if err := orch.HandleStepDone(ctx, msg); err == nil || !strings.Contains(err.Error(), "above the maximum of 100") {
+		t.Fatalf("HandleStepDone error = %v, want count above the maximum", err)
+	}
+	orch.wg.Wait()
+	step := wf.Steps["agent-step"]
+	if step.Status != types.StepStatusRunning || step.ValidationRetries != 1 {
+		t.Fatalf("status = %s, validation retries = %d; want running, 1", step.Status, step.ValidationRetries)
+	}
+	if len(agents.injections) != 1 || !strings.Contains(agents.injections[0].Prompt, "above the maximum of 100") {
+		t.Errorf("injections = %+v, want one correction prompt naming the bound", agents.injections)
+	}
+
+	msg.Outputs = map[string]any{"count": 100}
+	if err := orch.HandleStepDone(ctx, msg); err != nil {
+		t.Fatalf("HandleStepDone error = %v", err)
+	}
+	if step.Status != types.StepStatusDone {
+		t.Errorf("status = %s, want done", step.Status)
+	}
+}
+
+func TestOrchestrator_WorkflowCompletion(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	shell := newMockShellRunner()
+	expander := &mockTemplateExpander{}
+	logger := testLogger()
+
+	// Create workflow that will complete
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["step-1"] = &types.Step{
+		ID:       "step-1",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusDone,
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+
+	// Process - should complete workflow
+	ctx := context.Background()
+	err := orch.processWorkflow(ctx, wf)
+	if err != nil {
 		t.Fatalf("processWorkflow error = %v", err)
 	}
 
@@ -790,6 +1446,162 @@ func TestOrchestrator_SpawnAndKill(t *testing.T) {
 	}
 }
 
+// TestOrchestrator_SpawnReuseFrom tests that a spawn step with reuse_from
+// adopts a finished run's live agent and spawns a new one otherwise.
+func TestOrchestrator_SpawnReuseFrom(t *testing.T) {
+	tests := []struct {
+		name        string
+		priorStatus types.RunStatus
+		alive       bool
+		reuseFrom   string
+		wantReused  bool
+	}{
+		{name: "finished run live agent", priorStatus: types.RunStatusDone, alive: true, reuseFrom: "run-a", wantReused: true},
+		{name: "finished run dead agent", priorStatus: types.RunStatusDone, alive: false, reuseFrom: "run-a"},
+		{name: "prior run still running", priorStatus: types.RunStatusRunning, alive: true, reuseFrom: "run-a"},
+		{name: "unknown run", priorStatus: types.RunStatusDone, alive: true, reuseFrom: "run-missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			prior := types.NewRun("run-a", "pool", nil)
+			prior.Status = tt.priorStatus
+			prior.RegisterAgent("worker", &types.AgentInfo{TmuxSession: "meow-run-a-worker", Status: "active", Workdir: "/tmp"})
+			store.workflows[prior.ID] = prior
+
+			wf := types.NewRun("run-b", "pool", nil)
+			wf.Status = types.RunStatusRunning
+			wf.Steps["spawn"] = &types.Step{
+				ID:       "spawn",
+				Executor: types.ExecutorSpawn,
+				Status:   types.StepStatusPending,
+				Spawn:    &types.SpawnConfig{Agent: "worker", ReuseFrom: tt.reuseFrom},
+			}
+			store.workflows[wf.ID] = wf
+
+			agents := newMockAgentManager()
+			agents.running["worker"] = tt.alive
+
+			orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			if err := orch.handleSpawn(context.Background(), wf, wf.Steps["spawn"]); err != nil {
+				t.Fatalf("handleSpawn error = %v", err)
+			}
+
+			step := wf.Steps["spawn"]
+			if step.Status != types.StepStatusDone {
+				t.Fatalf("status = %v, want done", step.Status)
+			}
+			if step.Outputs["reused"] != tt.wantReused {
+				t.Errorf("reused = %v, want %v", step.Outputs["reused"], tt.wantReused)
+			}
+			if spawned := len(agents.started) == 1; spawned == tt.wantReused {
+				t.Errorf("started = %v, want a new agent only when not reused", agents.started)
+			}
+			if tt.wantReused && wf.Agents["worker"].TmuxSession != "meow-run-a-worker" {
+				t.Errorf("agent = %+v, want run-a's session", wf.Agents["worker"])
+			}
+		})
+	}
+}
+
+// TestHandleKill_Idempotent tests that killing an agent that already exited
+// succeeds, while killing a live agent still stops it (and reports stop errors).
+func TestHandleKill_Idempotent(t *testing.T) {
+	tests := []struct {
+		name       string
+		running    bool
+		stopErr    error
+		wantStatus types.StepStatus
+	}{
+		{name: "already dead agent", running: false, stopErr: errors.New("session not found"), wantStatus: types.StepStatusDone},
+		{name: "live agent", running: true, wantStatus: types.StepStatusDone},
+		{name: "live agent stop error", running: true, stopErr: errors.New("kill failed"), wantStatus: types.StepStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			agents := newMockAgentManager()
+			agents.running["worker"] = tt.running
+			agents.stopErr = tt.stopErr
+
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.Steps["kill-step"] = &types.Step{
+				ID:       "kill-step",
+				Executor: types.ExecutorKill,
+				Status:   types.StepStatusPending,
+				Kill:     &types.KillConfig{Agent: "worker"},
+			}
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			if err := orch.handleKill(context.Background(), wf, wf.Steps["kill-step"]); err != nil {
+				t.Fatalf("handleKill error = %v", err)
+			}
+			orch.wg.Wait()
+
+			if got := wf.Steps["kill-step"].Status; got != tt.wantStatus {
+				t.Errorf("kill step status = %v, want %v", got, tt.wantStatus)
+			}
+			if running, _ := agents.IsRunning(context.Background(), "worker"); running {
+				t.Error("agent should not be running after kill")
+			}
+			if tt.running && len(agents.stopped) != 1 {
+				t.Errorf("live agent should be stopped once, stopped = %v", agents.stopped)
+			}
+		})
+	}
+}
+
+// TestHandleKill_RecordsOutcome tests that a kill step's "stopped" output
+// reports whether its agent exited gracefully, was forced, or was already gone.
+func TestHandleKill_RecordsOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		running bool
+		outcome StopOutcome
+		want    string
+	}{
+		{name: "graceful", running: true, outcome: StopGraceful, want: "graceful"},
+		{name: "forced", running: true, outcome: StopForced, want: "forced"},
+		{name: "not running", running: false, outcome: StopForced, want: "not_running"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			agents := newMockAgentManager()
+			agents.running["worker"] = tt.running
+			agents.stopOutcome = tt.outcome
+
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.Steps["kill-step"] = &types.Step{
+				ID:       "kill-step",
+				Executor: types.ExecutorKill,
+				Status:   types.StepStatusPending,
+				Kill:     &types.KillConfig{Agent: "worker", Graceful: true, GracePeriod: time.Second},
+			}
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			if err := orch.handleKill(context.Background(), wf, wf.Steps["kill-step"]); err != nil {
+				t.Fatalf("handleKill error = %v", err)
+			}
+			orch.wg.Wait()
+
+			step := wf.Steps["kill-step"]
+			if step.Status != types.StepStatusDone {
+				t.Fatalf("kill step status = %v, want done", step.Status)
+			}
+			if got := step.Outputs["stopped"]; got != tt.want {
+				t.Errorf("stopped output = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestOrchestrator_ConcurrentStepCompletion tests that multiple concurrent
 // HandleStepDone calls do not result in lost updates. This is the critical test
 // for the race condition fix (meow-ilr).
@@ -925,7 +1737,7 @@ func TestOrchestrator_StepTimeout(t *testing.T) {
 	}
 
 	// Simulate grace period elapsed by setting InterruptedAt in the past
-	interruptedAt := time.Now().Add(-11 * time.Second) // 11 seconds ago
+	interruptedAt := time.Now().Add(-config.DefaultTimeoutGracePeriod - time.Second)
 	wf.Steps["agent-step"].InterruptedAt = &interruptedAt
 
 	// Second call - should fail the step
@@ -944,148 +1756,920 @@ func TestOrchestrator_StepTimeout(t *testing.T) {
 	}
 }
 
-// TestOrchestrator_StepNoTimeoutIfCompleted tests that steps that complete before timeout are not affected.
-func TestOrchestrator_StepNoTimeoutIfCompleted(t *testing.T) {
+// TestOrchestrator_StepTimeout_CancelPrompt checks that a timed-out agent step
+// with a cancel_prompt is asked to wrap up first, and can still finish with
+// meow done before it would be interrupted.
+func TestOrchestrator_StepTimeout_CancelPrompt(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
-	shell := newMockShellRunner()
-	expander := &mockTemplateExpander{}
-	logger := testLogger()
 
-	// Create workflow with a completed agent step
 	wf := types.NewRun("test-wf", "test-template", nil)
 	wf.Status = types.RunStatusRunning
-
 	startedAt := time.Now().Add(-2 * time.Second)
-	doneAt := time.Now().Add(-1 * time.Second)
 	wf.Steps["agent-step"] = &types.Step{
 		ID:        "agent-step",
 		Executor:  types.ExecutorAgent,
-		Status:    types.StepStatusDone,
+		Status:    types.StepStatusRunning,
 		StartedAt: &startedAt,
-		DoneAt:    &doneAt,
 		Agent: &types.AgentConfig{
-			Agent:   "test-agent",
-			Prompt:  "Do work",
-			Timeout: "1s",
+			Agent:        "test-agent",
+			Prompt:       "Do work",
+			Timeout:      "1s",
+			CancelPrompt: "Out of time: commit your progress and run meow done",
+			CancelGrace:  "1m",
 		},
 	}
 	store.workflows[wf.ID] = wf
 
-	orch := New(testConfig(), store, agents, shell, expander, logger)
-
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
 	ctx := context.Background()
-	orch.checkStepTimeouts(ctx, wf)
 
-	// Agent should NOT have been interrupted (step already done)
+	if !orch.checkStepTimeouts(ctx, wf) {
+		t.Error("checkStepTimeouts() = false, want the cancel prompt recorded")
+	}
+	orch.wg.Wait()
+	step := wf.Steps["agent-step"]
 	if len(agents.interrupted) != 0 {
-		t.Errorf("Expected no interrupts, got %v", agents.interrupted)
+		t.Errorf("interrupts = %v, want none before the cancel grace", agents.interrupted)
+	}
+	if injections := agents.GetInjections(); len(injections) != 1 || injections[0].Prompt != step.Agent.CancelPrompt {
+		t.Fatalf("injections = %+v, want the cancel prompt", injections)
+	}
+	if step.CancelPromptedAt == nil {
+		t.Fatal("CancelPromptedAt should be set")
 	}
 
-	// Step should still be done
-	if wf.Steps["agent-step"].Status != types.StepStatusDone {
-		t.Errorf("Step status = %v, want done", wf.Steps["agent-step"].Status)
+	// Still within the grace: nothing more happens
+	orch.checkStepTimeouts(ctx, wf)
+	if len(agents.interrupted) != 0 || step.Status != types.StepStatusRunning {
+		t.Fatalf("step %s with interrupts %v, want running and left alone", step.Status, agents.interrupted)
+	}
+
+	// The agent checkpoints and finishes
+	if err := orch.HandleStepDone(ctx, &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "test-agent",
+		Step:     "agent-step",
+		Outputs:  map[string]any{"checkpoint": "saved"},
+	}); err != nil {
+		t.Fatalf("HandleStepDone() error = %v", err)
+	}
+	if step.Status != types.StepStatusDone || step.Outputs["checkpoint"] != "saved" {
+		t.Errorf("step = %s with outputs %v, want done with the checkpoint", step.Status, step.Outputs)
 	}
 }
 
-// TestOrchestrator_CleanupOnCompletion tests that cleanup runs when workflow completes.
-func TestOrchestrator_CleanupOnCompletion(t *testing.T) {
+func TestOrchestrator_StepTimeout_CancelPromptGraceExpired(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
-	shell := newMockShellRunner()
-	expander := &mockTemplateExpander{}
-	logger := testLogger()
 
-	// Create workflow with cleanup_on_success script (opt-in cleanup)
 	wf := types.NewRun("test-wf", "test-template", nil)
 	wf.Status = types.RunStatusRunning
-	wf.CleanupOnSuccess = "echo cleanup executed"
-	wf.Steps["step-1"] = &types.Step{
-		ID:       "step-1",
-		Executor: types.ExecutorShell,
-		Status:   types.StepStatusPending,
-		Shell:    &types.ShellConfig{Command: "echo hello"},
+	startedAt := time.Now().Add(-time.Minute)
+	promptedAt := time.Now().Add(-2 * time.Second)
+	wf.Steps["agent-step"] = &types.Step{
+		ID:               "agent-step",
+		Executor:         types.ExecutorAgent,
+		Status:           types.StepStatusRunning,
+		StartedAt:        &startedAt,
+		CancelPromptedAt: &promptedAt,
+		Agent: &types.AgentConfig{
+			Agent:        "test-agent",
+			Prompt:       "Do work",
+			Timeout:      "1s",
+			CancelPrompt: "Wrap up",
+			CancelGrace:  "1s",
+		},
 	}
 	store.workflows[wf.ID] = wf
 
-	orch := New(testConfig(), store, agents, shell, expander, logger)
-	orch.SetWorkflowID(wf.ID)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.checkStepTimeouts(context.Background(), wf)
 
-	err := orch.Run(ctx)
-	if err != nil {
-		t.Errorf("Run() error = %v, want nil", err)
+	if len(agents.interrupted) != 1 || agents.interrupted[0] != "test-agent" {
+		t.Errorf("interrupts = %v, want the agent interrupted after the cancel grace", agents.interrupted)
 	}
+	if step := wf.Steps["agent-step"]; step.InterruptedAt == nil || step.Status != types.StepStatusRunning {
+		t.Errorf("step = %s, InterruptedAt = %v, want running in its timeout grace period", step.Status, step.InterruptedAt)
+	}
+}
 
-	// Workflow should be done (cleanup was successful)
-	finalWf, _ := store.Get(ctx, wf.ID)
-	if finalWf.Status != types.RunStatusDone {
-		t.Errorf("Workflow status = %v, want done", finalWf.Status)
+// interruptSavingStore records whether a timed-out step's InterruptedAt was
+// saved while the step was still running, in its grace period.
+type interruptSavingStore struct {
+	*mockRunStore
+	stepID          string
+	savedInterrupts bool
+}
+
+func (s *interruptSavingStore) Save(ctx context.Context, wf *types.Run) error {
+	if step, ok := wf.Steps[s.stepID]; ok && step.Status == types.StepStatusRunning && step.InterruptedAt != nil {
+		s.savedInterrupts = true
 	}
+	return s.mockRunStore.Save(ctx, wf)
 }
 
-// TestOrchestrator_RunCleanup tests the RunCleanup method directly.
-func TestOrchestrator_RunCleanup(t *testing.T) {
-	store := newMockRunStore()
+// TestOrchestrator_StepTimeout_GracePeriodConfig drives a timed-out agent step
+// through a 100ms timeout_grace_period with the orchestrator loop.
+func TestOrchestrator_StepTimeout_GracePeriodConfig(t *testing.T) {
+	store := &interruptSavingStore{mockRunStore: newMockRunStore(), stepID: "agent-step"}
 	agents := newMockAgentManager()
-	shell := newMockShellRunner()
-	expander := &mockTemplateExpander{}
-	logger := testLogger()
+	agents.running["test-agent"] = true
 
-	// Create workflow with cleanup_on_success script (testing RunCleanup with Done reason)
 	wf := types.NewRun("test-wf", "test-template", nil)
 	wf.Status = types.RunStatusRunning
-	wf.CleanupOnSuccess = "echo cleanup"
+	startedAt := time.Now()
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &startedAt,
+		Agent:     &types.AgentConfig{Agent: "test-agent", Prompt: "Do work", Timeout: "50ms"},
+	}
 	store.workflows[wf.ID] = wf
 
-	// Register some agents
-	agents.running["agent-1"] = true
-	agents.running["agent-2"] = true
-
-	orch := New(testConfig(), store, agents, shell, expander, logger)
+	cfg := testConfig()
+	cfg.Orchestrator.TimeoutGracePeriod = 100 * time.Millisecond
+	orch := New(cfg, store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
 
-	ctx := context.Background()
-	err := orch.RunCleanup(ctx, wf, types.RunStatusDone)
-	if err != nil {
-		t.Fatalf("RunCleanup error = %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
 	}
 
-	// Workflow should be in done state
-	if wf.Status != types.RunStatusDone {
-		t.Errorf("Workflow status = %v, want done", wf.Status)
+	step := wf.Steps["agent-step"]
+	if step.Status != types.StepStatusFailed || step.Error == nil || step.Error.Type != types.StepErrorTimeout {
+		t.Fatalf("step = %s (error %+v), want failed with a timeout", step.Status, step.Error)
 	}
-
-	// DoneAt should be set
-	if wf.DoneAt == nil {
-		t.Error("Workflow DoneAt should be set")
+	if len(agents.interrupted) != 1 {
+		t.Errorf("interrupts = %v, want one", agents.interrupted)
 	}
-
-	// All agents should have been killed
-	for agentID, running := range agents.running {
-		if running {
-			t.Errorf("Agent %s should have been killed", agentID)
-		}
+	if step.InterruptedAt == nil || step.DoneAt == nil {
+		t.Fatal("InterruptedAt and DoneAt should be set")
+	}
+	if grace := step.DoneAt.Sub(*step.InterruptedAt); grace < 100*time.Millisecond || grace > 2*time.Second {
+		t.Errorf("failed %s after the interrupt, want after the 100ms grace period", grace)
+	}
+	if !store.savedInterrupts {
+		t.Error("InterruptedAt was not saved during the grace period")
 	}
 }
 
-// TestOrchestrator_RunCleanup_FailedWorkflow tests cleanup for failed workflows.
-func TestOrchestrator_RunCleanup_FailedWorkflow(t *testing.T) {
+// TestOrchestrator_StepNoTimeoutIfCompleted tests that steps that complete before timeout are not affected.
+func TestOrchestrator_StepNoTimeoutIfCompleted(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
 	shell := newMockShellRunner()
 	expander := &mockTemplateExpander{}
 	logger := testLogger()
 
+	// Create workflow with a completed agent step
 	wf := types.NewRun("test-wf", "test-template", nil)
 	wf.Status = types.RunStatusRunning
-	wf.CleanupOnFailure = "echo cleanup"
-	store.workflows[wf.ID] = wf
-
-	orch := New(testConfig(), store, agents, shell, expander, logger)
 
-	ctx := context.Background()
+	startedAt := time.Now().Add(-2 * time.Second)
+	doneAt := time.Now().Add(-1 * time.Second)
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusDone,
+		StartedAt: &startedAt,
+		DoneAt:    &doneAt,
+		Agent: &types.AgentConfig{
+			Agent:   "test-agent",
+			Prompt:  "Do work",
+			Timeout: "1s",
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+
+	ctx := context.Background()
+	orch.checkStepTimeouts(ctx, wf)
+
+	// Agent should NOT have been interrupted (step already done)
+	if len(agents.interrupted) != 0 {
+		t.Errorf("Expected no interrupts, got %v", agents.interrupted)
+	}
+
+	// Step should still be done
+	if wf.Steps["agent-step"].Status != types.StepStatusDone {
+		t.Errorf("Step status = %v, want done", wf.Steps["agent-step"].Status)
+	}
+}
+
+// TestOrchestrator_AgentLiveness tests that a running agent step fails with
+// agent_crashed once its agent's session is gone.
+func TestOrchestrator_AgentLiveness(t *testing.T) {
+	startedAt := time.Now()
+	newStep := func(id, agentID string, completion string) *types.Step {
+		return &types.Step{
+			ID:        id,
+			Executor:  types.ExecutorAgent,
+			Status:    types.StepStatusRunning,
+			StartedAt: &startedAt,
+			Agent:     &types.AgentConfig{Agent: agentID, Prompt: "Do work", Completion: completion},
+		}
+	}
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["alive"] = newStep("alive", "alive-agent", "")
+	wf.Steps["crashed"] = newStep("crashed", "dead-agent", "")
+	wf.Steps["exits"] = newStep("exits", "exit-agent", types.CompletionExit)
+	wf.Steps["after-crash"] = &types.Step{
+		ID:       "after-crash",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"crashed"},
+		Shell:    &types.ShellConfig{Command: "echo never"},
+	}
+	store.workflows[wf.ID] = wf
+
+	agents := newMockAgentManager()
+	agents.running["alive-agent"] = true
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	if err := orch.processWorkflow(context.Background(), wf); err != nil {
+		t.Fatalf("processWorkflow error = %v", err)
+	}
+	wf = store.workflows[wf.ID]
+
+	crashed := wf.Steps["crashed"]
+	if crashed.Status != types.StepStatusFailed {
+		t.Fatalf("crashed status = %v, want failed", crashed.Status)
+	}
+	if crashed.Error == nil || crashed.Error.Type != types.StepErrorAgentCrashed {
+		t.Errorf("crashed error = %+v, want agent_crashed", crashed.Error)
+	}
+	if got := wf.Steps["after-crash"].Status; got != types.StepStatusSkipped {
+		t.Errorf("after-crash status = %v, want skipped", got)
+	}
+	if got := wf.Steps["alive"].Status; got != types.StepStatusRunning {
+		t.Errorf("alive status = %v, want running", got)
+	}
+	// Exit completion reports the exit code itself
+	if got := wf.Steps["exits"].Status; got != types.StepStatusRunning {
+		t.Errorf("exits status = %v, want running until its exit status is known", got)
+	}
+}
+
+// TestOrchestrator_StepTimeoutAfterAck tests that with ack_timeout the work
+// budget starts at the prompt-received acknowledgment, not at dispatch.
+func TestOrchestrator_StepTimeoutAfterAck(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+
+	// Dispatched 3s ago; the agent is slow to pick up the prompt
+	startedAt := time.Now().Add(-3 * time.Second)
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &startedAt,
+		Agent: &types.AgentConfig{
+			Agent:      "test-agent",
+			Prompt:     "Do work",
+			Timeout:    "2s",
+			AckTimeout: "10s",
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	ctx := context.Background()
+	step := wf.Steps["agent-step"]
+
+	// Past the 2s work budget, but still inside the 10s acknowledgment budget
+	orch.checkStepTimeouts(ctx, wf)
+	if len(agents.interrupted) != 0 {
+		t.Fatalf("interrupted before acknowledgment: %v", agents.interrupted)
+	}
+
+	// Acknowledgment arrives late: the full 2s work budget starts now
+	if err := orch.HandlePromptReceived(ctx, "", "test-agent"); err != nil {
+		t.Fatalf("HandlePromptReceived() error = %v", err)
+	}
+	if step.AcknowledgedAt == nil {
+		t.Fatal("AcknowledgedAt not recorded")
+	}
+	orch.checkStepTimeouts(ctx, wf)
+	if len(agents.interrupted) != 0 {
+		t.Fatalf("interrupted right after acknowledgment: %v", agents.interrupted)
+	}
+
+	// A second acknowledgment (e.g., a re-injected prompt) does not reset the clock
+	ackedAt := time.Now().Add(-1500 * time.Millisecond)
+	step.AcknowledgedAt = &ackedAt
+	if err := orch.HandlePromptReceived(ctx, "", "test-agent"); err != nil {
+		t.Fatalf("HandlePromptReceived() error = %v", err)
+	}
+	if !step.AcknowledgedAt.Equal(ackedAt) {
+		t.Errorf("AcknowledgedAt = %v, want unchanged %v", step.AcknowledgedAt, ackedAt)
+	}
+	orch.checkStepTimeouts(ctx, wf)
+	if len(agents.interrupted) != 0 {
+		t.Fatalf("interrupted within work budget: %v", agents.interrupted)
+	}
+
+	// Work budget exhausted 2s after acknowledgment
+	ackedAt = time.Now().Add(-2500 * time.Millisecond)
+	step.AcknowledgedAt = &ackedAt
+	orch.checkStepTimeouts(ctx, wf)
+	if len(agents.interrupted) != 1 {
+		t.Errorf("Expected interrupt once the work budget ran out, got %v", agents.interrupted)
+	}
+}
+
+// TestOrchestrator_StepAckTimeout tests that a step with ack_timeout fails
+// when the agent never acknowledges the prompt.
+func TestOrchestrator_StepAckTimeout(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	startedAt := time.Now().Add(-2 * time.Second)
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &startedAt,
+		Agent:     &types.AgentConfig{Agent: "test-agent", Prompt: "Do work", Timeout: "1h", AckTimeout: "1s"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	ctx := context.Background()
+
+	orch.checkStepTimeouts(ctx, wf)
+	if len(agents.interrupted) != 1 {
+		t.Fatalf("Expected interrupt after ack_timeout, got %v", agents.interrupted)
+	}
+
+	interruptedAt := time.Now().Add(-config.DefaultTimeoutGracePeriod - time.Second)
+	wf.Steps["agent-step"].InterruptedAt = &interruptedAt
+	orch.checkStepTimeouts(ctx, wf)
+
+	step := wf.Steps["agent-step"]
+	if step.Status != types.StepStatusFailed {
+		t.Fatalf("Step status = %v, want failed", step.Status)
+	}
+	if step.Error == nil || !strings.Contains(step.Error.Message, "not acknowledged within 1s") {
+		t.Errorf("Step error = %+v, want acknowledgment timeout", step.Error)
+	}
+}
+
+func TestOrchestrator_AgentStall(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+
+	clock := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	startedAt := clock
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &startedAt,
+		Agent: &types.AgentConfig{
+			Agent:        "test-agent",
+			Prompt:       "Do work",
+			Timeout:      "1h",
+			StallTimeout: "5m",
+			OnStall:      types.OnStallNudge,
+			PostDelay:    "2s",
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetClock(func() time.Time { return clock })
+	router := NewEventRouter(testLogger())
+	orch.SetEventRouter(router)
+	ctx := context.Background()
+
+	// Events keep the agent alive past the stall timeout
+	clock = startedAt.Add(4 * time.Minute)
+	orch.RecordAgentActivity(wf.ID, "test-agent")
+	clock = startedAt.Add(8 * time.Minute)
+	orch.checkAgentStalls(ctx, wf)
+	orch.wg.Wait()
+	if len(agents.GetInjections()) != 0 {
+		t.Fatalf("active agent was nudged: %v", agents.GetInjections())
+	}
+
+	// Silence for the stall timeout fires the warning and the nudge, well
+	// before the hard timeout
+	stalled := router.RegisterWaiter("agent-stalled", map[string]string{"agent": "test-agent"}, time.Minute)
+	clock = startedAt.Add(10 * time.Minute)
+	orch.checkAgentStalls(ctx, wf)
+	orch.wg.Wait()
+
+	select {
+	case event := <-stalled:
+		if event.Data["step"] != "agent-step" || event.Data["silent_for"] != "6m0s" {
+			t.Errorf("agent-stalled data = %v", event.Data)
+		}
+	default:
+		t.Fatal("expected agent-stalled event")
+	}
+	injections := agents.GetInjections()
+	if len(injections) != 1 || !injections[0].Stabilize || !strings.HasPrefix(injections[0].Prompt, "Do work") {
+		t.Fatalf("nudge injections = %+v, want one stabilized re-injection", injections)
+	}
+	if injections[0].PostDelay != 2*time.Second {
+		t.Errorf("nudge post_delay = %v, want the step's 2s", injections[0].PostDelay)
+	}
+	step := wf.Steps["agent-step"]
+	if step.Status != types.StepStatusRunning || step.InterruptedAt != nil {
+		t.Errorf("stall must not time the step out: status=%v interruptedAt=%v", step.Status, step.InterruptedAt)
+	}
+
+	// The warning re-arms instead of repeating every tick
+	clock = startedAt.Add(11 * time.Minute)
+	orch.checkAgentStalls(ctx, wf)
+	orch.wg.Wait()
+	if n := len(agents.GetInjections()); n != 1 {
+		t.Errorf("nudged %d times, want 1 until another stall_timeout passes", n)
+	}
+}
+
+func TestOrchestrator_AgentNudge(t *testing.T) {
+	tests := []struct {
+		name       string
+		resumeAt   time.Duration // agent sends an event this long after the start (0 = never)
+		wantStatus types.StepStatus
+		wantNudges int
+	}{
+		{name: "silent agent is failed after max_nudges", wantStatus: types.StepStatusFailed, wantNudges: 3},
+		{name: "agent resumes after a nudge", resumeAt: 45 * time.Second, wantStatus: types.StepStatusRunning, wantNudges: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			agents := newMockAgentManager()
+
+			startedAt := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+			clock := startedAt
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.Steps["agent-step"] = &types.Step{
+				ID:        "agent-step",
+				Executor:  types.ExecutorAgent,
+				Status:    types.StepStatusRunning,
+				StartedAt: &startedAt,
+				Agent: &types.AgentConfig{
+					Agent:  "test-agent",
+					Prompt: "Do work",
+					Nudge:  &types.NudgePolicy{After: "30s silence", Prompt: "Still there?", MaxNudges: 3},
+				},
+			}
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			orch.SetClock(func() time.Time { return clock })
+			ctx := context.Background()
+
+			// Tick every 10s for five minutes
+			for elapsed := 10 * time.Second; elapsed <= 5*time.Minute; elapsed += 10 * time.Second {
+				clock = startedAt.Add(elapsed)
+				if tt.resumeAt > 0 && elapsed >= tt.resumeAt {
+					orch.RecordAgentActivity(wf.ID, "test-agent")
+				}
+				orch.checkAgentNudges(ctx, wf)
+				orch.wg.Wait()
+			}
+
+			step := wf.Steps["agent-step"]
+			if step.Status != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", step.Status, tt.wantStatus)
+			}
+			injections := agents.GetInjections()
+			if len(injections) != tt.wantNudges || step.Nudges != tt.wantNudges {
+				t.Fatalf("nudges = %d (step.Nudges %d), want %d", len(injections), step.Nudges, tt.wantNudges)
+			}
+			for _, inj := range injections {
+				if inj.Prompt != "Still there?" || inj.Stabilize {
+					t.Errorf("injection = %+v, want the unstabilized nudge prompt", inj)
+				}
+			}
+			if tt.wantStatus == types.StepStatusFailed {
+				if step.Error == nil || step.Error.Type != types.StepErrorTimeout {
+					t.Errorf("error = %+v, want timeout", step.Error)
+				}
+				if len(agents.interrupted) != 1 {
+					t.Errorf("interrupted = %v, want the agent interrupted once", agents.interrupted)
+				}
+			}
+		})
+	}
+}
+
+// TestOrchestrator_AgentNudge_WithStallTimeout tests that stall reports,
+// which re-arm stall_timeout, do not count as agent activity: a nudge policy
+// with a longer after still nudges and fails a silent agent.
+func TestOrchestrator_AgentNudge_WithStallTimeout(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+
+	startedAt := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	clock := startedAt
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &startedAt,
+		Agent: &types.AgentConfig{
+			Agent:        "test-agent",
+			Prompt:       "Do work",
+			StallTimeout: "1m",
+			Nudge:        &types.NudgePolicy{After: "3m", Prompt: "Still there?", MaxNudges: 1},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetClock(func() time.Time { return clock })
+	router := NewEventRouter(testLogger())
+	orch.SetEventRouter(router)
+	stalled := router.RegisterWaiter("agent-stalled", nil, time.Minute)
+	ctx := context.Background()
+
+	// Tick every 10s for ten minutes, as processWorkflow does
+	for elapsed := 10 * time.Second; elapsed <= 10*time.Minute; elapsed += 10 * time.Second {
+		clock = startedAt.Add(elapsed)
+		orch.checkAgentStalls(ctx, wf)
+		orch.checkAgentNudges(ctx, wf)
+		orch.wg.Wait()
+	}
+
+	select {
+	case <-stalled:
+	default:
+		t.Error("expected agent-stalled events")
+	}
+	step := wf.Steps["agent-step"]
+	if step.Nudges != 1 {
+		t.Errorf("nudges = %d, want 1", step.Nudges)
+	}
+	if step.Status != types.StepStatusFailed || step.Error == nil || step.Error.Type != types.StepErrorTimeout {
+		t.Errorf("step = %s (%+v), want failed after max_nudges", step.Status, step.Error)
+	}
+}
+
+func TestOrchestrator_ExecutorLogLevels(t *testing.T) {
+	cfg := testConfig()
+	cfg.Logging.Executors = map[string]config.LogLevel{
+		"shell": config.LogLevelError,
+		"agent": config.LogLevelDebug,
+	}
+	var buf bytes.Buffer
+	logger := logging.New(config.LoggingConfig{Format: config.LogFormatJSON, Executors: cfg.Logging.Executors}, &buf, slog.LevelInfo)
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["agent-step"] = &types.Step{
+		ID:       "agent-step",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Agent:    &types.AgentConfig{Agent: "test-agent", Prompt: "Do work"},
+	}
+	wf.Steps["shell-step"] = &types.Step{
+		ID:       "shell-step",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "echo hi"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(cfg, store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, logger)
+	ctx := context.Background()
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow error = %v", err)
+	}
+	orch.wg.Wait()
+	if wf.Steps["shell-step"].Status != types.StepStatusDone {
+		t.Fatalf("shell step status = %v, want done", wf.Steps["shell-step"].Status)
+	}
+
+	levels := map[string]map[string]int{"agent-step": {}, "shell-step": {}}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("unmarshal log line %q: %v", line, err)
+		}
+		stepID, _ := record["step"].(string)
+		if stepID == "" {
+			stepID, _ = record["id"].(string)
+		}
+		if counts, ok := levels[stepID]; ok {
+			counts[record["level"].(string)]++
+		}
+	}
+
+	if n := len(levels["shell-step"]); n != 0 {
+		t.Errorf("shell step logged %v, want nothing below error", levels["shell-step"])
+	}
+	if levels["agent-step"]["DEBUG"] == 0 {
+		t.Errorf("agent step logged %v, want debug detail", levels["agent-step"])
+	}
+	if levels["agent-step"]["INFO"] == 0 {
+		t.Errorf("agent step logged %v, want its dispatch at info", levels["agent-step"])
+	}
+}
+
+func TestOrchestrator_StepTimeWindow(t *testing.T) {
+	clock := time.Date(2026, 3, 2, 8, 30, 0, 0, time.Local)
+	now := func() time.Time { return clock }
+
+	newWindowRun := func(outsideWindow string) (*mockRunStore, *types.Run) {
+		store := newMockRunStore()
+		wf := types.NewRun("test-wf", "test-template", nil)
+		wf.Status = types.RunStatusRunning
+		wf.Steps["deploy"] = &types.Step{
+			ID:            "deploy",
+			Executor:      types.ExecutorAgent,
+			Status:        types.StepStatusPending,
+			OnlyBetween:   "09:00-17:00",
+			OutsideWindow: outsideWindow,
+			Agent:         &types.AgentConfig{Agent: "test-agent", Prompt: "Deploy"},
+		}
+		wf.Steps["report"] = &types.Step{
+			ID:       "report",
+			Executor: types.ExecutorAgent,
+			Status:   types.StepStatusPending,
+			Needs:    []string{"deploy"},
+			Agent:    &types.AgentConfig{Agent: "test-agent", Prompt: "Report"},
+		}
+		store.workflows[wf.ID] = wf
+		return store, wf
+	}
+	ctx := context.Background()
+
+	t.Run("waits until the window opens", func(t *testing.T) {
+		clock = time.Date(2026, 3, 2, 8, 30, 0, 0, time.Local)
+		store, wf := newWindowRun("")
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		orch.SetClock(now)
+
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		if wf.Steps["deploy"].Status != types.StepStatusPending {
+			t.Fatalf("deploy status before window = %v, want pending", wf.Steps["deploy"].Status)
+		}
+
+		clock = time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		if wf.Steps["deploy"].Status != types.StepStatusRunning {
+			t.Errorf("deploy status in window = %v, want running", wf.Steps["deploy"].Status)
+		}
+	})
+
+	t.Run("waits after the window closes", func(t *testing.T) {
+		clock = time.Date(2026, 3, 2, 17, 0, 0, 0, time.Local)
+		store, wf := newWindowRun(types.OutsideWindowWait)
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		orch.SetClock(now)
+
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		if wf.Steps["deploy"].Status != types.StepStatusPending {
+			t.Errorf("deploy status at window end = %v, want pending", wf.Steps["deploy"].Status)
+		}
+		if wf.Status != types.RunStatusRunning {
+			t.Errorf("run status = %v, want running while the step waits", wf.Status)
+		}
+	})
+
+	t.Run("skips outside the window", func(t *testing.T) {
+		clock = time.Date(2026, 3, 2, 22, 0, 0, 0, time.Local)
+		store, wf := newWindowRun(types.OutsideWindowSkip)
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		orch.SetClock(now)
+
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		deploy := wf.Steps["deploy"]
+		if deploy.Status != types.StepStatusSkipped {
+			t.Fatalf("deploy status = %v, want skipped", deploy.Status)
+		}
+		if deploy.Error == nil || !strings.Contains(deploy.Error.Message, "outside time window 09:00-17:00") {
+			t.Errorf("deploy error = %+v, want time window reason", deploy.Error)
+		}
+
+		// Dependents cascade and the run finishes without failing
+		for i := 0; i < 2; i++ {
+			if err := orch.processWorkflow(ctx, wf); err != nil {
+				t.Fatalf("processWorkflow error = %v", err)
+			}
+		}
+		if wf.Steps["report"].Status != types.StepStatusSkipped {
+			t.Errorf("report status = %v, want skipped", wf.Steps["report"].Status)
+		}
+		if wf.Status != types.RunStatusDone {
+			t.Errorf("run status = %v, want done", wf.Status)
+		}
+	})
+
+	t.Run("invalid window fails the step", func(t *testing.T) {
+		store, wf := newWindowRun("")
+		wf.Steps["deploy"].OnlyBetween = "morning"
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		orch.SetClock(now)
+
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		if wf.Steps["deploy"].Status != types.StepStatusFailed {
+			t.Errorf("deploy status = %v, want failed", wf.Steps["deploy"].Status)
+		}
+	})
+}
+
+// TestOrchestrator_SkipReasons tests that every skipped step records a
+// structured reason: a failed branch condition skips its dependent, which in
+// turn skips its own, and a step outside its window is skipped on its own.
+func TestOrchestrator_SkipReasons(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["check"] = &types.Step{
+		ID:       "check",
+		Executor: types.ExecutorBranch,
+		Status:   types.StepStatusPending,
+		Branch:   &types.BranchConfig{Condition: "exit 1"},
+	}
+	wf.Steps["deploy"] = &types.Step{
+		ID:       "deploy",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"check"},
+		Shell:    &types.ShellConfig{Command: "echo deploy"},
+	}
+	wf.Steps["notify"] = &types.Step{
+		ID:       "notify",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"deploy"},
+		Shell:    &types.ShellConfig{Command: "echo notify"},
+	}
+	wf.Steps["vacuum"] = &types.Step{
+		ID:            "vacuum",
+		Executor:      types.ExecutorShell,
+		Status:        types.StepStatusPending,
+		OnlyBetween:   "09:00-17:00",
+		OutsideWindow: types.OutsideWindowSkip,
+		Shell:         &types.ShellConfig{Command: "echo vacuum"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetClock(func() time.Time { return time.Date(2026, 3, 2, 22, 0, 0, 0, time.Local) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run error = %v", err)
+	}
+
+	if wf.Steps["check"].Status != types.StepStatusFailed {
+		t.Fatalf("check status = %v, want failed", wf.Steps["check"].Status)
+	}
+	want := map[string]types.SkipReason{
+		"deploy": {Kind: types.SkipReasonDependencyFailed, Dependency: "check", Message: `dependency "check" failed`},
+		"notify": {Kind: types.SkipReasonDependencySkipped, Dependency: "deploy", Message: `dependency "deploy" was skipped`},
+		"vacuum": {Kind: types.SkipReasonOutsideWindow, Message: "outside time window 09:00-17:00"},
+	}
+	for id, reason := range want {
+		step := wf.Steps[id]
+		if step.Status != types.StepStatusSkipped {
+			t.Errorf("%s status = %v, want skipped", id, step.Status)
+			continue
+		}
+		if step.SkipReason == nil || *step.SkipReason != reason {
+			t.Errorf("%s skip reason = %+v, want %+v", id, step.SkipReason, reason)
+		}
+	}
+}
+
+// TestOrchestrator_CleanupOnCompletion tests that cleanup runs when workflow completes.
+func TestOrchestrator_CleanupOnCompletion(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	shell := newMockShellRunner()
+	expander := &mockTemplateExpander{}
+	logger := testLogger()
+
+	// Create workflow with cleanup_on_success script (opt-in cleanup)
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.CleanupOnSuccess = "echo cleanup executed"
+	wf.Steps["step-1"] = &types.Step{
+		ID:       "step-1",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "echo hello"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err := orch.Run(ctx)
+	if err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+
+	// Workflow should be done (cleanup was successful)
+	finalWf, _ := store.Get(ctx, wf.ID)
+	if finalWf.Status != types.RunStatusDone {
+		t.Errorf("Workflow status = %v, want done", finalWf.Status)
+	}
+}
+
+// TestOrchestrator_RunCleanup tests the RunCleanup method directly.
+func TestOrchestrator_RunCleanup(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	shell := newMockShellRunner()
+	expander := &mockTemplateExpander{}
+	logger := testLogger()
+
+	// Create workflow with cleanup_on_success script (testing RunCleanup with Done reason)
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.CleanupOnSuccess = "echo cleanup"
+	store.workflows[wf.ID] = wf
+
+	// Register some agents
+	agents.running["agent-1"] = true
+	agents.running["agent-2"] = true
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+
+	ctx := context.Background()
+	err := orch.RunCleanup(ctx, wf, types.RunStatusDone)
+	if err != nil {
+		t.Fatalf("RunCleanup error = %v", err)
+	}
+
+	// Workflow should be in done state
+	if wf.Status != types.RunStatusDone {
+		t.Errorf("Workflow status = %v, want done", wf.Status)
+	}
+
+	// DoneAt should be set
+	if wf.DoneAt == nil {
+		t.Error("Workflow DoneAt should be set")
+	}
+
+	// All agents should have been killed
+	for agentID, running := range agents.running {
+		if running {
+			t.Errorf("Agent %s should have been killed", agentID)
+		}
+	}
+}
+
+// TestOrchestrator_RunCleanup_FailedWorkflow tests cleanup for failed workflows.
+func TestOrchestrator_RunCleanup_FailedWorkflow(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	shell := newMockShellRunner()
+	expander := &mockTemplateExpander{}
+	logger := testLogger()
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.CleanupOnFailure = "echo cleanup"
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+
+	ctx := context.Background()
 	err := orch.RunCleanup(ctx, wf, types.RunStatusFailed)
 	if err != nil {
 		t.Fatalf("RunCleanup error = %v", err)
@@ -1097,6 +2681,167 @@ func TestOrchestrator_RunCleanup_FailedWorkflow(t *testing.T) {
 	}
 }
 
+// TestOrchestrator_RunCleanup_RunContext tests that cleanup scripts receive the
+// run context both substituted into the script and in the environment.
+func TestOrchestrator_RunCleanup_RunContext(t *testing.T) {
+	store := newMockRunStore()
+	outFile := filepath.Join(t.TempDir(), "cleanup.out")
+
+	wf := types.NewRun("test-wf", "test-template", map[string]any{"workspace": "ws-1"})
+	wf.Status = types.RunStatusRunning
+	wf.StartedAt = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	wf.Steps["build"] = &types.Step{ID: "build", Executor: types.ExecutorShell, Status: types.StepStatusDone,
+		Outputs: map[string]any{"artifact": "app.tar"}}
+	wf.Steps["test"] = &types.Step{ID: "test", Executor: types.ExecutorShell, Status: types.StepStatusFailed}
+	wf.Steps["lint"] = &types.Step{ID: "lint", Executor: types.ExecutorShell, Status: types.StepStatusFailed}
+	wf.CleanupOnFailure = `echo "{{workflow_id}} {{run_status}} {{run_duration}} {{step_count}} {{failed_steps}} {{workspace}} {{build.outputs.artifact}}" > ` + outFile + `
+echo "$MEOW_WORKFLOW $MEOW_RUN_STATUS $MEOW_RUN_DURATION $MEOW_STEP_COUNT $MEOW_FAILED_STEPS $workspace" >> ` + outFile
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetClock(func() time.Time { return wf.StartedAt.Add(90 * time.Second) })
+
+	if err := orch.RunCleanup(context.Background(), wf, types.RunStatusFailed); err != nil {
+		t.Fatalf("RunCleanup error = %v", err)
+	}
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("cleanup script did not run: %v", err)
+	}
+	want := "test-wf failed 1m30s 3 lint,test ws-1 app.tar\n" +
+		"test-wf failed 1m30s 3 lint,test ws-1\n"
+	if string(data) != want {
+		t.Errorf("cleanup output = %q, want %q", data, want)
+	}
+}
+
+func TestOrchestrator_RunCleanup_LiteralBraces(t *testing.T) {
+	store := newMockRunStore()
+	outFile := filepath.Join(t.TempDir(), "cleanup.out")
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.CleanupOnFailure = `echo '{{.Names}} {{workflow_id}}' > ` + outFile
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if err := orch.RunCleanup(context.Background(), wf, types.RunStatusFailed); err != nil {
+		t.Fatalf("RunCleanup error = %v", err)
+	}
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("cleanup script did not run: %v", err)
+	}
+	if want := "{{.Names}} test-wf\n"; string(data) != want {
+		t.Errorf("cleanup output = %q, want %q", data, want)
+	}
+}
+
+func TestOrchestrator_RunCleanup_ConditionalCleanup(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs map[string]any
+		want    string
+	}{
+		{"resource provisioned", map[string]any{"resource_id": "res-42"}, "release locks\ndeprovision res-42\n"},
+		{"nothing provisioned", nil, "release locks\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			outFile := filepath.Join(t.TempDir(), "cleanup.out")
+
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.Steps["provision"] = &types.Step{ID: "provision", Executor: types.ExecutorShell, Status: types.StepStatusDone,
+				Outputs: tt.outputs}
+			wf.CleanupOnFailure = "echo release locks > " + outFile
+			wf.CleanupWhen = []types.ConditionalCleanup{
+				{
+					Condition: `test -n "{{provision.outputs.resource_id}}"`,
+					Script:    "echo deprovision {{provision.outputs.resource_id}} >> " + outFile,
+				},
+				{Condition: "false", Script: "echo never >> " + outFile},
+			}
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			if err := orch.RunCleanup(context.Background(), wf, types.RunStatusFailed); err != nil {
+				t.Fatalf("RunCleanup error = %v", err)
+			}
+
+			data, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("cleanup script did not run: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("cleanup output = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+// markerAgentManager stands in for agents whose liveness a cleanup script can
+// check: the marker file exists until KillAll removes it.
+type markerAgentManager struct {
+	*mockAgentManager
+	marker string
+}
+
+func (m *markerAgentManager) KillAll(ctx context.Context, wf *types.Run) error {
+	os.Remove(m.marker)
+	return m.mockAgentManager.KillAll(ctx, wf)
+}
+
+func TestOrchestrator_RunCleanup_Order(t *testing.T) {
+	tests := []struct {
+		order string
+		want  string
+	}{
+		{"", "dead"},
+		{types.CleanupAgentsFirst, "dead"},
+		{types.CleanupScriptFirst, "alive"},
+	}
+	for _, tt := range tests {
+		t.Run("order="+tt.order, func(t *testing.T) {
+			dir := t.TempDir()
+			marker := filepath.Join(dir, "agent-alive")
+			outFile := filepath.Join(dir, "cleanup.out")
+			if err := os.WriteFile(marker, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			store := newMockRunStore()
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.CleanupOrder = tt.order
+			wf.CleanupOnSuccess = fmt.Sprintf("if test -f %s; then echo alive; else echo dead; fi > %s", marker, outFile)
+			store.workflows[wf.ID] = wf
+
+			agents := &markerAgentManager{mockAgentManager: newMockAgentManager(), marker: marker}
+			agents.running["worker"] = true
+			orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+			if err := orch.RunCleanup(context.Background(), wf, types.RunStatusDone); err != nil {
+				t.Fatalf("RunCleanup error = %v", err)
+			}
+
+			data, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("cleanup script did not run: %v", err)
+			}
+			if got := strings.TrimSpace(string(data)); got != tt.want {
+				t.Errorf("script saw agents %s, want %s", got, tt.want)
+			}
+			if agents.running["worker"] {
+				t.Error("agent still running after cleanup")
+			}
+		})
+	}
+}
+
 // TestOrchestrator_RunCleanup_Stopped tests cleanup for stopped workflows.
 func TestOrchestrator_RunCleanup_Stopped(t *testing.T) {
 	store := newMockRunStore()
@@ -1243,6 +2988,103 @@ func TestOrchestrator_Recover_PartialExpansion(t *testing.T) {
 	}
 }
 
+// TestOrchestrator_Recover_ForeachResumesIterations tests that recovering a fully
+// expanded foreach keeps finished iterations and re-runs only the others.
+func TestOrchestrator_Recover_ForeachResumesIterations(t *testing.T) {
+	store := newMockRunStore()
+	runLog := filepath.Join(t.TempDir(), "runs.log")
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+
+	now := time.Now()
+	wf.Steps["fan"] = &types.Step{
+		ID:        "fan",
+		Executor:  types.ExecutorForeach,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Foreach:   &types.ForeachConfig{Items: `["a","b","c","d"]`, ItemVar: "item", Template: ".work"},
+	}
+	// Iterations 0 and 1 finished before the crash, 2 was mid-command, 3 had not started
+	statuses := []types.StepStatus{types.StepStatusDone, types.StepStatusDone, types.StepStatusRunning, types.StepStatusPending}
+	for i, status := range statuses {
+		id := fmt.Sprintf("fan.%d.work", i)
+		step := &types.Step{
+			ID:           id,
+			Executor:     types.ExecutorShell,
+			Status:       status,
+			ExpandedFrom: "fan",
+			Shell: &types.ShellConfig{
+				Command: fmt.Sprintf("echo %d >> %s && echo rerun-%d", i, runLog, i),
+				Outputs: map[string]types.OutputSource{"value": {Source: "stdout"}},
+			},
+		}
+		if status != types.StepStatusPending {
+			step.StartedAt = &now
+		}
+		if status == types.StepStatusDone {
+			step.DoneAt = &now
+			step.Outputs = map[string]any{"value": fmt.Sprintf("original-%d", i)}
+		}
+		wf.Steps[id] = step
+		wf.Steps["fan"].ExpandedInto = append(wf.Steps["fan"].ExpandedInto, id)
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Recover(ctx); err != nil {
+		t.Fatalf("Recover error = %v", err)
+	}
+
+	if wf.Steps["fan"].Status != types.StepStatusRunning {
+		t.Errorf("foreach status after recovery = %v, want running", wf.Steps["fan"].Status)
+	}
+	if len(wf.Steps["fan"].ExpandedInto) != 4 {
+		t.Errorf("foreach ExpandedInto = %v, want all four children kept", wf.Steps["fan"].ExpandedInto)
+	}
+	for i, want := range []types.StepStatus{types.StepStatusDone, types.StepStatusDone, types.StepStatusPending, types.StepStatusPending} {
+		id := fmt.Sprintf("fan.%d.work", i)
+		step, ok := wf.Steps[id]
+		if !ok {
+			t.Fatalf("%s was deleted by recovery", id)
+		}
+		if step.Status != want {
+			t.Errorf("%s status after recovery = %v, want %v", id, step.Status, want)
+		}
+	}
+
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run error = %v", err)
+	}
+
+	if wf.Status != types.RunStatusDone {
+		t.Fatalf("workflow status = %v, want done", wf.Status)
+	}
+	ran, err := os.ReadFile(runLog)
+	if err != nil {
+		t.Fatalf("reading run log: %v", err)
+	}
+	if got := strings.Fields(string(ran)); len(got) != 2 || got[0] == "0" || got[0] == "1" || got[1] == "0" || got[1] == "1" {
+		t.Errorf("iterations re-run = %v, want only 2 and 3", got)
+	}
+
+	results, _ := wf.Steps["fan"].Outputs["results"].([]any)
+	if len(results) != 4 {
+		t.Fatalf("foreach results = %#v, want 4 entries", wf.Steps["fan"].Outputs["results"])
+	}
+	for i, want := range []string{"original-0", "original-1", "rerun-2", "rerun-3"} {
+		entry, _ := results[i].(map[string]any)
+		outputs, _ := entry["work"].(map[string]any)
+		if outputs["value"] != want {
+			t.Errorf("results[%d].work.value = %#v, want %q", i, outputs["value"], want)
+		}
+	}
+}
+
 // TestOrchestrator_Recover_AgentStepDeadAgent tests resetting agent steps when agent is dead.
 func TestOrchestrator_Recover_AgentStepDeadAgent(t *testing.T) {
 	store := newMockRunStore()
@@ -1326,6 +3168,74 @@ func TestOrchestrator_Recover_AgentStepAliveAgent(t *testing.T) {
 	}
 }
 
+// TestOrchestrator_Recover_AliveAgentCompletesOnDone tests that a recovered
+// orchestrator waits on a live agent without re-injecting its prompt, and
+// completes the step when the agent's meow done arrives.
+func TestOrchestrator_Recover_AliveAgentCompletesOnDone(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+
+	clock := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	startedAt := clock.Add(-time.Hour)
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["agent-step"] = &types.Step{
+		ID:        "agent-step",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &startedAt,
+		Agent: &types.AgentConfig{
+			Agent:        "test-agent",
+			Prompt:       "Do work",
+			StallTimeout: "5m",
+			OnStall:      types.OnStallNudge,
+			Outputs:      map[string]types.AgentOutputDef{"result": {Required: true, Type: "string"}},
+		},
+	}
+	store.workflows[wf.ID] = wf
+	agents.running["test-agent"] = true
+
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetClock(func() time.Time { return clock })
+	orch.SetEventRouter(NewEventRouter(testLogger()))
+	ctx := context.Background()
+
+	if err := orch.Recover(ctx); err != nil {
+		t.Fatalf("Recover error = %v", err)
+	}
+
+	// The first ticks after recovery neither re-inject the prompt nor treat
+	// the hour the step has been running as a stall
+	for i := 0; i < 2; i++ {
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+	}
+	orch.wg.Wait()
+	if injections := agents.GetInjections(); len(injections) != 0 {
+		t.Fatalf("recovered agent was re-injected: %+v", injections)
+	}
+
+	err := orch.HandleStepDone(ctx, &ipc.StepDoneMessage{
+		Workflow: wf.ID,
+		Agent:    "test-agent",
+		Step:     "agent-step",
+		Outputs:  map[string]any{"result": "finished"},
+	})
+	if err != nil {
+		t.Fatalf("HandleStepDone error = %v", err)
+	}
+
+	step := wf.Steps["agent-step"]
+	if step.Status != types.StepStatusDone {
+		t.Fatalf("step status = %v, want done", step.Status)
+	}
+	if step.Outputs["result"] != "finished" {
+		t.Errorf("outputs = %v, want result=finished", step.Outputs)
+	}
+}
+
 // TestOrchestrator_Recover_AgentStepCompletingToRunning tests that completing agent steps revert to running.
 func TestOrchestrator_Recover_AgentStepCompletingToRunning(t *testing.T) {
 	store := newMockRunStore()
@@ -1522,6 +3432,7 @@ func TestOrchestrator_ConcurrentStepCompletionWithOrchTick(t *testing.T) {
 			StartedAt: &now,
 			Agent:     &types.AgentConfig{Agent: agentID, Prompt: "Work"},
 		}
+		agents.running[agentID] = true
 	}
 
 	// 2 pending shell steps
@@ -1832,27 +3743,147 @@ func TestHandleBranch_TracksPendingCommand(t *testing.T) {
 
 	orch := New(testConfig(), store, agents, shell, expander, logger)
 
-	ctx := context.Background()
-	err := orch.handleBranch(ctx, wf, wf.Steps["branch-step"])
-	if err != nil {
+	ctx := context.Background()
+	err := orch.handleBranch(ctx, wf, wf.Steps["branch-step"])
+	if err != nil {
+		t.Fatalf("handleBranch error = %v", err)
+	}
+
+	// pendingCommands should contain the composite key workflowID:stepID
+	cancel, ok := orch.pendingCommands.Load("test-wf:branch-step")
+	if !ok {
+		t.Fatal("pendingCommands does not contain test-wf:branch-step")
+	}
+
+	// Value should be a cancel function
+	cancelFunc, ok := cancel.(context.CancelFunc)
+	if !ok {
+		t.Fatalf("pendingCommands value is %T, want context.CancelFunc", cancel)
+	}
+
+	// Cancel should be callable (won't panic)
+	cancelFunc()
+	orch.wg.Wait()
+}
+
+// TestShellFailure_ClassifiedByStderr verifies that [shell.error_patterns]
+// set the error type of failed shell steps.
+func TestShellFailure_ClassifiedByStderr(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		onError  string
+		wantType types.StepErrorType
+	}{
+		{name: "matching pattern", command: "echo 'open /etc/shadow: Permission denied' >&2; exit 1", wantType: "permission"},
+		{name: "first match wins", command: "echo 'permission denied; connection refused' >&2; exit 1", wantType: "permission"},
+		{name: "no match", command: "echo 'syntax error' >&2; exit 2", wantType: types.StepErrorCommandFailed},
+		{name: "on_error continue", command: "echo 'connection refused' >&2; exit 1", onError: "continue", wantType: "network"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.Steps["cmd"] = &types.Step{
+				ID:       "cmd",
+				Executor: types.ExecutorShell,
+				Status:   types.StepStatusPending,
+				Shell:    &types.ShellConfig{Command: tt.command, OnError: tt.onError},
+			}
+			store.workflows[wf.ID] = wf
+
+			cfg := testConfig()
+			cfg.Shell.ErrorPatterns = []config.ErrorPattern{
+				{Match: "(?i)permission denied", Type: "permission"},
+				{Match: "connection refused|could not resolve host", Type: "network"},
+			}
+			orch := New(cfg, store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			if err := orch.processWorkflow(context.Background(), wf); err != nil {
+				t.Fatalf("processWorkflow error = %v", err)
+			}
+			orch.wg.Wait()
+
+			step := wf.Steps["cmd"]
+			if tt.onError == "continue" {
+				if step.Status != types.StepStatusDone || step.Outputs["error_type"] != string(tt.wantType) {
+					t.Errorf("status = %v, outputs = %v, want done with error_type %q", step.Status, step.Outputs, tt.wantType)
+				}
+				return
+			}
+			if step.Status != types.StepStatusFailed || step.Error == nil {
+				t.Fatalf("status = %v, error = %+v, want failed", step.Status, step.Error)
+			}
+			if step.Error.Type != tt.wantType {
+				t.Errorf("Error.Type = %q, want %q", step.Error.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+// TestHandleBranch_CancelKeepsPartialOutput verifies that cancelling an
+// in-flight condition records the output produced so far on the step.
+func TestHandleBranch_CancelKeepsPartialOutput(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["long-step"] = &types.Step{
+		ID:       "long-step",
+		Executor: types.ExecutorBranch,
+		Status:   types.StepStatusPending,
+		Branch: &types.BranchConfig{
+			Condition: "echo progress-1; echo warning-1 >&2; sleep 10",
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	if err := orch.handleBranch(context.Background(), wf, wf.Steps["long-step"]); err != nil {
 		t.Fatalf("handleBranch error = %v", err)
 	}
+	// Let the command emit its output before cancelling
+	time.Sleep(200 * time.Millisecond)
 
-	// pendingCommands should contain the composite key workflowID:stepID
-	cancel, ok := orch.pendingCommands.Load("test-wf:branch-step")
-	if !ok {
-		t.Fatal("pendingCommands does not contain test-wf:branch-step")
+	start := time.Now()
+	orch.cancelPendingCommands()
+	orch.wg.Wait()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("cancellation took %v, want prompt exit", elapsed)
 	}
 
-	// Value should be a cancel function
-	cancelFunc, ok := cancel.(context.CancelFunc)
-	if !ok {
-		t.Fatalf("pendingCommands value is %T, want context.CancelFunc", cancel)
+	step := wf.Steps["long-step"]
+	if step.Status != types.StepStatusRunning {
+		t.Errorf("Step status = %v, want running (left for recovery)", step.Status)
+	}
+	if step.InterruptedAt == nil {
+		t.Error("Step InterruptedAt should be set")
+	}
+	if step.Error == nil {
+		t.Fatal("Step Error should hold the partial output")
+	}
+	if step.Error.Type != types.StepErrorInterrupted {
+		t.Errorf("Error.Type = %q, want %q", step.Error.Type, types.StepErrorInterrupted)
+	}
+	if step.Error.Stdout != "progress-1" {
+		t.Errorf("Error.Stdout = %q, want %q", step.Error.Stdout, "progress-1")
+	}
+	if step.Error.Stderr != "warning-1" {
+		t.Errorf("Error.Stderr = %q, want %q", step.Error.Stderr, "warning-1")
+	}
+	if !strings.Contains(step.Error.Command, "sleep 10") {
+		t.Errorf("Error.Command = %q, want the condition", step.Error.Command)
 	}
 
-	// Cancel should be callable (won't panic)
-	cancelFunc()
-	orch.wg.Wait()
+	// A later attempt discards the stale partial output
+	step.Status = types.StepStatusPending
+	if err := step.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if step.Error != nil {
+		t.Errorf("Start() kept interrupted error %+v, want nil", step.Error)
+	}
 }
 
 // TestParallelBranchSteps verifies that multiple branch steps with the same
@@ -2096,99 +4127,473 @@ func TestBranchCondition_TimeoutOutcome(t *testing.T) {
 			},
 		},
 	}
-	store.workflows[wf.ID] = wf
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := orch.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Re-fetch workflow
+	wf, _ = store.Get(ctx, wf.ID)
+
+	// Verify outcome
+	if wf.Steps["branch-step"].Outputs["outcome"] != "timeout" {
+		t.Errorf("Branch step outcome = %v, want 'timeout'", wf.Steps["branch-step"].Outputs["outcome"])
+	}
+
+	// Verify on_timeout was expanded
+	if _, ok := wf.Steps["branch-step.on-timeout-step"]; !ok {
+		t.Error("on_timeout step should be expanded")
+	}
+
+	// Verify branch step is done
+	if wf.Steps["branch-step"].Status != types.StepStatusDone {
+		t.Errorf("Branch step status = %v, want done", wf.Steps["branch-step"].Status)
+	}
+}
+
+// TestBranchCondition_OutputCapture tests that stdout/stderr are captured
+// in step outputs.
+func TestBranchCondition_OutputCapture(t *testing.T) {
+	store := newMockRunStore()
+	agents := newMockAgentManager()
+	shell := newMockShellRunner()
+	expander := &mockTemplateExpander{}
+	logger := testLogger()
+
+	// Create workflow with branch step that captures outputs
+	// Using a real shell command since branch conditions execute actual commands
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["branch-step"] = &types.Step{
+		ID:       "branch-step",
+		Executor: types.ExecutorBranch,
+		Status:   types.StepStatusPending,
+		Branch: &types.BranchConfig{
+			Condition: "echo hello && echo debug >&2",
+			Outputs: map[string]types.OutputSource{
+				"out": {Source: "stdout"},
+				"err": {Source: "stderr"},
+			},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, agents, shell, expander, logger)
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := orch.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Re-fetch workflow
+	wf, _ = store.Get(ctx, wf.ID)
+
+	// Verify outputs
+	outputs := wf.Steps["branch-step"].Outputs
+	if outputs["outcome"] != "true" {
+		t.Errorf("outcome = %v, want 'true'", outputs["outcome"])
+	}
+	if outputs["exit_code"] != 0 {
+		t.Errorf("exit_code = %v, want 0", outputs["exit_code"])
+	}
+	// Stdout should contain "hello" (may have trailing newline)
+	if out, ok := outputs["out"].(string); !ok || !strings.Contains(out, "hello") {
+		t.Errorf("out = %v, want to contain 'hello'", outputs["out"])
+	}
+	// Stderr should contain "debug" (may have trailing newline)
+	if err, ok := outputs["err"].(string); !ok || !strings.Contains(err, "debug") {
+		t.Errorf("err = %v, want to contain 'debug'", outputs["err"])
+	}
+
+	// Verify step is done (no targets to expand)
+	if wf.Steps["branch-step"].Status != types.StepStatusDone {
+		t.Errorf("Branch step status = %v, want done", wf.Steps["branch-step"].Status)
+	}
+}
+
+// TestBranchCondition_CapturesDuration tests that branch and shell steps
+// record how long their condition ran as the duration_ms output.
+func TestBranchCondition_CapturesDuration(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["wait-branch"] = &types.Step{
+		ID:       "wait-branch",
+		Executor: types.ExecutorBranch,
+		Status:   types.StepStatusPending,
+		Branch:   &types.BranchConfig{Condition: "sleep 0.2"},
+	}
+	wf.Steps["wait-shell"] = &types.Step{
+		ID:       "wait-shell",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "sleep 0.2"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	wf, _ = store.Get(ctx, wf.ID)
+	for _, id := range []string{"wait-branch", "wait-shell"} {
+		ms, ok := wf.Steps[id].Outputs["duration_ms"].(int64)
+		if !ok {
+			t.Errorf("%s duration_ms = %#v, want int64", id, wf.Steps[id].Outputs["duration_ms"])
+			continue
+		}
+		if ms < 200 || ms > 1000 {
+			t.Errorf("%s duration_ms = %d, want about 200", id, ms)
+		}
+	}
+}
+
+func TestShellStep_OutputAssertions(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["deploy"] = &types.Step{
+		ID:       "deploy",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell: &types.ShellConfig{
+			Command: "echo 'status=degraded'",
+			Outputs: map[string]types.OutputSource{
+				"status": {Source: "stdout", Pattern: `status=(\S+)`},
+			},
+		},
+		Assert: map[string]types.OutputAssertion{"status": {Equals: "ok"}},
+	}
+	wf.Steps["healthy"] = &types.Step{
+		ID:       "healthy",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "echo 'v2.0.1'", Outputs: map[string]types.OutputSource{"version": {Source: "stdout"}}},
+		Assert:   map[string]types.OutputAssertion{"version": {Matches: `^v2\.`}},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	wf, _ = store.Get(ctx, wf.ID)
+	if healthy := wf.Steps["healthy"]; healthy.Status != types.StepStatusDone {
+		t.Errorf("healthy status = %v, want done (error: %+v)", healthy.Status, healthy.Error)
+	}
+
+	deploy := wf.Steps["deploy"]
+	if deploy.Status != types.StepStatusFailed {
+		t.Fatalf("deploy status = %v, want failed", deploy.Status)
+	}
+	if deploy.Error == nil || deploy.Error.Type != types.StepErrorAssertionFailed {
+		t.Fatalf("deploy error = %+v, want type assertion_failed", deploy.Error)
+	}
+	if want := `status = "degraded", want "ok"`; !strings.Contains(deploy.Error.Message, want) {
+		t.Errorf("error message = %q, want it to contain %q", deploy.Error.Message, want)
+	}
+	if want := "status:\n  - want \"ok\"\n  + got  \"degraded\"\n"; deploy.Error.Output != want {
+		t.Errorf("error diff = %q, want %q", deploy.Error.Output, want)
+	}
+	if deploy.Error.Command != "echo 'status=degraded'" {
+		t.Errorf("error command = %q", deploy.Error.Command)
+	}
+}
+
+func TestBranch_ConditionOutputsFeedTarget(t *testing.T) {
+	result := filepath.Join(t.TempDir(), "result")
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["check"] = &types.Step{
+		ID:       "check",
+		Executor: types.ExecutorBranch,
+		Status:   types.StepStatusPending,
+		Branch: &types.BranchConfig{
+			Condition: "printf 'suite: unit\\npassed: 12\\ncoverage: 87%%\\n'",
+			Outputs: map[string]types.OutputSource{
+				"suite":    {Source: "stdout", Line: 1, Pattern: `suite: (\S+)`},
+				"coverage": {Source: "stdout", Line: -1, Pattern: `(\d+)`, Type: "json"},
+			},
+			OnTrue: &types.BranchTarget{
+				Variables: map[string]any{"suite": "{{check.outputs.suite}}"},
+				Inline: []types.InlineStep{{
+					ID:       "report",
+					Executor: types.ExecutorShell,
+					Command:  "echo {{check.outputs.suite}} {{check.outputs.coverage}} > " + result,
+				}},
+			},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	wf, _ = store.Get(ctx, wf.ID)
+	check := wf.Steps["check"]
+	if check.Status != types.StepStatusDone {
+		t.Fatalf("check status = %v, want done (error: %+v)", check.Status, check.Error)
+	}
+	if check.Outputs["suite"] != "unit" {
+		t.Errorf("suite = %#v, want \"unit\"", check.Outputs["suite"])
+	}
+	if check.Outputs["coverage"] != float64(87) {
+		t.Errorf("coverage = %#v, want 87", check.Outputs["coverage"])
+	}
+	data, err := os.ReadFile(result)
+	if err != nil {
+		t.Fatalf("on_true step did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "unit 87" {
+		t.Errorf("on_true step wrote %q, want %q", got, "unit 87")
+	}
+	// The target's variables are resolved for the expansion only
+	if got := check.Branch.OnTrue.Variables["suite"]; got != "{{check.outputs.suite}}" {
+		t.Errorf("on_true variable suite = %#v, want the unresolved reference", got)
+	}
+}
+
+func TestOrchestrator_HandleStepDone_OutputAssertion(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	wf.Steps["review"] = &types.Step{
+		ID:        "review",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent:     &types.AgentConfig{Agent: "reviewer", Prompt: "Review"},
+		Assert:    map[string]types.OutputAssertion{"verdict": {Matches: "^(approve|lgtm)$"}},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	err := orch.HandleStepDone(context.Background(), &ipc.StepDoneMessage{
+		Workflow: wf.ID,
+		Agent:    "reviewer",
+		Step:     "review",
+		Outputs:  map[string]any{"verdict": "reject"},
+	})
+	if err != nil {
+		t.Fatalf("HandleStepDone error = %v", err)
+	}
+
+	step := wf.Steps["review"]
+	if step.Status != types.StepStatusFailed {
+		t.Fatalf("status = %v, want failed", step.Status)
+	}
+	if step.Error == nil || step.Error.Type != types.StepErrorAssertionFailed {
+		t.Fatalf("error = %+v, want type assertion_failed", step.Error)
+	}
+	if step.Outputs["verdict"] != "reject" {
+		t.Errorf("outputs = %v, want the reported outputs kept", step.Outputs)
+	}
+}
+
+func TestOrchestrator_HandleStepDone_AgentError(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	wf.Steps["impl"] = &types.Step{
+		ID:        "impl",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Retries:   1,
+		Agent:     &types.AgentConfig{Agent: "worker", Prompt: "Implement"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	err := orch.HandleStepDone(context.Background(), &ipc.StepDoneMessage{
+		Workflow: wf.ID,
+		Agent:    "worker",
+		Error:    "tests do not compile",
+	})
+	if err != nil {
+		t.Fatalf("HandleStepDone error = %v", err)
+	}
+
+	step := wf.Steps["impl"]
+	if step.Status != types.StepStatusFailed {
+		t.Fatalf("status = %v, want failed", step.Status)
+	}
+	if step.Error == nil || step.Error.Type != types.StepErrorAgentFailed || step.Error.Message != "tests do not compile" {
+		t.Fatalf("error = %+v, want type agent_failed with the reported message", step.Error)
+	}
+
+	// The failure is handled like any other: the step has a retry left
+	if !orch.retryFailedSteps(wf) {
+		t.Fatal("retryFailedSteps() = false, want the step retried")
+	}
+	if step.Status != types.StepStatusPending {
+		t.Errorf("status after retry = %v, want pending", step.Status)
+	}
+}
 
-	orch := New(testConfig(), store, agents, shell, expander, logger)
-	orch.SetWorkflowID(wf.ID)
+func TestOrchestrator_HandleStepDone_CaptureTranscript(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	wf.Steps["review"] = &types.Step{
+		ID:        "review",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent:     &types.AgentConfig{Agent: "reviewer", Prompt: "Review", CaptureTranscript: true},
+	}
+	store.workflows[wf.ID] = wf
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	agents := newMockAgentManager()
+	agents.transcripts = map[string]string{"reviewer": "> Review\nLooks good.\n"}
+	artifactsDir := t.TempDir()
+	orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetArtifactsDir(artifactsDir)
 
-	err := orch.Run(ctx)
+	err := orch.HandleStepDone(context.Background(), &ipc.StepDoneMessage{
+		Workflow: wf.ID,
+		Agent:    "reviewer",
+		Step:     "review",
+		Outputs:  map[string]any{"verdict": "approve"},
+	})
 	if err != nil {
-		t.Fatalf("Run() error = %v", err)
+		t.Fatalf("HandleStepDone error = %v", err)
 	}
 
-	// Re-fetch workflow
-	wf, _ = store.Get(ctx, wf.ID)
-
-	// Verify outcome
-	if wf.Steps["branch-step"].Outputs["outcome"] != "timeout" {
-		t.Errorf("Branch step outcome = %v, want 'timeout'", wf.Steps["branch-step"].Outputs["outcome"])
+	step := wf.Steps["review"]
+	if step.Status != types.StepStatusDone {
+		t.Fatalf("status = %v, want done", step.Status)
 	}
-
-	// Verify on_timeout was expanded
-	if _, ok := wf.Steps["branch-step.on-timeout-step"]; !ok {
-		t.Error("on_timeout step should be expanded")
+	if step.Outputs["verdict"] != "approve" {
+		t.Errorf("outputs = %v, want the reported outputs kept", step.Outputs)
+	}
+	path, ok := step.Outputs[TranscriptOutput].(string)
+	if !ok {
+		t.Fatalf("outputs = %v, want a transcript path", step.Outputs)
+	}
+	if want := filepath.Join(artifactsDir, "review", "transcript.txt"); path != want {
+		t.Errorf("transcript path = %q, want %q", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading transcript: %v", err)
+	}
+	if string(data) != "> Review\nLooks good.\n" {
+		t.Errorf("transcript = %q", data)
 	}
 
-	// Verify branch step is done
-	if wf.Steps["branch-step"].Status != types.StepStatusDone {
-		t.Errorf("Branch step status = %v, want done", wf.Steps["branch-step"].Status)
+	manifestData, err := os.ReadFile(filepath.Join(artifactsDir, types.ArtifactManifestFile))
+	if err != nil {
+		t.Fatalf("reading manifest: %v", err)
+	}
+	var manifest types.ArtifactManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		t.Fatalf("decoding manifest: %v", err)
+	}
+	if manifest.Find("review", TranscriptOutput) == nil {
+		t.Errorf("manifest missing transcript: %+v", manifest.Artifacts)
 	}
 }
 
-// TestBranchCondition_OutputCapture tests that stdout/stderr are captured
-// in step outputs.
-func TestBranchCondition_OutputCapture(t *testing.T) {
+func TestShellStep_RequiredOutputCapture(t *testing.T) {
 	store := newMockRunStore()
-	agents := newMockAgentManager()
-	shell := newMockShellRunner()
-	expander := &mockTemplateExpander{}
-	logger := testLogger()
-
-	// Create workflow with branch step that captures outputs
-	// Using a real shell command since branch conditions execute actual commands
 	wf := types.NewRun("test-wf", "test-template", nil)
 	wf.Status = types.RunStatusRunning
-	wf.Steps["branch-step"] = &types.Step{
-		ID:       "branch-step",
-		Executor: types.ExecutorBranch,
+	wf.Steps["matched"] = &types.Step{
+		ID:       "matched",
+		Executor: types.ExecutorShell,
 		Status:   types.StepStatusPending,
-		Branch: &types.BranchConfig{
-			Condition: "echo hello && echo debug >&2",
+		Shell: &types.ShellConfig{
+			Command: "echo 'built version 1.2.3'",
 			Outputs: map[string]types.OutputSource{
-				"out": {Source: "stdout"},
-				"err": {Source: "stderr"},
+				"version": {Source: "stdout", Pattern: `version (\S+)`, Required: true},
+				"commit":  {Source: "stdout", Pattern: `commit ([0-9a-f]+)`}, // Optional: no match leaves it empty
+			},
+		},
+	}
+	wf.Steps["unmatched"] = &types.Step{
+		ID:       "unmatched",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell: &types.ShellConfig{
+			Command: "echo 'build ok'",
+			Outputs: map[string]types.OutputSource{
+				"version": {Source: "stdout", Pattern: `version (\S+)`, Required: true},
 			},
 		},
 	}
 	store.workflows[wf.ID] = wf
 
-	orch := New(testConfig(), store, agents, shell, expander, logger)
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
 	orch.SetWorkflowID(wf.ID)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-
-	err := orch.Run(ctx)
-	if err != nil {
+	if err := orch.Run(ctx); err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
 
-	// Re-fetch workflow
 	wf, _ = store.Get(ctx, wf.ID)
+	matched := wf.Steps["matched"]
+	if matched.Status != types.StepStatusDone {
+		t.Fatalf("matched status = %v, want done (error: %+v)", matched.Status, matched.Error)
+	}
+	if matched.Outputs["version"] != "1.2.3" {
+		t.Errorf("version = %#v, want \"1.2.3\"", matched.Outputs["version"])
+	}
+	if v, ok := matched.Outputs["commit"]; !ok || v != nil {
+		t.Errorf("commit = %#v, want nil for an optional output that did not match", v)
+	}
 
-	// Verify outputs
-	outputs := wf.Steps["branch-step"].Outputs
-	if outputs["outcome"] != "true" {
-		t.Errorf("outcome = %v, want 'true'", outputs["outcome"])
+	unmatched := wf.Steps["unmatched"]
+	if unmatched.Status != types.StepStatusFailed {
+		t.Fatalf("unmatched status = %v, want failed", unmatched.Status)
 	}
-	if outputs["exit_code"] != 0 {
-		t.Errorf("exit_code = %v, want 0", outputs["exit_code"])
+	if unmatched.Error == nil || unmatched.Error.Type != types.StepErrorOutputCapture {
+		t.Fatalf("unmatched error = %+v, want type output_capture", unmatched.Error)
 	}
-	// Stdout should contain "hello" (may have trailing newline)
-	if out, ok := outputs["out"].(string); !ok || !strings.Contains(out, "hello") {
-		t.Errorf("out = %v, want to contain 'hello'", outputs["out"])
+	for _, want := range []string{"version (source stdout)", `pattern "version (\\S+)" did not match`} {
+		if !strings.Contains(unmatched.Error.Message, want) {
+			t.Errorf("error message = %q, want it to contain %q", unmatched.Error.Message, want)
+		}
 	}
-	// Stderr should contain "debug" (may have trailing newline)
-	if err, ok := outputs["err"].(string); !ok || !strings.Contains(err, "debug") {
-		t.Errorf("err = %v, want to contain 'debug'", outputs["err"])
+	if unmatched.Error.Stdout != "build ok" {
+		t.Errorf("error stdout = %q, want the command's output", unmatched.Error.Stdout)
 	}
-
-	// Verify step is done (no targets to expand)
-	if wf.Steps["branch-step"].Status != types.StepStatusDone {
-		t.Errorf("Branch step status = %v, want done", wf.Steps["branch-step"].Status)
+	if wf.Status != types.RunStatusFailed {
+		t.Errorf("run status = %v, want failed", wf.Status)
 	}
 }
 
@@ -3042,6 +5447,142 @@ func TestGetNestedOutputValue_NotFound(t *testing.T) {
 	}
 }
 
+func TestGetNestedOutputValue_ArrayIndex(t *testing.T) {
+	outputs := map[string]any{
+		"result": map[string]any{
+			"items": []any{
+				map[string]any{"id": "first", "tags": []any{"a", "b"}},
+				map[string]any{"id": "second"},
+			},
+		},
+	}
+
+	tests := []struct {
+		path string
+		want any
+		ok   bool
+	}{
+		{"result.items[0].id", "first", true},
+		{"result.items[1].id", "second", true},
+		{"result.items.1.id", "second", true},
+		{"result.items[0].tags[1]", "b", true},
+		{"result.items[2].id", nil, false},
+		{"result.items[-1].id", nil, false},
+		{"result.items[x].id", nil, false},
+		{"result.items[0", nil, false},
+		{"result.items[0]x", nil, false},
+		{"[0]", nil, false},
+	}
+	for _, tt := range tests {
+		val, ok := getNestedOutputValue(outputs, tt.path)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.path, ok, tt.ok)
+			continue
+		}
+		if ok && val != tt.want {
+			t.Errorf("%s: val = %v, want %v", tt.path, val, tt.want)
+		}
+	}
+}
+
+func TestOrchestrator_HandleStepDone_OutputFromPath(t *testing.T) {
+	store := newMockRunStore()
+
+	now := time.Now()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["triage"] = &types.Step{
+		ID:        "triage",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent: &types.AgentConfig{
+			Agent:  "worker",
+			Prompt: "Triage the issues",
+			Outputs: map[string]types.AgentOutputDef{
+				"first_id": {Required: true, Type: "string", From: "result.items[0].id"},
+				"owner":    {Required: true, Type: "string", From: "result.items[0].meta.owner.name"},
+			},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	msg := &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "worker",
+		Step:     "triage",
+		Outputs: map[string]any{
+			"result": map[string]any{
+				"items": []any{
+					map[string]any{
+						"id":   "ISSUE-7",
+						"meta": map[string]any{"owner": map[string]any{"name": "dana"}},
+					},
+				},
+			},
+		},
+	}
+	if err := orch.HandleStepDone(context.Background(), msg); err != nil {
+		t.Fatalf("HandleStepDone error = %v", err)
+	}
+
+	step := wf.Steps["triage"]
+	if step.Status != types.StepStatusDone {
+		t.Fatalf("Step status = %v, want done", step.Status)
+	}
+	if step.Outputs["first_id"] != "ISSUE-7" {
+		t.Errorf("first_id = %v, want ISSUE-7", step.Outputs["first_id"])
+	}
+	if step.Outputs["owner"] != "dana" {
+		t.Errorf("owner = %v, want dana", step.Outputs["owner"])
+	}
+	if _, ok := step.Outputs["result"]; !ok {
+		t.Error("raw payload keys should be preserved")
+	}
+}
+
+func TestOrchestrator_HandleStepDone_OutputFromPathMissing(t *testing.T) {
+	store := newMockRunStore()
+
+	now := time.Now()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["triage"] = &types.Step{
+		ID:        "triage",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent: &types.AgentConfig{
+			Agent:  "worker",
+			Prompt: "Triage the issues",
+			Outputs: map[string]types.AgentOutputDef{
+				"first_id": {Required: true, Type: "string", From: "result.items[0].id"},
+			},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	msg := &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "worker",
+		Step:     "triage",
+		Outputs:  map[string]any{"result": map[string]any{"items": []any{}}},
+	}
+	err := orch.HandleStepDone(context.Background(), msg)
+	if err == nil || !strings.Contains(err.Error(), "missing required output: first_id") {
+		t.Fatalf("HandleStepDone error = %v, want missing required output", err)
+	}
+	if wf.Steps["triage"].Status != types.StepStatusRunning {
+		t.Errorf("Step status = %v, want running", wf.Steps["triage"].Status)
+	}
+}
+
 func TestResolveStepOutputRefs_ExecutorExpand(t *testing.T) {
 	store := newMockRunStore()
 	agents := newMockAgentManager()
@@ -3497,7 +6038,7 @@ func TestOrchestrator_PromptRecovery_Success(t *testing.T) {
 	// Initial timeout will expire (no event), triggering recovery
 	// Recovery will re-inject and second event will be emitted
 	go func() {
-		orch.waitForPromptAcknowledgmentWithRecovery(ctx, "test-agent", "step-1", "test prompt", 100*time.Millisecond)
+		orch.waitForPromptAcknowledgmentWithRecovery(ctx, "test-agent", "step-1", "test prompt", InjectPromptOpts{}, 100*time.Millisecond)
 		done <- true
 	}()
 
@@ -3563,7 +6104,7 @@ func TestOrchestrator_PromptRecovery_Escalate(t *testing.T) {
 	// Start waiting for acknowledgment with recovery enabled
 	// All attempts will timeout (no events emitted), triggering escalation
 	go func() {
-		orch.waitForPromptAcknowledgmentWithRecovery(ctx, "test-agent", "step-1", "test prompt", 100*time.Millisecond)
+		orch.waitForPromptAcknowledgmentWithRecovery(ctx, "test-agent", "step-1", "test prompt", InjectPromptOpts{}, 100*time.Millisecond)
 		done <- true
 	}()
 
@@ -3594,6 +6135,73 @@ func TestOrchestrator_PromptRecovery_Escalate(t *testing.T) {
 	}
 }
 
+// TestOrchestrator_PromptRecovery_Backoff tests that the waits between
+// recovery injections double up to prompt_recovery_max_backoff before the
+// prompt-swallowed escalation.
+func TestOrchestrator_PromptRecovery_Backoff(t *testing.T) {
+	agents := newMockAgentManager()
+	cfg := testConfig()
+	retries := 4
+	cfg.Agent.PromptRecoveryRetries = &retries
+	cfg.Agent.PromptRecoveryBackoff = 40 * time.Millisecond
+	cfg.Agent.PromptRecoveryMaxBackoff = 100 * time.Millisecond
+
+	orch := New(cfg, newMockRunStore(), agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	router := NewEventRouter(testLogger())
+	orch.SetEventRouter(router)
+	swallowedCh := router.RegisterWaiter("prompt-swallowed", map[string]string{"agent": "test-agent"}, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	orch.waitForPromptAcknowledgmentWithRecovery(ctx, "test-agent", "step-1", "test prompt", InjectPromptOpts{PostDelay: 2 * time.Second}, 20*time.Millisecond)
+
+	injections := agents.GetInjections()
+	if len(injections) != retries {
+		t.Fatalf("recovery injections = %d, want %d", len(injections), retries)
+	}
+	if inj := injections[0]; !inj.Stabilize || inj.PostDelay != 2*time.Second {
+		t.Errorf("recovery injection = %+v, want stabilized with the step's 2s post_delay", inj)
+	}
+	// Each interval is at least the wait after the earlier injection
+	want := []time.Duration{40 * time.Millisecond, 80 * time.Millisecond, 100 * time.Millisecond}
+	for i, w := range want {
+		got := injections[i+1].At.Sub(injections[i].At)
+		if got < w || got > w+200*time.Millisecond {
+			t.Errorf("interval after injection %d = %v, want about %v", i+1, got, w)
+		}
+	}
+
+	select {
+	case event := <-swallowedCh:
+		waits, _ := event.Data["waits"].([]string)
+		if strings.Join(waits, ",") != "40ms,80ms,100ms,100ms" {
+			t.Errorf("prompt-swallowed waits = %v, want 40ms,80ms,100ms,100ms", event.Data["waits"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("prompt-swallowed event was not emitted after recovery exhausted")
+	}
+}
+
+func TestOrchestrator_PromptRecoveryWait(t *testing.T) {
+	cfg := testConfig()
+	orch := New(cfg, newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+	// Defaults: half the acknowledgment timeout, doubling up to 30s
+	for attempt, want := range map[int]time.Duration{1: 2500 * time.Millisecond, 2: 5 * time.Second, 4: 20 * time.Second, 5: 30 * time.Second, 40: 30 * time.Second} {
+		if got := orch.promptRecoveryWait(attempt, 5*time.Second); got != want {
+			t.Errorf("promptRecoveryWait(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	cfg.Agent.PromptRecoveryBackoff = time.Second
+	cfg.Agent.PromptRecoveryMaxBackoff = 3 * time.Second
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 3 * time.Second} {
+		if got := orch.promptRecoveryWait(attempt, 5*time.Second); got != want {
+			t.Errorf("configured promptRecoveryWait(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
 // TestOrchestrator_PromptRecovery_NoRouter tests that recovery handles nil router gracefully.
 // Spec: agent-lifecycle.prompt-recovery-no-router
 func TestOrchestrator_PromptRecovery_NoRouter(t *testing.T) {
@@ -3614,7 +6222,7 @@ func TestOrchestrator_PromptRecovery_NoRouter(t *testing.T) {
 	start := time.Now()
 
 	// Should return immediately without panic
-	orch.waitForPromptAcknowledgmentWithRecovery(ctx, "test-agent", "step-1", "test prompt", 5*time.Second)
+	orch.waitForPromptAcknowledgmentWithRecovery(ctx, "test-agent", "step-1", "test prompt", InjectPromptOpts{}, 5*time.Second)
 
 	elapsed := time.Since(start)
 
@@ -4081,3 +6689,135 @@ func TestOrchestrator_AgentInjectionFailure_DispatchErrorFallback(t *testing.T)
 		t.Error("Agent step should have an error recorded after dispatch failure")
 	}
 }
+
+func TestOrchestrator_SecondInstanceFailsFast(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewYAMLRunStore(dir)
+	if err != nil {
+		t.Fatalf("NewYAMLRunStore: %v", err)
+	}
+
+	// A running agent step keeps the first orchestrator busy until cancelled
+	now := time.Now()
+	wf := types.NewRun("locked-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["work"] = &types.Step{
+		ID:        "work",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent:     &types.AgentConfig{Agent: "worker", Prompt: "do it"},
+	}
+	if err := store.Create(context.Background(), wf); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	newOrch := func() *Orchestrator {
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		orch.SetWorkflowID(wf.ID)
+		return orch
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := newOrch()
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- first.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for !store.IsLocked(wf.ID) {
+		if time.Now().After(deadline) {
+			t.Fatal("first orchestrator never acquired the workflow lock")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	second := newOrch()
+	start := time.Now()
+	err = second.Run(context.Background())
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second Run() error = %v, want ErrAlreadyRunning", err)
+	}
+	if !strings.Contains(err.Error(), "already running") {
+		t.Errorf("error %q should mention already running", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("second Run() took %v, want fail-fast", elapsed)
+	}
+	if err := newOrch().Recover(context.Background()); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Recover() error = %v, want ErrAlreadyRunning", err)
+	}
+
+	cancel()
+	<-firstDone
+
+	// Lock is released when the first orchestrator exits
+	if store.IsLocked(wf.ID) {
+		t.Error("workflow should be unlocked after first orchestrator exits")
+	}
+}
+
+func TestOrchestrator_ShellFailurePersistsErrorDetails(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewYAMLRunStore(dir)
+	if err != nil {
+		t.Fatalf("NewYAMLRunStore: %v", err)
+	}
+
+	command := "echo partial result; for i in $(seq 1 2000); do echo noise $i >&2; done; echo 'fatal: disk full' >&2; exit 3"
+	wf := types.NewRun("error-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: command},
+	}
+	if err := store.Create(context.Background(), wf); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Read back through a fresh store to check what was persisted
+	reloaded, err := NewYAMLRunStore(dir)
+	if err != nil {
+		t.Fatalf("NewYAMLRunStore: %v", err)
+	}
+	final, err := reloaded.Get(context.Background(), wf.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	stepErr := final.Steps["build"].Error
+	if stepErr == nil {
+		t.Fatal("expected persisted step error")
+	}
+
+	if stepErr.Type != types.StepErrorCommandFailed {
+		t.Errorf("Type = %q, want %q", stepErr.Type, types.StepErrorCommandFailed)
+	}
+	if stepErr.ExitCode == nil || *stepErr.ExitCode != 3 {
+		t.Errorf("ExitCode = %v, want 3", stepErr.ExitCode)
+	}
+	if stepErr.Command != command {
+		t.Errorf("Command = %q, want %q", stepErr.Command, command)
+	}
+	if stepErr.Stdout != "partial result" {
+		t.Errorf("Stdout = %q, want %q", stepErr.Stdout, "partial result")
+	}
+	if !strings.HasSuffix(stepErr.Stderr, "fatal: disk full") {
+		t.Errorf("Stderr should end with the last error line, got ...%q", stepErr.Stderr[max(0, len(stepErr.Stderr)-40):])
+	}
+	if len(stepErr.Stderr) > types.MaxErrorOutputBytes+len("...(truncated)\n") {
+		t.Errorf("Stderr length = %d, want at most %d bytes of tail", len(stepErr.Stderr), types.MaxErrorOutputBytes)
+	}
+	if !strings.HasPrefix(stepErr.Stderr, "...(truncated)") {
+		t.Error("truncated Stderr should be marked")
+	}
+}
@@ -0,0 +1,131 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/akatz-ai/meow/internal/types"
+	"github.com/akatz-ai/meow/internal/workflow"
+)
+
+// minTrimmedOutputSize is the smallest output the budget will spill or
+// truncate. Smaller values (exit codes, flags, short IDs) are always kept
+// as-is, since a spill path or truncation marker would not be any shorter.
+const minTrimmedOutputSize = 256
+
+// outputSize is the number of bytes an output value takes up in state.
+func outputSize(value any) int64 {
+	return int64(len(workflow.StringifyValue(value)))
+}
+
+// storedOutputSize sums the outputs held by every step of wf except skipID.
+func storedOutputSize(wf *types.Run, skipID string) int64 {
+	var total int64
+	for id, step := range wf.Steps {
+		if id == skipID {
+			continue
+		}
+		for _, value := range step.Outputs {
+			total += outputSize(value)
+		}
+	}
+	return total
+}
+
+// applyOutputBudget fits a finishing step's outputs into the run's output
+// budget. When storing outputs would push the run's total past the budget,
+// the largest outputs are spilled to artifact files (the output then holds
+// the file's absolute path) or, without an artifacts directory, truncated,
+// until the rest fits. Outputs smaller than minTrimmedOutputSize are never
+// trimmed, so the budget is a soft cap. Trimmed outputs are recorded in
+// step.TrimmedOutputs. Returns outputs unchanged when they fit.
+func (o *Orchestrator) applyOutputBudget(wf *types.Run, step *types.Step, outputs map[string]any) map[string]any {
+	step.TrimmedOutputs = nil
+	budget := wf.OutputBudget
+	if budget <= 0 || len(outputs) == 0 {
+		return outputs
+	}
+
+	used := storedOutputSize(wf, step.ID)
+	sizes := make(map[string]int64, len(outputs))
+	var total int64
+	for name, value := range outputs {
+		sizes[name] = outputSize(value)
+		total += sizes[name]
+	}
+	if used+total <= budget {
+		return outputs
+	}
+
+	// Trim the largest outputs first
+	names := sortedKeys(outputs)
+	sort.SliceStable(names, func(i, j int) bool { return sizes[names[i]] > sizes[names[j]] })
+
+	trimmed := make(map[string]any, len(outputs))
+	for name, value := range outputs {
+		trimmed[name] = value
+	}
+	var entries []types.ArtifactEntry
+	for _, name := range names {
+		if used+total <= budget || sizes[name] < minTrimmedOutputSize {
+			break
+		}
+		value := outputs[name]
+
+		if o.artifactsDir != "" {
+			entry, err := o.saveArtifact(step.ID, name, "", value)
+			if err == nil {
+				path := o.artifactPath(entry)
+				trimmed[name] = path
+				total += int64(len(path)) - sizes[name]
+				entries = append(entries, *entry)
+				o.markTrimmed(step, name, types.TrimmedOutputSpilled)
+				continue
+			}
+			o.logger.Warn("failed to spill output, truncating instead", "step", step.ID, "output", name, "error", err)
+		}
+
+		// Leave this output whatever room the others do not need
+		room := budget - used - (total - sizes[name])
+		text := truncateOutput(workflow.StringifyValue(value), room)
+		trimmed[name] = text
+		total += int64(len(text)) - sizes[name]
+		o.markTrimmed(step, name, types.TrimmedOutputTruncated)
+	}
+	o.recordArtifacts(wf.ID, entries)
+
+	o.logger.Warn("step outputs exceed the run's output budget",
+		"step", step.ID,
+		"budget", budget,
+		"used", used,
+		"stored", total,
+		"trimmed", step.TrimmedOutputs)
+	return trimmed
+}
+
+func (o *Orchestrator) markTrimmed(step *types.Step, name, how string) {
+	if step.TrimmedOutputs == nil {
+		step.TrimmedOutputs = make(map[string]string)
+	}
+	step.TrimmedOutputs[name] = how
+}
+
+// truncateOutput cuts text to fit in room bytes, ending with a marker that
+// says how much was dropped, without splitting a UTF-8 character. When even
+// the marker does not fit, the result is empty.
+func truncateOutput(text string, room int64) string {
+	if int64(len(text)) <= room {
+		return text
+	}
+	// Size the marker for the most that could be dropped
+	const marker = "\n[truncated %d bytes to fit output_budget]"
+	keep := room - int64(len(fmt.Sprintf(marker, len(text))))
+	if keep < 0 {
+		return ""
+	}
+	for keep > 0 && !utf8.RuneStart(text[keep]) {
+		keep--
+	}
+	return text[:keep] + fmt.Sprintf(marker, int64(len(text))-keep)
+}
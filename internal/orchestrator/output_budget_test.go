@@ -0,0 +1,209 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestOrchestrator_OutputBudget(t *testing.T) {
+	const (
+		steps   = 20
+		logSize = 4096
+		budget  = 16 << 10
+	)
+
+	tests := []struct {
+		name      string
+		artifacts bool
+		wantHow   string
+	}{
+		{name: "spills to artifacts", artifacts: true, wantHow: types.TrimmedOutputSpilled},
+		{name: "truncates without artifacts", artifacts: false, wantHow: types.TrimmedOutputTruncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.OutputBudget = budget
+			for i := range steps {
+				id := fmt.Sprintf("log-%02d", i)
+				wf.Steps[id] = &types.Step{
+					ID:       id,
+					Executor: types.ExecutorShell,
+					Status:   types.StepStatusPending,
+					Shell: &types.ShellConfig{
+						Command: fmt.Sprintf("head -c %d /dev/zero | tr '\\0' x", logSize),
+						Outputs: map[string]types.OutputSource{
+							"log":    {Source: "stdout"},
+							"status": {Source: "stdout", Pattern: `^(x)`},
+						},
+					},
+				}
+			}
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			orch.SetWorkflowID(wf.ID)
+			artifactsDir := ""
+			if tt.artifacts {
+				artifactsDir = t.TempDir()
+				orch.SetArtifactsDir(artifactsDir)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := orch.Run(ctx); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if wf.Status != types.RunStatusDone {
+				t.Fatalf("run status = %v, want done", wf.Status)
+			}
+			// Small outputs are never trimmed, so only the logs must fit
+			var logBytes int64
+			for _, step := range wf.Steps {
+				logBytes += outputSize(step.Outputs["log"])
+			}
+			if logBytes > budget {
+				t.Errorf("stored logs = %d bytes, want at most the %d byte budget", logBytes, budget)
+			}
+
+			var full, trimmed int
+			for _, step := range wf.Steps {
+				if step.Outputs["status"] != "x" {
+					t.Errorf("%s status = %v, want small outputs kept", step.ID, step.Outputs["status"])
+				}
+				log, _ := step.Outputs["log"].(string)
+				how := step.TrimmedOutputs["log"]
+				switch {
+				case how == "":
+					if len(log) != logSize {
+						t.Errorf("%s log is %d bytes but not marked trimmed", step.ID, len(log))
+					}
+					full++
+				case how != tt.wantHow:
+					t.Errorf("%s trimmed = %q, want %q", step.ID, how, tt.wantHow)
+				case how == types.TrimmedOutputSpilled:
+					data, err := os.ReadFile(log)
+					if err != nil {
+						t.Fatalf("%s spilled log: %v", step.ID, err)
+					}
+					if len(data) != logSize || !strings.HasPrefix(log, artifactsDir) {
+						t.Errorf("%s spilled %d bytes to %q, want the full log under the artifacts dir", step.ID, len(data), log)
+					}
+					trimmed++
+				default:
+					if len(log) >= logSize {
+						t.Errorf("%s log is %d bytes, want it truncated", step.ID, len(log))
+					}
+					trimmed++
+				}
+			}
+			if full == 0 || trimmed == 0 {
+				t.Errorf("full = %d, trimmed = %d, want early steps kept and later ones trimmed", full, trimmed)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_OutputBudget_ForeachAndGate(t *testing.T) {
+	const budget = 1024
+
+	t.Run("foreach aggregates", func(t *testing.T) {
+		wf := types.NewRun("test-wf", "test-template", nil)
+		wf.OutputBudget = budget
+		fan := &types.Step{
+			ID:       "fan",
+			Executor: types.ExecutorForeach,
+			Status:   types.StepStatusRunning,
+			Foreach:  &types.ForeachConfig{ItemVar: "item", Template: ".worker", Collect: "work.log"},
+		}
+		wf.Steps[fan.ID] = fan
+		// The iterations fit the budget, but the aggregates repeat them
+		for i := range 2 {
+			work := &types.Step{ID: fmt.Sprintf("fan.%d.work", i), Status: types.StepStatusRunning, ExpandedFrom: "fan"}
+			if err := work.Complete(map[string]any{"log": strings.Repeat("x", budget/4)}); err != nil {
+				t.Fatal(err)
+			}
+			wf.Steps[work.ID] = work
+			fan.ExpandedInto = append(fan.ExpandedInto, work.ID)
+		}
+
+		orch := New(testConfig(), newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		if !orch.checkForeachCompletion(wf) {
+			t.Fatal("checkForeachCompletion() = false, want foreach completed")
+		}
+		if fan.Status != types.StepStatusDone {
+			t.Fatalf("foreach status = %s, want done", fan.Status)
+		}
+		for _, name := range []string{"results", "results_by_index", "collected"} {
+			if fan.TrimmedOutputs[name] != types.TrimmedOutputTruncated {
+				t.Errorf("%s trimmed = %q, want %q", name, fan.TrimmedOutputs[name], types.TrimmedOutputTruncated)
+			}
+			if size := outputSize(fan.Outputs[name]); size >= budget {
+				t.Errorf("%s = %d bytes, want it truncated under the %d byte budget", name, size, budget)
+			}
+		}
+	})
+
+	t.Run("gate event data", func(t *testing.T) {
+		store := newMockRunStore()
+		wf := newGateWorkflow("")
+		wf.OutputBudget = budget
+		step := wf.Steps["approve"]
+		step.Status = types.StepStatusRunning
+		store.workflows[wf.ID] = wf
+
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		ch := make(chan *ipc.EventMessage, 1)
+		ch <- &ipc.EventMessage{
+			Type:      ipc.MsgEvent,
+			EventType: step.Gate.WaitForEvent,
+			Data:      map[string]any{"approver": "alice", "notes": strings.Repeat("x", 2*budget)},
+		}
+		orch.awaitGateEvent(context.Background(), wf.ID, step.ID, step.Gate, ch, 0)
+
+		if step.Status != types.StepStatusDone {
+			t.Fatalf("gate status = %s, want done", step.Status)
+		}
+		if step.Outputs["approver"] != "alice" {
+			t.Errorf("approver = %v, want small outputs kept", step.Outputs["approver"])
+		}
+		if step.TrimmedOutputs["notes"] != types.TrimmedOutputTruncated {
+			t.Errorf("notes trimmed = %q, want %q", step.TrimmedOutputs["notes"], types.TrimmedOutputTruncated)
+		}
+		if size := outputSize(step.Outputs["notes"]); size >= budget {
+			t.Errorf("notes = %d bytes, want it truncated under the %d byte budget", size, budget)
+		}
+	})
+}
+
+func TestTruncateOutput(t *testing.T) {
+	text := strings.Repeat("é", 100) // 200 bytes
+
+	got := truncateOutput(text, 120)
+	if len(got) > 120 {
+		t.Errorf("truncated to %d bytes, want at most 120", len(got))
+	}
+	if !strings.Contains(got, "[truncated") || !strings.HasPrefix(text, strings.SplitN(got, "\n", 2)[0]) {
+		t.Errorf("truncateOutput = %q, want a prefix and a marker", got)
+	}
+	if strings.ContainsRune(got, '�') {
+		t.Errorf("truncateOutput split a character: %q", got)
+	}
+
+	if got := truncateOutput(text, 10); got != "" {
+		t.Errorf("truncateOutput with no room = %q, want empty", got)
+	}
+	if got := truncateOutput("short", 10); got != "short" {
+		t.Errorf("truncateOutput = %q, want text that fits unchanged", got)
+	}
+}
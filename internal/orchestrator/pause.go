@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// A paused workflow (meow pause) keeps its orchestrator and agents: steps
+// already running carry on, are checked for timeouts and liveness, and
+// complete through meow done as usual, but no new step is dispatched until
+// the workflow resumes (meow resume).
+//
+// A workflow run with --pause-on-failure also pauses itself when a step fails
+// for good, that is with no retries or on_error recovery left. The failure is
+// held where it is: dependents are not skipped and the run does not fail, so
+// the operator can inspect the step and its agent. meow resume retries the
+// failed steps, meow resume --abort lets the failures stand (the run then
+// fails and runs cleanup_on_failure), and meow stop stops the run.
+
+// Pause holds a running workflow so that no new steps are dispatched.
+func (o *Orchestrator) Pause(ctx context.Context, id string) error {
+	return o.setRunPaused(ctx, id, (*types.Run).Pause)
+}
+
+// Resume returns a paused workflow to running, dispatching its ready steps
+// again from the next tick. Steps whose failure paused it run again.
+func (o *Orchestrator) Resume(ctx context.Context, id string) error {
+	return o.setRunPaused(ctx, id, (*types.Run).Resume)
+}
+
+// Abort returns a workflow paused on a failure to running without retrying
+// the failed steps, so it fails as it would have without pause_on_failure.
+func (o *Orchestrator) Abort(ctx context.Context, id string) error {
+	return o.setRunPaused(ctx, id, (*types.Run).Abort)
+}
+
+// setRunPaused pauses or resumes a workflow under the workflow mutex, so the
+// change cannot interleave with a tick's dispatch.
+func (o *Orchestrator) setRunPaused(ctx context.Context, id string, change func(*types.Run) error) error {
+	o.wfMu.Lock()
+	defer o.wfMu.Unlock()
+
+	wf, err := o.store.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("getting workflow %s: %w", id, err)
+	}
+	if wf == nil {
+		return fmt.Errorf("workflow %s not found", id)
+	}
+	if err := change(wf); err != nil {
+		return err
+	}
+	if err := o.store.Save(ctx, wf); err != nil {
+		return fmt.Errorf("saving workflow: %w", err)
+	}
+
+	if wf.Status == types.RunStatusPaused {
+		o.logger.Info("workflow paused", "id", id)
+	} else {
+		o.logger.Info("workflow resumed", "id", id)
+	}
+	return nil
+}
+
+// pauseOnFailures pauses a workflow with pause_on_failure when steps have
+// failed for good, recording them so that resuming retries them. Returns true
+// if the workflow was modified.
+func (o *Orchestrator) pauseOnFailures(wf *types.Run) bool {
+	if !wf.PauseOnFailure {
+		return false
+	}
+	var failed []string
+	for _, id := range sortedKeys(wf.Steps) {
+		step := wf.Steps[id]
+		if step.Status != types.StepStatusFailed || slices.Contains(wf.PausedOnFailure, id) {
+			continue
+		}
+		// Expansions fail through their children, which pause the run first
+		if len(step.ExpandedInto) > 0 || step.Attempts < step.Retries || isRecovering(step, wf.Steps) {
+			continue
+		}
+		failed = append(failed, id)
+	}
+	if len(failed) == 0 {
+		return false
+	}
+
+	wf.PausedOnFailure = append(wf.PausedOnFailure, failed...)
+	if wf.Status == types.RunStatusRunning {
+		wf.Status = types.RunStatusPaused
+	}
+	o.logger.Warn("workflow paused on step failure", "id", wf.ID, "steps", failed)
+	return true
+}
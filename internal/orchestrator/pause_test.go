@@ -0,0 +1,223 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// newPauseWorkflow returns a running workflow with an agent step in flight
+// and a shell step waiting to be dispatched.
+func newPauseWorkflow() *types.Run {
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	now := time.Now()
+	wf.Steps["work"] = &types.Step{
+		ID:        "work",
+		Executor:  types.ExecutorAgent,
+		Status:    types.StepStatusRunning,
+		StartedAt: &now,
+		Agent:     &types.AgentConfig{Agent: "worker", Prompt: "Do work"},
+	}
+	wf.Steps["report"] = &types.Step{
+		ID:       "report",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "echo report"},
+	}
+	return wf
+}
+
+func TestOrchestrator_PauseResume(t *testing.T) {
+	store := newMockRunStore()
+	wf := newPauseWorkflow()
+	store.workflows[wf.ID] = wf
+	agents := newMockAgentManager()
+	agents.running["worker"] = true
+	shell := newMockShellRunner()
+
+	orch := New(testConfig(), store, agents, shell, &mockTemplateExpander{}, testLogger())
+	ctx := context.Background()
+
+	if err := orch.Pause(ctx, wf.ID); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if wf.Status != types.RunStatusPaused {
+		t.Fatalf("status = %s, want paused", wf.Status)
+	}
+	if err := orch.Pause(ctx, wf.ID); err == nil {
+		t.Error("Pause() of a paused workflow should fail")
+	}
+
+	// Nothing new is dispatched while paused
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow() error = %v", err)
+	}
+	if status := wf.Steps["report"].Status; status != types.StepStatusPending {
+		t.Errorf("report status while paused = %s, want pending", status)
+	}
+
+	// The running agent can still finish its step, without finishing the workflow
+	if err := orch.HandleStepDone(ctx, &ipc.StepDoneMessage{
+		Type:     ipc.MsgStepDone,
+		Workflow: wf.ID,
+		Agent:    "worker",
+		Step:     "work",
+	}); err != nil {
+		t.Fatalf("HandleStepDone() error = %v", err)
+	}
+	if status := wf.Steps["work"].Status; status != types.StepStatusDone {
+		t.Errorf("work status = %s, want done", status)
+	}
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow() error = %v", err)
+	}
+	if wf.Status != types.RunStatusPaused {
+		t.Errorf("status after step done = %s, want paused", wf.Status)
+	}
+
+	if err := orch.Resume(ctx, wf.ID); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if wf.Status != types.RunStatusRunning {
+		t.Fatalf("status = %s, want running", wf.Status)
+	}
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow() error = %v", err)
+	}
+	if status := wf.Steps["report"].Status; status == types.StepStatusPending {
+		t.Error("report was not dispatched after resume")
+	}
+}
+
+func TestOrchestrator_Resume_NotPaused(t *testing.T) {
+	store := newMockRunStore()
+	wf := newPauseWorkflow()
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if err := orch.Resume(context.Background(), wf.ID); err == nil {
+		t.Error("Resume() of a running workflow should fail")
+	}
+	if err := orch.Pause(context.Background(), "missing"); err == nil {
+		t.Error("Pause() of an unknown workflow should fail")
+	}
+}
+
+func TestOrchestrator_Recover_Paused(t *testing.T) {
+	store := newMockRunStore()
+	wf := newPauseWorkflow()
+	wf.Status = types.RunStatusPaused
+	now := time.Now()
+	wf.Steps["report"].Status = types.StepStatusRunning
+	wf.Steps["report"].StartedAt = &now
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if err := orch.Recover(context.Background()); err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+
+	if wf.Status != types.RunStatusPaused {
+		t.Errorf("status = %s, want paused", wf.Status)
+	}
+	if status := wf.Steps["report"].Status; status != types.StepStatusPending {
+		t.Errorf("interrupted shell step status = %s, want pending", status)
+	}
+}
+
+// processUntil processes the workflow, waiting for its async steps after
+// each pass, until it reaches status.
+func processUntil(t *testing.T, orch *Orchestrator, wf *types.Run, status types.RunStatus) {
+	t.Helper()
+	for range 20 {
+		if err := orch.processWorkflow(context.Background(), wf); err != nil {
+			t.Fatalf("processWorkflow() error = %v", err)
+		}
+		orch.wg.Wait()
+		if wf.Status == status {
+			return
+		}
+	}
+	t.Fatalf("workflow status = %s, want %s", wf.Status, status)
+}
+
+// newPauseOnFailureWorkflow returns a running workflow with pause_on_failure
+// whose report step needs a step running command.
+func newPauseOnFailureWorkflow(command string) *types.Run {
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.PauseOnFailure = true
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: command},
+	}
+	wf.Steps["report"] = &types.Step{
+		ID:       "report",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"build"},
+		Shell:    &types.ShellConfig{Command: "echo report"},
+	}
+	return wf
+}
+
+func TestOrchestrator_PauseOnFailure_ResumeRetries(t *testing.T) {
+	// Fails the first time it runs, succeeds when retried
+	marker := filepath.Join(t.TempDir(), "attempted")
+	store := newMockRunStore()
+	wf := newPauseOnFailureWorkflow(fmt.Sprintf("test -f %[1]s || { touch %[1]s; exit 1; }", marker))
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	processUntil(t, orch, wf, types.RunStatusPaused)
+
+	if status := wf.Steps["build"].Status; status != types.StepStatusFailed {
+		t.Fatalf("build status = %s, want failed", status)
+	}
+	if len(wf.PausedOnFailure) != 1 || wf.PausedOnFailure[0] != "build" {
+		t.Errorf("paused on failure of %v, want [build]", wf.PausedOnFailure)
+	}
+	// The failure is held: more passes neither skip its dependent nor fail the run
+	processUntil(t, orch, wf, types.RunStatusPaused)
+	if status := wf.Steps["report"].Status; status != types.StepStatusPending {
+		t.Errorf("report status while paused = %s, want pending", status)
+	}
+
+	if err := orch.Resume(context.Background(), wf.ID); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	processUntil(t, orch, wf, types.RunStatusDone)
+	for _, id := range []string{"build", "report"} {
+		if status := wf.Steps[id].Status; status != types.StepStatusDone {
+			t.Errorf("%s status = %s, want done", id, status)
+		}
+	}
+}
+
+func TestOrchestrator_PauseOnFailure_Abort(t *testing.T) {
+	store := newMockRunStore()
+	wf := newPauseOnFailureWorkflow("exit 1")
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	processUntil(t, orch, wf, types.RunStatusPaused)
+
+	if err := orch.Abort(context.Background(), wf.ID); err != nil {
+		t.Fatalf("Abort() error = %v", err)
+	}
+	processUntil(t, orch, wf, types.RunStatusFailed)
+	if status := wf.Steps["report"].Status; status != types.StepStatusSkipped {
+		t.Errorf("report status = %s, want skipped", status)
+	}
+	if err := orch.Abort(context.Background(), wf.ID); err == nil {
+		t.Error("Abort() of a failed workflow should fail")
+	}
+}
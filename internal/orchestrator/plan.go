@@ -0,0 +1,221 @@
+package orchestrator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// A dry run (meow run --dry-run) plans a baked run instead of executing it:
+// steps are ordered into waves by their needs, and each is reported as it
+// would be dispatched. Step output references cannot be known yet, so they
+// are shown symbolically as <step.outputs.field>. Planning only reads the
+// run; nothing is spawned, executed, or saved.
+
+// PlanStep is one step of an execution plan.
+type PlanStep struct {
+	ID       string
+	Executor types.ExecutorType
+	Needs    []string
+	// Wave is the step's position in dependency order, from 1; steps in the
+	// same wave may run concurrently. 0 marks a step already finished (e.g.,
+	// by --skip-to), which would not be dispatched.
+	Wave   int
+	Status types.StepStatus
+	// Details are what the step would run (command, prompt, template, ...)
+	// as name/value pairs, with output references left symbolic.
+	Details []PlanDetail
+}
+
+// PlanDetail is a named field of a planned step.
+type PlanDetail struct {
+	Name  string
+	Value string
+}
+
+// Plan orders a run's steps into waves of dispatch. It fails if the steps'
+// needs form a cycle.
+func Plan(wf *types.Run) ([]PlanStep, error) {
+	waves := make(map[string]int, len(wf.Steps))
+	for _, id := range sortedKeys(wf.Steps) {
+		if step := wf.Steps[id]; step.Status.IsTerminal() {
+			waves[id] = 0
+		}
+	}
+
+	// Assign waves in passes: a step's wave follows its latest dependency
+	for len(waves) < len(wf.Steps) {
+		progressed := false
+		for _, id := range sortedKeys(wf.Steps) {
+			if _, done := waves[id]; done {
+				continue
+			}
+			wave, ready := 1, true
+			for _, need := range wf.Steps[id].Needs {
+				if _, ok := wf.Steps[need]; !ok {
+					continue // Validated at bake time; nothing to wait for
+				}
+				w, ok := waves[need]
+				if !ok {
+					ready = false
+					break
+				}
+				wave = max(wave, w+1)
+			}
+			if ready {
+				waves[id] = wave
+				progressed = true
+			}
+		}
+		if !progressed {
+			var stuck []string
+			for _, id := range sortedKeys(wf.Steps) {
+				if _, ok := waves[id]; !ok {
+					stuck = append(stuck, id)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle among steps: %s", strings.Join(stuck, ", "))
+		}
+	}
+
+	plan := make([]PlanStep, 0, len(wf.Steps))
+	for id, step := range wf.Steps {
+		plan = append(plan, PlanStep{
+			ID:       id,
+			Executor: step.Executor,
+			Needs:    step.Needs,
+			Wave:     waves[id],
+			Status:   step.Status,
+			Details:  planDetails(step),
+		})
+	}
+	sort.Slice(plan, func(i, j int) bool {
+		if plan[i].Wave != plan[j].Wave {
+			return plan[i].Wave < plan[j].Wave
+		}
+		return plan[i].ID < plan[j].ID
+	})
+	return plan, nil
+}
+
+// WritePlan writes the execution plan of a run to w.
+func WritePlan(w io.Writer, wf *types.Run) error {
+	plan, err := Plan(wf)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Execution plan for %s (%d steps):\n", wf.ID, len(plan))
+	wave := -1
+	for _, ps := range plan {
+		if ps.Wave != wave {
+			wave = ps.Wave
+			if wave == 0 {
+				fmt.Fprintln(w, "\nAlready finished:")
+			} else {
+				fmt.Fprintf(w, "\nWave %d:\n", wave)
+			}
+		}
+		if ps.Wave == 0 {
+			fmt.Fprintf(w, "  %s [%s]: %s\n", ps.ID, ps.Executor, ps.Status)
+			continue
+		}
+		fmt.Fprintf(w, "  would dispatch %s [%s]", ps.ID, ps.Executor)
+		if len(ps.Needs) > 0 {
+			fmt.Fprintf(w, " (needs: %s)", strings.Join(ps.Needs, ", "))
+		}
+		fmt.Fprintln(w)
+		for _, d := range ps.Details {
+			fmt.Fprintf(w, "    %s: %s\n", d.Name, indentContinuation(d.Value, "      "))
+		}
+	}
+	return nil
+}
+
+// planDetails returns what a step would run, with step output references
+// shown symbolically.
+func planDetails(step *types.Step) []PlanDetail {
+	var details []PlanDetail
+	add := func(name, value string) {
+		if value != "" {
+			details = append(details, PlanDetail{Name: name, Value: symbolicOutputRefs(value)})
+		}
+	}
+
+	add("if", step.If)
+	switch step.Executor {
+	case types.ExecutorShell:
+		if step.Shell != nil {
+			add("command", step.Shell.Command)
+			add("workdir", step.Shell.Workdir)
+			add("run_as", step.Shell.RunAs)
+		}
+	case types.ExecutorAgent:
+		if step.Agent != nil {
+			add("agent", step.Agent.Agent)
+			add("requires_capability", step.Agent.RequiresCapability)
+			add("mode", step.Agent.Mode)
+			add("prompt", step.Agent.Prompt)
+		}
+	case types.ExecutorSpawn:
+		if step.Spawn != nil {
+			add("agent", step.Spawn.Agent)
+			add("adapter", step.Spawn.Adapter)
+			add("workdir", step.Spawn.Workdir)
+		}
+	case types.ExecutorKill:
+		if step.Kill != nil {
+			add("agent", step.Kill.Agent)
+		}
+	case types.ExecutorExpand:
+		if step.Expand != nil {
+			add("template", step.Expand.Template)
+		}
+	case types.ExecutorBranch:
+		if step.Branch != nil {
+			add("condition", step.Branch.Condition)
+			for _, t := range []struct {
+				name   string
+				target *types.BranchTarget
+			}{
+				{"on_true", step.Branch.OnTrue},
+				{"on_false", step.Branch.OnFalse},
+				{"on_timeout", step.Branch.OnTimeout},
+			} {
+				if t.target == nil {
+					continue
+				}
+				if t.target.Template != "" {
+					add(t.name, "expand "+t.target.Template)
+				} else if len(t.target.Inline) > 0 {
+					add(t.name, fmt.Sprintf("%d inline steps", len(t.target.Inline)))
+				}
+			}
+		}
+	case types.ExecutorForeach:
+		if step.Foreach != nil {
+			add("items", step.Foreach.Items)
+			add("items_file", step.Foreach.ItemsFile)
+			add("template", step.Foreach.Template)
+		}
+	case types.ExecutorGate:
+		if step.Gate != nil {
+			add("wait_for_event", step.Gate.WaitForEvent)
+		}
+	}
+	return details
+}
+
+// symbolicOutputRefs replaces {{step.outputs.field}} references, which are
+// only resolved at dispatch, with <step.outputs.field>.
+func symbolicOutputRefs(s string) string {
+	return stepOutputRefPattern.ReplaceAllString(s, "<$1.outputs.$2>")
+}
+
+// indentContinuation indents every line of a multi-line value after the first.
+func indentContinuation(s, indent string) string {
+	return strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+indent)
+}
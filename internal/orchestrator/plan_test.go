@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func newPlanWorkflow() *types.Run {
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Steps["spawn"] = &types.Step{
+		ID:       "spawn",
+		Executor: types.ExecutorSpawn,
+		Status:   types.StepStatusPending,
+		Spawn:    &types.SpawnConfig{Agent: "worker"},
+	}
+	wf.Steps["setup"] = &types.Step{
+		ID:       "setup",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "git rev-parse HEAD"},
+	}
+	wf.Steps["implement"] = &types.Step{
+		ID:       "implement",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"spawn", "setup"},
+		Agent:    &types.AgentConfig{Agent: "worker", Prompt: "Implement on top of {{setup.outputs.stdout}}"},
+	}
+	wf.Steps["stop"] = &types.Step{
+		ID:       "stop",
+		Executor: types.ExecutorKill,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"implement"},
+		Kill:     &types.KillConfig{Agent: "worker"},
+	}
+	return wf
+}
+
+func TestPlan_Waves(t *testing.T) {
+	plan, err := Plan(newPlanWorkflow())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	var got []string
+	for _, ps := range plan {
+		got = append(got, fmt.Sprintf("%s@%d", ps.ID, ps.Wave))
+	}
+	if want := "setup@1 spawn@1 implement@2 stop@3"; strings.Join(got, " ") != want {
+		t.Errorf("plan = %s, want %s", strings.Join(got, " "), want)
+	}
+
+	implement := plan[2]
+	want := []PlanDetail{
+		{"agent", "worker"},
+		{"prompt", "Implement on top of <setup.outputs.stdout>"},
+	}
+	if len(implement.Details) != len(want) {
+		t.Fatalf("implement details = %v, want %v", implement.Details, want)
+	}
+	for i, d := range want {
+		if implement.Details[i] != d {
+			t.Errorf("implement detail %d = %v, want %v", i, implement.Details[i], d)
+		}
+	}
+}
+
+func TestPlan_FinishedStepsAreNotDispatched(t *testing.T) {
+	wf := newPlanWorkflow()
+	wf.Steps["setup"].Status = types.StepStatusDone
+
+	var buf bytes.Buffer
+	if err := WritePlan(&buf, wf); err != nil {
+		t.Fatalf("WritePlan() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Execution plan for test-wf (4 steps):",
+		"Already finished:\n  setup [shell]: done\n",
+		"Wave 1:\n  would dispatch spawn [spawn]\n    agent: worker\n",
+		"Wave 2:\n  would dispatch implement [agent] (needs: spawn, setup)\n",
+		"Wave 3:\n  would dispatch stop [kill] (needs: implement)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plan output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "would dispatch setup") {
+		t.Errorf("finished step planned for dispatch:\n%s", out)
+	}
+}
+
+func TestPlan_Cycle(t *testing.T) {
+	wf := newPlanWorkflow()
+	wf.Steps["spawn"].Needs = []string{"stop"}
+	if _, err := Plan(wf); err == nil || !strings.Contains(err.Error(), "implement, spawn, stop") {
+		t.Errorf("Plan() error = %v, want cycle among implement, spawn, stop", err)
+	}
+}
@@ -0,0 +1,40 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// While nothing happens (the orchestrator only waits on long-running agents),
+// ticks back off: each tick that dispatches nothing and changes no state
+// doubles the poll interval, up to max_poll_interval. The interval drops back
+// to poll_interval on the next tick that does something, and as soon as a step
+// finishes or an IPC message arrives, since either may make steps ready.
+
+// Wake resets the poll backoff, so the orchestrator ticks again within the
+// base poll interval. Safe to call from any goroutine.
+func (o *Orchestrator) Wake() {
+	select {
+	case o.wakeCh <- struct{}{}:
+	default: // A wake-up is already pending
+	}
+}
+
+// nextPollInterval returns the interval before the next tick, given the
+// current one and whether the last tick did anything.
+func (o *Orchestrator) nextPollInterval(current time.Duration, busy bool) time.Duration {
+	base := o.cfg.Orchestrator.PollInterval
+	limit := o.cfg.Orchestrator.MaxPollInterval
+	if busy || limit <= base {
+		return base
+	}
+	return min(current*2, limit)
+}
+
+// saveProgress saves a workflow that a tick changed, marking the tick busy.
+func (o *Orchestrator) saveProgress(ctx context.Context, wf *types.Run) error {
+	o.tickBusy = true
+	return o.store.Save(ctx, wf)
+}
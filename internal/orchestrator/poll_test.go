@@ -0,0 +1,116 @@
+package orchestrator
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestNextPollInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     time.Duration
+		current time.Duration
+		busy    bool
+		want    time.Duration
+	}{
+		{"idle doubles", 80 * time.Millisecond, 10 * time.Millisecond, false, 20 * time.Millisecond},
+		{"idle capped at max", 80 * time.Millisecond, 60 * time.Millisecond, false, 80 * time.Millisecond},
+		{"busy resets to base", 80 * time.Millisecond, 80 * time.Millisecond, true, 10 * time.Millisecond},
+		{"zero max disables backoff", 0, 10 * time.Millisecond, false, 10 * time.Millisecond},
+		{"max equal to base disables backoff", 10 * time.Millisecond, 10 * time.Millisecond, false, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Orchestrator.MaxPollInterval = tt.max
+			orch := New(cfg, newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			if got := orch.nextPollInterval(tt.current, tt.busy); got != tt.want {
+				t.Errorf("nextPollInterval(%s, %v) = %s, want %s", tt.current, tt.busy, got, tt.want)
+			}
+		})
+	}
+}
+
+// tickCountingStore counts orchestrator ticks (one listing of running runs
+// each) and reports them on ticks.
+type tickCountingStore struct {
+	*mockRunStore
+	count atomic.Int64
+	ticks chan struct{}
+}
+
+func (s *tickCountingStore) List(ctx context.Context, filter RunFilter) ([]*types.Run, error) {
+	if filter.Status == types.RunStatusRunning {
+		s.count.Add(1)
+		select {
+		case s.ticks <- struct{}{}:
+		default:
+		}
+	}
+	return s.mockRunStore.List(ctx, filter)
+}
+
+// runIdleOrchestrator runs an orchestrator over a workflow that only waits on
+// a gate, so every tick is idle. The orchestrator stops when the test ends.
+func runIdleOrchestrator(t *testing.T, maxPoll time.Duration) (*Orchestrator, *tickCountingStore) {
+	t.Helper()
+	store := &tickCountingStore{mockRunStore: newMockRunStore(), ticks: make(chan struct{}, 1)}
+	wf := newGateWorkflow("")
+	store.workflows[wf.ID] = wf
+
+	cfg := testConfig()
+	cfg.Orchestrator.MaxPollInterval = maxPoll
+	orch := New(cfg, store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+	orch.SetEventRouter(NewEventRouter(testLogger()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		orch.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return orch, store
+}
+
+func TestRunLoop_IdleTicksBackOff(t *testing.T) {
+	_, steady := runIdleOrchestrator(t, 0)
+	_, backoff := runIdleOrchestrator(t, 80*time.Millisecond)
+	time.Sleep(500 * time.Millisecond)
+
+	// At 10ms, ~50 ticks; backing off to 80ms, ~9
+	n, m := steady.count.Load(), backoff.count.Load()
+	if m >= 20 || m*2 >= n {
+		t.Errorf("idle ticks with backoff = %d, without = %d; want far fewer with backoff", m, n)
+	}
+}
+
+func TestRunLoop_WakeResetsBackoff(t *testing.T) {
+	orch, store := runIdleOrchestrator(t, 400*time.Millisecond)
+	// Back off to the maximum (10+20+...+320ms)
+	time.Sleep(time.Second)
+
+	// Drain the notification of the last tick, then wake
+	select {
+	case <-store.ticks:
+	default:
+	}
+	start := time.Now()
+	orch.Wake()
+	select {
+	case <-store.ticks:
+	case <-time.After(time.Second):
+		t.Fatal("no tick after Wake")
+	}
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("next tick came %s after Wake, want about the base poll interval", elapsed)
+	}
+}
@@ -0,0 +1,194 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// preconditionTimeout bounds each requires check, and a step's if check.
+// Checks are expected to be quick (git status, df, test -f), and a hung
+// check counts as a failure.
+const preconditionTimeout = 30 * time.Second
+
+// checkIf runs a resolved if check and reports whether the step should run:
+// true when the check exits zero, false for any other exit code. A check
+// that cannot run or times out is returned as an error.
+func checkIf(ctx context.Context, condExec *SimpleConditionExecutor, command string) (bool, *types.StepError) {
+	checkCtx, cancel := context.WithTimeout(ctx, preconditionTimeout)
+	defer cancel()
+	exitCode, stdout, stderr, err := condExec.Execute(checkCtx, command)
+	if err != nil {
+		stepErr := types.NewCommandError(fmt.Sprintf("if check failed: %v", err), command, exitCode, stdout, stderr)
+		stepErr.Type = types.StepErrorPreconditionFailed
+		return false, stepErr
+	}
+	return exitCode == 0, nil
+}
+
+// preconditionsPassed reports whether a step's if check and requires checks
+// have passed, so dispatch can go on to its executor. The first call resolves
+// the checks and runs them in a goroutine, off the workflow lock, in the
+// step's workdir and env; until they finish the step stays pending and
+// further calls return false. A false if check skips the step, and a check
+// that fails (or an if check that cannot run) fails it, from the goroutine;
+// passing checks are picked up by the next dispatch of the step.
+func (o *Orchestrator) preconditionsPassed(ctx context.Context, wf *types.Run, step *types.Step) bool {
+	key := wf.ID + ":" + step.ID
+	if passed, running := o.stepChecks.Load(key); running {
+		if passed.(bool) {
+			o.stepChecks.Delete(key)
+		}
+		return passed.(bool)
+	}
+
+	ifCommand := ""
+	if step.If != "" {
+		ifCommand = o.resolveOutputRefs(wf, step.If, step.ID)
+	}
+	checks := make(map[string]string, len(step.Requires))
+	for name, command := range step.Requires {
+		checks[name] = o.resolveOutputRefs(wf, command, step.ID)
+	}
+	workdir, env := o.stepCommandEnv(step)
+	condExec := &SimpleConditionExecutor{
+		SocketPath: ipc.SocketPath(wf.ID),
+		WorkflowID: wf.ID,
+		StepID:     step.ID,
+		Workdir:    workdir,
+		Env:        maps.Clone(env), // Dispatch resolves the step's env in place
+	}
+
+	// Capture IDs by value for goroutine (NOT pointers!)
+	workflowID := wf.ID
+	stepID := step.ID
+	checkCtx, cancel := context.WithCancel(ctx)
+	o.stepChecks.Store(key, false)
+	o.pendingCommands.Store(key+":requires", cancel)
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer o.pendingCommands.Delete(key + ":requires")
+		defer cancel()
+		if ifCommand != "" {
+			run, stepErr := checkIf(checkCtx, condExec, ifCommand)
+			if stepErr != nil || !run {
+				o.completePreconditions(checkCtx, workflowID, stepID, !run && stepErr == nil, stepErr)
+				return
+			}
+		}
+		stepErr := checkPreconditions(checkCtx, condExec, checks)
+		o.completePreconditions(checkCtx, workflowID, stepID, false, stepErr)
+	}()
+	return false
+}
+
+// completePreconditions records the outcome of a step's checks: it skips the
+// step on a false if check, fails it on a failed check, or marks the checks
+// passed and wakes the run loop to dispatch it. Steps that stopped being
+// pending meanwhile are left alone.
+func (o *Orchestrator) completePreconditions(ctx context.Context, workflowID, stepID string, skip bool, stepErr *types.StepError) {
+	logger := o.stepLogger(ctx)
+	key := workflowID + ":" + stepID
+
+	o.wfMu.Lock()
+	defer o.wfMu.Unlock()
+
+	if ctx.Err() != nil {
+		// Workflow stopping: the checks run again when the step is next dispatched
+		o.stepChecks.Delete(key)
+		return
+	}
+	wf, err := o.store.Get(ctx, workflowID)
+	if err != nil || wf == nil || wf.Status.IsTerminal() {
+		o.stepChecks.Delete(key)
+		return
+	}
+	step, ok := wf.GetStep(stepID)
+	if !ok || step.Status != types.StepStatusPending {
+		o.stepChecks.Delete(key)
+		return
+	}
+
+	if stepErr == nil && !skip {
+		o.stepChecks.Store(key, true)
+		o.Wake()
+		return
+	}
+
+	o.stepChecks.Delete(key)
+	if skip {
+		logger.Info("skipping step: if check is false", "id", stepID)
+		if err := step.Skip(&types.SkipReason{
+			Kind:    types.SkipReasonConditionFalse,
+			Message: "if check exited non-zero",
+		}); err != nil {
+			logger.Error("failed to skip step", "step", stepID, "error", err)
+			return
+		}
+	} else {
+		logger.Warn("step precondition failed", "id", stepID, "error", stepErr.Message)
+		if err := step.Start(); err != nil {
+			logger.Error("failed to start step", "step", stepID, "error", err)
+			return
+		}
+		if err := step.Fail(stepErr); err != nil {
+			logger.Error("failed to mark step as failed", "step", stepID, "error", err)
+			return
+		}
+		o.recordStepStarted(wf, step)
+		o.recordStepFinished(wf.ID, step)
+	}
+	if err := o.store.Save(ctx, wf); err != nil {
+		logger.Error("failed to save workflow after preconditions", "step", stepID, "error", err)
+	}
+	o.Wake()
+}
+
+// checkPreconditions runs resolved requires checks in name order and returns
+// the first failure as a precondition_failed error naming the check, or nil
+// when every check exits zero.
+func checkPreconditions(ctx context.Context, condExec *SimpleConditionExecutor, checks map[string]string) *types.StepError {
+	for _, name := range sortedKeys(checks) {
+		command := checks[name]
+		checkCtx, cancel := context.WithTimeout(ctx, preconditionTimeout)
+		exitCode, stdout, stderr, err := condExec.Execute(checkCtx, command)
+		cancel()
+		if err == nil && exitCode == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("precondition %q failed (exit code %d)", name, exitCode)
+		if err != nil {
+			message = fmt.Sprintf("precondition %q failed: %v", name, err)
+		}
+		stepErr := types.NewCommandError(message, command, exitCode, stdout, stderr)
+		stepErr.Type = types.StepErrorPreconditionFailed
+		return stepErr
+	}
+	return nil
+}
+
+// stepCommandEnv returns the working directory and environment a step's
+// own commands run with, for running its checks the same way. Agent steps
+// use their agent's workdir.
+func (o *Orchestrator) stepCommandEnv(step *types.Step) (string, map[string]string) {
+	switch {
+	case step.Shell != nil:
+		return step.Shell.Workdir, step.Shell.Env
+	case step.Branch != nil:
+		return step.Branch.Workdir, step.Branch.Env
+	case step.Spawn != nil:
+		return step.Spawn.Workdir, step.Spawn.Env
+	case step.Agent != nil:
+		if mgr, ok := o.agents.(*TmuxAgentManager); ok {
+			return mgr.GetWorkdir(step.Agent.Agent), nil
+		}
+	}
+	return "", nil
+}
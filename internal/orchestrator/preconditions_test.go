@@ -0,0 +1,255 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestOrchestrator_StepPreconditions(t *testing.T) {
+	newRun := func(requires map[string]string) (*mockRunStore, *types.Run) {
+		store := newMockRunStore()
+		wf := types.NewRun("test-wf", "test-template", nil)
+		wf.Status = types.RunStatusRunning
+		wf.Steps["deploy"] = &types.Step{
+			ID:       "deploy",
+			Executor: types.ExecutorAgent,
+			Status:   types.StepStatusPending,
+			Requires: requires,
+			Agent:    &types.AgentConfig{Agent: "test-agent", Prompt: "Deploy"},
+		}
+		store.workflows[wf.ID] = wf
+		return store, wf
+	}
+	ctx := context.Background()
+
+	t.Run("satisfied preconditions dispatch the step", func(t *testing.T) {
+		store, wf := newRun(map[string]string{
+			"disk space available": "true",
+			"git clean":            "test -z \"\"",
+		})
+		agents := newMockAgentManager()
+		orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+		// The checks run off the workflow lock; the next pass dispatches
+		for range 2 {
+			if err := orch.processWorkflow(ctx, wf); err != nil {
+				t.Fatalf("processWorkflow error = %v", err)
+			}
+			orch.wg.Wait()
+		}
+		if got := wf.Steps["deploy"].Status; got != types.StepStatusRunning {
+			t.Errorf("deploy status = %v, want running", got)
+		}
+		if len(agents.GetInjections()) != 1 {
+			t.Errorf("injections = %d, want the prompt sent once", len(agents.GetInjections()))
+		}
+	})
+
+	t.Run("failed precondition fails the step with its name", func(t *testing.T) {
+		store, wf := newRun(map[string]string{
+			"disk space available": "true",
+			"git clean":            "echo ' M main.go' >&2; exit 1",
+		})
+		agents := newMockAgentManager()
+		orch := New(testConfig(), store, agents, newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+		deploy := wf.Steps["deploy"]
+		if deploy.Status != types.StepStatusFailed {
+			t.Fatalf("deploy status = %v, want failed", deploy.Status)
+		}
+		if deploy.Error == nil || deploy.Error.Type != types.StepErrorPreconditionFailed {
+			t.Fatalf("deploy error = %+v, want precondition_failed", deploy.Error)
+		}
+		if !strings.Contains(deploy.Error.Message, `"git clean"`) || deploy.Error.Stderr != "M main.go" {
+			t.Errorf("deploy error = %+v, want the git clean check and its output", deploy.Error)
+		}
+		if len(agents.GetInjections()) != 0 {
+			t.Errorf("injections = %d, want the agent left alone", len(agents.GetInjections()))
+		}
+	})
+}
+
+func TestOrchestrator_StepPreconditions_OffLock(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "marker"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runs := filepath.Join(dir, "runs")
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Requires: map[string]string{
+			// Runs in the step's workdir, with its env
+			"in workdir": fmt.Sprintf(`echo run >> %s; sleep 0.5; test -f marker && test "$BUILD_MODE" = release`, runs),
+		},
+		Shell: &types.ShellConfig{
+			Command: "true",
+			Workdir: dir,
+			Env:     map[string]string{"BUILD_MODE": "release"},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	ctx := context.Background()
+
+	started := time.Now()
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow error = %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 250*time.Millisecond {
+		t.Errorf("processWorkflow took %v, want it not to wait for the check", elapsed)
+	}
+	// The workflow lock is free while the check runs, and the step waits
+	orch.wfMu.Lock()
+	status := wf.Steps["build"].Status
+	orch.wfMu.Unlock()
+	if status != types.StepStatusPending {
+		t.Errorf("build status = %v while checking, want pending", status)
+	}
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow error = %v", err)
+	}
+	orch.wg.Wait()
+
+	for i := 0; i < 5 && !wf.AllDone(); i++ {
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+	}
+	build := wf.Steps["build"]
+	if build.Status != types.StepStatusDone {
+		t.Fatalf("build status = %v (error %+v), want done", build.Status, build.Error)
+	}
+	if got := countLines(t, runs); got != 1 {
+		t.Errorf("check ran %d times, want once", got)
+	}
+}
+
+func TestOrchestrator_StepIf_OffLock(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "marker"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runs := filepath.Join(dir, "runs")
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		// Runs in the step's workdir, with its env, before the requires checks
+		If:       fmt.Sprintf(`echo run >> %s; sleep 0.5; test -f marker && test "$BUILD_MODE" = release`, runs),
+		Requires: map[string]string{"ran if first": "test -f " + runs},
+		Shell: &types.ShellConfig{
+			Command: "true",
+			Workdir: dir,
+			Env:     map[string]string{"BUILD_MODE": "release"},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	ctx := context.Background()
+
+	started := time.Now()
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow error = %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 250*time.Millisecond {
+		t.Errorf("processWorkflow took %v, want it not to wait for the if check", elapsed)
+	}
+	orch.wfMu.Lock()
+	status := wf.Steps["build"].Status
+	orch.wfMu.Unlock()
+	if status != types.StepStatusPending {
+		t.Errorf("build status = %v while checking, want pending", status)
+	}
+	if err := orch.processWorkflow(ctx, wf); err != nil {
+		t.Fatalf("processWorkflow error = %v", err)
+	}
+	orch.wg.Wait()
+
+	for i := 0; i < 5 && !wf.AllDone(); i++ {
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+	}
+	build := wf.Steps["build"]
+	if build.Status != types.StepStatusDone {
+		t.Fatalf("build status = %v (error %+v, skip %+v), want done", build.Status, build.Error, build.SkipReason)
+	}
+	if got := countLines(t, runs); got != 1 {
+		t.Errorf("if check ran %d times, want once", got)
+	}
+}
+
+func TestOrchestrator_StepIf(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["review"] = &types.Step{
+		ID:       "review",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusDone,
+		Outputs:  map[string]any{"verdict": "rejected"},
+	}
+	wf.Steps["deploy"] = &types.Step{
+		ID:       "deploy",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"review"},
+		If:       `test "{{review.outputs.verdict}}" = approved`,
+		Shell:    &types.ShellConfig{Command: "echo deploying"},
+	}
+	wf.Steps["notify"] = &types.Step{
+		ID:       "notify",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"deploy"},
+		If:       `test "{{review.outputs.verdict}}" = rejected`,
+		Shell:    &types.ShellConfig{Command: "echo notifying"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	ctx := context.Background()
+	for i := 0; i < 5 && !wf.AllDone(); i++ {
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+	}
+
+	deploy := wf.Steps["deploy"]
+	if deploy.Status != types.StepStatusSkipped {
+		t.Fatalf("deploy status = %v, want skipped", deploy.Status)
+	}
+	if deploy.SkipReason == nil || deploy.SkipReason.Kind != types.SkipReasonConditionFalse {
+		t.Errorf("deploy skip reason = %+v, want condition_false", deploy.SkipReason)
+	}
+	// A step skipped by its if check does not skip its dependents
+	if got := wf.Steps["notify"].Status; got != types.StepStatusDone {
+		t.Errorf("notify status = %v, want done", got)
+	}
+}
@@ -0,0 +1,67 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/config"
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// killProcessGroupOnCancel starts cmd in its own process group and, when the
+// command's context is cancelled or times out, kills the whole group rather
+// than just the shell. Without this, children forked by the command (e.g.
+// "server & wait") survive as orphans and keep stdout/stderr pipes open,
+// which also blocks Wait until they exit. Must be called before cmd.Start.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		// Negative PID signals every process in the group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// cancelGracefully makes cancelling cmd a soft cancel: its process group is
+// sent sig, giving the command grace to checkpoint and exit on its own, and is
+// killed only once grace has passed. Must be called after
+// killProcessGroupOnCancel. The returned stop must be called once cmd's Wait
+// returns, so the kill cannot reach a later group that reuses the ID.
+func cancelGracefully(cmd *exec.Cmd, sig syscall.Signal, grace time.Duration) (stop func()) {
+	// Wait collects Cancel's result before returning, so stop sees the timer
+	var kill *time.Timer
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		kill = time.AfterFunc(grace, func() { syscall.Kill(-pgid, syscall.SIGKILL) })
+		return syscall.Kill(-pgid, sig)
+	}
+	return func() {
+		if kill != nil {
+			kill.Stop()
+		}
+	}
+}
+
+// applyCancelSignal sets up a soft cancel for cmd from a step's cancel_signal
+// and cancel_grace, if it has one. The returned stop must be called once
+// cmd's Wait returns (see cancelGracefully).
+func applyCancelSignal(cmd *exec.Cmd, signal, grace string) (stop func(), err error) {
+	if signal == "" {
+		return func() {}, nil
+	}
+	sig, err := types.ParseCancelSignal(signal)
+	if err != nil {
+		return nil, err
+	}
+	wait := config.DefaultTimeoutGracePeriod
+	if grace != "" {
+		if wait, err = time.ParseDuration(grace); err != nil {
+			return nil, fmt.Errorf("invalid cancel_grace %q: %w", grace, err)
+		}
+	}
+	return cancelGracefully(cmd, sig, wait), nil
+}
@@ -0,0 +1,210 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// processAlive reports whether pid refers to a live (non-zombie) process.
+func processAlive(pid int) bool {
+	if data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat")); err == nil {
+		// Format: "pid (comm) state ..." - comm may contain spaces, so find the last ')'
+		s := string(data)
+		if i := strings.LastIndex(s, ")"); i >= 0 && i+2 < len(s) {
+			return s[i+2] != 'Z'
+		}
+		return true
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+// waitForPidFile polls until the command has written its background child's PID.
+func waitForPidFile(t *testing.T, path string) int {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.HasSuffix(string(data), "\n") {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatalf("parsing pid file: %v", err)
+			}
+			return pid
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("background child never wrote its pid")
+	return 0
+}
+
+func assertProcessGone(t *testing.T, pid int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("background child %d survived the step timeout", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExecuteShell_TimeoutKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	step := &types.Step{
+		ID:       "test-pgroup",
+		Executor: types.ExecutorShell,
+		Shell: &types.ShellConfig{
+			Command: "sleep 30 & echo $! > " + pidFile + "; wait",
+		},
+	}
+
+	start := time.Now()
+	_, stepErr := ExecuteShell(ctx, step)
+	elapsed := time.Since(start)
+
+	if stepErr == nil {
+		t.Fatal("expected error due to timeout")
+	}
+	// The orphaned sleep holds the stdout pipe; without the group kill Wait blocks for 30s
+	if elapsed > 5*time.Second {
+		t.Errorf("command took %v, background child kept it alive", elapsed)
+	}
+
+	assertProcessGone(t, waitForPidFile(t, pidFile))
+}
+
+func TestDefaultShellRunner_TimeoutKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	runner := NewDefaultShellRunner()
+	_, err := runner.Run(ctx, &types.ShellConfig{
+		Command: "sleep 30 & echo $! > " + pidFile + "; wait",
+	})
+	if err == nil {
+		t.Fatal("expected error due to timeout")
+	}
+
+	assertProcessGone(t, waitForPidFile(t, pidFile))
+}
+
+func TestExecuteShell_SoftCancelCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+	checkpoint := filepath.Join(dir, "checkpoint")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The step checkpoints and exits on SIGUSR1, long before the 10s hard cancel
+	step := &types.Step{
+		ID:       "test-soft-cancel",
+		Executor: types.ExecutorShell,
+		Shell: &types.ShellConfig{
+			Command:      "trap 'echo saved > " + checkpoint + "; exit 0' USR1; touch " + ready + "; while :; do sleep 0.05; done",
+			CancelSignal: "SIGUSR1",
+			CancelGrace:  "10s",
+		},
+	}
+	go func() {
+		for {
+			if _, err := os.Stat(ready); err == nil {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	ExecuteShell(ctx, step)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command took %v, want it to exit on the soft cancel", elapsed)
+	}
+	data, err := os.ReadFile(checkpoint)
+	if err != nil || strings.TrimSpace(string(data)) != "saved" {
+		t.Errorf("checkpoint = %q (%v), want the step to checkpoint before stopping", data, err)
+	}
+}
+
+func TestExecuteShell_SoftCancelKillsAfterGrace(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	// The step ignores the soft cancel, so it is killed once the grace passes
+	step := &types.Step{
+		ID:       "test-soft-cancel",
+		Executor: types.ExecutorShell,
+		Shell: &types.ShellConfig{
+			Command:      "trap '' USR1; sleep 30 & echo $! > " + pidFile + "; wait",
+			CancelSignal: "USR1",
+			CancelGrace:  "200ms",
+		},
+	}
+
+	start := time.Now()
+	_, stepErr := ExecuteShell(ctx, step)
+	elapsed := time.Since(start)
+
+	if stepErr == nil {
+		t.Fatal("expected error due to timeout")
+	}
+	if elapsed < 500*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("command took %v, want it killed 200ms after the 300ms timeout", elapsed)
+	}
+	assertProcessGone(t, waitForPidFile(t, pidFile))
+}
+
+func TestCancelGracefully_StopAfterWait(t *testing.T) {
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+	pidFile := filepath.Join(dir, "child.pid")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The shell exits on the soft cancel, leaving a child in its group that
+	// is only reachable through the kill timer
+	cmd := exec.CommandContext(ctx, "sh", "-c",
+		"trap 'exit 0' USR1; (trap '' USR1; exec sleep 30) >/dev/null 2>&1 & echo $! > "+pidFile+"; touch "+ready+"; while :; do sleep 0.05; done")
+	killProcessGroupOnCancel(cmd)
+	stop := cancelGracefully(cmd, syscall.SIGUSR1, 100*time.Millisecond)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	pid := waitForPidFile(t, pidFile)
+	defer syscall.Kill(pid, syscall.SIGKILL)
+
+	cancel()
+	cmd.Wait()
+	stop()
+
+	time.Sleep(300 * time.Millisecond)
+	if !processAlive(pid) {
+		t.Error("the kill timer fired after Wait returned and stop was called")
+	}
+}
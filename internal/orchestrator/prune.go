@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// PruneOptions selects the runs PruneRuns deletes.
+type PruneOptions struct {
+	Before   time.Time         // Delete runs that finished before this time
+	Statuses []types.RunStatus // Terminal statuses to delete (empty = all terminal)
+	DryRun   bool              // Report the matching runs without deleting them
+}
+
+// PruneRuns deletes terminal runs that finished before opts.Before and returns
+// them, oldest first. Runs that are still pending, running or cleaning up are
+// never deleted, whatever their age. A run without a finish time is aged by
+// its start time.
+func PruneRuns(ctx context.Context, store RunStore, opts PruneOptions) ([]*types.Run, error) {
+	for _, status := range opts.Statuses {
+		if !status.IsTerminal() {
+			return nil, fmt.Errorf("cannot prune %s runs: status must be done, failed or stopped", status)
+		}
+	}
+
+	runs, err := store.List(ctx, RunFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("listing runs: %w", err)
+	}
+
+	var pruned []*types.Run
+	for _, wf := range runs {
+		if !wf.Status.IsTerminal() {
+			continue
+		}
+		if len(opts.Statuses) > 0 && !slices.Contains(opts.Statuses, wf.Status) {
+			continue
+		}
+		if !runFinishedAt(wf).Before(opts.Before) {
+			continue
+		}
+		pruned = append(pruned, wf)
+	}
+	sort.Slice(pruned, func(i, j int) bool {
+		return runFinishedAt(pruned[i]).Before(runFinishedAt(pruned[j]))
+	})
+
+	if opts.DryRun {
+		return pruned, nil
+	}
+	for i, wf := range pruned {
+		if err := store.Delete(ctx, wf.ID); err != nil {
+			return pruned[:i], fmt.Errorf("deleting run %s: %w", wf.ID, err)
+		}
+	}
+	return pruned, nil
+}
+
+// runFinishedAt returns when a run finished, falling back to its start time.
+func runFinishedAt(wf *types.Run) time.Time {
+	if wf.DoneAt != nil {
+		return *wf.DoneAt
+	}
+	return wf.StartedAt
+}
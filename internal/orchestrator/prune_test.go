@@ -0,0 +1,134 @@
+package orchestrator
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestPruneRuns(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	newStore := func(t *testing.T) *YAMLRunStore {
+		t.Helper()
+		store, err := NewYAMLRunStore(filepath.Join(t.TempDir(), "runs"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs := []struct {
+			id     string
+			status types.RunStatus
+			done   *time.Time
+			start  time.Duration
+		}{
+			{"old-done", types.RunStatusDone, ago(10 * 24 * time.Hour), 10 * 24 * time.Hour},
+			{"old-failed", types.RunStatusFailed, ago(8 * 24 * time.Hour), 8 * 24 * time.Hour},
+			{"old-stopped", types.RunStatusStopped, ago(9 * 24 * time.Hour), 9 * 24 * time.Hour},
+			{"old-no-done-at", types.RunStatusDone, nil, 30 * 24 * time.Hour},
+			{"recent-done", types.RunStatusDone, ago(time.Hour), 2 * time.Hour},
+			// Started long ago but finished recently: aged by its finish time
+			{"long-failed", types.RunStatusFailed, ago(time.Hour), 20 * 24 * time.Hour},
+			{"old-running", types.RunStatusRunning, nil, 15 * 24 * time.Hour},
+			{"old-cleaning", types.RunStatusCleaningUp, nil, 15 * 24 * time.Hour},
+		}
+		for _, r := range runs {
+			wf := types.NewRun(r.id, "test.meow.toml", nil)
+			wf.Status = r.status
+			wf.StartedAt = now.Add(-r.start)
+			wf.DoneAt = r.done
+			if err := store.Create(ctx, wf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return store
+	}
+
+	remaining := func(t *testing.T, store *YAMLRunStore) []string {
+		t.Helper()
+		runs, err := store.List(ctx, RunFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, wf := range runs {
+			ids = append(ids, wf.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	ids := func(runs []*types.Run) []string {
+		var out []string
+		for _, wf := range runs {
+			out = append(out, wf.ID)
+		}
+		return out
+	}
+
+	cutoff := now.Add(-7 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		opts     PruneOptions
+		pruned   []string
+		remained []string
+	}{
+		{
+			name:     "done and failed",
+			opts:     PruneOptions{Before: cutoff, Statuses: []types.RunStatus{types.RunStatusDone, types.RunStatusFailed}},
+			pruned:   []string{"old-no-done-at", "old-done", "old-failed"},
+			remained: []string{"long-failed", "old-cleaning", "old-running", "old-stopped", "recent-done"},
+		},
+		{
+			name:     "all terminal",
+			opts:     PruneOptions{Before: cutoff},
+			pruned:   []string{"old-no-done-at", "old-done", "old-stopped", "old-failed"},
+			remained: []string{"long-failed", "old-cleaning", "old-running", "recent-done"},
+		},
+		{
+			name:     "dry run",
+			opts:     PruneOptions{Before: cutoff, DryRun: true},
+			pruned:   []string{"old-no-done-at", "old-done", "old-stopped", "old-failed"},
+			remained: []string{"long-failed", "old-cleaning", "old-done", "old-failed", "old-no-done-at", "old-running", "old-stopped", "recent-done"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore(t)
+			pruned, err := PruneRuns(ctx, store, tt.opts)
+			if err != nil {
+				t.Fatalf("PruneRuns() error = %v", err)
+			}
+			if got := ids(pruned); !slices.Equal(got, tt.pruned) {
+				t.Errorf("pruned = %v, want %v", got, tt.pruned)
+			}
+			if got := remaining(t, store); !slices.Equal(got, tt.remained) {
+				t.Errorf("remaining = %v, want %v", got, tt.remained)
+			}
+		})
+	}
+}
+
+func TestPruneRuns_RejectsActiveStatus(t *testing.T) {
+	store, err := NewYAMLRunStore(filepath.Join(t.TempDir(), "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = PruneRuns(context.Background(), store, PruneOptions{
+		Before:   time.Now(),
+		Statuses: []types.RunStatus{types.RunStatusRunning},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot prune running runs") {
+		t.Errorf("PruneRuns() error = %v, want rejection of running status", err)
+	}
+}
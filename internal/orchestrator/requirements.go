@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// A workflow's requires table declares what the run needs from the machine:
+// binaries on PATH and environment variables that must be set. They are
+// checked once when the orchestrator starts, before any step runs, so a
+// missing tool fails the run up front rather than halfway through. Everything
+// missing is reported in a single error.
+
+// checkRequirements fails the run if anything its requires table declares is
+// missing.
+func (o *Orchestrator) checkRequirements(ctx context.Context) error {
+	if o.workflowID == "" {
+		return nil
+	}
+
+	wf, err := o.store.Get(ctx, o.workflowID)
+	if err != nil {
+		return fmt.Errorf("getting workflow: %w", err)
+	}
+	if wf == nil || wf.Status.IsTerminal() || wf.Requires == nil {
+		return nil
+	}
+
+	if err := missingRequirements(wf.Requires); err != nil {
+		o.logger.Error("workflow requirements not met", "workflow", wf.ID, "error", err)
+		wf.Fail()
+		wf.Error = err.Error()
+		if saveErr := o.store.Save(ctx, wf); saveErr != nil {
+			o.logger.Error("failed to save workflow", "error", saveErr)
+		}
+		o.recordRunFinished(wf)
+		return fmt.Errorf("workflow requirements not met: %w", err)
+	}
+	return nil
+}
+
+// missingRequirements returns an error listing every required binary not
+// found on PATH and every required environment variable that is not set.
+func missingRequirements(req *types.RunRequirements) error {
+	var binaries, env []string
+	for _, bin := range req.Binaries {
+		if _, err := exec.LookPath(bin); err != nil {
+			binaries = append(binaries, bin)
+		}
+	}
+	for _, name := range req.Env {
+		if _, ok := os.LookupEnv(name); !ok {
+			env = append(env, name)
+		}
+	}
+
+	var missing []string
+	if len(binaries) > 0 {
+		missing = append(missing, "binaries not found on PATH: "+strings.Join(binaries, ", "))
+	}
+	if len(env) > 0 {
+		missing = append(missing, "environment variables not set: "+strings.Join(env, ", "))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(missing, "; "))
+}
@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// newRequiresWorkflow returns a running one-step workflow with requirements.
+func newRequiresWorkflow(req *types.RunRequirements) *types.Run {
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Requires = req
+	wf.Steps["build"] = &types.Step{
+		ID:       "build",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "echo building"},
+	}
+	return wf
+}
+
+func TestRun_RequirementsMissing(t *testing.T) {
+	t.Setenv("MEOW_TEST_PRESENT", "1")
+	store := newMockRunStore()
+	wf := newRequiresWorkflow(&types.RunRequirements{
+		Binaries: []string{"sh", "meow-no-such-binary", "meow-no-such-tool"},
+		Env:      []string{"MEOW_TEST_PRESENT", "MEOW_TEST_MISSING"},
+	})
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := orch.Run(ctx)
+	if err == nil {
+		t.Fatal("Run() succeeded with missing requirements")
+	}
+	want := "binaries not found on PATH: meow-no-such-binary, meow-no-such-tool; environment variables not set: MEOW_TEST_MISSING"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("Run() error = %v, want containing %q", err, want)
+	}
+	if wf.Status != types.RunStatusFailed {
+		t.Errorf("workflow status = %s, want failed", wf.Status)
+	}
+	if wf.Error != strings.TrimPrefix(err.Error(), "workflow requirements not met: ") {
+		t.Errorf("workflow error = %q, want the missing requirements", wf.Error)
+	}
+	if status := wf.Steps["build"].Status; status != types.StepStatusPending {
+		t.Errorf("build status = %s, want pending (no step runs)", status)
+	}
+}
+
+func TestRun_RequirementsPresent(t *testing.T) {
+	t.Setenv("MEOW_TEST_TOKEN", "secret")
+	store := newMockRunStore()
+	wf := newRequiresWorkflow(&types.RunRequirements{
+		Binaries: []string{"sh"},
+		Env:      []string{"MEOW_TEST_TOKEN"},
+	})
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if wf.Status != types.RunStatusDone {
+		t.Errorf("workflow status = %s, want done", wf.Status)
+	}
+	if status := wf.Steps["build"].Status; status != types.StepStatusDone {
+		t.Errorf("build status = %s, want done", status)
+	}
+}
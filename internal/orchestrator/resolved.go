@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// ResolvedRun is a run's step graph as it exists after expansion, with every
+// {{step.outputs.field}} reference that can be resolved substituted.
+// Template variables are substituted at bake time, so together these show
+// what actually ran (or will run) rather than what the template says.
+type ResolvedRun struct {
+	ID       string          `yaml:"id"`
+	Template string          `yaml:"template"`
+	Status   types.RunStatus `yaml:"status"`
+	Steps    []*types.Step   `yaml:"steps"` // Sorted by ID, so expanded children follow their parent
+}
+
+// ResolveRun loads the run with the given ID and returns its resolved step
+// graph. The stored run is not modified. References to outputs that do not
+// exist yet (steps still pending) are left as written.
+func ResolveRun(ctx context.Context, store RunStore, id string) (*ResolvedRun, error) {
+	wf, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolution mutates steps in place, so work on a deep copy
+	data, err := yaml.Marshal(wf)
+	if err != nil {
+		return nil, fmt.Errorf("copying run: %w", err)
+	}
+	var view types.Run
+	if err := yaml.Unmarshal(data, &view); err != nil {
+		return nil, fmt.Errorf("copying run: %w", err)
+	}
+
+	// Unresolvable references are expected here, so their warnings are dropped
+	resolver := &Orchestrator{logger: slog.New(slog.DiscardHandler)}
+	resolved := &ResolvedRun{
+		ID:       view.ID,
+		Template: view.Template,
+		Status:   view.Status,
+	}
+	for _, stepID := range sortedKeys(view.Steps) {
+		step := view.Steps[stepID]
+		resolver.resolveStepOutputRefs(&view, step)
+
+		// Branch conditions (and shell commands, once dispatched as branches)
+		// and preconditions and if checks are only resolved when they run, so
+		// never stored
+		if step.Branch != nil {
+			step.Branch.Condition = resolver.resolveOutputRefs(&view, step.Branch.Condition, step.ID)
+		}
+		for name, command := range step.Requires {
+			step.Requires[name] = resolver.resolveOutputRefs(&view, command, step.ID)
+		}
+		step.If = resolver.resolveOutputRefs(&view, step.If, step.ID)
+		resolved.Steps = append(resolved.Steps, step)
+	}
+	return resolved, nil
+}
+
+// WriteResolved encodes a resolved run as YAML.
+func WriteResolved(w io.Writer, resolved *ResolvedRun) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(resolved)
+}
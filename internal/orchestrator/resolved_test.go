@@ -0,0 +1,96 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestResolveRun(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("run-resolved", "ci.meow.toml", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["setup"] = &types.Step{
+		ID:       "setup",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusDone,
+		Outputs:  map[string]any{"dir": "/tmp/build"},
+	}
+	wf.Steps["tests"] = &types.Step{
+		ID:           "tests",
+		Executor:     types.ExecutorForeach,
+		Status:       types.StepStatusRunning,
+		Needs:        []string{"setup"},
+		Foreach:      &types.ForeachConfig{Items: `["a","b"]`, ItemVar: "pkg", Template: ".test"},
+		ExpandedInto: []string{"tests.0.run", "tests.1.run"},
+	}
+	// A dispatched shell step is stored as a branch whose condition is only
+	// resolved when it runs
+	wf.Steps["tests.0.run"] = &types.Step{
+		ID:           "tests.0.run",
+		Executor:     types.ExecutorShell,
+		Status:       types.StepStatusRunning,
+		ExpandedFrom: "tests",
+		Branch:       &types.BranchConfig{Condition: "go test {{setup.outputs.dir}}/a"},
+	}
+	wf.Steps["tests.1.run"] = &types.Step{
+		ID:           "tests.1.run",
+		Executor:     types.ExecutorShell,
+		Status:       types.StepStatusPending,
+		ExpandedFrom: "tests",
+		Shell:        &types.ShellConfig{Command: "go test {{setup.outputs.dir}}/b"},
+		Requires:     map[string]string{"build dir": "test -d {{setup.outputs.dir}}"},
+	}
+	wf.Steps["report"] = &types.Step{
+		ID:       "report",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"tests"},
+		Shell:    &types.ShellConfig{Command: "cat {{tests.1.run.outputs.summary}}"},
+	}
+	store.workflows[wf.ID] = wf
+
+	resolved, err := ResolveRun(context.Background(), store, wf.ID)
+	if err != nil {
+		t.Fatalf("ResolveRun() error = %v", err)
+	}
+
+	var ids []string
+	byID := make(map[string]*types.Step)
+	for _, step := range resolved.Steps {
+		ids = append(ids, step.ID)
+		byID[step.ID] = step
+	}
+	if got, want := strings.Join(ids, " "), "report setup tests tests.0.run tests.1.run"; got != want {
+		t.Errorf("steps = %q, want %q", got, want)
+	}
+
+	if got := byID["tests.0.run"].Branch.Condition; got != "go test /tmp/build/a" {
+		t.Errorf("tests.0.run condition = %q, want resolved", got)
+	}
+	if got := byID["tests.1.run"].Shell.Command; got != "go test /tmp/build/b" {
+		t.Errorf("tests.1.run command = %q, want resolved", got)
+	}
+	if got := byID["tests.1.run"].Requires["build dir"]; got != "test -d /tmp/build" {
+		t.Errorf("tests.1.run requires = %q, want resolved", got)
+	}
+	if got := byID["report"].Shell.Command; got != "cat {{tests.1.run.outputs.summary}}" {
+		t.Errorf("report command = %q, want the unproduced output left as written", got)
+	}
+
+	// The stored run is untouched
+	if got := wf.Steps["tests.1.run"].Shell.Command; got != "go test {{setup.outputs.dir}}/b" {
+		t.Errorf("stored command = %q, want unchanged", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResolved(&buf, resolved); err != nil {
+		t.Fatalf("WriteResolved() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "command: go test /tmp/build/b") {
+		t.Errorf("WriteResolved output missing resolved command:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,58 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// A workflow's timeout caps the whole run's wall-clock time, measured from
+// its start, so a stalled executor cannot keep it running forever. Once the
+// run outlives it, in-flight commands are cancelled, its running steps fail
+// with a timeout error, and the run fails, running cleanup_on_failure if the
+// template defines one.
+
+// runTimedOut reports whether the run has outlived its global timeout.
+func (o *Orchestrator) runTimedOut(wf *types.Run) bool {
+	return wf.Timeout > 0 && !wf.StartedAt.IsZero() && o.now().Sub(wf.StartedAt) > wf.Timeout
+}
+
+// failTimedOutRun fails a run that exceeded its global timeout. Called with
+// wfMu held.
+func (o *Orchestrator) failTimedOutRun(ctx context.Context, wf *types.Run) error {
+	msg := fmt.Sprintf("workflow exceeded global timeout of %s", wf.Timeout)
+	o.logger.Error("workflow timed out", "id", wf.ID, "timeout", wf.Timeout, "elapsed", o.now().Sub(wf.StartedAt))
+	wf.Error = msg
+
+	for _, id := range sortedKeys(wf.Steps) {
+		step := wf.Steps[id]
+		if step.Status != types.StepStatusRunning && step.Status != types.StepStatusCompleting {
+			continue
+		}
+		if err := step.Fail(&types.StepError{Message: msg, Type: types.StepErrorTimeout}); err != nil {
+			o.logger.Error("failed to mark step as failed", "step", id, "error", err)
+			continue
+		}
+		o.recordStepFinished(wf.ID, step)
+	}
+	// Cancelled commands leave their steps alone, so they exit without
+	// undoing the failures above
+	o.cancelPendingCommands()
+
+	if wf.HasCleanup(types.RunStatusFailed) {
+		// Unlock before RunCleanup since it may do I/O
+		o.tickBusy = true
+		o.wfMu.Unlock()
+		err := o.RunCleanup(ctx, wf, types.RunStatusFailed)
+		o.wfMu.Lock()
+		if err != nil {
+			o.logger.Error("cleanup failed", "error", err)
+		}
+		return nil
+	}
+
+	wf.Fail()
+	o.recordRunFinished(wf)
+	return o.saveProgress(ctx, wf)
+}
@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestOrchestrator_RunTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		cleanup bool
+	}{
+		{name: "no cleanup"},
+		{name: "runs cleanup_on_failure", cleanup: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockRunStore()
+			wf := types.NewRun("test-wf", "test-template", nil)
+			wf.Status = types.RunStatusRunning
+			wf.Timeout = 200 * time.Millisecond
+			marker := filepath.Join(t.TempDir(), "cleaned-up")
+			if tt.cleanup {
+				wf.CleanupOnFailure = "touch " + marker
+			}
+			wf.Steps["sleep"] = &types.Step{
+				ID:       "sleep",
+				Executor: types.ExecutorShell,
+				Status:   types.StepStatusPending,
+				Shell:    &types.ShellConfig{Command: "sleep 10"},
+			}
+			wf.Steps["after"] = &types.Step{
+				ID:       "after",
+				Executor: types.ExecutorShell,
+				Status:   types.StepStatusPending,
+				Needs:    []string{"sleep"},
+				Shell:    &types.ShellConfig{Command: "true"},
+			}
+			store.workflows[wf.ID] = wf
+
+			orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+			orch.SetWorkflowID(wf.ID)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			start := time.Now()
+			if err := orch.Run(ctx); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Run() took %v, want it to stop soon after the 200ms timeout", elapsed)
+			}
+
+			if wf.Status != types.RunStatusFailed {
+				t.Errorf("run status = %s, want failed", wf.Status)
+			}
+			if want := "workflow exceeded global timeout of 200ms"; wf.Error != want {
+				t.Errorf("run error = %q, want %q", wf.Error, want)
+			}
+			sleep := wf.Steps["sleep"]
+			if sleep.Status != types.StepStatusFailed || sleep.Error == nil || sleep.Error.Type != types.StepErrorTimeout {
+				t.Errorf("sleep step = %s (%+v), want failed with a timeout error", sleep.Status, sleep.Error)
+			}
+			if status := wf.Steps["after"].Status; status != types.StepStatusPending {
+				t.Errorf("after step status = %s, want pending", status)
+			}
+			if _, err := os.Stat(marker); (err == nil) != tt.cleanup {
+				t.Errorf("cleanup ran = %v, want %v", err == nil, tt.cleanup)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RunTimeout_NotExceeded(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Timeout = time.Minute
+	wf.Steps["quick"] = &types.Step{
+		ID:       "quick",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "true"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := orch.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if wf.Status != types.RunStatusDone || wf.Error != "" {
+		t.Errorf("run = %s (error %q), want done", wf.Status, wf.Error)
+	}
+}
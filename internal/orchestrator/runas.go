@@ -0,0 +1,71 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// A shell step with run_as runs its command as another OS user, typically to
+// drop privileges for one step of a workflow run by root. The command gets the
+// user's UID, primary and supplementary groups, and HOME, USER and LOGNAME.
+// Switching to another user needs the orchestrator to run as root; a step that
+// cannot switch fails instead of running as the orchestrator's user.
+
+// runAsCredential looks up the user a command should run as, by name or
+// numeric UID. It fails if the user does not exist or the orchestrator cannot
+// switch to it.
+func runAsCredential(name string) (*syscall.Credential, *user.User, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		var idErr error
+		if u, idErr = user.LookupId(name); idErr != nil {
+			return nil, nil, fmt.Errorf("run_as: unknown user %q", name)
+		}
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("run_as: user %q has non-numeric uid %q", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("run_as: user %q has non-numeric gid %q", name, u.Gid)
+	}
+	if euid := os.Geteuid(); euid != 0 && uint64(euid) != uid {
+		return nil, nil, fmt.Errorf("run_as: cannot run as user %q: the orchestrator runs as uid %d and needs root to switch users", name, euid)
+	}
+
+	cred := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	groupIDs, err := u.GroupIds()
+	if err != nil {
+		groupIDs = nil // Fall back to the primary group alone
+	}
+	for _, g := range groupIDs {
+		if id, err := strconv.ParseUint(g, 10, 32); err == nil {
+			cred.Groups = append(cred.Groups, uint32(id))
+		}
+	}
+	return cred, u, nil
+}
+
+// runAsUser makes cmd run as the named user. Must be called before cmd.Env is
+// extended with the step's own env, which takes precedence.
+func runAsUser(cmd *exec.Cmd, name string) error {
+	cred, u, err := runAsCredential(name)
+	if err != nil {
+		return err
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+	return nil
+}
@@ -0,0 +1,84 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestExecuteShell_RunAs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("run_as is tested on Linux")
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("switching users requires root")
+	}
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skipf("no nobody user: %v", err)
+	}
+
+	step := &types.Step{
+		ID:       "drop",
+		Executor: types.ExecutorShell,
+		Shell: &types.ShellConfig{
+			Command: `echo "$(id -u) $(id -g) $USER"`,
+			Workdir: os.TempDir(),
+			RunAs:   "nobody",
+		},
+	}
+	result, stepErr := ExecuteShell(context.Background(), step)
+	if stepErr != nil {
+		t.Fatalf("ExecuteShell() error = %v", stepErr)
+	}
+	if want := nobody.Uid + " " + nobody.Gid + " nobody"; result.Stdout != want {
+		t.Errorf("stdout = %q, want %q", result.Stdout, want)
+	}
+}
+
+func TestExecuteShell_RunAsUnknownUser(t *testing.T) {
+	step := &types.Step{
+		ID:       "drop",
+		Executor: types.ExecutorShell,
+		Shell:    &types.ShellConfig{Command: "echo ran", RunAs: "meow-no-such-user"},
+	}
+	result, stepErr := ExecuteShell(context.Background(), step)
+	if stepErr == nil || !strings.Contains(stepErr.Message, `unknown user "meow-no-such-user"`) {
+		t.Fatalf("ExecuteShell() error = %v, want unknown user", stepErr)
+	}
+	if result.Stdout != "" || result.ExitCode == 0 {
+		t.Errorf("command ran: stdout = %q, exit code = %d", result.Stdout, result.ExitCode)
+	}
+}
+
+func TestOrchestrator_ShellRunAsFailsStep(t *testing.T) {
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["drop"] = &types.Step{
+		ID:       "drop",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Shell:    &types.ShellConfig{Command: "echo ran", RunAs: "meow-no-such-user"},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	if err := orch.processWorkflow(context.Background(), wf); err != nil {
+		t.Fatalf("processWorkflow() error = %v", err)
+	}
+	orch.wg.Wait()
+
+	step := wf.Steps["drop"]
+	if step.Status != types.StepStatusFailed {
+		t.Fatalf("status = %s, want failed", step.Status)
+	}
+	if step.Error == nil || !strings.Contains(step.Error.Message, "unknown user") {
+		t.Errorf("error = %+v, want unknown user", step.Error)
+	}
+}
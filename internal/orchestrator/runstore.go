@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/akatz-ai/meow/internal/types"
 )
@@ -25,9 +26,26 @@ type RunStore interface {
 
 	// GetByAgent returns runs with steps assigned to agent.
 	GetByAgent(ctx context.Context, agentID string) ([]*types.Run, error)
+
+	// ListByTemplate returns runs started from the named template.
+	ListByTemplate(ctx context.Context, template string) ([]*types.Run, error)
 }
 
 // RunFilter for listing runs.
 type RunFilter struct {
-	Status types.RunStatus // Filter by status (empty = all)
+	Status   types.RunStatus // Filter by status (empty = all)
+	Template string          // Filter by template path or file name (empty = all)
+}
+
+// Matches reports whether a run passes the filter. A template filter matches
+// the run's template path exactly or by file name, so "deploy.toml" finds
+// runs of .meow/workflows/deploy.toml.
+func (f RunFilter) Matches(run *types.Run) bool {
+	if f.Status != "" && run.Status != f.Status {
+		return false
+	}
+	if f.Template != "" && run.Template != f.Template && filepath.Base(run.Template) != f.Template {
+		return false
+	}
+	return true
 }
@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestRunStore_ListTemplateFilter(t *testing.T) {
+	ctx := context.Background()
+	store := newMockRunStore()
+	for _, r := range []struct {
+		id, template string
+		status       types.RunStatus
+	}{
+		{"deploy-1", ".meow/workflows/deploy.toml", types.RunStatusRunning},
+		{"deploy-2", ".meow/workflows/deploy.toml", types.RunStatusDone},
+		{"deploy-3", "/other/deploy.toml", types.RunStatusRunning},
+		{"build-1", ".meow/workflows/build.toml", types.RunStatusRunning},
+	} {
+		wf := types.NewRun(r.id, r.template, nil)
+		wf.Status = r.status
+		store.workflows[r.id] = wf
+	}
+
+	ids := func(runs []*types.Run) []string {
+		var out []string
+		for _, r := range runs {
+			out = append(out, r.ID)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter RunFilter
+		want   []string
+	}{
+		{"template by file name", RunFilter{Template: "deploy.toml"}, []string{"deploy-1", "deploy-2", "deploy-3"}},
+		{"template by path", RunFilter{Template: ".meow/workflows/deploy.toml"}, []string{"deploy-1", "deploy-2"}},
+		{"status and template", RunFilter{Status: types.RunStatusRunning, Template: "deploy.toml"}, []string{"deploy-1", "deploy-3"}},
+		{"status and template path", RunFilter{Status: types.RunStatusDone, Template: ".meow/workflows/deploy.toml"}, []string{"deploy-2"}},
+		{"no match", RunFilter{Status: types.RunStatusDone, Template: "build.toml"}, nil},
+		{"partial name does not match", RunFilter{Template: "deploy"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := store.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			got := ids(runs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("List(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("List(%+v) = %v, want %v", tt.filter, got, tt.want)
+				}
+			}
+		})
+	}
+
+	runs, err := store.ListByTemplate(ctx, "build.toml")
+	if err != nil {
+		t.Fatalf("ListByTemplate() error = %v", err)
+	}
+	if got := ids(runs); len(got) != 1 || got[0] != "build-1" {
+		t.Errorf("ListByTemplate(build.toml) = %v, want [build-1]", got)
+	}
+}
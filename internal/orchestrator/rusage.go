@@ -0,0 +1,47 @@
+package orchestrator
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// ResourceUsage is what a finished shell command consumed, as reported by the
+// kernel when the orchestrator waited on it. It covers the shell and every
+// descendant it waited for.
+type ResourceUsage struct {
+	MaxRSSBytes int64         // Peak resident set size of the largest process
+	CPUTime     time.Duration // User plus system CPU time
+}
+
+// resourceUsage reads a finished command's usage from its process state, or
+// returns nil where the platform doesn't report rusage.
+func resourceUsage(state *os.ProcessState) *ResourceUsage {
+	if state == nil {
+		return nil
+	}
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return nil
+	}
+	// macOS reports ru_maxrss in bytes; Linux and the BSDs in kilobytes
+	maxRSS := int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		maxRSS *= 1024
+	}
+	return &ResourceUsage{
+		MaxRSSBytes: maxRSS,
+		CPUTime:     state.UserTime() + state.SystemTime(),
+	}
+}
+
+// addUsageOutputs exposes a command's resource usage as step outputs. Nothing
+// is added when usage is unavailable.
+func addUsageOutputs(outputs map[string]any, usage *ResourceUsage) {
+	if usage == nil {
+		return
+	}
+	outputs["max_rss_bytes"] = usage.MaxRSSBytes
+	outputs["cpu_ms"] = usage.CPUTime.Milliseconds()
+}
@@ -3,7 +3,6 @@ package orchestrator
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -30,15 +29,28 @@ func (r *DefaultShellRunner) Run(ctx context.Context, cfg *types.ShellConfig) (m
 
 	// Create command
 	cmd := exec.CommandContext(ctx, "sh", "-c", cfg.Command)
+	killProcessGroupOnCancel(cmd)
+	stopCancel, err := applyCancelSignal(cmd, cfg.CancelSignal, cfg.CancelGrace)
+	if err != nil {
+		return outputs, err
+	}
 
 	// Set working directory
 	if cfg.Workdir != "" {
 		cmd.Dir = cfg.Workdir
 	}
 
+	if cfg.RunAs != "" {
+		if err := runAsUser(cmd, cfg.RunAs); err != nil {
+			return outputs, err
+		}
+	}
+
 	// Set environment variables
 	if len(cfg.Env) > 0 {
-		cmd.Env = os.Environ()
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
 		for k, v := range cfg.Env {
 			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 		}
@@ -50,7 +62,8 @@ func (r *DefaultShellRunner) Run(ctx context.Context, cfg *types.ShellConfig) (m
 	cmd.Stderr = &stderr
 
 	// Run the command
-	err := cmd.Run()
+	err = cmd.Run()
+	stopCancel()
 
 	// Capture raw output
 	stdoutStr := strings.TrimSpace(stdout.String())
@@ -80,6 +93,7 @@ func (r *DefaultShellRunner) Run(ctx context.Context, cfg *types.ShellConfig) (m
 	outputs["stdout"] = stdoutStr
 	outputs["stderr"] = stderrStr
 	outputs["exit_code"] = exitCode
+	addUsageOutputs(outputs, resourceUsage(cmd.ProcessState))
 
 	return outputs, err
 }
@@ -88,46 +102,5 @@ func (r *DefaultShellRunner) Run(ctx context.Context, cfg *types.ShellConfig) (m
 // If substituteSource is provided, it will be called to substitute any remaining
 // variable references in file paths before reading.
 func (r *DefaultShellRunner) captureOutput(outputSource types.OutputSource, stdout, stderr string, exitCode int, substituteSource SourceSubstituteFunc) (any, error) {
-	source := outputSource.Source
-	var value string
-
-	switch source {
-	case "stdout":
-		value = stdout
-	case "stderr":
-		value = stderr
-	case "exit_code":
-		return exitCode, nil // exit_code is always int, ignore type
-	default:
-		// Check for file: prefix
-		if strings.HasPrefix(source, "file:") {
-			filePath := strings.TrimPrefix(source, "file:")
-			// Substitute any remaining variable references (e.g., step outputs)
-			if substituteSource != nil && strings.Contains(filePath, "{{") {
-				substituted, err := substituteSource(filePath)
-				if err != nil {
-					return nil, fmt.Errorf("substituting output path: %w", err)
-				}
-				filePath = substituted
-			}
-			content, err := os.ReadFile(filePath)
-			if err != nil {
-				return nil, fmt.Errorf("reading output file %s: %w", filePath, err)
-			}
-			value = strings.TrimSpace(string(content))
-		} else {
-			return nil, fmt.Errorf("unknown output source: %s", source)
-		}
-	}
-
-	// Handle type conversion
-	if outputSource.Type == "json" {
-		var parsed any
-		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
-			return nil, fmt.Errorf("parsing JSON from %s: %w", source, err)
-		}
-		return parsed, nil
-	}
-
-	return value, nil
+	return captureOutput(outputSource, &ShellResult{Stdout: stdout, Stderr: stderr, ExitCode: exitCode}, substituteSource)
 }
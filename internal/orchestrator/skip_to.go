@@ -0,0 +1,84 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// SkipTo marks every step the target transitively needs as done with empty
+// outputs, so the run starts at target without re-running upstream work.
+// Steps that are not upstream of target are left to run as usual.
+// Returns the sorted IDs of the bypassed steps.
+//
+// The skip is rejected when target could not run without the skipped work:
+// when it references an output of a skipped step, or when it is an agent
+// step whose agent is spawned by a skipped step.
+func SkipTo(wf *types.Run, target string) ([]string, error) {
+	step, ok := wf.Steps[target]
+	if !ok {
+		return nil, fmt.Errorf("step %q not found", target)
+	}
+	if step.Status != types.StepStatusPending {
+		return nil, fmt.Errorf("step %q is %s, not pending", target, step.Status)
+	}
+
+	upstream := make(map[string]bool)
+	queue := append([]string(nil), step.Needs...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if upstream[id] {
+			continue
+		}
+		dep, ok := wf.Steps[id]
+		if !ok {
+			return nil, fmt.Errorf("step %q needs unknown step %q", target, id)
+		}
+		upstream[id] = true
+		queue = append(queue, dep.Needs...)
+	}
+
+	if err := checkSkipSatisfiable(step, upstream, wf); err != nil {
+		return nil, err
+	}
+
+	skipped := make([]string, 0, len(upstream))
+	for id := range upstream {
+		skipped = append(skipped, id)
+	}
+	sort.Strings(skipped)
+	for _, id := range skipped {
+		if err := wf.Steps[id].Bypass(); err != nil {
+			return nil, fmt.Errorf("skipping step %s: %w", id, err)
+		}
+	}
+	return skipped, nil
+}
+
+// checkSkipSatisfiable returns an error if step depends on work done by the
+// steps in skipped beyond their completion.
+func checkSkipSatisfiable(step *types.Step, skipped map[string]bool, wf *types.Run) error {
+	// Scan every field rather than tracking which ones hold templates
+	data, err := yaml.Marshal(step)
+	if err != nil {
+		return fmt.Errorf("inspecting step %s: %w", step.ID, err)
+	}
+	for _, match := range stepOutputRefPattern.FindAllStringSubmatch(string(data), -1) {
+		if skipped[match[1]] {
+			return fmt.Errorf("step %s uses {{%s.outputs.%s}}, which a skipped step would not produce", step.ID, match[1], match[2])
+		}
+	}
+
+	if step.Agent != nil {
+		for id := range skipped {
+			if spawn := wf.Steps[id].Spawn; spawn != nil && spawn.Agent == step.Agent.Agent {
+				return fmt.Errorf("step %s needs agent %s, which skipped step %s would spawn", step.ID, step.Agent.Agent, id)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestSkipTo(t *testing.T) {
+	// fetch -> build -> test -> report, with lint off to the side
+	newRun := func() *types.Run {
+		wf := types.NewRun("test-wf", "test-template", nil)
+		wf.Status = types.RunStatusRunning
+		add := func(id, command string, needs ...string) {
+			wf.Steps[id] = &types.Step{
+				ID:       id,
+				Executor: types.ExecutorShell,
+				Status:   types.StepStatusPending,
+				Needs:    needs,
+				Shell:    &types.ShellConfig{Command: command},
+			}
+		}
+		add("fetch", "echo fetch")
+		add("build", "echo build", "fetch")
+		add("test", "echo test", "build")
+		add("report", "echo report", "test")
+		add("lint", "echo lint")
+		return wf
+	}
+
+	t.Run("starts execution at the target", func(t *testing.T) {
+		store := newMockRunStore()
+		wf := newRun()
+		store.workflows[wf.ID] = wf
+
+		skipped, err := SkipTo(wf, "test")
+		if err != nil {
+			t.Fatalf("SkipTo() error = %v", err)
+		}
+		if got := strings.Join(skipped, " "); got != "build fetch" {
+			t.Errorf("skipped = %q, want %q", got, "build fetch")
+		}
+		for _, id := range skipped {
+			step := wf.Steps[id]
+			if step.Status != types.StepStatusDone || step.DoneAt == nil || len(step.Outputs) != 0 {
+				t.Errorf("%s = %s (outputs %v), want done with empty outputs", id, step.Status, step.Outputs)
+			}
+		}
+
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		if err := orch.processWorkflow(context.Background(), wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+
+		for id, want := range map[string]bool{"test": true, "lint": true, "report": false} {
+			if started := wf.Steps[id].Status != types.StepStatusPending; started != want {
+				t.Errorf("%s status = %s, want started = %v", id, wf.Steps[id].Status, want)
+			}
+		}
+	})
+
+	t.Run("rejects a target using skipped outputs", func(t *testing.T) {
+		wf := newRun()
+		wf.Steps["test"].Shell.Command = "go test {{build.outputs.dir}}"
+		_, err := SkipTo(wf, "test")
+		if err == nil || !strings.Contains(err.Error(), "build.outputs.dir") {
+			t.Fatalf("SkipTo() error = %v, want unsatisfiable output reference", err)
+		}
+		if wf.Steps["build"].Status != types.StepStatusPending {
+			t.Errorf("build status = %s, want untouched after a rejected skip", wf.Steps["build"].Status)
+		}
+	})
+
+	t.Run("rejects a target whose agent a skipped step spawns", func(t *testing.T) {
+		wf := newRun()
+		wf.Steps["build"] = &types.Step{
+			ID:       "build",
+			Executor: types.ExecutorSpawn,
+			Status:   types.StepStatusPending,
+			Needs:    []string{"fetch"},
+			Spawn:    &types.SpawnConfig{Agent: "worker"},
+		}
+		wf.Steps["test"] = &types.Step{
+			ID:       "test",
+			Executor: types.ExecutorAgent,
+			Status:   types.StepStatusPending,
+			Needs:    []string{"build"},
+			Agent:    &types.AgentConfig{Agent: "worker", Prompt: "Test it"},
+		}
+		if _, err := SkipTo(wf, "test"); err == nil || !strings.Contains(err.Error(), "agent worker") {
+			t.Fatalf("SkipTo() error = %v, want skipped spawn error", err)
+		}
+	})
+
+	t.Run("rejects an unknown target", func(t *testing.T) {
+		if _, err := SkipTo(newRun(), "deploy"); err == nil {
+			t.Fatal("SkipTo() error = nil, want unknown step error")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// Steps with lock = "name" hold an exclusive flock on <locksDir>/<name>.lock
+// from dispatch until they stop running. Every orchestrator sharing the
+// directory, in this process or another, serializes those steps. The kernel
+// drops the locks of an orchestrator that dies, so a crash cannot wedge them;
+// a step recovered as running after a restart does not take its lock again.
+
+// SetLocksDir sets the directory holding step lock files. An empty dir
+// disables step locks: steps declaring one run without it.
+func (o *Orchestrator) SetLocksDir(dir string) {
+	o.locksDir = dir
+}
+
+// acquireStepLock takes the lock a ready step declares, if any. It returns
+// false while another step holds the lock; the step then stays pending. An
+// unusable locks dir fails the step. modified is true if the step's state
+// changed.
+func (o *Orchestrator) acquireStepLock(wf *types.Run, step *types.Step) (acquired, modified bool) {
+	if step.Lock == "" || o.locksDir == "" {
+		return true, false
+	}
+
+	o.stepLocksMu.Lock()
+	defer o.stepLocksMu.Unlock()
+	if _, held := o.stepLocks[wf.ID][step.ID]; held {
+		return true, false
+	}
+
+	f, err := tryFileLock(filepath.Join(o.locksDir, step.Lock+".lock"))
+	if err != nil {
+		o.logger.Error("step lock failed", "step", step.ID, "lock", step.Lock, "error", err)
+		if startErr := step.Start(); startErr == nil {
+			step.Fail(&types.StepError{Message: fmt.Sprintf("acquiring lock %q: %v", step.Lock, err)})
+		}
+		return false, true
+	}
+	if f == nil {
+		o.logger.Debug("step waiting for lock", "step", step.ID, "lock", step.Lock)
+		return false, false
+	}
+
+	if o.stepLocks == nil {
+		o.stepLocks = make(map[string]map[string]*os.File)
+	}
+	if o.stepLocks[wf.ID] == nil {
+		o.stepLocks[wf.ID] = make(map[string]*os.File)
+	}
+	o.stepLocks[wf.ID][step.ID] = f
+	o.logger.Debug("step lock acquired", "step", step.ID, "lock", step.Lock)
+	return true, false
+}
+
+// releaseStepLocks releases the locks held for wf's steps that are no longer
+// running: finished, failed, or reset to pending for a retry.
+func (o *Orchestrator) releaseStepLocks(wf *types.Run) {
+	o.stepLocksMu.Lock()
+	defer o.stepLocksMu.Unlock()
+	for stepID, f := range o.stepLocks[wf.ID] {
+		if step, ok := wf.Steps[stepID]; ok && step.Status == types.StepStatusRunning {
+			continue
+		}
+		releaseFileLock(f)
+		delete(o.stepLocks[wf.ID], stepID)
+		o.logger.Debug("step lock released", "step", stepID)
+	}
+	if len(o.stepLocks[wf.ID]) == 0 {
+		delete(o.stepLocks, wf.ID)
+	}
+}
+
+// releaseOtherStepLocks releases every lock held for workflows not in
+// running, which tick no longer processes.
+func (o *Orchestrator) releaseOtherStepLocks(running []*types.Run) {
+	o.stepLocksMu.Lock()
+	defer o.stepLocksMu.Unlock()
+	active := make(map[string]bool, len(running))
+	for _, wf := range running {
+		active[wf.ID] = true
+	}
+	for wfID, locks := range o.stepLocks {
+		if active[wfID] {
+			continue
+		}
+		for _, f := range locks {
+			releaseFileLock(f)
+		}
+		delete(o.stepLocks, wfID)
+	}
+}
+
+// releaseAllStepLocks releases every lock this orchestrator holds.
+func (o *Orchestrator) releaseAllStepLocks() {
+	o.releaseOtherStepLocks(nil)
+}
+
+// tryFileLock opens path and takes an exclusive flock on it without
+// blocking. It returns a nil file if the lock is held elsewhere.
+func tryFileLock(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// releaseFileLock unlocks and closes a file locked by tryFileLock.
+func releaseFileLock(f *os.File) {
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	f.Close()
+}
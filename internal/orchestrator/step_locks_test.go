@@ -0,0 +1,116 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestStepLocks_SerializeAcrossOrchestrators(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "events.log")
+	locksDir := filepath.Join(dir, "locks")
+	store := newMockRunStore()
+
+	// Two workflows, each driven by its own orchestrator, contend for "db"
+	var orchs []*Orchestrator
+	for _, id := range []string{"wf-a", "wf-b"} {
+		wf := types.NewRun(id, "test-template", nil)
+		wf.Status = types.RunStatusRunning
+		wf.Steps["migrate"] = &types.Step{
+			ID:       "migrate",
+			Executor: types.ExecutorShell,
+			Status:   types.StepStatusPending,
+			Lock:     "db",
+			Shell: &types.ShellConfig{
+				Command: "echo start " + id + " >> " + log + " && sleep 0.3 && echo end " + id + " >> " + log,
+			},
+		}
+		store.workflows[id] = wf
+
+		orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+		orch.SetWorkflowID(id)
+		orch.SetLocksDir(locksDir)
+		orchs = append(orchs, orch)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make([]error, len(orchs))
+	for i, orch := range orchs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = orch.Run(ctx)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("orchestrator %d: Run() error = %v", i, err)
+		}
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("reading event log: %v", err)
+	}
+	got := string(data)
+	if got != "start wf-a\nend wf-a\nstart wf-b\nend wf-b\n" && got != "start wf-b\nend wf-b\nstart wf-a\nend wf-a\n" {
+		t.Errorf("events = %q, want one migration to finish before the other starts", got)
+	}
+	for _, id := range []string{"wf-a", "wf-b"} {
+		if status := store.workflows[id].Steps["migrate"].Status; status != types.StepStatusDone {
+			t.Errorf("%s migrate status = %s, want done", id, status)
+		}
+	}
+}
+
+func TestStepLocks_HeldUntilStepStopsRunning(t *testing.T) {
+	locksDir := t.TempDir()
+	o := New(testConfig(), newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	o.SetLocksDir(locksDir)
+
+	wf := types.NewRun("test-wf", "test-template", nil)
+	first := &types.Step{ID: "first", Status: types.StepStatusPending, Lock: "deploy"}
+	second := &types.Step{ID: "second", Status: types.StepStatusPending, Lock: "deploy"}
+	wf.Steps[first.ID] = first
+	wf.Steps[second.ID] = second
+
+	if acquired, _ := o.acquireStepLock(wf, first); !acquired {
+		t.Fatal("first step did not get the free lock")
+	}
+	first.Status = types.StepStatusRunning
+	if acquired, _ := o.acquireStepLock(wf, second); acquired {
+		t.Fatal("second step got the lock while the first holds it")
+	}
+
+	// Still running: the lock is kept
+	o.releaseStepLocks(wf)
+	if acquired, _ := o.acquireStepLock(wf, second); acquired {
+		t.Fatal("lock released while its step is still running")
+	}
+
+	first.Status = types.StepStatusDone
+	o.releaseStepLocks(wf)
+	if acquired, _ := o.acquireStepLock(wf, second); !acquired {
+		t.Fatal("second step did not get the lock after the first finished")
+	}
+	o.releaseAllStepLocks()
+}
+
+func TestStepLocks_DisabledWithoutLocksDir(t *testing.T) {
+	o := New(testConfig(), newMockRunStore(), newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	wf := types.NewRun("test-wf", "test-template", nil)
+	step := &types.Step{ID: "step", Status: types.StepStatusPending, Lock: "deploy"}
+	wf.Steps[step.ID] = step
+	if acquired, modified := o.acquireStepLock(wf, step); !acquired || modified {
+		t.Errorf("acquireStepLock() = %v, %v, want true, false", acquired, modified)
+	}
+}
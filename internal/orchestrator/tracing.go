@@ -0,0 +1,206 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/akatz-ai/meow/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the instrumentation scope for spans emitted by the orchestrator.
+const tracerName = "github.com/akatz-ai/meow/internal/orchestrator"
+
+// runTracer emits OpenTelemetry spans for runs and their steps: one root span
+// per run, a child span per step (nested under the expand step that created
+// it, if any), and sub-spans for agent interactions within a step.
+//
+// Spans are created lazily, so a resumed run gets a fresh root span covering
+// the rest of its execution. Safe for concurrent use.
+type runTracer struct {
+	tracer trace.Tracer
+
+	mu sync.Mutex
+	// Root span per run. Key: workflow ID
+	runs map[string]trace.Span
+	// Open step spans. Key: "workflowID:stepID"
+	steps map[string]trace.Span
+	// Span contexts of every step seen, so expanded children can nest under
+	// an expand step that has already finished. Key: "workflowID:stepID"
+	stepContexts map[string]trace.SpanContext
+}
+
+func newRunTracer(tp trace.TracerProvider) *runTracer {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	return &runTracer{
+		tracer:       tp.Tracer(tracerName),
+		runs:         make(map[string]trace.Span),
+		steps:        make(map[string]trace.Span),
+		stepContexts: make(map[string]trace.SpanContext),
+	}
+}
+
+// SetTracerProvider sets the provider used to emit run and step spans.
+// Passing nil disables tracing.
+func (o *Orchestrator) SetTracerProvider(tp trace.TracerProvider) {
+	o.tracing = newRunTracer(tp)
+}
+
+// runSpan returns the root span for wf, starting it if needed. Must be called
+// with t.mu held.
+func (t *runTracer) runSpan(wf *types.Run) trace.Span {
+	if span, ok := t.runs[wf.ID]; ok {
+		return span
+	}
+	_, span := t.tracer.Start(context.Background(), "run "+wf.Template,
+		trace.WithAttributes(
+			attribute.String("meow.run.id", wf.ID),
+			attribute.String("meow.run.template", wf.Template),
+		))
+	t.runs[wf.ID] = span
+	return span
+}
+
+// stepSpan returns the open span for step, starting it if needed. Must be
+// called with t.mu held.
+func (t *runTracer) stepSpan(wf *types.Run, step *types.Step) trace.Span {
+	key := wf.ID + ":" + step.ID
+	if span, ok := t.steps[key]; ok {
+		return span
+	}
+
+	parent := trace.ContextWithSpan(context.Background(), t.runSpan(wf))
+	if step.ExpandedFrom != "" {
+		if sc, ok := t.stepContexts[wf.ID+":"+step.ExpandedFrom]; ok {
+			parent = trace.ContextWithSpanContext(parent, sc)
+		}
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("meow.step.id", step.ID),
+		attribute.String("meow.step.executor", string(step.Executor)),
+	}
+	if step.Agent != nil && step.Agent.Agent != "" {
+		attrs = append(attrs, attribute.String("meow.agent.id", step.Agent.Agent))
+	}
+	_, span := t.tracer.Start(parent, "step "+step.ID, trace.WithAttributes(attrs...))
+	t.steps[key] = span
+	t.stepContexts[key] = span.SpanContext()
+	return span
+}
+
+// stepStarted opens the span for a dispatched step.
+func (t *runTracer) stepStarted(wf *types.Run, step *types.Step) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stepSpan(wf, step)
+}
+
+// stepFinished ends the span for a step that reached done or failed.
+func (t *runTracer) stepFinished(wfID string, step *types.Step) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := wfID + ":" + step.ID
+	span, ok := t.steps[key]
+	if !ok {
+		return
+	}
+	delete(t.steps, key)
+
+	span.SetAttributes(attribute.String("meow.step.status", string(step.Status)))
+	if step.Status == types.StepStatusFailed {
+		msg := "step failed"
+		if step.Error != nil {
+			msg = step.Error.Message
+		}
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
+
+// startAgentSpan starts a sub-span of step for an interaction with its agent.
+// The caller must end the returned span.
+func (t *runTracer) startAgentSpan(wf *types.Run, step *types.Step, name string) trace.Span {
+	t.mu.Lock()
+	parent := trace.ContextWithSpan(context.Background(), t.stepSpan(wf, step))
+	t.mu.Unlock()
+
+	_, span := t.tracer.Start(parent, name)
+	if step.Agent != nil {
+		span.SetAttributes(attribute.String("meow.agent.id", step.Agent.Agent))
+	}
+	return span
+}
+
+// endSpan ends span, marking it as an error if err is non-nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// stepEvent records a point-in-time event on a step's open span.
+func (t *runTracer) stepEvent(wfID, stepID, name string, attrs ...attribute.KeyValue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if span, ok := t.steps[wfID+":"+stepID]; ok {
+		span.AddEvent(name, trace.WithAttributes(attrs...))
+	}
+}
+
+// runFinished ends wf's root span, along with any step spans still open.
+func (t *runTracer) runFinished(wf *types.Run) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span, ok := t.runs[wf.ID]
+	if !ok {
+		return
+	}
+	t.endStepSpans(wf.ID)
+	delete(t.runs, wf.ID)
+
+	span.SetAttributes(attribute.String("meow.run.status", string(wf.Status)))
+	if wf.Status == types.RunStatusFailed {
+		msg := "run failed"
+		if wf.Error != "" {
+			msg = wf.Error
+		}
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
+
+// close ends every open span. Called when the orchestrator stops, so runs
+// interrupted mid-flight still export what they recorded.
+func (t *runTracer) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, wfID := range sortedKeys(t.runs) {
+		t.endStepSpans(wfID)
+		t.runs[wfID].End()
+		delete(t.runs, wfID)
+	}
+}
+
+// endStepSpans ends the open step spans of a run. Must be called with t.mu held.
+func (t *runTracer) endStepSpans(wfID string) {
+	prefix := wfID + ":"
+	for key, span := range t.steps {
+		if strings.HasPrefix(key, prefix) {
+			span.End()
+			delete(t.steps, key)
+		}
+	}
+	for key := range t.stepContexts {
+		if strings.HasPrefix(key, prefix) {
+			delete(t.stepContexts, key)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/types"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// childStepExpander expands every template into a single shell step.
+type childStepExpander struct{}
+
+func (childStepExpander) Expand(ctx context.Context, wf *types.Run, step *types.Step) error {
+	id := step.ID + ".build"
+	wf.Steps[id] = &types.Step{
+		ID:           id,
+		Executor:     types.ExecutorShell,
+		Status:       types.StepStatusPending,
+		Shell:        &types.ShellConfig{Command: "make"},
+		ExpandedFrom: step.ID,
+	}
+	return nil
+}
+
+func TestOrchestrator_TracingSpanTree(t *testing.T) {
+	ctx := context.Background()
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["setup"] = &types.Step{
+		ID:       "setup",
+		Executor: types.ExecutorExpand,
+		Status:   types.StepStatusPending,
+		Expand:   &types.ExpandConfig{Template: "build"},
+	}
+	wf.Steps["work"] = &types.Step{
+		ID:       "work",
+		Executor: types.ExecutorAgent,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"setup"},
+		Agent:    &types.AgentConfig{Agent: "worker", Prompt: "Do work"},
+	}
+	wf.Steps["check"] = &types.Step{
+		ID:       "check",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"work"},
+		Shell:    &types.ShellConfig{Command: "exit 1"},
+	}
+	store.workflows[wf.ID] = wf
+
+	shell := newMockShellRunner()
+	shell.results["exit 1"] = map[string]any{"exit_code": 1}
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	orch := New(testConfig(), store, newMockAgentManager(), shell, childStepExpander{}, testLogger())
+	orch.SetTracerProvider(tp)
+
+	for i := 0; i < 20 && !wf.Status.IsTerminal(); i++ {
+		if err := orch.processWorkflow(ctx, wf); err != nil {
+			t.Fatalf("processWorkflow error = %v", err)
+		}
+		orch.wg.Wait()
+		if wf.Steps["work"].Status == types.StepStatusRunning {
+			if err := orch.HandleStepDone(ctx, &ipc.StepDoneMessage{Workflow: wf.ID, Agent: "worker", Step: "work"}); err != nil {
+				t.Fatalf("HandleStepDone error = %v", err)
+			}
+		}
+	}
+	if wf.Status != types.RunStatusFailed {
+		t.Fatalf("run status = %v, want failed", wf.Status)
+	}
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub)
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	if len(byName) != len(spans) {
+		t.Fatalf("got duplicate span names: %v", spanNames(spans))
+	}
+
+	root, ok := byName["run test-template"]
+	if !ok {
+		t.Fatalf("no root span in %v", spanNames(spans))
+	}
+	if root.Parent.IsValid() {
+		t.Errorf("root span has parent %v", root.Parent)
+	}
+	if root.Status.Code != codes.Error {
+		t.Errorf("root span status = %v, want error", root.Status.Code)
+	}
+
+	wantParents := map[string]string{
+		"step setup":          "run test-template",
+		"step setup.build":    "step setup",
+		"step work":           "run test-template",
+		"agent.inject_prompt": "step work",
+		"step check":          "run test-template",
+	}
+	if len(spans) != len(wantParents)+1 {
+		t.Errorf("spans = %v, want %d", spanNames(spans), len(wantParents)+1)
+	}
+	for name, parentName := range wantParents {
+		span, ok := byName[name]
+		if !ok {
+			t.Errorf("missing span %q", name)
+			continue
+		}
+		parent := byName[parentName]
+		if span.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("span %q parent = %v, want %q", name, span.Parent.SpanID(), parentName)
+		}
+		if span.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("span %q is not in the run's trace", name)
+		}
+	}
+
+	if got := byName["step check"].Status.Code; got != codes.Error {
+		t.Errorf("failed step span status = %v, want error", got)
+	}
+	if got := byName["step work"].Status.Code; got == codes.Error {
+		t.Errorf("done step span status = %v, want unset", got)
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	return names
+}
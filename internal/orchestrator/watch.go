@@ -0,0 +1,250 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// DefaultWatchInterval is how often watch mode polls step inputs for changes.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// Watch runs the workflow like Run, then watches the input files declared by
+// steps (inputs = [...]). When an input changes, the steps that declare it and
+// everything downstream of them are reset and the workflow runs again; all
+// other steps keep their cached outputs. Relative input globs are resolved
+// against baseDir. Watch returns when ctx is cancelled or on SIGINT/SIGTERM.
+func (o *Orchestrator) Watch(ctx context.Context, baseDir string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	o.inputsDir = baseDir
+	if err := o.acquireLock(); err != nil {
+		return err
+	}
+	defer o.releaseLock()
+
+	if err := o.validateAdapters(ctx); err != nil {
+		return err
+	}
+	if err := o.checkRequirements(ctx); err != nil {
+		return err
+	}
+	if o.workflowID != "" {
+		o.routeLifecycleEvent(EventWorkflowStarted, o.workflowID, nil)
+	}
+
+	for {
+		wf, err := o.store.Get(ctx, o.workflowID)
+		if err != nil {
+			return fmt.Errorf("getting workflow: %w", err)
+		}
+		// Fingerprint before running so edits made during the run are picked up
+		snapshot := snapshotInputs(baseDir, wf)
+
+		if err := o.runLoop(ctx); err != nil {
+			return err
+		}
+
+		wf, err = o.store.Get(ctx, o.workflowID)
+		if err != nil {
+			return fmt.Errorf("getting workflow: %w", err)
+		}
+		if wf.Status == types.RunStatusStopped {
+			return nil
+		}
+
+		changed, err := o.waitForInputChange(ctx, baseDir, snapshot, interval)
+		if err != nil || changed == nil {
+			return err
+		}
+
+		rerun, err := o.rerunChangedSteps(ctx, changed)
+		if err != nil {
+			return err
+		}
+		o.logger.Info("inputs changed, re-running steps", "workflow", o.workflowID, "steps", rerun)
+	}
+}
+
+// waitForInputChange polls input fingerprints until one differs from snapshot.
+// Returns the changed globs, or nil when ctx is cancelled or a signal arrives.
+func (o *Orchestrator) waitForInputChange(ctx context.Context, baseDir string, snapshot map[string]string, interval time.Duration) (map[string]bool, error) {
+	sigChan := o.setupSignalHandler()
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	o.logger.Info("watching inputs for changes", "workflow", o.workflowID, "inputs", len(snapshot))
+
+	for {
+		select {
+		case sig := <-sigChan:
+			o.logger.Info("received signal, stopping watch", "signal", sig)
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+			wf, err := o.store.Get(ctx, o.workflowID)
+			if err != nil {
+				return nil, fmt.Errorf("getting workflow: %w", err)
+			}
+			// Steps created by expansion during the run have no baseline yet
+			current := snapshotInputs(baseDir, wf)
+			for glob, fp := range current {
+				if _, ok := snapshot[glob]; !ok {
+					snapshot[glob] = fp
+				}
+			}
+
+			changed := make(map[string]bool)
+			for glob, fp := range current {
+				if snapshot[glob] != fp {
+					changed[glob] = true
+				}
+			}
+			if len(changed) > 0 {
+				return changed, nil
+			}
+		}
+	}
+}
+
+// rerunChangedSteps resets the dirty subgraph for the changed input globs and
+// reopens the workflow. Returns the IDs of the steps that will run again.
+func (o *Orchestrator) rerunChangedSteps(ctx context.Context, changed map[string]bool) ([]string, error) {
+	o.wfMu.Lock()
+	defer o.wfMu.Unlock()
+
+	wf, err := o.store.Get(ctx, o.workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("getting workflow: %w", err)
+	}
+
+	dirty := dirtySteps(wf, changed)
+	var rerun []string
+	for id := range dirty {
+		step := wf.Steps[id]
+		// Children of a dirty expansion are recreated when the parent runs again
+		if step.ExpandedFrom != "" && dirty[step.ExpandedFrom] {
+			delete(wf.Steps, id)
+			continue
+		}
+		if err := step.Rerun(); err != nil {
+			return nil, fmt.Errorf("resetting step %s: %w", id, err)
+		}
+		rerun = append(rerun, id)
+	}
+	sort.Strings(rerun)
+
+	wf.Reopen()
+	if err := o.store.Save(ctx, wf); err != nil {
+		return nil, fmt.Errorf("saving workflow: %w", err)
+	}
+	return rerun, nil
+}
+
+// dirtySteps computes the steps that must run again after the given input
+// globs changed: steps declaring a changed input, steps that did not finish
+// successfully last time, and everything that transitively depends on them.
+// Agents are killed when a run finishes, so re-running any step for an agent
+// also re-runs that agent's spawn and kill steps.
+func dirtySteps(wf *types.Run, changed map[string]bool) map[string]bool {
+	dirty := make(map[string]bool)
+	for id, step := range wf.Steps {
+		if step.Status != types.StepStatusDone {
+			dirty[id] = true
+			continue
+		}
+		for _, input := range step.Inputs {
+			if changed[input] {
+				dirty[id] = true
+				break
+			}
+		}
+	}
+	addDependents(wf, dirty)
+	return dirty
+}
+
+// addDependents grows dirty with every step that transitively depends on a
+// dirty step, and with the spawn and kill steps of agents that dirty steps run on.
+func addDependents(wf *types.Run, dirty map[string]bool) {
+	for grew := true; grew; {
+		grew = false
+		agents := make(map[string]bool)
+		for id := range dirty {
+			if step := wf.Steps[id]; step.Agent != nil {
+				agents[step.Agent.Agent] = true
+			}
+		}
+
+		for id, step := range wf.Steps {
+			if dirty[id] {
+				continue
+			}
+			isDirty := dirty[step.ExpandedFrom] ||
+				(step.Spawn != nil && agents[step.Spawn.Agent]) ||
+				(step.Kill != nil && agents[step.Kill.Agent])
+			for _, need := range step.Needs {
+				if dirty[need] {
+					isDirty = true
+				}
+			}
+			if isDirty {
+				dirty[id] = true
+				grew = true
+			}
+		}
+	}
+}
+
+// snapshotInputs fingerprints every input glob declared by the workflow's steps.
+func snapshotInputs(baseDir string, wf *types.Run) map[string]string {
+	snapshot := make(map[string]string)
+	for _, step := range wf.Steps {
+		for _, input := range step.Inputs {
+			if _, ok := snapshot[input]; !ok {
+				snapshot[input] = fingerprintGlob(baseDir, input)
+			}
+		}
+	}
+	return snapshot
+}
+
+// fingerprintGlob hashes the names and contents of all files matching glob.
+// Content hashing means touching a file without changing it keeps the cache.
+func fingerprintGlob(baseDir, glob string) string {
+	pattern := glob
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+	matches, _ := filepath.Glob(pattern)
+	sort.Strings(matches)
+
+	h := sha256.New()
+	for _, path := range matches {
+		io.WriteString(h, path)
+		h.Write([]byte{0})
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		if info, err := f.Stat(); err == nil && !info.IsDir() {
+			io.Copy(h, f)
+		}
+		f.Close()
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
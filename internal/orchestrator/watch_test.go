@@ -0,0 +1,149 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+// countLines returns the number of lines in path (0 if missing).
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	return len(strings.Split(strings.TrimSpace(string(data)), "\n"))
+}
+
+func waitForLines(t *testing.T, path string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for countLines(t, path) < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s has %d lines, want %d", filepath.Base(path), countLines(t, path), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOrchestrator_Watch_RerunsOnlyDependentSteps(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMockRunStore()
+	wf := types.NewRun("test-wf", "test-template", nil)
+	wf.Status = types.RunStatusRunning
+	wf.Steps["build-a"] = &types.Step{
+		ID:       "build-a",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Inputs:   []string{"a.txt"},
+		Shell:    &types.ShellConfig{Command: "cd " + dir + " && cat a.txt >> a.log"},
+	}
+	wf.Steps["use-a"] = &types.Step{
+		ID:       "use-a",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Needs:    []string{"build-a"},
+		Shell:    &types.ShellConfig{Command: "cd " + dir + " && echo run >> use-a.log"},
+	}
+	wf.Steps["build-c"] = &types.Step{
+		ID:       "build-c",
+		Executor: types.ExecutorShell,
+		Status:   types.StepStatusPending,
+		Inputs:   []string{"c.txt"},
+		Shell: &types.ShellConfig{
+			Command: "cd " + dir + " && echo run >> c.log && echo cached-value",
+			Outputs: map[string]types.OutputSource{"value": {Source: "stdout"}},
+		},
+	}
+	store.workflows[wf.ID] = wf
+
+	orch := New(testConfig(), store, newMockAgentManager(), newMockShellRunner(), &mockTemplateExpander{}, testLogger())
+	orch.SetWorkflowID(wf.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- orch.Watch(ctx, dir, 20*time.Millisecond) }()
+
+	// Initial run executes everything once
+	waitForLines(t, filepath.Join(dir, "use-a.log"), 1)
+	waitForLines(t, filepath.Join(dir, "c.log"), 1)
+
+	// Change a.txt: build-a and its dependent use-a re-run, build-c stays cached
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForLines(t, filepath.Join(dir, "use-a.log"), 2)
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+
+	if got := countLines(t, filepath.Join(dir, "a.log")); got != 2 {
+		t.Errorf("build-a ran %d times, want 2", got)
+	}
+	if got := countLines(t, filepath.Join(dir, "use-a.log")); got != 2 {
+		t.Errorf("use-a ran %d times, want 2", got)
+	}
+	if got := countLines(t, filepath.Join(dir, "c.log")); got != 1 {
+		t.Errorf("build-c ran %d times, want 1 (cached)", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.log")); string(data) != "v1\nv2\n" {
+		t.Errorf("a.log = %q, want both input versions", data)
+	}
+
+	final, _ := store.Get(context.Background(), wf.ID)
+	if final.Status != types.RunStatusDone {
+		t.Errorf("workflow status = %s, want done", final.Status)
+	}
+	if v := final.Steps["build-c"].Outputs["value"]; v != "cached-value" {
+		t.Errorf("build-c cached output = %v, want cached-value", v)
+	}
+}
+
+func TestDirtySteps(t *testing.T) {
+	done := func(id string, s *types.Step) *types.Step {
+		s.ID = id
+		s.Status = types.StepStatusDone
+		return s
+	}
+	wf := types.NewRun("test-wf", "test-template", nil)
+	for _, s := range []*types.Step{
+		done("spawn", &types.Step{Executor: types.ExecutorSpawn, Spawn: &types.SpawnConfig{Agent: "worker"}}),
+		done("gen", &types.Step{Executor: types.ExecutorShell, Inputs: []string{"spec.md"}, Shell: &types.ShellConfig{}}),
+		done("impl", &types.Step{Executor: types.ExecutorExpand, Needs: []string{"gen"}, Expand: &types.ExpandConfig{}}),
+		done("impl.work", &types.Step{Executor: types.ExecutorAgent, ExpandedFrom: "impl", Needs: []string{"spawn"}, Agent: &types.AgentConfig{Agent: "worker"}}),
+		done("kill", &types.Step{Executor: types.ExecutorKill, Needs: []string{"impl"}, Kill: &types.KillConfig{Agent: "worker"}}),
+		done("lint", &types.Step{Executor: types.ExecutorShell, Inputs: []string{"*.go"}, Shell: &types.ShellConfig{}}),
+	} {
+		wf.Steps[s.ID] = s
+	}
+
+	dirty := dirtySteps(wf, map[string]bool{"spec.md": true})
+
+	for _, id := range []string{"gen", "impl", "impl.work", "spawn", "kill"} {
+		if !dirty[id] {
+			t.Errorf("step %s should be dirty", id)
+		}
+	}
+	if dirty["lint"] {
+		t.Error("step lint should stay cached")
+	}
+}
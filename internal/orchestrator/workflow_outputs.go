@@ -0,0 +1,58 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akatz-ai/meow/internal/types"
+	"github.com/akatz-ai/meow/internal/workflow"
+)
+
+// resolveWorkflowOutputs evaluates the run's declared workflow outputs against
+// its step outputs and stores the results in wf.Outputs. Outputs that resolve
+// are kept even when others fail; the error names every output that was not
+// produced.
+func resolveWorkflowOutputs(wf *types.Run) error {
+	if len(wf.OutputDefs) == 0 {
+		return nil
+	}
+
+	vc := workflow.NewVarContext()
+	for k, v := range wf.Variables {
+		vc.Set(k, v)
+	}
+	vc.SetBuiltin("workflow_id", wf.ID)
+	vc.SetStepLookup(func(stepID string) (*workflow.StepInfo, error) {
+		s, ok := wf.GetStep(stepID)
+		if !ok {
+			return nil, nil // Not found
+		}
+		return &workflow.StepInfo{
+			ID:      s.ID,
+			Status:  string(s.Status),
+			Outputs: s.Outputs,
+		}, nil
+	})
+
+	outputs := make(map[string]any, len(wf.OutputDefs))
+	var missing []string
+	for _, name := range sortedKeys(wf.OutputDefs) {
+		source := wf.OutputDefs[name]
+		value, err := vc.Eval(source)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("%s (%s): %v", name, source, err))
+			continue
+		}
+		if value == nil {
+			missing = append(missing, fmt.Sprintf("%s (%s): no value", name, source))
+			continue
+		}
+		outputs[name] = value
+	}
+	wf.Outputs = outputs
+
+	if len(missing) > 0 {
+		return fmt.Errorf("workflow outputs not produced: %s", strings.Join(missing, "; "))
+	}
+	return nil
+}
@@ -34,8 +34,10 @@ func (l *WorkflowLock) Release() error {
 	return err
 }
 
-// YAMLRunStore persists runs as YAML files with atomic writes.
-// Multiple stores can be created for the same directory - locking is per-run.
+// YAMLRunStore persists runs as YAML files with atomic writes. Each save keeps
+// the previous state as <id>.yaml.bak, which Get falls back to if the main file
+// is unreadable. Multiple stores can be created for the same directory -
+// locking is per-run.
 type YAMLRunStore struct {
 	dir string // .meow/runs
 }
@@ -58,6 +60,8 @@ func NewYAMLRunStore(dir string) (*YAMLRunStore, error) {
 // AcquireWorkflowLock acquires an exclusive lock for a specific workflow.
 // This prevents multiple orchestrators from running the same workflow concurrently.
 // Other workflows are not affected and can run in parallel.
+// The lock is released automatically if the holding process dies, so a crashed
+// orchestrator never blocks a later resume. Returns ErrAlreadyRunning if held.
 func (s *YAMLRunStore) AcquireWorkflowLock(workflowID string) (*WorkflowLock, error) {
 	lockPath := filepath.Join(s.dir, workflowID+".yaml.lock")
 	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
@@ -67,7 +71,7 @@ func (s *YAMLRunStore) AcquireWorkflowLock(workflowID string) (*WorkflowLock, er
 
 	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
 		lockFile.Close()
-		return nil, fmt.Errorf("workflow %s is already being orchestrated (lock held): %w", workflowID, err)
+		return nil, fmt.Errorf("%w: %s is locked by another orchestrator (%v)", ErrAlreadyRunning, workflowID, err)
 	}
 
 	return &WorkflowLock{
@@ -141,14 +145,29 @@ func (s *YAMLRunStore) Create(ctx context.Context, wf *types.Run) error {
 	return s.Save(ctx, wf)
 }
 
-// Get retrieves a workflow by ID.
+// Get retrieves a workflow by ID. If the state file is missing, empty, or
+// unparsable (e.g., truncated by a crash), the backup of the previous save is
+// returned instead.
 func (s *YAMLRunStore) Get(ctx context.Context, id string) (*types.Run, error) {
 	path := filepath.Join(s.dir, id+".yaml")
+	wf, err := readRunFile(path, id)
+	if err == nil {
+		return wf, nil
+	}
+	if backup, bakErr := readRunFile(path+".bak", id); bakErr == nil {
+		return backup, nil
+	}
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("workflow not found: %s", id)
+	}
+	return nil, err
+}
+
+// readRunFile reads and parses one state file. A file that parses to a run
+// without an ID (such as an empty file) is treated as corrupt.
+func readRunFile(path, id string) (*types.Run, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("workflow not found: %s", id)
-		}
 		return nil, err
 	}
 
@@ -156,10 +175,16 @@ func (s *YAMLRunStore) Get(ctx context.Context, id string) (*types.Run, error) {
 	if err := yaml.Unmarshal(data, &wf); err != nil {
 		return nil, fmt.Errorf("parsing workflow %s: %w", id, err)
 	}
+	if wf.ID == "" {
+		return nil, fmt.Errorf("parsing workflow %s: state file is empty or truncated", id)
+	}
 	return &wf, nil
 }
 
-// Save persists workflow state atomically (write-then-rename).
+// Save persists workflow state atomically: the new state is written and synced
+// to a temp file, the current state is linked to .bak, and the temp file is
+// renamed into place. The main file exists throughout, so concurrent readers
+// never miss the run.
 func (s *YAMLRunStore) Save(ctx context.Context, wf *types.Run) error {
 	data, err := yaml.Marshal(wf)
 	if err != nil {
@@ -169,11 +194,19 @@ func (s *YAMLRunStore) Save(ctx context.Context, wf *types.Run) error {
 	mainPath := filepath.Join(s.dir, wf.ID+".yaml")
 	tmpPath := mainPath + ".tmp"
 
-	// Write to temp file
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+	// Write to temp file, synced so a crash cannot leave it half-written
+	// once it is renamed over the main file
+	if err := writeFileSync(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("writing temp file: %w", err)
 	}
 
+	// Keep the previous state as the backup
+	if err := backupStateFile(mainPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("backing up state: %w", err)
+	}
+
 	// Atomic rename
 	if err := os.Rename(tmpPath, mainPath); err != nil {
 		os.Remove(tmpPath) // Clean up on failure
@@ -183,7 +216,46 @@ func (s *YAMLRunStore) Save(ctx context.Context, wf *types.Run) error {
 	return nil
 }
 
-// Delete removes a workflow.
+// backupStateFile points <path>.bak at the current state file without moving
+// it: a hard link, or a copy where links are unsupported. A missing state file
+// (first save) leaves no backup.
+func backupStateFile(path string) error {
+	bakPath := path + ".bak"
+	if err := os.Remove(bakPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Link(path, bakPath)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return writeFileSync(bakPath, data)
+}
+
+// writeFileSync writes data to path and syncs it to disk.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Delete removes a workflow and its backup.
 func (s *YAMLRunStore) Delete(ctx context.Context, id string) error {
 	path := filepath.Join(s.dir, id+".yaml")
 	if err := os.Remove(path); err != nil {
@@ -192,6 +264,7 @@ func (s *YAMLRunStore) Delete(ctx context.Context, id string) error {
 		}
 		return err
 	}
+	os.Remove(path + ".bak")
 	return nil
 }
 
@@ -216,7 +289,7 @@ func (s *YAMLRunStore) List(ctx context.Context, filter RunFilter) ([]*types.Run
 			continue // Skip invalid files
 		}
 
-		if filter.Status != "" && wf.Status != filter.Status {
+		if !filter.Matches(wf) {
 			continue
 		}
 		workflows = append(workflows, wf)
@@ -243,5 +316,10 @@ func (s *YAMLRunStore) GetByAgent(ctx context.Context, agentID string) ([]*types
 	return result, nil
 }
 
+// ListByTemplate returns workflows started from the named template.
+func (s *YAMLRunStore) ListByTemplate(ctx context.Context, template string) ([]*types.Run, error) {
+	return s.List(ctx, RunFilter{Template: template})
+}
+
 // Ensure YAMLRunStore implements RunStore
 var _ RunStore = (*YAMLRunStore)(nil)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/akatz-ai/meow/internal/types"
@@ -170,6 +171,22 @@ func TestYAMLRunStore(t *testing.T) {
 			t.Errorf("wrong workflow: %s", results[0].ID)
 		}
 	})
+
+	t.Run("ListByTemplate", func(t *testing.T) {
+		store5, _ := NewYAMLRunStore(t.TempDir())
+		defer store5.Close()
+
+		store5.Create(ctx, types.NewRun("run-deploy", ".meow/workflows/deploy.toml", nil))
+		store5.Create(ctx, types.NewRun("run-build", ".meow/workflows/build.toml", nil))
+
+		results, err := store5.ListByTemplate(ctx, "deploy.toml")
+		if err != nil {
+			t.Fatalf("ListByTemplate failed: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != "run-deploy" {
+			t.Errorf("expected [run-deploy], got %d workflows", len(results))
+		}
+	})
 }
 
 func TestYAMLRunStoreLocking(t *testing.T) {
@@ -382,3 +399,138 @@ func TestYAMLRunStoreAtomicWrite(t *testing.T) {
 		t.Error("main file should exist")
 	}
 }
+
+func TestYAMLRunStoreCorruptStateFallsBackToBackup(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		corrupt []byte
+	}{
+		{name: "truncated mid-document", corrupt: []byte("id: run-corrupt\nsteps:\n  build: {id: build, status: \"do")},
+		{name: "empty file", corrupt: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := NewYAMLRunStore(dir)
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+
+			wf := types.NewRun("run-corrupt", "test.meow.toml", nil)
+			if err := store.Create(ctx, wf); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			wf.Status = types.RunStatusRunning
+			if err := store.Save(ctx, wf); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			// The first save is now the backup
+			backup, err := os.ReadFile(filepath.Join(dir, "run-corrupt.yaml.bak"))
+			if err != nil {
+				t.Fatalf("backup missing after save: %v", err)
+			}
+			if !strings.Contains(string(backup), "status: pending") {
+				t.Errorf("backup should hold the previous save:\n%s", backup)
+			}
+
+			// Simulate a crash that left the main file damaged
+			if err := os.WriteFile(filepath.Join(dir, "run-corrupt.yaml"), tt.corrupt, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// A fresh store (as after a restart) recovers from the backup
+			store, err = NewYAMLRunStore(dir)
+			if err != nil {
+				t.Fatalf("failed to reopen store: %v", err)
+			}
+			got, err := store.Get(ctx, "run-corrupt")
+			if err != nil {
+				t.Fatalf("Get should fall back to the backup: %v", err)
+			}
+			if got.ID != "run-corrupt" || got.Status != types.RunStatusPending {
+				t.Errorf("recovered run = %s/%s, want run-corrupt/pending", got.ID, got.Status)
+			}
+
+			// Saving again replaces the damaged file
+			got.Status = types.RunStatusRunning
+			if err := store.Save(ctx, got); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			if _, err := readRunFile(filepath.Join(dir, "run-corrupt.yaml"), "run-corrupt"); err != nil {
+				t.Errorf("main file should be valid after saving: %v", err)
+			}
+		})
+	}
+}
+
+func TestYAMLRunStoreListDuringSave(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewYAMLRunStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	wf := types.NewRun("run-busy", "test.meow.toml", nil)
+	if err := store.Create(ctx, wf); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			if err := store.Save(ctx, wf); err != nil {
+				t.Errorf("Save failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if _, err := os.Stat(filepath.Join(dir, "run-busy.yaml")); err != nil {
+			t.Fatalf("state file missing during save: %v", err)
+		}
+		runs, err := store.List(ctx, RunFilter{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(runs) != 1 {
+			t.Fatalf("List returned %d runs during save, want 1", len(runs))
+		}
+	}
+}
+
+func TestYAMLRunStoreDeleteRemovesBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewYAMLRunStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	wf := types.NewRun("run-delete", "test.meow.toml", nil)
+	if err := store.Create(ctx, wf); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := store.Save(ctx, wf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.Delete(ctx, wf.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run-delete.yaml.bak")); !os.IsNotExist(err) {
+		t.Error("backup should be removed with the run")
+	}
+	if _, err := store.Get(ctx, wf.ID); err == nil {
+		t.Error("Get should fail after Delete")
+	}
+}
@@ -30,6 +30,12 @@ func FormatDetailedWorkflow(summary *WorkflowSummary, opts FormatOptions) string
 	b.WriteString(formatProgress(summary, opts))
 	b.WriteString("\n\n")
 
+	// Workflow outputs
+	if len(summary.Outputs) > 0 {
+		b.WriteString(formatOutputs(summary))
+		b.WriteString("\n\n")
+	}
+
 	// Running steps
 	if len(summary.RunningSteps) > 0 {
 		b.WriteString(formatRunningSteps(summary, opts))
@@ -42,6 +48,12 @@ func FormatDetailedWorkflow(summary *WorkflowSummary, opts FormatOptions) string
 		b.WriteString("\n\n")
 	}
 
+	// Skipped steps
+	if len(summary.SkippedSteps) > 0 {
+		b.WriteString(formatSkippedSteps(summary, opts))
+		b.WriteString("\n")
+	}
+
 	// Errors
 	if len(summary.Errors) > 0 {
 		b.WriteString(formatErrors(summary, opts))
@@ -191,6 +203,22 @@ func formatRunningSteps(summary *WorkflowSummary, opts FormatOptions) string {
 	return b.String()
 }
 
+func formatOutputs(summary *WorkflowSummary) string {
+	var b strings.Builder
+
+	b.WriteString("Outputs:")
+	names := make([]string, 0, len(summary.Outputs))
+	for name := range summary.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("\n  %s = %s", name, workflow.StringifyValue(summary.Outputs[name])))
+	}
+
+	return b.String()
+}
+
 func formatAgents(summary *WorkflowSummary, opts FormatOptions) string {
 	var b strings.Builder
 
@@ -228,6 +256,20 @@ func formatAgents(summary *WorkflowSummary, opts FormatOptions) string {
 	return b.String()
 }
 
+func formatSkippedSteps(summary *WorkflowSummary, opts FormatOptions) string {
+	var b strings.Builder
+
+	gray := getColor("gray", opts.NoColor)
+	reset := resetColor(opts.NoColor)
+
+	b.WriteString("Skipped:\n")
+	for _, ss := range summary.SkippedSteps {
+		b.WriteString(fmt.Sprintf("  %s⊘%s %s: %s\n", gray, reset, ss.ID, ss.Message))
+	}
+
+	return b.String()
+}
+
 func formatErrors(summary *WorkflowSummary, opts FormatOptions) string {
 	var b strings.Builder
 
@@ -235,13 +277,54 @@ func formatErrors(summary *WorkflowSummary, opts FormatOptions) string {
 	reset := resetColor(opts.NoColor)
 
 	b.WriteString(fmt.Sprintf("%sErrors:%s\n", errColor, reset))
-	for _, err := range summary.Errors {
-		b.WriteString(fmt.Sprintf("  %s✗%s %s\n", errColor, reset, err))
+	if len(summary.FailedSteps) == 0 {
+		for _, err := range summary.Errors {
+			b.WriteString(fmt.Sprintf("  %s✗%s %s\n", errColor, reset, err))
+		}
+		return b.String()
+	}
+
+	for _, fs := range summary.FailedSteps {
+		line := fmt.Sprintf("%s: %s", fs.ID, fs.Message)
+		if fs.ExitCode != nil {
+			line += fmt.Sprintf(" (exit %d)", *fs.ExitCode)
+		}
+		b.WriteString(fmt.Sprintf("  %s✗%s %s\n", errColor, reset, line))
+		if fs.Command != "" {
+			b.WriteString(fmt.Sprintf("      $ %s\n", firstLine(fs.Command)))
+		}
+		for _, l := range lastLines(fs.Stderr, errorTailLines) {
+			b.WriteString(fmt.Sprintf("      %s\n", l))
+		}
 	}
 
 	return b.String()
 }
 
+// errorTailLines is how many trailing stderr lines are shown per failed step.
+const errorTailLines = 5
+
+// firstLine returns the first line of s, marking multi-line strings with "...".
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
+
+// lastLines returns up to n trailing non-empty lines of s.
+func lastLines(s string, n int) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
 func formatWorkflowListItem(summary *WorkflowSummary, opts FormatOptions) string {
 	var b strings.Builder
 
@@ -280,6 +363,8 @@ func getStatusIcon(status types.RunStatus, noColor bool) string {
 	switch status {
 	case types.RunStatusRunning:
 		return "●"
+	case types.RunStatusPaused:
+		return "‖"
 	case types.RunStatusDone:
 		return "✓"
 	case types.RunStatusFailed:
@@ -303,6 +388,8 @@ func getStatusColor(status types.RunStatus, noColor bool) string {
 	switch status {
 	case types.RunStatusRunning:
 		return "\033[33m" // Yellow
+	case types.RunStatusPaused:
+		return "\033[36m" // Cyan
 	case types.RunStatusDone:
 		return "\033[32m" // Green
 	case types.RunStatusFailed:
@@ -156,6 +156,7 @@ func TestGetStatusIcon(t *testing.T) {
 		want   string
 	}{
 		{types.RunStatusRunning, "●"},
+		{types.RunStatusPaused, "‖"},
 		{types.RunStatusDone, "✓"},
 		{types.RunStatusFailed, "✗"},
 		{types.RunStatusStopped, "■"},
@@ -219,3 +220,28 @@ func TestFormatErrors(t *testing.T) {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
+
+func TestFormatErrors_FailedStepDetails(t *testing.T) {
+	exitCode := 2
+	summary := &WorkflowSummary{
+		Errors: []string{"command failed"},
+		FailedSteps: []FailedStep{{
+			ID:       "build",
+			Message:  "command failed",
+			ExitCode: &exitCode,
+			Command:  "make build\nmake test",
+			Stderr:   "line1\nline2\nline3\nline4\nline5\nline6",
+		}},
+	}
+
+	output := formatErrors(summary, FormatOptions{NoColor: true})
+
+	for _, want := range []string{"build: command failed (exit 2)", "$ make build ...", "line6"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "line1") {
+		t.Errorf("output should only show the stderr tail, got:\n%s", output)
+	}
+}
@@ -1,6 +1,7 @@
 package status
 
 import (
+	"sort"
 	"time"
 
 	"github.com/akatz-ai/meow/internal/types"
@@ -14,10 +15,32 @@ type WorkflowSummary struct {
 	StartedAt   time.Time              `json:"started_at"`
 	DoneAt      *time.Time             `json:"done_at,omitempty"`
 	Variables   map[string]any         `json:"variables,omitempty"`
+	Outputs     map[string]any         `json:"outputs,omitempty"`
 	StepStats   StepStats              `json:"step_stats"`
 	RunningSteps []RunningStep         `json:"running_steps,omitempty"`
 	Agents      []AgentSummary         `json:"agents,omitempty"`
 	Errors      []string               `json:"errors,omitempty"`
+	FailedSteps []FailedStep           `json:"failed_steps,omitempty"`
+	SkippedSteps []SkippedStep         `json:"skipped_steps,omitempty"`
+}
+
+// FailedStep contains the structured error details of a failed step.
+type FailedStep struct {
+	ID       string `json:"id"`
+	Message  string `json:"message"`
+	Type     string `json:"type,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+// SkippedStep explains why a skipped step did not run.
+type SkippedStep struct {
+	ID         string `json:"id"`
+	Reason     string `json:"reason,omitempty"` // SkipReason kind; empty for state written before reasons were recorded
+	Dependency string `json:"dependency,omitempty"`
+	Message    string `json:"message"`
 }
 
 // StepStats contains step count breakdown.
@@ -57,6 +80,7 @@ func NewWorkflowSummary(wf *types.Run) *WorkflowSummary {
 		StartedAt: wf.StartedAt,
 		DoneAt:    wf.DoneAt,
 		Variables: wf.Variables,
+		Outputs:   wf.Outputs,
 		StepStats: computeStepStats(wf),
 	}
 
@@ -92,7 +116,43 @@ func NewWorkflowSummary(wf *types.Run) *WorkflowSummary {
 	for _, step := range wf.Steps {
 		if step.Status == types.StepStatusFailed && step.Error != nil {
 			summary.Errors = append(summary.Errors, step.Error.Message)
+			summary.FailedSteps = append(summary.FailedSteps, FailedStep{
+				ID:       step.ID,
+				Message:  step.Error.Message,
+				Type:     string(step.Error.Type),
+				ExitCode: step.Error.ExitCode,
+				Command:  step.Error.Command,
+				Stdout:   step.Error.Stdout,
+				Stderr:   step.Error.Stderr,
+			})
+		}
+	}
+	sort.Slice(summary.FailedSteps, func(i, j int) bool {
+		return summary.FailedSteps[i].ID < summary.FailedSteps[j].ID
+	})
+
+	// Collect reasons for skipped steps
+	for _, step := range wf.Steps {
+		if step.Status != types.StepStatusSkipped {
+			continue
 		}
+		skipped := SkippedStep{ID: step.ID}
+		if step.SkipReason != nil {
+			skipped.Reason = string(step.SkipReason.Kind)
+			skipped.Dependency = step.SkipReason.Dependency
+			skipped.Message = step.SkipReason.Message
+		} else if step.Error != nil {
+			skipped.Message = step.Error.Message
+		}
+		summary.SkippedSteps = append(summary.SkippedSteps, skipped)
+	}
+	sort.Slice(summary.SkippedSteps, func(i, j int) bool {
+		return summary.SkippedSteps[i].ID < summary.SkippedSteps[j].ID
+	})
+
+	// Run-level failure (e.g., a declared workflow output was not produced)
+	if wf.Error != "" {
+		summary.Errors = append(summary.Errors, wf.Error)
 	}
 
 	return summary
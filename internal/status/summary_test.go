@@ -1,6 +1,8 @@
 package status
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -190,3 +192,67 @@ func TestWorkflowSummaryWithErrors(t *testing.T) {
 		t.Errorf("not all errors were collected: %v", summary.Errors)
 	}
 }
+
+func TestWorkflowSummaryWithRunError(t *testing.T) {
+	wf := &types.Run{
+		ID:       "run-test",
+		Template: "test.meow.toml",
+		Status:   types.RunStatusFailed,
+		Outputs:  map[string]any{"version": "1.2.3"},
+		Error:    "workflow outputs not produced: digest",
+		Steps: map[string]*types.Step{
+			"build": {ID: "build", Status: types.StepStatusDone},
+		},
+	}
+
+	summary := NewWorkflowSummary(wf)
+
+	if summary.Outputs["version"] != "1.2.3" {
+		t.Errorf("expected workflow outputs in summary, got %v", summary.Outputs)
+	}
+	if len(summary.Errors) != 1 || summary.Errors[0] != wf.Error {
+		t.Errorf("expected run error in summary, got %v", summary.Errors)
+	}
+
+	output := FormatDetailedWorkflow(summary, FormatOptions{NoColor: true})
+	if !strings.Contains(output, "Outputs:\n  version = 1.2.3") {
+		t.Errorf("output should list workflow outputs:\n%s", output)
+	}
+	if !strings.Contains(output, wf.Error) {
+		t.Errorf("output should contain the run error:\n%s", output)
+	}
+}
+
+func TestWorkflowSummaryWithSkippedSteps(t *testing.T) {
+	wf := &types.Run{
+		ID:       "run-test",
+		Template: "test.meow.toml",
+		Status:   types.RunStatusFailed,
+		Steps: map[string]*types.Step{
+			"check": {ID: "check", Status: types.StepStatusFailed, Error: &types.StepError{Message: "command failed"}},
+			"deploy": {
+				ID:         "deploy",
+				Status:     types.StepStatusSkipped,
+				Error:      &types.StepError{Message: `dependency "check" failed`},
+				SkipReason: &types.SkipReason{Kind: types.SkipReasonDependencyFailed, Dependency: "check", Message: `dependency "check" failed`},
+			},
+			// Skipped before reasons were recorded
+			"legacy": {ID: "legacy", Status: types.StepStatusSkipped, Error: &types.StepError{Message: "dependency \"x\" failed"}},
+		},
+	}
+
+	summary := NewWorkflowSummary(wf)
+
+	want := []SkippedStep{
+		{ID: "deploy", Reason: "dependency_failed", Dependency: "check", Message: `dependency "check" failed`},
+		{ID: "legacy", Message: `dependency "x" failed`},
+	}
+	if !reflect.DeepEqual(summary.SkippedSteps, want) {
+		t.Errorf("skipped steps = %+v, want %+v", summary.SkippedSteps, want)
+	}
+
+	output := FormatDetailedWorkflow(summary, FormatOptions{NoColor: true})
+	if !strings.Contains(output, "Skipped:\n  ⊘ deploy: dependency \"check\" failed") {
+		t.Errorf("output should explain skipped steps:\n%s", output)
+	}
+}
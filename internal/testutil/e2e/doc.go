@@ -10,10 +10,34 @@
 //
 //	cfg := e2e.NewSimConfigBuilder().
 //	    WithBehavior("implement feature", e2e.ActionComplete).
+//	    WithBehaviorRegex(`review PR #\d+`, e2e.ActionComplete).
 //	    WithBehaviorOutputs("count files", map[string]any{"count": 42}).
 //	    WithDelay(10 * time.Millisecond).
 //	    Build()
 //
+// Prompt acknowledgment (the prompt-received event) can be delayed or
+// withheld per behavior, to exercise the orchestrator's prompt recovery:
+//
+//	cfg := e2e.NewSimConfigBuilder().
+//	    WithAckDelay("implement feature", 2*time.Second).
+//	    WithSwallowPrompts("review", 1). // Acknowledged only when re-injected
+//	    Build()
+//
+// A behavior can stream tool events before its final action, for testing how
+// the orchestrator's event router handles them:
+//
+//	cfg := e2e.NewSimConfigBuilder().
+//	    WithToolEventSequence("implement feature", []string{"edit file", "run tests"}, 500*time.Millisecond).
+//	    Build()
+//
+// Failure paths have their own behaviors: WithHangBehavior never answers,
+// WithCrashBehavior exits, and WithBehaviorError reports the step failed
+// through meow done --error:
+//
+//	cfg := e2e.NewSimConfigBuilder().
+//	    WithBehaviorError("implement feature", "tests do not compile").
+//	    Build()
+//
 // # Harness
 //
 // Provides test isolation with:
@@ -26,13 +50,42 @@
 //	h.WriteSimConfig(cfg)
 //	h.WriteTemplate("my-workflow", templateContent)
 //
+// Log records from captured meow commands can be asserted on by field rather
+// than by substring:
+//
+//	dispatches := h.LogsMatching(func(r map[string]any) bool {
+//	    return r["msg"] == "dispatching step" && r["id"] == "step-1"
+//	})
+//
 // # WorkflowRun
 //
 // Helpers for observing and asserting on running workflows:
 //
 //	run, _ := h.RunWorkflow("my-workflow")
 //	err := run.WaitForStep("step-1", "done", 5*time.Second)
+//	err = run.WaitForStepStatus("step-2", types.StepStatusRunning, 5*time.Second)
 //	output, _ := run.StepOutput("step-1", "result")
+//	version, _ := run.StepOutputString("build", "stdout") // Whitespace trimmed
+//	count, _ := run.StepOutputInt("scan", "report.count")  // Nested field
+//	ok, _ := run.StepOutputBool("check", "passed")
+//	outputs, _ := run.StepOutputs("step-1") // Copy of every output
+//	run.DumpSteps()                          // Log each step's status and outputs
+//	event, _ := run.WaitForEvent("prompt-received", 5*time.Second)
+//	event, _ = run.WaitForAgentStopped(5 * time.Second) // Next agent-stopped event
+//
+// # Live Workflows
+//
+// RunWorkflowAsync starts meow run in the background and returns an
+// OrchestratorProcess handle, so a test can act on the workflow while it runs:
+//
+//	proc, _ := h.RunWorkflowAsync(filepath.Join(h.TemplateDir, "my-workflow.toml"))
+//	run, _ := proc.WaitForRun(5 * time.Second) // Once meow run reports the workflow ID
+//	_ = run.WaitForStepStatus("work", types.StepStatusRunning, 5*time.Second)
+//	_ = proc.Signal(os.Interrupt)              // Or Kill() to simulate a crash
+//	err := proc.WaitWithTimeout(10 * time.Second)
+//
+// The handle also offers Wait, IsDone, Stdout, Stderr, PID, and WorkflowID.
+// RestartOrchestrator returns the same handle for meow resume.
 //
 // # Usage Example
 //
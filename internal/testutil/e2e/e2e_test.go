@@ -2,7 +2,9 @@ package e2e_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -79,7 +81,7 @@ func runMeowWithTimeout(h *e2e.Harness, timeout time.Duration, args ...string) (
 
 	var stdout, stderr strings.Builder
 	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stderr = io.MultiWriter(&stderr, h.LogWriter())
 
 	err := cmd.Run()
 	return stdout.String(), stderr.String(), err
@@ -159,6 +161,15 @@ command = "echo 'step 3'"
 	if !strings.Contains(stderr, "workflow completed") {
 		t.Errorf("expected workflow to complete, got:\n%s", stderr)
 	}
+
+	// The steps are dispatched in dependency order
+	var dispatched []any
+	for _, record := range h.LogsMatching(func(r map[string]any) bool { return r["msg"] == "dispatching step" }) {
+		dispatched = append(dispatched, record["id"])
+	}
+	if fmt.Sprint(dispatched) != "[step1 step2 step3]" {
+		t.Errorf("dispatched steps = %v, want [step1 step2 step3]", dispatched)
+	}
 }
 
 // TestE2E_ParallelShellSteps tests concurrent shell steps without dependencies.
@@ -251,6 +262,59 @@ command = "echo 'got: {{produce.outputs.result}}'"
 	}
 }
 
+// TestE2E_ShellOutputArtifacts tests that outputs marked artifact = true are
+// copied into the run's artifacts directory and listed in its manifest.
+func TestE2E_ShellOutputArtifacts(t *testing.T) {
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "shell-artifacts"
+
+[[main.steps]]
+id = "report"
+executor = "shell"
+command = "printf 'report-body\\n' > report.txt && echo 'done'"
+[main.steps.shell_outputs]
+report = { source = "file:report.txt", artifact = true }
+status = { source = "stdout" }
+`
+	if err := h.WriteTemplate("shell-artifacts.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "shell-artifacts.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	manifest, err := run.Artifacts()
+	if err != nil {
+		t.Fatalf("loading artifacts: %v", err)
+	}
+	entry := manifest.Find("report", "report")
+	if entry == nil {
+		t.Fatalf("manifest missing report artifact: %+v", manifest.Artifacts)
+	}
+	if manifest.Find("report", "status") != nil {
+		t.Errorf("unmarked output status should not be in the manifest")
+	}
+
+	data, err := os.ReadFile(filepath.Join(run.ArtifactsDir(), entry.Path))
+	if err != nil {
+		t.Fatalf("reading artifact: %v", err)
+	}
+	if string(data) != "report-body\n" {
+		t.Errorf("artifact content = %q, want %q", data, "report-body\n")
+	}
+}
+
 // ===========================================================================
 // Agent Step Tests (with Simulator)
 // Spec: specs/agent-lifecycle.yaml
@@ -325,6 +389,98 @@ graceful = true
 	}
 }
 
+// TestE2E_KillGracePeriod tests that a kill step reports whether its agent
+// exited within the grace period or had to be forced.
+func TestE2E_KillGracePeriod(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		// The simulator exits on C-c
+		{name: "exits on interrupt", keys: `["C-c"]`, want: "graceful"},
+		{name: "no stop keys", keys: `[]`, want: "forced"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := e2e.NewHarness(t)
+
+			simConfig := e2e.NewSimConfigBuilder().
+				WithDefaultAction(e2e.ActionComplete).
+				WithStartupDelay(50 * time.Millisecond).
+				Build()
+			if err := h.WriteSimConfig(simConfig); err != nil {
+				t.Fatalf("failed to write sim config: %v", err)
+			}
+
+			adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+startup_delay = "200ms"
+
+[environment]
+TMUX = ""
+
+[prompt_injection]
+pre_keys = ["Escape"]
+method = "literal"
+post_keys = ["Enter"]
+
+[graceful_stop]
+keys = ` + tt.keys + "\n"
+			if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+				t.Fatalf("failed to write adapter config: %v", err)
+			}
+
+			template := `
+[main]
+name = "kill-grace"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "test-agent"
+adapter = "simulator"
+
+[[main.steps]]
+id = "work"
+executor = "agent"
+agent = "test-agent"
+needs = ["spawn-agent"]
+prompt = "Do something simple"
+
+[[main.steps]]
+id = "kill-agent"
+executor = "kill"
+agent = "test-agent"
+needs = ["work"]
+grace_period = "1s"
+`
+			if err := h.WriteTemplate("kill-grace.toml", template); err != nil {
+				t.Fatalf("failed to write template: %v", err)
+			}
+
+			stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "kill-grace.toml"))
+			if err != nil {
+				t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+			}
+
+			runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+			if len(runFiles) != 1 {
+				t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+			}
+			run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+			if err := run.AssertStepOutputEquals("kill-agent", "stopped", tt.want); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
 // TestE2E_AgentWithOutputs tests agent producing outputs via meow done --output.
 // Spec: basic-lifecycle.agent-with-outputs
 func TestE2E_AgentWithOutputs(t *testing.T) {
@@ -391,6 +547,99 @@ graceful = true
 	}
 }
 
+// TestE2E_AgentTranscriptCapture tests that capture_transcript saves the
+// agent's pane and exposes it as an output later steps can reference.
+func TestE2E_AgentTranscriptCapture(t *testing.T) {
+	h := e2e.NewHarness(t)
+
+	simConfig := e2e.NewSimConfigBuilder().
+		WithDefaultAction(e2e.ActionComplete).
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+`
+	if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+		t.Fatalf("failed to write adapter config: %v", err)
+	}
+
+	template := `
+[main]
+name = "agent-transcript"
+
+[[main.steps]]
+id = "spawn"
+executor = "spawn"
+agent = "worker"
+
+[[main.steps]]
+id = "work"
+executor = "agent"
+agent = "worker"
+needs = ["spawn"]
+prompt = "Do something simple"
+capture_transcript = true
+
+[[main.steps]]
+id = "check"
+executor = "shell"
+needs = ["work"]
+command = "grep -c 'Task completed successfully.' '{{work.outputs.transcript}}'"
+[main.steps.shell_outputs]
+matches = { source = "stdout" }
+
+[[main.steps]]
+id = "kill"
+executor = "kill"
+agent = "worker"
+needs = ["check"]
+graceful = true
+`
+	if err := h.WriteTemplate("agent-transcript.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "agent-transcript.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	transcript, err := run.StepOutput("work", "transcript")
+	if err != nil {
+		t.Fatalf("getting transcript output: %v", err)
+	}
+	path, _ := transcript.(string)
+	if filepath.Dir(path) != filepath.Join(run.ArtifactsDir(), "work") {
+		t.Errorf("transcript path = %q, want it under the step's artifacts", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading transcript: %v", err)
+	}
+	if !strings.Contains(string(data), "Task completed successfully.") {
+		t.Errorf("transcript missing simulator output:\n%s", data)
+	}
+
+	if err := run.AssertStepOutputEquals("check", "matches", "1"); err != nil {
+		t.Error(err)
+	}
+}
+
 // TestE2E_ParallelAgents tests multiple agents working concurrently.
 // Spec: basic-lifecycle.parallel-agents
 func TestE2E_ParallelAgents(t *testing.T) {
@@ -509,8 +758,8 @@ command = "exit 1"
 
 	_, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "shell-fail.toml"))
 
-	// meow run may exit 0 even if workflow fails (it just reports the status)
-	// Check for failure indication in output
+	// Without --strict, meow run may exit 0 even if the workflow fails (it
+	// just reports the status). Check for failure indication in output
 	hasFailure := strings.Contains(stderr, "failed") ||
 		strings.Contains(stderr, "step failed") ||
 		strings.Contains(stderr, "exit code 1") ||
@@ -521,6 +770,41 @@ command = "exit 1"
 	}
 }
 
+// TestE2E_RunStrictExitCode tests that meow run --strict exits non-zero when
+// the workflow fails and zero when it succeeds.
+func TestE2E_RunStrictExitCode(t *testing.T) {
+	h := e2e.NewHarness(t)
+
+	for name, command := range map[string]string{"pass": "true", "fail": "exit 1"} {
+		template := fmt.Sprintf(`
+[main]
+name = "strict-%s"
+
+[[main.steps]]
+id = "step"
+executor = "shell"
+command = %q
+`, name, command)
+		if err := h.WriteTemplate("strict-"+name+".toml", template); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+	}
+
+	stdout, stderr, err := runMeow(h, "run", "--strict", filepath.Join(h.TemplateDir, "strict-pass.toml"))
+	if err != nil {
+		t.Errorf("meow run --strict of a passing workflow failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	_, stderr, err = runMeow(h, "run", "--strict", filepath.Join(h.TemplateDir, "strict-fail.toml"))
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() == 0 {
+		t.Fatalf("meow run --strict of a failing workflow: err = %v, want non-zero exit", err)
+	}
+	if !strings.Contains(stderr, "failed") {
+		t.Errorf("expected the failed status in stderr, got:\n%s", stderr)
+	}
+}
+
 // TestE2E_ShellStepFailureWithOnErrorContinue tests on_error=continue allows workflow to proceed.
 // Spec: error-handling.shell-step-failure-on-error-continue
 func TestE2E_ShellStepFailureWithOnErrorContinue(t *testing.T) {
@@ -1474,6 +1758,33 @@ func TestE2E_SimConfigBuilder_WithBehaviorSequence(t *testing.T) {
 	}
 }
 
+func TestE2E_SimConfigBuilder_WithToolEventSequence(t *testing.T) {
+	config := e2e.NewSimConfigBuilder().
+		WithBehaviorOutputs("implement", map[string]any{"done": true}).
+		WithToolEventSequence("implement", []string{"edit file", "run tests"}, 100*time.Millisecond).
+		Build()
+
+	if !config.Hooks.FireToolEvents {
+		t.Error("expected tool events to be enabled")
+	}
+	if len(config.Behaviors) != 1 {
+		t.Fatalf("expected 1 behavior, got %d", len(config.Behaviors))
+	}
+	events := config.Behaviors[0].Action.Events
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != "edit file" || events[0].When != 100*time.Millisecond {
+		t.Errorf("events[0] = %+v, want edit file at 100ms", events[0])
+	}
+	if events[1].Type != "run tests" || events[1].When != 200*time.Millisecond {
+		t.Errorf("events[1] = %+v, want run tests at 200ms", events[1])
+	}
+	if config.Behaviors[0].Action.Outputs["done"] != true {
+		t.Error("expected the existing behavior's outputs to be kept")
+	}
+}
+
 func TestE2E_AgentSessionControl(t *testing.T) {
 	h := e2e.NewHarness(t)
 
@@ -1637,29 +1948,19 @@ graceful = true
 	// The test verifies timeout detection occurs.
 }
 
-// TestE2E_AgentStepTimeout_OnErrorContinue tests that when an agent step times out
-// with on_error=continue, the workflow continues to subsequent steps.
-//
-// Per MVP-SPEC-v2 (lines 3069-3091):
-//   on_error = "continue" logs the error and continues to the next step
-//
-// Expected behavior:
-//   1. Agent step times out after 3s
-//   2. Step marked failed with error_type: timeout
-//   3. Workflow continues due to on_error=continue
-//   4. Subsequent steps execute normally
-//   5. Workflow completes successfully
-func TestE2E_AgentStepTimeout_OnErrorContinue(t *testing.T) {
+// TestE2E_AgentStall_WarnsBeforeTimeout tests that an agent which goes silent
+// (no events) is reported as stalled after stall_timeout, well before the step's
+// hard timeout interrupts it.
+func TestE2E_AgentStall_WarnsBeforeTimeout(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping E2E test in short mode")
 	}
 
 	h := e2e.NewHarness(t)
 
-	// Simulator hangs on "hang" prompts, completes on "continue" prompts
+	// Simulator goes silent once it receives the prompt
 	simConfig := e2e.NewSimConfigBuilder().
-		WithHangBehavior("hang forever").
-		WithBehavior("after timeout", e2e.ActionComplete).
+		WithHangBehavior("go silent").
 		WithStartupDelay(50 * time.Millisecond).
 		Build()
 
@@ -1673,110 +1974,696 @@ name = "simulator"
 
 [spawn]
 command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
 `
 	if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
 		t.Fatalf("failed to write adapter config: %v", err)
 	}
 
-	// Template with timeout step using on_error=continue and a follow-up shell step
 	template := `
 [main]
-name = "timeout-continue"
+name = "agent-stall"
 
 [[main.steps]]
-id = "spawn-worker"
+id = "spawn-agent"
 executor = "spawn"
-agent = "worker"
+agent = "stall-worker"
 
 [[main.steps]]
-id = "will-timeout"
+id = "silent-step"
 executor = "agent"
-agent = "worker"
-needs = ["spawn-worker"]
-prompt = "This prompt will hang forever"
-timeout = "3s"
+agent = "stall-worker"
+needs = ["spawn-agent"]
+prompt = "Please go silent"
+timeout = "5s"
+stall_timeout = "1s"
 on_error = "continue"
 
 [[main.steps]]
-id = "after-timeout"
-executor = "shell"
-needs = ["will-timeout"]
-command = "echo 'workflow continued after timeout'"
-
-[[main.steps]]
-id = "cleanup"
+id = "kill-agent"
 executor = "kill"
-agent = "worker"
-needs = ["after-timeout"]
-graceful = true
+agent = "stall-worker"
+needs = ["silent-step"]
 `
-	if err := h.WriteTemplate("timeout-continue.toml", template); err != nil {
+	if err := h.WriteTemplate("agent-stall.toml", template); err != nil {
 		t.Fatalf("failed to write template: %v", err)
 	}
 
-	// Use longer timeout to allow for timeout + grace period + continuation
-	stdout, stderr, err := runMeowWithTimeout(h, 45*time.Second, "run", filepath.Join(h.TemplateDir, "timeout-continue.toml"))
-
+	stdout, stderr, err := runMeowWithTimeout(h, 45*time.Second, "run", filepath.Join(h.TemplateDir, "agent-stall.toml"))
 	t.Logf("stdout: %s", stdout)
 	t.Logf("stderr: %s", stderr)
 	if err != nil {
 		t.Logf("err: %v", err)
 	}
 
-	// Verify timeout was detected
-	hasTimeoutDetection := strings.Contains(stderr, "timed out") ||
-		strings.Contains(stderr, "timeout") ||
-		strings.Contains(stderr, "sending interrupt")
-
-	if !hasTimeoutDetection {
-		t.Errorf("expected timeout detection in output")
+	stallAt := strings.Index(stderr, "agent stalled")
+	if stallAt < 0 {
+		t.Fatalf("expected agent stalled warning in output")
 	}
-
-	// With on_error=continue fully implemented, workflow should complete successfully
-	// even though one step timed out.
-	// Note: This requires on_error support for agent steps to be implemented.
-	// The test documents the expected behavior.
-	if strings.Contains(stderr, "workflow completed") {
-		t.Logf("SUCCESS: workflow completed with on_error=continue as expected")
-	} else {
-		// Log that this is expected behavior that may not be implemented yet
-		t.Logf("NOTE: on_error=continue for agent timeouts may not be fully implemented yet")
+	if timeoutAt := strings.Index(stderr, "step timed out"); timeoutAt >= 0 && timeoutAt < stallAt {
+		t.Errorf("stall warning came after the hard timeout")
 	}
 }
 
-// TestE2E_AgentStepTimeout_OnErrorFail tests that when an agent step times out
-// with on_error=fail (default), the workflow fails.
-//
-// Per MVP-SPEC-v2 (lines 3272-3279):
-//   When a step fails and on_error is "fail":
-//   1. Workflow status becomes `failed`
-//   2. No more steps are executed
-//   3. Cleanup runs (kills agents, runs cleanup script)
-//   4. Human intervention required
-//
-// Expected behavior:
-//   1. Agent step times out after 3s
-//   2. Step marked failed with error_type: timeout
-//   3. Workflow fails immediately (default on_error=fail)
-//   4. Subsequent steps do NOT run
-func TestE2E_AgentStepTimeout_OnErrorFail(t *testing.T) {
+// TestE2E_AgentNudge tests that an agent with a nudge policy that goes silent
+// is re-prompted up to max_nudges times, and that the step either completes
+// once the agent responds to a nudge or fails if it stays silent.
+func TestE2E_AgentNudge(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping E2E test in short mode")
 	}
 
-	h := e2e.NewHarness(t)
-
-	// Simulator hangs forever
-	simConfig := e2e.NewSimConfigBuilder().
-		WithHangBehavior("hang forever").
-		WithStartupDelay(50 * time.Millisecond).
-		Build()
-
-	if err := h.WriteSimConfig(simConfig); err != nil {
-		t.Fatalf("failed to write sim config: %v", err)
-	}
-
-	adapterConfig := `
+	tests := []struct {
+		name        string
+		nudgePrompt string
+		wantStatus  types.StepStatus
+		wantNudges  int
+	}{
+		{name: "resumes", nudgePrompt: "Still there? Please finish up", wantStatus: types.StepStatusDone, wantNudges: 1},
+		{name: "stays silent", nudgePrompt: "Still there?", wantStatus: types.StepStatusFailed, wantNudges: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := e2e.NewHarness(t)
+
+			// Simulator completes only when asked to finish up; any other
+			// prompt makes it print an error and idle silently (no meow done,
+			// no stop hook)
+			simConfig := e2e.NewSimConfigBuilder().
+				WithBehavior("finish up", e2e.ActionComplete).
+				WithDefaultAction(e2e.ActionFail).
+				WithStopHook(false).
+				WithStartupDelay(50 * time.Millisecond).
+				Build()
+			if err := h.WriteSimConfig(simConfig); err != nil {
+				t.Fatalf("failed to write sim config: %v", err)
+			}
+
+			adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+			if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+				t.Fatalf("failed to write adapter config: %v", err)
+			}
+
+			template := fmt.Sprintf(`
+[main]
+name = "agent-nudge"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "quiet-worker"
+
+[[main.steps]]
+id = "quiet-step"
+executor = "agent"
+agent = "quiet-worker"
+needs = ["spawn-agent"]
+prompt = "Please go quiet"
+timeout = "30s"
+nudge = { after = "1s silence", prompt = %q, max_nudges = 2 }
+
+[[main.steps]]
+id = "kill-agent"
+executor = "kill"
+agent = "quiet-worker"
+needs = ["quiet-step"]
+`, tt.nudgePrompt)
+			if err := h.WriteTemplate("agent-nudge.toml", template); err != nil {
+				t.Fatalf("failed to write template: %v", err)
+			}
+
+			stdout, stderr, err := runMeowWithTimeout(h, 45*time.Second, "run", filepath.Join(h.TemplateDir, "agent-nudge.toml"))
+			t.Logf("stdout: %s", stdout)
+			t.Logf("stderr: %s", stderr)
+			if err != nil {
+				t.Logf("err: %v", err)
+			}
+
+			runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+			if len(runFiles) != 1 {
+				t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+			}
+			wf, err := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml")).Workflow()
+			if err != nil {
+				t.Fatalf("loading run: %v", err)
+			}
+			step := wf.Steps["quiet-step"]
+			if step.Status != tt.wantStatus {
+				t.Fatalf("quiet-step status = %v, want %v (error: %+v)", step.Status, tt.wantStatus, step.Error)
+			}
+			if n := strings.Count(stderr, "nudging silent agent"); n != tt.wantNudges {
+				t.Errorf("nudged %d times, want %d", n, tt.wantNudges)
+			}
+			if tt.wantStatus == types.StepStatusFailed && (step.Error == nil || step.Error.Type != types.StepErrorTimeout) {
+				t.Errorf("quiet-step error = %+v, want a timeout", step.Error)
+			}
+		})
+	}
+}
+
+// TestE2E_BehaviorRegex tests that a regex behavior matches a prompt whose
+// text varies around the pattern.
+func TestE2E_BehaviorRegex(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	// Only the regex completes the step; anything else fails silently
+	simConfig := e2e.NewSimConfigBuilder().
+		WithBehaviorRegex("implement .* feature", e2e.ActionComplete).
+		WithDefaultAction(e2e.ActionFail).
+		WithStopHook(false).
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+	if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+		t.Fatalf("failed to write adapter config: %v", err)
+	}
+
+	template := `
+[main]
+name = "behavior-regex"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "worker"
+
+[[main.steps]]
+id = "build"
+executor = "agent"
+agent = "worker"
+needs = ["spawn-agent"]
+prompt = "Please implement the {{workflow_id}} login feature"
+timeout = "15s"
+
+[[main.steps]]
+id = "kill-agent"
+executor = "kill"
+agent = "worker"
+needs = ["build"]
+`
+	if err := h.WriteTemplate("behavior-regex.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeowWithTimeout(h, 30*time.Second, "run", filepath.Join(h.TemplateDir, "behavior-regex.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	wf, err := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml")).Workflow()
+	if err != nil {
+		t.Fatalf("loading run: %v", err)
+	}
+	if step := wf.Steps["build"]; step.Status != types.StepStatusDone {
+		t.Errorf("build status = %v, want done (error: %+v)", step.Status, step.Error)
+	}
+}
+
+// TestE2E_AgentExitCompletion tests agent steps with completion = "exit": the
+// simulator exits without calling meow done, and its exit code decides the
+// step's outcome.
+func TestE2E_AgentExitCompletion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tests := []struct {
+		name       string
+		exitCode   int
+		wantStatus types.StepStatus
+	}{
+		{name: "exit 0 succeeds", exitCode: 0, wantStatus: types.StepStatusDone},
+		{name: "nonzero exit fails", exitCode: 2, wantStatus: types.StepStatusFailed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := e2e.NewHarness(t)
+
+			simConfig := e2e.NewSimConfigBuilder().
+				WithExitBehavior("review", tc.exitCode, "result.json", map[string]any{"verdict": "approve"}).
+				WithStopHook(false).
+				WithStartupDelay(50 * time.Millisecond).
+				Build()
+			if err := h.WriteSimConfig(simConfig); err != nil {
+				t.Fatalf("failed to write sim config: %v", err)
+			}
+
+			adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+			if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+				t.Fatalf("failed to write adapter config: %v", err)
+			}
+
+			template := `
+[main]
+name = "agent-exit"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "reviewer"
+
+[[main.steps]]
+id = "review"
+executor = "agent"
+agent = "reviewer"
+needs = ["spawn-agent"]
+prompt = "Please review the change and exit"
+completion = "exit"
+result_file = "result.json"
+timeout = "15s"
+
+[main.steps.outputs]
+verdict = { required = true, type = "string" }
+
+[[main.steps]]
+id = "kill-agent"
+executor = "kill"
+agent = "reviewer"
+needs = ["review"]
+`
+			if err := h.WriteTemplate("agent-exit.toml", template); err != nil {
+				t.Fatalf("failed to write template: %v", err)
+			}
+
+			stdout, stderr, err := runMeowWithTimeout(h, 30*time.Second, "run", filepath.Join(h.TemplateDir, "agent-exit.toml"))
+			if tc.wantStatus == types.StepStatusDone && err != nil {
+				t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+			}
+
+			runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+			if len(runFiles) != 1 {
+				t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+			}
+			run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+			wf, err := run.Workflow()
+			if err != nil {
+				t.Fatalf("loading run: %v", err)
+			}
+			step := wf.Steps["review"]
+			if step.Status != tc.wantStatus {
+				t.Fatalf("review status = %v, want %v (error: %+v)\nstderr: %s", step.Status, tc.wantStatus, step.Error, stderr)
+			}
+			if tc.wantStatus == types.StepStatusDone {
+				if step.Outputs["verdict"] != "approve" {
+					t.Errorf("review outputs = %v, want verdict from the result file", step.Outputs)
+				}
+				return
+			}
+			if err := run.AssertStepError("review", fmt.Sprintf("exited with code %d", tc.exitCode)); err != nil {
+				t.Error(err)
+			}
+			if errType, err := run.StepErrorType("review"); err != nil || errType != string(types.StepErrorCommandFailed) {
+				t.Errorf("review error type = %q (%v), want command_failed", errType, err)
+			}
+		})
+	}
+}
+
+// TestE2E_AgentLogOutputs verifies that a field the agent writes to its
+// structured (JSON) log is extracted into the step's outputs (from_log).
+func TestE2E_AgentLogOutputs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	simConfig := e2e.NewSimConfigBuilder().
+		WithLogFields("implement", map[string]any{"files_changed": 4}).
+		WithStopHook(false).
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+	if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+		t.Fatalf("failed to write adapter config: %v", err)
+	}
+
+	template := `
+[main]
+name = "agent-log-outputs"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "worker"
+
+[[main.steps]]
+id = "implement"
+executor = "agent"
+agent = "worker"
+needs = ["spawn-agent"]
+prompt = "Please implement the feature"
+timeout = "15s"
+
+[main.steps.outputs]
+files_changed = { required = true, type = "number", from_log = "files_changed" }
+
+[[main.steps]]
+id = "kill-agent"
+executor = "kill"
+agent = "worker"
+needs = ["implement"]
+`
+	if err := h.WriteTemplate("agent-log-outputs.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeowWithTimeout(h, 30*time.Second, "run", filepath.Join(h.TemplateDir, "agent-log-outputs.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+	wf, err := run.Workflow()
+	if err != nil {
+		t.Fatalf("loading run: %v", err)
+	}
+	step := wf.Steps["implement"]
+	if step.Status != types.StepStatusDone {
+		t.Fatalf("implement status = %v, want done (error: %+v)\nstderr: %s", step.Status, step.Error, stderr)
+	}
+	if got := fmt.Sprint(step.Outputs["files_changed"]); got != "4" {
+		t.Errorf("implement outputs = %v, want files_changed = 4 from the agent's log", step.Outputs)
+	}
+}
+
+// TestE2E_PromptAcknowledgmentRecovery drives the orchestrator's prompt
+// recovery deterministically: the simulator acknowledges late, or swallows
+// prompts so the orchestrator re-injects (once) and then gives up.
+func TestE2E_PromptAcknowledgmentRecovery(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tests := []struct {
+		name       string
+		configure  func(*e2e.SimConfigBuilder) *e2e.SimConfigBuilder
+		wantStatus types.StepStatus
+		wantLog    string
+	}{
+		{
+			name: "late ack",
+			configure: func(b *e2e.SimConfigBuilder) *e2e.SimConfigBuilder {
+				return b.WithAckDelay("review", 500*time.Millisecond)
+			},
+			wantStatus: types.StepStatusDone,
+		},
+		{
+			name: "swallowed once recovers on re-injection",
+			configure: func(b *e2e.SimConfigBuilder) *e2e.SimConfigBuilder {
+				return b.WithSwallowPrompts("review", 1)
+			},
+			wantStatus: types.StepStatusDone,
+			wantLog:    "recovery successful",
+		},
+		{
+			name: "swallowed twice escalates",
+			configure: func(b *e2e.SimConfigBuilder) *e2e.SimConfigBuilder {
+				return b.WithSwallowPrompts("review", 2)
+			},
+			wantStatus: types.StepStatusFailed,
+			wantLog:    "recovery exhausted",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := e2e.NewHarness(t)
+
+			simConfig := tc.configure(e2e.NewSimConfigBuilder().
+				WithStopHook(false).
+				WithStartupDelay(50 * time.Millisecond)).
+				Build()
+			if err := h.WriteSimConfig(simConfig); err != nil {
+				t.Fatalf("failed to write sim config: %v", err)
+			}
+
+			adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+			if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+				t.Fatalf("failed to write adapter config: %v", err)
+			}
+
+			// The timeout outlasts the recovery attempt (5s wait, then 2.5s
+			// after re-injection), so only an escalated prompt fails the step
+			template := `
+[main]
+name = "prompt-recovery"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "reviewer"
+
+[[main.steps]]
+id = "review"
+executor = "agent"
+agent = "reviewer"
+needs = ["spawn-agent"]
+prompt = "Please review the change"
+timeout = "9s"
+
+[[main.steps]]
+id = "kill-agent"
+executor = "kill"
+agent = "reviewer"
+needs = ["review"]
+`
+			if err := h.WriteTemplate("prompt-recovery.toml", template); err != nil {
+				t.Fatalf("failed to write template: %v", err)
+			}
+
+			stdout, stderr, err := runMeowWithTimeout(h, 60*time.Second, "run", filepath.Join(h.TemplateDir, "prompt-recovery.toml"))
+			if tc.wantStatus == types.StepStatusDone && err != nil {
+				t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+			}
+
+			runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+			if len(runFiles) != 1 {
+				t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+			}
+			wf, err := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml")).Workflow()
+			if err != nil {
+				t.Fatalf("loading run: %v", err)
+			}
+			step := wf.Steps["review"]
+			if step.Status != tc.wantStatus {
+				t.Fatalf("review status = %v, want %v (error: %+v)", step.Status, tc.wantStatus, step.Error)
+			}
+			if tc.wantLog != "" && !strings.Contains(stderr, tc.wantLog) {
+				t.Errorf("expected %q in orchestrator log\nstderr: %s", tc.wantLog, stderr)
+			}
+		})
+	}
+}
+
+// TestE2E_AgentStepTimeout_OnErrorContinue tests that when an agent step times out
+// with on_error=continue, the workflow continues to subsequent steps.
+//
+// Per MVP-SPEC-v2 (lines 3069-3091):
+//   on_error = "continue" logs the error and continues to the next step
+//
+// Expected behavior:
+//   1. Agent step times out after 3s
+//   2. Step marked failed with error_type: timeout
+//   3. Workflow continues due to on_error=continue
+//   4. Subsequent steps execute normally
+//   5. Workflow completes successfully
+func TestE2E_AgentStepTimeout_OnErrorContinue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	// Simulator hangs on "hang" prompts, completes on "continue" prompts
+	simConfig := e2e.NewSimConfigBuilder().
+		WithHangBehavior("hang forever").
+		WithBehavior("after timeout", e2e.ActionComplete).
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+`
+	if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+		t.Fatalf("failed to write adapter config: %v", err)
+	}
+
+	// Template with timeout step using on_error=continue and a follow-up shell step
+	template := `
+[main]
+name = "timeout-continue"
+
+[[main.steps]]
+id = "spawn-worker"
+executor = "spawn"
+agent = "worker"
+
+[[main.steps]]
+id = "will-timeout"
+executor = "agent"
+agent = "worker"
+needs = ["spawn-worker"]
+prompt = "This prompt will hang forever"
+timeout = "3s"
+on_error = "continue"
+
+[[main.steps]]
+id = "after-timeout"
+executor = "shell"
+needs = ["will-timeout"]
+command = "echo 'workflow continued after timeout'"
+
+[[main.steps]]
+id = "cleanup"
+executor = "kill"
+agent = "worker"
+needs = ["after-timeout"]
+graceful = true
+`
+	if err := h.WriteTemplate("timeout-continue.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	// Use longer timeout to allow for timeout + grace period + continuation
+	stdout, stderr, err := runMeowWithTimeout(h, 45*time.Second, "run", filepath.Join(h.TemplateDir, "timeout-continue.toml"))
+
+	t.Logf("stdout: %s", stdout)
+	t.Logf("stderr: %s", stderr)
+	if err != nil {
+		t.Logf("err: %v", err)
+	}
+
+	// Verify timeout was detected
+	hasTimeoutDetection := strings.Contains(stderr, "timed out") ||
+		strings.Contains(stderr, "timeout") ||
+		strings.Contains(stderr, "sending interrupt")
+
+	if !hasTimeoutDetection {
+		t.Errorf("expected timeout detection in output")
+	}
+
+	// With on_error=continue fully implemented, workflow should complete successfully
+	// even though one step timed out.
+	// Note: This requires on_error support for agent steps to be implemented.
+	// The test documents the expected behavior.
+	if strings.Contains(stderr, "workflow completed") {
+		t.Logf("SUCCESS: workflow completed with on_error=continue as expected")
+	} else {
+		// Log that this is expected behavior that may not be implemented yet
+		t.Logf("NOTE: on_error=continue for agent timeouts may not be fully implemented yet")
+	}
+}
+
+// TestE2E_AgentStepTimeout_OnErrorFail tests that when an agent step times out
+// with on_error=fail (default), the workflow fails.
+//
+// Per MVP-SPEC-v2 (lines 3272-3279):
+//   When a step fails and on_error is "fail":
+//   1. Workflow status becomes `failed`
+//   2. No more steps are executed
+//   3. Cleanup runs (kills agents, runs cleanup script)
+//   4. Human intervention required
+//
+// Expected behavior:
+//   1. Agent step times out after 3s
+//   2. Step marked failed with error_type: timeout
+//   3. Workflow fails immediately (default on_error=fail)
+//   4. Subsequent steps do NOT run
+func TestE2E_AgentStepTimeout_OnErrorFail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	// Simulator hangs forever
+	simConfig := e2e.NewSimConfigBuilder().
+		WithHangBehavior("hang forever").
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	adapterConfig := `
 [adapter]
 name = "simulator"
 
@@ -1965,21 +2852,146 @@ needs = ["handle-timeout"]
 		strings.Contains(stderr, "timeout") ||
 		strings.Contains(stderr, "sending interrupt")
 
-	if !hasTimeoutDetection {
-		t.Errorf("expected timeout detection in output")
-	}
+	if !hasTimeoutDetection {
+		t.Errorf("expected timeout detection in output")
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	// The recovery steps ran, which recovered the timed-out step
+	for _, id := range []string{"will-timeout.handle-timeout", "will-timeout.notify", "will-timeout", "final-step"} {
+		if err := run.AssertStepDone(id); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := run.AssertWorkflowDone(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestE2E_AgentStepError tests an agent that reports it could not do its step
+// (meow done --error): the step fails with error type agent_failed and the
+// message, and on_error applies as for any other failure.
+func TestE2E_AgentStepError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tests := []struct {
+		name      string
+		onError   string
+		wantAfter types.StepStatus
+		wantDone  bool
+	}{
+		{name: "fails the step", wantAfter: types.StepStatusSkipped},
+		{name: "on_error recovers", onError: `on_error = ".recover"`, wantAfter: types.StepStatusDone, wantDone: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := e2e.NewHarness(t)
+
+			simConfig := e2e.NewSimConfigBuilder().
+				WithBehaviorError("broken task", "tests do not compile").
+				WithStartupDelay(50 * time.Millisecond).
+				Build()
+			if err := h.WriteSimConfig(simConfig); err != nil {
+				t.Fatalf("failed to write sim config: %v", err)
+			}
+
+			adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+			if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+				t.Fatalf("failed to write adapter config: %v", err)
+			}
+
+			// Cleanup kills the agent when the run fails
+			template := `
+[main]
+name = "agent-error"
+cleanup_on_failure = "echo cleaned up"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "worker"
+
+[[main.steps]]
+id = "work"
+executor = "agent"
+agent = "worker"
+needs = ["spawn-agent"]
+prompt = "Please do this broken task"
+timeout = "30s"
+` + tc.onError + `
+
+[[main.steps]]
+id = "after"
+executor = "shell"
+needs = ["work"]
+command = "echo after"
+
+[[main.steps]]
+id = "kill-agent"
+executor = "kill"
+agent = "worker"
+needs = ["after"]
 
-	// Check if recovery steps were executed
-	// This requires template-based on_error recovery to be implemented
-	hasRecovery := strings.Contains(stderr, "recovery") ||
-		strings.Contains(stdout, "recovery")
+[recover]
+name = "recover"
+internal = true
 
-	if hasRecovery {
-		t.Logf("SUCCESS: recovery template was expanded and executed")
-	} else {
-		// Template-based on_error recovery is a Phase 8 feature per spec
-		// This test documents the expected behavior
-		t.Logf("NOTE: Template-based on_error recovery may not be implemented yet (Phase 8)")
+[[recover.steps]]
+id = "note"
+executor = "shell"
+command = "echo 'recovering from {{_failed_step.error_type}}'"
+`
+			if err := h.WriteTemplate("agent-error.toml", template); err != nil {
+				t.Fatalf("failed to write template: %v", err)
+			}
+
+			_, stderr, err := runMeowWithTimeout(h, 30*time.Second, "run", filepath.Join(h.TemplateDir, "agent-error.toml"))
+			if err != nil && strings.Contains(err.Error(), "timeout") {
+				t.Fatalf("orchestrator hung after the agent reported an error: %v\nstderr: %s", err, stderr)
+			}
+
+			runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+			if len(runFiles) != 1 {
+				t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+			}
+			run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+			if tc.wantDone {
+				if err := run.AssertWorkflowDone(); err != nil {
+					t.Errorf("%v\nstderr: %s", err, stderr)
+				}
+			} else {
+				if errType, err := run.StepErrorType("work"); err != nil || errType != string(types.StepErrorAgentFailed) {
+					t.Errorf("work error type = %q (%v), want agent_failed\nstderr: %s", errType, err, stderr)
+				}
+				if err := run.AssertStepError("work", "tests do not compile"); err != nil {
+					t.Error(err)
+				}
+				if err := run.AssertWorkflowFailed(); err != nil {
+					t.Error(err)
+				}
+			}
+			if status, err := run.StepStatus("after"); err != nil || status != string(tc.wantAfter) {
+				t.Errorf("after status = %q (%v), want %s", status, err, tc.wantAfter)
+			}
+		})
 	}
 }
 
@@ -2010,8 +3022,6 @@ needs = ["handle-timeout"]
 // - Detects that session is gone
 // - Marks step as failed with error_type="agent_crashed"
 // - Workflow fails (unless on_error=continue)
-//
-// Current behavior: Relies on step timeout to eventually fail.
 func TestE2E_AgentCrash_SessionDies(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping agent crash test in short mode")
@@ -2043,8 +3053,8 @@ startup_delay = "100ms"
 		t.Fatalf("failed to write adapter config: %v", err)
 	}
 
-	// Template where agent receives a prompt that causes crash
-	// Using a very short timeout so the test doesn't hang
+	// The timeout is long enough that only liveness polling can fail the
+	// step within the test's deadline
 	template := `
 [main]
 name = "agent-crash-test"
@@ -2060,7 +3070,7 @@ executor = "agent"
 agent = "crash-agent"
 needs = ["spawn-agent"]
 prompt = "Please do this crash task for me"
-timeout = "5s"
+timeout = "5m"
 
 [[main.steps]]
 id = "after-crash"
@@ -2068,46 +3078,30 @@ executor = "shell"
 needs = ["crash-work"]
 command = "echo 'this should not run'"
 `
-	// Start orchestrator in background so we can test behavior
-	// StartOrchestratorWithTemplate writes the template and starts the orchestrator
-	proc, err := h.StartOrchestratorWithTemplate("agent-crash.toml", template)
-	if err != nil {
-		t.Fatalf("failed to start orchestrator: %v", err)
+	if err := h.WriteTemplate("agent-crash.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
 	}
 
-	// Wait a bit for workflow to start
-	time.Sleep(3 * time.Second)
-
-	// The simulator should have crashed by now
-	// Check that the orchestrator detected something (session gone)
-	// Note: Without crash detection implemented, this will wait for timeout
-
-	// Wait for completion or timeout
-	waitErr := proc.WaitWithTimeout(30 * time.Second)
-
-	stderr := proc.Stderr()
-	stdout := proc.Stdout()
-
+	stdout, stderr, err := runMeowWithTimeout(h, 60*time.Second, "run", filepath.Join(h.TemplateDir, "agent-crash.toml"))
 	t.Logf("Orchestrator stdout: %s", stdout)
-	t.Logf("Orchestrator stderr (first 2000 chars): %.2000s", stderr)
-
-	// Document the observed behavior
-	if waitErr != nil {
-		if strings.Contains(waitErr.Error(), "timeout") {
-			t.Log("NOTE: Test timed out - crash detection and timeout handling need implementation")
-			t.Skip("Crash detection not yet implemented - orchestrator hung waiting for meow done")
-		}
+	if err != nil && strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("orchestrator hung after the agent crashed: %v\nstderr: %s", err, stderr)
 	}
 
-	// Check if workflow indicated failure
-	hasFailure := proc.IsDone() && waitErr != nil ||
-		strings.Contains(stderr, "failed") ||
-		strings.Contains(stderr, "timed out")
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
 
-	if hasFailure {
-		t.Log("Workflow properly detected crash/timeout and failed")
-	} else if strings.Contains(stderr, "workflow completed") {
-		t.Log("NOTE: Workflow completed despite crash - crash detection not implemented")
+	if errType, err := run.StepErrorType("crash-work"); err != nil || errType != string(types.StepErrorAgentCrashed) {
+		t.Errorf("crash-work error type = %q (%v), want agent_crashed\nstderr: %s", errType, err, stderr)
+	}
+	if status, err := run.StepStatus("after-crash"); err != nil || status != string(types.StepStatusSkipped) {
+		t.Errorf("after-crash status = %q (%v), want skipped", status, err)
+	}
+	if err := run.AssertWorkflowFailed(); err != nil {
+		t.Error(err)
 	}
 }
 
@@ -2132,24 +3126,93 @@ func TestE2E_AgentCrash_OnErrorContinue(t *testing.T) {
 }
 
 // TestE2E_AgentCrash_StopHookNotCalled tests that when an agent crashes abruptly
-// (tmux session dies), the stop hook is NOT called since the process is gone.
+// partway through its work, the stop hook is NOT called since the process is gone.
 // This verifies the distinction between graceful stops (stop hook fires) and crashes.
 //
 // Expected behavior:
-// - Agent process crashes (exits with non-zero code)
+// - Agent is spawned successfully and starts working (emits a tool event)
+// - Agent process then crashes (exits with non-zero code)
 // - Process is gone before stop hook can fire
-// - Orchestrator detects crash via session liveness check
-// - Stop hook marker file should NOT exist
-//
-// Current status: Depends on crash detection being implemented.
+// - Orchestrator detects the exit (completion = "exit") and fails the step
+// - No agent-stopped event arrives after the work started
 func TestE2E_AgentCrash_StopHookNotCalled(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping agent crash test in short mode")
 	}
 
-	// This test documents expected behavior for stop hooks when agent crashes.
-	// Currently blocked by crash detection implementation.
-	t.Skip("Test blocked: crash detection not yet implemented - test would hang")
+	h := e2e.NewHarness(t)
+
+	simConfig := e2e.NewSimConfigBuilder().
+		WithDelayedCrashBehavior("crash task", 500*time.Millisecond, 3,
+			e2e.EventDef{Type: "file_written", Data: map[string]any{"path": "partial.txt"}}).
+		WithStopHook(true).
+		WithToolEvents(true).
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+	if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+		t.Fatalf("failed to write adapter config: %v", err)
+	}
+
+	template := `
+[main]
+name = "agent-crash-mid-work"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "crash-agent"
+
+[[main.steps]]
+id = "crash-work"
+executor = "agent"
+agent = "crash-agent"
+needs = ["spawn-agent"]
+prompt = "Please do this crash task for me"
+completion = "exit"
+timeout = "15s"
+`
+	if err := h.WriteTemplate("agent-crash-mid-work.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	_, stderr, _ := runMeowWithTimeout(h, 30*time.Second, "run", filepath.Join(h.TemplateDir, "agent-crash-mid-work.toml"))
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	// The spawn succeeded; the crash happened mid-work
+	if status, err := run.StepStatus("spawn-agent"); err != nil || status != string(types.StepStatusDone) {
+		t.Errorf("spawn-agent status = %v (%v), want done\nstderr: %s", status, err, stderr)
+	}
+	if err := run.AssertStepError("crash-work", "exited with code 3"); err != nil {
+		t.Errorf("%v\nstderr: %s", err, stderr)
+	}
+
+	// The tool event proves the agent started working before it crashed
+	workStarted := strings.Index(stderr, "event_type=file_written")
+	if workStarted < 0 {
+		t.Fatalf("expected the partial work's tool event\nstderr: %s", stderr)
+	}
+	if strings.Contains(stderr[workStarted:], "event_type=agent-stopped") {
+		t.Errorf("stop hook fired after the agent crashed\nstderr: %s", stderr)
+	}
 }
 
 // TestE2E_AgentCrash_RalphWiggum tests catastrophic failure scenario:
@@ -2181,21 +3244,89 @@ func TestE2E_AgentCrash_RalphWiggum(t *testing.T) {
 //
 // Expected behavior:
 // - Agent crashes immediately after receiving prompt
-// - Orchestrator detects crash via session liveness poll OR timeout fires
+// - Orchestrator detects crash via session liveness poll, long before the
+//   step's timeout
 // - Total time should be bounded (not infinite)
 //
 // This test ensures the system doesn't hang forever waiting for meow done
 // when an agent has crashed.
-//
-// Current status: Depends on crash detection or timeout handling being implemented.
 func TestE2E_AgentCrash_DetectionLatency(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping agent crash test in short mode")
 	}
 
-	// This test documents expected crash detection latency requirements.
-	// Currently blocked by crash detection and timeout handling implementation.
-	t.Skip("Test blocked: crash detection and timeout grace period handling not yet implemented")
+	h := e2e.NewHarness(t)
+
+	simConfig := e2e.NewSimConfigBuilder().
+		WithDefaultCrash(1).
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	adapterConfig := `
+[adapter]
+name = "simulator"
+
+[spawn]
+command = "/tmp/meow-agent-sim-e2e"
+
+[timing]
+startup_delay = "100ms"
+`
+	if err := h.WriteAdapterConfig("simulator", adapterConfig); err != nil {
+		t.Fatalf("failed to write adapter config: %v", err)
+	}
+
+	template := `
+[main]
+name = "crash-latency"
+
+[[main.steps]]
+id = "spawn-agent"
+executor = "spawn"
+agent = "crash-agent"
+
+[[main.steps]]
+id = "work"
+executor = "agent"
+agent = "crash-agent"
+needs = ["spawn-agent"]
+prompt = "Do anything"
+timeout = "5m"
+`
+	if err := h.WriteTemplate("crash-latency.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	proc, err := h.StartOrchestrator("run", filepath.Join(h.TemplateDir, "crash-latency.toml"))
+	if err != nil {
+		t.Fatalf("failed to start orchestrator: %v", err)
+	}
+	defer proc.Kill()
+
+	start := time.Now()
+
+	var runFiles []string
+	for deadline := time.Now().Add(5 * time.Second); len(runFiles) == 0 && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		runFiles, _ = filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	}
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d\nstderr: %s", len(runFiles), proc.Stderr())
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	// Well inside the 5m step timeout: only liveness polling can fail it
+	if err := run.WaitForStepStatus("work", types.StepStatusFailed, 20*time.Second); err != nil {
+		t.Fatalf("crash not detected: %v\nstderr: %s", err, proc.Stderr())
+	}
+	t.Logf("crash detected %.2fs after the run started", time.Since(start).Seconds())
+
+	if errType, err := run.StepErrorType("work"); err != nil || errType != string(types.StepErrorAgentCrashed) {
+		t.Errorf("work error type = %q (%v), want agent_crashed", errType, err)
+	}
 }
 
 // ===========================================================================
@@ -2216,9 +3347,6 @@ func TestE2E_OutputValidation_MissingRequired(t *testing.T) {
 		t.Skip("skipping E2E test in short mode")
 	}
 
-	// Test blocked: requires validation retry mechanism (re-inject prompt on validation failure)
-	t.Skip("Test blocked: validation retry mechanism (re-inject prompt on failure) not yet implemented")
-
 	h := e2e.NewHarness(t)
 
 	// Configure simulator:
@@ -2308,9 +3436,6 @@ func TestE2E_OutputValidation_WrongType(t *testing.T) {
 		t.Skip("skipping E2E test in short mode")
 	}
 
-	// Test blocked: requires validation retry mechanism (re-inject prompt on validation failure)
-	t.Skip("Test blocked: validation retry mechanism (re-inject prompt on failure) not yet implemented")
-
 	h := e2e.NewHarness(t)
 
 	// Configure simulator:
@@ -2395,9 +3520,6 @@ func TestE2E_OutputValidation_MultipleRetries(t *testing.T) {
 		t.Skip("skipping E2E test in short mode")
 	}
 
-	// Test blocked: requires validation retry mechanism (re-inject prompt on validation failure)
-	t.Skip("Test blocked: validation retry mechanism (re-inject prompt on failure) not yet implemented")
-
 	h := e2e.NewHarness(t)
 
 	// Configure simulator to fail 3 times, then succeed on 4th attempt
@@ -3306,41 +4428,223 @@ executor = "shell"
 needs = ["setup"]
 command = "sleep 1 && meow event test-signal && echo 'event sent'"
 
-# Verify both complete
+# Verify both complete
+[[main.steps]]
+id = "verify"
+executor = "shell"
+needs = ["event-waiter", "event-sender"]
+command = "echo 'event routing verified'"
+`
+
+	if err := h.WriteTemplate("event-routing.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	start := time.Now()
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "event-routing.toml"))
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	// Verify workflow completed
+	if !strings.Contains(stderr, "workflow completed") {
+		t.Errorf("expected workflow to complete\nstdout: %s\nstderr: %s", stdout, stderr)
+	}
+
+	// Verify event was received (not timeout)
+	if strings.Contains(stdout, "timeout - event not received") {
+		t.Errorf("event-waiter timed out instead of receiving event\nstdout: %s\nstderr: %s", stdout, stderr)
+	}
+
+	// Should complete in ~1-3s (sender waits 1s, then sends event)
+	if elapsed > 10*time.Second {
+		t.Errorf("workflow took too long (%.2fs) - event routing may have failed", elapsed.Seconds())
+	} else {
+		t.Logf("✓ Event routing verified in %.2fs", elapsed.Seconds())
+	}
+}
+
+// TestE2E_EventRouting_TemplatedFilter tests that an await-event filter can
+// reference a prior step's output. The reference is resolved when the waiter
+// is dispatched, so only the event carrying the planned task's ID matches.
+func TestE2E_EventRouting_TemplatedFilter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "templated-filter"
+
+[[main.steps]]
+id = "plan"
+executor = "shell"
+command = "echo T-42"
+
+[main.steps.outputs]
+id = { source = "stdout" }
+
+[[main.steps]]
+id = "waiter"
+executor = "shell"
+needs = ["plan"]
+command = "meow await-event task-done --filter task_id={{plan.outputs.id}} --timeout 10s"
+timeout = "15s"
+
+[main.steps.outputs]
+event = { source = "stdout", type = "json" }
+
+# Another task finishes first; its event must not satisfy the waiter
+[[main.steps]]
+id = "sender"
+executor = "shell"
+needs = ["plan"]
+command = "sleep 1 && meow event task-done --data task_id=T-41 && sleep 0.5 && meow event task-done --data task_id={{plan.outputs.id}}"
+`
+	if err := h.WriteTemplate("templated-filter.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "templated-filter.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	wf, err := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml")).Workflow()
+	if err != nil {
+		t.Fatalf("loading run: %v", err)
+	}
+	waiter := wf.Steps["waiter"]
+	if waiter.Status != types.StepStatusDone {
+		t.Fatalf("waiter status = %v, want done (error: %+v)", waiter.Status, waiter.Error)
+	}
+	event, _ := waiter.Outputs["event"].(map[string]any)
+	data, _ := event["data"].(map[string]any)
+	if data["task_id"] != "T-42" {
+		t.Errorf("waiter matched event %v, want the one with task_id T-42", waiter.Outputs["event"])
+	}
+}
+
+// TestE2E_EventRouting_LifecycleEvents tests that a step can await another
+// step's completion through the step-done lifecycle event the orchestrator
+// routes, without a needs dependency on it.
+func TestE2E_EventRouting_LifecycleEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "lifecycle-events"
+
+[[main.steps]]
+id = "waiter"
+executor = "shell"
+command = "meow await-event step-done --filter step=build --timeout 10s"
+timeout = "15s"
+
+[main.steps.outputs]
+event = { source = "stdout", type = "json" }
+
+[[main.steps]]
+id = "build"
+executor = "shell"
+command = "sleep 1"
+`
+	if err := h.WriteTemplate("lifecycle-events.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "lifecycle-events.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	wf, err := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml")).Workflow()
+	if err != nil {
+		t.Fatalf("loading run: %v", err)
+	}
+	waiter := wf.Steps["waiter"]
+	if waiter.Status != types.StepStatusDone {
+		t.Fatalf("waiter status = %v, want done (error: %+v)", waiter.Status, waiter.Error)
+	}
+	event, _ := waiter.Outputs["event"].(map[string]any)
+	data, _ := event["data"].(map[string]any)
+	if event["event_type"] != "step-done" || data["step"] != "build" {
+		t.Errorf("waiter received %v, want step-done for build", waiter.Outputs["event"])
+	}
+}
+
+// TestE2E_StepOutputs_Dump checks the debugging helpers: StepOutputs returns
+// every output of a step as a copy, and DumpSteps logs the whole run.
+func TestE2E_StepOutputs_Dump(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "dump-outputs"
+
 [[main.steps]]
-id = "verify"
+id = "report"
 executor = "shell"
-needs = ["event-waiter", "event-sender"]
-command = "echo 'event routing verified'"
-`
+command = "echo '{\"passed\":3}'"
 
-	if err := h.WriteTemplate("event-routing.toml", template); err != nil {
+[main.steps.outputs]
+summary = { source = "stdout", type = "json" }
+`
+	if err := h.WriteTemplate("dump-outputs.toml", template); err != nil {
 		t.Fatalf("failed to write template: %v", err)
 	}
 
-	start := time.Now()
-	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "event-routing.toml"))
-	elapsed := time.Since(start)
-
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "dump-outputs.toml"))
 	if err != nil {
 		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
 	}
 
-	// Verify workflow completed
-	if !strings.Contains(stderr, "workflow completed") {
-		t.Errorf("expected workflow to complete\nstdout: %s\nstderr: %s", stdout, stderr)
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
 	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+	run.DumpSteps()
 
-	// Verify event was received (not timeout)
-	if strings.Contains(stdout, "timeout - event not received") {
-		t.Errorf("event-waiter timed out instead of receiving event\nstdout: %s\nstderr: %s", stdout, stderr)
+	outputs, err := run.StepOutputs("report")
+	if err != nil {
+		t.Fatalf("StepOutputs() error = %v", err)
+	}
+	for _, key := range []string{"summary", "exit_code", "duration_ms"} {
+		if _, ok := outputs[key]; !ok {
+			t.Errorf("outputs missing %s: %v", key, outputs)
+		}
+	}
+	if summary, _ := outputs["summary"].(map[string]any); summary["passed"] != 3 {
+		t.Errorf("summary = %v, want passed=3", outputs["summary"])
 	}
 
-	// Should complete in ~1-3s (sender waits 1s, then sends event)
-	if elapsed > 10*time.Second {
-		t.Errorf("workflow took too long (%.2fs) - event routing may have failed", elapsed.Seconds())
-	} else {
-		t.Logf("✓ Event routing verified in %.2fs", elapsed.Seconds())
+	delete(outputs, "summary")
+	if again, _ := run.StepOutputs("report"); again["summary"] == nil {
+		t.Error("modifying the returned outputs changed the step's outputs")
+	}
+	if _, err := run.StepOutputs("missing"); err == nil {
+		t.Error("StepOutputs() of an unknown step should fail")
 	}
 }
 
@@ -3489,6 +4793,78 @@ graceful = true
 	}
 }
 
+// TestE2E_WaitForEvent tests that WorkflowRun.WaitForEvent observes the
+// prompt-received and agent-stopped events an agent sends, without the test
+// reading the orchestrator's logs itself.
+func TestE2E_WaitForEvent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	// The agent goes idle at startup, then gives up on the task without
+	// meow done: both fire the stop hook
+	simConfig := e2e.NewSimConfigBuilder().
+		WithBehavior("do the work", e2e.ActionFail).
+		WithPromptReceivedHook(true).
+		WithStartupDelay(50 * time.Millisecond).
+		Build()
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	template := `
+[main]
+name = "wait-for-event"
+
+[[main.steps]]
+id = "spawn"
+executor = "spawn"
+agent = "worker"
+
+[[main.steps]]
+id = "work"
+executor = "agent"
+agent = "worker"
+needs = ["spawn"]
+prompt = "do the work"
+`
+	if err := h.WriteTemplate("wait-for-event.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	proc, err := h.StartOrchestrator("run", filepath.Join(h.TemplateDir, "wait-for-event.toml"))
+	if err != nil {
+		t.Fatalf("failed to start orchestrator: %v", err)
+	}
+	defer proc.Kill()
+
+	var runFiles []string
+	for deadline := time.Now().Add(5 * time.Second); len(runFiles) == 0 && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		runFiles, _ = filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	}
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d\nstderr: %s", len(runFiles), proc.Stderr())
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	for _, eventType := range []string{"prompt-received", "agent-stopped", "agent-stopped"} {
+		event, err := run.WaitForEvent(eventType, 10*time.Second)
+		if err != nil {
+			t.Fatalf("WaitForEvent(%s) error = %v\nstderr: %s", eventType, err, proc.Stderr())
+		}
+		if event.EventType != eventType || event.Agent != "worker" {
+			t.Errorf("WaitForEvent(%s) = %s from %q, want it from worker", eventType, event.EventType, event.Agent)
+		}
+	}
+
+	// The agent gave up without finishing: no further stop
+	if _, err := run.WaitForAgentStopped(500 * time.Millisecond); err == nil {
+		t.Error("WaitForEvent returned a third agent-stopped event")
+	}
+}
+
 // ===========================================================================
 // Step Output Reference Tests
 // ===========================================================================
@@ -4054,6 +5430,256 @@ command = "echo 'Processing number {{num}}' && sleep 0.1"
 // Step Status Check Tests (Persistence Monitor Pattern)
 // ===========================================================================
 
+// TestE2E_WaitForStepStatus tests waiting for a step to pass through a
+// transient status, and the last-seen status reported on timeout.
+func TestE2E_WaitForStepStatus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "wait-step-status"
+
+[[main.steps]]
+id = "slow"
+executor = "shell"
+command = "sleep 2"
+`
+	if err := h.WriteTemplate("wait-step-status.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	proc, err := h.StartOrchestrator("run", filepath.Join(h.TemplateDir, "wait-step-status.toml"))
+	if err != nil {
+		t.Fatalf("failed to start orchestrator: %v", err)
+	}
+	defer proc.Kill()
+
+	var runFiles []string
+	for deadline := time.Now().Add(5 * time.Second); len(runFiles) == 0 && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		runFiles, _ = filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	}
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d\nstderr: %s", len(runFiles), proc.Stderr())
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	if err := run.WaitForStepStatus("slow", types.StepStatusRunning, 5*time.Second); err != nil {
+		t.Fatalf("WaitForStepStatus(running) error = %v", err)
+	}
+	if err := run.WaitForStepStatus("slow", types.StepStatusDone, 10*time.Second); err != nil {
+		t.Fatalf("WaitForStepStatus(done) error = %v", err)
+	}
+
+	err = run.WaitForStepStatus("slow", types.StepStatusFailed, 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "last seen: done") {
+		t.Errorf("WaitForStepStatus(failed) error = %v, want a timeout reporting done", err)
+	}
+}
+
+// TestE2E_GateWaitsForEvent tests that a gate step stays running until
+// meow event sends its event, and that the event data becomes its outputs.
+func TestE2E_GateWaitsForEvent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "gate-event"
+
+[[main.steps]]
+id = "approve"
+executor = "gate"
+wait_for_event = "approve-deploy"
+
+[[main.steps]]
+id = "deploy"
+executor = "shell"
+needs = ["approve"]
+command = "echo deployed by {{approve.outputs.approver}}"
+
+[main.steps.shell_outputs]
+result = { source = "stdout" }
+`
+	if err := h.WriteTemplate("gate-event.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	proc, err := h.StartOrchestrator("run", filepath.Join(h.TemplateDir, "gate-event.toml"))
+	if err != nil {
+		t.Fatalf("failed to start orchestrator: %v", err)
+	}
+	defer proc.Kill()
+
+	var runFiles []string
+	for deadline := time.Now().Add(5 * time.Second); len(runFiles) == 0 && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		runFiles, _ = filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	}
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d\nstderr: %s", len(runFiles), proc.Stderr())
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+
+	if err := run.WaitForStepStatus("approve", types.StepStatusRunning, 5*time.Second); err != nil {
+		t.Fatalf("WaitForStepStatus(running) error = %v", err)
+	}
+	// The gate holds without an event
+	time.Sleep(300 * time.Millisecond)
+	if status, _ := run.StepStatus("deploy"); status != string(types.StepStatusPending) {
+		t.Fatalf("deploy status = %s before the event, want pending", status)
+	}
+
+	stdout, stderr, err := runMeow(h, "event", "--workflow", run.ID, "approve-deploy", "--data", "approver=alice")
+	if err != nil {
+		t.Fatalf("meow event failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	if err := proc.WaitWithTimeout(10 * time.Second); err != nil {
+		t.Fatalf("orchestrator failed: %v\nstderr: %s", err, proc.Stderr())
+	}
+	if err := run.AssertWorkflowDone(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run.AssertStepOutputEquals("deploy", "result", "deployed by alice"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestE2E_RunWorkflowAsync_InterruptRunsCleanup tests that SIGINT sent to a
+// live workflow stops it and runs its cleanup_on_stop script.
+func TestE2E_RunWorkflowAsync_InterruptRunsCleanup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "interrupted"
+cleanup_on_stop = "touch cleanup-ran"
+
+[[main.steps]]
+id = "work"
+executor = "shell"
+command = "sleep 30"
+`
+	if err := h.WriteTemplate("interrupted.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	proc, err := h.RunWorkflowAsync(filepath.Join(h.TemplateDir, "interrupted.toml"))
+	if err != nil {
+		t.Fatalf("RunWorkflowAsync() error = %v", err)
+	}
+	run, err := proc.WaitForRun(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.WaitForStepStatus("work", types.StepStatusRunning, 5*time.Second); err != nil {
+		t.Fatalf("WaitForStepStatus(running) error = %v", err)
+	}
+
+	if err := proc.Signal(os.Interrupt); err != nil {
+		t.Fatalf("Signal() error = %v", err)
+	}
+	if err := proc.WaitWithTimeout(15 * time.Second); err != nil {
+		t.Fatalf("orchestrator did not exit cleanly after SIGINT: %v\nstderr: %s", err, proc.Stderr())
+	}
+
+	wf, err := run.Workflow()
+	if err != nil {
+		t.Fatalf("loading run: %v", err)
+	}
+	if wf.Status != types.RunStatusStopped {
+		t.Errorf("workflow status = %s, want stopped", wf.Status)
+	}
+	if _, err := os.Stat(filepath.Join(h.TempDir, "cleanup-ran")); err != nil {
+		t.Errorf("cleanup_on_stop did not run: %v", err)
+	}
+}
+
+// TestE2E_ResolvedRun tests that meow resolved shows foreach children and
+// commands with their step output references substituted.
+func TestE2E_ResolvedRun(t *testing.T) {
+	h := e2e.NewHarness(t)
+
+	template := `
+[main]
+name = "resolved-run"
+
+[[main.steps]]
+id = "setup"
+executor = "shell"
+command = "echo build-dir"
+
+[main.steps.shell_outputs]
+dir = { source = "stdout" }
+
+[[main.steps]]
+id = "check"
+executor = "foreach"
+needs = ["setup"]
+items = '["alpha", "beta"]'
+template = ".check-item"
+item_var = "pkg"
+
+[[main.steps]]
+id = "report"
+executor = "shell"
+needs = ["check"]
+command = "echo checked in {{setup.outputs.dir}}"
+
+[".check-item"]
+[[".check-item".steps]]
+id = "run"
+executor = "shell"
+command = "echo checking {{pkg}}"
+`
+	if err := h.WriteTemplate("resolved-run.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "resolved-run.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+	resolved, err := run.Resolved()
+	if err != nil {
+		t.Fatalf("resolving run: %v", err)
+	}
+
+	// Dispatched shell steps are stored as branches; their command is the condition
+	commands := make(map[string]string)
+	for _, step := range resolved.Steps {
+		if step.Branch != nil {
+			commands[step.ID] = step.Branch.Condition
+		}
+	}
+	want := map[string]string{
+		"setup":       "echo build-dir",
+		"check.0.run": "echo checking alpha",
+		"check.1.run": "echo checking beta",
+		"report":      "echo checked in build-dir",
+	}
+	for id, command := range want {
+		if commands[id] != command {
+			t.Errorf("%s command = %q, want %q", id, commands[id], command)
+		}
+	}
+}
+
 // TestE2E_StepStatusCheck_NonExistentStepWithDoneInName tests that checking
 // a non-existent step with "done" in its name correctly reports NOT done.
 //
@@ -4458,3 +6084,72 @@ fi
 	t.Logf("✓ __step_prefix__ works correctly in foreach iterations")
 	t.Logf("  Results:\n%s", resultsStr)
 }
+
+// TestE2E_ToolEventSequenceReachesGate tests that tool events an agent emits
+// while working are routed to a gate waiting for one of them, and that the
+// events nobody waits for do not disturb the agent's step.
+func TestE2E_ToolEventSequenceReachesGate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	h := e2e.NewHarness(t)
+
+	simConfig := e2e.NewSimConfigBuilder().
+		WithToolEventSequence("implement", []string{"file-edited", "tests-run"}, 200*time.Millisecond).
+		Build()
+	if err := h.WriteSimConfig(simConfig); err != nil {
+		t.Fatalf("failed to write sim config: %v", err)
+	}
+
+	template := `
+[main]
+name = "tool-events"
+
+[[main.steps]]
+id = "tests-seen"
+executor = "gate"
+wait_for_event = "tests-run"
+timeout = "20s"
+
+[[main.steps]]
+id = "spawn"
+executor = "spawn"
+agent = "worker"
+
+[[main.steps]]
+id = "implement"
+executor = "agent"
+agent = "worker"
+needs = ["spawn"]
+prompt = "implement the feature"
+
+[[main.steps]]
+id = "cleanup"
+executor = "kill"
+agent = "worker"
+needs = ["implement", "tests-seen"]
+`
+	if err := h.WriteTemplate("tool-events.toml", template); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	stdout, stderr, err := runMeow(h, "run", filepath.Join(h.TemplateDir, "tool-events.toml"))
+	if err != nil {
+		t.Fatalf("meow run failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	runFiles, _ := filepath.Glob(filepath.Join(h.RunsDir, "*.yaml"))
+	if len(runFiles) != 1 {
+		t.Fatalf("expected 1 run state file, found %d", len(runFiles))
+	}
+	run := e2e.WorkflowRunFromID(h, strings.TrimSuffix(filepath.Base(runFiles[0]), ".yaml"))
+	if err := run.AssertWorkflowDone(); err != nil {
+		t.Fatalf("%v\nstderr: %s", err, stderr)
+	}
+	for _, id := range []string{"tests-seen", "implement"} {
+		if status, _ := run.StepStatus(id); status != string(types.StepStatusDone) {
+			t.Errorf("%s status = %s, want done", id, status)
+		}
+	}
+}
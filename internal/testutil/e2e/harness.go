@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -34,6 +35,9 @@ type Harness struct {
 	// LogsDir is where per-run log files are stored.
 	LogsDir string
 
+	// ArtifactsDir is where per-run artifacts (outputs marked artifact = true) are stored.
+	ArtifactsDir string
+
 	// TemplateDir is where workflow templates are stored.
 	TemplateDir string
 
@@ -51,6 +55,12 @@ type Harness struct {
 
 	// cleanupFuncs are called on Cleanup().
 	cleanupFuncs []func()
+
+	// logMu guards logBuffers.
+	logMu sync.Mutex
+
+	// logBuffers hold the stderr of meow commands, read by OrchestratorLogs.
+	logBuffers []*syncBuffer
 }
 
 // NewHarness creates a new test harness with isolated directories.
@@ -65,6 +75,7 @@ func NewHarness(t *testing.T) *Harness {
 		TmuxSocket:    tmuxSocket,
 		RunsDir:       filepath.Join(tempDir, ".meow", "runs"),
 		LogsDir:       filepath.Join(tempDir, ".meow", "logs"),
+		ArtifactsDir:  filepath.Join(tempDir, ".meow", "artifacts"),
 		TemplateDir:   filepath.Join(tempDir, ".meow", "workflows"),
 		AdapterDir:    filepath.Join(tempDir, ".meow", "adapters"),
 		SimConfigPath: filepath.Join(tempDir, "sim-config.yaml"),
@@ -122,6 +133,7 @@ wait = "200ms"
 workflow_dir = ".meow/workflows"
 runs_dir = ".meow/runs"
 logs_dir = ".meow/logs"
+artifacts_dir = ".meow/artifacts"
 
 [agent]
 default_adapter = "claude"
@@ -146,6 +158,7 @@ func (h *Harness) createConfig() *config.Config {
 	cfg.Paths.WorkflowDir = h.TemplateDir
 	cfg.Paths.RunsDir = h.RunsDir
 	cfg.Paths.LogsDir = h.LogsDir
+	cfg.Paths.ArtifactsDir = h.ArtifactsDir
 	cfg.Agent.DefaultAdapter = "claude"
 	cfg.Logging.Level = config.LogLevelDebug
 	return cfg
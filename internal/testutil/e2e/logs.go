@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LogWriter returns a writer to use as a meow command's stderr so that the
+// orchestrator's log records are included in OrchestratorLogs. Processes
+// started with StartOrchestrator are captured automatically.
+func (h *Harness) LogWriter() io.Writer {
+	return h.newLogBuffer()
+}
+
+// newLogBuffer creates a stderr buffer read by OrchestratorLogs.
+func (h *Harness) newLogBuffer() *syncBuffer {
+	buf := &syncBuffer{}
+	h.logMu.Lock()
+	h.logBuffers = append(h.logBuffers, buf)
+	h.logMu.Unlock()
+	return buf
+}
+
+// OrchestratorLogs parses the slog records written to stderr by the meow
+// commands this harness captured, in the order the commands were started.
+// meow run logs in slog's text format, whose values are all parsed as
+// strings; JSON records keep their JSON types. Lines that are neither (CLI
+// messages, panics) are skipped.
+func (h *Harness) OrchestratorLogs() []map[string]any {
+	h.logMu.Lock()
+	buffers := append([]*syncBuffer(nil), h.logBuffers...)
+	h.logMu.Unlock()
+
+	var records []map[string]any
+	for _, buf := range buffers {
+		scanner := bufio.NewScanner(strings.NewReader(buf.String()))
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			if record := parseLogRecord(scanner.Text()); record != nil {
+				records = append(records, record)
+			}
+		}
+	}
+	return records
+}
+
+// parseLogRecord parses one slog JSON or text line, or returns nil if the
+// line is not a log record.
+func parseLogRecord(line string) map[string]any {
+	if strings.HasPrefix(line, "{") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil
+		}
+		return record
+	}
+	if !strings.HasPrefix(line, "time=") {
+		return nil
+	}
+	record := make(map[string]any)
+	for rest := line; rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok || key == "" {
+			return nil
+		}
+		if strings.HasPrefix(value, `"`) {
+			quoted, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return nil
+			}
+			unquoted, _ := strconv.Unquote(quoted)
+			record[key] = unquoted
+			value = value[len(quoted):]
+		} else {
+			end := strings.IndexByte(value, ' ')
+			if end < 0 {
+				end = len(value)
+			}
+			record[key] = value[:end]
+			value = value[end:]
+		}
+		rest = strings.TrimPrefix(value, " ")
+	}
+	return record
+}
+
+// LogsMatching returns the orchestrator log records for which match returns
+// true, e.g. to find the dispatch of one step:
+//
+//	h.LogsMatching(func(r map[string]any) bool {
+//	    return r["msg"] == "dispatching step" && r["id"] == "monitor.got-event"
+//	})
+func (h *Harness) LogsMatching(match func(record map[string]any) bool) []map[string]any {
+	var matched []map[string]any
+	for _, record := range h.OrchestratorLogs() {
+		if match(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,46 @@
+package e2e
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLogRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want map[string]any
+	}{
+		{
+			name: "text",
+			line: `time=2026-01-02T15:04:05.000Z level=INFO msg="dispatching step" id=monitor.got-event executor=shell`,
+			want: map[string]any{
+				"time": "2026-01-02T15:04:05.000Z", "level": "INFO", "msg": "dispatching step",
+				"id": "monitor.got-event", "executor": "shell",
+			},
+		},
+		{
+			name: "text with escaped quotes",
+			line: `time=2026-01-02T15:04:05.000Z level=WARN msg="step failed" error="exit \"1\"" empty=""`,
+			want: map[string]any{
+				"time": "2026-01-02T15:04:05.000Z", "level": "WARN", "msg": "step failed",
+				"error": `exit "1"`, "empty": "",
+			},
+		},
+		{
+			name: "json",
+			line: `{"time":"2026-01-02T15:04:05Z","level":"INFO","msg":"dispatching step","attempt":2}`,
+			want: map[string]any{"time": "2026-01-02T15:04:05Z", "level": "INFO", "msg": "dispatching step", "attempt": float64(2)},
+		},
+		{name: "cli message", line: "Workflow ID: run-123", want: nil},
+		{name: "unterminated quote", line: `time=x msg="oops`, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLogRecord(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLogRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
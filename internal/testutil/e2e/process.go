@@ -31,8 +31,16 @@ func (b *syncBuffer) String() string {
 	return b.buf.String()
 }
 
-// OrchestratorProcess represents a running orchestrator process.
-// It provides methods to control and observe the orchestrator for crash testing.
+// OrchestratorProcess represents a running orchestrator process, as returned
+// by RunWorkflowAsync, StartOrchestrator, and RestartOrchestrator. The handle
+// controls and observes the process while the workflow is live:
+//
+//   - WaitForRun, WorkflowID, ExtractWorkflowID: identify the workflow it runs
+//   - Signal, Kill: interrupt it (e.g., SIGINT to test cleanup) or crash it
+//   - Wait, WaitWithTimeout, IsDone: wait for it to exit
+//   - Stdout, Stderr, PID: inspect its output and process
+//
+// The process is killed when the test ends if it is still running.
 type OrchestratorProcess struct {
 	cmd    *exec.Cmd
 	pid    int
@@ -116,9 +124,17 @@ func (p *OrchestratorProcess) WorkflowID() string {
 	return p.workflowID
 }
 
-// ExtractWorkflowID attempts to extract the workflow ID from stderr.
-// Call this after the workflow has started.
+// workflowIDPattern matches the workflow ID meow run prints on startup.
+var workflowIDPattern = regexp.MustCompile(`Workflow ID: (\S+)`)
+
+// ExtractWorkflowID attempts to extract the workflow ID from the process
+// output. Call this after the workflow has started.
 func (p *OrchestratorProcess) ExtractWorkflowID() string {
+	if matches := workflowIDPattern.FindStringSubmatch(p.stdout.String()); matches != nil {
+		p.workflowID = matches[1]
+		return p.workflowID
+	}
+
 	// Look for pattern like "workflow_id=wf-xxx" or "Workflow: wf-xxx"
 	patterns := []string{
 		`workflow[_-]?id[=: ]+([a-zA-Z0-9_-]+)`,
@@ -144,6 +160,42 @@ func (p *OrchestratorProcess) ExtractWorkflowID() string {
 	return ""
 }
 
+// WaitForRun waits until the process reports the workflow it runs and returns
+// a WorkflowRun observing it. Fails if the process exits first or the timeout
+// passes.
+func (p *OrchestratorProcess) WaitForRun(timeout time.Duration) (*WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if id := p.ExtractWorkflowID(); id != "" {
+			return WorkflowRunFromID(p.harness, id), nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout waiting for workflow to start\nstderr: %s", p.Stderr())
+		case <-p.exited:
+			if id := p.ExtractWorkflowID(); id != "" {
+				return WorkflowRunFromID(p.harness, id), nil
+			}
+			return nil, fmt.Errorf("process exited before starting a workflow: %v\nstderr: %s", p.exitErr, p.Stderr())
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunWorkflowAsync starts meow run for a template in the background and
+// returns at once with a handle on the orchestrator process, so the test can
+// act on the workflow while it is live: send it signals, inject events, or
+// crash it. template is passed to meow run: a path, or the name of a template
+// written with WriteTemplate. Use WaitForRun to observe the workflow.
+func (h *Harness) RunWorkflowAsync(template string) (*OrchestratorProcess, error) {
+	return h.StartOrchestrator("run", template)
+}
+
 // StartOrchestrator starts the meow orchestrator in the background.
 // The process runs until completion, crash, or explicit kill.
 func (h *Harness) StartOrchestrator(args ...string) (*OrchestratorProcess, error) {
@@ -159,7 +211,7 @@ func (h *Harness) StartOrchestrator(args ...string) (*OrchestratorProcess, error
 	cmd.Env = h.Env()
 
 	stdout := &syncBuffer{}
-	stderr := &syncBuffer{}
+	stderr := h.newLogBuffer()
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
@@ -238,6 +290,5 @@ func (h *Harness) StartOrchestratorWithTemplate(templateName, templateContent st
 		return nil, fmt.Errorf("writing template: %w", err)
 	}
 
-	// Start orchestrator
-	return h.StartOrchestrator("run", templateName)
+	return h.RunWorkflowAsync(templateName)
 }
@@ -28,8 +28,9 @@ type TimingConfig struct {
 
 // HooksConfig controls simulator hook behavior.
 type HooksConfig struct {
-	FireStopHook   bool `yaml:"fire_stop_hook"`
-	FireToolEvents bool `yaml:"fire_tool_events"`
+	FireStopHook       bool `yaml:"fire_stop_hook"`
+	FireToolEvents     bool `yaml:"fire_tool_events"`
+	FirePromptReceived bool `yaml:"fire_prompt_received"`
 }
 
 // DefaultConfig provides default behavior settings.
@@ -45,9 +46,11 @@ type LoggingConfig struct {
 
 // Behavior defines how the simulator responds to a prompt pattern.
 type Behavior struct {
-	Match  string `yaml:"match"`
-	Type   string `yaml:"type"` // "contains" or "regex"
-	Action Action `yaml:"action"`
+	Match          string        `yaml:"match"`
+	Type           string        `yaml:"type"` // "contains" or "regex"
+	Action         Action        `yaml:"action"`
+	AckDelay       time.Duration `yaml:"ack_delay"`       // Wait before firing prompt-received
+	SwallowPrompts int           `yaml:"swallow_prompts"` // Ignore the first N matching prompts entirely
 }
 
 // ActionType defines what the simulator does when a prompt matches.
@@ -60,6 +63,9 @@ const (
 	ActionFailThenSucceed ActionType = "fail_then_succeed"
 	ActionHang            ActionType = "hang"
 	ActionCrash           ActionType = "crash"
+	ActionExit            ActionType = "exit"
+	ActionDelayCrash      ActionType = "delay_then_crash"
+	ActionError           ActionType = "error"
 )
 
 // Action defines the simulator's response action.
@@ -71,8 +77,11 @@ type Action struct {
 	Events          []EventDef       `yaml:"events"`
 	Question        string           `yaml:"question"`
 	FailCount       int              `yaml:"fail_count"`
-	FailMessage     string           `yaml:"fail_message"`
+	FailMessage     string           `yaml:"fail_message"` // For fail and error: the error reported
 	ExitCode        int              `yaml:"exit_code"`
+	ResultFile      string           `yaml:"result_file"` // For exit: write outputs here as JSON before exiting
+	CrashAfter      time.Duration    `yaml:"crash_after"` // For delay_then_crash: how long to work before crashing
+	Log             map[string]any   `yaml:"log"`         // Fields logged as one structured (JSON) log line before the final action
 }
 
 // EventDef defines a tool event to emit.
@@ -130,8 +139,10 @@ func (b *SimConfigBuilder) WithBehavior(pattern string, action ActionType) *SimC
 	return b
 }
 
-// WithRegexBehavior adds a behavior that matches prompts using a regex pattern.
-func (b *SimConfigBuilder) WithRegexBehavior(pattern string, action ActionType) *SimConfigBuilder {
+// WithBehaviorRegex adds a behavior that matches prompts against a regular
+// expression, for prompts with interpolated text such as step IDs. The
+// simulator refuses to start if the pattern does not compile.
+func (b *SimConfigBuilder) WithBehaviorRegex(pattern string, action ActionType) *SimConfigBuilder {
 	behavior := Behavior{
 		Match: pattern,
 		Type:  "regex",
@@ -201,6 +212,70 @@ func (b *SimConfigBuilder) WithToolEvents(enabled bool) *SimConfigBuilder {
 	return b
 }
 
+// WithToolEventSequence makes the simulator emit the named tool events, one
+// every interval, before acting on prompts matching the pattern. It applies to
+// the behavior already added for the pattern, or adds one that completes.
+// Enables tool events.
+func (b *SimConfigBuilder) WithToolEventSequence(match string, events []string, interval time.Duration) *SimConfigBuilder {
+	behavior := b.behaviorFor(match)
+	for i, event := range events {
+		behavior.Action.Events = append(behavior.Action.Events, EventDef{
+			Type: event,
+			When: time.Duration(i+1) * interval,
+		})
+	}
+	b.config.Hooks.FireToolEvents = true
+	return b
+}
+
+// WithPromptReceivedHook enables or disables the prompt-received event the
+// simulator fires when it accepts a prompt.
+func (b *SimConfigBuilder) WithPromptReceivedHook(enabled bool) *SimConfigBuilder {
+	b.config.Hooks.FirePromptReceived = enabled
+	return b
+}
+
+// WithAckDelay delays the prompt-received event for prompts matching the
+// pattern, for exercising prompt acknowledgment timeouts. It applies to the
+// behavior already added for the pattern, or adds one that completes.
+// Enables the prompt-received hook.
+func (b *SimConfigBuilder) WithAckDelay(match string, delay time.Duration) *SimConfigBuilder {
+	b.behaviorFor(match).AckDelay = delay
+	b.config.Hooks.FirePromptReceived = true
+	return b
+}
+
+// WithSwallowPrompts makes the simulator ignore the first n prompts matching
+// the pattern, without acknowledging or acting on them, as if they never
+// reached the agent. It applies to the behavior already added for the
+// pattern, or adds one that completes. Enables the prompt-received hook.
+func (b *SimConfigBuilder) WithSwallowPrompts(match string, n int) *SimConfigBuilder {
+	b.behaviorFor(match).SwallowPrompts = n
+	b.config.Hooks.FirePromptReceived = true
+	return b
+}
+
+// WithLogFields makes the simulator log the fields as one structured (JSON)
+// line on stderr before acting on prompts matching the pattern, like an agent
+// reporting its work in its logs. It applies to the behavior already added
+// for the pattern, or adds one that completes.
+func (b *SimConfigBuilder) WithLogFields(match string, fields map[string]any) *SimConfigBuilder {
+	b.behaviorFor(match).Action.Log = fields
+	return b
+}
+
+// behaviorFor returns the behavior added for match, adding a completing one
+// if there is none.
+func (b *SimConfigBuilder) behaviorFor(match string) *Behavior {
+	for i := range b.config.Behaviors {
+		if b.config.Behaviors[i].Match == match {
+			return &b.config.Behaviors[i]
+		}
+	}
+	b.WithBehavior(match, ActionComplete)
+	return &b.config.Behaviors[len(b.config.Behaviors)-1]
+}
+
 // WithLogLevel sets the logging level.
 func (b *SimConfigBuilder) WithLogLevel(level string) *SimConfigBuilder {
 	b.config.Logging.Level = level
@@ -252,6 +327,59 @@ func (b *SimConfigBuilder) WithCrashBehavior(pattern string, exitCode int) *SimC
 	return b
 }
 
+// WithBehaviorError adds a behavior that reports the step as failed with the
+// given message, as an agent calling `meow done --error` does. The
+// orchestrator fails the step, so retries and on_error apply.
+func (b *SimConfigBuilder) WithBehaviorError(match string, message string) *SimConfigBuilder {
+	behavior := Behavior{
+		Match: match,
+		Type:  "contains",
+		Action: Action{
+			Type:        ActionError,
+			FailMessage: message,
+		},
+	}
+	b.config.Behaviors = append(b.config.Behaviors, behavior)
+	return b
+}
+
+// WithDelayedCrashBehavior adds a behavior where the simulator starts working
+// on the prompt, emits the given tool events, and crashes with the specified
+// exit code once crashAfter has elapsed, like an agent dying mid-task.
+func (b *SimConfigBuilder) WithDelayedCrashBehavior(pattern string, crashAfter time.Duration, exitCode int, events ...EventDef) *SimConfigBuilder {
+	behavior := Behavior{
+		Match: pattern,
+		Type:  "contains",
+		Action: Action{
+			Type:       ActionDelayCrash,
+			CrashAfter: crashAfter,
+			ExitCode:   exitCode,
+			Events:     events,
+		},
+	}
+	b.config.Behaviors = append(b.config.Behaviors, behavior)
+	return b
+}
+
+// WithExitBehavior adds a behavior that makes the simulator exit with the
+// specified exit code without calling meow done, like an agent that reports
+// its outcome through its exit status. If resultFile is set, outputs are
+// written there as JSON first.
+func (b *SimConfigBuilder) WithExitBehavior(pattern string, exitCode int, resultFile string, outputs map[string]any) *SimConfigBuilder {
+	behavior := Behavior{
+		Match: pattern,
+		Type:  "contains",
+		Action: Action{
+			Type:       ActionExit,
+			ExitCode:   exitCode,
+			ResultFile: resultFile,
+			Outputs:    outputs,
+		},
+	}
+	b.config.Behaviors = append(b.config.Behaviors, behavior)
+	return b
+}
+
 // WithDefaultCrash sets the default action to crash with the specified exit code.
 // All prompts that don't match a specific behavior will cause a crash.
 func (b *SimConfigBuilder) WithDefaultCrash(exitCode int) *SimConfigBuilder {
@@ -2,12 +2,24 @@ package e2e
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/akatz-ai/meow/internal/ipc"
+	"github.com/akatz-ai/meow/internal/orchestrator"
 	"github.com/akatz-ai/meow/internal/types"
 )
 
@@ -25,6 +37,10 @@ type WorkflowRun struct {
 
 	// cancel can be called to stop the workflow.
 	cancel context.CancelFunc
+
+	// eventsSeen counts the events of each type WaitForEvent has returned.
+	eventsMu   sync.Mutex
+	eventsSeen map[string]int
 }
 
 // WaitForStep waits for a step to reach the given status.
@@ -63,6 +79,39 @@ func (r *WorkflowRun) WaitForStep(stepID string, status string, timeout time.Dur
 	}
 }
 
+// WaitForStepStatus waits for a step to reach exactly the given status, which
+// may be transient (running, completing). Unlike WaitForStep it does not give
+// up early when the step reaches another terminal status, since a retried step
+// leaves failed again. On timeout the error reports the last status seen.
+func (r *WorkflowRun) WaitForStepStatus(stepID string, status types.StepStatus, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	lastSeen := "step not found"
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for step %s to reach status %s (last seen: %s)", stepID, status, lastSeen)
+		case <-ticker.C:
+			wf, err := r.loadWorkflow()
+			if err != nil {
+				continue // Workflow file may not exist yet
+			}
+			step, ok := wf.GetStep(stepID)
+			if !ok {
+				continue // Step may not exist yet
+			}
+			if step.Status == status {
+				return nil
+			}
+			lastSeen = string(step.Status)
+		}
+	}
+}
+
 // WaitForDone waits for the workflow to complete (done or failed).
 func (r *WorkflowRun) WaitForDone(timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -158,7 +207,83 @@ func (r *WorkflowRun) StepOutput(stepID, key string) (any, error) {
 	return val, nil
 }
 
-// StepOutputs returns all outputs from a step.
+// StepOutputString returns a string output of a step with surrounding
+// whitespace trimmed, so captured stdout compares without its trailing
+// newline. Field may be a nested path as in {{step.outputs.field}}.
+func (r *WorkflowRun) StepOutputString(stepID, field string) (string, error) {
+	val, err := r.stepOutputValue(stepID, field)
+	if err != nil {
+		return "", err
+	}
+	s, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("output %s of step %s is %T (%v), not a string", field, stepID, val, val)
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// StepOutputInt returns an integer output of a step. Numeric outputs and
+// strings holding an integer (such as captured stdout) are accepted.
+func (r *WorkflowRun) StepOutputInt(stepID, field string) (int, error) {
+	val, err := r.stepOutputValue(stepID, field)
+	if err != nil {
+		return 0, err
+	}
+	switch v := val.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("output %s of step %s is %T (%v), not an integer", field, stepID, val, val)
+}
+
+// StepOutputBool returns a boolean output of a step. Strings such as "true"
+// or "0" (see strconv.ParseBool) are accepted.
+func (r *WorkflowRun) StepOutputBool(stepID, field string) (bool, error) {
+	val, err := r.stepOutputValue(stepID, field)
+	if err != nil {
+		return false, err
+	}
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("output %s of step %s is %T (%v), not a boolean", field, stepID, val, val)
+}
+
+// stepOutputValue returns the value at a possibly nested field of a step's
+// outputs.
+func (r *WorkflowRun) stepOutputValue(stepID, field string) (any, error) {
+	wf, err := r.loadWorkflow()
+	if err != nil {
+		return nil, err
+	}
+	step, ok := wf.GetStep(stepID)
+	if !ok {
+		return nil, fmt.Errorf("step %s not found", stepID)
+	}
+	val, ok := orchestrator.OutputValue(step.Outputs, field)
+	if !ok {
+		return nil, fmt.Errorf("output %s not found in step %s", field, stepID)
+	}
+	return val, nil
+}
+
+// StepOutputs returns a copy of all outputs from a step, empty if the step
+// has produced none yet.
 func (r *WorkflowRun) StepOutputs(stepID string) (map[string]any, error) {
 	wf, err := r.loadWorkflow()
 	if err != nil {
@@ -168,7 +293,41 @@ func (r *WorkflowRun) StepOutputs(stepID string) (map[string]any, error) {
 	if !ok {
 		return nil, fmt.Errorf("step %s not found", stepID)
 	}
-	return step.Outputs, nil
+	outputs := make(map[string]any, len(step.Outputs))
+	maps.Copy(outputs, step.Outputs)
+	return outputs, nil
+}
+
+// DumpSteps logs every step's ID, status, outputs and error via t.Log, in
+// step ID order. Call it when an assertion fails to see what each step
+// actually produced.
+func (r *WorkflowRun) DumpSteps() {
+	t := r.harness.t
+	t.Helper()
+	wf, err := r.loadWorkflow()
+	if err != nil {
+		t.Logf("run %s: %v", r.ID, err)
+		return
+	}
+	t.Logf("run %s: %s", r.ID, wf.Status)
+	for _, id := range slices.Sorted(maps.Keys(wf.Steps)) {
+		step := wf.Steps[id]
+		outputs, _ := json.Marshal(step.Outputs)
+		line := fmt.Sprintf("  %s [%s] outputs=%s", id, step.Status, outputs)
+		if step.Error != nil {
+			line += fmt.Sprintf(" error=%s", step.Error.Message)
+		}
+		t.Log(line)
+	}
+}
+
+// WorkflowOutputs returns the workflow-level outputs resolved at completion.
+func (r *WorkflowRun) WorkflowOutputs() (map[string]any, error) {
+	wf, err := r.loadWorkflow()
+	if err != nil {
+		return nil, err
+	}
+	return wf.Outputs, nil
 }
 
 // StepError returns the error from a failed step.
@@ -184,11 +343,63 @@ func (r *WorkflowRun) StepError(stepID string) (*types.StepError, error) {
 	return step.Error, nil
 }
 
+// StepErrorType returns the error type (e.g. "timeout") recorded on a step.
+// Returns an error if the step does not exist or has no recorded error.
+func (r *WorkflowRun) StepErrorType(stepID string) (string, error) {
+	stepErr, err := r.StepError(stepID)
+	if err != nil {
+		return "", err
+	}
+	if stepErr == nil {
+		return "", fmt.Errorf("step %s has no error", stepID)
+	}
+	return string(stepErr.Type), nil
+}
+
+// Artifacts returns the run's artifact manifest from <ArtifactsDir>/<run-id>.
+func (r *WorkflowRun) Artifacts() (*types.ArtifactManifest, error) {
+	data, err := os.ReadFile(filepath.Join(r.ArtifactsDir(), types.ArtifactManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading artifact manifest: %w", err)
+	}
+	var manifest types.ArtifactManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing artifact manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// ArtifactsDir returns the directory holding this run's artifacts.
+func (r *WorkflowRun) ArtifactsDir() string {
+	return filepath.Join(r.harness.ArtifactsDir, r.ID)
+}
+
 // Workflow returns the current workflow state.
 func (r *WorkflowRun) Workflow() (*types.Run, error) {
 	return r.loadWorkflow()
 }
 
+// Resolved runs 'meow resolved' for this run and returns its step graph,
+// with step output references substituted as far as they can be.
+func (r *WorkflowRun) Resolved() (*orchestrator.ResolvedRun, error) {
+	meowBin, err := r.harness.findMeowBinary()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(meowBin, "resolved", r.ID)
+	cmd.Dir = r.harness.TempDir
+	cmd.Env = r.harness.Env()
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("meow resolved: %w", err)
+	}
+	var resolved orchestrator.ResolvedRun
+	if err := yaml.Unmarshal(out, &resolved); err != nil {
+		return nil, fmt.Errorf("parsing resolved run: %w", err)
+	}
+	return &resolved, nil
+}
+
 // loadWorkflow loads the workflow from state.
 func (r *WorkflowRun) loadWorkflow() (*types.Run, error) {
 	return r.harness.LoadWorkflow(r.ID)
@@ -231,6 +442,84 @@ func (r *WorkflowRun) AssertStepFailed(stepID string) error {
 	return nil
 }
 
+// AssertStepError asserts that a step has a recorded error whose message
+// contains wantSubstring.
+func (r *WorkflowRun) AssertStepError(stepID string, wantSubstring string) error {
+	stepErr, err := r.StepError(stepID)
+	if err != nil {
+		return err
+	}
+	if stepErr == nil {
+		return fmt.Errorf("step %s has no error, expected one containing %q", stepID, wantSubstring)
+	}
+	if !strings.Contains(stepErr.Message, wantSubstring) {
+		return fmt.Errorf("step %s error %q does not contain %q", stepID, stepErr.Message, wantSubstring)
+	}
+	return nil
+}
+
+// AssertStepOutputContains asserts that a step output contains substring.
+// Field may be a nested path as in {{step.outputs.field}}. Values other than
+// strings are matched in their printed (%v) form.
+func (r *WorkflowRun) AssertStepOutputContains(stepID, field, substring string) error {
+	val, err := r.stepOutputValue(stepID, field)
+	if err != nil {
+		return err
+	}
+	s, ok := val.(string)
+	if !ok {
+		s = fmt.Sprintf("%v", val)
+	}
+	if !strings.Contains(s, substring) {
+		return fmt.Errorf("output %s of step %s is %q, expected it to contain %q", field, stepID, s, substring)
+	}
+	return nil
+}
+
+// AssertStepOutputEquals asserts that a step output equals want. Field may be
+// a nested path as in {{step.outputs.field}}. Numbers compare by value at any
+// depth, since saved outputs may come back as another numeric type (42
+// matches 42.0); everything else must match exactly.
+func (r *WorkflowRun) AssertStepOutputEquals(stepID, field string, want any) error {
+	val, err := r.stepOutputValue(stepID, field)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(normalizeNumbers(val), normalizeNumbers(want)) {
+		return fmt.Errorf("output %s of step %s is %T (%v), expected %T (%v)", field, stepID, val, val, want, want)
+	}
+	return nil
+}
+
+// normalizeNumbers returns v with every number, including those nested in
+// maps and slices, converted to float64.
+func normalizeNumbers(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			m[k] = normalizeNumbers(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, e := range x {
+			s[i] = normalizeNumbers(e)
+		}
+		return s
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return v
+}
+
 // AssertWorkflowDone asserts that the workflow completed successfully.
 func (r *WorkflowRun) AssertWorkflowDone() error {
 	status := r.Status()
@@ -249,6 +538,74 @@ func (r *WorkflowRun) AssertWorkflowFailed() error {
 	return nil
 }
 
+// eventLogMessages are the orchestrator log records announcing an event: one
+// sent by an agent or meow event, and the prompt-swallowed event the
+// orchestrator raises itself.
+var eventLogMessages = []string{
+	"handling event",
+	"recovery exhausted: emitting prompt-swallowed event",
+}
+
+// WaitForEvent waits until the run's orchestrator has received an event of the
+// given type, such as agent-stopped, prompt-received or prompt-swallowed, and
+// returns it. Successive calls for a type return successive events, so earlier
+// events are never missed. Events are read from the orchestrator's log records
+// (see OrchestratorLogs), before any filtering, so they include agent-stopped
+// events the orchestrator then ignores. The records carry no event data, so
+// Data is nil.
+func (r *WorkflowRun) WaitForEvent(eventType string, timeout time.Duration) (*ipc.EventMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		records := r.harness.LogsMatching(func(record map[string]any) bool {
+			msg, _ := record["msg"].(string)
+			return slices.Contains(eventLogMessages, msg) &&
+				record["event_type"] == eventType && record["workflow"] == r.ID
+		})
+		if record, ok := r.nextEvent(eventType, records); ok {
+			event := &ipc.EventMessage{Type: ipc.MsgEvent, EventType: eventType, Workflow: r.ID}
+			event.Agent, _ = record["agent"].(string)
+			if ts, ok := record["time"].(string); ok {
+				if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+					event.Timestamp = parsed.Unix()
+				}
+			}
+			return event, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout waiting for event %s", eventType)
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForAgentStopped waits for the next agent-stopped event, sent by an
+// agent's stop hook when it goes idle.
+func (r *WorkflowRun) WaitForAgentStopped(timeout time.Duration) (*ipc.EventMessage, error) {
+	return r.WaitForEvent("agent-stopped", timeout)
+}
+
+// nextEvent claims the first of records not yet returned by WaitForEvent.
+func (r *WorkflowRun) nextEvent(eventType string, records []map[string]any) (map[string]any, bool) {
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+	if r.eventsSeen == nil {
+		r.eventsSeen = make(map[string]int)
+	}
+	n := r.eventsSeen[eventType]
+	if len(records) <= n {
+		return nil, false
+	}
+	r.eventsSeen[eventType] = n + 1
+	return records[n], true
+}
+
 // WorkflowRunFromID creates a WorkflowRun from an existing workflow ID.
 // Useful for attaching to workflows started by other means.
 func WorkflowRunFromID(h *Harness, id string) *WorkflowRun {
@@ -0,0 +1,128 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akatz-ai/meow/internal/types"
+)
+
+func TestWorkflowRun_TypedStepOutputs(t *testing.T) {
+	h := &Harness{t: t, RunsDir: t.TempDir()}
+	wf := types.NewRun("run-typed", "test-template", nil)
+	wf.Steps["build"] = &types.Step{
+		ID:     "build",
+		Status: types.StepStatusDone,
+		Outputs: map[string]any{
+			"stdout":  "1.2.3\n",
+			"count":   " 42\n",
+			"passed":  "true\n",
+			"skipped": false,
+			"report":  map[string]any{"count": 7, "items": []any{map[string]any{"ok": true}}},
+			"ratio":   0.5,
+		},
+	}
+	if err := h.SaveWorkflow(wf); err != nil {
+		t.Fatalf("SaveWorkflow() error = %v", err)
+	}
+	run := &WorkflowRun{ID: wf.ID, harness: h}
+
+	if got, err := run.StepOutputString("build", "stdout"); err != nil || got != "1.2.3" {
+		t.Errorf("StepOutputString(stdout) = %q, %v; want 1.2.3", got, err)
+	}
+	if got, err := run.StepOutputInt("build", "count"); err != nil || got != 42 {
+		t.Errorf("StepOutputInt(count) = %d, %v; want 42", got, err)
+	}
+	if got, err := run.StepOutputInt("build", "report.count"); err != nil || got != 7 {
+		t.Errorf("StepOutputInt(report.count) = %d, %v; want 7", got, err)
+	}
+	if got, err := run.StepOutputBool("build", "passed"); err != nil || !got {
+		t.Errorf("StepOutputBool(passed) = %v, %v; want true", got, err)
+	}
+	if got, err := run.StepOutputBool("build", "report.items[0].ok"); err != nil || !got {
+		t.Errorf("StepOutputBool(report.items[0].ok) = %v, %v; want true", got, err)
+	}
+	if got, err := run.StepOutputBool("build", "skipped"); err != nil || got {
+		t.Errorf("StepOutputBool(skipped) = %v, %v; want false", got, err)
+	}
+
+	errTests := []struct {
+		name string
+		get  func() error
+		want string
+	}{
+		{"int from fraction", func() error { _, err := run.StepOutputInt("build", "ratio"); return err }, "output ratio of step build is float64 (0.5), not an integer"},
+		{"int from text", func() error { _, err := run.StepOutputInt("build", "stdout"); return err }, "not an integer"},
+		{"string from map", func() error { _, err := run.StepOutputString("build", "report"); return err }, "not a string"},
+		{"bool from text", func() error { _, err := run.StepOutputBool("build", "stdout"); return err }, "not a boolean"},
+		{"missing field", func() error { _, err := run.StepOutputString("build", "report.missing"); return err }, "output report.missing not found in step build"},
+		{"missing step", func() error { _, err := run.StepOutputString("deploy", "stdout"); return err }, "step deploy not found"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.get(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowRun_AssertStepOutput(t *testing.T) {
+	h := &Harness{t: t, RunsDir: t.TempDir()}
+	wf := types.NewRun("run-assert", "test-template", nil)
+	wf.Steps["build"] = &types.Step{
+		ID:     "build",
+		Status: types.StepStatusDone,
+		Outputs: map[string]any{
+			"stdout": "built app v1.2.3 in 4s\n",
+			"count":  42,
+			"ratio":  0.5,
+			"report": map[string]any{"files": []any{"a.go", "b.go"}, "lines": 120},
+		},
+	}
+	if err := h.SaveWorkflow(wf); err != nil {
+		t.Fatalf("SaveWorkflow() error = %v", err)
+	}
+	run := &WorkflowRun{ID: wf.ID, harness: h}
+
+	passing := []struct {
+		name   string
+		assert func() error
+	}{
+		{"contains string", func() error { return run.AssertStepOutputContains("build", "stdout", "v1.2.3") }},
+		{"contains nested list", func() error { return run.AssertStepOutputContains("build", "report.files", "b.go") }},
+		{"equals int as float", func() error { return run.AssertStepOutputEquals("build", "count", 42.0) }},
+		{"equals int", func() error { return run.AssertStepOutputEquals("build", "count", 42) }},
+		{"equals float", func() error { return run.AssertStepOutputEquals("build", "ratio", 0.5) }},
+		{"equals nested list item", func() error { return run.AssertStepOutputEquals("build", "report.files[1]", "b.go") }},
+		{"equals map with numbers", func() error {
+			return run.AssertStepOutputEquals("build", "report", map[string]any{"files": []any{"a.go", "b.go"}, "lines": 120.0})
+		}},
+	}
+	for _, tt := range passing {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.assert(); err != nil {
+				t.Errorf("assertion failed: %v", err)
+			}
+		})
+	}
+
+	failing := []struct {
+		name   string
+		assert func() error
+		want   string
+	}{
+		{"contains missing substring", func() error { return run.AssertStepOutputContains("build", "stdout", "v2") }, `expected it to contain "v2"`},
+		{"equals other number", func() error { return run.AssertStepOutputEquals("build", "count", 43) }, "output count of step build is int (42), expected int (43)"},
+		{"equals number as string", func() error { return run.AssertStepOutputEquals("build", "count", "42") }, "expected string (42)"},
+		{"missing field", func() error { return run.AssertStepOutputEquals("build", "report.missing", 1) }, "output report.missing not found in step build"},
+		{"missing step", func() error { return run.AssertStepOutputContains("deploy", "stdout", "x") }, "step deploy not found"},
+	}
+	for _, tt := range failing {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.assert(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -99,6 +99,11 @@ type GracefulStopConfig struct {
 
 	// Wait is how long to wait for graceful shutdown before killing
 	Wait Duration `toml:"wait"`
+
+	// InterruptKeys are the keys to send to cancel the agent's current work
+	// without stopping it, as on step timeout (default: ["C-c"]). Entries that
+	// are not tmux key names are typed as text, e.g. ["Escape"] or ["/stop", "Enter"].
+	InterruptKeys []string `toml:"interrupt_keys"`
 }
 
 // Duration is a time.Duration that can be unmarshaled from TOML strings like "3s", "100ms".
@@ -168,6 +173,14 @@ func (c *AdapterConfig) GetGracefulStopWait() time.Duration {
 	return c.GracefulStop.Wait.Duration()
 }
 
+// GetInterruptKeys returns the interrupt key sequence, defaulting to ["C-c"].
+func (c *AdapterConfig) GetInterruptKeys() []string {
+	if len(c.GracefulStop.InterruptKeys) == 0 {
+		return []string{"C-c"}
+	}
+	return c.GracefulStop.InterruptKeys
+}
+
 // GetSendKeysTimeout returns the send-keys timeout, defaulting to 30 seconds.
 func (c *AdapterConfig) GetSendKeysTimeout() time.Duration {
 	if c.PromptInjection.SendKeysTimeout == 0 {
@@ -195,6 +195,11 @@ func TestAdapterConfig_Defaults(t *testing.T) {
 	if config.GetSendKeysTimeout() != 30*time.Second {
 		t.Errorf("expected default send keys timeout = 30s, got %v", config.GetSendKeysTimeout())
 	}
+
+	// Test default interrupt keys
+	if keys := config.GetInterruptKeys(); len(keys) != 1 || keys[0] != "C-c" {
+		t.Errorf("expected default interrupt keys = [C-c], got %v", keys)
+	}
 }
 
 func TestAdapterConfig_GetSendKeysTimeout(t *testing.T) {
@@ -0,0 +1,32 @@
+package types
+
+import "time"
+
+// ArtifactManifestFile is the manifest written at the root of a run's artifacts directory.
+const ArtifactManifestFile = "manifest.json"
+
+// ArtifactManifest lists the step outputs persisted for a run (outputs marked artifact = true).
+type ArtifactManifest struct {
+	RunID     string          `json:"run_id"`
+	Artifacts []ArtifactEntry `json:"artifacts"`
+}
+
+// ArtifactEntry describes one persisted step output.
+type ArtifactEntry struct {
+	Step    string    `json:"step"`
+	Output  string    `json:"output"`
+	Path    string    `json:"path"`             // Relative to the artifacts directory
+	Source  string    `json:"source,omitempty"` // Original file, for outputs copied from disk
+	Size    int64     `json:"size"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Find returns the entry for a step output, or nil if it was not persisted.
+func (m *ArtifactManifest) Find(stepID, output string) *ArtifactEntry {
+	for i := range m.Artifacts {
+		if m.Artifacts[i].Step == stepID && m.Artifacts[i].Output == output {
+			return &m.Artifacts[i]
+		}
+	}
+	return nil
+}
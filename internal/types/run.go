@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"time"
 )
@@ -12,6 +13,7 @@ type RunStatus string
 const (
 	RunStatusPending    RunStatus = "pending"     // Created but not started
 	RunStatusRunning    RunStatus = "running"     // Orchestrator is processing
+	RunStatusPaused     RunStatus = "paused"      // Dispatch held via meow pause; running steps carry on
 	RunStatusCleaningUp RunStatus = "cleaning_up" // Running cleanup script
 	RunStatusDone       RunStatus = "done"        // All steps completed
 	RunStatusFailed     RunStatus = "failed"      // A step failed
@@ -21,7 +23,7 @@ const (
 // Valid returns true if this is a recognized run status.
 func (s RunStatus) Valid() bool {
 	switch s {
-	case RunStatusPending, RunStatusRunning, RunStatusCleaningUp,
+	case RunStatusPending, RunStatusRunning, RunStatusPaused, RunStatusCleaningUp,
 		RunStatusDone, RunStatusFailed, RunStatusStopped:
 		return true
 	}
@@ -33,6 +35,13 @@ func (s RunStatus) IsTerminal() bool {
 	return s == RunStatusDone || s == RunStatusFailed || s == RunStatusStopped
 }
 
+// Cleanup orders: whether cleanup kills the run's agents before or after
+// running the cleanup script.
+const (
+	CleanupAgentsFirst = "agents_first" // Kill agents, then run the script (default)
+	CleanupScriptFirst = "script_first" // Run the script while agents are alive, then kill them
+)
+
 // AgentInfo tracks persisted state for an agent.
 type AgentInfo struct {
 	TmuxSession   string `yaml:"tmux_session"`
@@ -40,6 +49,8 @@ type AgentInfo struct {
 	Workdir       string `yaml:"workdir"`                  // Working directory for file_path validation
 	CurrentStep   string `yaml:"current_step,omitempty"`   // Step currently assigned to agent
 	ClaudeSession string `yaml:"claude_session,omitempty"` // Session ID for resume
+	// Capabilities matched against requires_capability on agent steps
+	Capabilities []string `yaml:"capabilities,omitempty"`
 }
 
 // Run represents a running workflow instance.
@@ -67,6 +78,57 @@ type Run struct {
 	CleanupOnSuccess string `yaml:"cleanup_on_success,omitempty"` // Runs when all steps complete successfully
 	CleanupOnFailure string `yaml:"cleanup_on_failure,omitempty"` // Runs when a step fails
 	CleanupOnStop    string `yaml:"cleanup_on_stop,omitempty"`    // Runs on SIGINT/SIGTERM or meow stop
+	CleanupOrder     string `yaml:"cleanup_order,omitempty"`      // agents_first | script_first (default: agents_first)
+
+	// Extra cleanup scripts that run on any trigger, each only if its
+	// condition holds (e.g., a step reported a resource to deprovision)
+	CleanupWhen []ConditionalCleanup `yaml:"cleanup_when,omitempty"`
+
+	// Agent lifecycle hooks (from template): BeforeAllAgents runs once before
+	// the first agent is spawned, AfterAllAgents once after the last is killed.
+	// The *Ran flags record that a hook has fired so it never fires again.
+	BeforeAllAgents    string `yaml:"before_all_agents,omitempty"`
+	AfterAllAgents     string `yaml:"after_all_agents,omitempty"`
+	BeforeAllAgentsRan bool   `yaml:"before_all_agents_ran,omitempty"`
+	AfterAllAgentsRan  bool   `yaml:"after_all_agents_ran,omitempty"`
+
+	// Boilerplate wrapped around every agent step's prompt at injection (from
+	// template, variables already substituted). Steps opt out with skip_prompt_wrap.
+	PromptPrefix string `yaml:"prompt_prefix,omitempty"`
+	PromptSuffix string `yaml:"prompt_suffix,omitempty"`
+
+	// Workflow-level outputs: OutputDefs maps each declared output to its
+	// source expression (from template, variables substituted); Outputs holds
+	// the values resolved when the run completes
+	OutputDefs map[string]string `yaml:"output_defs,omitempty"`
+	Outputs    map[string]any    `yaml:"outputs,omitempty"`
+
+	// Error explains a run failure no step accounts for (e.g., a declared
+	// workflow output that was never produced)
+	Error string `yaml:"error,omitempty"`
+
+	// Retries left across all steps (from template retry_budget); nil when
+	// uncapped. Each step retry spends one; once it reaches zero, failures stand.
+	RetryBudget *int `yaml:"retry_budget,omitempty"`
+
+	// Cap in bytes on the outputs stored across all steps (from template
+	// output_budget; 0 = uncapped). Outputs that would exceed it are spilled
+	// to artifact files or truncated.
+	OutputBudget int64 `yaml:"output_budget,omitempty"`
+
+	// Cap on the run's wall-clock time from StartedAt (from template timeout;
+	// 0 = none). A run that exceeds it fails, running cleanup_on_failure.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// What the run needs from the machine (from template requires), checked
+	// before any step runs
+	Requires *RunRequirements `yaml:"requires,omitempty"`
+
+	// With PauseOnFailure (meow run --pause-on-failure), a step failing for
+	// good pauses the run instead of failing it, so the failure can be
+	// inspected. PausedOnFailure lists the failed steps holding it paused.
+	PauseOnFailure  bool     `yaml:"pause_on_failure,omitempty"`
+	PausedOnFailure []string `yaml:"paused_on_failure,omitempty"`
 
 	// Prior status before cleanup - used to determine final status after cleanup
 	PriorStatus RunStatus `yaml:"prior_status,omitempty"`
@@ -108,6 +170,29 @@ func (r *Run) RegisterAgent(id string, info *AgentInfo) {
 	r.Agents[id] = info
 }
 
+// SetAgentCapabilities records the capabilities an agent advertises,
+// registering the agent if needed.
+func (r *Run) SetAgentCapabilities(agentID string, capabilities []string) {
+	info, ok := r.Agents[agentID]
+	if !ok {
+		info = &AgentInfo{}
+		r.RegisterAgent(agentID, info)
+	}
+	info.Capabilities = capabilities
+}
+
+// AgentsWithCapability returns the IDs of agents advertising capability, sorted.
+func (r *Run) AgentsWithCapability(capability string) []string {
+	var ids []string
+	for id, info := range r.Agents {
+		if slices.Contains(info.Capabilities, capability) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // GetAgentWorkdir returns the working directory for an agent.
 // Used for file_path output validation.
 func (r *Run) GetAgentWorkdir(agentID string) (string, bool) {
@@ -146,16 +231,35 @@ func (r *Run) AllDone() bool {
 	return true
 }
 
-// HasFailed returns true if any step has failed.
+// HasFailed returns true if any step has failed, other than iterations a
+// foreach's failure_threshold tolerated.
 func (r *Run) HasFailed() bool {
 	for _, step := range r.Steps {
-		if step.Status == StepStatusFailed {
+		if step.Status == StepStatusFailed && !r.failureTolerated(step) {
 			return true
 		}
 	}
 	return false
 }
 
+// failureTolerated reports whether a failed step belongs to an iteration of
+// a foreach that completed within its failure_threshold.
+func (r *Run) failureTolerated(step *Step) bool {
+	id := step.ExpandedFrom
+	for depth := 0; id != "" && depth < len(r.Steps); depth++ {
+		parent, ok := r.Steps[id]
+		if !ok {
+			return false
+		}
+		if parent.Executor == ExecutorForeach && parent.Status == StepStatusDone &&
+			parent.Foreach != nil && parent.Foreach.FailureThreshold != "" {
+			return true
+		}
+		id = parent.ExpandedFrom
+	}
+	return false
+}
+
 // Complete marks the run as done.
 func (r *Run) Complete() {
 	now := time.Now()
@@ -211,6 +315,54 @@ func (r *Run) Stop() {
 	r.DoneAt = &now
 }
 
+// Pause holds a running run: no new steps are dispatched until it resumes,
+// while the steps already running carry on and may still complete.
+func (r *Run) Pause() error {
+	if r.Status != RunStatusRunning {
+		return fmt.Errorf("cannot pause run in status %s", r.Status)
+	}
+	r.Status = RunStatusPaused
+	return nil
+}
+
+// Resume returns a paused run to running. Steps whose failure paused it are
+// reset to run again.
+func (r *Run) Resume() error {
+	if r.Status != RunStatusPaused {
+		return fmt.Errorf("cannot resume run in status %s", r.Status)
+	}
+	for _, id := range r.PausedOnFailure {
+		if step, ok := r.Steps[id]; ok && step.Status == StepStatusFailed {
+			if err := step.Rerun(); err != nil {
+				return fmt.Errorf("retrying step %s: %w", id, err)
+			}
+		}
+	}
+	r.PausedOnFailure = nil
+	r.Status = RunStatusRunning
+	return nil
+}
+
+// Abort returns a run paused on a failure to running without retrying the
+// failed steps: the failures stand, and the run fails as it would have
+// without pause_on_failure. Later failures no longer pause it.
+func (r *Run) Abort() error {
+	if r.Status != RunStatusPaused || len(r.PausedOnFailure) == 0 {
+		return fmt.Errorf("cannot abort run in status %s: not paused on a failure", r.Status)
+	}
+	r.PauseOnFailure = false
+	r.PausedOnFailure = nil
+	r.Status = RunStatusRunning
+	return nil
+}
+
+// Reopen returns a finished run to running so rerun steps can execute (for watch mode).
+func (r *Run) Reopen() {
+	r.Status = RunStatusRunning
+	r.DoneAt = nil
+	r.PriorStatus = ""
+}
+
 // GetAgentIDs returns all agent IDs registered in this run.
 func (r *Run) GetAgentIDs() []string {
 	ids := make([]string, 0, len(r.Agents))
@@ -290,6 +442,21 @@ func (r *Run) AgentIsIdle(agentID string) bool {
 	return true
 }
 
+// ConditionalCleanup is a cleanup script gated by a condition: a shell
+// command that must exit 0 for the script to run. Both are substituted like
+// cleanup scripts, so the condition can test step outputs.
+type ConditionalCleanup struct {
+	Condition string `yaml:"condition" toml:"condition"`
+	Script    string `yaml:"script" toml:"script"`
+}
+
+// RunRequirements declares what a run needs from the machine it runs on:
+// binaries that must be on PATH and environment variables that must be set.
+type RunRequirements struct {
+	Binaries []string `yaml:"binaries,omitempty" toml:"binaries,omitempty"`
+	Env      []string `yaml:"env,omitempty" toml:"env,omitempty"`
+}
+
 // GetCleanupScript returns the cleanup script for the given reason, or empty string if none defined.
 // Cleanup is opt-in: returns empty string unless a cleanup script is explicitly defined for this trigger.
 func (r *Run) GetCleanupScript(reason RunStatus) string {
@@ -306,13 +473,14 @@ func (r *Run) GetCleanupScript(reason RunStatus) string {
 }
 
 // HasCleanup returns true if any cleanup script is defined for the given reason.
+// Conditional cleanups apply to every reason.
 func (r *Run) HasCleanup(reason RunStatus) bool {
-	return r.GetCleanupScript(reason) != ""
+	return r.GetCleanupScript(reason) != "" || len(r.CleanupWhen) > 0
 }
 
 // HasAnyCleanup returns true if any cleanup script is defined.
 func (r *Run) HasAnyCleanup() bool {
-	return r.CleanupOnSuccess != "" || r.CleanupOnFailure != "" || r.CleanupOnStop != ""
+	return r.CleanupOnSuccess != "" || r.CleanupOnFailure != "" || r.CleanupOnStop != "" || len(r.CleanupWhen) > 0
 }
 
 // AgentHasCompletedSteps returns true if the agent has completed any steps in this workflow.
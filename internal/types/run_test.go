@@ -9,7 +9,7 @@ import (
 func TestRunStatus(t *testing.T) {
 	t.Run("Valid returns true for valid statuses", func(t *testing.T) {
 		valid := []RunStatus{
-			RunStatusPending, RunStatusRunning,
+			RunStatusPending, RunStatusRunning, RunStatusPaused,
 			RunStatusDone, RunStatusFailed,
 		}
 		for _, s := range valid {
@@ -29,9 +29,40 @@ func TestRunStatus(t *testing.T) {
 		if RunStatusRunning.IsTerminal() {
 			t.Error("running should not be terminal")
 		}
+		if RunStatusPaused.IsTerminal() {
+			t.Error("paused should not be terminal")
+		}
 	})
 }
 
+func TestRunPauseResume(t *testing.T) {
+	run := NewRun("run-1", "test.meow.toml", nil)
+	if err := run.Pause(); err == nil {
+		t.Error("Pause() of a pending run should fail")
+	}
+
+	run.Status = RunStatusRunning
+	if err := run.Pause(); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if run.Status != RunStatusPaused {
+		t.Errorf("Status = %s, want paused", run.Status)
+	}
+	if err := run.Pause(); err == nil {
+		t.Error("Pause() of a paused run should fail")
+	}
+
+	if err := run.Resume(); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if run.Status != RunStatusRunning {
+		t.Errorf("Status = %s, want running", run.Status)
+	}
+	if err := run.Resume(); err == nil {
+		t.Error("Resume() of a running run should fail")
+	}
+}
+
 func TestNewRun(t *testing.T) {
 	vars := map[string]any{"agent": "claude-1"}
 	run := NewRun("run-123", "test.meow.toml", vars)
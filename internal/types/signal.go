@@ -0,0 +1,26 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// cancelSignals are the signals a step may ask to be sent as a soft cancel.
+var cancelSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// ParseCancelSignal parses a cancel_signal like "SIGUSR1" or "usr1".
+func ParseCancelSignal(s string) (syscall.Signal, error) {
+	name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "SIG")
+	if sig, ok := cancelSignals[name]; ok {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("invalid cancel_signal %q: use SIGHUP, SIGINT, SIGQUIT, SIGTERM, SIGUSR1, or SIGUSR2", s)
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseCancelSignal(t *testing.T) {
+	tests := []struct {
+		signal  string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{signal: "SIGUSR1", want: syscall.SIGUSR1},
+		{signal: "usr2", want: syscall.SIGUSR2},
+		{signal: " SIGTERM ", want: syscall.SIGTERM},
+		{signal: "SIGKILL", wantErr: true},
+		{signal: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCancelSignal(tt.signal)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCancelSignal(%q) error = %v, wantErr %v", tt.signal, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCancelSignal(%q) = %v, want %v", tt.signal, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteUnits maps size suffixes to their multipliers (binary: 1KB = 1024 bytes).
+var byteUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size like "512", "64KB", or "1.5MB" into bytes.
+// Units are case-insensitive and binary (1KB = 1024 bytes).
+func ParseByteSize(s string) (int64, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(s))
+	scale := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(trimmed, unit.suffix) {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, unit.suffix))
+			scale = unit.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: want bytes or a size like \"64KB\" or \"1MB\"", s)
+	}
+	return int64(n * float64(scale)), nil
+}
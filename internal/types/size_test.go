@@ -0,0 +1,31 @@
+package types
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "512", want: 512},
+		{size: "512B", want: 512},
+		{size: "64KB", want: 64 << 10},
+		{size: "64kb", want: 64 << 10},
+		{size: "1.5MB", want: 3 << 19},
+		{size: "2 GB", want: 2 << 30},
+		{size: "lots", wantErr: true},
+		{size: "-1KB", wantErr: true},
+		{size: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.size)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseByteSize(%q) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
@@ -2,13 +2,15 @@ package types
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // ExecutorType determines who runs a step and how.
-// IMPORTANT: There are exactly 7 executors. Gate is NOT an executor -
-// human approval is implemented via branch + meow await-approval.
+// IMPORTANT: There are exactly 8 executors. Approval that needs a decision
+// (approve or reject) is still branch + meow await-approval; gate only waits.
 type ExecutorType string
 
 const (
@@ -19,6 +21,7 @@ const (
 	ExecutorExpand  ExecutorType = "expand"  // Inline another workflow
 	ExecutorBranch  ExecutorType = "branch"  // Conditional execution
 	ExecutorForeach ExecutorType = "foreach" // Iterate over a list
+	ExecutorGate    ExecutorType = "gate"    // Wait for a named event
 
 	// External executors - wait for external completion signal
 	ExecutorAgent ExecutorType = "agent" // Agent does work, signals meow done
@@ -27,7 +30,7 @@ const (
 // IsOrchestrator returns true if this executor runs internally.
 func (e ExecutorType) IsOrchestrator() bool {
 	switch e {
-	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach:
+	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach, ExecutorGate:
 		return true
 	}
 	return false
@@ -41,7 +44,7 @@ func (e ExecutorType) IsExternal() bool {
 // Valid returns true if this is a recognized executor type.
 func (e ExecutorType) Valid() bool {
 	switch e {
-	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach, ExecutorAgent:
+	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach, ExecutorGate, ExecutorAgent:
 		return true
 	}
 	return false
@@ -90,8 +93,42 @@ func (s StepStatus) CanTransitionTo(target StepStatus) bool {
 
 // OutputSource defines where to capture output from shell commands.
 type OutputSource struct {
-	Source string `yaml:"source" toml:"source"`                  // stdout | stderr | exit_code | file:/path
-	Type   string `yaml:"type,omitempty" toml:"type,omitempty"` // json | (empty for string)
+	Source   string `yaml:"source" toml:"source"`                         // stdout | stderr | exit_code | file | file:/path
+	Type     string `yaml:"type,omitempty" toml:"type,omitempty"`         // json | (empty for string)
+	Artifact bool   `yaml:"artifact,omitempty" toml:"artifact,omitempty"` // Persist into the run's artifacts directory
+	Pattern  string `yaml:"pattern,omitempty" toml:"pattern,omitempty"`   // Regex applied to the source; first group (or whole match) is the value
+	Required bool   `yaml:"required,omitempty" toml:"required,omitempty"` // Fail the step if the output cannot be captured
+	Path     string `yaml:"path,omitempty" toml:"path,omitempty"`         // JSON file to read, for source "file"
+	Field    string `yaml:"field,omitempty" toml:"field,omitempty"`       // JSON path selecting part of the parsed value (e.g., "result.items[0].id")
+	Line     int    `yaml:"line,omitempty" toml:"line,omitempty"`         // 1-based line of the source to keep (negative counts from the end), applied before pattern
+}
+
+// OutputSourceFile is the source that reads a JSON file named by Path.
+const OutputSourceFile = "file"
+
+// FilePath returns the file an output is read from, for the "file" source
+// and the "file:/path" form, and false for other sources.
+func (s OutputSource) FilePath() (string, bool) {
+	if s.Source == OutputSourceFile {
+		return s.Path, true
+	}
+	if path, ok := strings.CutPrefix(s.Source, "file:"); ok {
+		return path, true
+	}
+	return "", false
+}
+
+// IsJSON reports whether the captured value is parsed as JSON: for the
+// "file" source, or when Type is json.
+func (s OutputSource) IsJSON() bool {
+	return s.Source == OutputSourceFile || s.Type == "json"
+}
+
+// OutputAssertion is an expected output value, checked after the step's
+// outputs are captured. Exactly one of Equals or Matches is set.
+type OutputAssertion struct {
+	Equals  string `yaml:"equals,omitempty" toml:"equals,omitempty"`   // Exact value (non-string outputs are compared in their string form)
+	Matches string `yaml:"matches,omitempty" toml:"matches,omitempty"` // Regex the value must match
 }
 
 // ShellConfig for executor: shell
@@ -101,6 +138,14 @@ type ShellConfig struct {
 	Env     map[string]string       `yaml:"env,omitempty" toml:"env,omitempty"`
 	OnError string                  `yaml:"on_error,omitempty" toml:"on_error,omitempty"` // continue | fail (default: fail)
 	Outputs map[string]OutputSource `yaml:"outputs,omitempty" toml:"outputs,omitempty"`
+	// RunAs runs the command as another OS user (name or numeric UID); the
+	// orchestrator must run as root to switch users
+	RunAs string `yaml:"run_as,omitempty" toml:"run_as,omitempty"`
+	// CancelSignal is sent to the command when it is cancelled (workflow
+	// stopped or step timed out), giving it CancelGrace to checkpoint and exit
+	// before it is killed. Empty kills it straight away.
+	CancelSignal string `yaml:"cancel_signal,omitempty" toml:"cancel_signal,omitempty"`
+	CancelGrace  string `yaml:"cancel_grace,omitempty" toml:"cancel_grace,omitempty"` // Duration string
 }
 
 // SpawnConfig for executor: spawn
@@ -110,7 +155,13 @@ type SpawnConfig struct {
 	Workdir       string            `yaml:"workdir,omitempty" toml:"workdir,omitempty"`
 	Env           map[string]string `yaml:"env,omitempty" toml:"env,omitempty"`
 	ResumeSession string            `yaml:"resume_session,omitempty" toml:"resume_session,omitempty"`
-	SpawnArgs     string            `yaml:"spawn_args,omitempty" toml:"spawn_args,omitempty"` // Extra CLI args to append to spawn command
+	SpawnArgs     string            `yaml:"spawn_args,omitempty" toml:"spawn_args,omitempty"`       // Extra CLI args to append to spawn command
+	StartupDelay  string            `yaml:"startup_delay,omitempty" toml:"startup_delay,omitempty"` // Overrides the adapter's startup_delay
+	// ReuseFrom names a finished run whose agent of the same ID is adopted,
+	// if its session is still alive, instead of spawning a new one.
+	ReuseFrom string `yaml:"reuse_from,omitempty" toml:"reuse_from,omitempty"`
+	// Capabilities tags the agent for agent steps that set requires_capability
+	Capabilities []string `yaml:"capabilities,omitempty" toml:"capabilities,omitempty"`
 }
 
 // KillConfig for executor: kill
@@ -118,6 +169,9 @@ type KillConfig struct {
 	Agent    string `yaml:"agent" toml:"agent"`
 	Graceful bool   `yaml:"graceful,omitempty" toml:"graceful,omitempty"` // Default: true
 	Timeout  int    `yaml:"timeout,omitempty" toml:"timeout,omitempty"`   // Seconds, default: 10
+	// GracePeriod bounds how long a graceful kill waits for the agent to exit
+	// before killing its session; 0 uses the adapter's graceful_stop wait
+	GracePeriod time.Duration `yaml:"grace_period,omitempty" toml:"grace_period,omitempty"`
 }
 
 // ExpandConfig for executor: expand
@@ -156,6 +210,18 @@ type BranchConfig struct {
 	Env     map[string]string       `yaml:"env,omitempty" toml:"env,omitempty"`
 	Outputs map[string]OutputSource `yaml:"outputs,omitempty" toml:"outputs,omitempty"`
 	OnError string                  `yaml:"on_error,omitempty" toml:"on_error,omitempty"` // continue | fail (default: fail when no on_false)
+	RunAs   string                  `yaml:"run_as,omitempty" toml:"run_as,omitempty"`
+	// Soft cancel (see ShellConfig)
+	CancelSignal string `yaml:"cancel_signal,omitempty" toml:"cancel_signal,omitempty"`
+	CancelGrace  string `yaml:"cancel_grace,omitempty" toml:"cancel_grace,omitempty"`
+}
+
+// GateConfig for executor: gate
+// The step stays running until an event named WaitForEvent reaches the
+// orchestrator (meow event <name>); the event's data becomes its outputs.
+type GateConfig struct {
+	WaitForEvent string `yaml:"wait_for_event" toml:"wait_for_event"`
+	Timeout      string `yaml:"timeout,omitempty" toml:"timeout,omitempty"` // Duration string; empty waits indefinitely
 }
 
 // ForeachConfig for executor: foreach
@@ -170,6 +236,19 @@ type ForeachConfig struct {
 	Parallel      *bool          `yaml:"parallel,omitempty" toml:"parallel,omitempty"`             // Run in parallel (default: true)
 	MaxConcurrent string         `yaml:"max_concurrent,omitempty" toml:"max_concurrent,omitempty"` // Limit concurrent iterations (supports variables like "{{max_agents}}")
 	Join          *bool          `yaml:"join,omitempty" toml:"join,omitempty"`                     // Wait for all iterations (default: true)
+	// Window bounds a huge fan-out: only this many unfinished iterations
+	// exist at a time, and later ones are expanded as earlier ones finish.
+	// Empty or 0 expands every iteration at once. Requires join.
+	Window string `yaml:"window,omitempty" toml:"window,omitempty"`
+	// FailureThreshold tolerates failed iterations: a count ("3") or a
+	// percentage of iterations ("30%"). The foreach fails only when more
+	// iterations fail than that. Empty tolerates none.
+	FailureThreshold string `yaml:"failure_threshold,omitempty" toml:"failure_threshold,omitempty"`
+	// Collect names an output to gather from every iteration, as the step
+	// ID within the iteration and the output field ("work.summary"). The
+	// joined foreach lists the values, in iteration order, in its collected
+	// output. Empty collects nothing.
+	Collect string `yaml:"collect,omitempty" toml:"collect,omitempty"`
 }
 
 // IsParallel returns whether iterations should run in parallel (default: true).
@@ -188,6 +267,33 @@ func (f *ForeachConfig) IsJoin() bool {
 	return *f.Join
 }
 
+// FailureLimit returns how many of the given number of iterations may fail
+// before the foreach fails. A percentage rounds down.
+func (f *ForeachConfig) FailureLimit(iterations int) (int, error) {
+	if f.FailureThreshold == "" {
+		return 0, nil
+	}
+	n, percent, err := ParseFailureThreshold(f.FailureThreshold)
+	if err != nil {
+		return 0, err
+	}
+	if percent {
+		return iterations * n / 100, nil
+	}
+	return n, nil
+}
+
+// ParseFailureThreshold parses a foreach failure_threshold: a non-negative
+// count, or a percentage from 0% to 100%.
+func ParseFailureThreshold(s string) (n int, percent bool, err error) {
+	digits, percent := strings.CutSuffix(strings.TrimSpace(s), "%")
+	n, err = strconv.Atoi(digits)
+	if err != nil || n < 0 || (percent && n > 100) {
+		return 0, false, fmt.Errorf("invalid failure_threshold %q: must be a count or a percentage like 30%%", s)
+	}
+	return n, percent, nil
+}
+
 // GetMaxConcurrent parses the MaxConcurrent string and returns the limit.
 // Returns 0 if not set or invalid (0 means unlimited).
 // This is called after variable substitution has occurred.
@@ -204,17 +310,43 @@ func (f *ForeachConfig) GetMaxConcurrent() int {
 	return n
 }
 
+// GetWindow parses the Window string and returns the number of iterations
+// expanded at a time. Returns 0 if not set or invalid (0 means all at once).
+func (f *ForeachConfig) GetWindow() int {
+	if f.Window == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(f.Window))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
 // AgentOutputDef defines an expected output from an agent step.
 type AgentOutputDef struct {
 	Required    bool   `yaml:"required" toml:"required"`
 	Type        string `yaml:"type" toml:"type"` // string | number | boolean | json | file_path
 	Description string `yaml:"description,omitempty" toml:"description,omitempty"`
+	From        string `yaml:"from,omitempty" toml:"from,omitempty"`         // JSON path into the done payload (e.g., "result.items[0].id")
+	FromLog     string `yaml:"from_log,omitempty" toml:"from_log,omitempty"` // JSON path into the agent's structured log lines
+	Artifact    bool   `yaml:"artifact,omitempty" toml:"artifact,omitempty"` // Persist into the run's artifacts directory
+
+	// Constraints checked after the type
+	Min     *float64 `yaml:"min,omitempty" toml:"min,omitempty"`         // Lowest allowed number
+	Max     *float64 `yaml:"max,omitempty" toml:"max,omitempty"`         // Highest allowed number
+	Enum    []string `yaml:"enum,omitempty" toml:"enum,omitempty"`       // Allowed values
+	Pattern string   `yaml:"pattern,omitempty" toml:"pattern,omitempty"` // Regexp a string must match
 }
 
 // AgentConfig for executor: agent
 type AgentConfig struct {
 	Agent  string `yaml:"agent" toml:"agent"`
 	Prompt string `yaml:"prompt" toml:"prompt"`
+	// RequiresCapability leaves the choice of agent to the orchestrator: the
+	// step goes to an idle agent spawned with this capability, and Agent is
+	// set at dispatch.
+	RequiresCapability string `yaml:"requires_capability,omitempty" toml:"requires_capability,omitempty"`
 	// Mode controls how the agent step behaves:
 	//   - "autonomous" (default): Agent works until meow done; stop hook re-injects prompt
 	//   - "interactive": Agent allows human conversation during step
@@ -222,6 +354,90 @@ type AgentConfig struct {
 	Mode    string                    `yaml:"mode,omitempty" toml:"mode,omitempty"`
 	Outputs map[string]AgentOutputDef `yaml:"outputs,omitempty" toml:"outputs,omitempty"`
 	Timeout string                    `yaml:"timeout,omitempty" toml:"timeout,omitempty"` // Max time for step
+	// AckTimeout is the budget for the agent to acknowledge the prompt
+	// (prompt-received event). When set, Timeout is a separate work budget
+	// measured from the acknowledgment rather than from dispatch.
+	AckTimeout string `yaml:"ack_timeout,omitempty" toml:"ack_timeout,omitempty"`
+	// Per-step overrides of the adapter's prompt injection timing, for agents
+	// that need slower (or faster) pacing than the adapter default
+	PreDelay  string `yaml:"pre_delay,omitempty" toml:"pre_delay,omitempty"`   // Wait after pre_keys before sending the prompt
+	PostDelay string `yaml:"post_delay,omitempty" toml:"post_delay,omitempty"` // Wait after sending the prompt before post_keys
+	// SkipPromptWrap opts the step out of the run's PromptPrefix/PromptSuffix
+	SkipPromptWrap bool `yaml:"skip_prompt_wrap,omitempty" toml:"skip_prompt_wrap,omitempty"`
+	// StallTimeout flags the agent as stalled when it sends no events for this
+	// long while the step runs; independent of (and usually shorter than) Timeout.
+	StallTimeout string `yaml:"stall_timeout,omitempty" toml:"stall_timeout,omitempty"`
+	OnStall      string `yaml:"on_stall,omitempty" toml:"on_stall,omitempty"` // warn (default) | nudge
+	// CaptureTranscript saves the agent's pane scrollback when the step
+	// completes and exposes the file path as the "transcript" output.
+	CaptureTranscript bool `yaml:"capture_transcript,omitempty" toml:"capture_transcript,omitempty"`
+	// Nudge re-injects a prompt when the agent goes silent, up to a cap,
+	// and fails the step if it stays silent after the last nudge.
+	Nudge *NudgePolicy `yaml:"nudge,omitempty" toml:"nudge,omitempty"`
+	// Completion selects how the step finishes: CompletionDone (default)
+	// waits for meow done; CompletionExit finishes when the agent process
+	// exits, succeeding on exit code 0.
+	Completion string `yaml:"completion,omitempty" toml:"completion,omitempty"`
+	// ResultFile is read after a clean exit (completion = "exit"), relative
+	// to the agent's workdir: a JSON object becomes the step's outputs, any
+	// other content the "result" output.
+	ResultFile string `yaml:"result_file,omitempty" toml:"result_file,omitempty"`
+	// CancelPrompt is injected when the step times out, asking the agent to
+	// wrap up; the agent is interrupted only if the step is still running
+	// CancelGrace later.
+	CancelPrompt string `yaml:"cancel_prompt,omitempty" toml:"cancel_prompt,omitempty"`
+	CancelGrace  string `yaml:"cancel_grace,omitempty" toml:"cancel_grace,omitempty"` // Duration string
+}
+
+// Values for AgentConfig.Completion.
+const (
+	CompletionDone = "done" // The agent calls meow done
+	CompletionExit = "exit" // The agent process exits; its exit code is the outcome
+)
+
+// Values for AgentConfig.OnStall.
+const (
+	OnStallWarn  = "warn"  // Log and emit an agent-stalled event
+	OnStallNudge = "nudge" // Also re-inject the step's prompt
+)
+
+// NudgePolicy controls nudging of a silent agent.
+type NudgePolicy struct {
+	After     string `yaml:"after" toml:"after"`                               // Silence before each nudge, e.g. "30s" or "30s silence"
+	Prompt    string `yaml:"prompt,omitempty" toml:"prompt,omitempty"`         // Nudge text (default: the step's prompt)
+	MaxNudges int    `yaml:"max_nudges,omitempty" toml:"max_nudges,omitempty"` // Nudges before failing (0 = never fail)
+}
+
+// ParseNudgeAfter parses a nudge policy's after value: a duration,
+// optionally followed by the word "silence" ("30s silence").
+func ParseNudgeAfter(s string) (time.Duration, error) {
+	trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "silence"))
+	d, err := time.ParseDuration(trimmed)
+	if err != nil {
+		return 0, fmt.Errorf("invalid nudge after %q: want a duration like \"30s\"", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid nudge after %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// ValidLockName reports whether name can be used as a step lock. Lock names
+// become file names, so they are limited to letters, digits, '.', '_' and
+// '-', and may not start with '.'.
+func ValidLockName(name string) bool {
+	if name == "" || name[0] == '.' {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 // Validate checks the foreach config has required fields.
@@ -244,28 +460,155 @@ func (f *ForeachConfig) Validate() error {
 	return nil
 }
 
+// StepErrorType classifies why a step failed.
+type StepErrorType string
+
+const (
+	StepErrorCommandFailed      StepErrorType = "command_failed"      // Shell command exited non-zero
+	StepErrorTimeout            StepErrorType = "timeout"             // Step exceeded its timeout
+	StepErrorExpansionFailed    StepErrorType = "expansion_failed"    // Branch target could not be expanded
+	StepErrorChildFailed        StepErrorType = "child_failed"        // A foreach iteration or branch child failed
+	StepErrorInterrupted        StepErrorType = "interrupted"         // Command was cancelled mid-run; output is partial
+	StepErrorOutputCapture      StepErrorType = "output_capture"      // A required output could not be captured
+	StepErrorAssertionFailed    StepErrorType = "assertion_failed"    // An output did not satisfy the step's assert table
+	StepErrorPreconditionFailed StepErrorType = "precondition_failed" // A requires check failed at dispatch
+	StepErrorAgentCrashed       StepErrorType = "agent_crashed"       // The agent stopped running before completing the step
+	StepErrorValidationFailed   StepErrorType = "validation_failed"   // Agent outputs still failed validation after the retry limit
+	StepErrorAgentFailed        StepErrorType = "agent_failed"        // The agent reported that it could not do the step (meow done --error)
+)
+
+// MaxErrorOutputBytes bounds the stdout/stderr kept in a StepError.
+// Only the tail is kept, since that is where commands usually report failures.
+const MaxErrorOutputBytes = 4096
+
 // StepError captures failure information.
 type StepError struct {
 	Message string `yaml:"message"`
 	Code    int    `yaml:"code,omitempty"`   // Exit code for shell
 	Output  string `yaml:"output,omitempty"` // stderr or other context
+
+	// Structured details, populated where known (e.g., shell and branch commands)
+	Type     StepErrorType `yaml:"type,omitempty"`
+	ExitCode *int          `yaml:"exit_code,omitempty"` // Set even when the exit code is 0
+	Command  string        `yaml:"command,omitempty"`
+	Stdout   string        `yaml:"stdout,omitempty"` // Tail, at most MaxErrorOutputBytes
+	Stderr   string        `yaml:"stderr,omitempty"` // Tail, at most MaxErrorOutputBytes
+}
+
+// SkipReasonKind classifies why a step was skipped.
+type SkipReasonKind string
+
+const (
+	SkipReasonDependencyFailed  SkipReasonKind = "dependency_failed"  // A need failed
+	SkipReasonDependencySkipped SkipReasonKind = "dependency_skipped" // A need was itself skipped
+	SkipReasonOutsideWindow     SkipReasonKind = "outside_window"     // Ready outside its only_between window with outside_window = "skip"
+	SkipReasonConditionFalse    SkipReasonKind = "condition_false"    // Its if check exited non-zero
+)
+
+// SkipReason records why a step was skipped instead of run.
+type SkipReason struct {
+	Kind       SkipReasonKind `yaml:"kind"`
+	Dependency string         `yaml:"dependency,omitempty"` // The need that failed or was skipped
+	Message    string         `yaml:"message"`
+}
+
+// Values for Step.TrimmedOutputs.
+const (
+	TrimmedOutputSpilled   = "spilled"   // Value moved to an artifact file; the output holds its path
+	TrimmedOutputTruncated = "truncated" // Value cut short, ending in a truncation marker
+)
+
+// NewCommandError creates a StepError for a failed shell command, keeping the
+// tail of its output so the failure can be diagnosed from persisted state.
+func NewCommandError(message, command string, exitCode int, stdout, stderr string) *StepError {
+	stderr = truncateTail(stderr, MaxErrorOutputBytes)
+	return &StepError{
+		Message:  message,
+		Code:     exitCode,
+		Output:   stderr,
+		Type:     StepErrorCommandFailed,
+		ExitCode: &exitCode,
+		Command:  command,
+		Stdout:   truncateTail(stdout, MaxErrorOutputBytes),
+		Stderr:   stderr,
+	}
+}
+
+// NewInterruptedError creates a StepError for a command cancelled before it
+// finished, keeping whatever output it produced up to that point.
+func NewInterruptedError(command, stdout, stderr string) *StepError {
+	return &StepError{
+		Message: "command interrupted",
+		Type:    StepErrorInterrupted,
+		Command: command,
+		Stdout:  truncateTail(stdout, MaxErrorOutputBytes),
+		Stderr:  truncateTail(stderr, MaxErrorOutputBytes),
+	}
+}
+
+// truncateTail keeps the last max bytes of s, prefixed with a marker when cut.
+func truncateTail(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return "...(truncated)\n" + s[len(s)-max:]
 }
 
 // Step is the single primitive in MEOW. Everything is a step.
-// IMPORTANT: Only 7 executor configs for 7 executors.
+// IMPORTANT: Only 8 executor configs for 8 executors.
 type Step struct {
 	// Identity
 	ID       string       `yaml:"id"`
 	Executor ExecutorType `yaml:"executor"`
 
 	// Lifecycle
-	Status        StepStatus `yaml:"status"`
-	StartedAt     *time.Time `yaml:"started_at,omitempty"`
-	DoneAt        *time.Time `yaml:"done_at,omitempty"`
-	InterruptedAt *time.Time `yaml:"interrupted_at,omitempty"` // For timeout tracking: when C-c was sent
+	Status         StepStatus `yaml:"status"`
+	StartedAt      *time.Time `yaml:"started_at,omitempty"`
+	DoneAt         *time.Time `yaml:"done_at,omitempty"`
+	InterruptedAt  *time.Time `yaml:"interrupted_at,omitempty"`  // When C-c was sent (agent timeout) or the command was cancelled (shell/branch)
+	AcknowledgedAt *time.Time `yaml:"acknowledged_at,omitempty"` // When the agent acknowledged the prompt (prompt-received event)
+	NudgedAt       *time.Time `yaml:"nudged_at,omitempty"`       // When the silent agent was last nudged (agent nudge policy)
+	Nudges         int        `yaml:"nudges,omitempty"`          // Nudges sent during the current attempt
+	LogOffset      int64      `yaml:"log_offset,omitempty"`      // Length of the agent's log at dispatch, where from_log outputs are read from
+	// Times the agent was re-prompted after its outputs failed validation
+	ValidationRetries int `yaml:"validation_retries,omitempty"`
+	// When the timed-out agent was sent its cancel_prompt (soft cancel)
+	CancelPromptedAt *time.Time `yaml:"cancel_prompted_at,omitempty"`
 
 	// Dependencies
-	Needs []string `yaml:"needs,omitempty"`
+	Needs  []string `yaml:"needs,omitempty"`
+	Inputs []string `yaml:"inputs,omitempty"` // File globs whose changes re-run this step in watch mode or on resume
+	// InputChecksums fingerprints each input glob's files when the step last
+	// completed; a resumed run re-runs the step if any of them changed.
+	InputChecksums map[string]string `yaml:"input_checksums,omitempty"`
+	// Requires lists preconditions checked just before dispatch:
+	// check name -> shell command. A failing check fails the step.
+	Requires map[string]string `yaml:"requires,omitempty"`
+	// If is a shell command run just before dispatch, ahead of Requires; a
+	// non-zero exit skips the step, and dependents run as if it had succeeded.
+	If string `yaml:"if,omitempty"`
+
+	// Scheduling
+	OnlyBetween   string `yaml:"only_between,omitempty"`   // Daily "HH:MM-HH:MM" window (local time) the step may be dispatched in
+	OutsideWindow string `yaml:"outside_window,omitempty"` // wait | skip (default: wait)
+	Lock          string `yaml:"lock,omitempty"`           // Named lock held while the step runs, shared by all orchestrators using the same locks dir
+
+	// Retry (shell, branch, agent): a failed step re-runs until Attempts
+	// reaches Retries or the run's retry budget is spent
+	Retries  int `yaml:"retries,omitempty"`
+	Attempts int `yaml:"attempts,omitempty"` // Retries used so far
+	// Backoff delays each retry: the first waits Backoff, each later one
+	// BackoffMultiplier (default 1) times the previous wait
+	Backoff           string     `yaml:"backoff,omitempty"`
+	BackoffMultiplier float64    `yaml:"backoff_multiplier,omitempty"`
+	RetryAt           *time.Time `yaml:"retry_at,omitempty"` // Earliest dispatch of the pending retry
+
+	// Recovery: once a failed step has no retries left, OnErrorTemplate
+	// (a ".workflow" reference) is expanded with the failure in _failed_step.
+	// The step stays failed while its RecoveryInto children run, and is done
+	// if they all succeed.
+	OnErrorTemplate string   `yaml:"on_error_template,omitempty"`
+	RecoveryInto    []string `yaml:"recovery_into,omitempty"` // Child step IDs of the recovery template
 
 	// Expansion tracking (for crash recovery)
 	ExpandedFrom  string   `yaml:"expanded_from,omitempty"`  // Parent expand step ID
@@ -274,8 +617,14 @@ type Step struct {
 	CollectionDir string   `yaml:"collection_dir,omitempty"` // Collection root for collection-relative resolution (empty for non-collection)
 
 	// Data
-	Outputs map[string]any `yaml:"outputs,omitempty"`
-	Error   *StepError     `yaml:"error,omitempty"`
+	Outputs map[string]any             `yaml:"outputs,omitempty"`
+	Assert  map[string]OutputAssertion `yaml:"assert,omitempty"` // Expected outputs (shell, branch, agent); a mismatch fails the step
+	Error   *StepError                 `yaml:"error,omitempty"`
+	// SkipReason explains a skipped step; Error repeats its message
+	SkipReason *SkipReason `yaml:"skip_reason,omitempty"`
+	// TrimmedOutputs records outputs cut down to fit the run's output budget:
+	// output name -> TrimmedOutputSpilled or TrimmedOutputTruncated
+	TrimmedOutputs map[string]string `yaml:"trimmed_outputs,omitempty"`
 
 	// Executor-specific config (exactly one populated based on Executor)
 	Shell   *ShellConfig   `yaml:"shell,omitempty"`
@@ -285,6 +634,7 @@ type Step struct {
 	Branch  *BranchConfig  `yaml:"branch,omitempty"`
 
 	Foreach *ForeachConfig `yaml:"foreach,omitempty"`
+	Gate    *GateConfig    `yaml:"gate,omitempty"`
 	Agent   *AgentConfig   `yaml:"agent,omitempty"`
 }
 
@@ -295,13 +645,22 @@ func (s *Step) IsReady(steps map[string]*Step) bool {
 	}
 	for _, depID := range s.Needs {
 		dep, ok := steps[depID]
-		if !ok || dep.Status != StepStatusDone {
+		if !ok || !dep.Satisfied() {
 			return false
 		}
 	}
 	return true
 }
 
+// Satisfied reports whether the step lets its dependents run: it is done, or
+// was skipped because its if check was false.
+func (s *Step) Satisfied() bool {
+	if s.Status == StepStatusSkipped {
+		return s.SkipReason != nil && s.SkipReason.Kind == SkipReasonConditionFalse
+	}
+	return s.Status == StepStatusDone
+}
+
 // Validate checks the step is well-formed.
 func (s *Step) Validate() error {
 	if s.ID == "" {
@@ -327,6 +686,7 @@ func (s *Step) validateConfig() error {
 		ExecutorExpand:  s.Expand != nil,
 		ExecutorBranch:  s.Branch != nil,
 		ExecutorForeach: s.Foreach != nil,
+		ExecutorGate:    s.Gate != nil,
 		ExecutorAgent:   s.Agent != nil,
 	}
 
@@ -374,15 +734,16 @@ func (s *Step) Fail(err *StepError) error {
 	return nil
 }
 
-// Skip marks the step as skipped (because a dependency failed).
-func (s *Step) Skip(reason string) error {
+// Skip marks the step as skipped (because a dependency failed or it ran outside its only_between window).
+func (s *Step) Skip(reason *SkipReason) error {
 	if !s.Status.CanTransitionTo(StepStatusSkipped) {
 		return fmt.Errorf("cannot skip step in status %s", s.Status)
 	}
 	now := time.Now()
 	s.Status = StepStatusSkipped
 	s.DoneAt = &now
-	s.Error = &StepError{Message: reason}
+	s.SkipReason = reason
+	s.Error = &StepError{Message: reason.Message}
 	return nil
 }
 
@@ -403,6 +764,87 @@ func (s *Step) Start() error {
 	now := time.Now()
 	s.Status = StepStatusRunning
 	s.StartedAt = &now
+	s.AcknowledgedAt = nil
+	s.NudgedAt = nil
+	s.Nudges = 0
+	s.LogOffset = 0
+	s.CancelPromptedAt = nil
+	// Partial output from an interrupted earlier attempt no longer applies
+	if s.Error != nil && s.Error.Type == StepErrorInterrupted {
+		s.Error = nil
+	}
+	return nil
+}
+
+// Rerun resets a finished step to pending so it executes again (for watch mode).
+// Outputs, errors, and expansion tracking from the previous execution are discarded.
+func (s *Step) Rerun() error {
+	if !s.Status.IsTerminal() {
+		return fmt.Errorf("can only rerun finished steps, got %s", s.Status)
+	}
+	s.Status = StepStatusPending
+	s.StartedAt = nil
+	s.DoneAt = nil
+	s.RetryAt = nil
+	s.InterruptedAt = nil
+	s.CancelPromptedAt = nil
+	s.AcknowledgedAt = nil
+	s.NudgedAt = nil
+	s.Nudges = 0
+	s.LogOffset = 0
+	s.ValidationRetries = 0
+	s.Outputs = nil
+	s.TrimmedOutputs = nil
+	s.InputChecksums = nil
+	s.Error = nil
+	s.SkipReason = nil
+	s.ExpandedInto = nil
+	s.RecoveryInto = nil
+
+	// Shell steps run as branches (shell-as-sugar); restore the original config
+	if s.Executor == ExecutorShell && s.Shell == nil && s.Branch != nil {
+		s.Shell = &ShellConfig{
+			Command: s.Branch.Condition,
+			Workdir: s.Branch.Workdir,
+			Env:     s.Branch.Env,
+			OnError: s.Branch.OnError,
+			Outputs: s.Branch.Outputs,
+			RunAs:   s.Branch.RunAs,
+
+			CancelSignal: s.Branch.CancelSignal,
+			CancelGrace:  s.Branch.CancelGrace,
+		}
+		s.Branch = nil
+	}
+	return nil
+}
+
+// RetryDelay returns how long to wait before the retry numbered attempt
+// (1 for the first retry), following the step's backoff.
+func (s *Step) RetryDelay(attempt int) (time.Duration, error) {
+	if s.Backoff == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(s.Backoff)
+	if err != nil {
+		return 0, fmt.Errorf("invalid backoff %q: %w", s.Backoff, err)
+	}
+	if s.BackoffMultiplier > 0 && attempt > 1 {
+		delay = time.Duration(float64(delay) * math.Pow(s.BackoffMultiplier, float64(attempt-1)))
+	}
+	return delay, nil
+}
+
+// Bypass marks a pending step done without running it, with empty outputs
+// (for meow run --skip-to).
+func (s *Step) Bypass() error {
+	if s.Status != StepStatusPending {
+		return fmt.Errorf("can only bypass pending steps, got %s", s.Status)
+	}
+	now := time.Now()
+	s.Status = StepStatusDone
+	s.DoneAt = &now
+	s.Outputs = map[string]any{}
 	return nil
 }
 
@@ -413,5 +855,6 @@ func (s *Step) ResetToPending() error {
 	}
 	s.Status = StepStatusPending
 	s.StartedAt = nil
+	s.AcknowledgedAt = nil
 	return nil
 }
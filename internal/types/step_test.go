@@ -9,6 +9,7 @@ func TestExecutorType(t *testing.T) {
 		validExecutors := []ExecutorType{
 			ExecutorShell, ExecutorSpawn, ExecutorKill,
 			ExecutorExpand, ExecutorBranch, ExecutorAgent, ExecutorForeach,
+			ExecutorGate,
 		}
 		for _, e := range validExecutors {
 			if !e.Valid() {
@@ -18,16 +19,16 @@ func TestExecutorType(t *testing.T) {
 	})
 
 	t.Run("Valid returns false for invalid executors", func(t *testing.T) {
-		invalid := ExecutorType("gate") // Gate is NOT an executor!
+		invalid := ExecutorType("approval") // Approval is branch + meow await-approval
 		if invalid.Valid() {
-			t.Error("gate should not be a valid executor")
+			t.Error("approval should not be a valid executor")
 		}
 	})
 
 	t.Run("IsOrchestrator for orchestrator executors", func(t *testing.T) {
 		orchestratorExecutors := []ExecutorType{
 			ExecutorShell, ExecutorSpawn, ExecutorKill,
-			ExecutorExpand, ExecutorBranch, ExecutorForeach,
+			ExecutorExpand, ExecutorBranch, ExecutorForeach, ExecutorGate,
 		}
 		for _, e := range orchestratorExecutors {
 			if !e.IsOrchestrator() {
@@ -341,6 +342,38 @@ func TestGetMaxConcurrent(t *testing.T) {
 	}
 }
 
+func TestFailureLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  string
+		iterations int
+		expected   int
+		wantErr    bool
+	}{
+		{"empty tolerates none", "", 10, 0, false},
+		{"count", "3", 10, 3, false},
+		{"percent", "30%", 10, 3, false},
+		{"percent rounds down", "30%", 7, 2, false},
+		{"all", "100%", 7, 7, false},
+		{"over 100 percent", "150%", 10, 0, true},
+		{"negative", "-1", 10, 0, true},
+		{"not a number", "some", 10, 0, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &ForeachConfig{FailureThreshold: tc.threshold}
+			result, err := cfg.FailureLimit(tc.iterations)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("FailureLimit(%d) with %q error = %v, wantErr %v", tc.iterations, tc.threshold, err, tc.wantErr)
+			}
+			if result != tc.expected {
+				t.Errorf("FailureLimit(%d) with %q = %d, expected %d", tc.iterations, tc.threshold, result, tc.expected)
+			}
+		})
+	}
+}
+
 func TestStepLifecycle(t *testing.T) {
 	t.Run("Start sets running status", func(t *testing.T) {
 		step := &Step{ID: "test", Status: StepStatusPending}
@@ -408,3 +441,16 @@ func TestStepLifecycle(t *testing.T) {
 		}
 	})
 }
+
+func TestStep_IsReady_IfSkippedDependency(t *testing.T) {
+	steps := map[string]*Step{
+		"guarded": {ID: "guarded", Status: StepStatusSkipped, SkipReason: &SkipReason{Kind: SkipReasonConditionFalse}},
+		"blocked": {ID: "blocked", Status: StepStatusSkipped, SkipReason: &SkipReason{Kind: SkipReasonDependencyFailed}},
+	}
+	if !(&Step{Status: StepStatusPending, Needs: []string{"guarded"}}).IsReady(steps) {
+		t.Error("step needing an if-skipped step is not ready")
+	}
+	if (&Step{Status: StepStatusPending, Needs: []string{"blocked"}}).IsReady(steps) {
+		t.Error("step needing a step skipped for a failed dependency is ready")
+	}
+}
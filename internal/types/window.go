@@ -0,0 +1,61 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Values for Step.OutsideWindow.
+const (
+	OutsideWindowWait = "wait" // Stay pending until the window opens (default)
+	OutsideWindowSkip = "skip" // Skip the step (and, by cascade, its dependents)
+)
+
+// TimeWindow is a daily window of wall-clock time, parsed from "HH:MM-HH:MM".
+// The start is inclusive and the end exclusive. A window whose end is before
+// its start wraps past midnight (e.g., "22:00-06:00").
+type TimeWindow struct {
+	Start time.Duration // Offset from midnight
+	End   time.Duration // Offset from midnight
+}
+
+// ParseTimeWindow parses a window like "09:00-17:00".
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: want HH:MM-HH:MM", s)
+	}
+	start, err := parseClockTime(strings.TrimSpace(startStr))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	end, err := parseClockTime(strings.TrimSpace(endStr))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	if start == end {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: start and end are equal", s)
+	}
+	return TimeWindow{Start: start, End: end}, nil
+}
+
+// parseClockTime parses "HH:MM" into an offset from midnight.
+func parseClockTime(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t (in its own location) falls inside the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
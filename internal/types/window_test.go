@@ -0,0 +1,63 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := []struct {
+		window  string
+		wantErr bool
+	}{
+		{window: "09:00-17:00"},
+		{window: "22:00 - 06:30"},
+		{window: "9:00-17:00"},
+		{window: "09-17", wantErr: true},
+		{window: "09:00", wantErr: true},
+		{window: "09:00-25:00", wantErr: true},
+		{window: "12:00-12:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := ParseTimeWindow(tt.window)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimeWindow(%q) error = %v, wantErr %v", tt.window, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2026, 3, 2, hour, min, 0, 0, time.UTC)
+	}
+
+	day, err := ParseTimeWindow("09:00-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	night, err := ParseTimeWindow("22:00-06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		window TimeWindow
+		at     time.Time
+		want   bool
+	}{
+		{"day start inclusive", day, at(9, 0), true},
+		{"day middle", day, at(12, 30), true},
+		{"day end exclusive", day, at(17, 0), false},
+		{"day before", day, at(8, 59), false},
+		{"overnight late", night, at(23, 15), true},
+		{"overnight early", night, at(5, 59), true},
+		{"overnight end exclusive", night, at(6, 0), false},
+		{"overnight daytime", night, at(12, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Contains(tt.at); got != tt.want {
+			t.Errorf("%s: Contains(%s) = %v, want %v", tt.name, tt.at.Format("15:04"), got, tt.want)
+		}
+	}
+}
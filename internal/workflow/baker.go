@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/akatz-ai/meow/internal/types"
 )
 
+// FailedStepVariable is the variable an on_error recovery template receives:
+// a map with the failed step's id, error, and error_type.
+const FailedStepVariable = "_failed_step"
+
 // Baker transforms template workflows into executable steps.
 type Baker struct {
 	// WorkflowID is the unique identifier for this workflow instance
@@ -23,6 +28,9 @@ type Baker struct {
 
 	// Now allows injecting time for testing
 	Now func() time.Time
+
+	// LookupEnv reads env_vars sources; allows injecting the environment for testing
+	LookupEnv func(key string) (string, bool)
 }
 
 // NewBaker creates a new Baker with default settings.
@@ -34,6 +42,7 @@ func NewBaker(workflowID string) *Baker {
 		WorkflowID: workflowID,
 		VarContext: vc,
 		Now:        time.Now,
+		LookupEnv:  os.LookupEnv,
 	}
 }
 
@@ -41,6 +50,23 @@ func NewBaker(workflowID string) *Baker {
 type BakeResult struct {
 	Steps      []*types.Step // Steps for the workflow
 	WorkflowID string        // Unique workflow instance ID
+
+	// Workflow-level agent prompt wrapping, variables substituted
+	PromptPrefix string
+	PromptSuffix string
+
+	// Total step retries allowed across the run (0 = no shared cap)
+	RetryBudget int
+
+	// Cap in bytes on the outputs stored across the run (0 = no cap)
+	OutputBudget int64
+
+	// Cap on the run's wall-clock time (0 = no cap)
+	Timeout time.Duration
+
+	// Declared workflow outputs, variables substituted (step output
+	// references are left for the orchestrator to resolve at completion)
+	Outputs map[string]string
 }
 
 // BakeWorkflow transforms a workflow into types.Step objects.
@@ -55,9 +81,12 @@ func (b *Baker) BakeWorkflow(workflow *Workflow, vars map[string]any) (*BakeResu
 	for k := range vars {
 		// Built-in variables like __step_prefix__ are injected by the system
 		// and don't need to be declared in the workflow
-		if strings.HasPrefix(k, "__") {
+		if strings.HasPrefix(k, "__") || k == FailedStepVariable {
 			continue
 		}
+		if _, ok := workflow.EnvVars[k]; ok {
+			continue // Explicit values override the environment
+		}
 		if _, ok := workflow.Variables[k]; !ok {
 			// Try to find a similar variable name to suggest
 			suggestion := findSimilarVariable(k, workflow.Variables)
@@ -86,6 +115,13 @@ func (b *Baker) BakeWorkflow(workflow *Workflow, vars map[string]any) (*BakeResu
 		b.VarContext.Set(k, coerced)
 	}
 
+	if err := b.applyEnvVars(workflow.EnvVars); err != nil {
+		return nil, err
+	}
+	if err := b.checkRequiredEnv(workflow.RequiredEnv); err != nil {
+		return nil, err
+	}
+
 	// Apply variable defaults from workflow (preserving types)
 	for name, v := range workflow.Variables {
 		if v.Default != nil && !b.VarContext.Has(name) {
@@ -108,33 +144,316 @@ func (b *Baker) BakeWorkflow(workflow *Workflow, vars map[string]any) (*BakeResu
 
 	// Process steps - create types.Step objects
 	var steps []*types.Step
+	dropped := make(map[string][]string) // dropped step ID -> its needs
+	renamed := make(map[string]string)   // templated step ID -> resolved ID
 	for _, templateStep := range workflow.Steps {
+		if b.omitStep(templateStep) {
+			dropped[templateStep.ID] = templateStep.Needs
+			continue
+		}
 		step, err := b.templateStepToStep(templateStep)
 		if err != nil {
 			return nil, fmt.Errorf("bake step %q: %w", templateStep.ID, err)
 		}
+		if step.ID != templateStep.ID {
+			renamed[templateStep.ID] = step.ID
+		}
 		steps = append(steps, step)
 	}
 
+	promptPrefix, err := b.VarContext.Substitute(workflow.PromptPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("substitute prompt_prefix: %w", err)
+	}
+	promptSuffix, err := b.VarContext.Substitute(workflow.PromptSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("substitute prompt_suffix: %w", err)
+	}
+
+	var outputBudget int64
+	if workflow.OutputBudget != "" {
+		outputBudget, err = types.ParseByteSize(workflow.OutputBudget)
+		if err != nil {
+			return nil, fmt.Errorf("output_budget: %w", err)
+		}
+	}
+
+	var timeout time.Duration
+	if workflow.Timeout != "" {
+		timeout, err = time.ParseDuration(workflow.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("timeout: %w", err)
+		}
+	}
+
+	var outputs map[string]string
+	if len(workflow.Outputs) > 0 {
+		outputs = make(map[string]string, len(workflow.Outputs))
+		for name, source := range workflow.Outputs {
+			substituted, err := b.VarContext.Substitute(source)
+			if err != nil {
+				return nil, fmt.Errorf("substitute outputs.%s: %w", name, err)
+			}
+			outputs[name] = substituted
+		}
+	}
+
+	// Rewire dependencies on omitted steps to the omitted step's own needs,
+	// so ordering through an optional step is preserved.
+	if len(dropped) > 0 {
+		for _, step := range steps {
+			step.Needs = rewireNeeds(step.Needs, dropped)
+		}
+	}
+	if len(renamed) > 0 {
+		seen := make(map[string]bool, len(steps))
+		for _, step := range steps {
+			if seen[step.ID] {
+				return nil, fmt.Errorf("duplicate step id %q after resolving templated step ids", step.ID)
+			}
+			seen[step.ID] = true
+			step.Needs = RenameNeeds(step.Needs, renamed)
+		}
+	}
+
 	return &BakeResult{
-		Steps:      steps,
-		WorkflowID: b.WorkflowID,
+		Steps:        steps,
+		WorkflowID:   b.WorkflowID,
+		PromptPrefix: promptPrefix,
+		PromptSuffix: promptSuffix,
+		RetryBudget:  workflow.RetryBudget,
+		OutputBudget: outputBudget,
+		Timeout:      timeout,
+		Outputs:      outputs,
 	}, nil
 }
 
+// applyEnvVars sets variables sourced from the environment (env_vars) that were
+// not provided explicitly. An unset or empty environment variable falls back to
+// the declared default; if there is none and the variable is required, baking fails.
+func (b *Baker) applyEnvVars(envVars map[string]*EnvVar) error {
+	lookup := b.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	for name, ev := range envVars {
+		if b.VarContext.Has(name) {
+			continue
+		}
+		if value, ok := lookup(ev.Env); ok && value != "" {
+			b.VarContext.Set(name, value)
+			continue
+		}
+		if ev.Default != "" {
+			b.VarContext.Set(name, ev.Default)
+			continue
+		}
+		if ev.Required && !b.VarContext.DeferUndefinedVariables {
+			return fmt.Errorf("required variable %q: environment variable %s is not set", name, ev.Env)
+		}
+	}
+	return nil
+}
+
+// checkRequiredEnv fails baking if an environment variable listed in
+// required_env is not set, so a run that reads it through {{env.NAME}} does
+// not fail partway through. Like required env_vars, it is skipped when
+// undefined variables are deferred.
+func (b *Baker) checkRequiredEnv(names []string) error {
+	if b.VarContext.DeferUndefinedVariables {
+		return nil
+	}
+	lookup := b.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	for _, name := range names {
+		if _, ok := lookup(name); !ok {
+			return fmt.Errorf("required environment variable %s is not set", name)
+		}
+	}
+	return nil
+}
+
+// bakeStepID resolves a templated step ID such as "deploy-{{region}}". An ID
+// whose variables are deferred (a foreach body's item_var) keeps its template
+// form until the iteration binds them.
+func (b *Baker) bakeStepID(id string) (string, error) {
+	if !strings.Contains(id, "{{") {
+		return id, nil
+	}
+	resolved, err := b.VarContext.Substitute(id)
+	if err != nil {
+		return "", fmt.Errorf("id: %w", err)
+	}
+	if strings.Contains(resolved, "{{") {
+		return resolved, nil
+	}
+	return resolved, ValidateResolvedStepID(id, resolved)
+}
+
+// ValidateResolvedStepID checks the ID a templated step ID resolved to. It
+// must be non-empty, and free of dots and whitespace, since expanded steps
+// are named parent.child.
+func ValidateResolvedStepID(template, id string) error {
+	if id == "" || strings.ContainsAny(id, ". \t\r\n") {
+		return fmt.Errorf("step id %q resolves to %q; ids must be non-empty, without dots or whitespace", template, id)
+	}
+	return nil
+}
+
+// RenameNeeds returns needs with the IDs of renamed steps replaced, including
+// as the first segment of a reference into a step's children ("track.done").
+func RenameNeeds(needs []string, renamed map[string]string) []string {
+	if len(needs) == 0 || len(renamed) == 0 {
+		return needs
+	}
+	result := make([]string, len(needs))
+	for i, need := range needs {
+		first, rest, dotted := strings.Cut(need, ".")
+		if id, ok := renamed[need]; ok {
+			need = id
+		} else if id, ok := renamed[first]; ok && dotted {
+			need = id + "." + rest
+		}
+		result[i] = need
+	}
+	return result
+}
+
+// omitStep reports whether an optional step should be dropped because its
+// when_var variable is unset or empty. When undefined variables are deferred
+// (foreach bodies), an unset variable keeps the step since it may be bound later.
+func (b *Baker) omitStep(ts *Step) bool {
+	if ts.WhenVar == "" {
+		return false
+	}
+	if !b.VarContext.Has(ts.WhenVar) {
+		return !b.VarContext.DeferUndefinedVariables
+	}
+	return b.VarContext.Get(ts.WhenVar) == ""
+}
+
+// rewireNeeds replaces references to dropped steps with their dependencies,
+// following chains of dropped steps and removing duplicates.
+func rewireNeeds(needs []string, dropped map[string][]string) []string {
+	if len(needs) == 0 {
+		return needs
+	}
+	var result []string
+	seen := make(map[string]bool)
+	var add func(ids []string)
+	add = func(ids []string) {
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if inherited, ok := dropped[id]; ok {
+				add(inherited)
+				continue
+			}
+			result = append(result, id)
+		}
+	}
+	add(needs)
+	return result
+}
+
 // templateStepToStep converts a template Step to a types.Step.
 func (b *Baker) templateStepToStep(ts *Step) (*types.Step, error) {
+	id, err := b.bakeStepID(ts.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Set step-specific builtins BEFORE substitution
-	b.VarContext.SetBuiltin("step_id", ts.ID)
+	b.VarContext.SetBuiltin("step_id", id)
 
 	// Create base step
 	step := &types.Step{
-		ID:       ts.ID,
+		ID:       id,
 		Executor: types.ExecutorType(ts.Executor),
 		Status:   types.StepStatusPending,
 		Needs:    ts.Needs,
 	}
 
+	// Substitute variables in input globs
+	for _, input := range ts.Inputs {
+		subInput, err := b.VarContext.Substitute(input)
+		if err != nil {
+			return nil, fmt.Errorf("substitute inputs: %w", err)
+		}
+		step.Inputs = append(step.Inputs, subInput)
+	}
+
+	if ts.OnlyBetween != "" {
+		window, err := b.VarContext.Substitute(ts.OnlyBetween)
+		if err != nil {
+			return nil, fmt.Errorf("substitute only_between: %w", err)
+		}
+		if _, err := types.ParseTimeWindow(window); err != nil {
+			return nil, fmt.Errorf("step %s: invalid only_between: %w", ts.ID, err)
+		}
+		step.OnlyBetween = window
+		step.OutsideWindow = ts.OutsideWindow
+	}
+	if ts.Lock != "" {
+		lock, err := b.VarContext.Substitute(ts.Lock)
+		if err != nil {
+			return nil, fmt.Errorf("substitute lock: %w", err)
+		}
+		if !types.ValidLockName(lock) {
+			return nil, fmt.Errorf("step %s: invalid lock %q: use letters, digits, '.', '_' and '-'", ts.ID, lock)
+		}
+		step.Lock = lock
+	}
+	if strings.HasPrefix(ts.OnError, ".") {
+		ref, err := b.VarContext.Substitute(ts.OnError)
+		if err != nil {
+			return nil, fmt.Errorf("substitute on_error: %w", err)
+		}
+		step.OnErrorTemplate = ref
+	}
+	step.Retries = ts.Retries
+	if ts.Retry != nil {
+		step.Retries = ts.Retry.MaxAttempts - 1
+		step.Backoff = ts.Retry.Backoff
+		step.BackoffMultiplier = ts.Retry.BackoffMultiplier
+	}
+
+	if len(ts.Assert) > 0 {
+		step.Assert = make(map[string]types.OutputAssertion, len(ts.Assert))
+		for name, a := range ts.Assert {
+			equals, err := b.VarContext.Substitute(a.Equals)
+			if err != nil {
+				return nil, fmt.Errorf("substitute assert %s: %w", name, err)
+			}
+			matches, err := b.VarContext.Substitute(a.Matches)
+			if err != nil {
+				return nil, fmt.Errorf("substitute assert %s: %w", name, err)
+			}
+			if _, err := regexp.Compile(matches); err != nil {
+				return nil, fmt.Errorf("step %s: invalid matches for assert %q: %w", ts.ID, name, err)
+			}
+			step.Assert[name] = types.OutputAssertion{Equals: equals, Matches: matches}
+		}
+	}
+
+	if len(ts.Requires) > 0 {
+		step.Requires = make(map[string]string, len(ts.Requires))
+		for name, command := range ts.Requires {
+			subCommand, err := b.VarContext.Substitute(command)
+			if err != nil {
+				return nil, fmt.Errorf("substitute requires %s: %w", name, err)
+			}
+			step.Requires[name] = subCommand
+		}
+	}
+	if step.If, err = b.VarContext.Substitute(ts.If); err != nil {
+		return nil, fmt.Errorf("substitute if: %w", err)
+	}
+
 	// Set executor-specific config
 	if err := b.setStepConfig(step, ts); err != nil {
 		return nil, err
@@ -158,6 +477,8 @@ func (b *Baker) setStepConfig(step *types.Step, ts *Step) error {
 		return b.setBranchConfig(step, ts)
 	case types.ExecutorForeach:
 		return b.setForeachConfig(step, ts)
+	case types.ExecutorGate:
+		return b.setGateConfig(step, ts)
 	case types.ExecutorAgent:
 		return b.setAgentConfig(step, ts)
 	default:
@@ -184,6 +505,15 @@ func (b *Baker) setShellConfig(step *types.Step, ts *Step) error {
 		}
 	}
 
+	runAs, err := b.VarContext.Substitute(ts.RunAs)
+	if err != nil {
+		return fmt.Errorf("substitute run_as: %w", err)
+	}
+	cancelSignal, cancelGrace, err := b.bakeCancelSignal(ts)
+	if err != nil {
+		return err
+	}
+
 	// Substitute env values
 	env := make(map[string]string)
 	for k, v := range ts.Env {
@@ -195,20 +525,9 @@ func (b *Baker) setShellConfig(step *types.Step, ts *Step) error {
 	}
 
 	// Convert shell outputs to types format, substituting variables in source paths
-	var outputs map[string]types.OutputSource
-	if len(ts.ShellOutputs) > 0 {
-		outputs = make(map[string]types.OutputSource)
-		for k, v := range ts.ShellOutputs {
-			source := v.Source
-			// Substitute variables in file paths (e.g., "file:.meow/worktrees/{{track_name}}-track/.meow-branch")
-			if source != "" {
-				source, err = b.VarContext.Substitute(source)
-				if err != nil {
-					return fmt.Errorf("substitute shell_outputs.%s.source: %w", k, err)
-				}
-			}
-			outputs[k] = types.OutputSource{Source: source, Type: v.Type}
-		}
+	outputs, err := b.bakeShellOutputs(ts.ShellOutputs)
+	if err != nil {
+		return err
 	}
 
 	step.Shell = &types.ShellConfig{
@@ -217,10 +536,36 @@ func (b *Baker) setShellConfig(step *types.Step, ts *Step) error {
 		Env:     env,
 		OnError: ts.OnError,
 		Outputs: outputs,
+		RunAs:   runAs,
+
+		CancelSignal: cancelSignal,
+		CancelGrace:  cancelGrace,
 	}
 	return nil
 }
 
+// bakeShellOutputs converts shell output sources to types format,
+// substituting variables in file paths (e.g.,
+// "file:.meow/worktrees/{{track_name}}-track/.meow-branch").
+func (b *Baker) bakeShellOutputs(sources map[string]OutputSource) (map[string]types.OutputSource, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	outputs := make(map[string]types.OutputSource)
+	for k, v := range sources {
+		source, err := b.VarContext.Substitute(v.Source)
+		if err != nil {
+			return nil, fmt.Errorf("substitute shell_outputs.%s.source: %w", k, err)
+		}
+		path, err := b.VarContext.Substitute(v.Path)
+		if err != nil {
+			return nil, fmt.Errorf("substitute shell_outputs.%s.path: %w", k, err)
+		}
+		outputs[k] = types.OutputSource{Source: source, Type: v.Type, Artifact: v.Artifact, Pattern: v.Pattern, Required: v.Required, Path: path, Field: v.Field, Line: v.Line}
+	}
+	return outputs, nil
+}
+
 // setSpawnConfig sets SpawnConfig for spawn executor steps.
 func (b *Baker) setSpawnConfig(step *types.Step, ts *Step) error {
 	agent := ts.Agent
@@ -267,6 +612,25 @@ func (b *Baker) setSpawnConfig(step *types.Step, ts *Step) error {
 		}
 	}
 
+	startupDelay, err := b.VarContext.Substitute(ts.StartupDelay)
+	if err != nil {
+		return fmt.Errorf("substitute startup_delay: %w", err)
+	}
+
+	reuseFrom, err := b.VarContext.Substitute(ts.ReuseFrom)
+	if err != nil {
+		return fmt.Errorf("substitute reuse_from: %w", err)
+	}
+
+	var capabilities []string
+	for _, c := range ts.Capabilities {
+		subC, err := b.VarContext.Substitute(c)
+		if err != nil {
+			return fmt.Errorf("substitute capabilities: %w", err)
+		}
+		capabilities = append(capabilities, subC)
+	}
+
 	step.Spawn = &types.SpawnConfig{
 		Agent:         agent,
 		Adapter:       adapter,
@@ -274,6 +638,9 @@ func (b *Baker) setSpawnConfig(step *types.Step, ts *Step) error {
 		Env:           env,
 		ResumeSession: ts.ResumeSession,
 		SpawnArgs:     spawnArgs,
+		StartupDelay:  startupDelay,
+		ReuseFrom:     reuseFrom,
+		Capabilities:  capabilities,
 	}
 	return nil
 }
@@ -295,10 +662,37 @@ func (b *Baker) setKillConfig(step *types.Step, ts *Step) error {
 
 	timeout := 10 // default timeout in seconds
 
+	var gracePeriod time.Duration
+	if ts.GracePeriod != "" {
+		period, err := b.VarContext.Substitute(ts.GracePeriod)
+		if err != nil {
+			return fmt.Errorf("substitute grace_period: %w", err)
+		}
+		gracePeriod, err = time.ParseDuration(period)
+		if err != nil {
+			return fmt.Errorf("invalid grace_period %q: %w", period, err)
+		}
+	}
+
 	step.Kill = &types.KillConfig{
-		Agent:    agent,
-		Graceful: graceful,
-		Timeout:  timeout,
+		Agent:       agent,
+		Graceful:    graceful,
+		Timeout:     timeout,
+		GracePeriod: gracePeriod,
+	}
+	return nil
+}
+
+// setGateConfig sets GateConfig for gate executor steps.
+func (b *Baker) setGateConfig(step *types.Step, ts *Step) error {
+	event, err := b.VarContext.Substitute(ts.WaitForEvent)
+	if err != nil {
+		return fmt.Errorf("substitute wait_for_event: %w", err)
+	}
+
+	step.Gate = &types.GateConfig{
+		WaitForEvent: event,
+		Timeout:      ts.Timeout,
 	}
 	return nil
 }
@@ -398,16 +792,56 @@ func (b *Baker) setForeachConfig(step *types.Step, ts *Step) error {
 		}
 	}
 
+	// Convert and substitute window (supports int or string like "{{window}}")
+	var window string
+	switch v := ts.Window.(type) {
+	case string:
+		window = v
+	case int64:
+		window = fmt.Sprintf("%d", v)
+	case int:
+		window = fmt.Sprintf("%d", v)
+	}
+	if window != "" {
+		window, err = b.VarContext.Substitute(window)
+		if err != nil {
+			return fmt.Errorf("substitute window: %w", err)
+		}
+	}
+
+	// Convert and substitute failure_threshold (a count, or a percentage string)
+	var failureThreshold string
+	switch v := ts.FailureThreshold.(type) {
+	case string:
+		failureThreshold = v
+	case int64:
+		failureThreshold = fmt.Sprintf("%d", v)
+	case int:
+		failureThreshold = fmt.Sprintf("%d", v)
+	}
+	if failureThreshold != "" {
+		failureThreshold, err = b.VarContext.Substitute(failureThreshold)
+		if err != nil {
+			return fmt.Errorf("substitute failure_threshold: %w", err)
+		}
+		if _, _, err := types.ParseFailureThreshold(failureThreshold); err != nil {
+			return err
+		}
+	}
+
 	step.Foreach = &types.ForeachConfig{
-		Items:         items,
-		ItemsFile:     itemsFile,
-		ItemVar:       itemVar,
-		IndexVar:      indexVar,
-		Template:      template,
-		Variables:     variables,
-		Parallel:      parallel,
-		MaxConcurrent: maxConcurrent,
-		Join:          ts.Join,
+		Items:            items,
+		ItemsFile:        itemsFile,
+		ItemVar:          itemVar,
+		IndexVar:         indexVar,
+		Template:         template,
+		Variables:        variables,
+		Parallel:         parallel,
+		MaxConcurrent:    maxConcurrent,
+		Join:             ts.Join,
+		Window:           window,
+		FailureThreshold: failureThreshold,
+		Collect:          ts.Collect,
 	}
 	return nil
 }
@@ -444,19 +878,14 @@ func (b *Baker) setBranchConfig(step *types.Step, ts *Step) error {
 	}
 
 	// Convert shell outputs to types format (shell-as-sugar support), substituting variables
-	var outputs map[string]types.OutputSource
-	if len(ts.ShellOutputs) > 0 {
-		outputs = make(map[string]types.OutputSource)
-		for k, v := range ts.ShellOutputs {
-			source := v.Source
-			if source != "" {
-				source, err = b.VarContext.Substitute(source)
-				if err != nil {
-					return fmt.Errorf("substitute shell_outputs.%s.source: %w", k, err)
-				}
-			}
-			outputs[k] = types.OutputSource{Source: source, Type: v.Type}
-		}
+	outputs, err := b.bakeShellOutputs(ts.ShellOutputs)
+	if err != nil {
+		return err
+	}
+
+	cancelSignal, cancelGrace, err := b.bakeCancelSignal(ts)
+	if err != nil {
+		return err
 	}
 
 	step.Branch = &types.BranchConfig{
@@ -466,6 +895,9 @@ func (b *Baker) setBranchConfig(step *types.Step, ts *Step) error {
 		Env:       env,
 		Outputs:   outputs,
 		OnError:   ts.OnError,
+
+		CancelSignal: cancelSignal,
+		CancelGrace:  cancelGrace,
 	}
 
 	// Convert expansion targets
@@ -538,8 +970,9 @@ func (b *Baker) expansionTargetToTypesBranch(et *ExpansionTarget) (*types.Branch
 
 // setAgentConfig sets AgentConfig for agent executor steps.
 func (b *Baker) setAgentConfig(step *types.Step, ts *Step) error {
+	// A capability requirement leaves the agent to be chosen at dispatch
 	agent := ts.Agent
-	if agent == "" {
+	if agent == "" && ts.RequiresCapability == "" {
 		agent = b.Assignee
 	}
 
@@ -551,6 +984,11 @@ func (b *Baker) setAgentConfig(step *types.Step, ts *Step) error {
 		}
 	}
 
+	requiresCapability, err := b.VarContext.Substitute(ts.RequiresCapability)
+	if err != nil {
+		return fmt.Errorf("substitute requires_capability: %w", err)
+	}
+
 	prompt := ts.Prompt
 	if prompt != "" {
 		prompt, err = b.VarContext.Substitute(prompt)
@@ -573,20 +1011,118 @@ func (b *Baker) setAgentConfig(step *types.Step, ts *Step) error {
 				Required:    def.Required,
 				Type:        def.Type,
 				Description: def.Description,
+				From:        def.From,
+				FromLog:     def.FromLog,
+				Artifact:    def.Artifact,
+				Min:         def.Min,
+				Max:         def.Max,
+				Enum:        def.Enum,
+				Pattern:     def.Pattern,
 			}
 		}
 	}
 
+	preDelay, err := b.VarContext.Substitute(ts.PreDelay)
+	if err != nil {
+		return fmt.Errorf("substitute pre_delay: %w", err)
+	}
+	postDelay, err := b.VarContext.Substitute(ts.PostDelay)
+	if err != nil {
+		return fmt.Errorf("substitute post_delay: %w", err)
+	}
+	stallTimeout, err := b.VarContext.Substitute(ts.StallTimeout)
+	if err != nil {
+		return fmt.Errorf("substitute stall_timeout: %w", err)
+	}
+	if stallTimeout != "" {
+		if _, err := time.ParseDuration(stallTimeout); err != nil {
+			return fmt.Errorf("invalid stall_timeout %q: %w", stallTimeout, err)
+		}
+	}
+
+	resultFile, err := b.VarContext.Substitute(ts.ResultFile)
+	if err != nil {
+		return fmt.Errorf("substitute result_file: %w", err)
+	}
+
+	cancelPrompt, err := b.VarContext.Substitute(ts.CancelPrompt)
+	if err != nil {
+		return fmt.Errorf("substitute cancel_prompt: %w", err)
+	}
+	cancelGrace, err := b.bakeCancelGrace(ts)
+	if err != nil {
+		return err
+	}
+
+	var nudge *types.NudgePolicy
+	if ts.Nudge != nil {
+		after, err := b.VarContext.Substitute(ts.Nudge.After)
+		if err != nil {
+			return fmt.Errorf("substitute nudge.after: %w", err)
+		}
+		if _, err := types.ParseNudgeAfter(after); err != nil {
+			return err
+		}
+		nudgePrompt, err := b.VarContext.Substitute(ts.Nudge.Prompt)
+		if err != nil {
+			return fmt.Errorf("substitute nudge.prompt: %w", err)
+		}
+		nudge = &types.NudgePolicy{After: after, Prompt: nudgePrompt, MaxNudges: ts.Nudge.MaxNudges}
+	}
+
 	step.Agent = &types.AgentConfig{
-		Agent:   agent,
-		Prompt:  prompt,
-		Mode:    mode,
-		Outputs: outputs,
-		Timeout: ts.Timeout,
+		Agent:              agent,
+		Prompt:             prompt,
+		RequiresCapability: requiresCapability,
+		Mode:               mode,
+		Outputs:            outputs,
+		Timeout:            ts.Timeout,
+		AckTimeout:         ts.AckTimeout,
+		PreDelay:           preDelay,
+		PostDelay:          postDelay,
+		SkipPromptWrap:     ts.SkipPromptWrap,
+		StallTimeout:       stallTimeout,
+		OnStall:            ts.OnStall,
+		CaptureTranscript:  ts.CaptureTranscript,
+		Nudge:              nudge,
+		Completion:         ts.Completion,
+		ResultFile:         resultFile,
+		CancelPrompt:       cancelPrompt,
+		CancelGrace:        cancelGrace,
 	}
 	return nil
 }
 
+// bakeCancelSignal substitutes and validates a command step's cancel_signal
+// and cancel_grace.
+func (b *Baker) bakeCancelSignal(ts *Step) (signal, grace string, err error) {
+	signal, err = b.VarContext.Substitute(ts.CancelSignal)
+	if err != nil {
+		return "", "", fmt.Errorf("substitute cancel_signal: %w", err)
+	}
+	if signal != "" {
+		if _, err := types.ParseCancelSignal(signal); err != nil {
+			return "", "", err
+		}
+	}
+	grace, err = b.bakeCancelGrace(ts)
+	return signal, grace, err
+}
+
+// bakeCancelGrace substitutes and validates a step's cancel_grace.
+func (b *Baker) bakeCancelGrace(ts *Step) (string, error) {
+	grace, err := b.VarContext.Substitute(ts.CancelGrace)
+	if err != nil {
+		return "", fmt.Errorf("substitute cancel_grace: %w", err)
+	}
+	if grace != "" {
+		if _, err := time.ParseDuration(grace); err != nil {
+			return "", fmt.Errorf("invalid cancel_grace %q: %w", grace, err)
+		}
+	}
+	return grace, nil
+}
+
 // findSimilarVariable finds a variable name similar to the given name using Levenshtein distance.
 // Returns the closest match if the distance is <= 2, otherwise returns empty string.
 func findSimilarVariable(name string, variables map[string]*Var) string {
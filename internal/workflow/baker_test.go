@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -62,6 +63,7 @@ func TestBakeWorkflow_ShellExecutor(t *testing.T) {
 				Workdir:  "/tmp",
 				Env:      map[string]string{"FOO": "bar"},
 				OnError:  "continue",
+				RunAs:    "builder",
 			},
 		},
 	}
@@ -100,6 +102,9 @@ func TestBakeWorkflow_ShellExecutor(t *testing.T) {
 	if step.Shell.OnError != "continue" {
 		t.Errorf("expected on_error 'continue', got %q", step.Shell.OnError)
 	}
+	if step.Shell.RunAs != "builder" {
+		t.Errorf("expected run_as 'builder', got %q", step.Shell.RunAs)
+	}
 }
 
 // TestBakeWorkflow_SpawnExecutor tests spawn executor step creation
@@ -155,13 +160,15 @@ func TestBakeWorkflow_KillExecutor(t *testing.T) {
 		Name: "kill-test",
 		Steps: []*Step{
 			{
-				ID:       "stop-agent",
-				Executor: ExecutorKill,
-				Agent:    "claude-worker",
-				Graceful: &graceful,
-				Timeout:  "30s",
+				ID:          "stop-agent",
+				Executor:    ExecutorKill,
+				Agent:       "claude-worker",
+				Graceful:    &graceful,
+				Timeout:     "30s",
+				GracePeriod: "{{grace}}",
 			},
 		},
+		Variables: map[string]*Var{"grace": {Default: "5s"}},
 	}
 
 	baker := NewBaker("run-kill-001")
@@ -185,6 +192,93 @@ func TestBakeWorkflow_KillExecutor(t *testing.T) {
 	if !step.Kill.Graceful {
 		t.Error("expected graceful=true")
 	}
+	if step.Kill.GracePeriod != 5*time.Second {
+		t.Errorf("expected grace_period 5s, got %s", step.Kill.GracePeriod)
+	}
+}
+
+// TestBakeWorkflow_GateExecutor tests gate executor step creation
+func TestBakeWorkflow_GateExecutor(t *testing.T) {
+	workflow := &Workflow{
+		Name:      "gate-test",
+		Variables: map[string]*Var{"env": {Required: true}},
+		Steps: []*Step{
+			{
+				ID:           "approve",
+				Executor:     ExecutorGate,
+				WaitForEvent: "approve-{{env}}",
+				Timeout:      "24h",
+			},
+		},
+	}
+
+	baker := NewBaker("run-gate-001")
+	baker.Now = fixedTime
+
+	result, err := baker.BakeWorkflow(workflow, map[string]any{"env": "prod"})
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+
+	step := result.Steps[0]
+	if step.Executor != types.ExecutorGate {
+		t.Errorf("expected gate executor, got %s", step.Executor)
+	}
+	if step.Gate == nil {
+		t.Fatal("expected GateConfig, got nil")
+	}
+	if step.Gate.WaitForEvent != "approve-prod" {
+		t.Errorf("expected event 'approve-prod', got %q", step.Gate.WaitForEvent)
+	}
+	if step.Gate.Timeout != "24h" {
+		t.Errorf("expected timeout '24h', got %q", step.Gate.Timeout)
+	}
+}
+
+func TestBakeWorkflow_Capabilities(t *testing.T) {
+	workflow := &Workflow{
+		Name:      "capability-test",
+		Variables: map[string]*Var{"accel": {Required: true}},
+		Steps: []*Step{
+			{
+				ID:           "spawn-worker",
+				Executor:     ExecutorSpawn,
+				Agent:        "worker",
+				Capabilities: []string{"{{accel}}", "linux"},
+			},
+			{
+				ID:                 "train",
+				Executor:           ExecutorAgent,
+				RequiresCapability: "{{accel}}",
+				Prompt:             "Train the model",
+				Needs:              []string{"spawn-worker"},
+			},
+		},
+	}
+
+	baker := NewBaker("run-cap-001")
+	baker.Now = fixedTime
+	baker.Assignee = "default-agent"
+
+	result, err := baker.BakeWorkflow(workflow, map[string]any{"accel": "gpu"})
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+
+	spawn := result.Steps[0].Spawn
+	if spawn == nil || len(spawn.Capabilities) != 2 || spawn.Capabilities[0] != "gpu" || spawn.Capabilities[1] != "linux" {
+		t.Errorf("expected spawn capabilities [gpu linux], got %+v", spawn)
+	}
+	agent := result.Steps[1].Agent
+	if agent == nil {
+		t.Fatal("expected AgentConfig, got nil")
+	}
+	if agent.RequiresCapability != "gpu" {
+		t.Errorf("expected requires_capability 'gpu', got %q", agent.RequiresCapability)
+	}
+	if agent.Agent != "" {
+		t.Errorf("expected agent left for dispatch, got %q", agent.Agent)
+	}
 }
 
 // TestBakeWorkflow_ExpandExecutor tests expand executor step creation
@@ -530,6 +624,201 @@ func TestBakeWorkflow_MissingRequiredVariable(t *testing.T) {
 	}
 }
 
+// TestBakeWorkflow_EnvVars tests variables sourced from the environment via [main.env_vars]
+func TestBakeWorkflow_EnvVars(t *testing.T) {
+	tomlStr := `
+[main]
+name = "env-vars"
+
+[main.env_vars]
+repo = { env = "GITHUB_REPO", required = true }
+branch = { env = "GITHUB_REF_NAME", default = "main" }
+
+[[main.steps]]
+id = "report"
+executor = "shell"
+command = "echo {{repo}}@{{branch}}"
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	wf := m.GetWorkflow("main")
+	if err := ValidateFullModule(m); err.HasErrors() {
+		t.Fatalf("validation errors: %v", err)
+	}
+	if ev := wf.EnvVars["repo"]; ev == nil || ev.Env != "GITHUB_REPO" || !ev.Required {
+		t.Fatalf("env_vars.repo = %+v, want required GITHUB_REPO", ev)
+	}
+
+	env := func(vars map[string]string) func(string) (string, bool) {
+		return func(key string) (string, bool) {
+			v, ok := vars[key]
+			return v, ok
+		}
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		vars    map[string]any
+		want    string
+		wantErr string
+	}{
+		{name: "supplied", env: map[string]string{"GITHUB_REPO": "akatz-ai/meow", "GITHUB_REF_NAME": "dev"}, want: "echo akatz-ai/meow@dev"},
+		{name: "default for optional", env: map[string]string{"GITHUB_REPO": "akatz-ai/meow"}, want: "echo akatz-ai/meow@main"},
+		{name: "explicit var overrides env", env: map[string]string{"GITHUB_REPO": "akatz-ai/meow"}, vars: map[string]any{"repo": "fork/meow"}, want: "echo fork/meow@main"},
+		{name: "required missing", env: map[string]string{}, wantErr: `required variable "repo": environment variable GITHUB_REPO is not set`},
+		{name: "required empty", env: map[string]string{"GITHUB_REPO": ""}, wantErr: "GITHUB_REPO is not set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baker := NewBaker("run-env-001")
+			baker.LookupEnv = env(tt.env)
+
+			result, err := baker.BakeWorkflow(wf, tt.vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("BakeWorkflow() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BakeWorkflow() error = %v", err)
+			}
+			if got := result.Steps[0].Shell.Command; got != tt.want {
+				t.Errorf("command = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBakeWorkflow_EnvRefs tests that {{env.NAME}} references are left for
+// dispatch and that required_env is checked up front
+func TestBakeWorkflow_EnvRefs(t *testing.T) {
+	tomlStr := `
+[main]
+name = "env-refs"
+required_env = ["DEPLOY_TOKEN"]
+
+[[main.steps]]
+id = "deploy"
+executor = "shell"
+command = "deploy --token {{env.DEPLOY_TOKEN}} --user {{ env.USER }}"
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if result := ValidateFullModule(m); result.HasErrors() {
+		t.Fatalf("validation errors: %v", result)
+	}
+	wf := m.GetWorkflow("main")
+	if len(wf.RequiredEnv) != 1 || wf.RequiredEnv[0] != "DEPLOY_TOKEN" {
+		t.Fatalf("required_env = %v, want [DEPLOY_TOKEN]", wf.RequiredEnv)
+	}
+
+	baker := NewBaker("run-env-002")
+	baker.LookupEnv = func(key string) (string, bool) {
+		if key == "DEPLOY_TOKEN" {
+			return "s3cret", true
+		}
+		return "", false
+	}
+	result, err := baker.BakeWorkflow(wf, nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow() error = %v", err)
+	}
+	want := "deploy --token {{env.DEPLOY_TOKEN}} --user {{ env.USER }}"
+	if got := result.Steps[0].Shell.Command; got != want {
+		t.Errorf("command = %q, want %q", got, want)
+	}
+
+	baker = NewBaker("run-env-003")
+	baker.LookupEnv = func(string) (string, bool) { return "", false }
+	if _, err := baker.BakeWorkflow(wf, nil); err == nil || !strings.Contains(err.Error(), "required environment variable DEPLOY_TOKEN is not set") {
+		t.Fatalf("BakeWorkflow() error = %v, want required environment variable error", err)
+	}
+}
+
+// TestBakeWorkflow_TemplatedStepIDs tests that variables in step IDs are
+// resolved, with needs following the renamed steps
+func TestBakeWorkflow_TemplatedStepIDs(t *testing.T) {
+	wf := &Workflow{
+		Name:      "deploy",
+		Variables: map[string]*Var{"region": {Required: true}, "zone": {Default: "a"}},
+		Steps: []*Step{
+			{ID: "deploy-{{region}}", Executor: ExecutorShell, Command: "echo {{step_id}}"},
+			{ID: "verify", Executor: ExecutorShell, Command: "echo {{deploy-{{region}}.outputs.stdout}}", Needs: []string{"deploy-{{region}}"}},
+		},
+	}
+
+	result, err := NewBaker("run-ids").BakeWorkflow(wf, map[string]any{"region": "us-east"})
+	if err != nil {
+		t.Fatalf("BakeWorkflow() error = %v", err)
+	}
+	deploy, verify := result.Steps[0], result.Steps[1]
+	if deploy.ID != "deploy-us-east" || deploy.Shell.Command != "echo deploy-us-east" {
+		t.Errorf("deploy = %s running %q, want deploy-us-east", deploy.ID, deploy.Shell.Command)
+	}
+	if len(verify.Needs) != 1 || verify.Needs[0] != "deploy-us-east" {
+		t.Errorf("verify needs = %v, want [deploy-us-east]", verify.Needs)
+	}
+	if want := "echo {{deploy-us-east.outputs.stdout}}"; verify.Shell.Command != want {
+		t.Errorf("verify command = %q, want %q", verify.Shell.Command, want)
+	}
+	if wf.Steps[1].Needs[0] != "deploy-{{region}}" {
+		t.Errorf("template needs modified: %v", wf.Steps[1].Needs)
+	}
+
+	wf.Steps[1].ID = "deploy-{{zone}}"
+	if _, err := NewBaker("run-ids").BakeWorkflow(wf, map[string]any{"region": "a"}); err == nil || !strings.Contains(err.Error(), `duplicate step id "deploy-a"`) {
+		t.Errorf("BakeWorkflow() error = %v, want duplicate step id", err)
+	}
+	if _, err := NewBaker("run-ids").BakeWorkflow(wf, map[string]any{"region": "us.east"}); err == nil || !strings.Contains(err.Error(), `resolves to "deploy-us.east"`) {
+		t.Errorf("BakeWorkflow() error = %v, want invalid resolved id", err)
+	}
+}
+
+// TestBakeWorkflow_If tests that a step's if check is parsed and substituted
+func TestBakeWorkflow_If(t *testing.T) {
+	m, err := ParseModuleString(`
+[main]
+name = "guarded"
+
+[main.variables]
+target = { default = "prod" }
+
+[[main.steps]]
+id = "review"
+executor = "shell"
+command = "echo approved"
+
+[[main.steps]]
+id = "deploy"
+executor = "shell"
+command = "deploy {{target}}"
+needs = ["review"]
+if = "test '{{review.outputs.stdout}}' = approved && test {{target}} = prod"
+`, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if result := ValidateFullModule(m); result.HasErrors() {
+		t.Fatalf("validation errors: %v", result)
+	}
+
+	result, err := NewBaker("run-if").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow() error = %v", err)
+	}
+	want := "test '{{review.outputs.stdout}}' = approved && test prod = prod"
+	if got := result.Steps[1].If; got != want {
+		t.Errorf("if = %q, want %q", got, want)
+	}
+}
+
 // TestBakeWorkflow_DefaultVariable tests that default variables are applied
 func TestBakeWorkflow_DefaultVariable(t *testing.T) {
 	workflow := &Workflow{
@@ -635,6 +924,37 @@ func TestBakeWorkflow_ShellStep(t *testing.T) {
 	}
 }
 
+func TestBakeWorkflow_JSONFileOutput(t *testing.T) {
+	workflow := &Workflow{
+		Name:      "file-output",
+		Variables: map[string]*Var{"dir": {Required: true}},
+		Steps: []*Step{
+			{
+				ID:       "tests",
+				Executor: ExecutorShell,
+				Command:  "make test-report",
+				ShellOutputs: map[string]OutputSource{
+					"failed": {Source: "file", Path: "{{dir}}/report.json", Field: "summary.failed"},
+				},
+			},
+		},
+	}
+
+	baker := NewBaker("run-file-001")
+	baker.Now = fixedTime
+
+	result, err := baker.BakeWorkflow(workflow, map[string]any{"dir": "out"})
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+
+	got := result.Steps[0].Shell.Outputs["failed"]
+	want := types.OutputSource{Source: "file", Path: "out/report.json", Field: "summary.failed"}
+	if got != want {
+		t.Errorf("output = %+v, want %+v", got, want)
+	}
+}
+
 // TestBakeWorkflow_SpawnKillSteps tests spawn/kill executors
 func TestBakeWorkflow_SpawnKillSteps(t *testing.T) {
 	workflow := &Workflow{
@@ -825,6 +1145,454 @@ func TestBakeWorkflow_ConditionWithVariable(t *testing.T) {
 	}
 }
 
+func TestBakeWorkflow_TimeWindow(t *testing.T) {
+	workflow := &Workflow{
+		Name: "window-test",
+		Variables: map[string]*Var{
+			"window": {Default: "22:00-06:00"},
+		},
+		Steps: []*Step{
+			{
+				ID:            "maintenance",
+				Executor:      ExecutorShell,
+				Command:       "make vacuum",
+				OnlyBetween:   "{{window}}",
+				OutsideWindow: "skip",
+			},
+		},
+	}
+
+	baker := NewBaker("run-window-001")
+	baker.Now = fixedTime
+
+	result, err := baker.BakeWorkflow(workflow, nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	step := result.Steps[0]
+	if step.OnlyBetween != "22:00-06:00" || step.OutsideWindow != "skip" {
+		t.Errorf("window = %q/%q, want 22:00-06:00/skip", step.OnlyBetween, step.OutsideWindow)
+	}
+
+	_, err = NewBaker("run-window-002").BakeWorkflow(workflow, map[string]any{"window": "nights"})
+	if err == nil || !strings.Contains(err.Error(), "invalid only_between") {
+		t.Errorf("expected invalid only_between error, got: %v", err)
+	}
+}
+
+func TestBakeWorkflow_PromptWrap(t *testing.T) {
+	workflow := &Workflow{
+		Name: "wrap-test",
+		Variables: map[string]*Var{
+			"repo": {Default: "/src/app"},
+		},
+		PromptPrefix: "Follow {{repo}}/CONTRIBUTING.md.",
+		PromptSuffix: "Be brief.",
+		Steps: []*Step{
+			{ID: "work", Executor: ExecutorAgent, Agent: "worker", Prompt: "Do it"},
+			{ID: "chat", Executor: ExecutorAgent, Agent: "worker", Prompt: "Talk", SkipPromptWrap: true},
+		},
+	}
+
+	result, err := NewBaker("run-wrap-001").BakeWorkflow(workflow, nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	if result.PromptPrefix != "Follow /src/app/CONTRIBUTING.md." {
+		t.Errorf("PromptPrefix = %q", result.PromptPrefix)
+	}
+	if result.PromptSuffix != "Be brief." {
+		t.Errorf("PromptSuffix = %q", result.PromptSuffix)
+	}
+	if result.Steps[0].Agent.SkipPromptWrap || !result.Steps[1].Agent.SkipPromptWrap {
+		t.Errorf("SkipPromptWrap = %v/%v, want false/true",
+			result.Steps[0].Agent.SkipPromptWrap, result.Steps[1].Agent.SkipPromptWrap)
+	}
+}
+
+func TestBakeWorkflow_WorkflowOutputs(t *testing.T) {
+	tomlStr := `
+[main]
+name = "outputs-test"
+
+[main.variables]
+registry = { default = "ghcr.io/acme" }
+
+[main.outputs]
+version = "{{build.outputs.version}}"
+image = "{{registry}}/app:{{build.outputs.version}}"
+
+[[main.steps]]
+id = "build"
+executor = "shell"
+command = "make"
+
+[main.steps.outputs]
+version = { source = "stdout" }
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	result, err := NewBaker("run-outputs-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	want := map[string]string{
+		"version": "{{build.outputs.version}}",
+		"image":   "ghcr.io/acme/app:{{build.outputs.version}}",
+	}
+	if !reflect.DeepEqual(result.Outputs, want) {
+		t.Errorf("Outputs = %v, want %v", result.Outputs, want)
+	}
+
+	if _, err := ParseModuleString("[main]\nname = \"bad\"\n\n[main.outputs]\nversion = 3\n", "bad.toml"); err == nil {
+		t.Error("expected error for non-string workflow output")
+	}
+}
+
+func TestBakeWorkflow_Retries(t *testing.T) {
+	tomlStr := `
+[main]
+name = "retry-test"
+retry_budget = 3
+
+[[main.steps]]
+id = "fetch"
+executor = "shell"
+command = "curl -f https://example.com"
+retries = 2
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	result, err := NewBaker("run-retry-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	if result.RetryBudget != 3 {
+		t.Errorf("RetryBudget = %d, want 3", result.RetryBudget)
+	}
+	if result.Steps[0].Retries != 2 {
+		t.Errorf("Retries = %d, want 2", result.Steps[0].Retries)
+	}
+
+	step := Step{ID: "spawn", Executor: ExecutorSpawn, Agent: "worker", Retries: 1}
+	if err := step.Validate(); err == nil || !strings.Contains(err.Error(), "retries is only supported") {
+		t.Errorf("Validate() = %v, want retries executor error", err)
+	}
+}
+
+func TestBakeWorkflow_RetryPolicy(t *testing.T) {
+	tomlStr := `
+[main]
+name = "retry-test"
+
+[[main.steps]]
+id = "fetch"
+executor = "shell"
+command = "curl -f https://example.com"
+retry = { max_attempts = 3, backoff = "5s", backoff_multiplier = 2 }
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	result, err := NewBaker("run-retry-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	step := result.Steps[0]
+	if step.Retries != 2 || step.Backoff != "5s" || step.BackoffMultiplier != 2 {
+		t.Errorf("retries = %d, backoff = %q, multiplier = %g; want 2, 5s, 2", step.Retries, step.Backoff, step.BackoffMultiplier)
+	}
+	if d, _ := step.RetryDelay(2); d != 10*time.Second {
+		t.Errorf("RetryDelay(2) = %v, want 10s", d)
+	}
+
+	for _, tc := range []struct {
+		step    Step
+		wantErr string
+	}{
+		{Step{ID: "s", Executor: ExecutorSpawn, Agent: "w", Retry: &RetryPolicy{MaxAttempts: 2}}, "retry is only supported"},
+		{Step{ID: "s", Executor: ExecutorShell, Command: "true", Retries: 1, Retry: &RetryPolicy{MaxAttempts: 2}}, "mutually exclusive"},
+		{Step{ID: "s", Executor: ExecutorShell, Command: "true", Retry: &RetryPolicy{}}, "max_attempts"},
+		{Step{ID: "s", Executor: ExecutorShell, Command: "true", Retry: &RetryPolicy{MaxAttempts: 2, Backoff: "soon"}}, "backoff"},
+		{Step{ID: "s", Executor: ExecutorShell, Command: "true", Retry: &RetryPolicy{MaxAttempts: 2, BackoffMultiplier: 0.5}}, "backoff_multiplier"},
+	} {
+		if err := tc.step.Validate(); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("Validate(%+v) = %v, want error containing %q", tc.step.Retry, err, tc.wantErr)
+		}
+	}
+}
+
+func TestBakeWorkflow_OnErrorTemplate(t *testing.T) {
+	tomlStr := `
+[main]
+name = "recovery-test"
+
+[[main.steps]]
+id = "build"
+executor = "shell"
+command = "make"
+on_error = ".recover"
+
+[recover]
+name = "recover"
+
+[[recover.steps]]
+id = "report"
+executor = "shell"
+command = "echo {{_failed_step.id}} failed: {{_failed_step.error}}"
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if err := ValidateFullModule(m); err.HasErrors() {
+		t.Fatalf("validation errors: %v", err)
+	}
+
+	result, err := NewBaker("run-recover-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	if got := result.Steps[0].OnErrorTemplate; got != ".recover" {
+		t.Errorf("OnErrorTemplate = %q, want .recover", got)
+	}
+
+	// The recovery template receives _failed_step without declaring it
+	failed := map[string]any{FailedStepVariable: map[string]string{"id": "build", "error": "exit 2"}}
+	result, err = NewBaker("run-recover-001").BakeWorkflow(m.GetWorkflow("recover"), failed)
+	if err != nil {
+		t.Fatalf("BakeWorkflow(recover) failed: %v", err)
+	}
+	if got := result.Steps[0].Shell.Command; got != "echo build failed: exit 2" {
+		t.Errorf("command = %q, want the failure substituted", got)
+	}
+
+	m, err = ParseModuleString(strings.Replace(tomlStr, ".recover", ".missing", 1), "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if err := ValidateFullModule(m); !strings.Contains(err.Error(), "unknown workflow") {
+		t.Errorf("validation with unknown on_error template = %v, want unknown workflow error", err)
+	}
+}
+
+func TestBakeWorkflow_OutputConstraints(t *testing.T) {
+	tomlStr := `
+[main]
+name = "constraints-test"
+
+[[main.steps]]
+id = "score"
+executor = "agent"
+agent = "reviewer"
+prompt = "Score the change"
+
+[main.steps.outputs]
+count = { type = "number", min = 0, max = 100.5 }
+severity = { type = "string", enum = ["low", "high"] }
+task_id = { type = "string", pattern = '^PROJ-\d+$' }
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	result, err := NewBaker("run-constraints-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	outputs := result.Steps[0].Agent.Outputs
+	count := outputs["count"]
+	if count.Min == nil || *count.Min != 0 || count.Max == nil || *count.Max != 100.5 {
+		t.Errorf("count bounds = %v, %v; want 0, 100.5", count.Min, count.Max)
+	}
+	if got := outputs["severity"].Enum; len(got) != 2 || got[0] != "low" || got[1] != "high" {
+		t.Errorf("severity enum = %v, want [low high]", got)
+	}
+	if got := outputs["task_id"].Pattern; got != `^PROJ-\d+$` {
+		t.Errorf("task_id pattern = %q, want ^PROJ-\\d+$", got)
+	}
+
+	lo, hi := 10.0, 1.0
+	for _, tc := range []struct {
+		def     AgentOutputDef
+		wantErr string
+	}{
+		{AgentOutputDef{Type: "string", Min: &lo}, "require type number"},
+		{AgentOutputDef{Type: "number", Min: &lo, Max: &hi}, "greater than max"},
+		{AgentOutputDef{Type: "number", Pattern: "^1"}, "pattern requires type string"},
+		{AgentOutputDef{Type: "string", Pattern: "PROJ-("}, "invalid pattern"},
+		{AgentOutputDef{Type: "number", From: "result.count", FromLog: "count"}, "from and from_log cannot both be set"},
+	} {
+		step := Step{ID: "s", Executor: ExecutorAgent, Agent: "w", Prompt: "p", Outputs: map[string]AgentOutputDef{"out": tc.def}}
+		if err := step.Validate(); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("Validate(%+v) = %v, want error containing %q", tc.def, err, tc.wantErr)
+		}
+	}
+}
+
+func TestBakeWorkflow_Requires(t *testing.T) {
+	tomlStr := `
+[main]
+name = "requires-test"
+
+[main.variables]
+min_free = { default = "1G" }
+
+[[main.steps]]
+id = "deploy"
+executor = "shell"
+command = "make deploy"
+
+[main.steps.requires]
+"git clean" = "git diff --quiet"
+"disk space available" = "test $(df --output=avail -h . | tail -1) != {{min_free}}"
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	result, err := NewBaker("run-requires-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	want := map[string]string{
+		"git clean":            "git diff --quiet",
+		"disk space available": "test $(df --output=avail -h . | tail -1) != 1G",
+	}
+	if !reflect.DeepEqual(result.Steps[0].Requires, want) {
+		t.Errorf("Requires = %v, want %v", result.Steps[0].Requires, want)
+	}
+
+	step := Step{ID: "deploy", Executor: ExecutorShell, Command: "make", Requires: map[string]string{"git clean": " "}}
+	if err := step.Validate(); err == nil || !strings.Contains(err.Error(), `requires "git clean"`) {
+		t.Errorf("Validate() = %v, want empty precondition error", err)
+	}
+}
+
+func TestBakeWorkflow_OutputBudget(t *testing.T) {
+	tests := []struct {
+		budget  string
+		want    int64
+		wantErr bool
+	}{
+		{budget: `"2MB"`, want: 2 << 20},
+		{budget: `"512 kb"`, want: 512 << 10},
+		{budget: `4096`, want: 4096},
+		{budget: `"lots"`, wantErr: true},
+		{budget: `0`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.budget, func(t *testing.T) {
+			tomlStr := "[main]\nname = \"budget-test\"\noutput_budget = " + tt.budget + "\n\n[[main.steps]]\nid = \"a\"\nexecutor = \"shell\"\ncommand = \"true\"\n"
+			m, err := ParseModuleString(tomlStr, "test.toml")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+
+			result, err := NewBaker("run-budget-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+			if err != nil {
+				t.Fatalf("BakeWorkflow failed: %v", err)
+			}
+			if result.OutputBudget != tt.want {
+				t.Errorf("OutputBudget = %d, want %d", result.OutputBudget, tt.want)
+			}
+		})
+	}
+}
+
+func TestBakeWorkflow_Timeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{timeout: `"90m"`, want: 90 * time.Minute},
+		{timeout: `"soon"`, wantErr: true},
+		{timeout: `"0s"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeout, func(t *testing.T) {
+			tomlStr := "[main]\nname = \"timeout-test\"\ntimeout = " + tt.timeout + "\n\n[[main.steps]]\nid = \"a\"\nexecutor = \"shell\"\ncommand = \"true\"\n"
+			m, err := ParseModuleString(tomlStr, "test.toml")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+
+			result, err := NewBaker("run-timeout-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+			if err != nil {
+				t.Fatalf("BakeWorkflow failed: %v", err)
+			}
+			if result.Timeout != tt.want {
+				t.Errorf("Timeout = %s, want %s", result.Timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestBakeWorkflow_Assert(t *testing.T) {
+	tomlStr := `
+[main]
+name = "assert-test"
+
+[main.variables]
+major = { default = "2" }
+
+[[main.steps]]
+id = "deploy"
+executor = "shell"
+command = "./deploy.sh"
+
+[main.steps.outputs]
+status = { source = "stdout" }
+version = { source = "file:VERSION" }
+
+[main.steps.assert]
+status = "ok"
+version = { matches = "^v{{major}}\\." }
+`
+	m, err := ParseModuleString(tomlStr, "test.toml")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if err := ValidateFullModule(m); err.HasErrors() {
+		t.Fatalf("validation errors: %v", err)
+	}
+
+	result, err := NewBaker("run-assert-001").BakeWorkflow(m.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	want := map[string]types.OutputAssertion{
+		"status":  {Equals: "ok"},
+		"version": {Matches: `^v2\.`},
+	}
+	if got := result.Steps[0].Assert; !reflect.DeepEqual(got, want) {
+		t.Errorf("Assert = %+v, want %+v", got, want)
+	}
+}
+
 // TestBakeWorkflow_CodeWithVariable tests variable substitution in shell commands
 func TestBakeWorkflow_CodeWithVariable(t *testing.T) {
 	workflow := &Workflow{
@@ -1087,3 +1087,58 @@ func TestLoader_ResolveWorkflow_InvalidReferences(t *testing.T) {
 		})
 	}
 }
+
+func TestLoader_LoadWorkflow_TemplateVersion(t *testing.T) {
+	projectDir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+
+	write := func(name, version string) {
+		content := fmt.Sprintf(`version = %q
+
+[main]
+name = "main"
+
+[[main.steps]]
+id = "step1"
+executor = "shell"
+command = "echo hello"
+`, version)
+		path := filepath.Join(projectDir, ".meow", "workflows", name+".meow.toml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create module dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write module: %v", err)
+		}
+	}
+	write("compatible", TemplateVersion)
+	write("older", "0.1.0")
+	write("too-new", "99.0.0")
+	write("malformed", "1.2")
+
+	loader := NewLoader(projectDir)
+
+	result, err := loader.LoadWorkflow("compatible")
+	if err != nil {
+		t.Fatalf("LoadWorkflow(compatible) error = %v", err)
+	}
+	if result.Module.Version != TemplateVersion {
+		t.Errorf("Module.Version = %q, want %q", result.Module.Version, TemplateVersion)
+	}
+
+	if _, err := loader.LoadWorkflow("older"); err != nil {
+		t.Errorf("LoadWorkflow(older) error = %v", err)
+	}
+
+	_, err = loader.LoadWorkflow("too-new")
+	if err == nil {
+		t.Fatal("LoadWorkflow(too-new) succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), "template requires template version 99.0.0") {
+		t.Errorf("error = %q, want it to mention \"template requires template version 99.0.0\"", err)
+	}
+
+	if _, err := loader.LoadWorkflow("malformed"); err == nil || !strings.Contains(err.Error(), "invalid version") {
+		t.Errorf("LoadWorkflow(malformed) error = %v, want invalid version", err)
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/akatz-ai/meow/internal/types"
 )
 
 // FileFormat indicates the template file format.
@@ -18,24 +22,61 @@ const (
 	FormatModule FileFormat = iota // [workflow-name] sections
 )
 
+// TemplateVersion is the template feature set this binary supports. A module
+// declaring a newer top-level version fails to load instead of running with
+// fields this binary would silently ignore. Bump the minor version whenever
+// templates gain fields or executors.
+const TemplateVersion = "0.2.0"
+
 // Module represents a parsed module file containing one or more workflows.
 type Module struct {
 	Path      string               // File path for error messages
+	Version   string               // Template version the module requires (optional)
 	Workflows map[string]*Workflow // Named workflows
 }
 
 // Workflow represents a single workflow within a module.
 type Workflow struct {
-	Name        string          `toml:"name"`
-	Description string          `toml:"description,omitempty"`
-	Internal    bool            `toml:"internal,omitempty"` // Cannot be called from outside
-	Variables   map[string]*Var `toml:"variables,omitempty"`
-	Steps       []*Step         `toml:"steps"`
+	Name        string                 `toml:"name"`
+	Description string                 `toml:"description,omitempty"`
+	Internal    bool                   `toml:"internal,omitempty"` // Cannot be called from outside
+	Variables   map[string]*Var        `toml:"variables,omitempty"`
+	EnvVars     map[string]*EnvVar     `toml:"env_vars,omitempty"`     // Variables read from the environment at startup
+	RequiredEnv []string               `toml:"required_env,omitempty"` // Environment variables that must be set at startup
+	Requires    *types.RunRequirements `toml:"requires,omitempty"`     // Binaries and environment the run checks before starting
+	Steps       []*Step                `toml:"steps"`
 
 	// Conditional cleanup scripts - all opt-in, no cleanup by default
 	CleanupOnSuccess string `toml:"cleanup_on_success,omitempty"` // Runs when all steps complete successfully
 	CleanupOnFailure string `toml:"cleanup_on_failure,omitempty"` // Runs when a step fails
 	CleanupOnStop    string `toml:"cleanup_on_stop,omitempty"`    // Runs on SIGINT/SIGTERM or meow stop
+	CleanupOrder     string `toml:"cleanup_order,omitempty"`      // agents_first | script_first (default: agents_first)
+
+	// Extra cleanup scripts, each run on any trigger if its condition exits 0
+	CleanupWhen []types.ConditionalCleanup `toml:"cleanup_when,omitempty"`
+
+	// Scripts run once before the first agent spawns and once after the last is killed
+	BeforeAllAgents string `toml:"before_all_agents,omitempty"`
+	AfterAllAgents  string `toml:"after_all_agents,omitempty"`
+
+	// Boilerplate wrapped around every agent step's prompt at injection
+	PromptPrefix string `toml:"prompt_prefix,omitempty"`
+	PromptSuffix string `toml:"prompt_suffix,omitempty"`
+
+	// Total step retries allowed across the run (0 = no shared cap)
+	RetryBudget int `toml:"retry_budget,omitempty"`
+
+	// Cap on the outputs stored across the run, in bytes or as a size like
+	// "1MB" (empty = no cap)
+	OutputBudget string `toml:"output_budget,omitempty"`
+
+	// Cap on the whole run's wall-clock time, as a duration like "2h"
+	// (empty = no cap)
+	Timeout string `toml:"timeout,omitempty"`
+
+	// Results the whole workflow produces, each sourced from step outputs
+	// (e.g., version = "{{build.outputs.version}}"); resolved at completion
+	Outputs map[string]string `toml:"outputs,omitempty"`
 }
 
 // GetWorkflow returns the workflow with the given name, or nil if not found.
@@ -75,6 +116,33 @@ func ParseModuleReader(r io.Reader, path string) (*Module, error) {
 
 // ParseModuleString parses a module-format TOML from a string.
 func ParseModuleString(content string, path string) (*Module, error) {
+	module, err := parseModuleContent(content, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the module
+	if err := module.Validate(); err != nil {
+		return nil, fmt.Errorf("validate module: %w", err)
+	}
+
+	return module, nil
+}
+
+// ParseModuleFileUnvalidated parses a module file without the fail-fast
+// structural checks of Validate, so that ValidateFullModule can report every
+// problem in a broken module instead of stopping at the first.
+func ParseModuleFileUnvalidated(path string) (*Module, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read module file: %w", err)
+	}
+
+	return parseModuleContent(string(content), path)
+}
+
+// parseModuleContent decodes the TOML and parses each workflow table.
+func parseModuleContent(content string, path string) (*Module, error) {
 	// Parse into a map first to get workflow names
 	var raw map[string]any
 	if _, err := toml.Decode(content, &raw); err != nil {
@@ -86,6 +154,13 @@ func ParseModuleString(content string, path string) (*Module, error) {
 		Workflows: make(map[string]*Workflow),
 	}
 
+	if v, ok := raw["version"].(string); ok {
+		if err := checkTemplateVersion(v); err != nil {
+			return nil, err
+		}
+		module.Version = v
+	}
+
 	// Parse each top-level key as a workflow
 	for name, value := range raw {
 		// Skip if not a table (workflows are tables)
@@ -113,12 +188,43 @@ func ParseModuleString(content string, path string) (*Module, error) {
 		return nil, fmt.Errorf("module has no workflows")
 	}
 
-	// Validate the module
-	if err := module.Validate(); err != nil {
-		return nil, fmt.Errorf("validate module: %w", err)
+	return module, nil
+}
+
+// checkTemplateVersion returns an error if a module's declared version is
+// malformed or newer than TemplateVersion.
+func checkTemplateVersion(version string) error {
+	want, err := parseVersion(version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", version, err)
 	}
+	have, _ := parseVersion(TemplateVersion)
+	for i := range want {
+		if want[i] != have[i] {
+			if want[i] > have[i] {
+				return fmt.Errorf("template requires template version %s (this binary supports up to %s)", version, TemplateVersion)
+			}
+			break
+		}
+	}
+	return nil
+}
 
-	return module, nil
+// parseVersion parses an X.Y.Z semantic version into its components.
+func parseVersion(version string) ([3]int, error) {
+	var v [3]int
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("expected version in X.Y.Z format")
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("expected version in X.Y.Z format")
+		}
+		v[i] = n
+	}
+	return v, nil
 }
 
 // parseWorkflow parses a single workflow from a map.
@@ -149,6 +255,84 @@ func parseWorkflow(name string, data map[string]any) (*Workflow, error) {
 	if v, ok := data["cleanup_on_stop"].(string); ok {
 		w.CleanupOnStop = v
 	}
+	if v, ok := data["cleanup_order"].(string); ok {
+		if v != types.CleanupAgentsFirst && v != types.CleanupScriptFirst {
+			return nil, fmt.Errorf("invalid cleanup_order %q: must be %s or %s", v, types.CleanupAgentsFirst, types.CleanupScriptFirst)
+		}
+		w.CleanupOrder = v
+	}
+	if entries, ok := data["cleanup_when"].([]map[string]any); ok {
+		for i, entry := range entries {
+			condition, _ := entry["condition"].(string)
+			script, _ := entry["script"].(string)
+			if condition == "" || script == "" {
+				return nil, fmt.Errorf("cleanup_when[%d]: condition and script are required", i)
+			}
+			w.CleanupWhen = append(w.CleanupWhen, types.ConditionalCleanup{Condition: condition, Script: script})
+		}
+	}
+
+	// Parse agent lifecycle hooks
+	if v, ok := data["before_all_agents"].(string); ok {
+		w.BeforeAllAgents = v
+	}
+	if v, ok := data["after_all_agents"].(string); ok {
+		w.AfterAllAgents = v
+	}
+
+	// Parse agent prompt wrapping
+	if v, ok := data["prompt_prefix"].(string); ok {
+		w.PromptPrefix = v
+	}
+	if v, ok := data["prompt_suffix"].(string); ok {
+		w.PromptSuffix = v
+	}
+
+	// Parse the shared retry budget
+	if v, ok := data["retry_budget"].(int64); ok {
+		if v < 0 {
+			return nil, fmt.Errorf("retry_budget must not be negative, got %d", v)
+		}
+		w.RetryBudget = int(v)
+	}
+
+	// Parse the output size budget: bytes, or a size string like "1MB"
+	switch v := data["output_budget"].(type) {
+	case int64:
+		if v <= 0 {
+			return nil, fmt.Errorf("output_budget must be positive, got %d", v)
+		}
+		w.OutputBudget = strconv.FormatInt(v, 10)
+	case string:
+		if size, err := types.ParseByteSize(v); err != nil {
+			return nil, fmt.Errorf("output_budget: %w", err)
+		} else if size <= 0 {
+			return nil, fmt.Errorf("output_budget must be positive, got %q", v)
+		}
+		w.OutputBudget = v
+	}
+
+	// Parse the global run timeout
+	if v, ok := data["timeout"].(string); ok {
+		if d, err := time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("timeout: %w", err)
+		} else if d <= 0 {
+			return nil, fmt.Errorf("timeout must be positive, got %q", v)
+		}
+		w.Timeout = v
+	}
+
+	// Parse declared workflow outputs
+	if outputs, ok := data["outputs"].(map[string]any); ok {
+		w.Outputs = make(map[string]string, len(outputs))
+		for outName, outData := range outputs {
+			source, ok := outData.(string)
+			if !ok || source == "" {
+				return nil, fmt.Errorf("outputs.%s must be a string like \"{{step.outputs.field}}\"", outName)
+			}
+			w.Outputs[outName] = source
+		}
+	}
 
 	// Parse variables
 	if vars, ok := data["variables"].(map[string]any); ok {
@@ -173,6 +357,48 @@ func parseWorkflow(name string, data map[string]any) (*Workflow, error) {
 		}
 	}
 
+	// Parse environment-sourced variables
+	if envVars, ok := data["env_vars"].(map[string]any); ok {
+		w.EnvVars = make(map[string]*EnvVar)
+		for varName, varData := range envVars {
+			varMap, ok := varData.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("env_vars.%s must be a table like { env = \"NAME\" }", varName)
+			}
+			ev := &EnvVar{}
+			if env, ok := varMap["env"].(string); ok {
+				ev.Env = env
+			}
+			if req, ok := varMap["required"].(bool); ok {
+				ev.Required = req
+			}
+			if def, ok := varMap["default"].(string); ok {
+				ev.Default = def
+			}
+			if desc, ok := varMap["description"].(string); ok {
+				ev.Description = desc
+			}
+			w.EnvVars[varName] = ev
+		}
+	}
+
+	// Parse environment variables that must be set ({{env.NAME}} references)
+	if names, ok := data["required_env"].([]any); ok {
+		for _, n := range names {
+			if ns, ok := n.(string); ok {
+				w.RequiredEnv = append(w.RequiredEnv, ns)
+			}
+		}
+	}
+
+	// Parse what the run needs from the machine, checked before it starts
+	if req, ok := data["requires"].(map[string]any); ok {
+		w.Requires = &types.RunRequirements{
+			Binaries: parseStringList(req["binaries"]),
+			Env:      parseStringList(req["env"]),
+		}
+	}
+
 	// Parse steps - TOML decoder returns []map[string]any
 	if steps, ok := data["steps"].([]map[string]any); ok {
 		for i, stepMap := range steps {
@@ -221,6 +447,28 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 	if v, ok := data["timeout"].(string); ok {
 		s.Timeout = v
 	}
+	if v, ok := data["when_var"].(string); ok {
+		s.WhenVar = v
+	}
+	if v, ok := data["only_between"].(string); ok {
+		s.OnlyBetween = v
+	}
+	if v, ok := data["outside_window"].(string); ok {
+		s.OutsideWindow = v
+	}
+	if v, ok := data["lock"].(string); ok {
+		s.Lock = v
+	}
+	if v, ok := data["retries"].(int64); ok {
+		s.Retries = int(v)
+	}
+	s.Retry = parseRetryPolicy(data["retry"])
+	s.Assert = parseAssertions(data["assert"])
+	s.Requires = parseRequires(data["requires"])
+	if v, ok := data["if"].(string); ok {
+		s.If = v
+	}
+	s.Nudge = parseNudgePolicy(data["nudge"])
 
 	// Parse needs (dependencies)
 	if needs, ok := data["needs"].([]any); ok {
@@ -231,16 +479,55 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 		}
 	}
 
+	// Parse inputs (watch mode)
+	if inputs, ok := data["inputs"].([]any); ok {
+		for _, in := range inputs {
+			if is, ok := in.(string); ok {
+				s.Inputs = append(s.Inputs, is)
+			}
+		}
+	}
+
 	// Parse agent executor fields
 	if v, ok := data["agent"].(string); ok {
 		s.Agent = v
 	}
+	if v, ok := data["requires_capability"].(string); ok {
+		s.RequiresCapability = v
+	}
 	if v, ok := data["prompt"].(string); ok {
 		s.Prompt = v
 	}
 	if v, ok := data["mode"].(string); ok {
 		s.Mode = v
 	}
+	if v, ok := data["ack_timeout"].(string); ok {
+		s.AckTimeout = v
+	}
+	if v, ok := data["pre_delay"].(string); ok {
+		s.PreDelay = v
+	}
+	if v, ok := data["post_delay"].(string); ok {
+		s.PostDelay = v
+	}
+	if v, ok := data["skip_prompt_wrap"].(bool); ok {
+		s.SkipPromptWrap = v
+	}
+	if v, ok := data["stall_timeout"].(string); ok {
+		s.StallTimeout = v
+	}
+	if v, ok := data["on_stall"].(string); ok {
+		s.OnStall = v
+	}
+	if v, ok := data["capture_transcript"].(bool); ok {
+		s.CaptureTranscript = v
+	}
+	if v, ok := data["completion"].(string); ok {
+		s.Completion = v
+	}
+	if v, ok := data["result_file"].(string); ok {
+		s.ResultFile = v
+	}
 
 	// Parse shell executor fields
 	if v, ok := data["command"].(string); ok {
@@ -252,6 +539,18 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 	if v, ok := data["on_error"].(string); ok {
 		s.OnError = v
 	}
+	if v, ok := data["run_as"].(string); ok {
+		s.RunAs = v
+	}
+	if v, ok := data["cancel_signal"].(string); ok {
+		s.CancelSignal = v
+	}
+	if v, ok := data["cancel_prompt"].(string); ok {
+		s.CancelPrompt = v
+	}
+	if v, ok := data["cancel_grace"].(string); ok {
+		s.CancelGrace = v
+	}
 
 	// Parse env (used by shell and spawn)
 	if env, ok := data["env"].(map[string]any); ok {
@@ -280,6 +579,24 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 				if typ, ok := vm["type"].(string); ok {
 					os.Type = typ
 				}
+				if artifact, ok := vm["artifact"].(bool); ok {
+					os.Artifact = artifact
+				}
+				if pattern, ok := vm["pattern"].(string); ok {
+					os.Pattern = pattern
+				}
+				if required, ok := vm["required"].(bool); ok {
+					os.Required = required
+				}
+				if path, ok := vm["path"].(string); ok {
+					os.Path = path
+				}
+				if field, ok := vm["field"].(string); ok {
+					os.Field = field
+				}
+				if line, ok := vm["line"].(int64); ok {
+					os.Line = int(line)
+				}
 				s.ShellOutputs[k] = os
 			}
 		}
@@ -295,11 +612,27 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 	if v, ok := data["spawn_args"].(string); ok {
 		s.SpawnArgs = v
 	}
+	if v, ok := data["startup_delay"].(string); ok {
+		s.StartupDelay = v
+	}
+	if v, ok := data["reuse_from"].(string); ok {
+		s.ReuseFrom = v
+	}
+	if caps, ok := data["capabilities"].([]any); ok {
+		for _, c := range caps {
+			if cs, ok := c.(string); ok {
+				s.Capabilities = append(s.Capabilities, cs)
+			}
+		}
+	}
 
 	// Parse kill executor fields
 	if v, ok := data["graceful"].(bool); ok {
 		s.Graceful = &v
 	}
+	if v, ok := data["grace_period"].(string); ok {
+		s.GracePeriod = v
+	}
 
 	// Parse expand executor fields
 	if v, ok := data["template"].(string); ok {
@@ -312,6 +645,11 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 		}
 	}
 
+	// Parse gate executor fields
+	if v, ok := data["wait_for_event"].(string); ok {
+		s.WaitForEvent = v
+	}
+
 	// Parse branch executor fields
 	if v, ok := data["condition"].(string); ok {
 		s.Condition = v
@@ -337,6 +675,9 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 		}
 		s.OnTimeout = target
 	}
+	if v, ok := data["exhaustive"].(bool); ok {
+		s.Exhaustive = v
+	}
 
 	// Parse foreach executor fields
 	if v, ok := data["items"].(string); ok {
@@ -363,6 +704,21 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 	} else if v, ok := data["max_concurrent"].(int64); ok {
 		s.MaxConcurrent = fmt.Sprintf("%d", v)
 	}
+	// window is an int, or a string for variable substitution
+	if v, ok := data["window"].(string); ok {
+		s.Window = v
+	} else if v, ok := data["window"].(int64); ok {
+		s.Window = v
+	}
+	// failure_threshold is a count or a percentage string like "30%"
+	if v, ok := data["failure_threshold"].(string); ok {
+		s.FailureThreshold = v
+	} else if v, ok := data["failure_threshold"].(int64); ok {
+		s.FailureThreshold = fmt.Sprintf("%d", v)
+	}
+	if v, ok := data["collect"].(string); ok {
+		s.Collect = v
+	}
 	if v, ok := data["join"].(bool); ok {
 		s.Join = &v
 	}
@@ -382,6 +738,31 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 				if desc, ok := defMap["description"].(string); ok {
 					outDef.Description = desc
 				}
+				if from, ok := defMap["from"].(string); ok {
+					outDef.From = from
+				}
+				if fromLog, ok := defMap["from_log"].(string); ok {
+					outDef.FromLog = fromLog
+				}
+				if artifact, ok := defMap["artifact"].(bool); ok {
+					outDef.Artifact = artifact
+				}
+				if n, ok := tomlNumber(defMap["min"]); ok {
+					outDef.Min = &n
+				}
+				if n, ok := tomlNumber(defMap["max"]); ok {
+					outDef.Max = &n
+				}
+				if enum, ok := defMap["enum"].([]any); ok {
+					for _, v := range enum {
+						if str, ok := v.(string); ok {
+							outDef.Enum = append(outDef.Enum, str)
+						}
+					}
+				}
+				if pattern, ok := defMap["pattern"].(string); ok {
+					outDef.Pattern = pattern
+				}
 				s.Outputs[name] = outDef
 			}
 		}
@@ -390,6 +771,122 @@ func parseModuleStep(data map[string]any) (*Step, error) {
 	return s, nil
 }
 
+// parseAssertions parses an assert table. Each entry is either a bare string
+// (shorthand for equals) or a table with equals or matches.
+func parseAssertions(data any) map[string]OutputAssertion {
+	table, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+	assertions := make(map[string]OutputAssertion, len(table))
+	for name, v := range table {
+		assertions[name] = assertionFromValue(v)
+	}
+	return assertions
+}
+
+// assertionFromValue parses one assert entry: a bare value (shorthand for
+// equals) or a table with equals or matches.
+func assertionFromValue(v any) OutputAssertion {
+	switch v := v.(type) {
+	case string:
+		return OutputAssertion{Equals: v}
+	case map[string]any:
+		a := OutputAssertion{}
+		if equals, ok := v["equals"].(string); ok {
+			a.Equals = equals
+		}
+		if matches, ok := v["matches"].(string); ok {
+			a.Matches = matches
+		}
+		return a
+	default:
+		return OutputAssertion{Equals: fmt.Sprint(v)}
+	}
+}
+
+// parseStringList parses an array of strings, keeping non-strings empty so
+// validation reports them.
+func parseStringList(data any) []string {
+	items, ok := data.([]any)
+	if !ok {
+		return nil
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		s, _ := item.(string)
+		list = append(list, s)
+	}
+	return list
+}
+
+// parseRequires parses a requires table of check names to shell commands.
+// Non-string commands are kept empty so validation reports them.
+func parseRequires(data any) map[string]string {
+	table, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+	requires := make(map[string]string, len(table))
+	for name, v := range table {
+		command, _ := v.(string)
+		requires[name] = command
+	}
+	return requires
+}
+
+// parseNudgePolicy parses a nudge table.
+func parseNudgePolicy(data any) *NudgePolicy {
+	table, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+	nudge := &NudgePolicy{}
+	if v, ok := table["after"].(string); ok {
+		nudge.After = v
+	}
+	if v, ok := table["prompt"].(string); ok {
+		nudge.Prompt = v
+	}
+	if v, ok := table["max_nudges"].(int64); ok {
+		nudge.MaxNudges = int(v)
+	}
+	return nudge
+}
+
+// parseRetryPolicy parses a retry table.
+func parseRetryPolicy(data any) *RetryPolicy {
+	table, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+	retry := &RetryPolicy{}
+	if v, ok := table["max_attempts"].(int64); ok {
+		retry.MaxAttempts = int(v)
+	}
+	if v, ok := table["backoff"].(string); ok {
+		retry.Backoff = v
+	}
+	switch v := table["backoff_multiplier"].(type) {
+	case float64:
+		retry.BackoffMultiplier = v
+	case int64:
+		retry.BackoffMultiplier = float64(v)
+	}
+	return retry
+}
+
+// tomlNumber returns a TOML integer or float as a float64.
+func tomlNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
 // parseExpansionTarget parses an expansion target from a map.
 func parseExpansionTarget(data map[string]any) (*ExpansionTarget, error) {
 	target := &ExpansionTarget{}
@@ -397,6 +894,9 @@ func parseExpansionTarget(data map[string]any) (*ExpansionTarget, error) {
 	if v, ok := data["template"].(string); ok {
 		target.Template = v
 	}
+	if v, ok := data["ignore"].(bool); ok {
+		target.Ignore = v
+	}
 
 	// Parse variables
 	if vars, ok := data["variables"].(map[string]any); ok {
@@ -452,6 +952,25 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 	if v, ok := data["timeout"].(string); ok {
 		step.Timeout = v
 	}
+	if v, ok := data["only_between"].(string); ok {
+		step.OnlyBetween = v
+	}
+	if v, ok := data["outside_window"].(string); ok {
+		step.OutsideWindow = v
+	}
+	if v, ok := data["lock"].(string); ok {
+		step.Lock = v
+	}
+	if v, ok := data["retries"].(int64); ok {
+		step.Retries = int(v)
+	}
+	step.Retry = parseRetryPolicy(data["retry"])
+	step.Assert = parseAssertions(data["assert"])
+	step.Requires = parseRequires(data["requires"])
+	if v, ok := data["if"].(string); ok {
+		step.If = v
+	}
+	step.Nudge = parseNudgePolicy(data["nudge"])
 
 	// Parse needs (dependencies)
 	if needs, ok := data["needs"].([]any); ok {
@@ -466,12 +985,42 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 	if v, ok := data["agent"].(string); ok {
 		step.Agent = v
 	}
+	if v, ok := data["requires_capability"].(string); ok {
+		step.RequiresCapability = v
+	}
 	if v, ok := data["prompt"].(string); ok {
 		step.Prompt = v
 	}
 	if v, ok := data["mode"].(string); ok {
 		step.Mode = v
 	}
+	if v, ok := data["ack_timeout"].(string); ok {
+		step.AckTimeout = v
+	}
+	if v, ok := data["pre_delay"].(string); ok {
+		step.PreDelay = v
+	}
+	if v, ok := data["post_delay"].(string); ok {
+		step.PostDelay = v
+	}
+	if v, ok := data["skip_prompt_wrap"].(bool); ok {
+		step.SkipPromptWrap = v
+	}
+	if v, ok := data["stall_timeout"].(string); ok {
+		step.StallTimeout = v
+	}
+	if v, ok := data["on_stall"].(string); ok {
+		step.OnStall = v
+	}
+	if v, ok := data["capture_transcript"].(bool); ok {
+		step.CaptureTranscript = v
+	}
+	if v, ok := data["completion"].(string); ok {
+		step.Completion = v
+	}
+	if v, ok := data["result_file"].(string); ok {
+		step.ResultFile = v
+	}
 
 	// Parse shell executor fields
 	if v, ok := data["command"].(string); ok {
@@ -483,6 +1032,18 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 	if v, ok := data["on_error"].(string); ok {
 		step.OnError = v
 	}
+	if v, ok := data["run_as"].(string); ok {
+		step.RunAs = v
+	}
+	if v, ok := data["cancel_signal"].(string); ok {
+		step.CancelSignal = v
+	}
+	if v, ok := data["cancel_prompt"].(string); ok {
+		step.CancelPrompt = v
+	}
+	if v, ok := data["cancel_grace"].(string); ok {
+		step.CancelGrace = v
+	}
 
 	// Parse env
 	if env, ok := data["env"].(map[string]any); ok {
@@ -504,11 +1065,27 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 	if v, ok := data["spawn_args"].(string); ok {
 		step.SpawnArgs = v
 	}
+	if v, ok := data["startup_delay"].(string); ok {
+		step.StartupDelay = v
+	}
+	if v, ok := data["reuse_from"].(string); ok {
+		step.ReuseFrom = v
+	}
+	if caps, ok := data["capabilities"].([]any); ok {
+		for _, c := range caps {
+			if cs, ok := c.(string); ok {
+				step.Capabilities = append(step.Capabilities, cs)
+			}
+		}
+	}
 
 	// Parse kill executor fields
 	if v, ok := data["graceful"].(bool); ok {
 		step.Graceful = &v
 	}
+	if v, ok := data["grace_period"].(string); ok {
+		step.GracePeriod = v
+	}
 
 	// Parse expand executor fields
 	if v, ok := data["template"].(string); ok {
@@ -521,6 +1098,11 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 		}
 	}
 
+	// Parse gate executor fields
+	if v, ok := data["wait_for_event"].(string); ok {
+		step.WaitForEvent = v
+	}
+
 	// Parse branch executor fields
 	if v, ok := data["condition"].(string); ok {
 		step.Condition = v
@@ -546,6 +1128,9 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 		}
 		step.OnTimeout = target
 	}
+	if v, ok := data["exhaustive"].(bool); ok {
+		step.Exhaustive = v
+	}
 
 	// Parse foreach executor fields
 	if v, ok := data["items"].(string); ok {
@@ -572,6 +1157,21 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 	} else if v, ok := data["max_concurrent"].(int64); ok {
 		step.MaxConcurrent = fmt.Sprintf("%d", v)
 	}
+	// window is an int, or a string for variable substitution
+	if v, ok := data["window"].(string); ok {
+		step.Window = v
+	} else if v, ok := data["window"].(int64); ok {
+		step.Window = v
+	}
+	// failure_threshold is a count or a percentage string like "30%"
+	if v, ok := data["failure_threshold"].(string); ok {
+		step.FailureThreshold = v
+	} else if v, ok := data["failure_threshold"].(int64); ok {
+		step.FailureThreshold = fmt.Sprintf("%d", v)
+	}
+	if v, ok := data["collect"].(string); ok {
+		step.Collect = v
+	}
 	if v, ok := data["join"].(bool); ok {
 		step.Join = &v
 	}
@@ -591,6 +1191,31 @@ func parseInlineStep(data map[string]any) (*InlineStep, error) {
 				if desc, ok := defMap["description"].(string); ok {
 					outDef.Description = desc
 				}
+				if from, ok := defMap["from"].(string); ok {
+					outDef.From = from
+				}
+				if fromLog, ok := defMap["from_log"].(string); ok {
+					outDef.FromLog = fromLog
+				}
+				if artifact, ok := defMap["artifact"].(bool); ok {
+					outDef.Artifact = artifact
+				}
+				if n, ok := tomlNumber(defMap["min"]); ok {
+					outDef.Min = &n
+				}
+				if n, ok := tomlNumber(defMap["max"]); ok {
+					outDef.Max = &n
+				}
+				if enum, ok := defMap["enum"].([]any); ok {
+					for _, v := range enum {
+						if str, ok := v.(string); ok {
+							outDef.Enum = append(outDef.Enum, str)
+						}
+					}
+				}
+				if pattern, ok := defMap["pattern"].(string); ok {
+					outDef.Pattern = pattern
+				}
 				step.Outputs[name] = outDef
 			}
 		}
@@ -633,6 +1258,11 @@ func (w *Workflow) Validate() error {
 		if step.Executor == ExecutorExpand {
 			expandSteps[step.ID] = true
 		}
+		if step.Executor == ExecutorBranch {
+			if err := step.validateBranchTargets(); err != nil {
+				return fmt.Errorf("step[%d] %q: %w", i, step.ID, err)
+			}
+		}
 	}
 
 	// Validate dependencies reference existing steps
@@ -752,9 +1382,9 @@ func (r *ModuleValidationResult) Add(workflow, stepID, field, message, suggest s
 func ValidateFullModule(m *Module) *ModuleValidationResult {
 	result := &ModuleValidationResult{}
 
-	// Validate each workflow
-	for name, w := range m.Workflows {
-		validateModuleWorkflow(m, name, w, result)
+	// Validate each workflow, in name order so reports are stable
+	for _, name := range sortedMapKeys(m.Workflows) {
+		validateModuleWorkflow(m, name, m.Workflows[name], result)
 	}
 
 	// Validate cross-workflow references
@@ -774,6 +1404,37 @@ func validateModuleWorkflow(m *Module, name string, w *Workflow, result *ModuleV
 		return
 	}
 
+	for varName, ev := range w.EnvVars {
+		if ev.Env == "" {
+			result.Add(name, "", "env_vars."+varName, "env_vars entry has no env name",
+				fmt.Sprintf("add env = \"%s\"", strings.ToUpper(varName)))
+		}
+		if _, exists := w.Variables[varName]; exists {
+			result.Add(name, "", "env_vars."+varName, "variable is declared in both variables and env_vars",
+				"remove one of the declarations")
+		}
+	}
+	for i, env := range w.RequiredEnv {
+		if env == "" {
+			result.Add(name, "", fmt.Sprintf("required_env[%d]", i), "required_env entry is empty",
+				"remove the entry or name an environment variable")
+		}
+	}
+	if w.Requires != nil {
+		for i, bin := range w.Requires.Binaries {
+			if bin == "" {
+				result.Add(name, "", fmt.Sprintf("requires.binaries[%d]", i), "requires.binaries entry is empty",
+					"remove the entry or name a binary")
+			}
+		}
+		for i, env := range w.Requires.Env {
+			if env == "" {
+				result.Add(name, "", fmt.Sprintf("requires.env[%d]", i), "requires.env entry is empty",
+					"remove the entry or name an environment variable")
+			}
+		}
+	}
+
 	// Check for duplicate step IDs and track expand steps
 	stepIDs := make(map[string]int)
 	expandSteps := make(map[string]bool)
@@ -794,6 +1455,8 @@ func validateModuleWorkflow(m *Module, name string, w *Workflow, result *ModuleV
 		if step.Executor == ExecutorExpand {
 			expandSteps[step.ID] = true
 		}
+
+		validateModuleStepSchema(name, step, result)
 	}
 
 	// Validate dependencies
@@ -824,15 +1487,45 @@ func validateModuleWorkflow(m *Module, name string, w *Workflow, result *ModuleV
 			"remove one of the dependencies to break the cycle")
 	}
 
-	// Validate variable references
+	// Validate variable and output references
 	validateModuleVariableReferences(m, name, w, result)
+	validateModuleOutputReferences(name, w, result)
+}
+
+// validExecutors lists the executor names accepted in templates.
+var validExecutors = []ExecutorType{
+	ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand,
+	ExecutorBranch, ExecutorForeach, ExecutorGate, ExecutorAgent,
+}
+
+// validateModuleStepSchema checks that a step names a known executor and sets
+// the fields that executor requires.
+func validateModuleStepSchema(workflowName string, step *Step, result *ModuleValidationResult) {
+	if step.Executor == "" {
+		result.Add(workflowName, step.ID, "executor", "executor is required",
+			"set executor = \"shell\", \"agent\", \"spawn\", ...")
+		return
+	}
+	if !step.Executor.Valid() {
+		candidates := make(map[string]bool, len(validExecutors))
+		for _, e := range validExecutors {
+			candidates[string(e)] = true
+		}
+		result.Add(workflowName, step.ID, "executor",
+			fmt.Sprintf("unknown executor %q", step.Executor),
+			findSimilarInBoolMap(string(step.Executor), candidates))
+		return
+	}
+	if err := step.Validate(); err != nil {
+		result.Add(workflowName, step.ID, "", err.Error(), "")
+	}
 }
 
 // validateLocalReferences checks that all local template references (.workflow syntax)
 // exist in the module and respects internal visibility.
 func validateLocalReferences(m *Module, result *ModuleValidationResult) {
-	for workflowName, w := range m.Workflows {
-		for _, step := range w.Steps {
+	for _, workflowName := range sortedMapKeys(m.Workflows) {
+		for _, step := range m.Workflows[workflowName].Steps {
 			// Check template field
 			checkLocalRef(m, workflowName, step.ID, "template", step.Template, result)
 
@@ -846,6 +1539,9 @@ func validateLocalReferences(m *Module, result *ModuleValidationResult) {
 			if step.OnTimeout != nil {
 				checkLocalRef(m, workflowName, step.ID, "on_timeout.template", step.OnTimeout.Template, result)
 			}
+			if strings.HasPrefix(step.OnError, ".") {
+				checkLocalRef(m, workflowName, step.ID, "on_error", step.OnError, result)
+			}
 		}
 	}
 }
@@ -912,11 +1608,14 @@ func validateModuleVariableReferences(m *Module, workflowName string, w *Workflo
 	for varName := range w.Variables {
 		defined[varName] = true
 	}
+	for varName := range w.EnvVars {
+		defined[varName] = true
+	}
 
 	// Add builtins
 	builtins := []string{
 		"timestamp", "date", "time", "agent", "bead_id", "molecule_id",
-		"workflow_id", "step_id",
+		"workflow_id", "step_id", FailedStepVariable,
 	}
 	for _, b := range builtins {
 		defined[b] = true
@@ -924,37 +1623,210 @@ func validateModuleVariableReferences(m *Module, workflowName string, w *Workflo
 
 	// Check all string fields in steps for variable references
 	for _, step := range w.Steps {
-		checkModuleVarRefs(step.Command, workflowName, step.ID, "command", defined, result)
-		checkModuleVarRefs(step.Prompt, workflowName, step.ID, "prompt", defined, result)
-		checkModuleVarRefs(step.Condition, workflowName, step.ID, "condition", defined, result)
+		if step.WhenVar != "" && !defined[step.WhenVar] {
+			result.Add(workflowName, step.ID, "when_var",
+				fmt.Sprintf("undefined variable %q", step.WhenVar),
+				findSimilarInBoolMap(step.WhenVar, defined))
+		}
 
-		for k, v := range step.Variables {
-			// Only check string values for variable references (typed values are preserved as-is)
-			if vs, ok := v.(string); ok {
-				checkModuleVarRefs(vs, workflowName, step.ID, fmt.Sprintf("variables.%s", k), defined, result)
+		// A foreach step's item and index variables are in scope for the
+		// variables it passes to each iteration
+		stepDefined := defined
+		if step.Executor == ExecutorForeach {
+			stepDefined = make(map[string]bool, len(defined)+2)
+			for k := range defined {
+				stepDefined[k] = true
+			}
+			if step.ItemVar != "" {
+				stepDefined[step.ItemVar] = true
+			}
+			if step.IndexVar != "" {
+				stepDefined[step.IndexVar] = true
+			}
+		}
+
+		for _, f := range moduleStepFields(step) {
+			scope := defined
+			if strings.HasPrefix(f.name, "variables.") {
+				scope = stepDefined
 			}
+			checkModuleVarRefs(f.text, workflowName, step.ID, f.name, scope, result)
+		}
+	}
+}
+
+// stepField is a templated string field of a step, named as in the TOML.
+type stepField struct {
+	name string
+	text string
+}
+
+// moduleStepFields returns the string fields of a step that may contain
+// {{...}} references, in a stable order.
+func moduleStepFields(step *Step) []stepField {
+	fields := []stepField{
+		{"id", step.ID},
+		{"agent", step.Agent},
+		{"requires_capability", step.RequiresCapability},
+		{"prompt", step.Prompt},
+		{"command", step.Command},
+		{"workdir", step.Workdir},
+		{"run_as", step.RunAs},
+		{"cancel_signal", step.CancelSignal},
+		{"cancel_prompt", step.CancelPrompt},
+		{"cancel_grace", step.CancelGrace},
+		{"condition", step.Condition},
+		{"wait_for_event", step.WaitForEvent},
+		{"template", step.Template},
+		{"items", step.Items},
+		{"items_file", step.ItemsFile},
+		{"only_between", step.OnlyBetween},
+		{"lock", step.Lock},
+		{"on_error", step.OnError},
+		{"result_file", step.ResultFile},
+	}
+	if step.Nudge != nil {
+		fields = append(fields,
+			stepField{"nudge.after", step.Nudge.After},
+			stepField{"nudge.prompt", step.Nudge.Prompt})
+	}
+	for _, k := range sortedMapKeys(step.Assert) {
+		fields = append(fields,
+			stepField{"assert." + k + ".equals", step.Assert[k].Equals},
+			stepField{"assert." + k + ".matches", step.Assert[k].Matches})
+	}
+	for _, k := range sortedMapKeys(step.Requires) {
+		fields = append(fields, stepField{"requires." + k, step.Requires[k]})
+	}
+	fields = append(fields, stepField{"if", step.If})
+	for _, k := range sortedMapKeys(step.Env) {
+		fields = append(fields, stepField{"env." + k, step.Env[k]})
+	}
+	fields = appendVariableFields(fields, "variables.", step.Variables)
+
+	targets := []struct {
+		name   string
+		target *ExpansionTarget
+	}{
+		{"on_true", step.OnTrue},
+		{"on_false", step.OnFalse},
+		{"on_timeout", step.OnTimeout},
+	}
+	for _, t := range targets {
+		if t.target != nil {
+			fields = appendVariableFields(fields, t.name+".variables.", t.target.Variables)
 		}
+	}
+	return fields
+}
 
-		if step.OnTrue != nil {
-			checkModuleExpansionVarRefs(step.OnTrue, workflowName, step.ID, "on_true", defined, result)
+// appendVariableFields adds the string values of vars as fields named prefix+key.
+// Typed values are preserved as-is and never contain references.
+func appendVariableFields(fields []stepField, prefix string, vars map[string]any) []stepField {
+	for _, k := range sortedMapKeys(vars) {
+		if vs, ok := vars[k].(string); ok {
+			fields = append(fields, stepField{prefix + k, vs})
 		}
-		if step.OnFalse != nil {
-			checkModuleExpansionVarRefs(step.OnFalse, workflowName, step.ID, "on_false", defined, result)
+	}
+	return fields
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateModuleOutputReferences checks that {{step.outputs.key}} references
+// name a step in the workflow and, when that step declares its outputs, one of
+// the declared keys. References into the children of expand, branch and
+// foreach steps can only be resolved at runtime and are skipped.
+func validateModuleOutputReferences(workflowName string, w *Workflow, result *ModuleValidationResult) {
+	steps := make(map[string]*Step, len(w.Steps))
+	stepIDs := make(map[string]int, len(w.Steps))
+	for i, step := range w.Steps {
+		if step.ID != "" {
+			steps[step.ID] = step
+			stepIDs[step.ID] = i
 		}
-		if step.OnTimeout != nil {
-			checkModuleExpansionVarRefs(step.OnTimeout, workflowName, step.ID, "on_timeout", defined, result)
+	}
+
+	for _, step := range w.Steps {
+		for _, f := range moduleStepFields(step) {
+			for _, match := range varRefPatternModule.FindAllStringSubmatch(f.text, -1) {
+				parts := strings.Split(strings.TrimSpace(match[1]), ".")
+				idx := -1
+				for i, part := range parts {
+					if part == "outputs" {
+						idx = i
+						break
+					}
+				}
+				if idx < 1 || parts[0] == "output" {
+					continue
+				}
+				refID := strings.Join(parts[:idx], ".")
+
+				target, ok := steps[refID]
+				if !ok {
+					if parent, isChild := steps[parts[0]]; isChild && idx > 1 && parent.Executor.expands() {
+						continue
+					}
+					result.Add(workflowName, step.ID, f.name,
+						fmt.Sprintf("references output of unknown step %q", refID),
+						findSimilarInMap(refID, stepIDs))
+					continue
+				}
+
+				if idx+1 >= len(parts) {
+					continue
+				}
+				key := parts[idx+1]
+				declared := declaredOutputs(target)
+				if declared != nil && !declared[key] {
+					result.Add(workflowName, step.ID, f.name,
+						fmt.Sprintf("step %q has no output %q", refID, key),
+						findSimilarInBoolMap(key, declared))
+				}
+			}
 		}
 	}
 }
 
-// checkModuleExpansionVarRefs checks variable references in an expansion target.
-func checkModuleExpansionVarRefs(target *ExpansionTarget, workflowName, stepID, field string, defined map[string]bool, result *ModuleValidationResult) {
-	for k, v := range target.Variables {
-		// Only check string values for variable references (typed values are preserved as-is)
-		if vs, ok := v.(string); ok {
-			checkModuleVarRefs(vs, workflowName, stepID, fmt.Sprintf("%s.variables.%s", field, k), defined, result)
+// shellImplicitOutputs are captured for every shell and branch step.
+var shellImplicitOutputs = []string{"outcome", "exit_code", "duration_ms", "error", "error_type", "max_rss_bytes", "cpu_ms"}
+
+// declaredOutputs returns the output keys a step declares, or nil when the
+// step does not declare them up front.
+func declaredOutputs(step *Step) map[string]bool {
+	var keys []string
+	switch step.Executor {
+	case ExecutorShell:
+		if len(step.ShellOutputs) > 0 {
+			keys = append(sortedMapKeys(step.ShellOutputs), shellImplicitOutputs...)
 		}
+	case ExecutorAgent:
+		if len(step.Outputs) > 0 {
+			keys = sortedMapKeys(step.Outputs)
+			if step.CaptureTranscript {
+				keys = append(keys, "transcript")
+			}
+			if step.Completion == "exit" {
+				keys = append(keys, "exit_code", "result")
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
 	}
+	declared := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		declared[k] = true
+	}
+	return declared
 }
 
 // varRefPatternModule matches {{variable}} patterns
@@ -989,6 +1861,11 @@ func checkModuleVarRefs(text, workflowName, stepID, field string, defined map[st
 			continue
 		}
 
+		// Environment references are resolved at dispatch
+		if root == EnvRefRoot && len(parts) == 2 && !defined[root] {
+			continue
+		}
+
 		if !defined[root] {
 			suggest := findSimilarInBoolMap(root, defined)
 			result.Add(workflowName, stepID, field,
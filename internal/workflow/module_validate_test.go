@@ -1,6 +1,8 @@
 package workflow
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -488,3 +490,348 @@ func containsModuleError(result *ModuleValidationResult, substr string) bool {
 	}
 	return false
 }
+
+func TestValidateFullModule_WhenVarUndefined(t *testing.T) {
+	module := &Module{
+		Path: "test.meow.toml",
+		Workflows: map[string]*Workflow{
+			"main": {
+				Name: "main",
+				Variables: map[string]*Var{
+					"reviewer": {},
+				},
+				Steps: []*Step{
+					{ID: "review", Executor: ExecutorShell, Command: "echo review", WhenVar: "reveiwer"},
+				},
+			},
+		},
+	}
+
+	result := ValidateFullModule(module)
+	if !containsModuleError(result, `undefined variable "reveiwer"`) {
+		t.Errorf("expected when_var error, got: %v", result.Error())
+	}
+}
+
+func TestValidateFullModule_EnvVars(t *testing.T) {
+	module := &Module{
+		Path: "test.meow.toml",
+		Workflows: map[string]*Workflow{
+			"main": {
+				Name:      "main",
+				Variables: map[string]*Var{"repo": {}},
+				EnvVars: map[string]*EnvVar{
+					"repo":  {Env: "GITHUB_REPO"},
+					"token": {Required: true},
+				},
+				Steps: []*Step{{ID: "step-1", Executor: ExecutorShell, Command: "echo {{repo}} {{token}}"}},
+			},
+		},
+	}
+
+	result := ValidateFullModule(module)
+	if !containsModuleError(result, "no env name") {
+		t.Errorf("expected missing env name error, got: %v", result.Error())
+	}
+	if !containsModuleError(result, "both variables and env_vars") {
+		t.Errorf("expected duplicate declaration error, got: %v", result.Error())
+	}
+	if containsModuleError(result, "undefined variable") {
+		t.Errorf("env_vars should count as defined variables, got: %v", result.Error())
+	}
+}
+
+// Lint tests: one template per class of authoring error
+
+func lintModule(t *testing.T, content string) *ModuleValidationResult {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lint.meow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	module, err := ParseModuleFileUnvalidated(path)
+	if err != nil {
+		t.Fatalf("ParseModuleFileUnvalidated() error = %v", err)
+	}
+	return ValidateFullModule(module)
+}
+
+func assertModuleErrors(t *testing.T, result *ModuleValidationResult, want ...string) {
+	t.Helper()
+	if len(result.Errors) != len(want) {
+		t.Errorf("got %d errors, want %d: %v", len(result.Errors), len(want), result.Error())
+	}
+	for _, w := range want {
+		if !containsModuleError(result, w) {
+			t.Errorf("missing diagnostic %q in: %v", w, result.Error())
+		}
+	}
+}
+
+func TestValidateFullModule_Lint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "unknown executor",
+			content: `
+[main]
+name = "main"
+[[main.steps]]
+id = "build"
+executor = "shel"
+command = "make"
+`,
+			want: []string{`step "build", field "executor": unknown executor "shel" (suggestion: did you mean "shell"?)`},
+		},
+		{
+			name: "missing executor and required fields",
+			content: `
+[main]
+name = "main"
+[[main.steps]]
+id = "build"
+command = "make"
+[[main.steps]]
+id = "review"
+executor = "agent"
+agent = "worker"
+`,
+			want: []string{
+				`step "build", field "executor": executor is required`,
+				`step "review": agent executor requires prompt`,
+			},
+		},
+		{
+			name: "dangling needs and template references",
+			content: `
+[main]
+name = "main"
+[[main.steps]]
+id = "build"
+executor = "shell"
+command = "make"
+needs = ["setup"]
+[[main.steps]]
+id = "impl"
+executor = "expand"
+template = ".implemnt"
+[[main.steps]]
+id = "each"
+executor = "foreach"
+items = "[1, 2]"
+item_var = "n"
+template = ".implement.missing"
+
+[implement]
+name = "implement"
+internal = true
+[[implement.steps]]
+id = "work"
+executor = "shell"
+command = "true"
+`,
+			want: []string{
+				`step "build", field "needs": references unknown step "setup"`,
+				`step "impl", field "template": references unknown workflow "implemnt" (suggestion: did you mean "implement"?)`,
+				`step "each", field "template": references unknown step "missing" in workflow "implement"`,
+			},
+		},
+		{
+			name: "undeclared variables",
+			content: `
+[main]
+name = "main"
+[main.variables]
+task = { required = true }
+[[main.steps]]
+id = "work"
+executor = "agent"
+agent = "{{worker}}"
+prompt = "Do {{task}} in {{ repo }}"
+[[main.steps]]
+id = "each"
+executor = "foreach"
+items = "{{tasks}}"
+item_var = "item"
+template = ".child"
+[main.steps.variables]
+task = "{{item}}"
+
+[child]
+name = "child"
+[child.variables]
+task = {}
+[[child.steps]]
+id = "run"
+executor = "shell"
+command = "echo {{task}}"
+workdir = "{{dir}}"
+`,
+			want: []string{
+				`step "work", field "agent": undefined variable "worker"`,
+				`step "work", field "prompt": undefined variable "repo"`,
+				`step "each", field "items": undefined variable "tasks" (suggestion: did you mean "task"?)`,
+				`template "child", step "run", field "workdir": undefined variable "dir"`,
+			},
+		},
+		{
+			name: "output references",
+			content: `
+[main]
+name = "main"
+[[main.steps]]
+id = "plan"
+executor = "shell"
+command = "echo plan"
+[main.steps.outputs]
+summary = { source = "stdout" }
+[[main.steps]]
+id = "impl"
+executor = "expand"
+template = ".child"
+[[main.steps]]
+id = "report"
+executor = "shell"
+command = "echo {{plan.outputs.sumary}} {{plann.outputs.summary}} {{impl.run.outputs.anything}}"
+
+[child]
+name = "child"
+[[child.steps]]
+id = "run"
+executor = "shell"
+command = "true"
+`,
+			want: []string{
+				`step "report", field "command": step "plan" has no output "sumary" (suggestion: did you mean "summary"?)`,
+				`step "report", field "command": references output of unknown step "plann" (suggestion: did you mean "plan"?)`,
+			},
+		},
+		{
+			name: "implicit shell outputs",
+			content: `
+[main]
+name = "main"
+[[main.steps]]
+id = "build"
+executor = "shell"
+command = "make"
+[main.steps.outputs]
+binary = { source = "stdout" }
+[[main.steps]]
+id = "report"
+executor = "shell"
+command = "echo {{build.outputs.exit_code}} {{build.outputs.cpu_ms}} {{build.outputs.max_rss_bytes}} {{build.outputs.error_type}} {{build.outputs.cpu_time}}"
+`,
+			want: []string{
+				`step "report", field "command": step "build" has no output "cpu_time"`,
+			},
+		},
+		{
+			name: "captured transcript output",
+			content: `
+[main]
+name = "main"
+[[main.steps]]
+id = "implement"
+executor = "agent"
+agent = "worker"
+prompt = "Implement it"
+capture_transcript = true
+[main.steps.outputs]
+summary = { required = true, type = "string" }
+[[main.steps]]
+id = "review"
+executor = "shell"
+command = "echo {{implement.outputs.transcript}} {{implement.outputs.summary}} {{implement.outputs.log}}"
+`,
+			want: []string{
+				`step "review", field "command": step "implement" has no output "log"`,
+			},
+		},
+		{
+			name: "exit completion outputs",
+			content: `
+[main]
+name = "main"
+[[main.steps]]
+id = "implement"
+executor = "agent"
+agent = "worker"
+prompt = "Implement it"
+completion = "exit"
+result_file = "result.json"
+[main.steps.outputs]
+summary = { required = true, type = "string" }
+[[main.steps]]
+id = "review"
+executor = "shell"
+command = "echo {{implement.outputs.exit_code}} {{implement.outputs.result}} {{implement.outputs.summary}} {{implement.outputs.log}}"
+`,
+			want: []string{
+				`step "review", field "command": step "implement" has no output "log"`,
+			},
+		},
+		{
+			name: "empty requires entries",
+			content: `
+[main]
+name = "main"
+requires = { binaries = ["git", ""], env = [""] }
+[[main.steps]]
+id = "push"
+executor = "shell"
+command = "git push"
+`,
+			want: []string{
+				`field "requires.binaries[1]": requires.binaries entry is empty`,
+				`field "requires.env[0]": requires.env entry is empty`,
+			},
+		},
+		{
+			name: "clean template",
+			content: `
+[main]
+name = "main"
+[main.variables]
+items = { default = "[]" }
+[[main.steps]]
+id = "plan"
+executor = "agent"
+agent = "worker"
+prompt = "Plan"
+[main.steps.outputs]
+tasks = { type = "json", required = true }
+[[main.steps]]
+id = "each"
+executor = "foreach"
+items = "{{plan.outputs.tasks}}"
+item_var = "task"
+index_var = "i"
+template = ".child"
+needs = ["plan"]
+[main.steps.variables]
+task = "{{task}}"
+n = "{{i}}"
+
+[child]
+name = "child"
+[child.variables]
+task = {}
+n = {}
+[[child.steps]]
+id = "run"
+executor = "shell"
+command = "echo {{n}} {{task}}"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertModuleErrors(t, lintModule(t, tt.content), tt.want...)
+		})
+	}
+}
@@ -550,6 +550,162 @@ command = "echo test"
 	}
 }
 
+func TestParseModuleString_CleanupWhen(t *testing.T) {
+	moduleToml := `
+[main]
+name = "provisioned"
+
+[[main.cleanup_when]]
+condition = 'test -n "{{provision.outputs.resource_id}}"'
+script = "deprovision {{provision.outputs.resource_id}}"
+
+[[main.steps]]
+id = "provision"
+executor = "shell"
+command = "echo res-42"
+`
+	module, err := ParseModuleString(moduleToml, "test.toml")
+	if err != nil {
+		t.Fatalf("ParseModuleString failed: %v", err)
+	}
+	cleanups := module.GetWorkflow("main").CleanupWhen
+	if len(cleanups) != 1 || cleanups[0].Script != "deprovision {{provision.outputs.resource_id}}" {
+		t.Errorf("cleanup_when = %+v, want the deprovision script", cleanups)
+	}
+
+	_, err = ParseModuleString(`
+[main]
+name = "provisioned"
+
+[[main.cleanup_when]]
+condition = "true"
+
+[[main.steps]]
+id = "provision"
+executor = "shell"
+command = "echo res-42"
+`, "test.toml")
+	if err == nil || !strings.Contains(err.Error(), "condition and script are required") {
+		t.Errorf("error = %v, want missing script rejected", err)
+	}
+}
+
+func TestParseModuleString_Requires(t *testing.T) {
+	module, err := ParseModuleString(`
+[main]
+name = "release"
+requires = { binaries = ["git", "docker"], env = ["TOKEN"] }
+
+[[main.steps]]
+id = "push"
+executor = "shell"
+command = "docker push app"
+`, "test.toml")
+	if err != nil {
+		t.Fatalf("ParseModuleString failed: %v", err)
+	}
+	req := module.GetWorkflow("main").Requires
+	if req == nil {
+		t.Fatal("requires not parsed")
+	}
+	if strings.Join(req.Binaries, ",") != "git,docker" || strings.Join(req.Env, ",") != "TOKEN" {
+		t.Errorf("requires = %+v, want binaries git,docker and env TOKEN", req)
+	}
+}
+
+func TestParseModuleString_ForeachCollect(t *testing.T) {
+	module, err := ParseModuleString(`
+[main]
+name = "review-all"
+
+[[main.steps]]
+id = "fan"
+executor = "foreach"
+items = '["a", "b", "c"]'
+item_var = "file"
+template = ".review"
+collect = "review.summary"
+
+[review]
+name = "review"
+
+[[review.steps]]
+id = "review"
+executor = "shell"
+command = "echo {{file}}"
+`, "test.toml")
+	if err != nil {
+		t.Fatalf("ParseModuleString failed: %v", err)
+	}
+
+	result, err := NewBaker("run-collect-001").BakeWorkflow(module.GetWorkflow("main"), nil)
+	if err != nil {
+		t.Fatalf("BakeWorkflow failed: %v", err)
+	}
+	if got := result.Steps[0].Foreach.Collect; got != "review.summary" {
+		t.Errorf("foreach collect = %q, want review.summary", got)
+	}
+}
+
+func TestParseModuleString_ExhaustiveBranch(t *testing.T) {
+	covered := `
+[main]
+name = "release"
+
+[[main.steps]]
+id = "check"
+executor = "branch"
+condition = "git diff --quiet"
+timeout = "30s"
+exhaustive = true
+
+[main.steps.on_true]
+template = ".publish"
+
+[main.steps.on_false]
+ignore = true
+
+[main.steps.on_timeout]
+inline = [{ id = "report", executor = "shell", command = "echo timed out" }]
+
+[publish]
+name = "publish"
+
+[[publish.steps]]
+id = "tag"
+executor = "shell"
+command = "git tag v1"
+`
+	module, err := ParseModuleString(covered, "test.toml")
+	if err != nil {
+		t.Fatalf("ParseModuleString failed: %v", err)
+	}
+	step := module.GetWorkflow("main").Steps[0]
+	if !step.Exhaustive || step.OnFalse == nil || !step.OnFalse.Ignore {
+		t.Errorf("exhaustive = %v, on_false = %+v; want exhaustive with an ignored on_false", step.Exhaustive, step.OnFalse)
+	}
+	if err := ValidateFullModule(module); err.HasErrors() {
+		t.Errorf("validation errors: %v", err)
+	}
+
+	missingFalse := strings.Replace(covered, "[main.steps.on_false]\nignore = true\n", "", 1)
+	_, err = ParseModuleString(missingFalse, "test.toml")
+	if err == nil || !strings.Contains(err.Error(), "exhaustive branch has no on_false target") {
+		t.Errorf("error = %v, want missing on_false rejected", err)
+	}
+
+	// on_timeout is only an outcome when the branch has a timeout
+	noTimeout := strings.Replace(strings.Replace(covered, `timeout = "30s"`, "", 1),
+		"[main.steps.on_timeout]\ninline = [{ id = \"report\", executor = \"shell\", command = \"echo timed out\" }]\n", "", 1)
+	module, err = ParseModuleString(noTimeout, "test.toml")
+	if err != nil {
+		t.Fatalf("exhaustive branch without timeout: %v", err)
+	}
+	if step := module.GetWorkflow("main").Steps[0]; step.Timeout != "" || step.OnTimeout != nil {
+		t.Errorf("timeout = %q, on_timeout = %+v; want neither", step.Timeout, step.OnTimeout)
+	}
+}
+
 func TestParseModuleString_CircularDependency(t *testing.T) {
 	moduleToml := `
 [main]
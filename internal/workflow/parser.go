@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/akatz-ai/meow/internal/types"
 )
 
 // Template represents a parsed MEOW template.
@@ -54,6 +58,15 @@ type Var struct {
 	Enum        []string `toml:"enum,omitempty"` // Allowed values
 }
 
+// EnvVar defines a workflow variable sourced from the orchestrator's environment,
+// e.g. repo = { env = "GITHUB_REPO", required = true }.
+type EnvVar struct {
+	Env         string `toml:"env"` // Environment variable to read
+	Required    bool   `toml:"required"`
+	Default     string `toml:"default,omitempty"` // Used when the environment variable is unset or empty
+	Description string `toml:"description,omitempty"`
+}
+
 // ExecutorType represents the type of executor for a step.
 type ExecutorType string
 
@@ -64,16 +77,17 @@ const (
 	ExecutorExpand  ExecutorType = "expand"
 	ExecutorBranch  ExecutorType = "branch"
 	ExecutorForeach ExecutorType = "foreach"
+	ExecutorGate    ExecutorType = "gate"
 	ExecutorAgent   ExecutorType = "agent"
-	// Note: Gate is NOT an executor. Human approval is implemented via
-	// branch executor with condition = "meow await-approval <gate-id>"
+	// Note: gate only waits for an event. Approval with a reject path is
+	// still a branch with condition = "meow await-approval <gate-id>"
 )
 
 // Valid returns true if the executor type is valid.
 // Note: Empty executor is allowed during template migration period.
 func (e ExecutorType) Valid() bool {
 	switch e {
-	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach, ExecutorAgent:
+	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach, ExecutorGate, ExecutorAgent:
 		return true
 	case "": // Allow empty during migration - templates use type field
 		return true
@@ -81,10 +95,15 @@ func (e ExecutorType) Valid() bool {
 	return false
 }
 
+// expands returns true if the executor creates child steps at runtime.
+func (e ExecutorType) expands() bool {
+	return e == ExecutorExpand || e == ExecutorBranch || e == ExecutorForeach
+}
+
 // IsOrchestrator returns true if the executor runs internally (not waiting for external completion).
 func (e ExecutorType) IsOrchestrator() bool {
 	switch e {
-	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach:
+	case ExecutorShell, ExecutorSpawn, ExecutorKill, ExecutorExpand, ExecutorBranch, ExecutorForeach, ExecutorGate:
 		return true
 	}
 	return false
@@ -92,8 +111,41 @@ func (e ExecutorType) IsOrchestrator() bool {
 
 // OutputSource defines where to capture output from for shell executor.
 type OutputSource struct {
-	Source string `toml:"source"`         // stdout | stderr | exit_code | file:/path
-	Type   string `toml:"type,omitempty"` // json | (empty for string)
+	Source   string `toml:"source"`             // stdout | stderr | exit_code | file | file:/path
+	Type     string `toml:"type,omitempty"`     // json | (empty for string)
+	Artifact bool   `toml:"artifact,omitempty"` // Persist into the run's artifacts directory
+	Pattern  string `toml:"pattern,omitempty"`  // Regex applied to the source; first group (or whole match) is the value
+	Required bool   `toml:"required,omitempty"` // Fail the step if the output cannot be captured
+	Path     string `toml:"path,omitempty"`     // JSON file to read, for source "file"
+	Field    string `toml:"field,omitempty"`    // JSON path selecting part of the parsed value
+	Line     int    `toml:"line,omitempty"`     // 1-based line of the source to keep (negative counts from the end)
+}
+
+// OutputAssertion is an expected output value (assert table). In TOML a bare
+// string is shorthand for equals.
+type OutputAssertion struct {
+	Equals  string `toml:"equals,omitempty"`  // Exact value
+	Matches string `toml:"matches,omitempty"` // Regex the value must match
+}
+
+// UnmarshalTOML accepts the bare-string shorthand as well as the table form.
+func (a *OutputAssertion) UnmarshalTOML(data any) error {
+	*a = assertionFromValue(data)
+	return nil
+}
+
+// NudgePolicy re-injects a prompt into a silent agent (nudge table).
+type NudgePolicy struct {
+	After     string `toml:"after"`                // Silence before each nudge, e.g. "30s" or "30s silence"
+	Prompt    string `toml:"prompt,omitempty"`     // Nudge text (default: the step's prompt)
+	MaxNudges int    `toml:"max_nudges,omitempty"` // Nudges before the step fails (0 = never fail)
+}
+
+// RetryPolicy re-runs a failed step with a delay between attempts (retry table).
+type RetryPolicy struct {
+	MaxAttempts       int     `toml:"max_attempts"`                 // Total runs, counting the first
+	Backoff           string  `toml:"backoff,omitempty"`            // Wait before the first retry, e.g. "5s"
+	BackoffMultiplier float64 `toml:"backoff_multiplier,omitempty"` // Growth of each later wait (default 1)
 }
 
 // AgentOutputDef defines an expected output from an agent step.
@@ -101,38 +153,115 @@ type AgentOutputDef struct {
 	Required    bool   `toml:"required"`
 	Type        string `toml:"type"` // string | number | boolean | json | file_path
 	Description string `toml:"description,omitempty"`
+	From        string `toml:"from,omitempty"`     // JSON path into the done payload (e.g., "result.items[0].id")
+	FromLog     string `toml:"from_log,omitempty"` // JSON path into the agent's structured log lines
+	Artifact    bool   `toml:"artifact,omitempty"` // Persist into the run's artifacts directory
+
+	// Constraints checked after the type
+	Min     *float64 `toml:"min,omitempty"`     // Lowest allowed number
+	Max     *float64 `toml:"max,omitempty"`     // Highest allowed number
+	Enum    []string `toml:"enum,omitempty"`    // Allowed values
+	Pattern string   `toml:"pattern,omitempty"` // Regexp a string must match
 }
 
 // Step represents a single step in a template.
 type Step struct {
 	ID       string       `toml:"id"`
-	Executor ExecutorType `toml:"executor,omitempty"` // shell | spawn | kill | expand | branch | foreach | agent
+	Executor ExecutorType `toml:"executor,omitempty"` // shell | spawn | kill | expand | branch | foreach | gate | agent
 
 	// Shared fields
 	Needs   []string `toml:"needs,omitempty"` // Step IDs that must complete first
 	Timeout string   `toml:"timeout,omitempty"`
+	WhenVar string   `toml:"when_var,omitempty"` // Omit step at bake time if this variable is unset or empty
+	Inputs  []string `toml:"inputs,omitempty"`   // File globs that re-run this step when changed (meow run --watch)
+
+	// Assert checks captured outputs (shell, branch, agent); a mismatch fails the step
+	Assert map[string]OutputAssertion `toml:"assert,omitempty"`
+
+	// Requires names shell commands that must succeed right before dispatch
+	// (check name -> command); a failing check fails the step
+	Requires map[string]string `toml:"requires,omitempty"`
+
+	// If is a shell command run right before dispatch; a non-zero exit skips
+	// the step, and its dependents run as if it had succeeded
+	If string `toml:"if,omitempty"`
+
+	// Scheduling: dispatch only inside a daily "HH:MM-HH:MM" window (local time)
+	OnlyBetween   string `toml:"only_between,omitempty"`
+	OutsideWindow string `toml:"outside_window,omitempty"` // wait | skip (default: wait)
+
+	// Lock names a file lock held while the step runs, serializing it with
+	// every step using the same name in any workflow sharing the locks dir
+	Lock string `toml:"lock,omitempty"`
+
+	// Retries re-runs the step after a failure (shell, branch, agent), drawing
+	// on the workflow's retry_budget when one is set
+	Retries int `toml:"retries,omitempty"`
+	// Retry is retries with backoff, counted in total attempts
+	Retry *RetryPolicy `toml:"retry,omitempty"`
 
 	// Agent executor fields
 	Agent  string `toml:"agent,omitempty"`  // Agent identifier (also used by spawn, kill)
 	Prompt string `toml:"prompt,omitempty"` // Instructions for agent (also used by gate)
 	Mode   string `toml:"mode,omitempty"`   // autonomous | interactive
 
+	// RequiresCapability dispatches to any idle agent spawned with this
+	// capability instead of a named agent
+	RequiresCapability string `toml:"requires_capability,omitempty"`
+
+	// AckTimeout bounds the wait for the agent's prompt-received acknowledgment;
+	// when set, Timeout is measured from the acknowledgment instead of dispatch
+	AckTimeout string `toml:"ack_timeout,omitempty"`
+
+	// Agent prompt injection timing (override adapter defaults)
+	PreDelay  string `toml:"pre_delay,omitempty"`  // Wait after pre_keys before sending the prompt
+	PostDelay string `toml:"post_delay,omitempty"` // Wait after sending the prompt before post_keys
+
+	// SkipPromptWrap opts this agent step out of the workflow's prompt_prefix/prompt_suffix
+	SkipPromptWrap bool `toml:"skip_prompt_wrap,omitempty"`
+
+	// Stall detection: flag the agent when it sends no events for stall_timeout
+	StallTimeout string `toml:"stall_timeout,omitempty"`
+	OnStall      string `toml:"on_stall,omitempty"` // warn | nudge (default: warn)
+
+	// CaptureTranscript saves the agent's pane scrollback at completion as the "transcript" output
+	CaptureTranscript bool `toml:"capture_transcript,omitempty"`
+
+	// Nudge re-injects a prompt when the agent goes silent, failing the step after max_nudges
+	Nudge *NudgePolicy `toml:"nudge,omitempty"`
+
+	// Completion: done (default, meow done) | exit (the agent process exits;
+	// exit code 0 succeeds, with outputs read from result_file if set)
+	Completion string `toml:"completion,omitempty"`
+	ResultFile string `toml:"result_file,omitempty"`
+
 	// Shell executor fields
 	Command string            `toml:"command,omitempty"`  // Shell command to execute
 	Workdir string            `toml:"workdir,omitempty"`  // Working directory (also used by spawn)
 	Env     map[string]string `toml:"env,omitempty"`      // Environment variables (also used by spawn)
-	OnError string            `toml:"on_error,omitempty"` // continue | fail (default: fail)
+	OnError string            `toml:"on_error,omitempty"` // continue | fail (default: fail) | .workflow recovery template
+	RunAs   string            `toml:"run_as,omitempty"`   // OS user to run the command as (needs a root orchestrator)
 
 	// Shell output capture
 	ShellOutputs map[string]OutputSource `toml:"shell_outputs,omitempty"` // For shell executor stdout/stderr/file capture
 
+	// Soft cancel: cancel_signal (shell, branch) or cancel_prompt (agent) gives
+	// the step cancel_grace to checkpoint before it is killed or interrupted
+	CancelSignal string `toml:"cancel_signal,omitempty"`
+	CancelPrompt string `toml:"cancel_prompt,omitempty"`
+	CancelGrace  string `toml:"cancel_grace,omitempty"`
+
 	// Spawn executor fields (uses Agent, Workdir, Env)
-	Adapter       string `toml:"adapter,omitempty"`        // Which adapter to use (defaults to config hierarchy)
-	ResumeSession string `toml:"resume_session,omitempty"` // Claude session ID to resume
-	SpawnArgs     string `toml:"spawn_args,omitempty"`     // Extra CLI args to append to spawn command
+	Adapter       string   `toml:"adapter,omitempty"`        // Which adapter to use (defaults to config hierarchy)
+	ResumeSession string   `toml:"resume_session,omitempty"` // Claude session ID to resume
+	SpawnArgs     string   `toml:"spawn_args,omitempty"`     // Extra CLI args to append to spawn command
+	StartupDelay  string   `toml:"startup_delay,omitempty"`  // Overrides the adapter's startup_delay
+	ReuseFrom     string   `toml:"reuse_from,omitempty"`     // Prior run whose live agent is adopted instead of spawning
+	Capabilities  []string `toml:"capabilities,omitempty"`   // Tags matched by agent steps' requires_capability
 
 	// Kill executor fields (uses Agent)
-	Graceful *bool `toml:"graceful,omitempty"` // Send SIGTERM first (default: true)
+	Graceful    *bool  `toml:"graceful,omitempty"`     // Send SIGTERM first (default: true)
+	GracePeriod string `toml:"grace_period,omitempty"` // How long a graceful kill waits before forcing
 	// Timeout already defined above
 
 	// Expand executor fields
@@ -144,6 +273,12 @@ type Step struct {
 	OnTrue    *ExpansionTarget `toml:"on_true,omitempty"`    // Expand if condition true
 	OnFalse   *ExpansionTarget `toml:"on_false,omitempty"`   // Expand if condition false
 	OnTimeout *ExpansionTarget `toml:"on_timeout,omitempty"` // Expand if condition times out
+	// Exhaustive requires a target (or ignore = true) for every outcome:
+	// on_true, on_false, and on_timeout when the branch has a timeout
+	Exhaustive bool `toml:"exhaustive,omitempty"`
+
+	// Gate executor fields (uses Timeout)
+	WaitForEvent string `toml:"wait_for_event,omitempty"` // Event name that completes the step (meow event <name>)
 
 	// Foreach executor fields
 	Items         string `toml:"items,omitempty"`          // JSON array expression (may contain variable refs)
@@ -153,6 +288,12 @@ type Step struct {
 	Parallel      any    `toml:"parallel,omitempty"`       // Run iterations in parallel (bool or string for variables, default true)
 	MaxConcurrent any    `toml:"max_concurrent,omitempty"` // Limit concurrent executions (int or string for variables)
 	Join          *bool  `toml:"join,omitempty"`           // Wait for all iterations (default true)
+	Window        any    `toml:"window,omitempty"`         // Iterations expanded at a time (int or string for variables)
+	// Failed iterations tolerated before the foreach fails: a count or a
+	// percentage like "30%" (int or string for variables)
+	FailureThreshold any `toml:"failure_threshold,omitempty"`
+	// Output gathered from every iteration: "<step>.<field>"
+	Collect string `toml:"collect,omitempty"`
 	// Template and Variables fields already defined above for expand executor
 
 	// Agent output definitions (for agent executor)
@@ -195,6 +336,9 @@ func (s *Step) Validate() error {
 		if s.Condition == "" {
 			return fmt.Errorf("branch executor requires condition")
 		}
+		if err := s.validateBranchTargets(); err != nil {
+			return err
+		}
 	case ExecutorForeach:
 		// Exactly one of items or items_file must be set
 		if s.Items == "" && s.ItemsFile == "" {
@@ -209,61 +353,343 @@ func (s *Step) Validate() error {
 		if s.Template == "" {
 			return fmt.Errorf("foreach executor requires template")
 		}
+		if s.Window != nil && s.Join != nil && !*s.Join {
+			return fmt.Errorf("foreach window requires join")
+		}
+		if v, ok := s.Window.(int64); ok && v < 0 {
+			return fmt.Errorf("window must not be negative")
+		}
+		if v, ok := s.FailureThreshold.(string); ok && !strings.Contains(v, "{{") {
+			if _, _, err := types.ParseFailureThreshold(v); err != nil {
+				return err
+			}
+		} else if v, ok := s.FailureThreshold.(int64); ok && v < 0 {
+			return fmt.Errorf("failure_threshold must not be negative")
+		}
+		if s.Collect != "" {
+			if stepID, field, ok := strings.Cut(s.Collect, "."); !ok || stepID == "" || field == "" {
+				return fmt.Errorf("collect must name a step and an output field (step.field), got %q", s.Collect)
+			}
+			if s.Join != nil && !*s.Join {
+				return fmt.Errorf("foreach collect requires join")
+			}
+		}
+	case ExecutorGate:
+		if s.WaitForEvent == "" {
+			return fmt.Errorf("gate executor requires wait_for_event")
+		}
 	case ExecutorAgent:
-		if s.Agent == "" {
-			return fmt.Errorf("agent executor requires agent")
+		if s.Agent == "" && s.RequiresCapability == "" {
+			return fmt.Errorf("agent executor requires agent or requires_capability")
+		}
+		if s.Agent != "" && s.RequiresCapability != "" {
+			return fmt.Errorf("agent and requires_capability are mutually exclusive")
 		}
 		if s.Prompt == "" {
 			return fmt.Errorf("agent executor requires prompt")
 		}
 	}
 
+	if s.RunAs != "" && s.Executor != ExecutorShell {
+		return fmt.Errorf("run_as is only supported on shell steps")
+	}
+
+	// Validate soft cancel
+	if s.CancelSignal != "" {
+		if s.Executor != ExecutorShell && s.Executor != ExecutorBranch {
+			return fmt.Errorf("cancel_signal is only supported on shell and branch steps")
+		}
+		if !strings.Contains(s.CancelSignal, "{{") {
+			if _, err := types.ParseCancelSignal(s.CancelSignal); err != nil {
+				return err
+			}
+		}
+	}
+	if s.CancelPrompt != "" && s.Executor != ExecutorAgent {
+		return fmt.Errorf("cancel_prompt is only supported on agent steps")
+	}
+	if s.CancelGrace != "" {
+		if s.CancelSignal == "" && s.CancelPrompt == "" {
+			return fmt.Errorf("cancel_grace requires cancel_signal or cancel_prompt")
+		}
+		if !strings.Contains(s.CancelGrace, "{{") {
+			if _, err := time.ParseDuration(s.CancelGrace); err != nil {
+				return fmt.Errorf("invalid cancel_grace %q: %w", s.CancelGrace, err)
+			}
+		}
+	}
+
 	// Validate mode if specified
 	if s.Mode != "" && s.Mode != "autonomous" && s.Mode != "interactive" {
 		return fmt.Errorf("invalid mode %q: must be autonomous or interactive", s.Mode)
 	}
 
 	// Validate on_error if specified
-	if s.OnError != "" && s.OnError != "continue" && s.OnError != "fail" {
-		return fmt.Errorf("invalid on_error %q: must be continue or fail", s.OnError)
+	if s.OnError != "" && s.OnError != "continue" && s.OnError != "fail" && !strings.HasPrefix(s.OnError, ".") {
+		return fmt.Errorf("invalid on_error %q: must be continue, fail, or a .workflow recovery template", s.OnError)
+	}
+
+	// Validate output capture patterns and JSON file sources
+	for _, name := range sortedMapKeys(s.ShellOutputs) {
+		out := s.ShellOutputs[name]
+		if out.Pattern != "" {
+			if _, err := regexp.Compile(out.Pattern); err != nil {
+				return fmt.Errorf("invalid pattern for output %q: %w", name, err)
+			}
+		}
+		if (out.Source == types.OutputSourceFile) != (out.Path != "") {
+			return fmt.Errorf("output %q: source \"file\" and path must be set together", name)
+		}
+		if out.Field != "" && out.Source != types.OutputSourceFile && out.Type != "json" {
+			return fmt.Errorf("output %q: field requires source \"file\" or type json", name)
+		}
+		if out.Line != 0 && out.Source == "exit_code" {
+			return fmt.Errorf("output %q: line does not apply to source exit_code", name)
+		}
+	}
+
+	// Validate the time window unless it is filled in at bake time
+	if s.OnlyBetween != "" && !strings.Contains(s.OnlyBetween, "{{") {
+		if _, err := types.ParseTimeWindow(s.OnlyBetween); err != nil {
+			return fmt.Errorf("invalid only_between: %w", err)
+		}
+	}
+	if s.OutsideWindow != "" && s.OutsideWindow != types.OutsideWindowWait && s.OutsideWindow != types.OutsideWindowSkip {
+		return fmt.Errorf("invalid outside_window %q: must be wait or skip", s.OutsideWindow)
+	}
+	if s.OutsideWindow != "" && s.OnlyBetween == "" {
+		return fmt.Errorf("outside_window requires only_between")
+	}
+
+	// Validate the lock name unless it is filled in at bake time
+	if s.Lock != "" && !strings.Contains(s.Lock, "{{") && !types.ValidLockName(s.Lock) {
+		return fmt.Errorf("invalid lock %q: use letters, digits, '.', '_' and '-'", s.Lock)
 	}
 
+	// Validate retries
+	if s.Retries < 0 {
+		return fmt.Errorf("retries must not be negative, got %d", s.Retries)
+	}
+	if s.Retries > 0 && s.Executor != ExecutorShell && s.Executor != ExecutorBranch && s.Executor != ExecutorAgent {
+		return fmt.Errorf("retries is only supported on shell, branch, and agent steps")
+	}
+	if s.Retry != nil {
+		if s.Executor != ExecutorShell && s.Executor != ExecutorBranch && s.Executor != ExecutorAgent {
+			return fmt.Errorf("retry is only supported on shell, branch, and agent steps")
+		}
+		if s.Retries > 0 {
+			return fmt.Errorf("retry and retries are mutually exclusive")
+		}
+		if s.Retry.MaxAttempts < 1 {
+			return fmt.Errorf("retry max_attempts must be at least 1, got %d", s.Retry.MaxAttempts)
+		}
+		if s.Retry.Backoff != "" {
+			if d, err := time.ParseDuration(s.Retry.Backoff); err != nil || d < 0 {
+				return fmt.Errorf("invalid retry backoff %q", s.Retry.Backoff)
+			}
+		}
+		if s.Retry.BackoffMultiplier != 0 && s.Retry.BackoffMultiplier < 1 {
+			return fmt.Errorf("retry backoff_multiplier must be at least 1, got %g", s.Retry.BackoffMultiplier)
+		}
+	}
+
+	// Validate agent output constraints
+	for _, name := range sortedMapKeys(s.Outputs) {
+		def := s.Outputs[name]
+		if def.From != "" && def.FromLog != "" {
+			return fmt.Errorf("output %q: from and from_log cannot both be set", name)
+		}
+		if (def.Min != nil || def.Max != nil) && def.Type != "number" {
+			return fmt.Errorf("output %q: min and max require type number", name)
+		}
+		if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+			return fmt.Errorf("output %q: min %g is greater than max %g", name, *def.Min, *def.Max)
+		}
+		if def.Pattern != "" {
+			if def.Type != "string" {
+				return fmt.Errorf("output %q: pattern requires type string", name)
+			}
+			if _, err := regexp.Compile(def.Pattern); err != nil {
+				return fmt.Errorf("output %q: invalid pattern: %w", name, err)
+			}
+		}
+	}
+
+	// Validate output assertions
+	if len(s.Assert) > 0 && s.Executor != ExecutorShell && s.Executor != ExecutorBranch && s.Executor != ExecutorAgent {
+		return fmt.Errorf("assert is only supported on shell, branch, and agent steps")
+	}
+	for _, name := range sortedMapKeys(s.Assert) {
+		a := s.Assert[name]
+		if (a.Equals == "") == (a.Matches == "") {
+			return fmt.Errorf("assert %q must set exactly one of equals or matches", name)
+		}
+		if a.Matches != "" && !strings.Contains(a.Matches, "{{") {
+			if _, err := regexp.Compile(a.Matches); err != nil {
+				return fmt.Errorf("invalid matches for assert %q: %w", name, err)
+			}
+		}
+	}
+
+	// Validate preconditions
+	for _, name := range sortedMapKeys(s.Requires) {
+		if strings.TrimSpace(s.Requires[name]) == "" {
+			return fmt.Errorf("requires %q must be a shell command", name)
+		}
+	}
+	if s.If != "" && strings.TrimSpace(s.If) == "" {
+		return fmt.Errorf("if must be a shell command")
+	}
+
+	// Validate stall detection unless the timeout is filled in at bake time
+	if s.StallTimeout != "" && !strings.Contains(s.StallTimeout, "{{") {
+		if _, err := time.ParseDuration(s.StallTimeout); err != nil {
+			return fmt.Errorf("invalid stall_timeout %q: %w", s.StallTimeout, err)
+		}
+	}
+	// Validate the kill grace period unless it is filled in at bake time
+	if s.GracePeriod != "" && !strings.Contains(s.GracePeriod, "{{") {
+		if _, err := time.ParseDuration(s.GracePeriod); err != nil {
+			return fmt.Errorf("invalid grace_period %q: %w", s.GracePeriod, err)
+		}
+	}
+	if s.OnStall != "" && s.OnStall != types.OnStallWarn && s.OnStall != types.OnStallNudge {
+		return fmt.Errorf("invalid on_stall %q: must be warn or nudge", s.OnStall)
+	}
+	if s.OnStall != "" && s.StallTimeout == "" {
+		return fmt.Errorf("on_stall requires stall_timeout")
+	}
+
+	// Validate the nudge policy unless its timing is filled in at bake time
+	if s.Nudge != nil {
+		if s.Executor != ExecutorAgent {
+			return fmt.Errorf("nudge is only supported on agent steps")
+		}
+		if s.Nudge.After == "" {
+			return fmt.Errorf("nudge requires after")
+		}
+		if !strings.Contains(s.Nudge.After, "{{") {
+			if _, err := types.ParseNudgeAfter(s.Nudge.After); err != nil {
+				return err
+			}
+		}
+		if s.Nudge.MaxNudges < 0 {
+			return fmt.Errorf("nudge max_nudges must not be negative")
+		}
+	}
+
+	// Validate the completion mode
+	if s.Completion != "" || s.ResultFile != "" {
+		if s.Executor != ExecutorAgent {
+			return fmt.Errorf("completion and result_file are only supported on agent steps")
+		}
+		if s.Completion != "" && s.Completion != types.CompletionDone && s.Completion != types.CompletionExit {
+			return fmt.Errorf("invalid completion %q: must be done or exit", s.Completion)
+		}
+		if s.ResultFile != "" && s.Completion != types.CompletionExit {
+			return fmt.Errorf("result_file requires completion = \"exit\"")
+		}
+	}
+
+	if s.Exhaustive && s.Executor != ExecutorBranch {
+		return fmt.Errorf("exhaustive is only supported on branch steps")
+	}
+
+	return nil
+}
+
+// validateBranchTargets checks a branch step's expansion targets. An ignore
+// target stands alone, and an exhaustive branch must handle every outcome it
+// can have, so none silently falls through.
+func (s *Step) validateBranchTargets() error {
+	targets := []struct {
+		name   string
+		target *ExpansionTarget
+		needed bool
+	}{
+		{"on_true", s.OnTrue, true},
+		{"on_false", s.OnFalse, true},
+		{"on_timeout", s.OnTimeout, s.Timeout != ""},
+	}
+	for _, t := range targets {
+		if t.target == nil {
+			if s.Exhaustive && t.needed {
+				return fmt.Errorf("exhaustive branch has no %s target (set %s = { ignore = true } to do nothing)", t.name, t.name)
+			}
+			continue
+		}
+		if t.target.Ignore && (t.target.Template != "" || len(t.target.Inline) > 0) {
+			return fmt.Errorf("%s: ignore cannot be combined with template or inline", t.name)
+		}
+	}
 	return nil
 }
 
 // ToStep converts an InlineStep to a Step.
 func (is *InlineStep) ToStep() *Step {
 	return &Step{
-		ID:            is.ID,
-		Executor:      is.Executor,
-		Needs:         is.Needs,
-		Timeout:       is.Timeout,
-		Agent:         is.Agent,
-		Prompt:        is.Prompt,
-		Mode:          is.Mode,
-		Command:       is.Command,
-		Workdir:       is.Workdir,
-		Env:           is.Env,
-		OnError:       is.OnError,
-		ShellOutputs:  is.ShellOutputs,
-		Adapter:       is.Adapter,
-		ResumeSession: is.ResumeSession,
-		SpawnArgs:     is.SpawnArgs,
-		Graceful:      is.Graceful,
-		Template:      is.Template,
-		Variables:     is.Variables,
-		Condition:     is.Condition,
-		OnTrue:        is.OnTrue,
-		OnFalse:       is.OnFalse,
-		OnTimeout:     is.OnTimeout,
+		ID:                 is.ID,
+		Executor:           is.Executor,
+		Needs:              is.Needs,
+		Timeout:            is.Timeout,
+		OnlyBetween:        is.OnlyBetween,
+		OutsideWindow:      is.OutsideWindow,
+		Lock:               is.Lock,
+		Retries:            is.Retries,
+		Retry:              is.Retry,
+		Assert:             is.Assert,
+		Requires:           is.Requires,
+		If:                 is.If,
+		Agent:              is.Agent,
+		Prompt:             is.Prompt,
+		Mode:               is.Mode,
+		RequiresCapability: is.RequiresCapability,
+		AckTimeout:         is.AckTimeout,
+		PreDelay:           is.PreDelay,
+		PostDelay:          is.PostDelay,
+		SkipPromptWrap:     is.SkipPromptWrap,
+		StallTimeout:       is.StallTimeout,
+		OnStall:            is.OnStall,
+		CaptureTranscript:  is.CaptureTranscript,
+		Nudge:              is.Nudge,
+		Completion:         is.Completion,
+		ResultFile:         is.ResultFile,
+		Command:            is.Command,
+		Workdir:            is.Workdir,
+		Env:                is.Env,
+		OnError:            is.OnError,
+		RunAs:              is.RunAs,
+		CancelSignal:       is.CancelSignal,
+		CancelPrompt:       is.CancelPrompt,
+		CancelGrace:        is.CancelGrace,
+		ShellOutputs:       is.ShellOutputs,
+		Adapter:            is.Adapter,
+		ResumeSession:      is.ResumeSession,
+		SpawnArgs:          is.SpawnArgs,
+		StartupDelay:       is.StartupDelay,
+		ReuseFrom:          is.ReuseFrom,
+		Capabilities:       is.Capabilities,
+		Graceful:           is.Graceful,
+		GracePeriod:        is.GracePeriod,
+		Template:           is.Template,
+		Variables:          is.Variables,
+		Condition:          is.Condition,
+		OnTrue:             is.OnTrue,
+		OnFalse:            is.OnFalse,
+		OnTimeout:          is.OnTimeout,
+		Exhaustive:         is.Exhaustive,
+		WaitForEvent:       is.WaitForEvent,
 		// Foreach fields
-		Items:         is.Items,
-		ItemVar:       is.ItemVar,
-		IndexVar:      is.IndexVar,
-		Parallel:      is.Parallel,
-		MaxConcurrent: is.MaxConcurrent,
-		Join:          is.Join,
-		Outputs:       is.Outputs,
+		Items:            is.Items,
+		ItemVar:          is.ItemVar,
+		IndexVar:         is.IndexVar,
+		Parallel:         is.Parallel,
+		MaxConcurrent:    is.MaxConcurrent,
+		Join:             is.Join,
+		Window:           is.Window,
+		FailureThreshold: is.FailureThreshold,
+		Collect:          is.Collect,
+		Outputs:          is.Outputs,
 	}
 }
 
@@ -272,6 +698,7 @@ type ExpansionTarget struct {
 	Template  string         `toml:"template,omitempty"`
 	Inline    []InlineStep   `toml:"inline,omitempty"`
 	Variables map[string]any `toml:"variables,omitempty"` // Typed values preserved
+	Ignore    bool           `toml:"ignore,omitempty"`    // Explicitly do nothing for this outcome
 }
 
 // InlineStep represents an inline step definition within an expansion target.
@@ -284,44 +711,91 @@ type InlineStep struct {
 	Needs   []string `toml:"needs,omitempty"`
 	Timeout string   `toml:"timeout,omitempty"`
 
+	OnlyBetween   string       `toml:"only_between,omitempty"`
+	OutsideWindow string       `toml:"outside_window,omitempty"`
+	Lock          string       `toml:"lock,omitempty"`
+	Retries       int          `toml:"retries,omitempty"`
+	Retry         *RetryPolicy `toml:"retry,omitempty"`
+
+	Assert   map[string]OutputAssertion `toml:"assert,omitempty"`
+	Requires map[string]string          `toml:"requires,omitempty"`
+	If       string                     `toml:"if,omitempty"`
+
 	// Agent executor fields
 	Agent  string `toml:"agent,omitempty"`
 	Prompt string `toml:"prompt,omitempty"`
 	Mode   string `toml:"mode,omitempty"`
 
+	RequiresCapability string `toml:"requires_capability,omitempty"`
+
+	AckTimeout string `toml:"ack_timeout,omitempty"`
+
+	// Agent prompt injection timing
+	PreDelay  string `toml:"pre_delay,omitempty"`
+	PostDelay string `toml:"post_delay,omitempty"`
+
+	SkipPromptWrap bool `toml:"skip_prompt_wrap,omitempty"`
+
+	StallTimeout string `toml:"stall_timeout,omitempty"`
+	OnStall      string `toml:"on_stall,omitempty"`
+
+	CaptureTranscript bool `toml:"capture_transcript,omitempty"`
+
+	Nudge *NudgePolicy `toml:"nudge,omitempty"`
+
+	Completion string `toml:"completion,omitempty"`
+	ResultFile string `toml:"result_file,omitempty"`
+
 	// Shell executor fields
 	Command      string                  `toml:"command,omitempty"`
 	Workdir      string                  `toml:"workdir,omitempty"`
 	Env          map[string]string       `toml:"env,omitempty"`
 	OnError      string                  `toml:"on_error,omitempty"`
+	RunAs        string                  `toml:"run_as,omitempty"`
 	ShellOutputs map[string]OutputSource `toml:"shell_outputs,omitempty"`
 
+	// Soft cancel
+	CancelSignal string `toml:"cancel_signal,omitempty"`
+	CancelPrompt string `toml:"cancel_prompt,omitempty"`
+	CancelGrace  string `toml:"cancel_grace,omitempty"`
+
 	// Spawn executor fields
-	Adapter       string `toml:"adapter,omitempty"` // Which adapter to use (defaults to config hierarchy)
-	ResumeSession string `toml:"resume_session,omitempty"`
-	SpawnArgs     string `toml:"spawn_args,omitempty"` // Extra CLI args to append to spawn command
+	Adapter       string   `toml:"adapter,omitempty"` // Which adapter to use (defaults to config hierarchy)
+	ResumeSession string   `toml:"resume_session,omitempty"`
+	SpawnArgs     string   `toml:"spawn_args,omitempty"` // Extra CLI args to append to spawn command
+	StartupDelay  string   `toml:"startup_delay,omitempty"`
+	ReuseFrom     string   `toml:"reuse_from,omitempty"`
+	Capabilities  []string `toml:"capabilities,omitempty"`
 
 	// Kill executor fields
-	Graceful *bool `toml:"graceful,omitempty"`
+	Graceful    *bool  `toml:"graceful,omitempty"`
+	GracePeriod string `toml:"grace_period,omitempty"`
 
 	// Expand executor fields
 	Template  string         `toml:"template,omitempty"`
 	Variables map[string]any `toml:"variables,omitempty"` // Typed values preserved
 
 	// Branch executor fields
-	Condition string           `toml:"condition,omitempty"`
-	OnTrue    *ExpansionTarget `toml:"on_true,omitempty"`
-	OnFalse   *ExpansionTarget `toml:"on_false,omitempty"`
-	OnTimeout *ExpansionTarget `toml:"on_timeout,omitempty"`
+	Condition  string           `toml:"condition,omitempty"`
+	OnTrue     *ExpansionTarget `toml:"on_true,omitempty"`
+	OnFalse    *ExpansionTarget `toml:"on_false,omitempty"`
+	OnTimeout  *ExpansionTarget `toml:"on_timeout,omitempty"`
+	Exhaustive bool             `toml:"exhaustive,omitempty"`
+
+	// Gate executor fields
+	WaitForEvent string `toml:"wait_for_event,omitempty"`
 
 	// Foreach executor fields
-	Items         string `toml:"items,omitempty"`
-	ItemsFile     string `toml:"items_file,omitempty"`
-	ItemVar       string `toml:"item_var,omitempty"`
-	IndexVar      string `toml:"index_var,omitempty"`
-	Parallel      any    `toml:"parallel,omitempty"`
-	MaxConcurrent any    `toml:"max_concurrent,omitempty"`
-	Join          *bool  `toml:"join,omitempty"`
+	Items            string `toml:"items,omitempty"`
+	ItemsFile        string `toml:"items_file,omitempty"`
+	ItemVar          string `toml:"item_var,omitempty"`
+	IndexVar         string `toml:"index_var,omitempty"`
+	Parallel         any    `toml:"parallel,omitempty"`
+	MaxConcurrent    any    `toml:"max_concurrent,omitempty"`
+	Join             *bool  `toml:"join,omitempty"`
+	Window           any    `toml:"window,omitempty"`
+	FailureThreshold any    `toml:"failure_threshold,omitempty"`
+	Collect          string `toml:"collect,omitempty"`
 	// Template and Variables fields already defined above for expand executor
 
 	// Agent outputs
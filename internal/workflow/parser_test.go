@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -65,6 +66,30 @@ instructions = "Do something else"
 	}
 }
 
+func TestParseString_AssertShorthand(t *testing.T) {
+	tmpl, err := ParseString(`
+[meta]
+name = "test-template"
+
+[[steps]]
+id = "deploy"
+executor = "shell"
+command = "deploy"
+assert = { status = "ok", version = { matches = "^v\\d" } }
+`)
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	want := map[string]OutputAssertion{
+		"status":  {Equals: "ok"},
+		"version": {Matches: `^v\d`},
+	}
+	if got := tmpl.Steps[0].Assert; !reflect.DeepEqual(got, want) {
+		t.Errorf("Assert = %+v, want %+v", got, want)
+	}
+}
+
 func TestParseString_MissingName(t *testing.T) {
 	toml := `
 [meta]
@@ -912,6 +937,101 @@ func TestStep_Validate_Kill(t *testing.T) {
 	}
 }
 
+func TestStep_Validate_Gate(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name: "valid gate step",
+			step: Step{
+				ID:           "approve",
+				Executor:     ExecutorGate,
+				WaitForEvent: "approve-deploy",
+			},
+			wantErr: "",
+		},
+		{
+			name: "gate without event",
+			step: Step{
+				ID:       "approve",
+				Executor: ExecutorGate,
+			},
+			wantErr: "gate executor requires wait_for_event",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStep_Validate_RequiresCapability(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name: "capability instead of agent",
+			step: Step{
+				ID:                 "train",
+				Executor:           ExecutorAgent,
+				RequiresCapability: "gpu",
+				Prompt:             "Train the model",
+			},
+			wantErr: "",
+		},
+		{
+			name: "neither agent nor capability",
+			step: Step{
+				ID:       "train",
+				Executor: ExecutorAgent,
+				Prompt:   "Train the model",
+			},
+			wantErr: "agent executor requires agent or requires_capability",
+		},
+		{
+			name: "both agent and capability",
+			step: Step{
+				ID:                 "train",
+				Executor:           ExecutorAgent,
+				Agent:              "worker",
+				RequiresCapability: "gpu",
+				Prompt:             "Train the model",
+			},
+			wantErr: "agent and requires_capability are mutually exclusive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+				}
+			}
+		})
+	}
+}
+
 func TestStep_Validate_Expand(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -1132,6 +1252,16 @@ func TestStep_Validate_OnError(t *testing.T) {
 			},
 			wantErr: "",
 		},
+		{
+			name: "recovery template on_error",
+			step: Step{
+				ID:       "test",
+				Executor: ExecutorShell,
+				Command:  "npm test",
+				OnError:  ".recover",
+			},
+			wantErr: "",
+		},
 		{
 			name: "invalid on_error",
 			step: Step{
@@ -1160,6 +1290,443 @@ func TestStep_Validate_OnError(t *testing.T) {
 	}
 }
 
+func TestStep_Validate_TimeWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name:    "valid window",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make deploy", OnlyBetween: "09:00-17:00"},
+			wantErr: "",
+		},
+		{
+			name:    "window from variable",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make deploy", OnlyBetween: "{{window}}", OutsideWindow: "skip"},
+			wantErr: "",
+		},
+		{
+			name:    "malformed window",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make deploy", OnlyBetween: "9am-5pm"},
+			wantErr: "invalid only_between",
+		},
+		{
+			name:    "invalid outside_window",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make deploy", OnlyBetween: "09:00-17:00", OutsideWindow: "later"},
+			wantErr: "invalid outside_window",
+		},
+		{
+			name:    "outside_window without window",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make deploy", OutsideWindow: "skip"},
+			wantErr: "outside_window requires only_between",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStep_Validate_Lock(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name:    "valid lock",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make migrate", Lock: "db-primary_1.x"},
+			wantErr: "",
+		},
+		{
+			name:    "lock from variable",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make migrate", Lock: "{{env}}-db"},
+			wantErr: "",
+		},
+		{
+			name:    "path separator",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make migrate", Lock: "../db"},
+			wantErr: "invalid lock",
+		},
+		{
+			name:    "leading dot",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make migrate", Lock: ".db"},
+			wantErr: "invalid lock",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+				}
+			}
+		})
+	}
+}
+func TestStep_Validate_StallTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name:    "valid stall timeout",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", StallTimeout: "10m", OnStall: "nudge"},
+			wantErr: "",
+		},
+		{
+			name:    "stall timeout from variable",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", StallTimeout: "{{stall}}"},
+			wantErr: "",
+		},
+		{
+			name:    "malformed stall timeout",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", StallTimeout: "ten minutes"},
+			wantErr: "invalid stall_timeout",
+		},
+		{
+			name:    "invalid on_stall",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", StallTimeout: "10m", OnStall: "kill"},
+			wantErr: "invalid on_stall",
+		},
+		{
+			name:    "on_stall without stall timeout",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", OnStall: "warn"},
+			wantErr: "on_stall requires stall_timeout",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+func TestStep_Validate_GracePeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name:    "valid grace period",
+			step:    Step{ID: "test", Executor: ExecutorKill, Agent: "worker", GracePeriod: "30s"},
+			wantErr: "",
+		},
+		{
+			name:    "grace period from variable",
+			step:    Step{ID: "test", Executor: ExecutorKill, Agent: "worker", GracePeriod: "{{grace}}"},
+			wantErr: "",
+		},
+		{
+			name:    "malformed grace period",
+			step:    Step{ID: "test", Executor: ExecutorKill, Agent: "worker", GracePeriod: "half a minute"},
+			wantErr: "invalid grace_period",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+func TestStep_Validate_Nudge(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name:    "valid nudge",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Nudge: &NudgePolicy{After: "30s silence", Prompt: "Still there?", MaxNudges: 3}},
+			wantErr: "",
+		},
+		{
+			name:    "after from variable",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Nudge: &NudgePolicy{After: "{{quiet}}"}},
+			wantErr: "",
+		},
+		{
+			name:    "missing after",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Nudge: &NudgePolicy{MaxNudges: 3}},
+			wantErr: "nudge requires after",
+		},
+		{
+			name:    "malformed after",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Nudge: &NudgePolicy{After: "a while"}},
+			wantErr: "invalid nudge after",
+		},
+		{
+			name:    "negative max_nudges",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Nudge: &NudgePolicy{After: "30s", MaxNudges: -1}},
+			wantErr: "max_nudges must not be negative",
+		},
+		{
+			name:    "non-agent step",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make", Nudge: &NudgePolicy{After: "30s"}},
+			wantErr: "only supported on agent steps",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStep_Validate_Completion(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name:    "exit with result file",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Completion: "exit", ResultFile: "result.json"},
+			wantErr: "",
+		},
+		{
+			name:    "explicit done",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Completion: "done"},
+			wantErr: "",
+		},
+		{
+			name:    "unknown mode",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", Completion: "signal"},
+			wantErr: "invalid completion",
+		},
+		{
+			name:    "result file without exit",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", ResultFile: "result.json"},
+			wantErr: "result_file requires completion",
+		},
+		{
+			name:    "non-agent step",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "make", Completion: "exit"},
+			wantErr: "only supported on agent steps",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStep_Validate_Assert(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name:    "equals and matches",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "deploy", Assert: map[string]OutputAssertion{"status": {Equals: "ok"}, "version": {Matches: `^v\d`}}},
+			wantErr: "",
+		},
+		{
+			name:    "neither equals nor matches",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "deploy", Assert: map[string]OutputAssertion{"status": {}}},
+			wantErr: "exactly one of equals or matches",
+		},
+		{
+			name:    "invalid regex",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "deploy", Assert: map[string]OutputAssertion{"status": {Matches: "("}}},
+			wantErr: "invalid matches",
+		},
+		{
+			name:    "executor without outputs",
+			step:    Step{ID: "test", Executor: ExecutorSpawn, Agent: "worker", Assert: map[string]OutputAssertion{"status": {Equals: "ok"}}},
+			wantErr: "only supported on shell, branch, and agent",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStep_Validate_RunAs(t *testing.T) {
+	shell := Step{ID: "test", Executor: ExecutorShell, Command: "id -u", RunAs: "nobody"}
+	if err := shell.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	branch := Step{ID: "test", Executor: ExecutorBranch, Condition: "true", RunAs: "nobody"}
+	if err := branch.Validate(); err == nil || !strings.Contains(err.Error(), "only supported on shell steps") {
+		t.Errorf("expected run_as error for branch step, got: %v", err)
+	}
+}
+
+func TestStep_Validate_SoftCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name: "shell cancel_signal",
+			step: Step{ID: "test", Executor: ExecutorShell, Command: "train", CancelSignal: "SIGUSR1", CancelGrace: "30s"},
+		},
+		{
+			name: "agent cancel_prompt",
+			step: Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", CancelPrompt: "Wrap up"},
+		},
+		{
+			name:    "unknown signal",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "train", CancelSignal: "SIGKILL"},
+			wantErr: "invalid cancel_signal",
+		},
+		{
+			name:    "cancel_signal on agent",
+			step:    Step{ID: "test", Executor: ExecutorAgent, Agent: "worker", Prompt: "Work", CancelSignal: "SIGUSR1"},
+			wantErr: "only supported on shell and branch steps",
+		},
+		{
+			name:    "cancel_prompt on shell",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "train", CancelPrompt: "Wrap up"},
+			wantErr: "only supported on agent steps",
+		},
+		{
+			name:    "cancel_grace alone",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "train", CancelGrace: "30s"},
+			wantErr: "requires cancel_signal or cancel_prompt",
+		},
+		{
+			name:    "invalid cancel_grace",
+			step:    Step{ID: "test", Executor: ExecutorShell, Command: "train", CancelSignal: "USR1", CancelGrace: "soon"},
+			wantErr: "invalid cancel_grace",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStep_Validate_OutputPattern(t *testing.T) {
+	step := Step{
+		ID:       "test",
+		Executor: ExecutorShell,
+		Command:  "make build",
+		ShellOutputs: map[string]OutputSource{
+			"version": {Source: "stdout", Pattern: `version (\S+)`, Required: true},
+		},
+	}
+	if err := step.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	step.ShellOutputs["broken"] = OutputSource{Source: "stdout", Pattern: "version ("}
+	if err := step.Validate(); err == nil || !strings.Contains(err.Error(), `invalid pattern for output "broken"`) {
+		t.Errorf("expected invalid pattern error, got: %v", err)
+	}
+}
+
+func TestStep_Validate_JSONFileOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  OutputSource
+		wantErr string
+	}{
+		{"file with path", OutputSource{Source: "file", Path: "report.json", Field: "summary.failed"}, ""},
+		{"json stdout field", OutputSource{Source: "stdout", Type: "json", Field: "id"}, ""},
+		{"file without path", OutputSource{Source: "file"}, `source "file" and path must be set together`},
+		{"path without file", OutputSource{Source: "stdout", Path: "report.json"}, `source "file" and path must be set together`},
+		{"field on string", OutputSource{Source: "stdout", Field: "id"}, `field requires source "file" or type json`},
+		{"last line of stdout", OutputSource{Source: "stdout", Line: -1}, ""},
+		{"line of exit code", OutputSource{Source: "exit_code", Line: 1}, "line does not apply to source exit_code"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := Step{
+				ID:           "test",
+				Executor:     ExecutorShell,
+				Command:      "make report",
+				ShellOutputs: map[string]OutputSource{"report": tt.output},
+			}
+			err := step.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestStep_Validate_EmptyExecutor(t *testing.T) {
 	// Empty executor is allowed for migration period
 	step := Step{
@@ -1316,6 +1883,7 @@ template = ".worker"
 }
 
 func TestStep_Validate_Foreach(t *testing.T) {
+	noJoin := false
 	tests := []struct {
 		name    string
 		step    Step
@@ -1362,6 +1930,92 @@ func TestStep_Validate_Foreach(t *testing.T) {
 			},
 			wantErr: "foreach executor requires template",
 		},
+		{
+			name: "foreach with percent failure_threshold",
+			step: Step{
+				ID:               "tolerant",
+				Executor:         ExecutorForeach,
+				Items:            `["a", "b"]`,
+				ItemVar:          "item",
+				Template:         ".worker",
+				FailureThreshold: "30%",
+			},
+			wantErr: "",
+		},
+		{
+			name: "foreach with malformed failure_threshold",
+			step: Step{
+				ID:               "tolerant",
+				Executor:         ExecutorForeach,
+				Items:            `["a", "b"]`,
+				ItemVar:          "item",
+				Template:         ".worker",
+				FailureThreshold: "a few",
+			},
+			wantErr: "invalid failure_threshold",
+		},
+		{
+			name: "foreach with window",
+			step: Step{
+				ID:       "huge",
+				Executor: ExecutorForeach,
+				Items:    `["a", "b"]`,
+				ItemVar:  "item",
+				Template: ".worker",
+				Window:   int64(10),
+			},
+			wantErr: "",
+		},
+		{
+			name: "foreach window without join",
+			step: Step{
+				ID:       "huge",
+				Executor: ExecutorForeach,
+				Items:    `["a", "b"]`,
+				ItemVar:  "item",
+				Template: ".worker",
+				Window:   int64(10),
+				Join:     &noJoin,
+			},
+			wantErr: "foreach window requires join",
+		},
+		{
+			name: "foreach with collect",
+			step: Step{
+				ID:       "fan",
+				Executor: ExecutorForeach,
+				Items:    `["a", "b"]`,
+				ItemVar:  "item",
+				Template: ".worker",
+				Collect:  "work.summary",
+			},
+			wantErr: "",
+		},
+		{
+			name: "foreach collect without a field",
+			step: Step{
+				ID:       "fan",
+				Executor: ExecutorForeach,
+				Items:    `["a", "b"]`,
+				ItemVar:  "item",
+				Template: ".worker",
+				Collect:  "summary",
+			},
+			wantErr: "collect must name a step and an output field",
+		},
+		{
+			name: "foreach collect without join",
+			step: Step{
+				ID:       "fan",
+				Executor: ExecutorForeach,
+				Items:    `["a", "b"]`,
+				ItemVar:  "item",
+				Template: ".worker",
+				Collect:  "work.summary",
+				Join:     &noJoin,
+			},
+			wantErr: "foreach collect requires join",
+		},
 	}
 
 	for _, tc := range tests {
@@ -321,6 +321,11 @@ func checkVarRefs(text, name, stepID, field string, defined map[string]bool, t *
 			continue
 		}
 
+		// Environment references are resolved at dispatch
+		if root == EnvRefRoot && len(parts) == 2 && !defined[root] {
+			continue
+		}
+
 		if !defined[root] {
 			suggest := findSimilarVar(root, defined)
 			result.Add(name, stepID, field,
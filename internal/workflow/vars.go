@@ -159,6 +159,10 @@ func (c *VarContext) SetCurrentStep(stepID string) {
 	c.ScopeWalkEnabled = true
 }
 
+// EnvRefRoot is the root of environment references like {{env.DEPLOY_TOKEN}},
+// unless the workflow declares a variable of that name.
+const EnvRefRoot = "env"
+
 // errDeferred is a sentinel error indicating the variable should be left for runtime
 var errDeferred = fmt.Errorf("deferred for runtime")
 
@@ -198,7 +202,7 @@ func (c *VarContext) Substitute(input string) (string, error) {
 	}
 
 	// Check for remaining unresolved variables (only if not in defer mode)
-	if !c.DeferStepOutputs && varPattern.MatchString(result) && lastErr == nil {
+	if !c.DeferStepOutputs && !c.DeferUndefinedVariables && varPattern.MatchString(result) && lastErr == nil {
 		matches := varPattern.FindAllString(result, -1)
 		lastErr = fmt.Errorf("unresolved variables after max depth: %v", matches)
 	}
@@ -247,6 +251,12 @@ func (c *VarContext) resolve(path string) (any, error) {
 		return time.Now().Format("15:04:05"), nil
 	}
 
+	// Environment references ({{env.NAME}}) are read from the orchestrator's
+	// environment at dispatch, so their values are never baked into the run
+	if root == EnvRefRoot && len(parts) == 2 && c.DeferStepOutputs {
+		return nil, errDeferred
+	}
+
 	// If deferring undefined variables, return the sentinel error
 	if c.DeferUndefinedVariables {
 		return nil, errDeferred